@@ -1319,6 +1319,69 @@ func (bc *Blockchain) GetSyndicateManager() *SyndicateManager {
     return bc.syndicateManager
 }
 
+// SyndicateRewards sums, per syndicate, the coinbase paid in each of the
+// last window blocks on the chain to a farmer whose membership NFT was
+// active at the block's time. Memberships are those the farmers hold now.
+func (bc *Blockchain) SyndicateRewards(window uint64) map[SyndicateType]uint64 {
+    bc.mu.RLock()
+    var blocks []*Block
+    for height := bc.tipHeight; height > 0 && uint64(len(blocks)) < window; height-- {
+        if block, exists := bc.blocksByHeight[height]; exists {
+            blocks = append(blocks, block)
+        }
+    }
+    bc.mu.RUnlock()
+
+    rewards := make(map[SyndicateType]uint64)
+    if bc.tokenState == nil {
+        return rewards
+    }
+    memberships := make(map[string][]*SyndicateData)
+    for _, block := range blocks {
+        farmer := block.Header.FarmerAddress
+        held, seen := memberships[farmer]
+        if !seen {
+            balances, err := bc.tokenState.GetAllTokenBalances(farmer)
+            if err != nil {
+                log.Printf("⚠️ [BLOCKCHAIN] Failed to get farmer token balances: %v", err)
+            }
+            for _, balance := range balances {
+                if balance.Balance > 0 && balance.TokenInfo != nil && balance.TokenInfo.Syndicate != nil {
+                    held = append(held, balance.TokenInfo.Syndicate)
+                }
+            }
+            memberships[farmer] = held
+        }
+
+        for _, membership := range held {
+            if membership.ExpirationTime > block.Header.Timestamp.Unix() && membership.JoinTime <= block.Header.Timestamp.Unix() {
+                rewards[membership.Syndicate] += coinbasePaid(block)
+                break
+            }
+        }
+    }
+    return rewards
+}
+
+// coinbasePaid is what a block's coinbase transaction paid out
+func coinbasePaid(block *Block) uint64 {
+    for _, signed := range block.Body.Transactions {
+        if signed.Algorithm != "coinbase" {
+            continue
+        }
+        var tx Transaction
+        if err := json.Unmarshal(signed.Transaction, &tx); err != nil {
+            return 0
+        }
+        var paid uint64
+        for _, output := range tx.Outputs {
+            paid += output.Value
+        }
+        return paid
+    }
+    return 0
+}
+
 // determineSyndicateWinner determines which syndicate a farmer belongs to based on their active NFTs
 func (bc *Blockchain) determineSyndicateWinner(farmerAddress string) SyndicateType {
     // Default to -1 (solo miner) if no syndicate membership found
@@ -132,6 +132,7 @@ func (sn *ShadowNode) initializeHTTPServer() error {
 	// Syndicate endpoints
 	webwallet.HandleFunc("/syndicate-membership", sn.handleWebWalletSyndicateMembership).Methods("GET")
 	webwallet.HandleFunc("/syndicate-stats", sn.handleWebWalletSyndicateStats).Methods("GET")
+	webwallet.HandleFunc("/syndicates/stats", sn.handleWebWalletSyndicatesStats).Methods("GET")
 	webwallet.HandleFunc("/join-syndicate", sn.handleWebWalletJoinSyndicate).Methods("POST")
 	
 	// Marketplace endpoints
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 )
@@ -265,7 +266,7 @@ func (sm *SyndicateManager) CheckDominanceThreshold(syndicate SyndicateType) boo
 		return false
 	}
 	
-	return stats.WinPercentage > 35.0
+	return stats.WinPercentage > SyndicateDominanceThreshold
 }
 
 // GetMemberBySyndicate returns all members of a specific syndicate
@@ -276,4 +277,88 @@ func (sm *SyndicateManager) GetMembersBySyndicate(syndicate SyndicateType) ([]Sy
 	}
 	
 	return stats.Members, nil
-}
\ No newline at end of file
+}
+
+// SyndicateRewardWindow is how many of the latest blocks the wallet counts
+// rewards over, the same fortnight as the win percentages
+const SyndicateRewardWindow = 2016
+
+// SyndicateExpiringSoonWindow is how close to expiry a membership must be
+// before the wallet flags it as needing renewal
+const SyndicateExpiringSoonWindow = 48 * time.Hour
+
+// SyndicateDominanceThreshold is the fortnight win percentage above which a
+// syndicate is considered dominant
+const SyndicateDominanceThreshold = 35.0
+
+// SyndicateMembershipView describes one address's standing in a syndicate
+type SyndicateMembershipView struct {
+	Member         bool   `json:"member"`
+	NFTTokenID     string `json:"nft_token_id,omitempty"`
+	ExpirationTime int64  `json:"expiration_time,omitempty"`
+	ExpiresIn      int64  `json:"expires_in,omitempty"` // Seconds until expiry
+	ExpiringSoon   bool   `json:"expiring_soon"`
+	Expired        bool   `json:"expired"`
+}
+
+// SyndicateStatsView is the per-syndicate summary served to the web wallet
+type SyndicateStatsView struct {
+	Syndicate          string                  `json:"syndicate"`
+	Name               string                  `json:"name"`
+	MemberCount        int                     `json:"member_count"`
+	TotalCapacity      uint64                  `json:"total_capacity"`
+	TotalStaked        uint64                  `json:"total_staked"`        // SHADOW locked in member NFTs
+	RewardsDistributed uint64                  `json:"rewards_distributed"` // Coinbase paid to members in the last SyndicateRewardWindow blocks
+	BlocksWon          uint64                  `json:"blocks_won"`
+	WinPercentage      float64                 `json:"win_percentage"`
+	Dominant           bool                    `json:"dominant"`
+	Warning            bool                    `json:"warning"` // Dominant or membership expiring soon
+	Membership         SyndicateMembershipView `json:"membership"`
+}
+
+// BuildStatsView summarizes every syndicate from the point of view of address.
+// lockedShadow returns the SHADOW locked in a membership NFT and may be nil;
+// rewards are what each syndicate's members were paid, from
+// Blockchain.SyndicateRewards.
+func (sm *SyndicateManager) BuildStatsView(address string, lockedShadow func(nftTokenID string) uint64, rewards map[SyndicateType]uint64, now time.Time) map[string]*SyndicateStatsView {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	
+	result := make(map[string]*SyndicateStatsView)
+	for syndicate := SyndicateSeiryu; syndicate <= SyndicateGenbu; syndicate++ {
+		stats := sm.syndicates[syndicate]
+		view := &SyndicateStatsView{
+			Syndicate:          strings.ToLower(syndicate.String()),
+			Name:               syndicate.Description(),
+			MemberCount:        len(stats.Members),
+			TotalCapacity:      stats.TotalCapacity,
+			RewardsDistributed: rewards[syndicate],
+			BlocksWon:          stats.BlocksWon,
+			WinPercentage:      stats.WinPercentage,
+			Dominant:           stats.WinPercentage > SyndicateDominanceThreshold,
+		}
+		
+		for _, member := range stats.Members {
+			if lockedShadow != nil {
+				view.TotalStaked += lockedShadow(member.NFTTokenID)
+			}
+			
+			if address != "" && member.Address == address {
+				remaining := member.ExpirationTime.Sub(now)
+				view.Membership = SyndicateMembershipView{
+					Member:         true,
+					NFTTokenID:     member.NFTTokenID,
+					ExpirationTime: member.ExpirationTime.Unix(),
+					ExpiresIn:      int64(remaining / time.Second),
+					ExpiringSoon:   remaining > 0 && remaining <= SyndicateExpiringSoonWindow,
+					Expired:        remaining <= 0,
+				}
+			}
+		}
+		
+		view.Warning = view.Dominant || view.Membership.ExpiringSoon || view.Membership.Expired
+		result[view.Syndicate] = view
+	}
+	
+	return result
+}
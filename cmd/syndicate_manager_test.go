@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestSyndicateManager(t *testing.T, now time.Time) *SyndicateManager {
+	sm := NewSyndicateManager()
+
+	members := []struct {
+		nft       string
+		address   string
+		syndicate SyndicateType
+		expires   time.Time
+	}{
+		{"nft_member", "S_member", SyndicateSeiryu, now.Add(24 * time.Hour)},
+		{"nft_other", "S_other", SyndicateSeiryu, now.Add(30 * 24 * time.Hour)},
+		{"nft_genbu", "S_genbu", SyndicateGenbu, now.Add(30 * 24 * time.Hour)},
+	}
+	for _, m := range members {
+		err := sm.AddMember(m.nft, &SyndicateData{
+			Syndicate:        m.syndicate,
+			MinerAddress:     m.address,
+			ReportedCapacity: 1 << 40,
+			JoinTime:         now.Add(-time.Hour).Unix(),
+			ExpirationTime:   m.expires.Unix(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to add member %s: %v", m.nft, err)
+		}
+	}
+
+	// Seiryu wins two of three blocks, putting it over the dominance threshold
+	sm.UpdateBlockWin(1, SyndicateSeiryu, "S_member")
+	sm.UpdateBlockWin(2, SyndicateSeiryu, "S_other")
+	sm.UpdateBlockWin(3, SyndicateGenbu, "S_genbu")
+
+	return sm
+}
+
+func testLockedShadow(nftTokenID string) uint64 {
+	locked := map[string]uint64{
+		"nft_member": 100,
+		"nft_other":  250,
+		"nft_genbu":  40,
+	}
+	return locked[nftTokenID]
+}
+
+var testSyndicateRewards = map[SyndicateType]uint64{
+	SyndicateSeiryu: 5000,
+	SyndicateGenbu:  1200,
+}
+
+func TestSyndicateStatsViewMember(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	sm := newTestSyndicateManager(t, now)
+
+	view := sm.BuildStatsView("S_member", testLockedShadow, testSyndicateRewards, now)
+	if len(view) != 4 {
+		t.Fatalf("Expected 4 syndicates, got %d", len(view))
+	}
+
+	seiryu := view["seiryu"]
+	if seiryu == nil {
+		t.Fatal("Missing seiryu in stats view")
+	}
+	if seiryu.MemberCount != 2 {
+		t.Errorf("Expected 2 seiryu members, got %d", seiryu.MemberCount)
+	}
+	if seiryu.TotalStaked != 350 {
+		t.Errorf("Expected 350 staked in seiryu, got %d", seiryu.TotalStaked)
+	}
+	if seiryu.RewardsDistributed != 5000 {
+		t.Errorf("Expected 5000 rewards distributed, got %d", seiryu.RewardsDistributed)
+	}
+	if !seiryu.Dominant {
+		t.Error("Seiryu should be flagged dominant")
+	}
+
+	membership := seiryu.Membership
+	if !membership.Member {
+		t.Fatal("Session address should be a seiryu member")
+	}
+	if membership.NFTTokenID != "nft_member" {
+		t.Errorf("Expected nft_member, got %s", membership.NFTTokenID)
+	}
+	if membership.ExpiresIn != int64(24*time.Hour/time.Second) {
+		t.Errorf("Expected expiry in 24h, got %ds", membership.ExpiresIn)
+	}
+	if !membership.ExpiringSoon {
+		t.Error("Membership expiring in 24h should be flagged expiring soon")
+	}
+	if !seiryu.Warning {
+		t.Error("Expiring membership should set the warning flag")
+	}
+
+	genbu := view["genbu"]
+	if genbu.Membership.Member {
+		t.Error("Session address should not be a genbu member")
+	}
+	if genbu.TotalStaked != 40 || genbu.RewardsDistributed != 1200 {
+		t.Errorf("Unexpected genbu totals: staked %d, rewards %d", genbu.TotalStaked, genbu.RewardsDistributed)
+	}
+}
+
+func TestSyndicateStatsViewNonMember(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	sm := newTestSyndicateManager(t, now)
+
+	view := sm.BuildStatsView("S_outsider", testLockedShadow, testSyndicateRewards, now)
+
+	for name, syndicate := range view {
+		if syndicate.Membership.Member {
+			t.Errorf("Outsider should not be a member of %s", name)
+		}
+		if syndicate.Membership.ExpiringSoon || syndicate.Membership.Expired {
+			t.Errorf("Outsider should have no expiry flags on %s", name)
+		}
+	}
+
+	// Totals are the same regardless of who is asking
+	if view["seiryu"].MemberCount != 2 || view["seiryu"].TotalStaked != 350 {
+		t.Errorf("Unexpected seiryu totals for non-member view: %+v", view["seiryu"])
+	}
+
+	// Only dominance can raise a warning for a non-member
+	if !view["seiryu"].Warning {
+		t.Error("Dominant syndicate should carry a warning")
+	}
+	if view["byakko"].Warning {
+		t.Error("Empty syndicate should not carry a warning")
+	}
+}
@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -18,6 +18,7 @@ type TrackerClient struct {
 	nodeID        string
 	miningAddr    string
 	publicKey     string
+	keys          *KeyPair // Signs registrations and heartbeats
 	httpClient    *http.Client
 	lastHeartbeat time.Time
 }
@@ -73,13 +74,15 @@ type TrackerPeersResponse struct {
 	Count int           `json:"count"`
 }
 
-// NewTrackerClient creates a new tracker client
-func NewTrackerClient(trackerURL, nodeID, miningAddr, publicKey string) *TrackerClient {
+// NewTrackerClient creates a new tracker client for the node whose mining
+// key is keys. The tracker only accepts requests signed with that key.
+func NewTrackerClient(trackerURL, nodeID string, keys *KeyPair) *TrackerClient {
 	return &TrackerClient{
 		trackerURL: trackerURL,
 		nodeID:     nodeID,
-		miningAddr: miningAddr,
-		publicKey:  publicKey,
+		miningAddr: DeriveAddress(keys.PublicKey[:]),
+		publicKey:  keys.PublicKeyHex(),
+		keys:       keys,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -157,12 +160,15 @@ func (tc *TrackerClient) RegisterWithTracker(ce *ConsensusEngine, blockchain *Bl
 		Architecture:    getArchitecture(),
 		TotalPlotSize:   totalPlotSize,
 		PlotCount:       plotCount,
-		Timestamp:       time.Now().Format(time.RFC3339),
+		Timestamp:       requestTimestamp(),
 		Signature:       "",
 	}
 
-	// Generate signature
-	req.Signature = tc.generateSimpleSignature(req)
+	signature, err := tc.sign(trackerRegistrationMessage(&req))
+	if err != nil {
+		return fmt.Errorf("failed to sign registration request: %w", err)
+	}
+	req.Signature = signature
 
 	// Send registration request
 	jsonData, err := json.Marshal(req)
@@ -237,12 +243,15 @@ func (tc *TrackerClient) SendHeartbeat(blockchain *Blockchain, farmingService *F
 		Status:        status,
 		TotalPlotSize: totalPlotSize,
 		PlotCount:     plotCount,
-		Timestamp:     time.Now().Format(time.RFC3339),
+		Timestamp:     requestTimestamp(),
 		Signature:     "",
 	}
 
-	// Generate signature
-	req.Signature = tc.generateSimpleHeartbeatSignature(req)
+	signature, err := tc.sign(trackerHeartbeatMessage(&req))
+	if err != nil {
+		return fmt.Errorf("failed to sign heartbeat: %w", err)
+	}
+	req.Signature = signature
 
 	jsonData, err := json.Marshal(req)
 	if err != nil {
@@ -296,25 +305,41 @@ func (tc *TrackerClient) DiscoverPeers(chainID string) ([]TrackerPeer, error) {
 	return peersResp.Peers, nil
 }
 
-// generateSimpleSignature creates a simple signature for development
-func (tc *TrackerClient) generateSimpleSignature(req TrackerRegistrationRequest) string {
-	// For development, create a simple signature
-	message := fmt.Sprintf("%s|%s|%s|%d|%s|%s",
-		req.NodeID, req.MiningAddr, req.ExternalIP,
-		req.ChainHeight, req.Timestamp, req.SoftwareVersion)
+// requestTimestamp is the time a request is signed at. The tracker rejects
+// a request that is not newer than the node's last one, so it carries
+// sub-second precision for heartbeats sent within the same second.
+func requestTimestamp() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
 
-	// Simple hash-based signature for development
-	hash := sha256.Sum256([]byte(message))
-	return fmt.Sprintf("%x", hash[:16])
+// trackerRegistrationMessage is what the tracker verifies a registration's
+// signature against; it must match the tracker's registrationMessage
+func trackerRegistrationMessage(req *TrackerRegistrationRequest) []byte {
+	return []byte(fmt.Sprintf("register|%s|%s|%s|%s|%d|%d|%d|%s|%s|%s|%s|%s|%s|%d|%d|%s",
+		req.NodeID, req.MiningAddr, req.PublicKey, req.ExternalIP, req.P2PPort, req.HTTPPort,
+		req.ChainHeight, req.ChainHash, req.ChainID, req.LastBlockTime,
+		req.SoftwareVersion, req.OSVersion, req.Architecture,
+		req.TotalPlotSize, req.PlotCount, req.Timestamp))
 }
 
-// generateSimpleHeartbeatSignature creates a simple heartbeat signature
-func (tc *TrackerClient) generateSimpleHeartbeatSignature(req TrackerHeartbeatRequest) string {
-	message := fmt.Sprintf("%s|%d|%s|%s",
-		req.NodeID, req.ChainHeight, req.ChainHash, req.Timestamp)
+// trackerHeartbeatMessage is what the tracker verifies a heartbeat's
+// signature against; it must match the tracker's heartbeatMessage
+func trackerHeartbeatMessage(req *TrackerHeartbeatRequest) []byte {
+	return []byte(fmt.Sprintf("heartbeat|%s|%d|%s|%s|%s|%d|%d|%s",
+		req.NodeID, req.ChainHeight, req.ChainHash, req.LastBlockTime, req.Status,
+		req.TotalPlotSize, req.PlotCount, req.Timestamp))
+}
 
-	hash := sha256.Sum256([]byte(message))
-	return fmt.Sprintf("%x", hash[:8])
+// sign signs message with the node's ML-DSA-87 mining key, hex encoded
+func (tc *TrackerClient) sign(message []byte) (string, error) {
+	if tc.keys == nil {
+		return "", fmt.Errorf("no signing key configured")
+	}
+	signature, err := tc.keys.Sign(message)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(signature), nil
 }
 
 // Helper functions for system info
@@ -3608,23 +3608,32 @@ func (sn *ShadowNode) serveWalletDashboard(w http.ResponseWriter, r *http.Reques
 
         async function loadSyndicateStats() {
             try {
-                const response = await fetch('/wallet/syndicate-stats');
+                const response = await fetch('/wallet/syndicates/stats');
                 const data = await response.json();
                 const statsDiv = document.getElementById('syndicate-stats');
                 
                 let html = '<div class="syndicate-stats-grid">';
-                for (const [syndicate, stats] of Object.entries(data)) {
+                for (const [syndicate, stats] of Object.entries(data.syndicates || {})) {
                     const winPercentage = stats.win_percentage ? stats.win_percentage.toFixed(1) : "0.0";
+                    const membership = stats.membership || {};
+                    let membershipText = 'Not a member';
+                    if (membership.member) {
+                        const daysLeft = Math.ceil((membership.expires_in || 0) / 86400);
+                        membershipText = membership.expired ? 'Expired' : 'Member (' + daysLeft + ' days left)';
+                    }
                     
                     html += '<div class="syndicate-stat-card">' +
                         '<div class="stat-header">' +
                             '<h4>' + getSyndicateIcon(syndicate) + ' ' + getSyndicateName(syndicate) + '</h4>' +
                         '</div>' +
                         '<div class="stat-body">' +
-                            '<div class="stat-row"><span>Members:</span> <span>' + (stats.members || 0) + '</span></div>' +
+                            '<div class="stat-row"><span>Members:</span> <span>' + (stats.member_count || 0) + '</span></div>' +
+                            '<div class="stat-row"><span>Total Staked:</span> <span>' + ((stats.total_staked || 0) / 100000000).toFixed(8) + ' SHADOW</span></div>' +
+                            '<div class="stat-row"><span>Rewards Distributed:</span> <span>' + ((stats.rewards_distributed || 0) / 100000000).toFixed(8) + ' SHADOW</span></div>' +
                             '<div class="stat-row"><span>Blocks Won:</span> <span>' + (stats.blocks_won || 0) + '</span></div>' +
-                            '<div class="stat-row"><span>Win Rate:</span> <span>' + winPercentage + '%</span></div>' +
+                            '<div class="stat-row"><span>Win Rate:</span> <span class="stat-value' + (stats.dominant ? ' warning' : '') + '">' + winPercentage + '%</span></div>' +
                             '<div class="stat-row"><span>Capacity:</span> <span>' + ((stats.total_capacity || 0) / (1024*1024*1024*1024)).toFixed(2) + ' TB</span></div>' +
+                            '<div class="stat-row"><span>Your Membership:</span> <span class="stat-value' + (stats.warning && membership.member ? ' warning' : '') + '">' + membershipText + '</span></div>' +
                         '</div>' +
                     '</div>';
                 }
@@ -6459,7 +6468,7 @@ func (sn *ShadowNode) handleWebWalletPeers(w http.ResponseWriter, r *http.Reques
 
         async function loadSyndicateStats() {
             try {
-                const response = await fetch('/wallet/syndicate-stats');
+                const response = await fetch('/wallet/syndicates/stats');
                 const stats = await response.json();
 
                 const statsDiv = document.getElementById('syndicate-stats');
@@ -6467,7 +6476,13 @@ func (sn *ShadowNode) handleWebWalletPeers(w http.ResponseWriter, r *http.Reques
 
                 const syndicates = ['seiryu', 'byakko', 'suzaku', 'genbu'];
                 for (const syndicate of syndicates) {
-                    const data = stats[syndicate] || { members: 0, total_capacity: 0, win_percentage: 0, blocks_won: 0 };
+                    const data = (stats.syndicates || {})[syndicate] || { member_count: 0, total_capacity: 0, total_staked: 0, rewards_distributed: 0, win_percentage: 0, blocks_won: 0, membership: {} };
+                    const membership = data.membership || {};
+                    let membershipText = 'Not a member';
+                    if (membership.member) {
+                        membershipText = membership.expired ? 'Expired' : Math.ceil(membership.expires_in / 86400) + ' days left';
+                    }
+
                     html += '<div class="syndicate-stat-card">' +
                         '<div class="stat-header">' +
                             '<h4>' + getSyndicateIcon(syndicate) + ' ' + getSyndicateName(syndicate) + '</h4>' +
@@ -6475,7 +6490,15 @@ func (sn *ShadowNode) handleWebWalletPeers(w http.ResponseWriter, r *http.Reques
                         '<div class="stat-details">' +
                             '<div class="stat-item">' +
                                 '<span class="stat-label">Members:</span>' +
-                                '<span class="stat-value">' + data.members + '</span>' +
+                                '<span class="stat-value">' + data.member_count + '</span>' +
+                            '</div>' +
+                            '<div class="stat-item">' +
+                                '<span class="stat-label">Total Staked:</span>' +
+                                '<span class="stat-value">' + (data.total_staked / 100000000).toFixed(2) + ' SHADOW</span>' +
+                            '</div>' +
+                            '<div class="stat-item">' +
+                                '<span class="stat-label">Rewards Distributed:</span>' +
+                                '<span class="stat-value">' + (data.rewards_distributed / 100000000).toFixed(2) + ' SHADOW</span>' +
                             '</div>' +
                             '<div class="stat-item">' +
                                 '<span class="stat-label">Capacity:</span>' +
@@ -6487,7 +6510,11 @@ func (sn *ShadowNode) handleWebWalletPeers(w http.ResponseWriter, r *http.Reques
                             '</div>' +
                             '<div class="stat-item">' +
                                 '<span class="stat-label">Win Rate:</span>' +
-                                '<span class="stat-value ' + (data.win_percentage > 35 ? 'warning' : '') + '">' + data.win_percentage.toFixed(1) + '%</span>' +
+                                '<span class="stat-value ' + (data.dominant ? 'warning' : '') + '">' + data.win_percentage.toFixed(1) + '%</span>' +
+                            '</div>' +
+                            '<div class="stat-item">' +
+                                '<span class="stat-label">Your Membership:</span>' +
+                                '<span class="stat-value ' + (membership.expiring_soon || membership.expired ? 'warning' : '') + '">' + membershipText + '</span>' +
                             '</div>' +
                         '</div>' +
                     '</div>';
@@ -7331,6 +7358,52 @@ func (sn *ShadowNode) handleWebWalletSyndicateStats(w http.ResponseWriter, r *ht
     json.NewEncoder(w).Encode(response)
 }
 
+// handleWebWalletSyndicatesStats returns per-syndicate totals computed from chain
+// state together with the session's own membership status
+func (sn *ShadowNode) handleWebWalletSyndicatesStats(w http.ResponseWriter, r *http.Request) {
+    // Check authentication
+    session, authenticated := validateSession(r)
+    if !authenticated {
+        http.Error(w, "Not authenticated", http.StatusUnauthorized)
+        return
+    }
+
+    // Check blockchain availability
+    if sn.blockchain == nil {
+        http.Error(w, "Blockchain not available", http.StatusServiceUnavailable)
+        return
+    }
+
+    syndicateManager := sn.blockchain.GetSyndicateManager()
+    if syndicateManager == nil {
+        http.Error(w, "Syndicate system not available", http.StatusServiceUnavailable)
+        return
+    }
+
+    // Staked amounts come from the SHADOW locked in each membership NFT
+    var lockedShadow func(string) uint64
+    if tokenState := sn.blockchain.GetTokenState(); tokenState != nil {
+        lockedShadow = func(nftTokenID string) uint64 {
+            locked, err := tokenState.GetLockedShadow(nftTokenID)
+            if err != nil {
+                return 0
+            }
+            return locked
+        }
+    }
+    rewards := sn.blockchain.SyndicateRewards(SyndicateRewardWindow)
+
+    response := map[string]interface{}{
+        "address":              session.Address,
+        "syndicates":           syndicateManager.BuildStatsView(session.Address, lockedShadow, rewards, time.Now()),
+        "expiring_soon_window": int64(SyndicateExpiringSoonWindow / time.Second),
+        "dominance_threshold":  SyndicateDominanceThreshold,
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(response)
+}
+
 // handleWebWalletJoinSyndicate creates a syndicate membership transaction
 func (sn *ShadowNode) handleWebWalletJoinSyndicate(w http.ResponseWriter, r *http.Request) {
     if r.Method != "POST" {
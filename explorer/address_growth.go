@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// The first-seen index records the UTC day each address first appeared
+// (first_seen:<address> -> day) and how many addresses appeared on each day
+// (addr_growth:<day> -> big-endian count). StoreTransaction keeps both up to
+// date and a rollback rebuilds both; databases indexed before it existed are
+// backfilled by a migration.
+const (
+	firstSeenPrefix  = "first_seen:"
+	addrGrowthPrefix = "addr_growth:"
+)
+
+// recordFirstSeen moves address's first-seen day to the day of timestamp if
+// that is earlier than what the index holds, adjusting the daily counters
+func recordFirstSeen(txn *badger.Txn, address string, timestamp time.Time) error {
+	day := timestamp.UTC().Format(dailyDateFormat)
+	key := []byte(firstSeenPrefix + address)
+
+	item, err := txn.Get(key)
+	switch {
+	case err == badger.ErrKeyNotFound:
+	case err != nil:
+		return err
+	default:
+		var previous string
+		if err := item.Value(func(val []byte) error {
+			previous = string(val)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if previous <= day {
+			return nil
+		}
+		if err := addGrowthCount(txn, previous, -1); err != nil {
+			return err
+		}
+	}
+
+	if err := txn.Set(key, []byte(day)); err != nil {
+		return fmt.Errorf("failed to store first-seen day: %w", err)
+	}
+	return addGrowthCount(txn, day, 1)
+}
+
+// addGrowthCount adjusts the number of addresses first seen on day
+func addGrowthCount(txn *badger.Txn, day string, delta int64) error {
+	key := []byte(addrGrowthPrefix + day)
+
+	var count int64
+	item, err := txn.Get(key)
+	if err != nil && err != badger.ErrKeyNotFound {
+		return err
+	}
+	if err == nil {
+		if err := item.Value(func(val []byte) error {
+			if len(val) == 8 {
+				count = int64(binary.BigEndian.Uint64(val))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	count += delta
+	if count <= 0 {
+		return txn.Delete(key)
+	}
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(count))
+	return txn.Set(key, value)
+}
+
+// buildFirstSeenIndex rebuilds the first-seen index from stored
+// transactions and the history of pruned addresses
+func (d *Database) buildFirstSeenIndex() (int, error) {
+	changed := 0
+	err := d.update(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		// Drop partial entries written since the upgrade, then replay
+		var stale [][]byte
+		for _, prefix := range []string{firstSeenPrefix, addrGrowthPrefix} {
+			for it.Seek([]byte(prefix)); it.ValidForPrefix([]byte(prefix)); it.Next() {
+				stale = append(stale, it.Item().KeyCopy(nil))
+			}
+		}
+		for _, key := range stale {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		// Pruned addresses keep their first activity in their history
+		firstSeen := make(map[string]string)
+		prunedPrefix := []byte(prunedAddressPrefix)
+		for it.Seek(prunedPrefix); it.ValidForPrefix(prunedPrefix); it.Next() {
+			var history prunedHistory
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &history)
+			}); err != nil || history.Transactions == 0 {
+				continue
+			}
+			address := strings.TrimPrefix(string(it.Item().Key()), prunedAddressPrefix)
+			firstSeen[address] = history.FirstActivity.UTC().Format(dailyDateFormat)
+		}
+
+		txPrefix := []byte("tx:")
+		for it.Seek(txPrefix); it.ValidForPrefix(txPrefix); it.Next() {
+			var tx WalletTransaction
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				continue
+			}
+			day := tx.Timestamp.UTC().Format(dailyDateFormat)
+			for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+				if address == "" {
+					continue
+				}
+				if seen, ok := firstSeen[address]; !ok || day < seen {
+					firstSeen[address] = day
+				}
+			}
+		}
+
+		counts := make(map[string]uint64)
+		for address, day := range firstSeen {
+			if err := txn.Set([]byte(firstSeenPrefix+address), []byte(day)); err != nil {
+				return err
+			}
+			counts[day]++
+		}
+		for day, count := range counts {
+			value := make([]byte, 8)
+			binary.BigEndian.PutUint64(value, count)
+			if err := txn.Set([]byte(addrGrowthPrefix+day), value); err != nil {
+				return err
+			}
+		}
+
+		changed = len(firstSeen)
+		return nil
+	})
+	return changed, err
+}
+
+// GetAddressGrowth returns, for each of the days days ending with the UTC
+// day containing now, how many addresses first appeared that day and how
+// many distinct addresses had been seen by the end of it
+func (d *Database) GetAddressGrowth(days int, now time.Time) ([]AddressGrowthPoint, error) {
+	first := dayStart(now).AddDate(0, 0, -(days - 1))
+	firstDay := first.Format(dailyDateFormat)
+
+	var before uint64
+	perDay := make(map[string]uint64)
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(addrGrowthPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			day := strings.TrimPrefix(string(it.Item().Key()), addrGrowthPrefix)
+			var count uint64
+			if err := it.Item().Value(func(val []byte) error {
+				if len(val) == 8 {
+					count = binary.BigEndian.Uint64(val)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			if day < firstDay {
+				before += count
+			} else {
+				perDay[day] = count
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return addressGrowthPoints(first, days, before, perDay), nil
+}
+
+// addressGrowthPoints lays out days days from first, given how many
+// addresses appeared before first and on each day after it
+func addressGrowthPoints(first time.Time, days int, before uint64, perDay map[string]uint64) []AddressGrowthPoint {
+	points := make([]AddressGrowthPoint, days)
+	total := before
+	for i := range points {
+		day := first.AddDate(0, 0, i).Format(dailyDateFormat)
+		total += perDay[day]
+		points[i] = AddressGrowthPoint{
+			Date:           day,
+			NewAddresses:   perDay[day],
+			TotalAddresses: total,
+		}
+	}
+	return points
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func storeTestTransfer(t *testing.T, database *Database, hash, from, to string, timestamp time.Time) {
+	tx := &WalletTransaction{
+		TxHash:      hash,
+		Timestamp:   timestamp,
+		Type:        "sent",
+		Amount:      1,
+		FromAddress: from,
+		ToAddress:   to,
+	}
+	if err := database.StoreTransaction(tx); err != nil {
+		t.Fatalf("Failed to store %s: %v", hash, err)
+	}
+}
+
+func growthTotals(t *testing.T, database *Database, days int, now time.Time) ([]uint64, []uint64) {
+	points, err := database.GetAddressGrowth(days, now)
+	if err != nil {
+		t.Fatalf("GetAddressGrowth failed: %v", err)
+	}
+	var totals, added []uint64
+	for _, point := range points {
+		totals = append(totals, point.TotalAddresses)
+		added = append(added, point.NewAddresses)
+	}
+	return totals, added
+}
+
+func TestAddressGrowthIncrementsOnFirstAppearance(t *testing.T) {
+	database := newTestDatabase(t)
+	day := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	now := day.AddDate(0, 0, 3).Add(time.Hour)
+
+	// Build the (empty) index up front so later writes go through the incremental path
+	if _, err := database.GetAddressGrowth(1, now); err != nil {
+		t.Fatalf("GetAddressGrowth failed: %v", err)
+	}
+
+	storeTestTransfer(t, database, "tx_1", "alice", "bob", day.Add(-time.Second)) // April 30
+	storeTestTransfer(t, database, "tx_2", "alice", "carol", day.Add(time.Hour))
+	storeTestTransfer(t, database, "tx_3", "bob", "alice", day.AddDate(0, 0, 1))
+	storeTestTransfer(t, database, "tx_4", "dave", "erin", day.AddDate(0, 0, 3))
+
+	totals, added := growthTotals(t, database, 4, now)
+	if fmt.Sprint(totals) != "[3 3 3 5]" || fmt.Sprint(added) != "[1 0 0 2]" {
+		t.Fatalf("Unexpected growth: totals %v, new %v", totals, added)
+	}
+	for i := 1; i < len(totals); i++ {
+		if totals[i] < totals[i-1] {
+			t.Errorf("Cumulative count decreased on day %d: %v", i, totals)
+		}
+	}
+
+	// A late-indexed earlier sighting moves carol's first day back
+	storeTestTransfer(t, database, "tx_0", "carol", "alice", day.AddDate(0, 0, -1).Add(time.Hour))
+	totals, added = growthTotals(t, database, 4, now)
+	if fmt.Sprint(totals) != "[3 3 3 5]" || fmt.Sprint(added) != "[0 0 0 2]" {
+		t.Errorf("Expected carol counted before the range, got totals %v, new %v", totals, added)
+	}
+}
+
+func TestAddressGrowthBackfillsExistingData(t *testing.T) {
+	database := newTestDatabase(t)
+	day := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	storeTestTransfer(t, database, "tx_1", "alice", "bob", day)
+	storeTestTransfer(t, database, "tx_2", "bob", "carol", day.AddDate(0, 0, 1))
+
+	// Simulate a database indexed before the first-seen index existed
+	err := database.update(func(txn *badger.Txn) error {
+		for _, key := range []string{"first_seen:alice", "first_seen:bob", "first_seen:carol", "addr_growth:2025-05-01", "addr_growth:2025-05-02", firstSeenIndexMarker} {
+			if err := txn.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to clear index: %v", err)
+	}
+	if err := database.migrate(); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	totals, added := growthTotals(t, database, 2, day.AddDate(0, 0, 1))
+	if fmt.Sprint(totals) != "[2 3]" || fmt.Sprint(added) != "[2 1]" {
+		t.Errorf("Expected the backfill to rebuild growth, got totals %v, new %v", totals, added)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Every request to an admin route, allowed or not, is recorded in the
+// audit log (audit:<unix nanos>:<random>), which survives resets
+const (
+	auditPrefix = "audit:"
+
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// requireAdmin wraps an admin handler. The request must carry
+// EXPLORER_ADMIN_TOKEN as a bearer token or present a client certificate
+// from the admin client CAs; when no token is set and the explorer is not
+// in production mode, loopback clients are allowed too.
+func (es *ExplorerServer) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := es.adminActor(r)
+		if !ok {
+			slog.WarnContext(r.Context(), "Rejected admin request", "method", r.Method, "path", r.URL.Path, "client", es.config.logClient(r))
+			es.audit(r, "", http.StatusUnauthorized)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="explorer-admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		es.audit(r, actor, recorder.status)
+	}
+}
+
+// isAdminRequest reports whether r is authorized for admin endpoints
+func (es *ExplorerServer) isAdminRequest(r *http.Request) bool {
+	_, ok := es.adminActor(r)
+	return ok
+}
+
+// adminActor identifies who is making an admin request: "client-cert:<CN>",
+// "token" or "loopback". It reports false for anyone else.
+func (es *ExplorerServer) adminActor(r *http.Request) (string, bool) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && es.config != nil && es.config.clientCAs != nil {
+		return "client-cert:" + r.TLS.VerifiedChains[0][0].Subject.CommonName, true
+	}
+
+	if es.adminToken == "" {
+		if es.production() {
+			return "", false
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		ip := net.ParseIP(host)
+		return "loopback", ip != nil && ip.IsLoopback()
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	return "token", subtle.ConstantTimeCompare([]byte(token), []byte(es.adminToken)) == 1
+}
+
+// production reports whether test and debug routes are disabled
+func (es *ExplorerServer) production() bool {
+	return es.config != nil && es.config.Production
+}
+
+// audit records an admin request and the status it got
+func (es *ExplorerServer) audit(r *http.Request, actor string, status int) {
+	if es.database == nil {
+		return
+	}
+	entry := &AuditEntry{
+		Time:       time.Now().UTC(),
+		Actor:      actor,
+		RemoteAddr: es.config.clientAddr(r),
+		Method:     r.Method,
+		Path:       r.URL.RequestURI(),
+		Status:     status,
+	}
+	if err := es.database.AppendAuditEntry(entry); err != nil {
+		slog.Error("Failed to record admin request in the audit log", "method", entry.Method, "path", entry.Path, "err", err)
+	}
+}
+
+// AppendAuditEntry adds an entry to the audit log
+func (d *Database) AppendAuditEntry(entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	key := fmt.Sprintf("%s%020d:%s", auditPrefix, entry.Time.UnixNano(), randomHex(4))
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(key), data)
+	})
+}
+
+// GetAuditLog returns the latest limit audit entries, newest first
+func (d *Database) GetAuditLog(limit int) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(auditPrefix)
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(auditPrefix + "\xff")); it.Valid() && len(entries) < limit; it.Next() {
+			var entry AuditEntry
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			}); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Audit log endpoint: the latest admin requests, newest first
+func (es *ExplorerServer) handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAuditLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxAuditLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	entries, err := es.database.GetAuditLog(limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get audit log", "err", err)
+		http.Error(w, "Failed to get audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries})
+}
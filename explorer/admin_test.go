@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminRequestsAudited(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database, adminToken: "secret"}
+	handler := es.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/reset?full=1", nil)
+	req.RemoteAddr = "192.0.2.7:5000"
+	handler(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("POST", "/api/v1/admin/reset", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	handler(httptest.NewRecorder(), req)
+
+	entries, err := database.GetAuditLog(10)
+	if err != nil {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected both requests audited, got %+v", entries)
+	}
+	if allowed := entries[0]; allowed.Actor != "token" || allowed.Status != http.StatusAccepted || allowed.Method != "POST" {
+		t.Errorf("Unexpected entry for the allowed request %+v", allowed)
+	}
+	if rejected := entries[1]; rejected.Actor != "" || rejected.Status != http.StatusUnauthorized ||
+		rejected.Path != "/api/v1/admin/reset?full=1" || rejected.RemoteAddr != "192.0.2.7:5000" {
+		t.Errorf("Unexpected entry for the rejected request %+v", rejected)
+	}
+
+	// The audit log outlives a reset of the chain data
+	if err := database.ResetDatabase(); err != nil {
+		t.Fatalf("ResetDatabase failed: %v", err)
+	}
+	if entries, _ := database.GetAuditLog(1); len(entries) != 1 || entries[0].Actor != "token" {
+		t.Errorf("Expected the latest entry kept across a reset, got %+v", entries)
+	}
+
+	rec := httptest.NewRecorder()
+	es.handleAuditLog(rec, httptest.NewRequest("GET", "/api/v1/admin/audit?limit=5000", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an oversized limit, got %d", rec.Code)
+	}
+}
+
+func TestAdminActor(t *testing.T) {
+	loopback := httptest.NewRequest("GET", "/api/v1/admin/audit", nil)
+	loopback.RemoteAddr = "127.0.0.1:5000"
+
+	es := &ExplorerServer{config: &ServerConfig{}}
+	if actor, ok := es.adminActor(loopback); !ok || actor != "loopback" {
+		t.Errorf("Expected loopback allowed without a token, got %q %v", actor, ok)
+	}
+	es.config.Production = true
+	if _, ok := es.adminActor(loopback); ok {
+		t.Error("Expected loopback refused in production mode")
+	}
+
+	// A client certificate verified against the admin CAs
+	es.config.clientCAs = x509.NewCertPool()
+	withCert := httptest.NewRequest("GET", "/api/v1/admin/audit", nil)
+	withCert.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "ops"}}}}}
+	if actor, ok := es.adminActor(withCert); !ok || actor != "client-cert:ops" {
+		t.Errorf("Expected the client certificate admitted, got %q %v", actor, ok)
+	}
+	withCert.TLS.VerifiedChains = nil
+	if _, ok := es.adminActor(withCert); ok {
+		t.Error("Expected an unverified TLS client refused")
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AMMRoute is a swap decoded from a transaction that pays a pool's
+// L-address: which pool it routes through and what goes in and comes out
+type AMMRoute struct {
+	PoolID       string        `json:"pool_id"`
+	PoolLAddress string        `json:"pool_l_address"`
+	Pair         string        `json:"pair"` // e.g. "GOLD/SHADOW"
+	Swapper      string        `json:"swapper,omitempty"`
+	Direction    string        `json:"direction"` // "a_to_b" or "b_to_a"
+	Legs         []AMMRouteLeg `json:"legs"`      // Input leg, then output leg
+	FeeRate      uint64        `json:"fee_rate"`  // Basis points
+	Fee          uint64        `json:"fee"`       // Charged in the input token
+	MinReceived  uint64        `json:"min_received,omitempty"`
+	PriceImpact  float64       `json:"price_impact"` // Percent
+	// Estimated routes were not recorded by sync, so their legs are priced
+	// against the pool's current reserves rather than those at the time
+	Estimated bool `json:"estimated"`
+}
+
+// AMMRouteLeg is one token movement of a swap
+type AMMRouteLeg struct {
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+	TokenID string `json:"token_id"` // "SHADOW" for SHADOW
+	Symbol  string `json:"symbol"`
+	Amount  uint64 `json:"amount"`
+}
+
+// Summary describes the route in one line, e.g.
+// "1,000 GOLD → 0.50000000 SHADOW". Token amounts are in base units.
+func (r *AMMRoute) Summary() string {
+	if len(r.Legs) != 2 {
+		return ""
+	}
+	return r.Legs[0].display() + " → " + r.Legs[1].display()
+}
+
+func (l AMMRouteLeg) display() string {
+	if l.TokenID == "SHADOW" {
+		return formatShadowAmount(int64(l.Amount)) + " SHADOW"
+	}
+	return formatUnits(l.Amount, 0) + " " + l.Symbol
+}
+
+// ammPayment is a payment to an L-address found in a transaction
+type ammPayment struct {
+	lAddress    string
+	inputToken  string
+	amount      uint64
+	swapper     string
+	minReceived uint64
+}
+
+// findAMMPayment returns the transaction's first swap: a POOL_SWAP token
+// operation, or else SHADOW paid straight to an L-address
+func findAMMPayment(tx *Transaction) *ammPayment {
+	for _, op := range tx.TokenOps {
+		if op.Type != POOL_SWAP {
+			continue
+		}
+		payment := &ammPayment{lAddress: op.To, inputToken: op.TokenID, amount: op.Amount, swapper: op.From}
+		if op.Metadata != nil && op.Metadata.PoolSwap != nil {
+			swap := op.Metadata.PoolSwap
+			if swap.PoolLAddress != "" {
+				payment.lAddress = swap.PoolLAddress
+			}
+			if swap.InputTokenID != "" {
+				payment.inputToken = swap.InputTokenID
+			}
+			if swap.SwapperAddress != "" {
+				payment.swapper = swap.SwapperAddress
+			}
+			payment.minReceived = swap.MinReceived
+		}
+		return payment
+	}
+	for _, output := range tx.Outputs {
+		if strings.HasPrefix(output.Address, "L") {
+			return &ammPayment{lAddress: output.Address, inputToken: "SHADOW", amount: output.Value}
+		}
+	}
+	return nil
+}
+
+// decodeAMMRoute decodes the swap in tx, returning nil when the transaction
+// pays no L-address, the L-address is not a known pool, or the pool cannot
+// trade what was paid. The swap sync recorded for the transaction is used
+// when there is one; otherwise the legs are estimated.
+func decodeAMMRoute(store Store, hash string, tx *Transaction) *AMMRoute {
+	payment := findAMMPayment(tx)
+	if payment == nil {
+		return nil
+	}
+	pool, err := store.GetPoolByAddress(payment.lAddress)
+	if err != nil {
+		return nil
+	}
+
+	var swap *PoolTransaction
+	swaps, err := store.GetPoolSwaps(pool.PoolID, tx.Timestamp, tx.Timestamp)
+	if err != nil {
+		slog.Warn("Failed to read pool swaps", "pool_id", pool.PoolID, "err", err)
+	}
+	for i := range swaps {
+		if swaps[i].TxHash == hash {
+			swap = &swaps[i]
+			break
+		}
+	}
+	estimated := swap == nil
+	if estimated {
+		current := *pool
+		swap, err = applyPoolSwap(&current, payment.inputToken, payment.amount, tx.Timestamp)
+		if err != nil {
+			return nil
+		}
+	}
+
+	symbolA, symbolB := pool.TokenASymbol, pool.TokenBSymbol
+	tokenA, tokenB := pool.TokenA, pool.TokenB
+	if tokenB == "" {
+		tokenB, symbolB = "SHADOW", "SHADOW"
+	}
+	if tokenA == "" {
+		tokenA, symbolA = "SHADOW", "SHADOW"
+	}
+	in := AMMRouteLeg{From: payment.swapper, To: pool.LAddress, TokenID: tokenA, Symbol: symbolA, Amount: swap.AmountA}
+	out := AMMRouteLeg{From: pool.LAddress, To: payment.swapper, TokenID: tokenB, Symbol: symbolB, Amount: swap.AmountB}
+	if swap.Direction == swapBToA {
+		in.TokenID, in.Symbol, in.Amount = tokenB, symbolB, swap.AmountB
+		out.TokenID, out.Symbol, out.Amount = tokenA, symbolA, swap.AmountA
+	}
+
+	feeRate := poolFeeRate(pool)
+	return &AMMRoute{
+		PoolID:       pool.PoolID,
+		PoolLAddress: pool.LAddress,
+		Pair:         symbolA + "/" + symbolB,
+		Swapper:      payment.swapper,
+		Direction:    swap.Direction,
+		Legs:         []AMMRouteLeg{in, out},
+		FeeRate:      feeRate,
+		Fee:          in.Amount * feeRate / 10000,
+		MinReceived:  payment.minReceived,
+		PriceImpact:  swap.PriceImpact,
+		Estimated:    estimated,
+	}
+}
+
+// TransactionDetails is one transaction as it appears in its block, with
+// the swap it routes through a pool, if any
+type TransactionDetails struct {
+	TxHash      string       `json:"tx_hash"`
+	BlockHash   string       `json:"block_hash"`
+	BlockHeight uint64       `json:"block_height"`
+	Timestamp   time.Time    `json:"timestamp"`
+	Transaction *Transaction `json:"transaction"`
+	AMMRoute    *AMMRoute    `json:"amm_route,omitempty"`
+}
+
+// Transaction details API endpoint: a transaction found through the block
+// the wallet index recorded it in
+func (es *ExplorerServer) handleTransactionAPI(w http.ResponseWriter, r *http.Request) {
+	details, err := es.transactionDetails(mux.Vars(r)["hash"])
+	if err == errNotFound {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Transaction does not decode", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// transactionDetails finds the transaction hash in the block the wallet
+// index recorded it in, returning errNotFound when either is missing
+func (es *ExplorerServer) transactionDetails(hash string) (*TransactionDetails, error) {
+	walletTx, err := es.database.GetTransaction(hash)
+	if err != nil {
+		return nil, errNotFound
+	}
+	block, err := es.database.GetBlock(walletTx.BlockHash)
+	if err != nil {
+		return nil, errNotFound
+	}
+
+	for i := range block.Body.Transactions {
+		signed := &block.Body.Transactions[i]
+		txHash := signed.TxHash
+		if signed.Algorithm == "coinbase" && txHash == "transaction" {
+			txHash = fmt.Sprintf("coinbase_%s", walletTx.BlockHash)
+		}
+		if txHash != hash {
+			continue
+		}
+		tx, err := decodeSignedTransaction(signed)
+		if err != nil {
+			return nil, err
+		}
+		return &TransactionDetails{
+			TxHash:      hash,
+			BlockHash:   walletTx.BlockHash,
+			BlockHeight: block.Header.Height,
+			Timestamp:   tx.Timestamp,
+			Transaction: tx,
+			AMMRoute:    decodeAMMRoute(es.database, hash, tx),
+		}, nil
+	}
+	return nil, errNotFound
+}
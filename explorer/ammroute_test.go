@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// storeTestSwapBlock stores a block holding tx under hash, indexed for
+// lookups by hash, and a GOLD/SHADOW pool at L-address Lpool
+func storeTestSwapBlock(t *testing.T, database Store, hash string, tx *Transaction) {
+	pool := &LiquidityPool{PoolID: "pool_gold", LAddress: "Lpool", TokenA: "70ce", TokenASymbol: "GOLD",
+		TokenBSymbol: "SHADOW", ReserveA: 1000, ReserveB: 1000}
+	if err := database.StorePool(pool); err != nil {
+		t.Fatalf("Failed to store pool: %v", err)
+	}
+
+	data, _ := json.Marshal(tx)
+	block := &Block{Header: BlockHeader{Height: 1, Timestamp: tx.Timestamp}}
+	block.Body.Transactions = []SignedTransaction{{TxHash: hash, Transaction: data}}
+	if err := database.StoreBlock("block_1", block); err != nil {
+		t.Fatalf("Failed to store block: %v", err)
+	}
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: hash, BlockHash: "block_1", BlockHeight: 1, ToAddress: "Lpool", Timestamp: tx.Timestamp}); err != nil {
+		t.Fatalf("Failed to store transaction: %v", err)
+	}
+}
+
+func TestDecodeTokenSwapRoute(t *testing.T) {
+	database := newTestDatabase(t)
+	tx := &Transaction{Timestamp: time.Unix(100, 0), TokenOps: []TokenOperation{{
+		Type: POOL_SWAP, TokenID: "70ce", Amount: 100, From: "alice", To: "Lpool",
+		Metadata: &TokenMetadata{PoolSwap: &PoolSwapData{MinReceived: 85}},
+	}}}
+	storeTestSwapBlock(t, database, "swap_1", tx)
+
+	// Not recorded by sync, so priced at the current reserves
+	route := decodeAMMRoute(database, "swap_1", tx)
+	if route == nil {
+		t.Fatal("Expected a route")
+	}
+	if !route.Estimated || route.PoolID != "pool_gold" || route.Pair != "GOLD/SHADOW" || route.Direction != swapAToB {
+		t.Errorf("Unexpected route %+v", route)
+	}
+	in, out := route.Legs[0], route.Legs[1]
+	if in.From != "alice" || in.To != "Lpool" || in.Symbol != "GOLD" || in.Amount != 100 {
+		t.Errorf("Unexpected input leg %+v", in)
+	}
+	if out.To != "alice" || out.TokenID != "SHADOW" || out.Amount != 90 {
+		t.Errorf("Unexpected output leg %+v", out)
+	}
+	if route.FeeRate != 30 || route.MinReceived != 85 || route.Summary() != "100 GOLD → 0.00000090 SHADOW" {
+		t.Errorf("Unexpected fee, minimum or summary: %+v %q", route, route.Summary())
+	}
+
+	// The swap sync recorded wins over the estimate
+	recorded := &PoolTransaction{TxHash: "swap_1", Type: "swap", Timestamp: tx.Timestamp, AmountA: 100, AmountB: 80, Direction: swapAToB, PriceImpact: 17.4}
+	if err := database.StorePoolTransaction("pool_gold", recorded); err != nil {
+		t.Fatalf("Failed to store pool transaction: %v", err)
+	}
+	route = decodeAMMRoute(database, "swap_1", tx)
+	if route == nil || route.Estimated || route.Legs[1].Amount != 80 || route.PriceImpact != 17.4 {
+		t.Errorf("Expected the recorded swap, got %+v", route)
+	}
+}
+
+func TestDecodeShadowPaymentRoute(t *testing.T) {
+	database := newTestDatabase(t)
+	tx := &Transaction{Timestamp: time.Unix(100, 0), Outputs: []TransactionOutput{{Address: "Lpool", Value: 100}}}
+	storeTestSwapBlock(t, database, "swap_2", tx)
+
+	route := decodeAMMRoute(database, "swap_2", tx)
+	if route == nil || route.Direction != swapBToA || route.Legs[0].TokenID != "SHADOW" || route.Legs[1].Symbol != "GOLD" {
+		t.Errorf("Expected SHADOW in and GOLD out, got %+v", route)
+	}
+
+	if decodeAMMRoute(database, "x", &Transaction{Outputs: []TransactionOutput{{Address: "Lunknown", Value: 1}}}) != nil {
+		t.Error("Payments to unknown L-addresses should not decode")
+	}
+	if decodeAMMRoute(database, "x", &Transaction{Outputs: []TransactionOutput{{Address: "Sbob", Value: 1}}}) != nil {
+		t.Error("Plain payments should not decode")
+	}
+}
+
+func TestTransactionAPIIncludesRoute(t *testing.T) {
+	database := newTestDatabase(t)
+	tx := &Transaction{Timestamp: time.Unix(100, 0), Outputs: []TransactionOutput{{Address: "Lpool", Value: 100}}}
+	storeTestSwapBlock(t, database, "swap_3", tx)
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleTransactionAPI(rec, mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/tx/swap_3", nil), map[string]string{"hash": "swap_3"}))
+	var details TransactionDetails
+	if err := json.NewDecoder(rec.Body).Decode(&details); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if details.BlockHash != "block_1" || details.AMMRoute == nil || details.AMMRoute.PoolID != "pool_gold" {
+		t.Errorf("Unexpected transaction details %+v", details)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleTransactionAPI(rec, mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/tx/missing", nil), map[string]string{"hash": "missing"}))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown transaction, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleBlockDetailsPage(rec, mux.SetURLVars(httptest.NewRequest("GET", "/block/block_1", nil), map[string]string{"hash": "block_1"}))
+	if !strings.Contains(rec.Body.String(), `AMM swap via <a href="/pool/pool_gold"`) {
+		t.Errorf("Block page should show the decoded route, got %s", rec.Body.String())
+	}
+}
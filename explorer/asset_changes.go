@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// assetChanges nets a transaction's token operations into one balance
+// change per address and token, in the order they first appear. Creates
+// mint to their recipient, transfers move tokens from one address to
+// another and melts burn them; changes that cancel out are dropped.
+func (s *SyncService) assetChanges(ops []TokenOperation) []AssetChange {
+	type assetKey struct{ address, tokenID string }
+	index := make(map[assetKey]int)
+	var changes []AssetChange
+
+	add := func(address, tokenID, symbol string, delta int64) {
+		if address == "" {
+			return
+		}
+		key := assetKey{address, tokenID}
+		if i, ok := index[key]; ok {
+			changes[i].Delta += delta
+			return
+		}
+		index[key] = len(changes)
+		changes = append(changes, AssetChange{Address: address, TokenID: tokenID, Symbol: symbol, Delta: delta})
+	}
+
+	symbols := make(map[string]string)
+	for _, op := range ops {
+		if op.Type != TOKEN_CREATE && op.Type != TOKEN_TRANSFER && op.Type != TOKEN_MELT {
+			continue
+		}
+		symbol, ok := symbols[op.TokenID]
+		if !ok {
+			// A token created in this transaction is not indexed yet
+			if op.Type == TOKEN_CREATE && op.Metadata != nil && op.Metadata.Ticker != "" {
+				symbol = op.Metadata.Ticker
+			} else {
+				symbol = s.poolTokenSymbol(op.TokenID)
+			}
+			symbols[op.TokenID] = symbol
+		}
+
+		amount := int64(op.Amount)
+		if op.Type != TOKEN_CREATE {
+			add(op.From, op.TokenID, symbol, -amount)
+		}
+		if op.Type != TOKEN_MELT {
+			add(op.To, op.TokenID, symbol, amount)
+		}
+	}
+
+	netted := changes[:0]
+	for _, change := range changes {
+		if change.Delta != 0 {
+			netted = append(netted, change)
+		}
+	}
+	if len(netted) == 0 {
+		return nil
+	}
+	return netted
+}
+
+// assetChangesOf picks out the changes to one address's token balances
+func assetChangesOf(changes []AssetChange, address string) []AssetChange {
+	var own []AssetChange
+	for _, change := range changes {
+		if change.Address == address {
+			own = append(own, change)
+		}
+	}
+	return own
+}
+
+// formatAssetChanges writes changes as signed amounts with their symbols,
+// e.g. "-40 BET; +2 ALP"
+func formatAssetChanges(changes []AssetChange) string {
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		parts[i] = fmt.Sprintf("%+d %s", change.Delta, change.Symbol)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// storeAssetChangeBlocks indexes a token minted to alice at height 1, then
+// one transaction at height 2 that moves it and a second token between
+// alice, bob and carol
+func storeAssetChangeBlocks(t *testing.T, svc *SyncService) {
+	const alpha, beta = "0c0c0c0c0c0c0c0c", "0d0d0d0d0d0d0d0d"
+	txs := [][]TokenOperation{
+		{
+			{Type: TOKEN_CREATE, TokenID: alpha, Amount: 100, To: "alice", Metadata: &TokenMetadata{Name: "Alpha", Ticker: "ALP"}},
+		},
+		{
+			{Type: TOKEN_CREATE, TokenID: beta, Amount: 50, To: "bob", Metadata: &TokenMetadata{Name: "Beta", Ticker: "BET"}},
+			{Type: TOKEN_TRANSFER, TokenID: alpha, Amount: 30, From: "alice", To: "bob"},
+			{Type: TOKEN_TRANSFER, TokenID: beta, Amount: 20, From: "bob", To: "alice"},
+			{Type: TOKEN_TRANSFER, TokenID: alpha, Amount: 10, From: "bob", To: "carol"},
+			{Type: TOKEN_MELT, TokenID: alpha, Amount: 5, From: "alice"},
+		},
+	}
+	start := time.Unix(1700000000, 0).UTC()
+	for i, ops := range txs {
+		height := uint64(i + 1)
+		timestamp := start.Add(time.Duration(height) * time.Minute)
+		tx, _ := json.Marshal(Transaction{Version: 1, TokenOps: ops, Timestamp: timestamp})
+		block := &Block{
+			Header: BlockHeader{Height: height, Timestamp: timestamp},
+			Body:   BlockBody{Transactions: []SignedTransaction{{Transaction: tx, TxHash: "assets" + string(rune('0'+height))}}},
+		}
+		if _, err := svc.extractAndStoreTransactions("hash", block, indexAll); err != nil {
+			t.Fatalf("Failed to index block %d: %v", height, err)
+		}
+	}
+}
+
+func TestAssetChanges(t *testing.T) {
+	database := newTestDatabase(t)
+	storeAssetChangeBlocks(t, NewSyncService("", newTestNodeClient(), database))
+
+	tx, err := database.GetTransaction("assets2")
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+	want := []AssetChange{
+		{Address: "bob", TokenID: "0d0d0d0d0d0d0d0d", Symbol: "BET", Delta: 30},
+		{Address: "alice", TokenID: "0c0c0c0c0c0c0c0c", Symbol: "ALP", Delta: -35},
+		{Address: "bob", TokenID: "0c0c0c0c0c0c0c0c", Symbol: "ALP", Delta: 20},
+		{Address: "alice", TokenID: "0d0d0d0d0d0d0d0d", Symbol: "BET", Delta: 20},
+		{Address: "carol", TokenID: "0c0c0c0c0c0c0c0c", Symbol: "ALP", Delta: 10},
+	}
+	if len(tx.AssetChanges) != len(want) {
+		t.Fatalf("Expected %d asset changes, got %+v", len(want), tx.AssetChanges)
+	}
+	for i := range want {
+		if tx.AssetChanges[i] != want[i] {
+			t.Errorf("Expected change %d to be %+v, got %+v", i, want[i], tx.AssetChanges[i])
+		}
+	}
+
+	// Every address the transaction touched sees the same changes
+	history, _ := database.GetWalletTransactions("carol", 10)
+	if len(history) != 1 || len(history[0].AssetChanges) != len(want) {
+		t.Errorf("Expected carol's history to carry the changes, got %+v", history)
+	}
+
+	if got := formatAssetChanges(assetChangesOf(tx.AssetChanges, "alice")); got != "-35 ALP; +20 BET" {
+		t.Errorf("Unexpected formatted changes %q", got)
+	}
+
+	// Netted changes cancel out
+	svc := NewSyncService("", newTestNodeClient(), database)
+	roundTrip := []TokenOperation{
+		{Type: TOKEN_TRANSFER, TokenID: "0c0c0c0c0c0c0c0c", Amount: 7, From: "alice", To: "bob"},
+		{Type: TOKEN_TRANSFER, TokenID: "0c0c0c0c0c0c0c0c", Amount: 7, From: "bob", To: "alice"},
+	}
+	if changes := svc.assetChanges(roundTrip); changes != nil {
+		t.Errorf("Expected a round trip to change nothing, got %+v", changes)
+	}
+}
+
+func TestWatchEventsFromAssetChanges(t *testing.T) {
+	changes := []AssetChange{
+		{Address: "alice", TokenID: "0c", Symbol: "ALP", Delta: -35},
+		{Address: "bob", TokenID: "0c", Symbol: "ALP", Delta: 35},
+	}
+	stored := []*WalletTransaction{
+		{TxHash: "tx", Type: "token_TRANSFER", FromAddress: "alice", ToAddress: "bob", AssetChanges: changes},
+		{TxHash: "tx", Type: "token_MELT", FromAddress: "alice", AssetChanges: changes},
+	}
+
+	events := watchEvents(&Block{Header: BlockHeader{Height: 2}}, stored)
+	if len(events) != 2 {
+		t.Fatalf("Expected one event per token change, got %+v", events)
+	}
+	if events[0].Event != "sent" || events[0].Address != "alice" || events[0].TokenSymbol != "ALP" || events[0].TokenAmount != 35 {
+		t.Errorf("Unexpected sent event %+v", events[0])
+	}
+	if events[1].Event != "received" || events[1].Address != "bob" || events[1].TokenAmount != 35 || events[1].Amount != 0 {
+		t.Errorf("Unexpected received event %+v", events[1])
+	}
+}
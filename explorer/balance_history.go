@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// The balance history records how much each block changed each address's
+// SHADOW balance (balance_delta:<address>:<height> -> BalanceDelta, height
+// zero-padded to 16 digits). It moves with the balance index, so stores and
+// rollbacks keep it current; databases indexed before it existed are
+// backfilled by a migration.
+const (
+	balanceDeltaPrefix          = "balance_delta:"
+	defaultBalanceHistoryPeriod = "day"
+)
+
+// balanceHistoryIntervals are the bucket widths the history endpoint serves
+var balanceHistoryIntervals = map[string]func(time.Time) time.Time{
+	"hour": func(t time.Time) time.Time { return t.UTC().Truncate(time.Hour) },
+	"day":  dayStart,
+	"week": func(t time.Time) time.Time {
+		day := dayStart(t)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7) // Weeks start on Monday
+	},
+}
+
+// BalanceDelta is how much one block changed an address's balance
+type BalanceDelta struct {
+	Height    uint64    `json:"height"`
+	Timestamp time.Time `json:"timestamp"`
+	Delta     int64     `json:"delta"`
+}
+
+// BalancePoint is an address's balance at the end of an interval in which
+// it changed
+type BalancePoint struct {
+	Time    time.Time `json:"time"` // Start of the interval
+	Balance int64     `json:"balance"`
+	Change  int64     `json:"change"`
+}
+
+// BalanceHistory is an address's balance over time. Intervals without
+// activity are left out: the balance carries over from the point before.
+type BalanceHistory struct {
+	Address  string         `json:"address"`
+	Interval string         `json:"interval"`
+	Balance  int64          `json:"balance"` // After the last point
+	Points   []BalancePoint `json:"points"`
+}
+
+func balanceDeltaKey(address string, height uint64) []byte {
+	return []byte(fmt.Sprintf("%s%s:%016d", balanceDeltaPrefix, address, height))
+}
+
+// adjustBalanceDelta moves the change the block at height made to
+// address's balance by delta, dropping the record once it nets to zero
+func adjustBalanceDelta(txn *badger.Txn, address string, height uint64, timestamp time.Time, delta int64) error {
+	if address == "" || delta == 0 {
+		return nil
+	}
+	key := balanceDeltaKey(address, height)
+
+	record := BalanceDelta{Height: height}
+	if err := readJSON(txn, string(key), &record); err != nil {
+		return err
+	}
+	record.Delta += delta
+	if timestamp.After(record.Timestamp) {
+		record.Timestamp = timestamp
+	}
+
+	if record.Delta == 0 {
+		return txn.Delete(key)
+	}
+	return writeJSON(txn, string(key), record)
+}
+
+// applyBalanceHistory adds (sign 1) or removes (sign -1) a transaction's
+// effect on the balance history of both parties
+func applyBalanceHistory(txn *badger.Txn, tx *WalletTransaction, sign int64) error {
+	if err := adjustBalanceDelta(txn, tx.ToAddress, tx.BlockHeight, tx.Timestamp, sign*int64(tx.Amount)); err != nil {
+		return err
+	}
+	return adjustBalanceDelta(txn, tx.FromAddress, tx.BlockHeight, tx.Timestamp, -sign*int64(tx.Amount+tx.Fee))
+}
+
+// buildBalanceHistory rebuilds the balance history from stored
+// transactions. Pruned transactions only survive as totals, so each pruned
+// address opens with one record at height 0 holding what they added up to.
+func (d *Database) buildBalanceHistory() (int, error) {
+	changed := 0
+	err := d.update(func(txn *badger.Txn) error {
+		// Drop partial entries written since the upgrade, then replay
+		stale, err := collectKeys(txn, balanceDeltaPrefix, func(string, []byte) bool { return true })
+		if err != nil {
+			return err
+		}
+		if err := deleteKeys(txn, stale); err != nil {
+			return err
+		}
+
+		var opening []BalanceDelta
+		var addresses []string
+		var txs []WalletTransaction
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		prunedPrefix := []byte(prunedAddressPrefix)
+		for it.Seek(prunedPrefix); it.ValidForPrefix(prunedPrefix); it.Next() {
+			var history prunedHistory
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &history)
+			}); err != nil {
+				continue
+			}
+			addresses = append(addresses, strings.TrimPrefix(string(it.Item().Key()), prunedAddressPrefix))
+			opening = append(opening, BalanceDelta{Timestamp: history.LastActivity, Delta: history.Balance})
+		}
+		txPrefix := []byte("tx:")
+		for it.Seek(txPrefix); it.ValidForPrefix(txPrefix); it.Next() {
+			var tx WalletTransaction
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				continue
+			}
+			txs = append(txs, tx)
+		}
+		it.Close()
+
+		for i, address := range addresses {
+			if err := adjustBalanceDelta(txn, address, 0, opening[i].Timestamp, opening[i].Delta); err != nil {
+				return err
+			}
+		}
+		for i := range txs {
+			if err := applyBalanceHistory(txn, &txs[i], 1); err != nil {
+				return err
+			}
+		}
+
+		changed = len(addresses) + len(txs)
+		return nil
+	})
+	return changed, err
+}
+
+// GetBalanceDeltas returns every change to address's balance, by block in
+// height order
+func (d *Database) GetBalanceDeltas(address string) ([]BalanceDelta, error) {
+	deltas := []BalanceDelta{}
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(balanceDeltaPrefix + address + ":")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var delta BalanceDelta
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &delta)
+			}); err != nil {
+				return err
+			}
+			deltas = append(deltas, delta)
+		}
+		return nil
+	})
+	return deltas, err
+}
+
+// balanceSeries accumulates per-block deltas into the balance at the end of
+// each interval with activity, bucketing blocks with start
+func balanceSeries(deltas []BalanceDelta, start func(time.Time) time.Time) ([]BalancePoint, int64) {
+	points := []BalancePoint{}
+	var balance int64
+	for _, delta := range deltas {
+		balance += delta.Delta
+		bucket := start(delta.Timestamp)
+		if n := len(points); n > 0 && !bucket.After(points[n-1].Time) {
+			// Block timestamps can run slightly backwards; keep the series ordered
+			points[n-1].Balance = balance
+			points[n-1].Change += delta.Delta
+			continue
+		}
+		points = append(points, BalancePoint{Time: bucket, Balance: balance, Change: delta.Delta})
+	}
+	return points, balance
+}
+
+// Wallet balance history API endpoint: the balance after every hour, day
+// (default) or week in which it changed
+func (es *ExplorerServer) handleBalanceHistory(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = defaultBalanceHistoryPeriod
+	}
+	start, ok := balanceHistoryIntervals[interval]
+	if !ok {
+		http.Error(w, "interval must be hour, day or week", http.StatusBadRequest)
+		return
+	}
+
+	deltas, err := es.database.GetBalanceDeltas(address)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get balance history", "address", address, "err", err)
+		http.Error(w, "Failed to get balance history", http.StatusInternalServerError)
+		return
+	}
+	points, balance := balanceSeries(deltas, start)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BalanceHistory{
+		Address:  address,
+		Interval: interval,
+		Balance:  balance,
+		Points:   points,
+	})
+}
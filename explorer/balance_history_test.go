@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+var historyDay = time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC) // A Monday
+
+// storeTestBalanceHistory gives alice two rewards on day one, a payment to
+// bob on day two and a refund from bob a week later
+func storeTestBalanceHistory(t *testing.T, database Store) {
+	txs := []*WalletTransaction{
+		{TxHash: "reward_1", BlockHeight: 1, Type: "mining_reward", Amount: 100, ToAddress: "alice", Timestamp: historyDay},
+		{TxHash: "reward_2", BlockHeight: 2, Type: "mining_reward", Amount: 100, ToAddress: "alice", Timestamp: historyDay.Add(time.Hour)},
+		{TxHash: "pay", BlockHeight: 3, Type: "transfer", Amount: 30, Fee: 1, FromAddress: "alice", ToAddress: "bob", Timestamp: historyDay.AddDate(0, 0, 1)},
+		{TxHash: "refund", BlockHeight: 4, Type: "transfer", Amount: 10, FromAddress: "bob", ToAddress: "alice", Timestamp: historyDay.AddDate(0, 0, 8)},
+	}
+	for _, tx := range txs {
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store transaction: %v", err)
+		}
+	}
+}
+
+// checkBalanceHistory checks the deltas stored by storeTestBalanceHistory
+func checkBalanceHistory(t *testing.T, database Store) {
+	deltas, err := database.GetBalanceDeltas("alice")
+	if err != nil {
+		t.Fatalf("GetBalanceDeltas failed: %v", err)
+	}
+	want := []int64{100, 100, -31, 10}
+	if len(deltas) != len(want) {
+		t.Fatalf("Expected %d deltas, got %+v", len(want), deltas)
+	}
+	for i, delta := range deltas {
+		if delta.Height != uint64(i+1) || delta.Delta != want[i] {
+			t.Errorf("Delta %d: expected %d at height %d, got %+v", i, want[i], i+1, delta)
+		}
+	}
+}
+
+func TestBalanceHistory(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBalanceHistory(t, database)
+	checkBalanceHistory(t, database)
+
+	// Storing a transaction again does not count it twice
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: "pay", BlockHeight: 3, Type: "transfer", Amount: 30, Fee: 1,
+		FromAddress: "alice", ToAddress: "bob", Timestamp: historyDay.AddDate(0, 0, 1)}); err != nil {
+		t.Fatalf("Failed to store transaction: %v", err)
+	}
+	checkBalanceHistory(t, database)
+
+	if _, err := database.RollbackToHeight(3); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	deltas, err := database.GetBalanceDeltas("alice")
+	if err != nil || len(deltas) != 3 {
+		t.Errorf("Expected the refund rolled back, got %+v (%v)", deltas, err)
+	}
+}
+
+func TestBalanceHistoryBackfill(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBalanceHistory(t, database)
+
+	// Forget the history, as in a database indexed before it existed
+	if err := database.update(func(txn *badger.Txn) error {
+		keys, err := collectKeys(txn, balanceDeltaPrefix, func(string, []byte) bool { return true })
+		if err != nil {
+			return err
+		}
+		return deleteKeys(txn, append(keys, []byte(balanceHistoryIndexMarker)))
+	}); err != nil {
+		t.Fatalf("Failed to drop history: %v", err)
+	}
+	if err := database.migrate(); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	checkBalanceHistory(t, database)
+}
+
+func TestBalanceSeries(t *testing.T) {
+	deltas := []BalanceDelta{
+		{Height: 1, Timestamp: historyDay, Delta: 100},
+		{Height: 2, Timestamp: historyDay.Add(time.Hour), Delta: 100},
+		{Height: 3, Timestamp: historyDay.AddDate(0, 0, 1), Delta: -31},
+		{Height: 4, Timestamp: historyDay.AddDate(0, 0, 8), Delta: 10},
+	}
+
+	days, balance := balanceSeries(deltas, balanceHistoryIntervals["day"])
+	if balance != 179 || len(days) != 3 || days[0].Balance != 200 || days[0].Change != 200 || days[1].Balance != 169 ||
+		!days[2].Time.Equal(dayStart(historyDay.AddDate(0, 0, 8))) {
+		t.Errorf("Unexpected daily series %+v", days)
+	}
+
+	weeks, _ := balanceSeries(deltas, balanceHistoryIntervals["week"])
+	if len(weeks) != 2 || !weeks[0].Time.Equal(dayStart(historyDay)) || weeks[0].Balance != 169 || weeks[1].Change != 10 {
+		t.Errorf("Unexpected weekly series %+v", weeks)
+	}
+}
+
+func TestBalanceHistoryAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBalanceHistory(t, database)
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/wallet/alice/history", nil)
+	es.handleBalanceHistory(rec, mux.SetURLVars(req, map[string]string{"address": "alice"}))
+	var history BalanceHistory
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if history.Interval != "day" || history.Balance != 179 || len(history.Points) != 3 {
+		t.Errorf("Unexpected history %+v", history)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v1/wallet/alice/history?interval=month", nil)
+	es.handleBalanceHistory(rec, mux.SetURLVars(req, map[string]string{"address": "alice"}))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown interval, got %d", rec.Code)
+	}
+}
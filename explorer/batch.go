@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	maxBatchLookups = 100
+	maxBatchBody    = 64 << 10
+)
+
+// BatchRequest lists the wallets, transactions and tokens to look up at once
+type BatchRequest struct {
+	Addresses    []string `json:"addresses"`
+	Transactions []string `json:"transactions"` // Hashes
+	Tokens       []string `json:"tokens"`       // Token IDs
+}
+
+// BatchError is a lookup of a batch that found nothing or failed
+type BatchError struct {
+	Kind  string `json:"kind"` // "address", "transaction" or "token"
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// BatchResponse holds each lookup that succeeded, keyed by what was asked
+// for, in the shape its single-item endpoint returns, and the rest under
+// errors
+type BatchResponse struct {
+	Addresses    map[string]*WalletSummary      `json:"addresses"`
+	Transactions map[string]*TransactionDetails `json:"transactions"`
+	Tokens       map[string]*TokenDetails       `json:"tokens"`
+	Errors       []BatchError                   `json:"errors"`
+}
+
+// uniqueLookups trims ids and drops blanks and repeats
+func uniqueLookups(ids []string) []string {
+	unique := []string{}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// Batch API endpoint: up to maxBatchLookups wallets, transactions and tokens
+// in one request, so clients tracking many addresses need one round trip
+func (es *ExplorerServer) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody)).Decode(&req); err != nil {
+		http.Error(w, "Expected JSON with addresses, transactions and/or tokens", http.StatusBadRequest)
+		return
+	}
+	addresses := uniqueLookups(req.Addresses)
+	hashes := uniqueLookups(req.Transactions)
+	tokenIDs := uniqueLookups(req.Tokens)
+	if n := len(addresses) + len(hashes) + len(tokenIDs); n == 0 || n > maxBatchLookups {
+		http.Error(w, fmt.Sprintf("A batch holds between 1 and %d lookups", maxBatchLookups), http.StatusBadRequest)
+		return
+	}
+
+	resp := BatchResponse{
+		Addresses:    make(map[string]*WalletSummary),
+		Transactions: make(map[string]*TransactionDetails),
+		Tokens:       make(map[string]*TokenDetails),
+		Errors:       []BatchError{},
+	}
+	labels := es.newLabeler()
+
+	for _, address := range addresses {
+		summary, err := es.database.GetWalletSummary(address)
+		if err != nil {
+			slog.Error("Batch wallet lookup failed", "address", address, "err", err)
+			resp.Errors = append(resp.Errors, BatchError{Kind: "address", ID: address, Error: "Failed to get wallet data"})
+			continue
+		}
+		es.annotateFinality(summary.Transactions)
+		labels.add(summary.Address, &summary.Label)
+		labels.addTransactions(summary.Transactions)
+		resp.Addresses[address] = summary
+	}
+
+	for _, hash := range hashes {
+		details, err := es.transactionDetails(hash)
+		if err == errNotFound {
+			resp.Errors = append(resp.Errors, BatchError{Kind: "transaction", ID: hash, Error: "Transaction not found"})
+			continue
+		}
+		if err != nil {
+			resp.Errors = append(resp.Errors, BatchError{Kind: "transaction", ID: hash, Error: "Transaction does not decode"})
+			continue
+		}
+		resp.Transactions[hash] = details
+	}
+
+	for _, tokenID := range tokenIDs {
+		details, err := es.database.GetTokenDetails(tokenID)
+		if err != nil {
+			resp.Errors = append(resp.Errors, BatchError{Kind: "token", ID: tokenID, Error: "Token not found"})
+			continue
+		}
+		for i := range details.Holders {
+			labels.add(details.Holders[i].Address, &details.Holders[i].Label)
+		}
+		for i := range details.Transactions {
+			tx := &details.Transactions[i]
+			labels.add(tx.FromAddress, &tx.FromLabel)
+			labels.add(tx.ToAddress, &tx.ToLabel)
+		}
+		resp.Tokens[tokenID] = details
+	}
+	labels.apply()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	tx := &Transaction{Timestamp: time.Unix(100, 0), Outputs: []TransactionOutput{{Address: "Lpool", Value: 100}}}
+	storeTestSwapBlock(t, database, "swap_batch", tx)
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: "reward", BlockHeight: 1, Type: "mining_reward", Amount: 50,
+		ToAddress: "alice", Timestamp: time.Unix(100, 0)}); err != nil {
+		t.Fatalf("Failed to store transaction: %v", err)
+	}
+	if err := database.StoreToken(&TokenInfo{TokenID: "70ce", Name: "Gold", Ticker: "GOLD"}); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleBatch(rec, httptest.NewRequest("POST", "/api/v1/batch", strings.NewReader(
+		`{"addresses":["alice","alice "],"transactions":["swap_batch","missing"],"tokens":["70ce","nope"]}`)))
+	var resp BatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Addresses) != 1 || resp.Addresses["alice"] == nil || resp.Addresses["alice"].Balance != 50 {
+		t.Errorf("Expected alice's wallet once, got %+v", resp.Addresses)
+	}
+	if details := resp.Transactions["swap_batch"]; details == nil || details.AMMRoute == nil {
+		t.Errorf("Expected the swap with its route, got %+v", resp.Transactions)
+	}
+	if token := resp.Tokens["70ce"]; token == nil || token.Ticker != "GOLD" {
+		t.Errorf("Expected the token, got %+v", resp.Tokens)
+	}
+	if len(resp.Errors) != 2 || resp.Errors[0].Kind != "transaction" || resp.Errors[1].ID != "nope" {
+		t.Errorf("Expected the missing transaction and token reported, got %+v", resp.Errors)
+	}
+
+	tooMany := make([]string, maxBatchLookups+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("%q", fmt.Sprintf("addr%d", i))
+	}
+	for _, body := range []string{"not json", `{}`, `{"addresses":[` + strings.Join(tooMany, ",") + `]}`} {
+		rec = httptest.NewRecorder()
+		es.handleBatch(rec, httptest.NewRequest("POST", "/api/v1/batch", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %.20s, got %d", body, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultMaxBlockSize is Tendermint's default limit on block bytes, which
+// the node does not override
+const defaultMaxBlockSize = 22020096
+
+// maxBlockSizeFromEnv reads the block size limit fullness is measured
+// against from EXPLORER_MAX_BLOCK_SIZE, in bytes
+func maxBlockSizeFromEnv() int {
+	value := os.Getenv("EXPLORER_MAX_BLOCK_SIZE")
+	if value == "" {
+		return defaultMaxBlockSize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		slog.Warn("Ignoring invalid EXPLORER_MAX_BLOCK_SIZE", "value", value, "default", defaultMaxBlockSize)
+		return defaultMaxBlockSize
+	}
+	return size
+}
+
+// blockSizeLimit is the block size limit new blocks are measured against
+func (s *SyncService) blockSizeLimit() int {
+	if s == nil || s.maxBlockSize <= 0 {
+		return defaultMaxBlockSize
+	}
+	return s.maxBlockSize
+}
+
+// blockSizes measures a block as it is stored, and the transactions in it
+// that paid fees (those in paying, by hash) as serialized in the block
+func blockSizes(block *Block, paying map[string]uint64) (size, txBytes int) {
+	if block.Body.Pruned {
+		return block.Body.PrunedSize, 0
+	}
+	if data, err := json.Marshal(block); err == nil {
+		size = len(data)
+	}
+	for i := range block.Body.Transactions {
+		tx := &block.Body.Transactions[i]
+		if _, ok := paying[tx.TxHash]; !ok {
+			continue
+		}
+		if data, err := json.Marshal(tx); err == nil {
+			txBytes += len(data)
+		}
+	}
+	return size, txBytes
+}
+
+// blockFullness lays rollups out as one fullness point per bucket, for the
+// given number of buckets ending with the one containing now
+func blockFullness(granularity string, buckets int, now time.Time, rollups []ChartRollup) BlockFullness {
+	byStart := make(map[int64]*ChartRollup, len(rollups))
+	for i := range rollups {
+		byStart[rollups[i].Start.Unix()] = &rollups[i]
+	}
+
+	first := addChartBuckets(granularity, chartBucketStart(granularity, now), -(buckets - 1))
+	fullness := BlockFullness{Granularity: granularity, Points: make([]BlockFullnessPoint, buckets)}
+	for i := range fullness.Points {
+		point := &fullness.Points[i]
+		point.Time = addChartBuckets(granularity, first, i)
+
+		rollup, ok := byStart[point.Time.Unix()]
+		if !ok {
+			continue
+		}
+		point.Blocks = rollup.Blocks
+		point.Transactions = rollup.Transactions
+		if rollup.SizedBlocks > 0 {
+			avg := float64(rollup.Bytes) / float64(rollup.SizedBlocks)
+			point.AvgSize = &avg
+		}
+		if rollup.MaxBytes > 0 {
+			used := float64(rollup.Bytes) / float64(rollup.MaxBytes)
+			point.Fullness = &used
+		}
+		if rollup.TxBytes > 0 {
+			perByte := float64(rollup.TxBytesFees) / float64(rollup.TxBytes)
+			point.FeePerByte = &perByte
+		}
+	}
+	return fullness
+}
+
+// transactionHeatmap counts transactions by UTC weekday and hour
+func transactionHeatmap(hours []ChartRollup) [7][24]int {
+	var heatmap [7][24]int
+	for _, rollup := range hours {
+		start := rollup.Start.UTC()
+		heatmap[start.Weekday()][start.Hour()] += rollup.Transactions
+	}
+	return heatmap
+}
+
+// Block fullness endpoint: block sizes against the size limit and fee per
+// byte over the requested range, with the last week's transaction heatmap
+func (es *ExplorerServer) handleBlockFullness(w http.ResponseWriter, r *http.Request) {
+	granularity, buckets, err := parseChartRange(r.URL.Query().Get("range"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	first := addChartBuckets(granularity, chartBucketStart(granularity, now), -(buckets - 1))
+	rollups, err := es.database.GetChartRollups(granularity, first, now)
+	if err != nil {
+		http.Error(w, "Failed to get chart data", http.StatusInternalServerError)
+		return
+	}
+	weekStart := addChartBuckets(chartHour, chartBucketStart(chartHour, now), -(maxChartHours - 1))
+	hours, err := es.database.GetChartRollups(chartHour, weekStart, now)
+	if err != nil {
+		http.Error(w, "Failed to get chart data", http.StatusInternalServerError)
+		return
+	}
+
+	fullness := blockFullness(granularity, buckets, now, rollups)
+	fullness.MaxBlockSize = es.syncService.blockSizeLimit()
+	fullness.Heatmap = transactionHeatmap(hours)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fullness)
+}
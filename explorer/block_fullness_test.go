@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBlockSizes(t *testing.T) {
+	block := &Block{Body: BlockBody{Transactions: []SignedTransaction{
+		{TxHash: "reward", Transaction: json.RawMessage(`{"outputs":[{"address":"farmer","value":5}]}`)},
+		{TxHash: "paid", Transaction: json.RawMessage(`{"outputs":[{"address":"bob","value":1}]}`), Signature: "sig"},
+	}}}
+	stored, _ := json.Marshal(block)
+	paid, _ := json.Marshal(&block.Body.Transactions[1])
+
+	size, txBytes := blockSizes(block, map[string]uint64{"paid": 10})
+	if size != len(stored) || txBytes != len(paid) {
+		t.Errorf("Expected %d/%d bytes, got %d/%d", len(stored), len(paid), size, txBytes)
+	}
+
+	block.Body = BlockBody{Pruned: true, PrunedSize: 4096}
+	if size, txBytes := blockSizes(block, nil); size != 4096 || txBytes != 0 {
+		t.Errorf("Expected a pruned block's original size, got %d/%d", size, txBytes)
+	}
+}
+
+func TestBlockFullnessAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	now := time.Now().UTC()
+	samples := []*ChartSample{
+		{Height: 1, Timestamp: now, Transactions: 1, Fees: 500, Addresses: []string{}}, // Synced before sizes were kept
+		{Height: 2, Timestamp: now, Transactions: 2, Fees: 1000, Addresses: []string{}, Size: 1000, MaxSize: 4000, TxBytes: 200},
+	}
+	for _, sample := range append(samples, samples[1]) {
+		if err := database.RecordChartSample(sample); err != nil {
+			t.Fatalf("RecordChartSample failed: %v", err)
+		}
+	}
+
+	es := &ExplorerServer{database: database}
+	rec := httptest.NewRecorder()
+	es.handleBlockFullness(rec, httptest.NewRequest("GET", "/api/v1/charts/block-fullness?range=2h", nil))
+	var fullness BlockFullness
+	if err := json.NewDecoder(rec.Body).Decode(&fullness); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if fullness.Granularity != chartHour || len(fullness.Points) != 2 || fullness.MaxBlockSize != defaultMaxBlockSize {
+		t.Fatalf("Unexpected response %+v", fullness)
+	}
+	if empty := fullness.Points[0]; empty.Blocks != 0 || empty.Fullness != nil || empty.FeePerByte != nil {
+		t.Errorf("Expected the previous hour empty, got %+v", empty)
+	}
+	last := fullness.Points[1]
+	if last.Blocks != 2 || last.Transactions != 3 || last.AvgSize == nil || *last.AvgSize != 1000 ||
+		last.Fullness == nil || *last.Fullness != 0.25 || last.FeePerByte == nil || *last.FeePerByte != 5 {
+		t.Errorf("Expected a quarter-full hour at 5 per byte, got %+v", last)
+	}
+	if cell := fullness.Heatmap[now.Weekday()][now.Hour()]; cell != 3 {
+		t.Errorf("Expected 3 transactions in the current heatmap cell, got %d", cell)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleBlockFullness(rec, httptest.NewRequest("GET", "/api/v1/charts/block-fullness?range=2w", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid range rejected, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// chainPathPrefix is where each chain's routes are served, as
+// /chain/{id}/...
+const chainPathPrefix = "/chain/"
+
+// chainIDPattern bounds chain IDs, which name URL paths, Badger
+// directories and Postgres schemas
+var chainIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// ChainConfig names a chain the explorer serves and the nodes it syncs from
+type ChainConfig struct {
+	ID      string `json:"id"`       // e.g. testnet0
+	NodeURL string `json:"node_url"` // Comma-separated to fail over between nodes
+	APIURL  string `json:"api_url"`  // Node REST API for the mempool; none leaves it off
+}
+
+// ChainInfo describes one chain served by the explorer
+type ChainInfo struct {
+	ID      string `json:"id"`
+	Path    string `json:"path"`    // URL prefix of its pages and API
+	Primary bool   `json:"primary"` // Also served at the root
+	SyncLag
+}
+
+// parseChains reads chains written as id=node[,node...], separated by
+// semicolons since node lists use commas
+func parseChains(value string) ([]ChainConfig, error) {
+	var chains []ChainConfig
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		id, nodes, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid chain %q, want id=node_url", item)
+		}
+		chains = append(chains, ChainConfig{ID: strings.TrimSpace(id), NodeURL: strings.TrimSpace(nodes)})
+	}
+	return chains, nil
+}
+
+// validateChains checks chain IDs are well-formed and unique and that
+// every chain has a node
+func validateChains(chains []ChainConfig) error {
+	seen := make(map[string]bool)
+	for i := range chains {
+		chain := &chains[i]
+		if !chainIDPattern.MatchString(chain.ID) {
+			return fmt.Errorf("invalid chain id %q", chain.ID)
+		}
+		if seen[chain.ID] {
+			return fmt.Errorf("chain %q is listed twice", chain.ID)
+		}
+		seen[chain.ID] = true
+		nodes := parseNodeURLs(chain.NodeURL)
+		if len(nodes) == 0 {
+			return fmt.Errorf("chain %q has no node_url", chain.ID)
+		}
+		chain.NodeURL = strings.Join(nodes, ",")
+	}
+	return nil
+}
+
+// forChain is the config of a chain's pages, which are served under
+// /chain/{id} below the base path
+func (c *ServerConfig) forChain(id string) *ServerConfig {
+	chain := *c
+	chain.chainPrefix = chainPathPrefix + id
+	chain.BasePath = c.BasePath + chain.chainPrefix
+	if chain.PublicURL != "" {
+		chain.PublicURL += chainPathPrefix + id
+	}
+	return &chain
+}
+
+// chainSchema is the Postgres schema holding a chain's tables
+func chainSchema(id string) string {
+	return "chain_" + strings.ReplaceAll(id, "-", "_")
+}
+
+// openChainStoreFromEnv opens the store of a chain other than the primary
+// one, in its own keyspace beside the primary store: the Badger directory
+// EXPLORER_DB_PATH-<id>, or the chain_<id> schema of the Postgres database.
+// A store per chain, rather than chain-prefixed keys in one store, leaves
+// the primary chain's index as it was.
+func openChainStoreFromEnv(id string) (Store, error) {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("EXPLORER_DB_BACKEND")))
+	switch backend {
+	case "", "badger":
+		dbPath := os.Getenv("EXPLORER_DB_PATH")
+		if dbPath == "" {
+			dbPath = defaultBadgerPath
+		}
+		return openDatabase(strings.TrimSuffix(dbPath, "/") + "-" + id), nil
+
+	case "postgres", "postgresql":
+		dsn := os.Getenv("EXPLORER_POSTGRES_DSN")
+		if dsn == "" {
+			return nil, fmt.Errorf("EXPLORER_POSTGRES_DSN is required for the postgres backend")
+		}
+		return newPostgresChainStore(dsn, id)
+	}
+	return nil, fmt.Errorf("unknown EXPLORER_DB_BACKEND %q (want badger or postgres)", backend)
+}
+
+// newPostgresChainStore opens the store of a chain in its own schema of
+// the database at dsn, creating the schema if needed
+func newPostgresChainStore(dsn, id string) (*PostgresStore, error) {
+	schema := chainSchema(id)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+	_, err = db.Exec(`CREATE SCHEMA IF NOT EXISTS ` + schema)
+	db.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema %s: %w", schema, err)
+	}
+
+	schemaDSN, err := postgresSchemaDSN(dsn, schema)
+	if err != nil {
+		return nil, err
+	}
+	return NewPostgresStore(schemaDSN)
+}
+
+// postgresSchemaDSN points every connection made from dsn at schema, for
+// both URL and key=value DSNs
+func postgresSchemaDSN(dsn, schema string) (string, error) {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " search_path=" + schema, nil
+	}
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid EXPLORER_POSTGRES_DSN: %w", err)
+	}
+	query := parsed.Query()
+	query.Set("search_path", schema)
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// chainHandler serves every chain's routes under /chain/{id}, and the
+// primary chain's, given as primary, at the root as well. Each chain's
+// pages link below its own base path, see forChain.
+func (es *ExplorerServer) chainHandler(primary http.Handler) http.Handler {
+	routers := make(map[string]http.Handler, len(es.chains))
+	for _, chain := range es.chains {
+		if chain == es {
+			routers[chain.chainID] = primary
+		} else {
+			routers[chain.chainID] = chain.routes()
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, chainPathPrefix)
+		if !ok {
+			primary.ServeHTTP(w, r)
+			return
+		}
+		id, _, _ := strings.Cut(rest, "/")
+		router, found := routers[id]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		prefix := chainPathPrefix + id
+		if r.URL.Path == prefix {
+			http.Redirect(w, r, es.config.pagePath(prefix+"/"), http.StatusMovedPermanently)
+			return
+		}
+		http.StripPrefix(prefix, router).ServeHTTP(w, r)
+	})
+}
+
+// chainIDs lists the chains served, the primary first; nil for a single
+// chain
+func (es *ExplorerServer) chainIDs() []string {
+	var ids []string
+	for _, chain := range es.chains {
+		ids = append(ids, chain.chainID)
+	}
+	return ids
+}
+
+// Chains API endpoint: every chain served with how far its index trails
+// its node; empty for a single-chain explorer
+func (es *ExplorerServer) handleChains(w http.ResponseWriter, r *http.Request) {
+	chains := []ChainInfo{}
+	for i, chain := range es.chains {
+		info := ChainInfo{ID: chain.chainID, Path: chainPathPrefix + chain.chainID, Primary: i == 0}
+		if chain.syncService != nil {
+			info.SyncLag = chain.syncService.Lag()
+		}
+		chains = append(chains, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"chain_id": es.chainID, "chains": chains})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadServerConfigChains(t *testing.T) {
+	t.Setenv("EXPLORER_CHAINS", "testnet0=http://a:26657, http://b:26657/ ; mainnet=http://c:26657")
+	config, err := loadServerConfig(nil)
+	if err != nil {
+		t.Fatalf("loadServerConfig failed: %v", err)
+	}
+	if len(config.Chains) != 2 || config.Chains[0].ID != "testnet0" || config.Chains[0].NodeURL != "http://a:26657,http://b:26657" ||
+		config.Chains[1].ID != "mainnet" || config.Chains[1].NodeURL != "http://c:26657" {
+		t.Errorf("Unexpected chains %+v", config.Chains)
+	}
+
+	t.Setenv("EXPLORER_CHAINS", "")
+	for _, chains := range []string{"Main Net=http://a", "mainnet", "mainnet=", "a=http://a;a=http://b"} {
+		if _, err := loadServerConfig([]string{"-chains", chains}); err == nil {
+			t.Errorf("Expected chains %q to be rejected", chains)
+		}
+	}
+}
+
+func TestPostgresSchemaDSN(t *testing.T) {
+	for dsn, want := range map[string]string{
+		"postgres://explorer@db/explorer?sslmode=disable": "postgres://explorer@db/explorer?search_path=chain_main_net&sslmode=disable",
+		"host=db dbname=explorer":                         "host=db dbname=explorer search_path=chain_main_net",
+	} {
+		if got, err := postgresSchemaDSN(dsn, chainSchema("main-net")); err != nil || got != want {
+			t.Errorf("Expected %s, got %s (%v)", want, got, err)
+		}
+	}
+}
+
+func TestChainHandlerServesEachChain(t *testing.T) {
+	config := &ServerConfig{}
+	if err := config.validate(); err != nil {
+		t.Fatal(err)
+	}
+	testnet := NewExplorerServer("http://testnet", newTestDatabase(t), nil)
+	testnet.chainID, testnet.config = "testnet0", config
+	mainnet := NewExplorerServer("http://mainnet", newTestDatabase(t), nil)
+	mainnet.chainID, mainnet.config = "mainnet", config.forChain("mainnet")
+	chains := []*ExplorerServer{testnet, mainnet}
+	testnet.chains, mainnet.chains = chains, chains
+	handler := testnet.chainHandler(testnet.routes())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+	chainOf := func(path string) string {
+		var response map[string]interface{}
+		json.NewDecoder(get(path).Body).Decode(&response)
+		id, _ := response["chain_id"].(string)
+		return id
+	}
+
+	if id := chainOf("/api/v1/config"); id != "testnet0" {
+		t.Errorf("Expected the root to serve the primary chain, got %q", id)
+	}
+	if id := chainOf("/chain/testnet0/api/v1/config"); id != "testnet0" {
+		t.Errorf("Expected the primary chain under its prefix too, got %q", id)
+	}
+	if id := chainOf("/chain/mainnet/api/v1/config"); id != "mainnet" {
+		t.Errorf("Expected mainnet under its prefix, got %q", id)
+	}
+
+	var listing struct {
+		Chains []ChainInfo `json:"chains"`
+	}
+	json.NewDecoder(get("/chain/mainnet/api/v1/chains").Body).Decode(&listing)
+	if len(listing.Chains) != 2 || !listing.Chains[0].Primary || listing.Chains[1].Path != "/chain/mainnet" {
+		t.Errorf("Unexpected chain listing %+v", listing.Chains)
+	}
+
+	if rec := get("/chain/mainnet"); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/chain/mainnet/" {
+		t.Errorf("Expected a redirect to the chain root, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := get("/chain/devnet/"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown chain to 404, got %d", rec.Code)
+	}
+
+	body := get("/chain/mainnet/blocks").Body.String()
+	for _, link := range []string{`href="/chain/mainnet/blocks"`, `href="/chain/mainnet/static/css/explorer.css"`, `href="/chain/testnet0/"`} {
+		if !strings.Contains(body, link) {
+			t.Errorf("Expected %s in mainnet's blocks page", link)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// Chart rollups aggregate every synced block into hourly and daily buckets
+// (chart:<granularity>:<bucket unix> -> ChartRollup). Each block's own
+// contribution is kept as well (chart_sample:<height> -> ChartSample) so a
+// re-synced or rolled back block can be subtracted again, and distinct
+// active addresses are reference counted per bucket
+// (chart_addr:<granularity>:<bucket unix>:<address> -> big-endian count).
+const (
+	chartSamplePrefix = "chart_sample:"
+	chartRollupPrefix = "chart:"
+	chartAddrPrefix   = "chart_addr:"
+
+	chartHour = "hour"
+	chartDay  = "day"
+
+	defaultChartRange = "30d"
+	maxChartHours     = 168
+	maxChartDays      = 365
+
+	// Netspace readings only describe blocks produced around the time they
+	// were taken, so blocks indexed during catch-up carry none
+	netspaceSampleWindow = 10 * time.Minute
+)
+
+var chartGranularities = []string{chartHour, chartDay}
+
+// chartMetrics read a metric's value from a rollup, reporting false when
+// the bucket has no data for it
+var chartMetrics = map[string]func(r *ChartRollup) (float64, bool){
+	"block-interval": func(r *ChartRollup) (float64, bool) {
+		if r.Intervals == 0 {
+			return 0, false
+		}
+		return r.IntervalSeconds / float64(r.Intervals), true
+	},
+	"transactions": func(r *ChartRollup) (float64, bool) {
+		return float64(r.Transactions), true
+	},
+	"fees": func(r *ChartRollup) (float64, bool) {
+		return float64(r.Fees), true
+	},
+	"netspace": func(r *ChartRollup) (float64, bool) {
+		if r.NetspaceSamples == 0 {
+			return 0, false
+		}
+		return float64(r.NetspaceSum) / float64(r.NetspaceSamples), true
+	},
+	"active-addresses": func(r *ChartRollup) (float64, bool) {
+		return float64(r.ActiveAddresses), true
+	},
+}
+
+// sampleNetspace records the tracker's netspace, as last fetched, for the
+// blocks synced in this cycle
+func (s *SyncService) sampleNetspace() {
+	if s.tracker == nil {
+		return
+	}
+	snapshot, stale := s.tracker.Snapshot()
+	if !stale && snapshot.Stats.TotalNetspace > 0 {
+		s.netspace = snapshot.Stats.TotalNetspace
+		s.netspaceAt = snapshot.FetchedAt
+	}
+}
+
+// chartSample summarizes a synced block and the transactions stored from it
+func (s *SyncService) chartSample(block *Block, stored []*WalletTransaction) *ChartSample {
+	sample := &ChartSample{
+		Height:    block.Header.Height,
+		Timestamp: block.Header.Timestamp.UTC(),
+		Addresses: []string{},
+	}
+
+	if block.Header.Height > 0 {
+		if parent, err := s.database.GetBlockByHeight(block.Header.Height - 1); err == nil {
+			if interval := block.Header.Timestamp.Sub(parent.Header.Timestamp).Seconds(); interval >= 0 {
+				sample.IntervalSeconds = interval
+				sample.HasInterval = true
+			}
+		}
+	}
+
+	// Outputs of one transaction share its hash; count and charge it once
+	fees := make(map[string]uint64)
+	addresses := make(map[string]bool)
+	for _, tx := range stored {
+		for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+			if address != "" {
+				addresses[address] = true
+			}
+		}
+		if tx.Type != "mining_reward" {
+			fees[tx.TxHash] = tx.Fee
+		}
+	}
+	sample.Transactions = len(fees)
+	for _, fee := range fees {
+		sample.Fees += fee
+	}
+	for address := range addresses {
+		sample.Addresses = append(sample.Addresses, address)
+	}
+	sort.Strings(sample.Addresses)
+	sample.Size, sample.TxBytes = blockSizes(block, fees)
+	sample.MaxSize = s.blockSizeLimit()
+
+	if s.netspace > 0 && time.Since(s.netspaceAt) < netspaceSampleWindow &&
+		absDuration(time.Since(sample.Timestamp)) < netspaceSampleWindow {
+		sample.Netspace = s.netspace
+	}
+	return sample
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// chartBucketStart truncates t to the start of its hour or UTC day
+func chartBucketStart(granularity string, t time.Time) time.Time {
+	if granularity == chartHour {
+		return t.UTC().Truncate(time.Hour)
+	}
+	return dayStart(t)
+}
+
+// addChartBuckets moves start forward by n buckets
+func addChartBuckets(granularity string, start time.Time, n int) time.Time {
+	if granularity == chartHour {
+		return start.Add(time.Duration(n) * time.Hour)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// apply adds (sign 1) or removes (sign -1) a block's contribution, except
+// for active addresses, which the stores count per bucket
+func (r *ChartRollup) apply(sample *ChartSample, sign int) {
+	r.Blocks += sign
+	if sample.HasInterval {
+		r.IntervalSeconds += float64(sign) * sample.IntervalSeconds
+		r.Intervals += sign
+	}
+	r.Transactions += sign * sample.Transactions
+	if sample.Netspace > 0 {
+		r.NetspaceSamples += sign
+	}
+	if sample.MaxSize > 0 {
+		r.SizedBlocks += sign
+	}
+	var txBytesFees uint64
+	if sample.TxBytes > 0 {
+		txBytesFees = sample.Fees
+	}
+	if sign > 0 {
+		r.Fees += sample.Fees
+		r.NetspaceSum += sample.Netspace
+		r.Bytes += uint64(sample.Size)
+		r.MaxBytes += uint64(sample.MaxSize)
+		r.TxBytes += uint64(sample.TxBytes)
+		r.TxBytesFees += txBytesFees
+	} else {
+		r.Fees -= sample.Fees
+		r.NetspaceSum -= sample.Netspace
+		r.Bytes -= uint64(sample.Size)
+		r.MaxBytes -= uint64(sample.MaxSize)
+		r.TxBytes -= uint64(sample.TxBytes)
+		r.TxBytesFees -= txBytesFees
+	}
+}
+
+func chartRollupKey(granularity string, start time.Time) []byte {
+	return []byte(fmt.Sprintf("%s%s:%016d", chartRollupPrefix, granularity, start.Unix()))
+}
+
+func chartAddrKey(granularity string, start time.Time, address string) []byte {
+	return []byte(fmt.Sprintf("%s%s:%016d:%s", chartAddrPrefix, granularity, start.Unix(), address))
+}
+
+// applyChartSample adds or removes a block's contribution to the rollups
+// of every granularity
+func applyChartSample(txn *badger.Txn, sample *ChartSample, sign int) error {
+	for _, granularity := range chartGranularities {
+		start := chartBucketStart(granularity, sample.Timestamp)
+		key := chartRollupKey(granularity, start)
+
+		rollup := ChartRollup{Start: start}
+		item, err := txn.Get(key)
+		switch {
+		case err == badger.ErrKeyNotFound:
+		case err != nil:
+			return err
+		default:
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &rollup)
+			}); err != nil {
+				return err
+			}
+		}
+		rollup.apply(sample, sign)
+
+		for _, address := range sample.Addresses {
+			addrKey := chartAddrKey(granularity, start, address)
+			var refs int64
+			item, err := txn.Get(addrKey)
+			switch {
+			case err == badger.ErrKeyNotFound:
+			case err != nil:
+				return err
+			default:
+				if err := item.Value(func(val []byte) error {
+					if len(val) == 8 {
+						refs = int64(binary.BigEndian.Uint64(val))
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+
+			before := refs
+			refs += int64(sign)
+			if before <= 0 && refs > 0 {
+				rollup.ActiveAddresses++
+			} else if before > 0 && refs <= 0 {
+				rollup.ActiveAddresses--
+			}
+
+			if refs <= 0 {
+				err = txn.Delete(addrKey)
+			} else {
+				value := make([]byte, 8)
+				binary.BigEndian.PutUint64(value, uint64(refs))
+				err = txn.Set(addrKey, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if rollup.Blocks <= 0 {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := json.Marshal(rollup)
+		if err != nil {
+			return err
+		}
+		if err := txn.Set(key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordChartSample folds a block into the chart rollups, replacing what
+// an earlier sync of the same height contributed
+func (d *Database) RecordChartSample(sample *ChartSample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chart sample: %w", err)
+	}
+
+	return d.update(func(txn *badger.Txn) error {
+		key := []byte(fmt.Sprintf("%s%016d", chartSamplePrefix, sample.Height))
+		item, err := txn.Get(key)
+		switch {
+		case err == badger.ErrKeyNotFound:
+		case err != nil:
+			return err
+		default:
+			var previous ChartSample
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &previous)
+			}); err != nil {
+				return err
+			}
+			if err := applyChartSample(txn, &previous, -1); err != nil {
+				return err
+			}
+		}
+
+		if err := applyChartSample(txn, sample, 1); err != nil {
+			return err
+		}
+		return txn.Set(key, data)
+	})
+}
+
+// removeChartSample subtracts the block at height from the chart rollups
+// and forgets its sample
+func removeChartSample(txn *badger.Txn, height uint64) error {
+	key := []byte(fmt.Sprintf("%s%016d", chartSamplePrefix, height))
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var sample ChartSample
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &sample)
+	}); err != nil {
+		return err
+	}
+	if err := applyChartSample(txn, &sample, -1); err != nil {
+		return err
+	}
+	return txn.Delete(key)
+}
+
+// GetChartRollups returns the rollups of a granularity whose buckets start
+// between from and to inclusive, oldest first
+func (d *Database) GetChartRollups(granularity string, from, to time.Time) ([]ChartRollup, error) {
+	var rollups []ChartRollup
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(chartRollupPrefix + granularity + ":")
+		last := string(chartRollupKey(granularity, to))
+		for it.Seek(chartRollupKey(granularity, from)); it.ValidForPrefix(prefix); it.Next() {
+			if string(it.Item().Key()) > last {
+				break
+			}
+			var rollup ChartRollup
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &rollup)
+			}); err != nil {
+				return err
+			}
+			rollups = append(rollups, rollup)
+		}
+		return nil
+	})
+	return rollups, err
+}
+
+// parseChartRange parses a range such as "24h" (hourly buckets) or "30d"
+// (daily buckets) into a granularity and a number of buckets
+func parseChartRange(value string) (string, int, error) {
+	if value == "" {
+		value = defaultChartRange
+	}
+
+	granularity, limit := chartDay, maxChartDays
+	if strings.HasSuffix(value, "h") {
+		granularity, limit = chartHour, maxChartHours
+	} else if !strings.HasSuffix(value, "d") {
+		return "", 0, fmt.Errorf("range must be hours (1h-%dh) or days (1d-%dd)", maxChartHours, maxChartDays)
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 1 || n > limit {
+		return "", 0, fmt.Errorf("range must be hours (1h-%dh) or days (1d-%dd)", maxChartHours, maxChartDays)
+	}
+	return granularity, n, nil
+}
+
+// chartSeries lays rollups out as one point per bucket, for the given
+// number of buckets ending with the one containing now
+func chartSeries(metric, granularity string, buckets int, now time.Time, rollups []ChartRollup) ChartSeries {
+	byStart := make(map[int64]*ChartRollup, len(rollups))
+	for i := range rollups {
+		byStart[rollups[i].Start.Unix()] = &rollups[i]
+	}
+
+	value := chartMetrics[metric]
+	first := addChartBuckets(granularity, chartBucketStart(granularity, now), -(buckets - 1))
+	series := ChartSeries{Metric: metric, Granularity: granularity, Points: make([]ChartPoint, buckets)}
+	for i := range series.Points {
+		start := addChartBuckets(granularity, first, i)
+		series.Points[i].Time = start
+
+		rollup, ok := byStart[start.Unix()]
+		if !ok {
+			rollup = &ChartRollup{Start: start}
+		}
+		if v, ok := value(rollup); ok {
+			series.Points[i].Value = &v
+		}
+	}
+	return series
+}
+
+// Chart endpoint: one metric per hour or day over the requested range
+func (es *ExplorerServer) handleChartSeries(w http.ResponseWriter, r *http.Request) {
+	metric := mux.Vars(r)["metric"]
+	if _, ok := chartMetrics[metric]; !ok {
+		http.Error(w, "Unknown metric (want block-interval, transactions, fees, netspace or active-addresses)", http.StatusNotFound)
+		return
+	}
+
+	granularity, buckets, err := parseChartRange(r.URL.Query().Get("range"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	first := addChartBuckets(granularity, chartBucketStart(granularity, now), -(buckets - 1))
+	rollups, err := es.database.GetChartRollups(granularity, first, now)
+	if err != nil {
+		http.Error(w, "Failed to get chart data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chartSeries(metric, granularity, buckets, now, rollups))
+}
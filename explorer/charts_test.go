@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestChartRollupsAggregateSamples(t *testing.T) {
+	database := newTestDatabase(t)
+	hour := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	samples := []*ChartSample{
+		{Height: 1, Timestamp: hour.Add(5 * time.Minute), Transactions: 1, Fees: 10, Addresses: []string{"alice", "bob"}},
+		{Height: 2, Timestamp: hour.Add(35 * time.Minute), IntervalSeconds: 1800, HasInterval: true,
+			Transactions: 2, Fees: 30, Addresses: []string{"alice"}, Netspace: 1000},
+		{Height: 3, Timestamp: hour.Add(65 * time.Minute), IntervalSeconds: 1800, HasInterval: true,
+			Addresses: []string{"carol"}, Netspace: 3000},
+	}
+	for _, sample := range samples {
+		if err := database.RecordChartSample(sample); err != nil {
+			t.Fatalf("RecordChartSample failed: %v", err)
+		}
+	}
+	// Re-syncing a block replaces its contribution instead of adding to it
+	if err := database.RecordChartSample(samples[1]); err != nil {
+		t.Fatalf("RecordChartSample failed: %v", err)
+	}
+
+	hours, err := database.GetChartRollups(chartHour, hour, hour.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetChartRollups failed: %v", err)
+	}
+	if len(hours) != 2 {
+		t.Fatalf("Expected 2 hourly rollups, got %+v", hours)
+	}
+	if h := hours[0]; h.Blocks != 2 || h.Transactions != 3 || h.Fees != 40 || h.ActiveAddresses != 2 || h.Intervals != 1 {
+		t.Errorf("Unexpected first hour %+v", h)
+	}
+
+	days, err := database.GetChartRollups(chartDay, hour.Truncate(24*time.Hour), hour)
+	if err != nil || len(days) != 1 {
+		t.Fatalf("Expected 1 daily rollup, got %+v (%v)", days, err)
+	}
+	day := days[0]
+	if day.Blocks != 3 || day.ActiveAddresses != 3 || day.NetspaceSamples != 2 || day.NetspaceSum != 4000 {
+		t.Errorf("Unexpected day %+v", day)
+	}
+	if v, ok := chartMetrics["block-interval"](&day); !ok || v != 1800 {
+		t.Errorf("Expected a 1800s average interval, got %v", v)
+	}
+
+	// Rolling back to height 1 leaves only the first block
+	if _, err := database.RollbackToHeight(1); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	days, _ = database.GetChartRollups(chartDay, hour.Truncate(24*time.Hour), hour)
+	if len(days) != 1 || days[0].Blocks != 1 || days[0].ActiveAddresses != 2 || days[0].NetspaceSamples != 0 {
+		t.Errorf("Unexpected day after rollback %+v", days)
+	}
+	if hours, _ := database.GetChartRollups(chartHour, hour, hour.Add(time.Hour)); len(hours) != 1 {
+		t.Errorf("Expected the second hour to be dropped, got %+v", hours)
+	}
+}
+
+func TestSyncRecordsChartSamples(t *testing.T) {
+	node, _ := newFakeNode(t, 3)
+	database := newTestDatabase(t)
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+	svc.syncOnce()
+
+	genesis := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	days, err := database.GetChartRollups(chartDay, genesis.AddDate(0, 0, -1), genesis.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetChartRollups failed: %v", err)
+	}
+	// Block 1 has no stored parent, so only blocks 2 and 3 carry an interval
+	if len(days) != 1 || days[0].Blocks != 3 || days[0].Intervals != 2 {
+		t.Errorf("Expected blocks 1-3 in one daily rollup, got %+v", days)
+	}
+}
+
+func TestParseChartRange(t *testing.T) {
+	for value, want := range map[string]string{"": "day/30", "24h": "hour/24", "7d": "day/7"} {
+		granularity, n, err := parseChartRange(value)
+		if err != nil || fmt.Sprintf("%s/%d", granularity, n) != want {
+			t.Errorf("parseChartRange(%q) = %s/%d (%v), want %s", value, granularity, n, err, want)
+		}
+	}
+	for _, value := range []string{"0d", "169h", "366d", "2w", "h"} {
+		if _, _, err := parseChartRange(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestChartSeriesAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	now := time.Now().UTC()
+	if err := database.RecordChartSample(&ChartSample{Height: 1, Timestamp: now, Transactions: 4,
+		Addresses: []string{"alice"}}); err != nil {
+		t.Fatalf("RecordChartSample failed: %v", err)
+	}
+	es := &ExplorerServer{database: database}
+	get := func(metric, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/charts/"+metric+query, nil)
+		es.handleChartSeries(rec, mux.SetURLVars(req, map[string]string{"metric": metric}))
+		return rec
+	}
+
+	rec := get("transactions", "?range=3h")
+	var series ChartSeries
+	if err := json.NewDecoder(rec.Body).Decode(&series); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if series.Granularity != chartHour || len(series.Points) != 3 {
+		t.Fatalf("Unexpected series %+v", series)
+	}
+	if last := series.Points[2]; last.Value == nil || *last.Value != 4 {
+		t.Errorf("Expected 4 transactions in the current hour, got %+v", last)
+	}
+
+	rec = get("netspace", "?range=2d")
+	series = ChartSeries{}
+	json.NewDecoder(rec.Body).Decode(&series)
+	if len(series.Points) != 2 || series.Points[1].Value != nil {
+		t.Errorf("Expected no netspace value without samples, got %+v", series.Points)
+	}
+
+	rec = get("hashrate", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown metric, got %d", rec.Code)
+	}
+	rec = get("fees", "?range=1y")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad range, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// errInvalidCursor is returned for cursors this endpoint did not issue
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor turns the last key on a page into an opaque cursor
+func encodeCursor(key []byte) string {
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+// decodeCursor recovers the key behind a cursor, which must fall under
+// prefix. An empty cursor decodes to nil, meaning "start from the newest".
+func decodeCursor(cursor string, prefix []byte) ([]byte, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !bytes.HasPrefix(key, prefix) {
+		return nil, errInvalidCursor
+	}
+	return key, nil
+}
+
+// scanKeysBefore walks keys under prefix in descending order, starting
+// strictly below cursorKey (or at the newest key when it is nil). It skips
+// skip keys, returns up to limit, and reports whether more keys follow.
+func scanKeysBefore(txn *badger.Txn, prefix, cursorKey []byte, skip, limit int) ([][]byte, bool) {
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = true
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	seek := cursorKey
+	if seek == nil {
+		seek = append(append([]byte{}, prefix...), 0xff)
+	}
+
+	var keys [][]byte
+	for it.Seek(seek); it.ValidForPrefix(prefix); it.Next() {
+		key := it.Item().Key()
+		if cursorKey != nil && bytes.Equal(key, cursorKey) {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if len(keys) == limit {
+			return keys, true
+		}
+		keys = append(keys, it.Item().KeyCopy(nil))
+	}
+	return keys, false
+}
+
+// scanKeysAfter walks keys under prefix in ascending order, starting
+// strictly above cursorKey (or at the first key when it is nil). It returns
+// up to limit keys and reports whether more keys follow.
+func scanKeysAfter(txn *badger.Txn, prefix, cursorKey []byte, limit int) ([][]byte, bool) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	seek := cursorKey
+	if seek == nil {
+		seek = prefix
+	}
+
+	var keys [][]byte
+	for it.Seek(seek); it.ValidForPrefix(prefix); it.Next() {
+		key := it.Item().Key()
+		if cursorKey != nil && bytes.Equal(key, cursorKey) {
+			continue
+		}
+		if len(keys) == limit {
+			return keys, true
+		}
+		keys = append(keys, it.Item().KeyCopy(nil))
+	}
+	return keys, false
+}
+
+// scanIndexPage reads one cursor page of an index whose keys fall under
+// prefix and whose values are record IDs, in descending key order unless
+// ascending is set. It returns the IDs, the cursor for the next page ("" on
+// the last one) and how many keys the index holds.
+func scanIndexPage(txn *badger.Txn, prefix []byte, cursor string, ascending bool, limit int) ([]string, string, int64, error) {
+	cursorKey, err := decodeCursor(cursor, prefix)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var keys [][]byte
+	var hasMore bool
+	if ascending {
+		keys, hasMore = scanKeysAfter(txn, prefix, cursorKey, limit)
+	} else {
+		keys, hasMore = scanKeysBefore(txn, prefix, cursorKey, 0, limit)
+	}
+
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		item, err := txn.Get(key)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		id, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		ids = append(ids, string(id))
+	}
+
+	var nextCursor string
+	if hasMore && len(keys) > 0 {
+		nextCursor = encodeCursor(keys[len(keys)-1])
+	}
+	return ids, nextCursor, countKeys(txn, prefix), nil
+}
+
+// countKeys counts the keys under prefix without reading their values
+func countKeys(txn *badger.Txn, prefix []byte) int64 {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var count int64
+	for it.Rewind(); it.Valid(); it.Next() {
+		count++
+	}
+	return count
+}
+
+// walletCursor is the cursor for a wallet list page ending on address. It
+// points into the addr_tx index, where each address's keys start.
+func walletCursor(address string) string {
+	return encodeCursor([]byte("addr_tx:" + address))
+}
+
+// walletCursorAddress recovers the address behind a wallet list cursor
+func walletCursorAddress(cursor string) (string, error) {
+	key, err := decodeCursor(cursor, []byte("addr_tx:"))
+	if err != nil {
+		return "", err
+	}
+	address := strings.TrimPrefix(string(key), "addr_tx:")
+	if key != nil && (address == "" || strings.Contains(address, ":")) {
+		// A transaction cursor for one address, not a wallet list cursor
+		return "", errInvalidCursor
+	}
+	return address, nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func storeTestBlocks(t *testing.T, database Store, from, to uint64) {
+	for height := from; height <= to; height++ {
+		block := &Block{Header: BlockHeader{Height: height, Timestamp: time.Unix(int64(height), 0)}}
+		if err := database.StoreBlock(fmt.Sprintf("hash_%d", height), block); err != nil {
+			t.Fatalf("Failed to store block %d: %v", height, err)
+		}
+	}
+}
+
+func TestBlockCursorStableUnderInserts(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 0, 9)
+
+	seen := make(map[uint64]int)
+	var order []uint64
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("Cursor iteration did not terminate")
+		}
+
+		result, err := database.GetBlocksByCursor(cursor, 4)
+		if err != nil {
+			t.Fatalf("GetBlocksByCursor failed: %v", err)
+		}
+		for _, block := range result.Blocks {
+			seen[block.Height]++
+			order = append(order, block.Height)
+		}
+
+		// New blocks land at the tip between requests
+		if pages == 0 {
+			storeTestBlocks(t, database, 10, 11)
+		}
+		if pages == 1 {
+			storeTestBlocks(t, database, 12, 12)
+		}
+
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	for height := uint64(0); height <= 9; height++ {
+		if seen[height] != 1 {
+			t.Errorf("Block %d returned %d times", height, seen[height])
+		}
+	}
+	if len(order) != 10 {
+		t.Errorf("Expected only the original 10 blocks, got %v", order)
+	}
+	for i := 1; i < len(order); i++ {
+		if order[i] >= order[i-1] {
+			t.Errorf("Blocks out of order: %v", order)
+			break
+		}
+	}
+}
+
+func TestBlocksAPICursorAndPageCompatibility(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 0, 4)
+	es := &ExplorerServer{database: database}
+
+	get := func(url string) (*httptest.ResponseRecorder, PaginatedBlocks) {
+		rec := httptest.NewRecorder()
+		es.handleBlocks(rec, httptest.NewRequest("GET", url, nil))
+		var body PaginatedBlocks
+		json.NewDecoder(rec.Body).Decode(&body)
+		return rec, body
+	}
+
+	// Page mode hands out a cursor that continues where the page ended
+	_, first := get("/api/v1/blocks?page=1&per_page=2")
+	if len(first.Blocks) != 2 || first.Blocks[1].Height != 3 || first.NextCursor == "" {
+		t.Fatalf("Unexpected first page: %+v", first)
+	}
+
+	_, next := get("/api/v1/blocks?per_page=2&cursor=" + first.NextCursor)
+	if len(next.Blocks) != 2 || next.Blocks[0].Height != 2 || next.Blocks[1].Height != 1 {
+		t.Errorf("Unexpected cursor page: %+v", next.Blocks)
+	}
+
+	// An empty cursor starts at the tip
+	_, tip := get("/api/v1/blocks?per_page=1&cursor=")
+	if len(tip.Blocks) != 1 || tip.Blocks[0].Height != 4 {
+		t.Errorf("Expected the tip block, got %+v", tip.Blocks)
+	}
+
+	rec, _ := get("/api/v1/blocks?cursor=" + encodeCursor([]byte("tx:foreign")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a foreign cursor, got %d", rec.Code)
+	}
+}
+
+func TestWalletTransactionsCursor(t *testing.T) {
+	database := newTestDatabase(t)
+
+	// Heights of differing width must still sort newest first
+	for _, height := range []uint64{9, 10, 100, 2} {
+		tx := &WalletTransaction{TxHash: fmt.Sprintf("tx_%d", height), BlockHeight: height, Type: "transfer", Amount: height, FromAddress: "alice", ToAddress: "bob"}
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store transaction: %v", err)
+		}
+	}
+
+	es := &ExplorerServer{database: database}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/wallet/{address}/transactions", es.handleWalletTransactionsAPI)
+
+	var heights []uint64
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/wallet/bob/transactions?per_page=3&cursor="+cursor, nil))
+
+		var body PaginatedTransactions
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		for _, tx := range body.Transactions {
+			heights = append(heights, tx.BlockHeight)
+		}
+		if body.NextCursor == "" {
+			break
+		}
+		cursor = body.NextCursor
+	}
+
+	want := []uint64{100, 10, 9, 2}
+	if fmt.Sprint(heights) != fmt.Sprint(want) {
+		t.Errorf("Expected heights %v, got %v", want, heights)
+	}
+
+	// Page mode remains available
+	page, err := database.GetWalletTransactionsPage("bob", "", 2, 3)
+	if err != nil {
+		t.Fatalf("GetWalletTransactionsPage failed: %v", err)
+	}
+	if len(page.Transactions) != 1 || page.Transactions[0].BlockHeight != 2 {
+		t.Errorf("Unexpected page 2: %+v", page.Transactions)
+	}
+}
+
+// checkListCursors walks the token, pool and wallet lists a page at a time
+func checkListCursors(t *testing.T, store Store) {
+	for i, ticker := range []string{"GOLD", "GEM", "SILVER", "GLOW", "IRON"} {
+		token := &TokenInfo{TokenID: fmt.Sprintf("tok_%d", i), Ticker: ticker, CreationTime: time.Unix(int64(i+1), 0)}
+		if err := store.StoreToken(token); err != nil {
+			t.Fatalf("Failed to store token: %v", err)
+		}
+		pool := &LiquidityPool{PoolID: fmt.Sprintf("pool_%d", i), TokenA: ticker, TokenB: "SHADOW", TVL: uint64(100 * (i + 1))}
+		if err := store.StorePool(pool); err != nil {
+			t.Fatalf("Failed to store pool: %v", err)
+		}
+		tx := &WalletTransaction{TxHash: fmt.Sprintf("tx_%d", i), ToAddress: strings.ToLower(ticker), Amount: 1, BlockHeight: uint64(i), Timestamp: time.Unix(int64(i), 0)}
+		if err := store.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store transaction: %v", err)
+		}
+	}
+
+	walk := func(name string, page func(cursor string) ([]string, string, error)) string {
+		var all []string
+		cursor := ""
+		for pages := 0; pages < 10; pages++ {
+			ids, next, err := page(cursor)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			all = append(all, ids...)
+			if next == "" {
+				return strings.Join(all, ",")
+			}
+			cursor = next
+		}
+		t.Fatalf("%s: cursor iteration did not terminate", name)
+		return ""
+	}
+
+	tokens := func(search string) func(string) ([]string, string, error) {
+		return func(cursor string) ([]string, string, error) {
+			page, err := store.GetTokensByCursor(search, cursor, 2)
+			if err != nil {
+				return nil, "", err
+			}
+			var ids []string
+			for _, token := range page.Tokens {
+				ids = append(ids, token.TokenID)
+			}
+			return ids, page.NextCursor, nil
+		}
+	}
+	if got := walk("tokens", tokens("")); got != "tok_4,tok_3,tok_2,tok_1,tok_0" {
+		t.Errorf("Expected tokens newest first, got %s", got)
+	}
+	if got := walk("token search", tokens("G")); got != "tok_1,tok_3,tok_0" {
+		t.Errorf("Expected G tickers in ticker order, got %s", got)
+	}
+
+	pools := func(search string) func(string) ([]string, string, error) {
+		return func(cursor string) ([]string, string, error) {
+			page, err := store.GetPoolsByCursor(search, cursor, 2)
+			if err != nil {
+				return nil, "", err
+			}
+			var ids []string
+			for _, pool := range page.Pools {
+				ids = append(ids, pool.PoolID)
+			}
+			return ids, page.NextCursor, nil
+		}
+	}
+	if got := walk("pools", pools("")); got != "pool_4,pool_3,pool_2,pool_1,pool_0" {
+		t.Errorf("Expected pools by TVL, got %s", got)
+	}
+	if got := walk("pool search", pools("G")); got != "pool_1,pool_3,pool_0" {
+		t.Errorf("Expected G pairs in pair order, got %s", got)
+	}
+
+	wallets := walk("wallets", func(cursor string) ([]string, string, error) {
+		page, err := store.GetWalletsByCursor(cursor, 2)
+		if err != nil {
+			return nil, "", err
+		}
+		if page.TotalWallets != 5 {
+			t.Errorf("Expected 5 wallets in total, got %d", page.TotalWallets)
+		}
+		var addresses []string
+		for _, wallet := range page.Wallets {
+			addresses = append(addresses, wallet.Address)
+		}
+		return addresses, page.NextCursor, nil
+	})
+	if wallets != "gem,glow,gold,iron,silver" {
+		t.Errorf("Expected wallets in address order, got %s", wallets)
+	}
+
+	// A cursor only continues the list that issued it
+	first, err := store.GetTokensByCursor("G", "", 1)
+	if err != nil {
+		t.Fatalf("GetTokensByCursor failed: %v", err)
+	}
+	if _, err := store.GetTokensByCursor("S", first.NextCursor, 1); err != errInvalidCursor {
+		t.Errorf("Expected a cursor from another search to be rejected, got %v", err)
+	}
+	if _, err := store.GetWalletsByCursor(encodeCursor([]byte("addr_tx:gold:0000000000000002:tx_0")), 1); err != errInvalidCursor {
+		t.Errorf("Expected a transaction cursor to be rejected, got %v", err)
+	}
+}
+
+func TestListCursors(t *testing.T) {
+	checkListCursors(t, newTestDatabase(t))
+}
+
+func TestListAPIsAcceptCursors(t *testing.T) {
+	database := newTestDatabase(t)
+	for i := 0; i < 3; i++ {
+		if err := database.StorePool(&LiquidityPool{PoolID: fmt.Sprintf("pool_%d", i), TokenA: "GOLD", TokenB: "SHADOW", TVL: uint64(i + 1)}); err != nil {
+			t.Fatalf("Failed to store pool: %v", err)
+		}
+	}
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handlePoolsAPI(rec, httptest.NewRequest("GET", "/api/v1/pools?per_page=2&cursor=", nil))
+	var first PaginatedPools
+	json.NewDecoder(rec.Body).Decode(&first)
+	if len(first.Pools) != 2 || first.TotalPools != 3 || first.NextCursor == "" {
+		t.Fatalf("Unexpected first cursor page: %+v", first)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handlePoolsAPI(rec, httptest.NewRequest("GET", "/api/v1/pools?per_page=2&cursor="+first.NextCursor, nil))
+	var next PaginatedPools
+	json.NewDecoder(rec.Body).Decode(&next)
+	if len(next.Pools) != 1 || next.Pools[0].PoolID != "pool_0" || next.NextCursor != "" {
+		t.Errorf("Unexpected last cursor page: %+v", next)
+	}
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"/api/v1/pools":   es.handlePoolsAPI,
+		"/api/v1/tokens":  es.handleTokensAPI,
+		"/api/v1/wallets": es.handleWalletsAPI,
+	} {
+		rec = httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", name+"?cursor="+encodeCursor([]byte("height:1")), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 for a foreign cursor, got %d", name, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	es.handlePoolsAPI(rec, httptest.NewRequest("GET", "/api/v1/pools?token=GOLD&cursor=", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a cursor with a token filter, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	dailyStatsPrefix   = "stats_daily:"
+	dailyDateFormat    = "2006-01-02"
+	defaultDailyRange  = 30
+	maxDailyStatsRange = 365
+)
+
+// parseDailyRange parses a range such as "30d" into a number of days
+func parseDailyRange(value string) (int, error) {
+	if value == "" {
+		return defaultDailyRange, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+	if err != nil || days < 1 || days > maxDailyStatsRange {
+		return 0, fmt.Errorf("range must be between 1d and %dd", maxDailyStatsRange)
+	}
+	return days, nil
+}
+
+// dayStart truncates a timestamp to midnight UTC
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetDailyStats returns per-day aggregates for the days days ending with
+// the UTC day containing now, oldest first. Days that are complete both
+// on the clock and in the index are cached and served from the cache from
+// then on, so their numbers stay stable.
+func (d *Database) GetDailyStats(days int, now time.Time) ([]DailyStats, error) {
+	today := dayStart(now)
+	first := today.AddDate(0, 0, -(days - 1))
+
+	stats := make([]DailyStats, days)
+	pending := make(map[string]*DailyStats)
+
+	err := d.view(func(txn *badger.Txn) error {
+		for i := range stats {
+			date := first.AddDate(0, 0, i).Format(dailyDateFormat)
+			stats[i] = DailyStats{Date: date}
+
+			item, err := txn.Get([]byte(dailyStatsPrefix + date))
+			if err == badger.ErrKeyNotFound {
+				pending[date] = &stats[i]
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &stats[i])
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		return stats, nil
+	}
+
+	indexedUntil, err := d.computeDailyStats(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	finalized := finalizeDailyStats(pending, now, indexedUntil)
+	if len(finalized) > 0 {
+		err := d.update(func(txn *badger.Txn) error {
+			for date, day := range finalized {
+				data, err := json.Marshal(day)
+				if err != nil {
+					return err
+				}
+				if err := txn.Set([]byte(dailyStatsPrefix+date), data); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Warn("Failed to cache daily stats", "err", err)
+		}
+	}
+
+	return stats, nil
+}
+
+// finalizeDailyStats marks the pending days that can no longer change and
+// returns them for caching. A day is final once it is over and the index
+// holds a later block.
+func finalizeDailyStats(pending map[string]*DailyStats, now, indexedUntil time.Time) map[string]DailyStats {
+	finalized := make(map[string]DailyStats)
+	for date, day := range pending {
+		start, _ := time.Parse(dailyDateFormat, date)
+		end := start.AddDate(0, 0, 1)
+		if !end.After(now) && !end.After(indexedUntil) {
+			day.Finalized = true
+			finalized[date] = *day
+		}
+	}
+	return finalized
+}
+
+// computeDailyStats fills the pending buckets from the block and transaction
+// indexes and returns the timestamp of the newest indexed block. Mining
+// rewards count towards active and new addresses but not towards
+// transactions, volume or fees.
+func (d *Database) computeDailyStats(pending map[string]*DailyStats) (time.Time, error) {
+	var indexedUntil time.Time
+	firstSeen := make(map[string]time.Time)
+	active := make(map[string]map[string]bool)
+	fees := make(map[string]uint64)
+
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		heightPrefix := []byte("height:")
+		for it.Seek(heightPrefix); it.ValidForPrefix(heightPrefix); it.Next() {
+			var blockHash string
+			if err := it.Item().Value(func(val []byte) error {
+				blockHash = string(val)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			blockInfo, err := readBlockInfo(txn, blockHash)
+			if err != nil {
+				continue
+			}
+			timestamp := blockInfo.Timestamp.UTC()
+			if timestamp.After(indexedUntil) {
+				indexedUntil = timestamp
+			}
+			if day, ok := pending[timestamp.Format(dailyDateFormat)]; ok {
+				day.Blocks++
+			}
+		}
+
+		txPrefix := []byte("tx:")
+		for it.Seek(txPrefix); it.ValidForPrefix(txPrefix); it.Next() {
+			var tx WalletTransaction
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				continue
+			}
+
+			timestamp := tx.Timestamp.UTC()
+			for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+				if address == "" {
+					continue
+				}
+				if seen, ok := firstSeen[address]; !ok || timestamp.Before(seen) {
+					firstSeen[address] = timestamp
+				}
+			}
+
+			date := timestamp.Format(dailyDateFormat)
+			day, ok := pending[date]
+			if !ok {
+				continue
+			}
+
+			if active[date] == nil {
+				active[date] = make(map[string]bool)
+			}
+			if tx.FromAddress != "" {
+				active[date][tx.FromAddress] = true
+			}
+			if tx.ToAddress != "" {
+				active[date][tx.ToAddress] = true
+			}
+
+			if tx.Type == "mining_reward" {
+				continue
+			}
+			day.Transactions++
+			day.Volume += tx.Amount
+			fees[date] += tx.Fee
+		}
+
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, seen := range firstSeen {
+		if day, ok := pending[seen.Format(dailyDateFormat)]; ok {
+			day.NewAddresses++
+		}
+	}
+	for date, day := range pending {
+		day.ActiveAddresses = len(active[date])
+		if day.Transactions > 0 {
+			day.AverageFee = float64(fees[date]) / float64(day.Transactions)
+		}
+	}
+
+	return indexedUntil, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func storeTestDailyBlock(t *testing.T, database *Database, height uint64, timestamp time.Time) {
+	block := &Block{Header: BlockHeader{Height: height, Timestamp: timestamp}}
+	if err := database.StoreBlock(fmt.Sprintf("block_%d", height), block); err != nil {
+		t.Fatalf("Failed to store block %d: %v", height, err)
+	}
+}
+
+func storeTestDailyTx(t *testing.T, database *Database, tx WalletTransaction) {
+	if err := database.StoreTransaction(&tx); err != nil {
+		t.Fatalf("Failed to store transaction %s: %v", tx.TxHash, err)
+	}
+}
+
+func TestDailyStatsBucketBoundaries(t *testing.T) {
+	database, err := NewDatabase(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	midnight := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	lastSecond := midnight.Add(-time.Second)
+
+	storeTestDailyBlock(t, database, 1, lastSecond)
+	storeTestDailyBlock(t, database, 2, midnight)
+	storeTestDailyBlock(t, database, 3, midnight.Add(30*time.Hour))
+
+	storeTestDailyTx(t, database, WalletTransaction{TxHash: "reward_1", BlockHeight: 1, Timestamp: lastSecond, Type: "mining_reward", Amount: 50, ToAddress: "alice"})
+	storeTestDailyTx(t, database, WalletTransaction{TxHash: "late", BlockHeight: 1, Timestamp: lastSecond, Type: "sent", Amount: 100, Fee: 2, FromAddress: "alice", ToAddress: "bob"})
+	storeTestDailyTx(t, database, WalletTransaction{TxHash: "early", BlockHeight: 2, Timestamp: midnight, Type: "sent", Amount: 10, Fee: 4, FromAddress: "bob", ToAddress: "carol"})
+	storeTestDailyTx(t, database, WalletTransaction{TxHash: "morning", BlockHeight: 2, Timestamp: midnight.Add(time.Hour), Type: "sent", Amount: 5, Fee: 1, FromAddress: "alice", ToAddress: "carol"})
+
+	stats, err := database.GetDailyStats(3, midnight.Add(31*time.Hour))
+	if err != nil {
+		t.Fatalf("GetDailyStats failed: %v", err)
+	}
+	if len(stats) != 3 || stats[0].Date != "2025-03-01" || stats[2].Date != "2025-03-03" {
+		t.Fatalf("Unexpected day range: %+v", stats)
+	}
+
+	march1, march2 := stats[0], stats[1]
+	if march1.Blocks != 1 || march1.Transactions != 1 || march1.Volume != 100 || march1.AverageFee != 2 {
+		t.Errorf("23:59:59 activity should land on March 1, got %+v", march1)
+	}
+	if march1.NewAddresses != 2 || march1.ActiveAddresses != 2 {
+		t.Errorf("Expected alice and bob new and active on March 1, got %+v", march1)
+	}
+	if march2.Blocks != 1 || march2.Transactions != 2 || march2.Volume != 15 || march2.AverageFee != 2.5 {
+		t.Errorf("Midnight activity should land on March 2, got %+v", march2)
+	}
+	if march2.NewAddresses != 1 || march2.ActiveAddresses != 3 {
+		t.Errorf("Expected only carol new on March 2 with three active, got %+v", march2)
+	}
+	if !march1.Finalized || !march2.Finalized {
+		t.Error("Completed days followed by a later block should be finalized")
+	}
+	if stats[2].Finalized {
+		t.Error("The current day should not be finalized")
+	}
+}
+
+func TestDailyStatsFinalizedDayIsStable(t *testing.T) {
+	database, err := NewDatabase(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := day.Add(24 * time.Hour)
+
+	storeTestDailyBlock(t, database, 1, day)
+	storeTestDailyTx(t, database, WalletTransaction{TxHash: "tx_1", BlockHeight: 1, Timestamp: day, Type: "sent", Amount: 100, Fee: 1, FromAddress: "alice", ToAddress: "bob"})
+
+	// Without a block past the end of the day the index may still be catching up
+	stats, err := database.GetDailyStats(2, now)
+	if err != nil {
+		t.Fatalf("GetDailyStats failed: %v", err)
+	}
+	if stats[0].Finalized {
+		t.Fatal("Day should not be finalized before the index has moved past it")
+	}
+
+	storeTestDailyBlock(t, database, 2, now)
+	stats, err = database.GetDailyStats(2, now)
+	if err != nil {
+		t.Fatalf("GetDailyStats failed: %v", err)
+	}
+	first := stats[0]
+	if !first.Finalized || first.Transactions != 1 || first.Volume != 100 {
+		t.Fatalf("Expected a finalized day with one transaction, got %+v", first)
+	}
+
+	// Late writes into a finalized day must not change the cached numbers
+	storeTestDailyTx(t, database, WalletTransaction{TxHash: "tx_late", BlockHeight: 1, Timestamp: day, Type: "sent", Amount: 900, Fee: 9, FromAddress: "carol", ToAddress: "dave"})
+	storeTestDailyTx(t, database, WalletTransaction{TxHash: "tx_today", BlockHeight: 2, Timestamp: now, Type: "sent", Amount: 7, FromAddress: "bob", ToAddress: "alice"})
+
+	stats, err = database.GetDailyStats(2, now)
+	if err != nil {
+		t.Fatalf("GetDailyStats failed: %v", err)
+	}
+	if stats[0] != first {
+		t.Errorf("Finalized day changed: was %+v, now %+v", first, stats[0])
+	}
+	if stats[1].Transactions != 1 || stats[1].Volume != 7 {
+		t.Errorf("Current day should keep updating, got %+v", stats[1])
+	}
+}
+
+func TestDailyStatsEndpointRange(t *testing.T) {
+	database, err := NewDatabase(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleDailyStats(rec, httptest.NewRequest("GET", "/api/v1/stats/daily?range=7d", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var body DailyStatsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.RangeDays != 7 || len(body.Days) != 7 {
+		t.Errorf("Expected 7 days, got %d (%d buckets)", body.RangeDays, len(body.Days))
+	}
+	if body.Days[6].Date != time.Now().UTC().Format(dailyDateFormat) {
+		t.Errorf("Last bucket should be today, got %s", body.Days[6].Date)
+	}
+
+	for _, bad := range []string{"0d", "abc", "400d"} {
+		rec := httptest.NewRecorder()
+		es.handleDailyStats(rec, httptest.NewRequest("GET", "/api/v1/stats/daily?range="+bad, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for range %q, got %d", bad, rec.Code)
+		}
+	}
+}
@@ -3,40 +3,215 @@ package main
 import (
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
 )
 
+// errDatabaseUnavailable is returned while the database could not be opened
+var errDatabaseUnavailable = errors.New("database is not open")
+
 // Database handles BadgerDB operations for block storage
 type Database struct {
-	db *badger.DB
+	db   *badger.DB
+	path string
+	mu   sync.RWMutex // guards db while the handle is reopened
+	feed *FeedBroker  // live updates for indexed data
+
+	tipMu sync.Mutex
+	tip   *LatestBlock // cached chain tip, cleared whenever a block is stored
 }
 
-// NewDatabase creates a new database instance
+// NewDatabase opens the database at path read-write. It fails while
+// another process holds the database; openDatabase starts degraded instead.
 func NewDatabase(path string) (*Database, error) {
-	opts := badger.DefaultOptions(path)
-	opts.Logger = nil // Disable BadgerDB logging to reduce noise
-	
-	db, err := badger.Open(opts)
+	db, err := openBadger(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	
-	return &Database{db: db}, nil
+	database := &Database{db: db, path: path, feed: NewFeedBroker()}
+	if err := database.migrate(); err != nil {
+		database.Close()
+		return nil, err
+	}
+	if err := database.resumeRollback(); err != nil {
+		database.Close()
+		return nil, err
+	}
+	return database, nil
+}
+
+// openDatabase opens the database at path, or when that fails returns it
+// unopened: every read and write fails with errDatabaseUnavailable until
+// the health monitor's retry opens it
+func openDatabase(path string) *Database {
+	database, err := NewDatabase(path)
+	if err != nil {
+		// Usually another process holds the lock - start degraded and let the
+		// health monitor keep retrying instead of exiting
+		slog.Error("Failed to initialize database", "path", path, "err", err)
+		slog.Warn("Starting degraded, database open will be retried in the background", "path", path)
+		return &Database{path: path, feed: NewFeedBroker()}
+	}
+	return database
+}
+
+// openBadger opens the Badger store at path read-write
+func openBadger(path string) (*badger.DB, error) {
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil // Disable BadgerDB logging to reduce noise
+
+	return badger.Open(opts)
+}
+
+// Feed returns the broker that publishes live updates as data is indexed
+func (d *Database) Feed() *FeedBroker {
+	return d.feed
 }
 
 // Close closes the database
 func (d *Database) Close() error {
-	return d.db.Close()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.db == nil {
+		return nil
+	}
+	err := d.db.Close()
+	d.db = nil
+	return err
+}
+
+// view runs fn in a read-only Badger transaction
+func (d *Database) view(fn func(txn *badger.Txn) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.db == nil {
+		return errDatabaseUnavailable
+	}
+	return d.db.View(fn)
+}
+
+// update runs fn in a read-write Badger transaction
+func (d *Database) update(fn func(txn *badger.Txn) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.db == nil {
+		return errDatabaseUnavailable
+	}
+	return d.db.Update(fn)
+}
+
+// writeBatch applies the writes fn makes to a Badger write batch, which
+// commits in as many transactions as needed however large it grows
+func (d *Database) writeBatch(fn func(batch *badger.WriteBatch) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.db == nil {
+		return errDatabaseUnavailable
+	}
+	batch := d.db.NewWriteBatch()
+	defer batch.Cancel()
+	if err := fn(batch); err != nil {
+		return err
+	}
+	return batch.Flush()
+}
+
+// isOpen reports whether the database has been opened
+func (d *Database) isOpen() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db != nil
+}
+
+// DiskUsage reports the size of the LSM tree and value log, as last
+// measured by Badger
+func (d *Database) DiskUsage() (int64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.db == nil {
+		return 0, errDatabaseUnavailable
+	}
+	lsm, vlog := d.db.Size()
+	return lsm + vlog, nil
+}
+
+// Probe performs a tiny write/read round trip to verify the database is writable
+func (d *Database) Probe() error {
+	if !d.isOpen() {
+		return fmt.Errorf("database %s is not open (locked by another process?)", d.path)
+	}
+
+	stamp := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	if err := d.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("health_probe"), stamp)
+	}); err != nil {
+		return fmt.Errorf("database write failed: %w", err)
+	}
+
+	var readBack []byte
+	if err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte("health_probe"))
+		if err != nil {
+			return err
+		}
+		readBack, err = item.ValueCopy(nil)
+		return err
+	}); err != nil {
+		return fmt.Errorf("database read failed: %w", err)
+	}
+
+	if string(readBack) != string(stamp) {
+		return fmt.Errorf("database read back %q, expected %q", readBack, stamp)
+	}
+	return nil
+}
+
+// Reopen opens a database that could not be opened before, migrating it
+// and finishing any interrupted rollback. It does nothing to one that is open.
+func (d *Database) Reopen() error {
+	opened, err := d.reopen()
+	if err != nil || !opened {
+		return err
+	}
+	if err := d.migrate(); err != nil {
+		return err
+	}
+	return d.resumeRollback()
+}
+
+// reopen opens the Badger handle if there is none, reporting whether it did
+func (d *Database) reopen() (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.db != nil {
+		return false, nil
+	}
+	db, err := openBadger(d.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open database %s: %w", d.path, err)
+	}
+	d.db = db
+	slog.Info("Database opened", "path", d.path)
+	return true, nil
 }
 
 // StoreBlock stores a block in the database
 func (d *Database) StoreBlock(blockHash string, block *Block) error {
-	return d.db.Update(func(txn *badger.Txn) error {
+	err := d.update(func(txn *badger.Txn) error {
 		// Store full block data
 		blockKey := fmt.Sprintf("block:%s", blockHash)
 		blockData, err := json.Marshal(block)
@@ -48,8 +223,26 @@ func (d *Database) StoreBlock(blockHash string, block *Block) error {
 			return fmt.Errorf("failed to store block: %w", err)
 		}
 		
-		// Store height -> hash mapping for easy retrieval
+		// A different block already at this height loses it
 		heightKey := fmt.Sprintf("height:%016d", block.Header.Height)
+		if item, err := txn.Get([]byte(heightKey)); err == nil {
+			previous, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if string(previous) != blockHash {
+				if err := orphanBlock(txn, string(previous), block.Header.Height, orphanReplaced); err != nil {
+					return fmt.Errorf("failed to orphan block: %w", err)
+				}
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err := txn.Delete(orphanKey(block.Header.Height, blockHash)); err != nil {
+			return err
+		}
+		
+		// Store height -> hash mapping for easy retrieval
 		if err := txn.Set([]byte(heightKey), []byte(blockHash)); err != nil {
 			return fmt.Errorf("failed to store height mapping: %w", err)
 		}
@@ -64,13 +257,16 @@ func (d *Database) StoreBlock(blockHash string, block *Block) error {
 		
 		return nil
 	})
+	d.invalidateTip()
+	
+	return err
 }
 
 // GetBlock retrieves a block by hash
 func (d *Database) GetBlock(blockHash string) (*Block, error) {
 	var block Block
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		key := fmt.Sprintf("block:%s", blockHash)
 		item, err := txn.Get([]byte(key))
 		if err != nil {
@@ -94,7 +290,7 @@ func (d *Database) GetBlockByHeight(height uint64) (*Block, error) {
 	var blockHash string
 	
 	// First get the hash for this height
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		key := fmt.Sprintf("height:%016d", height)
 		item, err := txn.Get([]byte(key))
 		if err != nil {
@@ -115,11 +311,62 @@ func (d *Database) GetBlockByHeight(height uint64) (*Block, error) {
 	return d.GetBlock(blockHash)
 }
 
+// GetBlockRange returns the full blocks from height from to height to
+// inclusive, skipping heights that are not indexed
+func (d *Database) GetBlockRange(from, to uint64) ([]BlockWithHash, error) {
+	blocks := []BlockWithHash{}
+	
+	err := d.view(func(txn *badger.Txn) error {
+		// Counting up with the check at the end keeps to = MaxUint64 from wrapping
+		for height := from; ; height++ {
+			block, err := readBlockAtHeight(txn, height)
+			if err != nil && err != badger.ErrKeyNotFound {
+				return err
+			}
+			if err == nil {
+				blocks = append(blocks, *block)
+			}
+			if height == to {
+				return nil
+			}
+		}
+	})
+	
+	return blocks, err
+}
+
+// readBlockAtHeight loads the block indexed at height inside txn
+func readBlockAtHeight(txn *badger.Txn, height uint64) (*BlockWithHash, error) {
+	item, err := txn.Get([]byte(fmt.Sprintf("height:%016d", height)))
+	if err != nil {
+		return nil, err
+	}
+	
+	var entry BlockWithHash
+	if err := item.Value(func(val []byte) error {
+		entry.Hash = string(val)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	
+	item, err = txn.Get([]byte(fmt.Sprintf("block:%s", entry.Hash)))
+	if err != nil {
+		return nil, err
+	}
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &entry.Block)
+	}); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
 // GetLatestHeight returns the latest block height
 func (d *Database) GetLatestHeight() (uint64, error) {
 	var height uint64
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte("latest_height"))
 		if err != nil {
 			if err == badger.ErrKeyNotFound {
@@ -140,6 +387,73 @@ func (d *Database) GetLatestHeight() (uint64, error) {
 	return height, err
 }
 
+// GetLatestBlock returns the current chain tip, served from cache until
+// the next block is stored
+func (d *Database) GetLatestBlock() (*LatestBlock, error) {
+	d.tipMu.Lock()
+	defer d.tipMu.Unlock()
+	
+	if d.tip != nil {
+		return d.tip, nil
+	}
+	
+	var tip LatestBlock
+	err := d.view(func(txn *badger.Txn) error {
+		height := uint64(0)
+		item, err := txn.Get([]byte("latest_height"))
+		if err == nil {
+			err = item.Value(func(val []byte) error {
+				if len(val) == 8 {
+					height = binary.BigEndian.Uint64(val)
+				}
+				return nil
+			})
+		}
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		
+		item, err = txn.Get([]byte(fmt.Sprintf("height:%016d", height)))
+		if err != nil {
+			return err
+		}
+		if err := item.Value(func(val []byte) error {
+			tip.Hash = string(val)
+			return nil
+		}); err != nil {
+			return err
+		}
+		
+		item, err = txn.Get([]byte(fmt.Sprintf("block:%s", tip.Hash)))
+		if err != nil {
+			return err
+		}
+		var block Block
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &block)
+		}); err != nil {
+			return err
+		}
+		tip.Header = block.Header
+		
+		tip.Summary, err = readBlockInfo(txn, tip.Hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	d.tip = &tip
+	return d.tip, nil
+}
+
+// invalidateTip drops the cached chain tip
+func (d *Database) invalidateTip() {
+	d.tipMu.Lock()
+	d.tip = nil
+	d.tipMu.Unlock()
+}
+
 // GetBlocks retrieves blocks with pagination
 func (d *Database) GetBlocks(page, perPage int) (*PaginatedBlocks, error) {
 	latestHeight, err := d.GetLatestHeight()
@@ -161,7 +475,7 @@ func (d *Database) GetBlocks(page, perPage int) (*PaginatedBlocks, error) {
 	startHeight := latestHeight - uint64((page-1)*perPage)
 	var blocks []BlockInfo
 	
-	err = d.db.View(func(txn *badger.Txn) error {
+	err = d.view(func(txn *badger.Txn) error {
 		for i := 0; i < perPage && startHeight >= uint64(i); i++ {
 			height := startHeight - uint64(i)
 			
@@ -185,34 +499,16 @@ func (d *Database) GetBlocks(page, perPage int) (*PaginatedBlocks, error) {
 			}
 			
 			// Get the block
-			blockKey := fmt.Sprintf("block:%s", blockHash)
-			blockItem, err := txn.Get([]byte(blockKey))
-			if err != nil {
+			blockInfo, err := readBlockInfo(txn, blockHash)
+			if err == badger.ErrKeyNotFound {
 				continue
 			}
-			
-			err = blockItem.Value(func(val []byte) error {
-				var block Block
-				if err := json.Unmarshal(val, &block); err != nil {
-					return err
-				}
-				
-				blockInfo := BlockInfo{
-					Hash:          blockHash,
-					Height:        block.Header.Height,
-					Timestamp:     block.Header.Timestamp,
-					TxCount:       int(block.Body.TxCount),
-					FarmerAddress: block.Header.FarmerAddress,
-					Size:          len(val),
-				}
-				
-				blocks = append(blocks, blockInfo)
-				return nil
-			})
-			
 			if err != nil {
-				log.Printf("Error processing block at height %d: %v", height, err)
+				slog.Error("Failed to read block", "height", height, "err", err)
+				continue
 			}
+			
+			blocks = append(blocks, blockInfo)
 		}
 		
 		return nil
@@ -222,12 +518,108 @@ func (d *Database) GetBlocks(page, perPage int) (*PaginatedBlocks, error) {
 		return nil, err
 	}
 	
+	// Let page-based clients switch to a cursor from here
+	var nextCursor string
+	if len(blocks) > 0 && blocks[len(blocks)-1].Height > 0 {
+		nextCursor = encodeCursor([]byte(fmt.Sprintf("height:%016d", blocks[len(blocks)-1].Height)))
+	}
+	
 	return &PaginatedBlocks{
 		Blocks:      blocks,
 		CurrentPage: page,
 		TotalPages:  totalPages,
 		TotalBlocks: totalBlocks,
 		PerPage:     perPage,
+		NextCursor:  nextCursor,
+	}, nil
+}
+
+// readBlockInfo loads the explorer summary of a stored block
+func readBlockInfo(txn *badger.Txn, blockHash string) (BlockInfo, error) {
+	var blockInfo BlockInfo
+	
+	item, err := txn.Get([]byte(fmt.Sprintf("block:%s", blockHash)))
+	if err != nil {
+		return blockInfo, err
+	}
+	
+	err = item.Value(func(val []byte) error {
+		var block Block
+		if err := json.Unmarshal(val, &block); err != nil {
+			return err
+		}
+		
+		blockInfo = BlockInfo{
+			Hash:          blockHash,
+			Height:        block.Header.Height,
+			Timestamp:     block.Header.Timestamp,
+			TxCount:       int(block.Body.TxCount),
+			FarmerAddress: block.Header.FarmerAddress,
+			Size:          len(val),
+		}
+		if block.Body.Pruned {
+			blockInfo.Size = block.Body.PrunedSize
+		}
+		return nil
+	})
+	
+	return blockInfo, err
+}
+
+// GetBlocksByCursor retrieves up to limit blocks, newest first, below the
+// block a previous page ended on. An empty cursor starts at the tip. Blocks
+// that arrive between requests never shift later pages.
+func (d *Database) GetBlocksByCursor(cursor string, limit int) (*PaginatedBlocks, error) {
+	prefix := []byte("height:")
+	cursorKey, err := decodeCursor(cursor, prefix)
+	if err != nil {
+		return nil, err
+	}
+	
+	totalBlocks, err := d.GetBlockCount()
+	if err != nil {
+		return nil, err
+	}
+	
+	blocks := []BlockInfo{}
+	var nextCursor string
+	
+	err = d.view(func(txn *badger.Txn) error {
+		keys, hasMore := scanKeysBefore(txn, prefix, cursorKey, 0, limit)
+		
+		for _, key := range keys {
+			item, err := txn.Get(key)
+			if err != nil {
+				return err
+			}
+			blockHash, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			
+			blockInfo, err := readBlockInfo(txn, string(blockHash))
+			if err != nil {
+				slog.Error("Failed to read block", "key", key, "err", err)
+				continue
+			}
+			blocks = append(blocks, blockInfo)
+		}
+		
+		if hasMore && len(keys) > 0 {
+			nextCursor = encodeCursor(keys[len(keys)-1])
+		}
+		return nil
+	})
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return &PaginatedBlocks{
+		Blocks:      blocks,
+		TotalBlocks: totalBlocks,
+		PerPage:     limit,
+		NextCursor:  nextCursor,
 	}, nil
 }
 
@@ -242,7 +634,7 @@ func (d *Database) GetBlockCount() (int64, error) {
 
 // SetLastSyncTime stores the last sync timestamp
 func (d *Database) SetLastSyncTime(t time.Time) error {
-	return d.db.Update(func(txn *badger.Txn) error {
+	return d.update(func(txn *badger.Txn) error {
 		timeBytes, _ := t.MarshalBinary()
 		return txn.Set([]byte("last_sync"), timeBytes)
 	})
@@ -252,7 +644,7 @@ func (d *Database) SetLastSyncTime(t time.Time) error {
 func (d *Database) GetLastSyncTime() (time.Time, error) {
 	var syncTime time.Time
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte("last_sync"))
 		if err != nil {
 			if err == badger.ErrKeyNotFound {
@@ -271,12 +663,58 @@ func (d *Database) GetLastSyncTime() (time.Time, error) {
 
 // ResetDatabase clears all explorer data for fresh sync
 func (d *Database) ResetDatabase() error {
-	return d.db.DropAll()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.db == nil {
+		return errDatabaseUnavailable
+	}
+	defer d.invalidateTip()
+
+	// API keys, watchlist subscriptions, address labels, the audit log and
+	// job records are not chain data and outlive a resync
+	var saved map[string][]byte
+	if err := d.db.View(func(txn *badger.Txn) error {
+		var err error
+		saved, err = preserveKeys(txn, apiKeyPrefix, watchPrefix, labelPrefix, auditPrefix, jobPrefix)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := d.db.DropAll(); err != nil {
+		return err
+	}
+	return d.db.Update(func(txn *badger.Txn) error {
+		for key, val := range saved {
+			if err := txn.Set([]byte(key), val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// preserveKeys reads every key under the prefixes so a reset can restore them
+func preserveKeys(txn *badger.Txn, prefixes ...string) (map[string][]byte, error) {
+	saved := make(map[string][]byte)
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	for _, prefix := range prefixes {
+		for it.Seek([]byte(prefix)); it.ValidForPrefix([]byte(prefix)); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return nil, err
+			}
+			saved[string(it.Item().KeyCopy(nil))] = val
+		}
+	}
+	return saved, nil
 }
 
 // StoreTransaction stores an individual transaction with address indexing
 func (d *Database) StoreTransaction(tx *WalletTransaction) error {
-	return d.db.Update(func(txn *badger.Txn) error {
+	err := d.update(func(txn *badger.Txn) error {
 		// Store full transaction data
 		txKey := fmt.Sprintf("tx:%s", tx.TxHash)
 		txData, err := json.Marshal(tx)
@@ -284,37 +722,175 @@ func (d *Database) StoreTransaction(tx *WalletTransaction) error {
 			return fmt.Errorf("failed to marshal transaction: %w", err)
 		}
 		
+		// Re-indexing a transaction replaces its effect on the balance index
+		if err := replaceBalanceEffect(txn, txKey, tx); err != nil {
+			return fmt.Errorf("failed to update balance index: %w", err)
+		}
+		
 		if err := txn.Set([]byte(txKey), txData); err != nil {
 			return fmt.Errorf("failed to store transaction: %w", err)
 		}
+		if err := recordAtHeight(txn, tx.BlockHeight, txKey); err != nil {
+			return fmt.Errorf("failed to store height index: %w", err)
+		}
 		
 		// Index by from_address
 		if tx.FromAddress != "" {
-			fromKey := fmt.Sprintf("addr_tx:%s:%d:%s", tx.FromAddress, tx.BlockHeight, tx.TxHash)
+			fromKey := fmt.Sprintf("addr_tx:%s:%016d:%s", tx.FromAddress, tx.BlockHeight, tx.TxHash)
 			if err := txn.Set([]byte(fromKey), []byte(tx.TxHash)); err != nil {
 				return fmt.Errorf("failed to store from_address index: %w", err)
 			}
 		}
 		
-		// Index by to_address
-		if tx.ToAddress != "" {
-			toKey := fmt.Sprintf("addr_tx:%s:%d:%s", tx.ToAddress, tx.BlockHeight, tx.TxHash)
-			if err := txn.Set([]byte(toKey), []byte(tx.TxHash)); err != nil {
-				return fmt.Errorf("failed to store to_address index: %w", err)
+		// Index by to_address
+		if tx.ToAddress != "" {
+			toKey := fmt.Sprintf("addr_tx:%s:%016d:%s", tx.ToAddress, tx.BlockHeight, tx.TxHash)
+			if err := txn.Set([]byte(toKey), []byte(tx.TxHash)); err != nil {
+				return fmt.Errorf("failed to store to_address index: %w", err)
+			}
+		}
+		
+		// Track the day each address first appeared
+		for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+			if address == "" {
+				continue
+			}
+			if err := recordFirstSeen(txn, address, tx.Timestamp); err != nil {
+				return fmt.Errorf("failed to update first-seen index: %w", err)
+			}
+		}
+		
+		// Mining rewards feed the farmer index
+		if tx.Type == "mining_reward" && tx.ToAddress != "" {
+			block := MinedBlock{
+				Height:    tx.BlockHeight,
+				Hash:      tx.BlockHash,
+				Timestamp: tx.Timestamp,
+				Reward:    tx.Amount,
+			}
+			mined, err := json.Marshal(block)
+			if err != nil {
+				return fmt.Errorf("failed to marshal mined block: %w", err)
+			}
+			farmerKey := fmt.Sprintf("farmer:%s:%016d", tx.ToAddress, tx.BlockHeight)
+			if err := txn.Set([]byte(farmerKey), mined); err != nil {
+				return fmt.Errorf("failed to store farmer index: %w", err)
+			}
+			if err := setMinedBlock(txn, tx.ToAddress, block); err != nil {
+				return fmt.Errorf("failed to store mined block index: %w", err)
+			}
+		}
+		
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	
+	// Wake wallet long-polls waiting on either side
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		if address != "" {
+			d.feed.Publish(walletFeedTopic(address), FeedEvent{Type: "transaction", Data: tx.TxHash})
+		}
+	}
+	return nil
+}
+
+// GetTransaction returns the wallet transaction stored under txHash
+func (d *Database) GetTransaction(txHash string) (*WalletTransaction, error) {
+	var tx WalletTransaction
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte("tx:" + txHash))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &tx)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// GetMinedBlocks retrieves the blocks farmed by an address, newest first
+func (d *Database) GetMinedBlocks(address string, page, perPage int) (*PaginatedMinedBlocks, error) {
+	blocks := []MinedBlock{}
+	var totalBlocks int64
+	
+	if page < 1 {
+		page = 1
+	}
+	
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		// Keys sort by zero-padded height, so collect them and page from the end
+		prefix := []byte(fmt.Sprintf("farmer:%s:", address))
+		var keys [][]byte
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		
+		totalBlocks = int64(len(keys))
+		
+		start := (page - 1) * perPage
+		if start > len(keys) {
+			start = len(keys)
+		}
+		end := start + perPage
+		if end > len(keys) {
+			end = len(keys)
+		}
+		
+		for i := start; i < end; i++ {
+			key := keys[len(keys)-1-i]
+			item, err := txn.Get(key)
+			if err != nil {
+				return err
+			}
+			
+			err = item.Value(func(val []byte) error {
+				var block MinedBlock
+				if err := json.Unmarshal(val, &block); err != nil {
+					return err
+				}
+				blocks = append(blocks, block)
+				return nil
+			})
+			if err != nil {
+				slog.Error("Failed to unmarshal mined block", "key", key, "err", err)
 			}
 		}
 		
 		return nil
 	})
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return &PaginatedMinedBlocks{
+		Address:     address,
+		Blocks:      blocks,
+		CurrentPage: page,
+		TotalPages:  int((totalBlocks + int64(perPage) - 1) / int64(perPage)),
+		TotalBlocks: totalBlocks,
+		PerPage:     perPage,
+	}, nil
 }
 
 // GetWalletTransactions retrieves transactions for an address
 func (d *Database) GetWalletTransactions(address string, limit int) ([]WalletTransaction, error) {
 	var transactions []WalletTransaction
 
-	log.Printf("🔍 [DEBUG] GetWalletTransactions: Looking for address %s with limit %d", address, limit)
+	slog.Debug("Getting wallet transactions", "address", address, "limit", limit)
 
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		// Create iterator for address transactions (newest first)
 		prefix := fmt.Sprintf("addr_tx:%s:", address)
 		opts := badger.DefaultIteratorOptions
@@ -323,7 +899,7 @@ func (d *Database) GetWalletTransactions(address string, limit int) ([]WalletTra
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
-		log.Printf("🔍 [DEBUG] GetWalletTransactions: Using manual prefix matching for '%s'", prefix)
+		slog.Debug("Scanning wallet transaction keys", "prefix", prefix)
 
 		// Collect matching transactions using manual prefix matching
 		count := 0
@@ -340,34 +916,34 @@ func (d *Database) GetWalletTransactions(address string, limit int) ([]WalletTra
 			keysFound++
 
 			if count < 3 { // Only log first few for debugging
-				log.Printf("🔍 [DEBUG] Processing addr_tx key #%d: %s", count+1, addrTxKey)
+				slog.Debug("Processing wallet transaction key", "n", count+1, "key", addrTxKey)
 			}
 
 			err := item.Value(func(val []byte) error {
 				txHash := string(val)
 				if count < 3 {
-					log.Printf("🔍 [DEBUG] TxHash from key: %s", txHash)
+					slog.Debug("Transaction hash from key", "tx_hash", txHash)
 				}
 
 				// Get the full transaction
 				txKey := fmt.Sprintf("tx:%s", txHash)
 				if count < 3 {
-					log.Printf("🔍 [DEBUG] Looking for tx key: %s", txKey)
+					slog.Debug("Looking up transaction", "key", txKey)
 				}
 				txItem, err := txn.Get([]byte(txKey))
 				if err != nil {
-					log.Printf("❌ [DEBUG] Failed to find tx key %s: %v", txKey, err)
+					slog.Error("Failed to find transaction key", "key", txKey, "err", err)
 					return nil // Skip missing transactions
 				}
 
 				return txItem.Value(func(txData []byte) error {
 					var walletTx WalletTransaction
 					if err := json.Unmarshal(txData, &walletTx); err != nil {
-						log.Printf("❌ [DEBUG] Failed to unmarshal tx data: %v", err)
+						slog.Error("Failed to unmarshal transaction", "key", txKey, "err", err)
 						return nil // Skip invalid transactions
 					}
 					if count < 3 {
-						log.Printf("✅ [DEBUG] Successfully retrieved transaction: %s, Type: %s, Amount: %d SHADOW (%.8f)", walletTx.TxHash, walletTx.Type, walletTx.Amount, float64(walletTx.Amount)/100000000.0)
+						slog.Debug("Retrieved transaction", "tx_hash", walletTx.TxHash, "type", walletTx.Type, "amount", walletTx.Amount)
 					}
 					transactions = append(transactions, walletTx)
 					return nil
@@ -379,27 +955,94 @@ func (d *Database) GetWalletTransactions(address string, limit int) ([]WalletTra
 			count++
 		}
 
-		log.Printf("🔍 [DEBUG] GetWalletTransactions completed: Found %d keys, retrieved %d transactions for address %s", keysFound, len(transactions), address)
+		slog.Debug("Scanned wallet transaction keys", "address", address, "keys", keysFound, "transactions", len(transactions))
 
 		return nil
 	})
 
-	log.Printf("🔍 [DEBUG] GetWalletTransactions final result: %d transactions returned for %s", len(transactions), address)
+	slog.Debug("Got wallet transactions", "address", address, "transactions", len(transactions))
 
 	return transactions, err
 }
 
+// GetWalletTransactionsPage retrieves up to limit transactions for an
+// address, newest first. With a cursor it continues below the transaction a
+// previous page ended on; otherwise page selects an offset from the newest.
+func (d *Database) GetWalletTransactionsPage(address, cursor string, page, limit int) (*PaginatedTransactions, error) {
+	prefix := []byte(fmt.Sprintf("addr_tx:%s:", address))
+	cursorKey, err := decodeCursor(cursor, prefix)
+	if err != nil {
+		return nil, err
+	}
+	
+	skip := 0
+	if cursorKey == nil && page > 1 {
+		skip = (page - 1) * limit
+	}
+	
+	transactions := []WalletTransaction{}
+	var nextCursor string
+	
+	err = d.view(func(txn *badger.Txn) error {
+		keys, hasMore := scanKeysBefore(txn, prefix, cursorKey, skip, limit)
+		
+		for _, key := range keys {
+			item, err := txn.Get(key)
+			if err != nil {
+				return err
+			}
+			txHash, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			
+			txItem, err := txn.Get([]byte(fmt.Sprintf("tx:%s", txHash)))
+			if err != nil {
+				slog.Error("Wallet index points at a missing transaction", "address", address, "tx_hash", txHash)
+				continue
+			}
+			err = txItem.Value(func(val []byte) error {
+				var walletTx WalletTransaction
+				if err := json.Unmarshal(val, &walletTx); err != nil {
+					return err
+				}
+				transactions = append(transactions, walletTx)
+				return nil
+			})
+			if err != nil {
+				slog.Error("Failed to unmarshal transaction", "tx_hash", txHash, "err", err)
+			}
+		}
+		
+		if hasMore && len(keys) > 0 {
+			nextCursor = encodeCursor(keys[len(keys)-1])
+		}
+		return nil
+	})
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return &PaginatedTransactions{
+		Address:      address,
+		Transactions: transactions,
+		PerPage:      limit,
+		NextCursor:   nextCursor,
+	}, nil
+}
+
 // GetWalletSummary gets wallet statistics
 func (d *Database) GetWalletSummary(address string) (*WalletSummary, error) {
-	log.Printf("🔍 [DEBUG] GetWalletSummary: Called for address %s", address)
+	slog.Debug("Getting wallet summary", "address", address)
 
 	// Get recent transactions for display (limited)
 	transactions, err := d.GetWalletTransactions(address, 50)
 	if err != nil {
-		log.Printf("🔍 [DEBUG] GetWalletSummary: Error getting recent transactions: %v", err)
+		slog.Debug("Failed to get recent wallet transactions", "address", address, "err", err)
 		return nil, err
 	}
-	log.Printf("🔍 [DEBUG] GetWalletSummary: Got %d recent transactions", len(transactions))
+	slog.Debug("Got recent wallet transactions", "address", address, "transactions", len(transactions))
 	
 	// Get ALL transactions for accurate balance calculation
 	allTransactions, err := d.GetWalletTransactions(address, 999999) // Very high limit to get all
@@ -440,15 +1083,35 @@ func (d *Database) GetWalletSummary(address string) (*WalletSummary, error) {
 		}
 	}
 	
+	// Fold in what pruning removed from the index
+	var pruned prunedHistory
+	if err := d.view(func(txn *badger.Txn) error {
+		pruned, err = getPrunedHistory(txn, address)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if pruned.Transactions > 0 {
+		balance += uint64(pruned.Balance)
+		blocksMined += pruned.BlocksMined
+		if len(allTransactions) == 0 || pruned.FirstActivity.Before(firstActivity) {
+			firstActivity = pruned.FirstActivity
+		}
+		if len(allTransactions) == 0 || pruned.LastActivity.After(lastActivity) {
+			lastActivity = pruned.LastActivity
+		}
+	}
+	
 	// Get token balances for this wallet
 	tokenBalances, err := d.GetWalletTokenBalances(address)
 	if err != nil {
-		log.Printf("❌ Failed to get token balances for %s: %v", address, err)
+		slog.Error("Failed to get token balances", "address", address, "err", err)
 		tokenBalances = []TokenBalance{} // Continue with empty token balances
 	}
 
 	summary.Balance = balance
-	summary.TransactionCount = len(allTransactions) // Total count, not just recent
+	summary.TransactionCount = len(allTransactions) + pruned.Transactions // Total count, not just recent
+	summary.PrunedTransactions = pruned.Transactions
 	summary.BlocksMined = blocksMined
 	summary.FirstActivity = firstActivity
 	summary.LastActivity = lastActivity
@@ -457,39 +1120,140 @@ func (d *Database) GetWalletSummary(address string) (*WalletSummary, error) {
 	return summary, nil
 }
 
-// GetAllWallets gets all wallets with basic info
-func (d *Database) GetAllWallets(limit int, offset int) ([]WalletOverview, int64, error) {
-	// First, collect all unique wallet addresses from transaction indices
-	addressMap := make(map[string]bool)
-
-	err := d.db.View(func(txn *badger.Txn) error {
-		// Scan through all addr_tx keys to find unique addresses
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		it := txn.NewIterator(opts)
+// CheckSupply recomputes circulating supply from the transaction store and
+// compares it with the sum of wallet balances derived through the address
+// index. A nonzero discrepancy means the two views disagree, which points
+// at an indexing bug rather than anything on chain.
+func (d *Database) CheckSupply() (*SupplyCheck, error) {
+	check := &SupplyCheck{CheckedAt: time.Now().UTC()}
+	balances := make(map[string]int64)
+	
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
 		defer it.Close()
-
-		prefix := []byte("addr_tx:")
-		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-			key := string(it.Item().Key())
+		
+		// Supply side: every stored transaction once
+		txPrefix := []byte("tx:")
+		for it.Seek(txPrefix); it.ValidForPrefix(txPrefix); it.Next() {
+			var tx WalletTransaction
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				slog.Error("Supply check skipping unreadable entry", "key", string(it.Item().Key()), "err", err)
+				continue
+			}
+			
+			check.TransactionsChecked++
+			if tx.Type == "mining_reward" {
+				check.Emission += tx.Amount
+				continue
+			}
+			check.Burned += tx.Fee
+			if tx.ToAddress == "" {
+				check.Burned += tx.Amount
+			}
+		}
+		
+		// Pruned transactions count through their totals
+		var supply prunedSupply
+		if err := readJSON(txn, prunedSupplyKey, &supply); err != nil {
+			return err
+		}
+		check.TransactionsChecked += supply.Transactions
+		check.Emission += supply.Emission
+		check.Burned += supply.Burned
+		
+		prunedPrefix := []byte(prunedAddressPrefix)
+		for it.Seek(prunedPrefix); it.ValidForPrefix(prunedPrefix); it.Next() {
+			var history prunedHistory
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &history)
+			}); err != nil {
+				return err
+			}
+			address := strings.TrimPrefix(string(it.Item().Key()), prunedAddressPrefix)
+			balances[address] += history.Balance
+		}
+		
+		// Wallet side: balances the same way GetWalletSummary derives them,
+		// but signed so an over-spent wallet shows up instead of wrapping
+		addrPrefix := []byte("addr_tx:")
+		for it.Seek(addrPrefix); it.ValidForPrefix(addrPrefix); it.Next() {
 			// Format: addr_tx:address:blockheight:txhash
-			parts := strings.Split(key, ":")
-			if len(parts) >= 2 {
-				address := parts[1]
-				addressMap[address] = true
+			parts := strings.SplitN(string(it.Item().Key()), ":", 3)
+			if len(parts) < 3 {
+				continue
+			}
+			address := parts[1]
+			
+			var txHash string
+			if err := it.Item().Value(func(val []byte) error {
+				txHash = string(val)
+				return nil
+			}); err != nil {
+				return err
+			}
+			
+			item, err := txn.Get([]byte(fmt.Sprintf("tx:%s", txHash)))
+			if err != nil {
+				slog.Error("Supply check found an index pointing at a missing transaction", "address", address, "tx_hash", txHash)
+				continue
+			}
+			var tx WalletTransaction
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				continue
+			}
+			
+			balance := balances[address]
+			if tx.ToAddress == address {
+				balance += int64(tx.Amount)
+			}
+			if tx.FromAddress == address {
+				balance -= int64(tx.Amount + tx.Fee)
 			}
+			balances[address] = balance
 		}
+		
 		return nil
 	})
-
+	
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
+	
+	check.reconcile(balances)
+	return check, nil
+}
 
-	// Convert map keys to slice
-	var addresses []string
-	for addr := range addressMap {
-		addresses = append(addresses, addr)
+// reconcile totals the per-wallet balances against the supply side of the
+// check and records any discrepancy
+func (check *SupplyCheck) reconcile(balances map[string]int64) {
+	for address, balance := range balances {
+		check.WalletBalanceTotal += balance
+		if balance < 0 {
+			check.NegativeBalances = append(check.NegativeBalances, address)
+		}
+	}
+	sort.Strings(check.NegativeBalances)
+	
+	check.WalletsChecked = len(balances)
+	check.CirculatingSupply = int64(check.Emission) - int64(check.Burned)
+	check.Discrepancy = check.WalletBalanceTotal - check.CirculatingSupply
+	check.Consistent = check.Discrepancy == 0 && len(check.NegativeBalances) == 0
+	
+	if !check.Consistent {
+		slog.Warn("Supply check found a discrepancy", "discrepancy", check.Discrepancy, "overspent_wallets", len(check.NegativeBalances))
+	}
+}
+
+// GetAllWallets gets all wallets with basic info, in address order
+func (d *Database) GetAllWallets(limit int, offset int) ([]WalletOverview, int64, error) {
+	// Every address with a transaction has addr_tx keys
+	addresses, err := d.ListAddresses("", math.MaxInt)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Apply pagination
@@ -503,38 +1267,122 @@ func (d *Database) GetAllWallets(limit int, offset int) ([]WalletOverview, int64
 		end = len(addresses)
 	}
 
-	paginatedAddresses := addresses[start:end]
+	return d.walletOverviews(addresses[start:end]), total, nil
+}
+
+// GetWalletsByCursor retrieves up to limit wallets in address order, after
+// the wallet a previous page ended on. An empty cursor starts at the first.
+func (d *Database) GetWalletsByCursor(cursor string, limit int) (*PaginatedWallets, error) {
+	after, err := walletCursorAddress(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := d.ListAddresses("", math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	start := sort.SearchStrings(addresses, after)
+	if start < len(addresses) && addresses[start] == after {
+		start++
+	}
+	page := addresses[start:]
+
+	var nextCursor string
+	if len(page) > limit {
+		page = page[:limit]
+		nextCursor = walletCursor(page[len(page)-1])
+	}
+
+	return &PaginatedWallets{
+		Wallets:      d.walletOverviews(page),
+		TotalWallets: int64(len(addresses)),
+		PerPage:      limit,
+		NextCursor:   nextCursor,
+	}, nil
+}
 
-	// Get wallet summaries for paginated addresses
-	var wallets []WalletOverview
-	for _, address := range paginatedAddresses {
+// walletOverviews summarizes each address with its token balances
+func (d *Database) walletOverviews(addresses []string) []WalletOverview {
+	wallets := []WalletOverview{}
+	for _, address := range addresses {
 		summary, err := d.GetWalletSummary(address)
 		if err != nil {
-			log.Printf("❌ Failed to get wallet summary for %s: %v", address, err)
+			slog.Error("Failed to get wallet summary", "address", address, "err", err)
 			continue
 		}
 
 		// Get token balances for this wallet
 		tokenBalances, err := d.GetWalletTokenBalances(address)
 		if err != nil {
-			log.Printf("❌ Failed to get token balances for %s: %v", address, err)
+			slog.Error("Failed to get token balances", "address", address, "err", err)
 			tokenBalances = []TokenBalance{} // Continue with empty token balances
 		}
 
-		wallet := WalletOverview{
-			Address:           summary.Address,
-			Balance:           summary.Balance,
-			TransactionCount:  summary.TransactionCount,
-			BlocksMined:       summary.BlocksMined,
-			FirstActivity:     summary.FirstActivity,
-			LastActivity:      summary.LastActivity,
-			TokenBalances:     tokenBalances,
+		wallets = append(wallets, WalletOverview{
+			Address:          summary.Address,
+			Balance:          summary.Balance,
+			TransactionCount: summary.TransactionCount,
+			BlocksMined:      summary.BlocksMined,
+			FirstActivity:    summary.FirstActivity,
+			LastActivity:     summary.LastActivity,
+			TokenBalances:    tokenBalances,
+		})
+	}
+	return wallets
+}
+
+// ListAddresses returns up to limit addresses with transactions, in
+// order, starting after the given address ("" for the first)
+func (d *Database) ListAddresses(after string, limit int) ([]string, error) {
+	addresses := []string{}
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// Keys are addr_tx:address:blockheight:txhash, so seeking just past
+		// "address:" (';' follows ':') skips the rest of an address's keys
+		prefix := []byte("addr_tx:")
+		seek := prefix
+		if after != "" {
+			seek = []byte("addr_tx:" + after + ";")
+		}
+		for it.Seek(seek); it.ValidForPrefix(prefix) && len(addresses) < limit; it.Seek(seek) {
+			address, _, _ := strings.Cut(strings.TrimPrefix(string(it.Item().Key()), "addr_tx:"), ":")
+			addresses = append(addresses, address)
+			seek = []byte("addr_tx:" + address + ";")
 		}
 
-		wallets = append(wallets, wallet)
-	}
+		// Addresses whose every transaction was pruned only have a history
+		var pruned []string
+		prefix = []byte(prunedAddressPrefix)
+		for it.Seek([]byte(prunedAddressPrefix + after + "\x00")); it.ValidForPrefix(prefix) && len(pruned) < limit; it.Next() {
+			pruned = append(pruned, strings.TrimPrefix(string(it.Item().Key()), prunedAddressPrefix))
+		}
+		if len(pruned) > 0 {
+			addresses = mergeSortedUnique(addresses, pruned, limit)
+		}
+		return nil
+	})
+	return addresses, err
+}
 
-	return wallets, total, nil
+// mergeSortedUnique merges two sorted lists, dropping duplicates, up to limit
+func mergeSortedUnique(a, b []string, limit int) []string {
+	merged := make([]string, 0, min(len(a)+len(b), limit))
+	for len(merged) < limit && (len(a) > 0 || len(b) > 0) {
+		switch {
+		case len(b) == 0 || (len(a) > 0 && a[0] < b[0]):
+			merged, a = append(merged, a[0]), a[1:]
+		case len(a) == 0 || b[0] < a[0]:
+			merged, b = append(merged, b[0]), b[1:]
+		default:
+			merged, a, b = append(merged, a[0]), a[1:], b[1:]
+		}
+	}
+	return merged
 }
 
 // GetWalletTokenBalances gets all token balances for a wallet address
@@ -543,7 +1391,7 @@ func (d *Database) GetWalletTokenBalances(address string) ([]TokenBalance, error
 
 	// This is a simplified version - in a production system you'd maintain
 	// a separate index of token holders for better performance
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		// Scan all token holder keys
 		opts := badger.DefaultIteratorOptions
 		it := txn.NewIterator(opts)
@@ -568,7 +1416,7 @@ func (d *Database) GetWalletTokenBalances(address string) ([]TokenBalance, error
 						// Get token info
 						tokenInfo, err := d.GetToken(tokenId)
 						if err != nil {
-							log.Printf("❌ Failed to get token info for %s: %v", tokenId, err)
+							slog.Error("Failed to get token info", "token_id", tokenId, "err", err)
 							return nil // Skip this balance
 						}
 
@@ -585,7 +1433,7 @@ func (d *Database) GetWalletTokenBalances(address string) ([]TokenBalance, error
 				})
 
 				if err != nil {
-					log.Printf("❌ Failed to process token holder %s: %v", key, err)
+					slog.Error("Failed to process token holder", "key", key, "err", err)
 				}
 			}
 		}
@@ -597,7 +1445,7 @@ func (d *Database) GetWalletTokenBalances(address string) ([]TokenBalance, error
 
 // StoreToken stores token information
 func (d *Database) StoreToken(token *TokenInfo) error {
-	return d.db.Update(func(txn *badger.Txn) error {
+	return d.update(func(txn *badger.Txn) error {
 		// Store full token data
 		tokenKey := fmt.Sprintf("token:%s", token.TokenID)
 		tokenData, err := json.Marshal(token)
@@ -605,15 +1453,18 @@ func (d *Database) StoreToken(token *TokenInfo) error {
 			return fmt.Errorf("failed to marshal token: %w", err)
 		}
 		
-		log.Printf("💾 Storing token with key: %s", tokenKey)
+		slog.Debug("Storing token", "key", tokenKey)
 		if err := txn.Set([]byte(tokenKey), tokenData); err != nil {
 			return fmt.Errorf("failed to store token: %w", err)
 		}
+		if err := recordAtHeight(txn, token.CreationBlock, tokenKey); err != nil {
+			return fmt.Errorf("failed to store height index: %w", err)
+		}
 		
 		// Index by ticker for searching
 		if token.Ticker != "" {
 			tickerKey := fmt.Sprintf("token_ticker:%s:%s", token.Ticker, token.TokenID)
-			log.Printf("💾 Creating ticker index: %s", tickerKey)
+			slog.Debug("Creating ticker index", "key", tickerKey)
 			if err := txn.Set([]byte(tickerKey), []byte(token.TokenID)); err != nil {
 				return fmt.Errorf("failed to store ticker index: %w", err)
 			}
@@ -622,7 +1473,7 @@ func (d *Database) StoreToken(token *TokenInfo) error {
 		// Index by name for searching
 		if token.Name != "" {
 			nameKey := fmt.Sprintf("token_name:%s:%s", token.Name, token.TokenID)
-			log.Printf("💾 Creating name index: %s", nameKey)
+			slog.Debug("Creating name index", "key", nameKey)
 			if err := txn.Set([]byte(nameKey), []byte(token.TokenID)); err != nil {
 				return fmt.Errorf("failed to store name index: %w", err)
 			}
@@ -630,12 +1481,12 @@ func (d *Database) StoreToken(token *TokenInfo) error {
 		
 		// Index by creation time for sorting
 		creationKey := fmt.Sprintf("token_time:%016d:%s", token.CreationTime.Unix(), token.TokenID)
-		log.Printf("💾 Creating time index: %s", creationKey)
+		slog.Debug("Creating time index", "key", creationKey)
 		if err := txn.Set([]byte(creationKey), []byte(token.TokenID)); err != nil {
 			return fmt.Errorf("failed to store creation time index: %w", err)
 		}
 		
-		log.Printf("✅ Token %s stored with all indexes", token.TokenID)
+		slog.Debug("Stored token with all indexes", "token_id", token.TokenID)
 		return nil
 	})
 }
@@ -645,9 +1496,9 @@ func (d *Database) GetTokens(page, perPage int, search string) (*PaginatedTokens
 	var tokens []TokenInfo
 	var totalTokens int64
 	
-	log.Printf("🔍 DB: GetTokens called - page=%d, perPage=%d, search='%s'", page, perPage, search)
+	slog.Debug("Listing tokens", "page", page, "per_page", perPage, "search", search)
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		// Get all keys and filter in Go code (more reliable than prefix iterator)
 		opts := badger.DefaultIteratorOptions
 		opts.PrefetchValues = false // We only want keys initially
@@ -670,7 +1521,7 @@ func (d *Database) GetTokens(page, perPage int, search string) (*PaginatedTokens
 			}
 		}
 		
-		log.Printf("📊 DB: Found %d keys matching prefix '%s'", len(matchingKeys), searchPrefix)
+		slog.Debug("Found matching keys", "count", len(matchingKeys), "prefix", searchPrefix)
 		
 		// For token_time keys, we want newest first (reverse sort)
 		if searchPrefix == "token_time:" {
@@ -687,7 +1538,7 @@ func (d *Database) GetTokens(page, perPage int, search string) (*PaginatedTokens
 			// Get the value (token ID) for each key
 			item, err := txn.Get([]byte(key))
 			if err != nil {
-				log.Printf("❌ Failed to get value for key %s: %v", key, err)
+				slog.Error("Failed to get value", "key", key, "err", err)
 				continue
 			}
 			
@@ -697,13 +1548,13 @@ func (d *Database) GetTokens(page, perPage int, search string) (*PaginatedTokens
 				return nil
 			})
 			if err != nil {
-				log.Printf("❌ Failed to read value for key %s: %v", key, err)
+				slog.Error("Failed to read value", "key", key, "err", err)
 				continue
 			}
 		}
 		
 		totalTokens = int64(len(tokenIDs))
-		log.Printf("📊 DB: Found %d total tokens with prefix '%s'", totalTokens, searchPrefix)
+		slog.Debug("Counted tokens", "count", totalTokens, "prefix", searchPrefix)
 		
 		// Calculate pagination
 		totalPages := int((totalTokens + int64(perPage) - 1) / int64(perPage))
@@ -721,24 +1572,24 @@ func (d *Database) GetTokens(page, perPage int, search string) (*PaginatedTokens
 			end = len(tokenIDs)
 		}
 		
-		log.Printf("📊 DB: Getting tokens %d-%d from %d total", start, end-1, len(tokenIDs))
+		slog.Debug("Loading tokens", "from", start, "to", end-1, "total", len(tokenIDs))
 		for i := start; i < end; i++ {
 			tokenID := tokenIDs[i]
 			tokenKey := fmt.Sprintf("token:%s", tokenID)
 			
 			item, err := txn.Get([]byte(tokenKey))
 			if err != nil {
-				log.Printf("❌ DB: Failed to get token %s: %v", tokenID, err)
+				slog.Error("Failed to get token", "token_id", tokenID, "err", err)
 				continue
 			}
 			
 			err = item.Value(func(val []byte) error {
 				var token TokenInfo
 				if err := json.Unmarshal(val, &token); err != nil {
-					log.Printf("❌ DB: Failed to unmarshal token %s: %v", tokenID, err)
+					slog.Error("Failed to unmarshal token", "token_id", tokenID, "err", err)
 					return nil // Skip invalid tokens
 				}
-				log.Printf("✅ DB: Loaded token %s (%s)", token.Name, token.Ticker)
+				slog.Debug("Loaded token", "name", token.Name, "ticker", token.Ticker)
 				tokens = append(tokens, token)
 				return nil
 			})
@@ -756,20 +1607,116 @@ func (d *Database) GetTokens(page, perPage int, search string) (*PaginatedTokens
 	
 	totalPages := int((totalTokens + int64(perPage) - 1) / int64(perPage))
 	
+	aggregates, err := d.GetTokenAggregates()
+	if err != nil {
+		return nil, err
+	}
+	
 	return &PaginatedTokens{
 		Tokens:      tokens,
 		CurrentPage: page,
 		TotalPages:  totalPages,
 		TotalTokens: totalTokens,
 		PerPage:     perPage,
+		Aggregates:  *aggregates,
+	}, nil
+}
+
+// GetTokensByCursor retrieves up to limit tokens after the token a previous
+// page ended on, in the same order as GetTokens. An empty cursor starts at
+// the first token.
+func (d *Database) GetTokensByCursor(search, cursor string, limit int) (*PaginatedTokens, error) {
+	prefix, ascending := "token_time:", false
+	if search != "" {
+		prefix, ascending = "token_ticker:"+search, true
+	}
+
+	tokens := []TokenInfo{}
+	var nextCursor string
+	var totalTokens int64
+	err := d.view(func(txn *badger.Txn) error {
+		tokenIDs, next, total, err := scanIndexPage(txn, []byte(prefix), cursor, ascending, limit)
+		if err != nil {
+			return err
+		}
+		nextCursor, totalTokens = next, total
+
+		for _, tokenID := range tokenIDs {
+			item, err := txn.Get([]byte("token:" + tokenID))
+			if err != nil {
+				slog.Error("Failed to get token", "token_id", tokenID, "err", err)
+				continue
+			}
+			var token TokenInfo
+			if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &token) }); err != nil {
+				slog.Error("Failed to unmarshal token", "token_id", tokenID, "err", err)
+				continue
+			}
+			tokens = append(tokens, token)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	aggregates, err := d.GetTokenAggregates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedTokens{
+		Tokens:      tokens,
+		TotalTokens: totalTokens,
+		PerPage:     limit,
+		Aggregates:  *aggregates,
+		NextCursor:  nextCursor,
 	}, nil
 }
 
+// GetTokenAggregates computes totals across all stored tokens
+func (d *Database) GetTokenAggregates() (*TokenAggregates, error) {
+	var aggregates TokenAggregates
+	
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		
+		prefix := []byte("token:")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var token TokenInfo
+				if err := json.Unmarshal(val, &token); err != nil {
+					return err
+				}
+				
+				aggregates.TotalTokens++
+				if token.TotalSupply > 0 {
+					aggregates.ActiveTokens++
+				}
+				aggregates.TotalValueLocked += token.MeltValue
+				return nil
+			})
+			if err != nil {
+				slog.Error("Failed to read token", "key", string(it.Item().Key()), "err", err)
+			}
+		}
+		
+		return nil
+	})
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return &aggregates, nil
+}
+
 // GetToken retrieves a single token by ID
 func (d *Database) GetToken(tokenID string) (*TokenInfo, error) {
 	var token TokenInfo
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		key := fmt.Sprintf("token:%s", tokenID)
 		item, err := txn.Get([]byte(key))
 		if err != nil {
@@ -798,29 +1745,96 @@ func (d *Database) GetTokenDetails(tokenID string) (*TokenDetails, error) {
 	// Get token transactions
 	transactions, err := d.GetTokenTransactions(tokenID, 20)
 	if err != nil {
-		log.Printf("Failed to get token transactions: %v", err)
+		slog.Error("Failed to get token transactions", "err", err)
 		transactions = []TokenTransaction{} // Continue with empty list
 	}
 	
 	// Get token holders
 	holders, err := d.GetTokenHolders(tokenID, 50)
 	if err != nil {
-		log.Printf("Failed to get token holders: %v", err)
+		slog.Error("Failed to get token holders", "err", err)
 		holders = []TokenHolder{} // Continue with empty list
 	}
 	
-	return &TokenDetails{
-		TokenInfo:    *token,
-		Holders:      holders,
-		Transactions: transactions,
-	}, nil
+	details := &TokenDetails{
+		TokenInfo:    *token,
+		Holders:      holders,
+		Transactions: transactions,
+	}
+	
+	balances, err := d.getTokenHolderBalances(tokenID)
+	if err != nil {
+		slog.Error("Failed to get token holder balances", "err", err)
+	}
+	details.Top10Share, details.Gini = holderConcentration(balances)
+	details.HighlyConcentrated = details.Top10Share >= highConcentrationShare
+	
+	return details, nil
+}
+
+// highConcentrationShare is the top-10 share above which a token is flagged
+const highConcentrationShare = 0.9
+
+// getTokenHolderBalances returns every positive balance in the holder index
+func (d *Database) getTokenHolderBalances(tokenID string) ([]uint64, error) {
+	var balances []uint64
+	
+	err := d.view(func(txn *badger.Txn) error {
+		prefix := []byte(fmt.Sprintf("token_holder:%s:", tokenID))
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var holder TokenHolder
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &holder)
+			}); err != nil {
+				continue
+			}
+			if holder.Balance > 0 {
+				balances = append(balances, holder.Balance)
+			}
+		}
+		return nil
+	})
+	
+	return balances, err
+}
+
+// holderConcentration returns the share of the total held by the ten
+// largest balances and the Gini coefficient of all balances
+func holderConcentration(balances []uint64) (float64, float64) {
+	if len(balances) == 0 {
+		return 0, 0
+	}
+	
+	sorted := append([]uint64(nil), balances...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	
+	var total, top10, weighted float64
+	n := len(sorted)
+	for i, balance := range sorted {
+		value := float64(balance)
+		total += value
+		weighted += float64(i+1) * value
+		if i >= n-10 {
+			top10 += value
+		}
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	
+	// Gini over ascending balances: 2*sum(i*x_i)/(n*sum(x)) - (n+1)/n
+	gini := 2*weighted/(float64(n)*total) - float64(n+1)/float64(n)
+	return top10 / total, gini
 }
 
 // GetTokenTransactions retrieves transactions for a specific token
 func (d *Database) GetTokenTransactions(tokenID string, limit int) ([]TokenTransaction, error) {
 	var transactions []TokenTransaction
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		prefix := []byte(fmt.Sprintf("token_tx:%s:", tokenID))
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = prefix
@@ -829,7 +1843,7 @@ func (d *Database) GetTokenTransactions(tokenID string, limit int) ([]TokenTrans
 		defer it.Close()
 		
 		count := 0
-		for it.Rewind(); it.Valid() && count < limit; it.Next() {
+		for it.Seek(append(prefix, 0xff)); it.Valid() && count < limit; it.Next() {
 			err := it.Item().Value(func(val []byte) error {
 				var tokenTx TokenTransaction
 				if err := json.Unmarshal(val, &tokenTx); err != nil {
@@ -850,44 +1864,18 @@ func (d *Database) GetTokenTransactions(tokenID string, limit int) ([]TokenTrans
 	return transactions, err
 }
 
-// GetTokenHolders retrieves holders for a specific token
+// GetTokenHolders retrieves the largest holders of a token
 func (d *Database) GetTokenHolders(tokenID string, limit int) ([]TokenHolder, error) {
-	var holders []TokenHolder
-	
-	err := d.db.View(func(txn *badger.Txn) error {
-		prefix := []byte(fmt.Sprintf("token_holder:%s:", tokenID))
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = prefix
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		
-		count := 0
-		for it.Rewind(); it.Valid() && count < limit; it.Next() {
-			err := it.Item().Value(func(val []byte) error {
-				var holder TokenHolder
-				if err := json.Unmarshal(val, &holder); err != nil {
-					return nil // Skip invalid holders
-				}
-				if holder.Balance > 0 { // Only include holders with positive balance
-					holders = append(holders, holder)
-				}
-				return nil
-			})
-			if err != nil {
-				continue
-			}
-			count++
-		}
-		
-		return nil
-	})
-	
-	return holders, err
+	page, err := d.GetTokenHoldersPage(tokenID, 1, limit)
+	if err != nil {
+		return nil, err
+	}
+	return page.Holders, nil
 }
 
 // StoreTokenTransaction stores a token transaction
 func (d *Database) StoreTokenTransaction(tokenID string, tx *TokenTransaction) error {
-	return d.db.Update(func(txn *badger.Txn) error {
+	return d.update(func(txn *badger.Txn) error {
 		// Store transaction with timestamp-based key for sorting
 		txKey := fmt.Sprintf("token_tx:%s:%016d:%s", tokenID, tx.Timestamp.Unix(), tx.TxHash)
 		txData, err := json.Marshal(tx)
@@ -895,67 +1883,231 @@ func (d *Database) StoreTokenTransaction(tokenID string, tx *TokenTransaction) e
 			return fmt.Errorf("failed to marshal token transaction: %w", err)
 		}
 		
-		return txn.Set([]byte(txKey), txData)
+		// Only count activity the first time a transaction is seen
+		_, err = txn.Get([]byte(txKey))
+		alreadyStored := err == nil
+		
+		if err := txn.Set([]byte(txKey), txData); err != nil {
+			return err
+		}
+		if err := recordAtHeight(txn, tx.BlockHeight, txKey); err != nil {
+			return err
+		}
+		
+		if alreadyStored {
+			return nil
+		}
+		if err := recordTokenTrend(txn, tokenID, tx); err != nil {
+			return err
+		}
+		return updateTokenActivity(txn, tokenID, tx)
 	})
 }
 
-// UpdateTokenHolder updates token holder balance
-func (d *Database) UpdateTokenHolder(tokenID, address string, balance uint64) error {
-	return d.db.Update(func(txn *badger.Txn) error {
-		holderKey := fmt.Sprintf("token_holder:%s:%s", tokenID, address)
-		holder := TokenHolder{
-			Address: address,
-			Balance: balance,
+// updateTokenActivity records tx against the token-address index of every
+// address involved in it
+func updateTokenActivity(txn *badger.Txn, tokenID string, tx *TokenTransaction) error {
+	for _, address := range tokenActivityAddresses(tx) {
+		key := []byte(fmt.Sprintf("token_addr:%s:%s", address, tokenID))
+		activity := TokenActivity{TokenID: tokenID}
+		if item, err := txn.Get(key); err == nil {
+			err = item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &activity)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to read token activity: %w", err)
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
 		}
 		
-		holderData, err := json.Marshal(holder)
+		activity.add(address, tx)
+		data, err := json.Marshal(activity)
 		if err != nil {
-			return fmt.Errorf("failed to marshal token holder: %w", err)
+			return fmt.Errorf("failed to marshal token activity: %w", err)
+		}
+		if err := txn.Set(key, data); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// tokenActivityAddresses lists the addresses a token transaction counts
+// toward; a self-transfer touches the index once
+func tokenActivityAddresses(tx *TokenTransaction) []string {
+	var addresses []string
+	if tx.FromAddress != "" {
+		addresses = append(addresses, tx.FromAddress)
+	}
+	if tx.ToAddress != "" && tx.ToAddress != tx.FromAddress {
+		addresses = append(addresses, tx.ToAddress)
+	}
+	return addresses
+}
+
+// add counts tx, as seen by address, into the activity
+func (activity *TokenActivity) add(address string, tx *TokenTransaction) {
+	opType := strings.ToLower(tx.Type)
+	switch {
+	case opType == "create" && address == tx.ToAddress:
+		activity.Created++
+	case opType == "melt" && address == tx.FromAddress:
+		activity.Melted++
+	case opType == "transfer" && address == tx.FromAddress:
+		activity.Sent++
+	case opType == "transfer" && address == tx.ToAddress:
+		activity.Received++
+	}
+	activity.TxCount++
+	
+	if activity.FirstActivity.IsZero() || tx.Timestamp.Before(activity.FirstActivity) {
+		activity.FirstActivity = tx.Timestamp
+	}
+	if tx.Timestamp.After(activity.LastActivity) {
+		activity.LastActivity = tx.Timestamp
+	}
+}
+
+// GetTokenActivity returns every token an address has sent, received,
+// created or melted, including tokens it no longer holds, most recent first
+func (d *Database) GetTokenActivity(address string) ([]TokenActivity, error) {
+	activities := []TokenActivity{}
+	
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		
+		prefix := []byte(fmt.Sprintf("token_addr:%s:", address))
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var activity TokenActivity
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &activity)
+			})
+			if err != nil {
+				slog.Error("Failed to read token activity", "key", string(it.Item().Key()), "err", err)
+				continue
+			}
+			
+			// Fill in token details and the current balance
+			if item, err := txn.Get([]byte(fmt.Sprintf("token:%s", activity.TokenID))); err == nil {
+				item.Value(func(val []byte) error {
+					var token TokenInfo
+					if err := json.Unmarshal(val, &token); err == nil {
+						activity.TokenName = token.Name
+						activity.TokenTicker = token.Ticker
+					}
+					return nil
+				})
+			}
+			if item, err := txn.Get([]byte(fmt.Sprintf("token_holder:%s:%s", activity.TokenID, address))); err == nil {
+				item.Value(func(val []byte) error {
+					var holder TokenHolder
+					if err := json.Unmarshal(val, &holder); err == nil {
+						activity.Balance = holder.Balance
+					}
+					return nil
+				})
+			}
+			
+			activities = append(activities, activity)
 		}
 		
-		return txn.Set([]byte(holderKey), holderData)
+		return nil
+	})
+	
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].LastActivity.After(activities[j].LastActivity)
+	})
+	
+	return activities, err
+}
+
+// UpdateTokenHolder updates token holder balance
+func (d *Database) UpdateTokenHolder(tokenID, address string, balance uint64) error {
+	return d.update(func(txn *badger.Txn) error {
+		return setTokenHolder(txn, tokenID, address, balance)
 	})
 }
 
 // StorePool stores liquidity pool information
 func (d *Database) StorePool(pool *LiquidityPool) error {
-	return d.db.Update(func(txn *badger.Txn) error {
-		// Store full pool data
-		poolKey := fmt.Sprintf("pool:%s", pool.PoolID)
-		poolData, err := json.Marshal(pool)
-		if err != nil {
-			return fmt.Errorf("failed to marshal pool: %w", err)
-		}
-		
-		log.Printf("💾 Storing pool with key: %s", poolKey)
-		if err := txn.Set([]byte(poolKey), poolData); err != nil {
-			return fmt.Errorf("failed to store pool: %w", err)
-		}
-		
-		// Index by token pair for searching
-		pairKey := fmt.Sprintf("pool_pair:%s_%s:%s", pool.TokenA, pool.TokenB, pool.PoolID)
-		log.Printf("💾 Creating pair index: %s", pairKey)
-		if err := txn.Set([]byte(pairKey), []byte(pool.PoolID)); err != nil {
-			return fmt.Errorf("failed to store pair index: %w", err)
+	return d.update(func(txn *badger.Txn) error {
+		return storePool(txn, pool)
+	})
+}
+
+// storePool writes a pool and its indexes inside txn, dropping the TVL
+// index entry of the version it replaces
+func storePool(txn *badger.Txn, pool *LiquidityPool) error {
+	// Store full pool data
+	poolKey := fmt.Sprintf("pool:%s", pool.PoolID)
+	poolData, err := json.Marshal(pool)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pool: %w", err)
+	}
+	
+	if item, err := txn.Get([]byte(poolKey)); err == nil {
+		var previous LiquidityPool
+		if item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &previous)
+		}) == nil && previous.TVL != pool.TVL {
+			staleKey := fmt.Sprintf("pool_tvl:%016d:%s", previous.TVL, pool.PoolID)
+			if err := txn.Delete([]byte(staleKey)); err != nil {
+				return fmt.Errorf("failed to drop stale TVL index: %w", err)
+			}
 		}
-		
-		// Index by creation time for sorting
-		creationKey := fmt.Sprintf("pool_time:%016d:%s", pool.CreationTime.Unix(), pool.PoolID)
-		log.Printf("💾 Creating time index: %s", creationKey)
-		if err := txn.Set([]byte(creationKey), []byte(pool.PoolID)); err != nil {
-			return fmt.Errorf("failed to store creation time index: %w", err)
+	} else if err != badger.ErrKeyNotFound {
+		return err
+	}
+	
+	slog.Debug("Storing pool", "key", poolKey)
+	if err := txn.Set([]byte(poolKey), poolData); err != nil {
+		return fmt.Errorf("failed to store pool: %w", err)
+	}
+	if err := recordAtHeight(txn, pool.CreationBlock, poolKey); err != nil {
+		return fmt.Errorf("failed to store height index: %w", err)
+	}
+	
+	// Index by L-address so swaps find their pool
+	if pool.LAddress != "" {
+		addressKey := fmt.Sprintf("pool_addr:%s", pool.LAddress)
+		if err := txn.Set([]byte(addressKey), []byte(pool.PoolID)); err != nil {
+			return fmt.Errorf("failed to store address index: %w", err)
 		}
-		
-		// Index by TVL for sorting by value
-		tvlKey := fmt.Sprintf("pool_tvl:%016d:%s", pool.TVL, pool.PoolID)
-		log.Printf("💾 Creating TVL index: %s", tvlKey)
-		if err := txn.Set([]byte(tvlKey), []byte(pool.PoolID)); err != nil {
-			return fmt.Errorf("failed to store TVL index: %w", err)
+	}
+	
+	// Index by token pair for searching
+	pairKey := fmt.Sprintf("pool_pair:%s_%s:%s", pool.TokenA, pool.TokenB, pool.PoolID)
+	slog.Debug("Creating pair index", "key", pairKey)
+	if err := txn.Set([]byte(pairKey), []byte(pool.PoolID)); err != nil {
+		return fmt.Errorf("failed to store pair index: %w", err)
+	}
+	
+	// Index by each side's token id and symbol so a token finds every pool it is in
+	for _, token := range poolTokenKeys(pool) {
+		if err := txn.Set(poolTokenKey(token, pool.PoolID), []byte(pool.PoolID)); err != nil {
+			return fmt.Errorf("failed to store token index: %w", err)
 		}
-		
-		log.Printf("✅ Pool %s stored with all indexes", pool.PoolID)
-		return nil
-	})
+	}
+	
+	// Index by creation time for sorting
+	creationKey := fmt.Sprintf("pool_time:%016d:%s", pool.CreationTime.Unix(), pool.PoolID)
+	slog.Debug("Creating time index", "key", creationKey)
+	if err := txn.Set([]byte(creationKey), []byte(pool.PoolID)); err != nil {
+		return fmt.Errorf("failed to store creation time index: %w", err)
+	}
+	
+	// Index by TVL for sorting by value
+	tvlKey := fmt.Sprintf("pool_tvl:%016d:%s", pool.TVL, pool.PoolID)
+	slog.Debug("Creating TVL index", "key", tvlKey)
+	if err := txn.Set([]byte(tvlKey), []byte(pool.PoolID)); err != nil {
+		return fmt.Errorf("failed to store TVL index: %w", err)
+	}
+	
+	slog.Debug("Stored pool with all indexes", "pool_id", pool.PoolID)
+	return nil
 }
 
 // GetPools retrieves pools with pagination and optional search
@@ -963,9 +2115,9 @@ func (d *Database) GetPools(page, perPage int, search string) (*PaginatedPools,
 	var pools []LiquidityPool
 	var totalPools int64
 	
-	log.Printf("🔍 DB: GetPools called - page=%d, perPage=%d, search='%s'", page, perPage, search)
+	slog.Debug("Listing pools", "page", page, "per_page", perPage, "search", search)
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		// Get all keys and filter in Go code (consistent with GetTokens approach)
 		opts := badger.DefaultIteratorOptions
 		opts.PrefetchValues = false
@@ -990,7 +2142,7 @@ func (d *Database) GetPools(page, perPage int, search string) (*PaginatedPools,
 			}
 		}
 		
-		log.Printf("📊 DB: Found %d keys matching prefix '%s'", len(matchingKeys), searchPrefix)
+		slog.Debug("Found matching keys", "count", len(matchingKeys), "prefix", searchPrefix)
 		
 		// For pool_tvl keys, we want highest TVL first (reverse sort)
 		if searchPrefix == "pool_tvl:" {
@@ -1005,7 +2157,7 @@ func (d *Database) GetPools(page, perPage int, search string) (*PaginatedPools,
 		for _, key := range matchingKeys {
 			item, err := txn.Get([]byte(key))
 			if err != nil {
-				log.Printf("❌ Failed to get value for key %s: %v", key, err)
+				slog.Error("Failed to get value", "key", key, "err", err)
 				continue
 			}
 			
@@ -1015,13 +2167,13 @@ func (d *Database) GetPools(page, perPage int, search string) (*PaginatedPools,
 				return nil
 			})
 			if err != nil {
-				log.Printf("❌ Failed to read value for key %s: %v", key, err)
+				slog.Error("Failed to read value", "key", key, "err", err)
 				continue
 			}
 		}
 		
 		totalPools = int64(len(poolIDs))
-		log.Printf("📊 DB: Found %d total pools with prefix '%s'", totalPools, searchPrefix)
+		slog.Debug("Counted pools", "count", totalPools, "prefix", searchPrefix)
 		
 		// Calculate pagination
 		totalPages := int((totalPools + int64(perPage) - 1) / int64(perPage))
@@ -1039,24 +2191,24 @@ func (d *Database) GetPools(page, perPage int, search string) (*PaginatedPools,
 			end = len(poolIDs)
 		}
 		
-		log.Printf("📊 DB: Getting pools %d-%d from %d total", start, end-1, len(poolIDs))
+		slog.Debug("Loading pools", "from", start, "to", end-1, "total", len(poolIDs))
 		for i := start; i < end; i++ {
 			poolID := poolIDs[i]
 			poolKey := fmt.Sprintf("pool:%s", poolID)
 			
 			item, err := txn.Get([]byte(poolKey))
 			if err != nil {
-				log.Printf("❌ DB: Failed to get pool %s: %v", poolID, err)
+				slog.Error("Failed to get pool", "pool_id", poolID, "err", err)
 				continue
 			}
 			
 			err = item.Value(func(val []byte) error {
 				var pool LiquidityPool
 				if err := json.Unmarshal(val, &pool); err != nil {
-					log.Printf("❌ DB: Failed to unmarshal pool %s: %v", poolID, err)
+					slog.Error("Failed to unmarshal pool", "pool_id", poolID, "err", err)
 					return nil
 				}
-				log.Printf("✅ DB: Loaded pool %s/%s", pool.TokenASymbol, pool.TokenBSymbol)
+				slog.Debug("Loaded pool", "pair", pool.TokenASymbol+"/"+pool.TokenBSymbol)
 				pools = append(pools, pool)
 				return nil
 			})
@@ -1083,11 +2235,159 @@ func (d *Database) GetPools(page, perPage int, search string) (*PaginatedPools,
 	}, nil
 }
 
+// GetPoolsByCursor retrieves up to limit pools after the pool a previous
+// page ended on, in the same order as GetPools. An empty cursor starts at
+// the first pool.
+func (d *Database) GetPoolsByCursor(search, cursor string, limit int) (*PaginatedPools, error) {
+	prefix, ascending := "pool_tvl:", false
+	if search != "" {
+		prefix, ascending = "pool_pair:"+search, true
+	}
+
+	pools := []LiquidityPool{}
+	var nextCursor string
+	var totalPools int64
+	err := d.view(func(txn *badger.Txn) error {
+		poolIDs, next, total, err := scanIndexPage(txn, []byte(prefix), cursor, ascending, limit)
+		if err != nil {
+			return err
+		}
+		nextCursor, totalPools = next, total
+
+		for _, poolID := range poolIDs {
+			item, err := txn.Get([]byte("pool:" + poolID))
+			if err != nil {
+				slog.Error("Failed to get pool", "pool_id", poolID, "err", err)
+				continue
+			}
+			var pool LiquidityPool
+			if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &pool) }); err != nil {
+				slog.Error("Failed to unmarshal pool", "pool_id", poolID, "err", err)
+				continue
+			}
+			pools = append(pools, pool)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedPools{
+		Pools:      pools,
+		TotalPools: totalPools,
+		PerPage:    limit,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// poolTokenPrefix is where the pools indexed under token start. The token
+// is length prefixed, so a symbol containing ':' cannot match as a prefix
+// of another.
+func poolTokenPrefix(token string) string {
+	return fmt.Sprintf("pool_token:%d:%s:", len(token), token)
+}
+
+func poolTokenKey(token, poolID string) []byte {
+	return []byte(poolTokenPrefix(token) + poolID)
+}
+
+// poolTokenKeys returns the normalized token ids and symbols on both sides of a pool
+func poolTokenKeys(pool *LiquidityPool) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, token := range []string{pool.TokenA, pool.TokenASymbol, pool.TokenB, pool.TokenBSymbol} {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		keys = append(keys, token)
+	}
+	return keys
+}
+
+// GetPoolsByToken retrieves all pools containing a token, matched by id or
+// symbol on either side of the pair
+func (d *Database) GetPoolsByToken(token string, page, perPage int) (*PaginatedPools, error) {
+	pools := []LiquidityPool{}
+	var totalPools int64
+	
+	token = strings.ToLower(strings.TrimSpace(token))
+	if page < 1 {
+		page = 1
+	}
+	
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		// A token whose id equals another token's symbol can hit a pool twice
+		prefix := []byte(poolTokenPrefix(token))
+		seen := make(map[string]bool)
+		var poolIDs []string
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			poolID := string(it.Item().Key()[len(prefix):])
+			if !seen[poolID] {
+				seen[poolID] = true
+				poolIDs = append(poolIDs, poolID)
+			}
+		}
+		
+		totalPools = int64(len(poolIDs))
+		
+		start := (page - 1) * perPage
+		if start > len(poolIDs) {
+			start = len(poolIDs)
+		}
+		end := start + perPage
+		if end > len(poolIDs) {
+			end = len(poolIDs)
+		}
+		
+		for _, poolID := range poolIDs[start:end] {
+			item, err := txn.Get([]byte(fmt.Sprintf("pool:%s", poolID)))
+			if err != nil {
+				slog.Error("Failed to get pool", "pool_id", poolID, "err", err)
+				continue
+			}
+			
+			err = item.Value(func(val []byte) error {
+				var pool LiquidityPool
+				if err := json.Unmarshal(val, &pool); err != nil {
+					return err
+				}
+				pools = append(pools, pool)
+				return nil
+			})
+			if err != nil {
+				slog.Error("Failed to unmarshal pool", "pool_id", poolID, "err", err)
+			}
+		}
+		
+		return nil
+	})
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return &PaginatedPools{
+		Pools:       pools,
+		CurrentPage: page,
+		TotalPages:  int((totalPools + int64(perPage) - 1) / int64(perPage)),
+		TotalPools:  totalPools,
+		PerPage:     perPage,
+	}, nil
+}
+
 // GetPool retrieves a single pool by ID
 func (d *Database) GetPool(poolID string) (*LiquidityPool, error) {
 	var pool LiquidityPool
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		key := fmt.Sprintf("pool:%s", poolID)
 		item, err := txn.Get([]byte(key))
 		if err != nil {
@@ -1106,6 +2406,24 @@ func (d *Database) GetPool(poolID string) (*LiquidityPool, error) {
 	return &pool, nil
 }
 
+// GetPoolByAddress retrieves the pool whose L-address is address
+func (d *Database) GetPoolByAddress(address string) (*LiquidityPool, error) {
+	var poolID string
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(fmt.Sprintf("pool_addr:%s", address)))
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		poolID = string(value)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.GetPool(poolID)
+}
+
 // GetPoolDetails retrieves detailed pool information including transactions
 func (d *Database) GetPoolDetails(poolID string) (*PoolDetails, error) {
 	pool, err := d.GetPool(poolID)
@@ -1116,21 +2434,32 @@ func (d *Database) GetPoolDetails(poolID string) (*PoolDetails, error) {
 	// Get pool transactions
 	transactions, err := d.GetPoolTransactions(poolID, 20)
 	if err != nil {
-		log.Printf("Failed to get pool transactions: %v", err)
+		slog.Error("Failed to get pool transactions", "err", err)
 		transactions = []PoolTransaction{}
 	}
 	
-	return &PoolDetails{
+	details := &PoolDetails{
 		LiquidityPool: *pool,
 		Transactions:  transactions,
-	}, nil
+	}
+	
+	// APR comes from recent trading rather than the stored figure
+	activity, err := d.GetPoolActivity(pool, time.Now())
+	if err != nil {
+		slog.Error("Failed to compute pool activity", "err", err)
+	}
+	details.PoolActivity = activity
+	details.FeeRate = poolFeeRate(pool)
+	details.APR = activity.FeeAPR + activity.RewardAPR
+	
+	return details, nil
 }
 
 // GetPoolTransactions retrieves transactions for a specific pool
 func (d *Database) GetPoolTransactions(poolID string, limit int) ([]PoolTransaction, error) {
 	var transactions []PoolTransaction
 	
-	err := d.db.View(func(txn *badger.Txn) error {
+	err := d.view(func(txn *badger.Txn) error {
 		// Get all keys and filter for pool transactions
 		opts := badger.DefaultIteratorOptions
 		opts.PrefetchValues = false
@@ -1184,9 +2513,10 @@ func (d *Database) GetPoolTransactions(poolID string, limit int) ([]PoolTransact
 	return transactions, err
 }
 
-// StorePoolTransaction stores a pool transaction
+// StorePoolTransaction stores a pool transaction and pushes the pool's
+// current price to live subscribers
 func (d *Database) StorePoolTransaction(poolID string, tx *PoolTransaction) error {
-	return d.db.Update(func(txn *badger.Txn) error {
+	err := d.update(func(txn *badger.Txn) error {
 		// Store transaction with timestamp-based key for sorting
 		txKey := fmt.Sprintf("pool_tx:%s:%016d:%s", poolID, tx.Timestamp.Unix(), tx.TxHash)
 		txData, err := json.Marshal(tx)
@@ -1194,6 +2524,50 @@ func (d *Database) StorePoolTransaction(poolID string, tx *PoolTransaction) erro
 			return fmt.Errorf("failed to marshal pool transaction: %w", err)
 		}
 		
-		return txn.Set([]byte(txKey), txData)
+		if err := txn.Set([]byte(txKey), txData); err != nil {
+			return err
+		}
+		return recordAtHeight(txn, tx.BlockHeight, txKey)
 	})
+	if err != nil {
+		return err
+	}
+	
+	publishPoolPrice(d, poolID, tx)
+	return nil
+}
+
+// publishPoolPrice pushes the pool's price after tx to live subscribers
+func publishPoolPrice(store Store, poolID string, tx *PoolTransaction) {
+	if store.Feed().SubscriberCount(poolFeedTopic(poolID)) == 0 {
+		return
+	}
+	pool, err := store.GetPool(poolID)
+	if err != nil {
+		slog.Warn("Pool not found for price update", "pool_id", poolID, "err", err)
+		return
+	}
+	update := newPoolPriceUpdate(pool)
+	update.TxHash = tx.TxHash
+	update.TxType = tx.Type
+	update.Timestamp = tx.Timestamp
+	store.Feed().Publish(poolFeedTopic(poolID), FeedEvent{Type: "price", Data: update})
+}
+
+// poolFeedTopic is the feed topic carrying a pool's price updates
+func poolFeedTopic(poolID string) string {
+	return "pool:" + poolID
+}
+
+// newPoolPriceUpdate snapshots a pool's reserves and spot price
+func newPoolPriceUpdate(pool *LiquidityPool) PoolPriceUpdate {
+	return PoolPriceUpdate{
+		PoolID:       pool.PoolID,
+		TokenASymbol: pool.TokenASymbol,
+		TokenBSymbol: pool.TokenBSymbol,
+		ReserveA:     pool.ReserveA,
+		ReserveB:     pool.ReserveB,
+		SpotPrice:    poolSpotPrice(pool),
+		Timestamp:    pool.LastActivity,
+	}
 }
\ No newline at end of file
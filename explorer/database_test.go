@@ -0,0 +1,649 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+func newTestDatabase(t *testing.T) *Database {
+	database, err := NewDatabase(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func storeTestPools(t *testing.T, database Store) {
+	pools := []*LiquidityPool{
+		{PoolID: "pool_gold_silver", TokenA: "tok_gold", TokenASymbol: "GOLD", TokenB: "tok_silver", TokenBSymbol: "SILVER", TVL: 300},
+		{PoolID: "pool_copper_gold", TokenA: "tok_copper", TokenASymbol: "COPPER", TokenB: "tok_gold", TokenBSymbol: "GOLD", TVL: 200},
+		{PoolID: "pool_copper_shadow", TokenA: "tok_copper", TokenASymbol: "COPPER", TokenB: "", TokenBSymbol: "SHADOW", TVL: 100},
+	}
+	for _, pool := range pools {
+		pool.CreationTime = time.Now()
+		if err := database.StorePool(pool); err != nil {
+			t.Fatalf("Failed to store pool %s: %v", pool.PoolID, err)
+		}
+	}
+}
+
+func poolIDs(pools []LiquidityPool) []string {
+	var ids []string
+	for _, pool := range pools {
+		ids = append(ids, pool.PoolID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestGetPoolsByTokenMatchesEitherSide(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestPools(t, database)
+
+	want := []string{"pool_copper_gold", "pool_gold_silver"}
+	for _, token := range []string{"tok_gold", "GOLD", "gold"} {
+		result, err := database.GetPoolsByToken(token, 1, 20)
+		if err != nil {
+			t.Fatalf("GetPoolsByToken(%q) failed: %v", token, err)
+		}
+		got := poolIDs(result.Pools)
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("GetPoolsByToken(%q) = %v, want %v", token, got, want)
+		}
+		if result.TotalPools != 2 {
+			t.Errorf("GetPoolsByToken(%q) total = %d, want 2", token, result.TotalPools)
+		}
+	}
+
+	// Token ids must not match as prefixes of other ids
+	result, err := database.GetPoolsByToken("tok", 1, 20)
+	if err != nil {
+		t.Fatalf("GetPoolsByToken failed: %v", err)
+	}
+	if len(result.Pools) != 0 {
+		t.Errorf("Partial token id should not match, got %v", poolIDs(result.Pools))
+	}
+}
+
+// checkPoolsByTokenWithColons looks up pools whose symbols contain ':', so
+// one symbol is the start of another's key
+func checkPoolsByTokenWithColons(t *testing.T, store Store) {
+	for _, pool := range []*LiquidityPool{
+		{PoolID: "pool_a", TokenA: "tok_a", TokenASymbol: "A", TokenB: "tok_x", TokenBSymbol: "X"},
+		{PoolID: "pool_ab", TokenA: "tok_ab", TokenASymbol: "A:B", TokenB: "tok_y", TokenBSymbol: "Y"},
+		{PoolID: "pool_b", TokenA: "tok_b", TokenASymbol: "B:pool_a", TokenB: "tok_z", TokenBSymbol: "Z"},
+	} {
+		pool.CreationTime = time.Now()
+		if err := store.StorePool(pool); err != nil {
+			t.Fatalf("Failed to store pool %s: %v", pool.PoolID, err)
+		}
+	}
+
+	for token, want := range map[string]string{"a": "pool_a", "a:b": "pool_ab", "b": "", "b:pool_a": "pool_b"} {
+		result, err := store.GetPoolsByToken(token, 1, 20)
+		if err != nil {
+			t.Fatalf("GetPoolsByToken(%q) failed: %v", token, err)
+		}
+		got := strings.Join(poolIDs(result.Pools), ",")
+		if got != want || result.TotalPools != int64(len(result.Pools)) {
+			t.Errorf("GetPoolsByToken(%q) = %q (total %d), want %q", token, got, result.TotalPools, want)
+		}
+	}
+}
+
+func TestGetPoolsByTokenWithColons(t *testing.T) {
+	checkPoolsByTokenWithColons(t, newTestDatabase(t))
+}
+
+func TestGetPoolsByTokenPagination(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestPools(t, database)
+
+	first, err := database.GetPoolsByToken("COPPER", 1, 1)
+	if err != nil {
+		t.Fatalf("GetPoolsByToken failed: %v", err)
+	}
+	second, err := database.GetPoolsByToken("COPPER", 2, 1)
+	if err != nil {
+		t.Fatalf("GetPoolsByToken failed: %v", err)
+	}
+	if first.TotalPages != 2 || len(first.Pools) != 1 || len(second.Pools) != 1 {
+		t.Fatalf("Unexpected pages: %+v / %+v", first, second)
+	}
+	if first.Pools[0].PoolID == second.Pools[0].PoolID {
+		t.Error("Pages should not repeat pools")
+	}
+}
+
+func TestPoolsAPITokenFilter(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestPools(t, database)
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handlePoolsAPI(rec, httptest.NewRequest("GET", "/api/v1/pools?token=tok_silver", nil))
+
+	var result PaginatedPools
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(result.Pools) != 1 || result.Pools[0].PoolID != "pool_gold_silver" {
+		t.Errorf("Expected only pool_gold_silver, got %v", poolIDs(result.Pools))
+	}
+}
+
+func TestTokenAggregatesIndependentOfPaging(t *testing.T) {
+	database := newTestDatabase(t)
+
+	tokens := []*TokenInfo{
+		{TokenID: "tok_a", Name: "Alpha", Ticker: "ALP", TotalSupply: 1000, MeltValue: 500},
+		{TokenID: "tok_b", Name: "Beta", Ticker: "BET", TotalSupply: 2000, MeltValue: 1500},
+		{TokenID: "tok_c", Name: "Gamma", Ticker: "GAM", TotalSupply: 0, MeltValue: 0},
+	}
+	for i, token := range tokens {
+		token.CreationTime = time.Unix(int64(1700000000+i), 0)
+		if err := database.StoreToken(token); err != nil {
+			t.Fatalf("Failed to store token %s: %v", token.TokenID, err)
+		}
+	}
+
+	want := TokenAggregates{TotalTokens: 3, ActiveTokens: 2, TotalValueLocked: 2000}
+	for page := 1; page <= 3; page++ {
+		result, err := database.GetTokens(page, 1, "")
+		if err != nil {
+			t.Fatalf("GetTokens page %d failed: %v", page, err)
+		}
+		if len(result.Tokens) != 1 {
+			t.Errorf("Page %d: expected 1 token, got %d", page, len(result.Tokens))
+		}
+		if result.Aggregates != want {
+			t.Errorf("Page %d: aggregates %+v, want %+v", page, result.Aggregates, want)
+		}
+	}
+
+	// Searching narrows the page but not the aggregates
+	result, err := database.GetTokens(1, 20, "BET")
+	if err != nil {
+		t.Fatalf("GetTokens search failed: %v", err)
+	}
+	if result.TotalTokens != 1 {
+		t.Errorf("Search should match 1 token, got %d", result.TotalTokens)
+	}
+	if result.Aggregates != want {
+		t.Errorf("Search aggregates %+v, want %+v", result.Aggregates, want)
+	}
+}
+
+func TestTokenActivityIncludesFullyTransferredToken(t *testing.T) {
+	database := newTestDatabase(t)
+	svc := NewSyncService("", newTestNodeClient(), database)
+	block := &Block{Header: BlockHeader{Height: 1}}
+	start := time.Unix(1700000000, 0).UTC()
+
+	ops := []*TokenOperation{
+		{Type: TOKEN_CREATE, TokenID: "abcdef0123456789", Amount: 100, To: "alice",
+			Metadata: &TokenMetadata{Name: "Alpha", Ticker: "ALP", Decimals: 2, LockAmount: 10}},
+		{Type: TOKEN_TRANSFER, TokenID: "abcdef0123456789", Amount: 100, From: "alice", To: "bob"},
+	}
+	for i, op := range ops {
+		txHash := fmt.Sprintf("tx%d", i)
+		if err := svc.processTokenOperation("blockhash", block, txHash, op, start.Add(time.Duration(i)*time.Minute), indexAll); err != nil {
+			t.Fatalf("Failed to process op %d: %v", i, err)
+		}
+	}
+
+	// Replaying a transaction (e.g. on resync) must not double count
+	replay := &TokenTransaction{TxHash: "tx1", Timestamp: start.Add(time.Minute), Type: "TRANSFER", Amount: 100, FromAddress: "alice", ToAddress: "bob"}
+	if err := database.StoreTokenTransaction("abcdef0123456789", replay); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	activity, err := database.GetTokenActivity("alice")
+	if err != nil {
+		t.Fatalf("GetTokenActivity failed: %v", err)
+	}
+	if len(activity) != 1 {
+		t.Fatalf("Expected 1 token in alice's activity, got %d", len(activity))
+	}
+	got := activity[0]
+	if got.Balance != 0 {
+		t.Errorf("Alice transferred everything, expected balance 0, got %d", got.Balance)
+	}
+	if got.Created != 1 || got.Sent != 1 || got.Received != 0 || got.TxCount != 2 {
+		t.Errorf("Unexpected counts for alice: %+v", got)
+	}
+	if got.TokenTicker != "ALP" || got.TokenName != "Alpha" {
+		t.Errorf("Expected token details, got %q/%q", got.TokenName, got.TokenTicker)
+	}
+	if !got.LastActivity.Equal(start.Add(time.Minute)) || !got.FirstActivity.Equal(start) {
+		t.Errorf("Unexpected activity window %v - %v", got.FirstActivity, got.LastActivity)
+	}
+
+	// Balances only list what alice still holds
+	balances, err := database.GetWalletTokenBalances("alice")
+	if err != nil {
+		t.Fatalf("GetWalletTokenBalances failed: %v", err)
+	}
+	if len(balances) != 0 {
+		t.Errorf("Expected no balances for alice, got %+v", balances)
+	}
+
+	bob, err := database.GetTokenActivity("bob")
+	if err != nil {
+		t.Fatalf("GetTokenActivity failed: %v", err)
+	}
+	if len(bob) != 1 || bob[0].Received != 1 || bob[0].Balance != 100 {
+		t.Errorf("Unexpected activity for bob: %+v", bob)
+	}
+}
+
+func TestTokenActivityAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	tx := &TokenTransaction{TxHash: "tx0", Timestamp: time.Now(), Type: "MELT", Amount: 5, FromAddress: "carol"}
+	if err := database.StoreTokenTransaction("tok_melted", tx); err != nil {
+		t.Fatalf("Failed to store token transaction: %v", err)
+	}
+
+	es := &ExplorerServer{database: database}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/wallet/{address}/token-activity", es.handleTokenActivityAPI)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/wallet/carol/token-activity", nil))
+
+	var body struct {
+		Tokens []TokenActivity `json:"tokens"`
+		Count  int             `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Count != 1 || body.Tokens[0].TokenID != "tok_melted" || body.Tokens[0].Melted != 1 {
+		t.Errorf("Unexpected token activity response: %+v", body)
+	}
+}
+
+func storeTestMiningRewards(t *testing.T, database *Database) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rewards := []struct {
+		address string
+		height  uint64
+	}{
+		{"farmer1", 5},
+		{"farmer1", 12},
+		{"farmer10", 50},
+		{"farmer1", 100},
+		{"farmer1", 1000},
+	}
+	for _, r := range rewards {
+		tx := &WalletTransaction{
+			TxHash:      fmt.Sprintf("coinbase_%d", r.height),
+			BlockHash:   fmt.Sprintf("block_%d", r.height),
+			BlockHeight: r.height,
+			Timestamp:   base.Add(time.Duration(r.height) * time.Minute),
+			Type:        "mining_reward",
+			Amount:      5000000000 + r.height,
+			ToAddress:   r.address,
+		}
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store mining reward at %d: %v", r.height, err)
+		}
+	}
+
+	// Ordinary payments to a farmer are not mined blocks
+	transfer := &WalletTransaction{TxHash: "tx_pay", BlockHeight: 200, Type: "transfer", Amount: 7, FromAddress: "alice", ToAddress: "farmer1"}
+	if err := database.StoreTransaction(transfer); err != nil {
+		t.Fatalf("Failed to store transfer: %v", err)
+	}
+}
+
+func TestGetMinedBlocksAcrossHeights(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestMiningRewards(t, database)
+
+	first, err := database.GetMinedBlocks("farmer1", 1, 3)
+	if err != nil {
+		t.Fatalf("GetMinedBlocks failed: %v", err)
+	}
+	if first.TotalBlocks != 4 || first.TotalPages != 2 {
+		t.Fatalf("Expected 4 blocks over 2 pages, got %d over %d", first.TotalBlocks, first.TotalPages)
+	}
+
+	var heights []uint64
+	for _, block := range first.Blocks {
+		heights = append(heights, block.Height)
+	}
+	if len(heights) != 3 || heights[0] != 1000 || heights[1] != 100 || heights[2] != 12 {
+		t.Fatalf("Expected newest-first heights [1000 100 12], got %v", heights)
+	}
+	if first.Blocks[0].Hash != "block_1000" || first.Blocks[0].Reward != 5000001000 {
+		t.Errorf("Unexpected mined block: %+v", first.Blocks[0])
+	}
+
+	second, err := database.GetMinedBlocks("farmer1", 2, 3)
+	if err != nil {
+		t.Fatalf("GetMinedBlocks page 2 failed: %v", err)
+	}
+	if len(second.Blocks) != 1 || second.Blocks[0].Height != 5 {
+		t.Errorf("Expected only height 5 on page 2, got %+v", second.Blocks)
+	}
+
+	// An address sharing a prefix must not leak into the result
+	other, err := database.GetMinedBlocks("farmer10", 1, 20)
+	if err != nil {
+		t.Fatalf("GetMinedBlocks for farmer10 failed: %v", err)
+	}
+	if other.TotalBlocks != 1 || other.Blocks[0].Height != 50 {
+		t.Errorf("Unexpected blocks for farmer10: %+v", other.Blocks)
+	}
+}
+
+func TestMinedBlocksAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestMiningRewards(t, database)
+
+	es := &ExplorerServer{database: database}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/wallet/{address}/mined", es.handleMinedBlocksAPI)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/wallet/farmer1/mined?page=2&per_page=2", nil))
+
+	var body PaginatedMinedBlocks
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.CurrentPage != 2 || body.TotalBlocks != 4 || len(body.Blocks) != 2 {
+		t.Fatalf("Unexpected paging in response: %+v", body)
+	}
+	if body.Blocks[0].Height != 12 || body.Blocks[1].Height != 5 {
+		t.Errorf("Expected heights 12 and 5 on page 2, got %+v", body.Blocks)
+	}
+}
+
+func storeTestLedger(t *testing.T, database *Database) {
+	txs := []*WalletTransaction{
+		{TxHash: "coinbase_1", BlockHeight: 1, Type: "mining_reward", Amount: 100, ToAddress: "alice"},
+		{TxHash: "coinbase_2", BlockHeight: 2, Type: "mining_reward", Amount: 100, ToAddress: "bob"},
+		{TxHash: "tx_pay", BlockHeight: 3, Type: "transfer", Amount: 30, Fee: 1, FromAddress: "alice", ToAddress: "bob"},
+	}
+	for _, tx := range txs {
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store %s: %v", tx.TxHash, err)
+		}
+	}
+}
+
+func TestSupplyCheckConsistentLedger(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestLedger(t, database)
+
+	check, err := database.CheckSupply()
+	if err != nil {
+		t.Fatalf("CheckSupply failed: %v", err)
+	}
+	if check.Emission != 200 || check.Burned != 1 || check.CirculatingSupply != 199 {
+		t.Errorf("Unexpected supply: %+v", check)
+	}
+	if !check.Consistent || check.Discrepancy != 0 || check.WalletBalanceTotal != 199 {
+		t.Errorf("Expected a consistent ledger, got %+v", check)
+	}
+	if check.WalletsChecked != 2 || check.TransactionsChecked != 3 {
+		t.Errorf("Unexpected coverage: %+v", check)
+	}
+}
+
+func TestSupplyCheckSurfacesCorruptedBalance(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestLedger(t, database)
+
+	// A stray index entry credits alice's mining reward a second time
+	err := database.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("addr_tx:alice:0000000000000009:coinbase_1"), []byte("coinbase_1"))
+	})
+	if err != nil {
+		t.Fatalf("Failed to corrupt index: %v", err)
+	}
+
+	check, err := database.CheckSupply()
+	if err != nil {
+		t.Fatalf("CheckSupply failed: %v", err)
+	}
+	if check.Consistent || check.Discrepancy != 100 {
+		t.Errorf("Expected a discrepancy of 100, got %+v", check)
+	}
+
+	// Losing bob's reward from the index leaves him spent below zero
+	err = database.update(func(txn *badger.Txn) error {
+		if err := txn.Delete([]byte("addr_tx:alice:0000000000000009:coinbase_1")); err != nil {
+			return err
+		}
+		return txn.Delete([]byte("addr_tx:bob:0000000000000002:coinbase_2"))
+	})
+	if err != nil {
+		t.Fatalf("Failed to corrupt index: %v", err)
+	}
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: "tx_spend", BlockHeight: 4, Type: "transfer", Amount: 50, FromAddress: "bob", ToAddress: "carol"}); err != nil {
+		t.Fatalf("Failed to store spend: %v", err)
+	}
+
+	check, err = database.CheckSupply()
+	if err != nil {
+		t.Fatalf("CheckSupply failed: %v", err)
+	}
+	if check.Discrepancy != -100 {
+		t.Errorf("Expected a discrepancy of -100, got %d", check.Discrepancy)
+	}
+	if len(check.NegativeBalances) != 1 || check.NegativeBalances[0] != "bob" {
+		t.Errorf("Expected bob to be over-spent, got %v", check.NegativeBalances)
+	}
+}
+
+func TestSupplyCheckRequiresAdmin(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestLedger(t, database)
+
+	es := &ExplorerServer{database: database, adminToken: "secret"}
+	handler := es.requireAdmin(es.handleSupplyCheck)
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/api/v1/stats/supply-check", nil))
+	if rec.Code != 401 {
+		t.Errorf("Expected 401 without a token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/stats/supply-check", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+
+	var check SupplyCheck
+	if err := json.NewDecoder(rec.Body).Decode(&check); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !check.Consistent || check.CirculatingSupply != 199 {
+		t.Errorf("Unexpected supply check response: %+v", check)
+	}
+}
+
+func TestLatestBlockTracksTip(t *testing.T) {
+	database := newTestDatabase(t)
+
+	if _, err := database.GetLatestBlock(); err != badger.ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound with no blocks, got %v", err)
+	}
+
+	storeTestBlocks(t, database, 0, 4)
+	tip, err := database.GetLatestBlock()
+	if err != nil {
+		t.Fatalf("GetLatestBlock failed: %v", err)
+	}
+	if tip.Hash != "hash_4" || tip.Header.Height != 4 || tip.Summary.Height != 4 {
+		t.Fatalf("Expected tip at height 4, got %+v", tip)
+	}
+
+	block, err := database.GetBlock(tip.Hash)
+	if err != nil {
+		t.Fatalf("GetBlock failed: %v", err)
+	}
+	if tip.Header != block.Header {
+		t.Errorf("Tip header does not match GetBlock: %+v vs %+v", tip.Header, block.Header)
+	}
+
+	// Storing a new block must invalidate the cached tip
+	storeTestBlocks(t, database, 5, 5)
+	tip, err = database.GetLatestBlock()
+	if err != nil {
+		t.Fatalf("GetLatestBlock failed: %v", err)
+	}
+	if tip.Hash != "hash_5" || tip.Header.Height != 5 {
+		t.Errorf("Expected tip to move to height 5, got %+v", tip)
+	}
+}
+
+func TestLatestBlockAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/block/latest", es.handleLatestBlock)
+	router.HandleFunc("/api/v1/block/{hash}", es.handleBlockDetails)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/block/latest", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before any block, got %d", rec.Code)
+	}
+
+	storeTestBlocks(t, database, 0, 2)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/block/latest", nil))
+
+	var body LatestBlock
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Hash != "hash_2" || body.Summary.Hash != "hash_2" {
+		t.Errorf("Expected hash_2 as the tip, got %+v", body)
+	}
+}
+
+func TestTokenConcentrationWideVsSingleHolder(t *testing.T) {
+	database := newTestDatabase(t)
+
+	for _, token := range []*TokenInfo{
+		{TokenID: "tok_wide", Name: "Wide", Ticker: "WID", TotalSupply: 100000},
+		{TokenID: "tok_whale", Name: "Whale", Ticker: "WHL", TotalSupply: 100000},
+	} {
+		if err := database.StoreToken(token); err != nil {
+			t.Fatalf("Failed to store token %s: %v", token.TokenID, err)
+		}
+	}
+
+	// 100 equal holders, more than the 50 returned in the details
+	for i := 0; i < 100; i++ {
+		if err := database.UpdateTokenHolder("tok_wide", fmt.Sprintf("holder_%03d", i), 1000); err != nil {
+			t.Fatalf("Failed to store holder: %v", err)
+		}
+	}
+	// One holder with nearly everything and 99 dust holders
+	if err := database.UpdateTokenHolder("tok_whale", "whale", 99901); err != nil {
+		t.Fatalf("Failed to store holder: %v", err)
+	}
+	for i := 0; i < 99; i++ {
+		if err := database.UpdateTokenHolder("tok_whale", fmt.Sprintf("dust_%03d", i), 1); err != nil {
+			t.Fatalf("Failed to store holder: %v", err)
+		}
+	}
+
+	wide, err := database.GetTokenDetails("tok_wide")
+	if err != nil {
+		t.Fatalf("GetTokenDetails failed: %v", err)
+	}
+	if len(wide.Holders) != 50 {
+		t.Fatalf("Expected 50 returned holders, got %d", len(wide.Holders))
+	}
+	if math.Abs(wide.Top10Share-0.1) > 1e-9 || math.Abs(wide.Gini) > 1e-9 || wide.HighlyConcentrated {
+		t.Errorf("Equal holders: expected top10 0.1 and gini 0, got %f and %f (flagged=%v)", wide.Top10Share, wide.Gini, wide.HighlyConcentrated)
+	}
+
+	whale, err := database.GetTokenDetails("tok_whale")
+	if err != nil {
+		t.Fatalf("GetTokenDetails failed: %v", err)
+	}
+	if math.Abs(whale.Top10Share-0.9991) > 1e-9 || !whale.HighlyConcentrated {
+		t.Errorf("Expected top10 share 0.9991 and a flag, got %f (flagged=%v)", whale.Top10Share, whale.HighlyConcentrated)
+	}
+	if whale.Gini < 0.98 || whale.Gini >= 1 {
+		t.Errorf("Expected gini close to 1, got %f", whale.Gini)
+	}
+}
+
+func TestHolderConcentrationEdgeCases(t *testing.T) {
+	if top10, gini := holderConcentration(nil); top10 != 0 || gini != 0 {
+		t.Errorf("No holders should give zero, got %f and %f", top10, gini)
+	}
+	if top10, gini := holderConcentration([]uint64{500}); top10 != 1 || gini != 0 {
+		t.Errorf("A single holder owns everything with no inequality among holders, got %f and %f", top10, gini)
+	}
+}
+
+func TestBlockRangeAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 0, 9)
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleBlockRange(rec, httptest.NewRequest("GET", "/api/v1/blocks/range?from=3&to=12", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body BlockRange
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	// Heights past the tip are simply absent
+	if len(body.Blocks) != 7 || body.Blocks[0].Block.Header.Height != 3 || body.Blocks[6].Hash != "hash_9" {
+		t.Fatalf("Expected blocks 3 through 9, got %d blocks", len(body.Blocks))
+	}
+
+	block, err := database.GetBlock("hash_5")
+	if err != nil {
+		t.Fatalf("GetBlock failed: %v", err)
+	}
+	if body.Blocks[2].Block.Header != block.Header {
+		t.Errorf("Range block does not match GetBlock: %+v", body.Blocks[2].Block.Header)
+	}
+
+	for _, query := range []string{"from=0&to=100", "from=5&to=4", "from=x&to=3", "to=3"} {
+		rec := httptest.NewRecorder()
+		es.handleBlockRange(rec, httptest.NewRequest("GET", "/api/v1/blocks/range?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleBlockRange(rec, httptest.NewRequest("GET", "/api/v1/blocks/range?from=0&to=99", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("A range of exactly 100 blocks should be allowed, got %d", rec.Code)
+	}
+}
+
+func TestBlockRangeAtMaxHeight(t *testing.T) {
+	database := newTestDatabase(t)
+	blocks, err := database.GetBlockRange(math.MaxUint64-1, math.MaxUint64)
+	if err != nil || len(blocks) != 0 {
+		t.Errorf("Expected an empty range without wrapping, got %d blocks, err %v", len(blocks), err)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// exportFlushEvery is how many rows are written between flushes to the client
+const exportFlushEvery = 100
+
+var walletExportColumns = []string{
+	"block_height", "timestamp", "tx_hash", "type", "from_address", "to_address",
+	"amount", "fee", "change", "balance", "asset_changes", "confirmations", "final",
+}
+
+// EachWalletTransaction calls fn for every transaction of an address,
+// oldest first, without loading the history into memory. It stops at the
+// first error fn returns.
+func (d *Database) EachWalletTransaction(address string, fn func(tx *WalletTransaction) error) error {
+	prefix := []byte(fmt.Sprintf("addr_tx:%s:", address))
+
+	return d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			txHash, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			item, err := txn.Get([]byte(fmt.Sprintf("tx:%s", txHash)))
+			if err != nil {
+				slog.Error("Wallet index points at a missing transaction", "address", address, "tx_hash", txHash)
+				continue
+			}
+			var tx WalletTransaction
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				continue
+			}
+			if err := fn(&tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// walletBalanceChange is a transaction's effect on an address's SHADOW
+// balance: it gains what it receives and pays what it sends plus the fee
+func walletBalanceChange(address string, tx *WalletTransaction) int64 {
+	var change int64
+	if tx.ToAddress == address {
+		change += int64(tx.Amount)
+	}
+	if tx.FromAddress == address {
+		change -= int64(tx.Amount + tx.Fee)
+	}
+	return change
+}
+
+// parseExportTime accepts an RFC 3339 time or a date. A date used as the
+// upper bound covers that whole day.
+func parseExportTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(dailyDateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or RFC 3339 time", value)
+	}
+	if end {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// formatShadowAmount renders base units as SHADOW with all 8 decimals
+func formatShadowAmount(units int64) string {
+	sign := ""
+	magnitude := uint64(units)
+	if units < 0 {
+		sign = "-"
+		magnitude = uint64(-units)
+	}
+	return fmt.Sprintf("%s%d.%08d", sign, magnitude/100000000, magnitude%100000000)
+}
+
+// Wallet export endpoint: the full history of an address with the running
+// balance after each transaction, streamed as CSV or JSON
+func (es *ExplorerServer) handleWalletExport(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		http.Error(w, "format must be csv or json", http.StatusBadRequest)
+		return
+	}
+
+	var from, to time.Time
+	var err error
+	if value := query.Get("from"); value != "" {
+		if from, err = parseExportTime(value, false); err != nil {
+			http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if to, err = parseExportTime(value, true); err != nil {
+			http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	filename := fmt.Sprintf("%s-transactions.%s", address, format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	flusher, _ := w.(http.Flusher)
+
+	var csvWriter *csv.Writer
+	var encoder *json.Encoder
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		csvWriter = csv.NewWriter(w)
+		csvWriter.Write(walletExportColumns)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		encoder = json.NewEncoder(w)
+		fmt.Fprint(w, "[")
+	}
+
+	depth := es.finalityDepth
+	if depth == 0 {
+		depth = defaultFinalityDepth
+	}
+	tip, tipErr := es.database.GetLatestBlock()
+
+	// Every transaction counts toward the balance, including those before
+	// the range, so the first exported row carries the right opening balance
+	var balance int64
+	rows := 0
+	err = es.database.EachWalletTransaction(address, func(stored *WalletTransaction) error {
+		change := walletBalanceChange(address, stored)
+		balance += change
+		if (!from.IsZero() && stored.Timestamp.Before(from)) || (!to.IsZero() && stored.Timestamp.After(to)) {
+			return nil
+		}
+
+		row := []WalletTransaction{*stored}
+		if tipErr == nil {
+			setFinality(row, tip.Header.Height, depth)
+		}
+		tx := &row[0]
+
+		if csvWriter != nil {
+			csvWriter.Write([]string{
+				strconv.FormatUint(tx.BlockHeight, 10),
+				tx.Timestamp.UTC().Format(time.RFC3339),
+				tx.TxHash,
+				tx.Type,
+				tx.FromAddress,
+				tx.ToAddress,
+				formatShadowAmount(int64(tx.Amount)),
+				formatShadowAmount(int64(tx.Fee)),
+				formatShadowAmount(change),
+				formatShadowAmount(balance),
+				formatAssetChanges(assetChangesOf(tx.AssetChanges, address)),
+				strconv.FormatUint(tx.Confirmations, 10),
+				strconv.FormatBool(tx.Final),
+			})
+		} else {
+			if rows > 0 {
+				fmt.Fprint(w, ",")
+			}
+			encoder.Encode(WalletExportRow{WalletTransaction: *tx, Change: change, Balance: balance})
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if csvWriter != nil {
+				csvWriter.Flush()
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if csvWriter != nil {
+			return csvWriter.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		// The status line has been sent; leaving the JSON array unclosed is
+		// the only way left to tell the client the export is incomplete
+		slog.Error("Export failed", "address", address, "rows", rows, "err", err)
+		return
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+	} else {
+		fmt.Fprint(w, "]")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func exportWallet(es *ExplorerServer, address, query string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/wallet/"+address+"/export"+query, nil)
+	es.handleWalletExport(rec, mux.SetURLVars(req, map[string]string{"address": address}))
+	return rec
+}
+
+func TestWalletExportRunningBalance(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 0, 3)
+	storeTestPayment(t, database, "r1", "", "alice", 1, 500000000, 0)
+	storeTestPayment(t, database, "t1", "alice", "bob", 2, 150000000, 1000)
+	storeTestPayment(t, database, "r2", "", "alice", 3, 25000000, 0)
+	es := &ExplorerServer{database: database}
+
+	rec := exportWallet(es, "alice", "")
+	if got := rec.Header().Get("Content-Type"); got != "text/csv" {
+		t.Errorf("Expected text/csv, got %q", got)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) != 4 || records[0][0] != "block_height" {
+		t.Fatalf("Expected a header and 3 rows, got %v", records)
+	}
+	want := [][2]string{{"5.00000000", "5.00000000"}, {"-1.50001000", "3.49999000"}, {"0.25000000", "3.74999000"}}
+	for i, row := range records[1:] {
+		if row[8] != want[i][0] || row[9] != want[i][1] {
+			t.Errorf("Row %d: expected change/balance %v, got %s/%s", i+1, want[i], row[8], row[9])
+		}
+	}
+	if records[3][11] != "1" || records[1][11] != "3" {
+		t.Errorf("Expected confirmations from the tip, got %s and %s", records[1][11], records[3][11])
+	}
+}
+
+func TestWalletExportJSONRange(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestPayment(t, database, "r1", "", "alice", 1, 500, 0)
+	storeTestPayment(t, database, "t1", "alice", "bob", 2, 100, 10)
+	storeTestPayment(t, database, "r2", "", "alice", 3, 50, 0)
+	es := &ExplorerServer{database: database}
+
+	// storeTestPayment timestamps each transaction at Unix(height)
+	rec := exportWallet(es, "alice", "?format=json&from="+time.Unix(2, 0).UTC().Format(time.RFC3339)+
+		"&to="+time.Unix(2, 0).UTC().Format(time.RFC3339))
+	var rows []WalletExportRow
+	if err := json.NewDecoder(rec.Body).Decode(&rows); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(rows) != 1 || rows[0].TxHash != "t1" || rows[0].Change != -110 || rows[0].Balance != 390 {
+		t.Errorf("Expected only t1 with the opening balance carried in, got %+v", rows)
+	}
+
+	rec = exportWallet(es, "nobody", "?format=json")
+	rows = nil
+	if err := json.NewDecoder(rec.Body).Decode(&rows); err != nil || len(rows) != 0 {
+		t.Errorf("Expected an empty array for an unknown address, got %v (%v)", rows, err)
+	}
+
+	for _, query := range []string{"?format=xml", "?from=yesterday"} {
+		if rec := exportWallet(es, "alice", query); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestParseExportTimeDates(t *testing.T) {
+	from, _ := parseExportTime("2025-03-01", false)
+	to, _ := parseExportTime("2025-03-01", true)
+	if !from.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) || to.Day() != 1 || to.Hour() != 23 {
+		t.Errorf("Unexpected bounds %v - %v", from, to)
+	}
+	if got := formatShadowAmount(-123456789); got != "-1.23456789" {
+		t.Errorf("Unexpected amount %s", got)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// Beside the per-farmer index (farmer:<address>:<height>), every block won
+// is indexed by height (mined:<height>) with its farmer, so leaderboards
+// read a window of recent blocks without visiting every farmer. Databases
+// indexed before the height index existed are backfilled by a migration.
+const (
+	minedBlockPrefix = "mined:"
+
+	defaultFarmerWindow  = "7d"
+	defaultFarmersLimit  = 50
+	maxFarmersLimit      = 500
+	farmerDailyBlockDays = 30
+)
+
+// farmerWindows are the rolling windows the leaderboard ranks over
+var farmerWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+func minedBlockKey(height uint64) []byte {
+	return []byte(fmt.Sprintf("%s%016d", minedBlockPrefix, height))
+}
+
+// setMinedBlock records in the height index that address won block
+func setMinedBlock(txn *badger.Txn, address string, block MinedBlock) error {
+	block.Address = address
+	data, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	return txn.Set(minedBlockKey(block.Height), data)
+}
+
+// buildMinedBlockIndex builds the height index from the per-farmer index
+func (d *Database) buildMinedBlockIndex() (int, error) {
+	changed := 0
+	err := d.update(func(txn *badger.Txn) error {
+		var blocks []MinedBlock
+		if _, err := collectKeys(txn, "farmer:", func(key string, val []byte) bool {
+			var block MinedBlock
+			if json.Unmarshal(val, &block) == nil {
+				// farmer:<address>:<height>
+				block.Address = strings.TrimSuffix(strings.TrimPrefix(key, "farmer:"), fmt.Sprintf(":%016d", block.Height))
+				blocks = append(blocks, block)
+			}
+			return false
+		}); err != nil {
+			return err
+		}
+
+		for _, block := range blocks {
+			if err := setMinedBlock(txn, block.Address, block); err != nil {
+				return err
+			}
+		}
+
+		changed = len(blocks)
+		return nil
+	})
+	return changed, err
+}
+
+// GetFarmerBlocks returns every block address farmed, oldest first
+func (d *Database) GetFarmerBlocks(address string) ([]MinedBlock, error) {
+	blocks := []MinedBlock{}
+	err := d.view(func(txn *badger.Txn) error {
+		_, err := collectKeys(txn, fmt.Sprintf("farmer:%s:", address), func(key string, val []byte) bool {
+			var block MinedBlock
+			if json.Unmarshal(val, &block) == nil {
+				block.Address = address
+				blocks = append(blocks, block)
+			}
+			return false
+		})
+		return err
+	})
+	return blocks, err
+}
+
+// GetMinedBlocksSince returns every block won at or after since with its
+// farmer, oldest first. The height index is read backwards from the tip
+// until blocks get older than since.
+func (d *Database) GetMinedBlocksSince(since time.Time) ([]MinedBlock, error) {
+	blocks := []MinedBlock{}
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(minedBlockPrefix)
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(minedBlockPrefix + "\xff")); it.Valid(); it.Next() {
+			var block MinedBlock
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &block)
+			}); err != nil {
+				continue
+			}
+			if block.Timestamp.Before(since) {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+		return nil
+	})
+
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	return blocks, err
+}
+
+// blockStreaks returns the length of the last run of consecutive heights
+// and of the longest, for heights in ascending order
+func blockStreaks(blocks []MinedBlock) (last, longest int) {
+	for i, block := range blocks {
+		if i > 0 && block.Height == blocks[i-1].Height+1 {
+			last++
+		} else {
+			last = 1
+		}
+		if last > longest {
+			longest = last
+		}
+	}
+	return last, longest
+}
+
+// buildFarmerLeaderboard ranks the farmers of blocks (oldest first, all
+// won since since) by blocks won, then by most recent win
+func buildFarmerLeaderboard(window string, since time.Time, blocks []MinedBlock, limit int) FarmerLeaderboard {
+	byFarmer := make(map[string][]MinedBlock)
+	for _, block := range blocks {
+		byFarmer[block.Address] = append(byFarmer[block.Address], block)
+	}
+
+	farmers := make([]FarmerSummary, 0, len(byFarmer))
+	for address, won := range byFarmer {
+		summary := FarmerSummary{Address: address, Blocks: len(won)}
+		for _, block := range won {
+			summary.Rewards += block.Reward
+		}
+		_, summary.LongestStreak = blockStreaks(won)
+		summary.LastHeight = won[len(won)-1].Height
+		summary.LastBlock = won[len(won)-1].Timestamp
+		summary.Share = float64(len(won)) / float64(len(blocks)) * 100
+		farmers = append(farmers, summary)
+	}
+	sort.Slice(farmers, func(i, j int) bool {
+		if farmers[i].Blocks != farmers[j].Blocks {
+			return farmers[i].Blocks > farmers[j].Blocks
+		}
+		return farmers[i].LastHeight > farmers[j].LastHeight
+	})
+	if len(farmers) > limit {
+		farmers = farmers[:limit]
+	}
+	for i := range farmers {
+		farmers[i].Rank = i + 1
+	}
+
+	return FarmerLeaderboard{Window: window, Since: since, TotalBlocks: len(blocks), Farmers: farmers}
+}
+
+// buildFarmerStats summarizes the blocks an address won (oldest first) as
+// of now. networkBlocks7d is every block won in the last 7 days and tip
+// the indexed chain height, which a current streak must reach.
+func buildFarmerStats(address string, blocks []MinedBlock, networkBlocks7d int, tip uint64, now time.Time) FarmerStats {
+	stats := FarmerStats{Address: address, TotalBlocks: len(blocks), DailyBlocks: make([]FarmerDay, farmerDailyBlockDays)}
+
+	firstDay := dayStart(now).AddDate(0, 0, -(farmerDailyBlockDays - 1))
+	for i := range stats.DailyBlocks {
+		stats.DailyBlocks[i].Date = firstDay.AddDate(0, 0, i).Format(dailyDateFormat)
+	}
+
+	for _, block := range blocks {
+		stats.TotalRewards += block.Reward
+		age := now.Sub(block.Timestamp)
+		if age <= 24*time.Hour {
+			stats.Blocks24h++
+		}
+		if age <= farmerWindows["7d"] {
+			stats.Blocks7d++
+		}
+		if age <= farmerWindows["30d"] {
+			stats.Blocks30d++
+		}
+		if day := int(dayStart(block.Timestamp).Sub(firstDay) / (24 * time.Hour)); !block.Timestamp.Before(firstDay) && day < farmerDailyBlockDays {
+			stats.DailyBlocks[day].Blocks++
+		}
+	}
+	if len(blocks) == 0 {
+		return stats
+	}
+
+	stats.FirstBlock = blocks[0].Timestamp
+	stats.LastBlock = blocks[len(blocks)-1].Timestamp
+	stats.LastHeight = blocks[len(blocks)-1].Height
+	if networkBlocks7d > 0 {
+		stats.NetspaceShare = float64(stats.Blocks7d) / float64(networkBlocks7d) * 100
+	}
+	last, longest := blockStreaks(blocks)
+	stats.LongestStreak = longest
+	if stats.LastHeight == tip {
+		stats.CurrentStreak = last
+	}
+	return stats
+}
+
+// Farmer leaderboard API endpoint: farmers ranked by blocks won over a
+// rolling window
+func (es *ExplorerServer) handleFarmers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	window := query.Get("window")
+	if window == "" {
+		window = defaultFarmerWindow
+	}
+	span, ok := farmerWindows[window]
+	if !ok {
+		http.Error(w, "window must be 24h, 7d or 30d", http.StatusBadRequest)
+		return
+	}
+	limit := defaultFarmersLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxFarmersLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxFarmersLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	since := time.Now().UTC().Add(-span)
+	blocks, err := es.database.GetMinedBlocksSince(since)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get mined blocks", "err", err)
+		http.Error(w, "Failed to get farmers", http.StatusInternalServerError)
+		return
+	}
+
+	leaderboard := buildFarmerLeaderboard(window, since, blocks, limit)
+	labels := es.newLabeler()
+	for i := range leaderboard.Farmers {
+		labels.add(leaderboard.Farmers[i].Address, &leaderboard.Farmers[i].Label)
+	}
+	labels.apply()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leaderboard)
+}
+
+// Farmer API endpoint: one address's farming history and streaks
+func (es *ExplorerServer) handleFarmer(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	blocks, err := es.database.GetFarmerBlocks(address)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get farmed blocks", "address", address, "err", err)
+		http.Error(w, "Failed to get farmer", http.StatusInternalServerError)
+		return
+	}
+	if len(blocks) == 0 {
+		http.Error(w, "Farmer not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now().UTC()
+	network, err := es.database.GetMinedBlocksSince(now.Add(-farmerWindows["7d"]))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get mined blocks", "err", err)
+		http.Error(w, "Failed to get farmer", http.StatusInternalServerError)
+		return
+	}
+	tip, err := es.database.GetLatestHeight()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get latest height", "err", err)
+		http.Error(w, "Failed to get farmer", http.StatusInternalServerError)
+		return
+	}
+
+	stats := buildFarmerStats(address, blocks, len(network), tip, now)
+	labels := es.newLabeler()
+	labels.add(address, &stats.Label)
+	labels.apply()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// storeTestRewards records the mining reward of each height, paid to the
+// farmer at the same index, one minute apart ending at end
+func storeTestRewards(t *testing.T, database Store, farmers []string, end time.Time) {
+	for i, farmer := range farmers {
+		height := uint64(i + 1)
+		timestamp := end.Add(-time.Duration(len(farmers)-1-i) * time.Minute)
+		block := &Block{Header: BlockHeader{Height: height, Timestamp: timestamp, FarmerAddress: farmer}}
+		if err := database.StoreBlock(fmt.Sprintf("hash_%d", height), block); err != nil {
+			t.Fatalf("Failed to store block %d: %v", height, err)
+		}
+		tx := &WalletTransaction{TxHash: fmt.Sprintf("reward_%d", height), BlockHash: fmt.Sprintf("hash_%d", height),
+			BlockHeight: height, Timestamp: timestamp, Type: "mining_reward", Amount: 50, ToAddress: farmer}
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store reward %d: %v", height, err)
+		}
+	}
+}
+
+func TestBuildFarmerLeaderboard(t *testing.T) {
+	blocks := []MinedBlock{
+		{Height: 1, Address: "alice", Reward: 50},
+		{Height: 2, Address: "alice", Reward: 50},
+		{Height: 3, Address: "bob", Reward: 50},
+		{Height: 4, Address: "alice", Reward: 50},
+		{Height: 5, Address: "carol", Reward: 50},
+		{Height: 6, Address: "bob", Reward: 50},
+	}
+
+	board := buildFarmerLeaderboard("7d", time.Time{}, blocks, 2)
+	if board.TotalBlocks != 6 || len(board.Farmers) != 2 {
+		t.Fatalf("Expected the top 2 of 6 blocks, got %+v", board)
+	}
+	alice, bob := board.Farmers[0], board.Farmers[1]
+	if alice.Address != "alice" || alice.Rank != 1 || alice.Blocks != 3 || alice.Rewards != 150 || alice.Share != 50 {
+		t.Errorf("Unexpected leader %+v", alice)
+	}
+	if alice.LongestStreak != 2 || alice.LastHeight != 4 {
+		t.Errorf("Expected alice's streak of 2 ending at 4, got %+v", alice)
+	}
+	if bob.Address != "bob" || bob.Rank != 2 || bob.LongestStreak != 1 {
+		t.Errorf("Unexpected runner-up %+v", bob)
+	}
+}
+
+func TestBuildFarmerStats(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	blocks := []MinedBlock{
+		{Height: 10, Timestamp: now.Add(-40 * 24 * time.Hour), Reward: 50},
+		{Height: 11, Timestamp: now.Add(-20 * 24 * time.Hour), Reward: 50},
+		{Height: 12, Timestamp: now.Add(-20 * 24 * time.Hour), Reward: 50},
+		{Height: 13, Timestamp: now.Add(-20 * 24 * time.Hour), Reward: 50},
+		{Height: 30, Timestamp: now.Add(-3 * 24 * time.Hour), Reward: 50},
+		{Height: 41, Timestamp: now.Add(-2 * time.Hour), Reward: 50},
+		{Height: 42, Timestamp: now.Add(-time.Hour), Reward: 50},
+	}
+
+	stats := buildFarmerStats("alice", blocks, 10, 42, now)
+	if stats.TotalBlocks != 7 || stats.TotalRewards != 350 || stats.LastHeight != 42 {
+		t.Errorf("Unexpected totals %+v", stats)
+	}
+	if stats.Blocks24h != 2 || stats.Blocks7d != 3 || stats.Blocks30d != 6 {
+		t.Errorf("Unexpected windows 24h=%d 7d=%d 30d=%d", stats.Blocks24h, stats.Blocks7d, stats.Blocks30d)
+	}
+	if stats.NetspaceShare != 30 {
+		t.Errorf("Expected 3 of 10 weekly blocks to be a 30%% share, got %f", stats.NetspaceShare)
+	}
+	if stats.LongestStreak != 4 || stats.CurrentStreak != 2 {
+		t.Errorf("Expected streaks 4 longest / 2 current, got %d/%d", stats.LongestStreak, stats.CurrentStreak)
+	}
+	if len(stats.DailyBlocks) != farmerDailyBlockDays || stats.DailyBlocks[farmerDailyBlockDays-1].Date != "2025-03-10" {
+		t.Fatalf("Expected 30 days ending today, got %+v", stats.DailyBlocks)
+	}
+	if stats.DailyBlocks[farmerDailyBlockDays-1].Blocks != 2 || stats.DailyBlocks[farmerDailyBlockDays-21].Blocks != 3 {
+		t.Errorf("Unexpected daily blocks %+v", stats.DailyBlocks)
+	}
+
+	// Someone else has won since
+	if stats := buildFarmerStats("alice", blocks, 10, 43, now); stats.CurrentStreak != 0 {
+		t.Errorf("Expected no current streak once the tip moves on, got %d", stats.CurrentStreak)
+	}
+}
+
+func TestFarmerEndpoints(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database}
+	storeTestRewards(t, database, []string{"alice", "bob", "alice", "alice"}, time.Now().UTC())
+
+	get := func(handler http.HandlerFunc, path string, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req = mux.SetURLVars(req, vars)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	rec := get(es.handleFarmers, "/api/v1/farmers?window=24h", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var board FarmerLeaderboard
+	if err := json.Unmarshal(rec.Body.Bytes(), &board); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if board.TotalBlocks != 4 || len(board.Farmers) != 2 || board.Farmers[0].Address != "alice" || board.Farmers[0].Blocks != 3 {
+		t.Errorf("Unexpected leaderboard %+v", board)
+	}
+
+	rec = get(es.handleFarmer, "/api/v1/farmer/alice", map[string]string{"address": "alice"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var stats FarmerStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if stats.TotalBlocks != 3 || stats.CurrentStreak != 2 || stats.NetspaceShare != 75 {
+		t.Errorf("Unexpected farmer stats %+v", stats)
+	}
+
+	if rec := get(es.handleFarmer, "/api/v1/farmer/nobody", map[string]string{"address": "nobody"}); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an address that never farmed, got %d", rec.Code)
+	}
+	if rec := get(es.handleFarmers, "/api/v1/farmers?window=1y", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown window, got %d", rec.Code)
+	}
+}
+
+func TestMinedBlockIndexBackfillAndRollback(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestRewards(t, database, []string{"alice", "bob", "alice"}, time.Now().UTC())
+
+	// Drop the height index as if the database predated it
+	err := database.update(func(txn *badger.Txn) error {
+		keys, err := collectKeys(txn, minedBlockPrefix, func(string, []byte) bool { return true })
+		if err != nil {
+			return err
+		}
+		return deleteKeys(txn, append(keys, []byte(minedBlockIndexMarker)))
+	})
+	if err != nil {
+		t.Fatalf("Failed to drop index: %v", err)
+	}
+	if err := database.migrate(); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	blocks, err := database.GetMinedBlocksSince(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetMinedBlocksSince failed: %v", err)
+	}
+	if len(blocks) != 3 || blocks[0].Address != "alice" || blocks[1].Address != "bob" || blocks[2].Height != 3 {
+		t.Errorf("Expected the index rebuilt oldest first, got %+v", blocks)
+	}
+
+	if _, err := database.RollbackToHeight(2); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	if blocks, _ := database.GetMinedBlocksSince(time.Now().Add(-time.Hour)); len(blocks) != 2 {
+		t.Errorf("Expected blocks above the fork dropped from the index, got %+v", blocks)
+	}
+}
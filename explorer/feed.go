@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// feedBufferSize is how many events a slow subscriber may fall behind
+// before further events are dropped for it
+const feedBufferSize = 16
+
+// feedKeepAlive is how often an idle stream sends a comment so proxies
+// keep the connection open
+const feedKeepAlive = 15 * time.Second
+
+// FeedEvent is a message pushed to live feed subscribers
+type FeedEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// FeedBroker fans events out to subscribers by topic. A nil broker
+// accepts publishes and drops them.
+type FeedBroker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan FeedEvent]struct{}
+}
+
+// NewFeedBroker creates an empty broker
+func NewFeedBroker() *FeedBroker {
+	return &FeedBroker{subs: make(map[string]map[chan FeedEvent]struct{})}
+}
+
+// Subscribe registers for events on topic. The returned function must be
+// called to unsubscribe; it closes the channel.
+func (b *FeedBroker) Subscribe(topic string) (<-chan FeedEvent, func()) {
+	ch := make(chan FeedEvent, feedBufferSize)
+
+	b.mu.Lock()
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[chan FeedEvent]struct{})
+	}
+	b.subs[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[topic], ch)
+			if len(b.subs[topic]) == 0 {
+				delete(b.subs, topic)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish delivers event to every subscriber of topic without blocking
+func (b *FeedBroker) Publish(topic string, event FeedEvent) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[topic] {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up; it will catch up on the next event
+		}
+	}
+}
+
+// SubscriberCount returns the number of live subscribers on topic
+func (b *FeedBroker) SubscriberCount(topic string) int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs[topic])
+}
+
+// serveEventStream writes events to w as server-sent events until the
+// client disconnects or the subscription ends
+func serveEventStream(w http.ResponseWriter, r *http.Request, events <-chan FeedEvent, initial *FeedEvent) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	write := func(event FeedEvent) bool {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return true
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	if initial != nil && !write(*initial) {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(feedKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok || !write(event) {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
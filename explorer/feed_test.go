@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestFeedBrokerSubscribeAndUnsubscribe(t *testing.T) {
+	broker := NewFeedBroker()
+	events, unsubscribe := broker.Subscribe("pool:a")
+
+	broker.Publish("pool:a", FeedEvent{Type: "price", Data: 1})
+	broker.Publish("pool:b", FeedEvent{Type: "price", Data: 2})
+
+	if event := <-events; event.Data != 1 {
+		t.Errorf("Expected only pool:a events, got %+v", event)
+	}
+
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+	if broker.SubscriberCount("pool:a") != 0 {
+		t.Error("Subscriber should be removed")
+	}
+	if _, ok := <-events; ok {
+		t.Error("Channel should be closed after unsubscribe")
+	}
+
+	// Publishing with nobody listening must not block
+	broker.Publish("pool:a", FeedEvent{Type: "price"})
+}
+
+// readSSEData returns the data payload of the next server-sent event
+func readSSEData(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Stream ended: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func TestIndexedSwapPushesPriceUpdate(t *testing.T) {
+	database := newTestDatabase(t)
+	pool := &LiquidityPool{PoolID: "pool_gold", TokenASymbol: "GOLD", TokenBSymbol: "SHADOW", ReserveA: 1000, ReserveB: 2000, CreationTime: time.Now()}
+	if err := database.StorePool(pool); err != nil {
+		t.Fatalf("Failed to store pool: %v", err)
+	}
+
+	es := &ExplorerServer{database: database}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/pool/{poolId}/stream", es.handlePoolStream)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/pool/pool_gold/stream")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", ct)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	var snapshot PoolPriceUpdate
+	json.Unmarshal([]byte(readSSEData(t, reader)), &snapshot)
+	if snapshot.SpotPrice != 2 {
+		t.Errorf("Expected initial spot price 2, got %f", snapshot.SpotPrice)
+	}
+
+	// Index a swap: the pool's reserves move, then its transaction is stored
+	pool.ReserveA, pool.ReserveB = 1100, 1820
+	if err := database.StorePool(pool); err != nil {
+		t.Fatalf("Failed to update pool: %v", err)
+	}
+	swap := &PoolTransaction{TxHash: "swap_1", Timestamp: time.Now(), Type: "swap", AmountA: 100, AmountB: 180}
+	if err := database.StorePoolTransaction("pool_gold", swap); err != nil {
+		t.Fatalf("Failed to store swap: %v", err)
+	}
+
+	var update PoolPriceUpdate
+	if err := json.Unmarshal([]byte(readSSEData(t, reader)), &update); err != nil {
+		t.Fatalf("Failed to decode update: %v", err)
+	}
+	if update.ReserveA != 1100 || update.ReserveB != 1820 || update.TxType != "swap" || update.TxHash != "swap_1" {
+		t.Errorf("Unexpected price update: %+v", update)
+	}
+	if update.SpotPrice < 1.65 || update.SpotPrice > 1.66 {
+		t.Errorf("Expected spot price ~1.6545, got %f", update.SpotPrice)
+	}
+
+	// Disconnecting removes the subscriber
+	resp.Body.Close()
+	topic := poolFeedTopic("pool_gold")
+	deadline := time.Now().Add(5 * time.Second)
+	for database.Feed().SubscriberCount(topic) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscriber was not cleaned up after disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPoolStreamUnknownPool(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/pool/{poolId}/stream", es.handlePoolStream)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/pool/missing/stream", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+	if database.Feed().SubscriberCount(poolFeedTopic("missing")) != 0 {
+		t.Error("Rejected stream must not leave a subscriber behind")
+	}
+}
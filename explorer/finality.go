@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// defaultFinalityDepth is how many confirmations make a transaction final
+// unless EXPLORER_FINALITY_DEPTH says otherwise
+const defaultFinalityDepth = 100
+
+// finalityDepthFromEnv reads EXPLORER_FINALITY_DEPTH, falling back to the
+// default when unset or invalid
+func finalityDepthFromEnv() uint64 {
+	value := os.Getenv("EXPLORER_FINALITY_DEPTH")
+	if value == "" {
+		return defaultFinalityDepth
+	}
+
+	depth, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || depth == 0 {
+		slog.Warn("Ignoring invalid EXPLORER_FINALITY_DEPTH", "value", value, "default", defaultFinalityDepth)
+		return defaultFinalityDepth
+	}
+	return depth
+}
+
+// setFinality fills in confirmations relative to tipHeight and marks the
+// transactions buried at least depth blocks deep as final
+func setFinality(txs []WalletTransaction, tipHeight, depth uint64) {
+	for i := range txs {
+		tx := &txs[i]
+		tx.Confirmations = 0
+		if tx.BlockHeight <= tipHeight {
+			tx.Confirmations = tipHeight - tx.BlockHeight + 1
+		}
+		tx.Final = tx.Confirmations >= depth
+	}
+}
+
+// annotateFinality sets confirmations and finality from the canonical tip.
+// Transactions are left unannotated while no block has been indexed.
+func (es *ExplorerServer) annotateFinality(txs []WalletTransaction) {
+	tip, err := es.database.GetLatestBlock()
+	if err != nil {
+		return
+	}
+
+	depth := es.finalityDepth
+	if depth == 0 {
+		depth = defaultFinalityDepth
+	}
+	setFinality(txs, tip.Header.Height, depth)
+}
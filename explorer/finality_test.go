@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTransactionBecomesFinalAtDepth(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database, finalityDepth: 10}
+
+	storeTestBlocks(t, database, 0, 5)
+	txs := []WalletTransaction{{TxHash: "tx_5", BlockHeight: 5}}
+
+	es.annotateFinality(txs)
+	if txs[0].Confirmations != 1 || txs[0].Final {
+		t.Fatalf("Expected 1 confirmation and not final at the tip, got %d final=%v", txs[0].Confirmations, txs[0].Final)
+	}
+
+	// One block short of the threshold
+	storeTestBlocks(t, database, 6, 13)
+	es.annotateFinality(txs)
+	if txs[0].Confirmations != 9 || txs[0].Final {
+		t.Fatalf("Expected 9 confirmations and not final, got %d final=%v", txs[0].Confirmations, txs[0].Final)
+	}
+
+	storeTestBlocks(t, database, 14, 14)
+	es.annotateFinality(txs)
+	if txs[0].Confirmations != 10 || !txs[0].Final {
+		t.Fatalf("Expected final at 10 confirmations, got %d final=%v", txs[0].Confirmations, txs[0].Final)
+	}
+}
+
+func TestFinalityLeftUnsetWithoutBlocks(t *testing.T) {
+	es := &ExplorerServer{database: newTestDatabase(t)}
+	txs := []WalletTransaction{{TxHash: "tx", BlockHeight: 0}}
+
+	es.annotateFinality(txs)
+	if txs[0].Confirmations != 0 || txs[0].Final {
+		t.Errorf("Expected no confirmations before any block is indexed, got %+v", txs[0])
+	}
+}
+
+func TestSetFinalityAheadOfTip(t *testing.T) {
+	txs := []WalletTransaction{{BlockHeight: 20}}
+	setFinality(txs, 15, 1)
+	if txs[0].Confirmations != 0 || txs[0].Final {
+		t.Errorf("A transaction above the tip should be unconfirmed, got %+v", txs[0])
+	}
+}
+
+func TestWalletTransactionsAPIReportsFinality(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database, finalityDepth: 3}
+	storeTestBlocks(t, database, 0, 4)
+
+	for _, height := range []uint64{1, 3} {
+		tx := &WalletTransaction{
+			TxHash:      fmt.Sprintf("tx_%d", height),
+			BlockHeight: height,
+			Timestamp:   time.Unix(int64(height), 0),
+			Type:        "received",
+			Amount:      10,
+			ToAddress:   "S_wallet",
+		}
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store transaction: %v", err)
+		}
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/wallet/{address}/transactions", es.handleWalletTransactionsAPI)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/wallet/S_wallet/transactions", nil))
+
+	var body PaginatedTransactions
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	final := make(map[uint64]bool)
+	for _, tx := range body.Transactions {
+		final[tx.BlockHeight] = tx.Final
+	}
+	if len(final) != 2 || !final[1] || final[3] {
+		t.Errorf("Expected height 1 final and height 3 confirming, got %v", final)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	forkObserveInterval = 10 * time.Second
+	maxForkEvents       = 50 // Most recent observations of competing tips kept
+	maxForkBranches     = 10 // Most recent orphaned branches shown
+	forkBranchGap       = time.Minute
+)
+
+// Tip statuses, against the chain the explorer indexed
+const (
+	tipCanonical = "canonical" // Matches the indexed block at its height
+	tipCompeting = "competing" // Differs from the indexed block at its height
+	tipAhead     = "ahead"     // Above the indexed height, so unchecked
+)
+
+// ForkReporter is a node that reported a tip
+type ForkReporter struct {
+	Source        string `json:"source"` // "node" for a synced node, "tracker" for a tracker registration
+	ID            string `json:"id"`     // Node URL or tracker node ID
+	FarmerAddress string `json:"farmer_address,omitempty"`
+}
+
+// ForkTip is a chain tip and the nodes reporting it
+type ForkTip struct {
+	Height    uint64         `json:"height"`
+	Hash      string         `json:"hash"`
+	Status    string         `json:"status"`
+	Reporters []ForkReporter `json:"reporters"`
+}
+
+// ForkEvent is a stretch of time during which nodes reported competing tips
+type ForkEvent struct {
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Tips      []ForkTip `json:"tips"`
+}
+
+// ForkBranch is a run of blocks the explorer indexed and then orphaned,
+// with the canonical blocks that replaced them
+type ForkBranch struct {
+	ForkHeight uint64          `json:"fork_height"` // Last height both chains share
+	OrphanedAt time.Time       `json:"orphaned_at"`
+	Blocks     []OrphanBlock   `json:"blocks"`    // Lowest first
+	Canonical  []BlockWithHash `json:"canonical"` // The indexed blocks at the same heights
+}
+
+// ForkView is the payload of /api/v1/forks
+type ForkView struct {
+	Forking  bool         `json:"forking"` // Whether the current tips disagree
+	Tips     []ForkTip    `json:"tips"`
+	Recent   []ForkEvent  `json:"recent"`   // Newest first
+	Branches []ForkBranch `json:"branches"` // Newest first
+}
+
+// ForkTracker compares the tips reported by the synced nodes and by nodes
+// registered with the tracker against the indexed chain, remembering when
+// they disagreed. Brief forks resolve before the explorer syncs the losing
+// block, so this is the only place they show.
+type ForkTracker struct {
+	store    Store
+	nodes    *NodePool
+	tracker  *TrackerClient
+	interval time.Duration
+
+	mu     sync.RWMutex
+	tips   []ForkTip
+	events []ForkEvent // Oldest first
+
+	stopCh chan struct{}
+}
+
+// NewForkTracker creates a tracker comparing the tips of nodes and the
+// tracker's registrations, either of which may be nil
+func NewForkTracker(store Store, nodes *NodePool, tracker *TrackerClient, interval time.Duration) *ForkTracker {
+	return &ForkTracker{
+		store:    store,
+		nodes:    nodes,
+		tracker:  tracker,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start observes once and then keeps observing in the background
+func (f *ForkTracker) Start() {
+	f.Observe(time.Now())
+
+	go func() {
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				f.Observe(time.Now())
+			case <-f.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background observation
+func (f *ForkTracker) Stop() {
+	close(f.stopCh)
+}
+
+// Observe collects the current tips and records an event when they
+// disagree. A disagreement over the same tips as the last event extends it.
+func (f *ForkTracker) Observe(now time.Time) []ForkTip {
+	tips := f.classify(f.collect())
+	forking := tipsDisagree(tips)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tips = tips
+	if !forking {
+		return tips
+	}
+	if n := len(f.events); n > 0 && sameTips(f.events[n-1].Tips, tips) {
+		f.events[n-1].LastSeen = now
+		return tips
+	}
+	slog.Warn("Nodes report competing tips", "tips", len(tips))
+	f.events = append(f.events, ForkEvent{FirstSeen: now, LastSeen: now, Tips: tips})
+	if len(f.events) > maxForkEvents {
+		f.events = f.events[len(f.events)-maxForkEvents:]
+	}
+	return tips
+}
+
+// collect groups the healthy nodes' and registered nodes' tips by block
+func (f *ForkTracker) collect() []ForkTip {
+	byTip := make(map[string]*ForkTip)
+	add := func(height uint64, hash string, reporter ForkReporter) {
+		if hash == "" {
+			return
+		}
+		tip := byTip[hash]
+		if tip == nil {
+			tip = &ForkTip{Height: height, Hash: hash}
+			byTip[hash] = tip
+		}
+		tip.Reporters = append(tip.Reporters, reporter)
+	}
+
+	if f.nodes != nil {
+		for _, node := range f.nodes.Health() {
+			if node.Healthy {
+				add(node.Height, node.TipHash, ForkReporter{Source: "node", ID: node.URL})
+			}
+		}
+	}
+	if f.tracker != nil {
+		snapshot, _ := f.tracker.Snapshot()
+		for _, node := range snapshot.Nodes {
+			if node.Status == "online" {
+				add(node.ChainHeight, node.ChainHash, ForkReporter{Source: "tracker", ID: node.NodeID, FarmerAddress: node.MiningAddress})
+			}
+		}
+	}
+
+	tips := make([]ForkTip, 0, len(byTip))
+	for _, tip := range byTip {
+		tips = append(tips, *tip)
+	}
+	sort.Slice(tips, func(i, j int) bool {
+		if tips[i].Height != tips[j].Height {
+			return tips[i].Height > tips[j].Height
+		}
+		return tips[i].Hash < tips[j].Hash
+	})
+	return tips
+}
+
+// classify checks each tip against the indexed block at its height
+func (f *ForkTracker) classify(tips []ForkTip) []ForkTip {
+	indexed, err := f.store.GetLatestHeight()
+	if err != nil {
+		indexed = 0 // Nothing indexed yet
+	}
+	for i := range tips {
+		tip := &tips[i]
+		if tip.Height > indexed {
+			tip.Status = tipAhead
+			continue
+		}
+		hash, err := f.store.GetNodeHash(tip.Height)
+		switch {
+		case err != nil || hash == "":
+			tip.Status = tipAhead // Indexed before node hashes were kept
+		case hash == tip.Hash:
+			tip.Status = tipCanonical
+		default:
+			tip.Status = tipCompeting
+		}
+	}
+	return tips
+}
+
+// tipsDisagree reports whether any tip is off the indexed chain, or two
+// tips claim the same height
+func tipsDisagree(tips []ForkTip) bool {
+	heights := make(map[uint64]bool)
+	for _, tip := range tips {
+		if tip.Status == tipCompeting || heights[tip.Height] {
+			return true
+		}
+		heights[tip.Height] = true
+	}
+	return false
+}
+
+// sameTips reports whether a and b name the same blocks
+func sameTips(a, b []ForkTip) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Hash != b[i].Hash {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshot returns the latest tips and the recorded events, newest first
+func (f *ForkTracker) Snapshot() ([]ForkTip, []ForkEvent) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	events := make([]ForkEvent, 0, len(f.events))
+	for i := len(f.events) - 1; i >= 0; i-- {
+		events = append(events, f.events[i])
+	}
+	return append([]ForkTip{}, f.tips...), events
+}
+
+// forkBranches groups orphaned blocks, highest first, into the branches
+// they were on: consecutive heights orphaned together
+func forkBranches(store Store, orphans []OrphanBlock, limit int) ([]ForkBranch, error) {
+	branches := []ForkBranch{}
+	for i := 0; i < len(orphans); {
+		j := i + 1
+		for j < len(orphans) && orphans[j].Height+1 == orphans[j-1].Height &&
+			absDuration(orphans[j].OrphanedAt.Sub(orphans[j-1].OrphanedAt)) <= forkBranchGap {
+			j++
+		}
+		blocks := make([]OrphanBlock, 0, j-i)
+		for k := j - 1; k >= i; k-- {
+			blocks = append(blocks, orphans[k])
+		}
+		branches = append(branches, ForkBranch{
+			ForkHeight: blocks[0].Height - 1,
+			OrphanedAt: blocks[len(blocks)-1].OrphanedAt,
+			Blocks:     blocks,
+		})
+		i = j
+	}
+
+	// Orphans sort by height; show the most recently orphaned first
+	sort.SliceStable(branches, func(i, j int) bool { return branches[i].OrphanedAt.After(branches[j].OrphanedAt) })
+	if len(branches) > limit {
+		branches = branches[:limit]
+	}
+	for i := range branches {
+		b := &branches[i]
+		canonical, err := store.GetBlockRange(b.ForkHeight+1, b.Blocks[len(b.Blocks)-1].Height)
+		if err != nil {
+			return nil, err
+		}
+		b.Canonical = canonical
+	}
+	return branches, nil
+}
+
+// forkView combines the latest tips, past disagreements and orphaned
+// branches
+func (es *ExplorerServer) forkView() (*ForkView, error) {
+	view := &ForkView{Tips: []ForkTip{}, Recent: []ForkEvent{}}
+	if es.forks != nil {
+		view.Tips, view.Recent = es.forks.Snapshot()
+		view.Forking = tipsDisagree(view.Tips)
+	}
+
+	orphans, err := es.database.GetOrphanBlocks(maxOrphansLimit)
+	if err != nil {
+		return nil, err
+	}
+	view.Branches, err = forkBranches(es.database, orphans.Orphans, maxForkBranches)
+	if err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// Forks API endpoint: the tips nodes report now, recent disagreements
+// between them and the branches the explorer orphaned
+func (es *ExplorerServer) handleForks(w http.ResponseWriter, r *http.Request) {
+	view, err := es.forkView()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get forks", "err", err)
+		http.Error(w, "Failed to get forks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// Forks page handler: the branches drawn beside the chain that won
+func (es *ExplorerServer) handleForksPage(w http.ResponseWriter, r *http.Request) {
+	view, err := es.forkView()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get forks", "err", err)
+		http.Error(w, "Failed to get forks", http.StatusInternalServerError)
+		return
+	}
+	es.renderPage(w, r, http.StatusOK, "forks", page{Title: "Forks", Refresh: es.refreshSeconds(), Data: view})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTipNode serves a Tendermint /status at height 3 whose hash is read
+// from hash on every request
+func newTipNode(t *testing.T, hash *atomic.Value) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"result":{"sync_info":{"latest_block_height":"3","latest_block_hash":"%s"}}}`, hash.Load())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestForkTrackerObserve(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 1, 3)
+	if err := database.SetNodeHash(3, "A"); err != nil {
+		t.Fatalf("SetNodeHash failed: %v", err)
+	}
+
+	var hashA, hashB atomic.Value
+	hashA.Store("A")
+	hashB.Store("B")
+	nodeA, nodeB := newTipNode(t, &hashA), newTipNode(t, &hashB)
+	pool := NewNodePool(nodeA.URL+","+nodeB.URL, newTestNodeClient())
+
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/nodes") {
+			fmt.Fprint(w, `{"nodes":{"n1":{"mining_address":"Sfarmer","status":"online","chain_height":3,"chain_hash":"B"}}}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer tracker.Close()
+	tc := NewTrackerClient(tracker.URL, time.Hour)
+	if err := tc.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	forks := NewForkTracker(database, pool, tc, time.Hour)
+	pool.CheckAll()
+	start := time.Now()
+	tips := forks.Observe(start)
+	if len(tips) != 2 || tips[0].Hash != "A" || tips[0].Status != tipCanonical || tips[1].Status != tipCompeting {
+		t.Fatalf("Expected A canonical and B competing, got %+v", tips)
+	}
+	if reporters := tips[1].Reporters; len(reporters) != 2 || reporters[1].Source != "tracker" || reporters[1].FarmerAddress != "Sfarmer" {
+		t.Errorf("Expected B reported by a node and the tracker, got %+v", reporters)
+	}
+
+	// The same disagreement later extends the event
+	forks.Observe(start.Add(time.Minute))
+	if _, events := forks.Snapshot(); len(events) != 1 || !events[0].LastSeen.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected one extended event, got %+v", events)
+	}
+
+	// Once the nodes agree the tips are current, and the event is kept
+	hashB.Store("A")
+	forks.tracker = nil
+	pool.CheckAll()
+	forks.Observe(start.Add(2 * time.Minute))
+	tips, events := forks.Snapshot()
+	if len(tips) != 1 || tipsDisagree(tips) || len(events) != 1 {
+		t.Errorf("Expected the nodes to agree, got %+v and %d events", tips, len(events))
+	}
+}
+
+func TestForkBranches(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 1, 5)
+	if _, err := database.RollbackToHeight(3); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	for height := uint64(4); height <= 5; height++ {
+		block := &Block{Header: BlockHeader{Height: height, Timestamp: time.Unix(int64(height), 0), FarmerAddress: "Swinner"}}
+		if err := database.StoreBlock(fmt.Sprintf("hash_%db", height), block); err != nil {
+			t.Fatalf("StoreBlock failed: %v", err)
+		}
+	}
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleForks(rec, httptest.NewRequest("GET", "/api/v1/forks", nil))
+	var view ForkView
+	if err := json.NewDecoder(rec.Body).Decode(&view); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if view.Forking || len(view.Tips) != 0 || len(view.Branches) != 1 {
+		t.Fatalf("Expected one branch and no tips, got %+v", view)
+	}
+	branch := view.Branches[0]
+	if branch.ForkHeight != 3 || len(branch.Blocks) != 2 || branch.Blocks[0].Hash != "hash_4" ||
+		len(branch.Canonical) != 2 || branch.Canonical[1].Hash != "hash_5b" || branch.Canonical[1].Block.Header.FarmerAddress != "Swinner" {
+		t.Errorf("Unexpected branch %+v", branch)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleForksPage(rec, httptest.NewRequest("GET", "/forks", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Forked after block 3") || !strings.Contains(body, `href="/block/hash_5b"`) {
+		t.Errorf("Forks page should draw the branch beside the canonical chain, got %s", body)
+	}
+}
@@ -7,6 +7,8 @@ toolchain go1.23.11
 require (
 	github.com/dgraph-io/badger/v4 v4.8.0
 	github.com/gorilla/mux v1.8.1
+	github.com/lib/pq v1.12.3
+	golang.org/x/crypto v0.40.0
 )
 
 require (
@@ -23,5 +25,6 @@ require (
 	go.opentelemetry.io/otel/trace v1.37.0 // indirect
 	golang.org/x/net v0.41.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
+	golang.org/x/text v0.27.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 )
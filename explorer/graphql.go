@@ -0,0 +1,1099 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// /graphql serves the indexed data as a GraphQL API so a frontend can fetch
+// a composite view (a block with its transactions and the wallets they
+// touch, say) in one request. Queries run against the schema in
+// graphql_schema.go. The executor supports what clients send in practice:
+// variables, aliases, named and inline fragments and @include/@skip, but
+// not mutations, subscriptions or introspection beyond __typename.
+const (
+	maxGraphQLBody  = 1 << 20
+	maxGraphQLDepth = 10
+	// Fields resolved per request, however they are nested
+	maxGraphQLFields = 5000
+)
+
+// gqlError is an entry in a response's errors list
+type gqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (e *gqlError) Error() string { return e.Message }
+
+func gqlErrorf(format string, args ...interface{}) *gqlError {
+	return &gqlError{Message: fmt.Sprintf(format, args...)}
+}
+
+// Parsed documents
+
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+type gqlOperation struct {
+	kind      string // query, mutation or subscription
+	name      string
+	variables []gqlVariableDef
+	selection []gqlSelection
+}
+
+type gqlVariableDef struct {
+	name       string
+	typ        string // As written, such as "Int!"
+	defaultVal interface{}
+	hasDefault bool
+}
+
+type gqlFragment struct {
+	name      string
+	onType    string // Empty for an inline fragment without a type condition
+	selection []gqlSelection
+}
+
+// gqlSelection is a field, a fragment spread or an inline fragment
+type gqlSelection struct {
+	field      *gqlFieldNode
+	spread     string
+	inline     *gqlFragment
+	directives []gqlDirective
+}
+
+type gqlFieldNode struct {
+	alias     string
+	name      string
+	args      []gqlArgument
+	selection []gqlSelection
+}
+
+func (f *gqlFieldNode) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type gqlArgument struct {
+	name  string
+	value interface{}
+}
+
+type gqlDirective struct {
+	name string
+	args []gqlArgument
+}
+
+// Values in a document are int64, float64, string, bool, nil, gqlEnum,
+// gqlVariable, []interface{} or map[string]interface{}
+type (
+	gqlEnum     string
+	gqlVariable string
+)
+
+// Lexer
+
+type gqlTokenKind int
+
+const (
+	gqlEOF gqlTokenKind = iota
+	gqlPunct
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind  gqlTokenKind
+	value string
+	pos   int
+}
+
+func lexGraphQL(src string) ([]gqlToken, error) {
+	var tokens []gqlToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "\ufeff"):
+			i += len("\ufeff")
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, gqlToken{gqlPunct, "...", i})
+			i += 3
+		case strings.IndexByte("{}()[]:=!$@|&", c) >= 0:
+			tokens = append(tokens, gqlToken{gqlPunct, string(c), i})
+			i++
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] >= 'a' && src[i] <= 'z' || src[i] >= 'A' && src[i] <= 'Z' || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, gqlToken{gqlName, src[start:i], start})
+		case c == '-' || c >= '0' && c <= '9':
+			start := i
+			i++
+			kind := gqlInt
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || strings.IndexByte(".eE+-", src[i]) >= 0) {
+				if strings.IndexByte(".eE", src[i]) >= 0 {
+					kind = gqlFloat
+				}
+				i++
+			}
+			tokens = append(tokens, gqlToken{kind, src[start:i], start})
+		case c == '"':
+			if strings.HasPrefix(src[i:], `"""`) {
+				return nil, gqlErrorf("block strings are not supported (at %d)", i)
+			}
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\\' {
+					i++
+				}
+				if i < len(src) && (src[i] == '\n' || src[i] == '\r') {
+					return nil, gqlErrorf("unterminated string at %d", start)
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, gqlErrorf("unterminated string at %d", start)
+			}
+			i++
+			// GraphQL's escapes are a subset of JSON's
+			var value string
+			if err := json.Unmarshal([]byte(src[start:i]), &value); err != nil {
+				return nil, gqlErrorf("invalid string at %d", start)
+			}
+			tokens = append(tokens, gqlToken{gqlString, value, start})
+		default:
+			return nil, gqlErrorf("unexpected character %q at %d", c, i)
+		}
+	}
+	return append(tokens, gqlToken{gqlEOF, "", len(src)}), nil
+}
+
+// Parser
+
+type gqlParser struct {
+	tokens []gqlToken
+	pos    int
+}
+
+func (p *gqlParser) peek() gqlToken { return p.tokens[p.pos] }
+
+func (p *gqlParser) next() gqlToken {
+	token := p.tokens[p.pos]
+	if token.kind != gqlEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *gqlParser) isPunct(value string) bool {
+	token := p.peek()
+	return token.kind == gqlPunct && token.value == value
+}
+
+func (p *gqlParser) expectPunct(value string) error {
+	token := p.next()
+	if token.kind != gqlPunct || token.value != value {
+		return gqlErrorf("expected %q at %d", value, token.pos)
+	}
+	return nil
+}
+
+func (p *gqlParser) expectName() (string, error) {
+	token := p.next()
+	if token.kind != gqlName {
+		return "", gqlErrorf("expected a name at %d", token.pos)
+	}
+	return token.value, nil
+}
+
+// parseGraphQL parses a query document
+func parseGraphQL(src string) (*gqlDocument, error) {
+	tokens, err := lexGraphQL(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{tokens: tokens}
+	doc := &gqlDocument{fragments: make(map[string]*gqlFragment)}
+
+	for p.peek().kind != gqlEOF {
+		token := p.peek()
+		switch {
+		case token.kind == gqlPunct && token.value == "{":
+			selection, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &gqlOperation{kind: "query", selection: selection})
+		case token.kind == gqlName && token.value == "fragment":
+			p.next()
+			fragment, err := p.parseFragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := doc.fragments[fragment.name]; exists {
+				return nil, gqlErrorf("fragment %q is defined twice", fragment.name)
+			}
+			doc.fragments[fragment.name] = fragment
+		case token.kind == gqlName && (token.value == "query" || token.value == "mutation" || token.value == "subscription"):
+			operation, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, operation)
+		default:
+			return nil, gqlErrorf("unexpected %q at %d", token.value, token.pos)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, gqlErrorf("the document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) parseOperation() (*gqlOperation, error) {
+	operation := &gqlOperation{kind: p.next().value}
+	if p.peek().kind == gqlName {
+		operation.name = p.next().value
+	}
+	if p.isPunct("(") {
+		p.next()
+		for !p.isPunct(")") {
+			if err := p.expectPunct("$"); err != nil {
+				return nil, err
+			}
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return nil, err
+			}
+			typ, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			def := gqlVariableDef{name: name, typ: typ}
+			if p.isPunct("=") {
+				p.next()
+				if def.defaultVal, err = p.parseValue(true); err != nil {
+					return nil, err
+				}
+				def.hasDefault = true
+			}
+			operation.variables = append(operation.variables, def)
+		}
+		p.next()
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	selection, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	operation.selection = selection
+	return operation, nil
+}
+
+// parseType returns a variable's type as written
+func (p *gqlParser) parseType() (string, error) {
+	var typ string
+	if p.isPunct("[") {
+		p.next()
+		inner, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+	if p.isPunct("!") {
+		p.next()
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *gqlParser) parseFragmentDefinition() (*gqlFragment, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if on, err := p.expectName(); err != nil || on != "on" {
+		return nil, gqlErrorf("expected \"on\" after fragment %s", name)
+	}
+	onType, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	selection, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &gqlFragment{name: name, onType: onType, selection: selection}, nil
+}
+
+func (p *gqlParser) parseSelectionSet() ([]gqlSelection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var selection []gqlSelection
+	for !p.isPunct("}") {
+		if p.peek().kind == gqlEOF {
+			return nil, gqlErrorf("unterminated selection set")
+		}
+		item, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selection = append(selection, item)
+	}
+	p.next()
+	if len(selection) == 0 {
+		return nil, gqlErrorf("empty selection set")
+	}
+	return selection, nil
+}
+
+func (p *gqlParser) parseSelection() (gqlSelection, error) {
+	var item gqlSelection
+	if p.isPunct("...") {
+		p.next()
+		if token := p.peek(); token.kind == gqlName && token.value != "on" {
+			item.spread = p.next().value
+		} else {
+			fragment := &gqlFragment{}
+			if token.kind == gqlName {
+				p.next()
+				onType, err := p.expectName()
+				if err != nil {
+					return item, err
+				}
+				fragment.onType = onType
+			}
+			item.inline = fragment
+		}
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return item, err
+		}
+		item.directives = directives
+		if item.inline != nil {
+			if item.inline.selection, err = p.parseSelectionSet(); err != nil {
+				return item, err
+			}
+		}
+		return item, nil
+	}
+
+	field := &gqlFieldNode{}
+	name, err := p.expectName()
+	if err != nil {
+		return item, err
+	}
+	if p.isPunct(":") {
+		p.next()
+		field.alias = name
+		if name, err = p.expectName(); err != nil {
+			return item, err
+		}
+	}
+	field.name = name
+	if field.args, err = p.parseArguments(); err != nil {
+		return item, err
+	}
+	if item.directives, err = p.parseDirectives(); err != nil {
+		return item, err
+	}
+	if p.isPunct("{") {
+		if field.selection, err = p.parseSelectionSet(); err != nil {
+			return item, err
+		}
+	}
+	item.field = field
+	return item, nil
+}
+
+func (p *gqlParser) parseArguments() ([]gqlArgument, error) {
+	if !p.isPunct("(") {
+		return nil, nil
+	}
+	p.next()
+	var args []gqlArgument
+	for !p.isPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, gqlArgument{name: name, value: value})
+	}
+	p.next()
+	return args, nil
+}
+
+func (p *gqlParser) parseDirectives() ([]gqlDirective, error) {
+	var directives []gqlDirective
+	for p.isPunct("@") {
+		p.next()
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, gqlDirective{name: name, args: args})
+	}
+	return directives, nil
+}
+
+// parseValue parses a literal; constant values may not use variables
+func (p *gqlParser) parseValue(constant bool) (interface{}, error) {
+	token := p.next()
+	switch token.kind {
+	case gqlInt:
+		n, err := strconv.ParseInt(token.value, 10, 64)
+		if err != nil {
+			return nil, gqlErrorf("invalid integer %s", token.value)
+		}
+		return n, nil
+	case gqlFloat:
+		f, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, gqlErrorf("invalid number %s", token.value)
+		}
+		return f, nil
+	case gqlString:
+		return token.value, nil
+	case gqlName:
+		switch token.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return gqlEnum(token.value), nil
+	case gqlPunct:
+		switch token.value {
+		case "$":
+			if constant {
+				return nil, gqlErrorf("variables are not allowed here (at %d)", token.pos)
+			}
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return gqlVariable(name), nil
+		case "[":
+			list := []interface{}{}
+			for !p.isPunct("]") {
+				if p.peek().kind == gqlEOF {
+					return nil, gqlErrorf("unterminated list")
+				}
+				value, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			object := map[string]interface{}{}
+			for !p.isPunct("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				if object[name], err = p.parseValue(constant); err != nil {
+					return nil, err
+				}
+			}
+			p.next()
+			return object, nil
+		}
+	}
+	return nil, gqlErrorf("unexpected %q at %d", token.value, token.pos)
+}
+
+// Schema
+
+// gqlType is an object type of the schema
+type gqlType struct {
+	name   string
+	fields map[string]*gqlField
+}
+
+// gqlField is a field of an object type. A field without a type is a
+// leaf, whose resolved value is returned as JSON.
+type gqlField struct {
+	typ     *gqlType
+	list    bool
+	args    map[string]gqlArgDef
+	resolve func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error)
+}
+
+// gqlArgDef declares an argument: its type ("Int", "String" or "Boolean"),
+// whether it is required and its default
+type gqlArgDef struct {
+	typ        string
+	required   bool
+	defaultVal interface{}
+}
+
+// gqlArgs are a field's coerced arguments: int, string or bool values,
+// absent when neither given nor defaulted
+type gqlArgs map[string]interface{}
+
+func (a gqlArgs) Int(name string) int {
+	n, _ := a[name].(int)
+	return n
+}
+
+func (a gqlArgs) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+func (a gqlArgs) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// gqlStructFields exposes every field of the struct behind example as a
+// leaf under its JSON name, resolved from a pointer to that struct
+func gqlStructFields(example interface{}) map[string]*gqlField {
+	fields := make(map[string]*gqlField)
+	t := reflect.TypeOf(example)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if !sf.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		index := sf.Index
+		fields[name] = &gqlField{resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return reflect.ValueOf(parent).Elem().FieldByIndex(index).Interface(), nil
+		}}
+	}
+	return fields
+}
+
+// Execution
+
+// gqlObject is a resolved object, keeping fields in the order selected
+type gqlObject []gqlEntry
+
+type gqlEntry struct {
+	key   string
+	value interface{}
+}
+
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type gqlExecutor struct {
+	doc       *gqlDocument
+	variables map[string]interface{}
+	ctx       *gqlContext
+	errors    []gqlError
+	resolved  int
+}
+
+// gqlCollected is a response key with the fields selected under it
+type gqlCollected struct {
+	key    string
+	fields []*gqlFieldNode
+}
+
+// collectFields flattens fragments and applies @include/@skip, merging
+// fields selected under the same response key
+func (ex *gqlExecutor) collectFields(typ *gqlType, selection []gqlSelection, collected []gqlCollected, visited map[string]bool) ([]gqlCollected, error) {
+	for _, item := range selection {
+		include, err := ex.included(item.directives)
+		if err != nil {
+			return nil, err
+		}
+		if !include {
+			continue
+		}
+
+		switch {
+		case item.field != nil:
+			key := item.field.responseKey()
+			merged := false
+			for i := range collected {
+				if collected[i].key == key {
+					if collected[i].fields[0].name != item.field.name {
+						return nil, gqlErrorf("fields %q and %q conflict under %q", collected[i].fields[0].name, item.field.name, key)
+					}
+					collected[i].fields = append(collected[i].fields, item.field)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				collected = append(collected, gqlCollected{key: key, fields: []*gqlFieldNode{item.field}})
+			}
+
+		case item.spread != "":
+			if visited[item.spread] {
+				continue
+			}
+			fragment, ok := ex.doc.fragments[item.spread]
+			if !ok {
+				return nil, gqlErrorf("unknown fragment %q", item.spread)
+			}
+			visited[item.spread] = true
+			if fragment.onType != typ.name {
+				if _, known := gqlTypes[fragment.onType]; !known {
+					return nil, gqlErrorf("unknown type %q in fragment %q", fragment.onType, fragment.name)
+				}
+				continue
+			}
+			if collected, err = ex.collectFields(typ, fragment.selection, collected, visited); err != nil {
+				return nil, err
+			}
+
+		case item.inline != nil:
+			if onType := item.inline.onType; onType != "" && onType != typ.name {
+				if _, known := gqlTypes[onType]; !known {
+					return nil, gqlErrorf("unknown type %q in inline fragment", onType)
+				}
+				continue
+			}
+			if collected, err = ex.collectFields(typ, item.inline.selection, collected, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return collected, nil
+}
+
+// included evaluates @include(if:) and @skip(if:)
+func (ex *gqlExecutor) included(directives []gqlDirective) (bool, error) {
+	for _, directive := range directives {
+		if directive.name != "include" && directive.name != "skip" {
+			return false, gqlErrorf("unknown directive @%s", directive.name)
+		}
+		if len(directive.args) != 1 || directive.args[0].name != "if" {
+			return false, gqlErrorf("@%s takes a single if argument", directive.name)
+		}
+		value, err := ex.value(directive.args[0].value)
+		if err != nil {
+			return false, err
+		}
+		condition, ok := value.(bool)
+		if !ok {
+			return false, gqlErrorf("@%s(if:) must be a Boolean", directive.name)
+		}
+		if condition == (directive.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// value substitutes variables into a document value
+func (ex *gqlExecutor) value(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case gqlVariable:
+		value, ok := ex.variables[string(v)]
+		if !ok {
+			return nil, gqlErrorf("variable $%s is not defined", v)
+		}
+		return value, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			value, err := ex.value(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	}
+	return v, nil
+}
+
+// coerceArgs checks a field's arguments against their definitions
+func (ex *gqlExecutor) coerceArgs(name string, def *gqlField, given []gqlArgument) (gqlArgs, error) {
+	args := gqlArgs{}
+	for _, arg := range given {
+		argDef, ok := def.args[arg.name]
+		if !ok {
+			return nil, gqlErrorf("unknown argument %q on field %q", arg.name, name)
+		}
+		value, err := ex.value(arg.value)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+
+		switch argDef.typ {
+		case "Int":
+			var n int64
+			switch v := value.(type) {
+			case int64:
+				n = v
+			case float64: // From JSON variables
+				if v != float64(int64(v)) {
+					return nil, gqlErrorf("argument %q of %q must be an Int", arg.name, name)
+				}
+				n = int64(v)
+			default:
+				return nil, gqlErrorf("argument %q of %q must be an Int", arg.name, name)
+			}
+			if n < -1<<31 || n >= 1<<31 {
+				return nil, gqlErrorf("argument %q of %q is out of range", arg.name, name)
+			}
+			args[arg.name] = int(n)
+		case "String":
+			s, ok := value.(string)
+			if !ok {
+				return nil, gqlErrorf("argument %q of %q must be a String", arg.name, name)
+			}
+			args[arg.name] = s
+		case "Boolean":
+			b, ok := value.(bool)
+			if !ok {
+				return nil, gqlErrorf("argument %q of %q must be a Boolean", arg.name, name)
+			}
+			args[arg.name] = b
+		}
+	}
+	for argName, argDef := range def.args {
+		if args.Has(argName) {
+			continue
+		}
+		if argDef.defaultVal != nil {
+			args[argName] = argDef.defaultVal
+		} else if argDef.required {
+			return nil, gqlErrorf("argument %q of %q is required", argName, name)
+		}
+	}
+	return args, nil
+}
+
+// validate checks a selection on typ before anything is resolved, so a
+// malformed query fails as a whole instead of after partial work
+func (ex *gqlExecutor) validate(typ *gqlType, selection []gqlSelection, depth int) error {
+	if depth > maxGraphQLDepth {
+		return gqlErrorf("query is nested deeper than %d levels", maxGraphQLDepth)
+	}
+	collected, err := ex.collectFields(typ, selection, nil, map[string]bool{})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range collected {
+		field := c.fields[0]
+		var subselection []gqlSelection
+		for _, f := range c.fields {
+			subselection = append(subselection, f.selection...)
+		}
+		if field.name == "__typename" {
+			if len(subselection) > 0 {
+				return gqlErrorf("field \"__typename\" has no subfields")
+			}
+			continue
+		}
+		def, ok := typ.fields[field.name]
+		if !ok {
+			return gqlErrorf("cannot query field %q on type %s", field.name, typ.name)
+		}
+		if def.typ == nil && len(subselection) > 0 {
+			return gqlErrorf("field %q of type %s has no subfields", field.name, typ.name)
+		}
+		if def.typ != nil && len(subselection) == 0 {
+			return gqlErrorf("field %q of type %s needs a selection of subfields", field.name, typ.name)
+		}
+		for _, f := range c.fields {
+			if _, err := ex.coerceArgs(field.name, def, f.args); err != nil {
+				return err
+			}
+		}
+		if def.typ != nil {
+			if err := ex.validate(def.typ, subselection, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// executeObject resolves the selection on parent, an object of type typ.
+// The selection has been validated.
+func (ex *gqlExecutor) executeObject(typ *gqlType, parent interface{}, selection []gqlSelection, path []interface{}) (gqlObject, error) {
+	collected, err := ex.collectFields(typ, selection, nil, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(gqlObject, 0, len(collected))
+	for _, c := range collected {
+		field := c.fields[0]
+		fieldPath := append(append([]interface{}{}, path...), c.key)
+
+		if field.name == "__typename" {
+			result = append(result, gqlEntry{c.key, typ.name})
+			continue
+		}
+		def := typ.fields[field.name]
+		var subselection []gqlSelection
+		for _, f := range c.fields {
+			subselection = append(subselection, f.selection...)
+		}
+		args, err := ex.coerceArgs(field.name, def, field.args)
+		if err != nil {
+			return nil, err
+		}
+
+		ex.resolved++
+		if ex.resolved > maxGraphQLFields {
+			return nil, gqlErrorf("query resolves more than %d fields", maxGraphQLFields)
+		}
+		value, err := def.resolve(ex.ctx, parent, args)
+		if err != nil {
+			// The field is null and the rest of the response carries on
+			message := err.Error()
+			if err == errNotFound {
+				message = "not found"
+			}
+			ex.errors = append(ex.errors, gqlError{Message: message, Path: fieldPath})
+			result = append(result, gqlEntry{c.key, nil})
+			continue
+		}
+
+		resolved, err := ex.complete(def, value, subselection, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, gqlEntry{c.key, resolved})
+	}
+	return result, nil
+}
+
+// complete turns a resolved value into its response: leaves as they are,
+// objects and lists of objects by resolving the subselection
+func (ex *gqlExecutor) complete(def *gqlField, value interface{}, selection []gqlSelection, path []interface{}) (interface{}, error) {
+	if isNilValue(value) || def.typ == nil {
+		return value, nil
+	}
+	if !def.list {
+		return ex.executeObject(def.typ, value, selection, path)
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("field %v resolved to %T, not a list", path, value)
+	}
+	list := make([]interface{}, len(items))
+	for i, item := range items {
+		if isNilValue(item) {
+			continue
+		}
+		object, err := ex.executeObject(def.typ, item, selection, append(path, i))
+		if err != nil {
+			return nil, err
+		}
+		list[i] = object
+	}
+	return list, nil
+}
+
+// isNilValue reports whether v is nil, including a typed nil pointer
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// gqlRequest is a GraphQL request as POSTed or passed in the query string
+type gqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// gqlResponse is the body of every /graphql response
+type gqlResponse struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []gqlError  `json:"errors,omitempty"`
+}
+
+// executeGraphQL runs a request against the schema. A request that cannot
+// run at all returns an error; errors in single fields are reported in
+// the response next to the data that did resolve.
+func executeGraphQL(ctx *gqlContext, req *gqlRequest) (*gqlResponse, error) {
+	doc, err := parseGraphQL(req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var operation *gqlOperation
+	for _, op := range doc.operations {
+		if req.OperationName == "" || op.name == req.OperationName {
+			if operation != nil {
+				return nil, gqlErrorf("operationName is required for a document with several operations")
+			}
+			operation = op
+		}
+	}
+	if operation == nil {
+		return nil, gqlErrorf("no operation named %q", req.OperationName)
+	}
+	if operation.kind != "query" {
+		return nil, gqlErrorf("%s operations are not supported", operation.kind)
+	}
+
+	variables := make(map[string]interface{})
+	for _, def := range operation.variables {
+		value, given := req.Variables[def.name]
+		switch {
+		case given:
+			if n, ok := value.(float64); ok && strings.TrimSuffix(def.typ, "!") == "Int" && n == float64(int64(n)) {
+				value = int64(n)
+			}
+			variables[def.name] = value
+		case def.hasDefault:
+			variables[def.name] = def.defaultVal
+		case strings.HasSuffix(def.typ, "!"):
+			return nil, gqlErrorf("variable $%s of type %s is required", def.name, def.typ)
+		default:
+			variables[def.name] = nil
+		}
+	}
+
+	ex := &gqlExecutor{doc: doc, variables: variables, ctx: ctx}
+	if err := ex.validate(gqlQueryType, operation.selection, 1); err != nil {
+		return nil, err
+	}
+	data, err := ex.executeObject(gqlQueryType, struct{}{}, operation.selection, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &gqlResponse{Data: data, Errors: ex.errors}, nil
+}
+
+// GraphQL endpoint: a query in the POSTed JSON body (or an
+// application/graphql body), or in ?query= with ?variables= as JSON
+func (es *ExplorerServer) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req gqlRequest
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				writeGraphQLError(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	} else {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGraphQLBody))
+		if err != nil {
+			writeGraphQLError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/graphql") {
+			req.Query = string(body)
+		} else if err := json.Unmarshal(body, &req); err != nil {
+			writeGraphQLError(w, http.StatusBadRequest, "request body must be JSON with a query")
+			return
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeGraphQLError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	resp, err := executeGraphQL(newGQLContext(es.database), &req)
+	if err != nil {
+		var gqlErr *gqlError
+		if !errors.As(err, &gqlErr) {
+			slog.ErrorContext(r.Context(), "GraphQL query failed", "err", err)
+		}
+		writeGraphQLError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write GraphQL response", "err", err)
+	}
+}
+
+func writeGraphQLError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(gqlResponse{Errors: []gqlError{{Message: message}}})
+}
@@ -0,0 +1,943 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The GraphQL schema: the query root and the object types under it. Each
+// type keeps its leaves in the JSON names the REST API uses and adds the
+// relations between records, resolved lazily through the store.
+
+const (
+	defaultGraphQLPage = 20
+	maxGraphQLPage     = 100
+)
+
+var (
+	gqlQueryType *gqlType
+
+	// gqlTypes holds every object type by name, for fragment type conditions
+	gqlTypes = map[string]*gqlType{}
+)
+
+// gqlContext is the state of one request: the store and what has been
+// read from it, so a record reached along several paths is read once
+type gqlContext struct {
+	store   Store
+	blocks  map[string]*gqlBlock
+	heights map[uint64]*gqlBlock
+	wallets map[string]*WalletSummary
+	tokens  map[string]*TokenInfo
+	labels  map[string]*AddressLabel
+}
+
+func newGQLContext(store Store) *gqlContext {
+	return &gqlContext{
+		store:   store,
+		blocks:  make(map[string]*gqlBlock),
+		heights: make(map[uint64]*gqlBlock),
+		wallets: make(map[string]*WalletSummary),
+		tokens:  make(map[string]*TokenInfo),
+		labels:  make(map[string]*AddressLabel),
+	}
+}
+
+// gqlBlock is a block with the hash it is stored under
+type gqlBlock struct {
+	hash  string
+	block *Block
+}
+
+// gqlTransaction is a transaction decoded from its block
+type gqlTransaction struct {
+	hash   string
+	signed *SignedTransaction
+	tx     *Transaction
+	block  *gqlBlock
+}
+
+// gqlWallet is an address; its summary is read when a field needs it
+type gqlWallet struct {
+	address string
+}
+
+// gqlConnection is one page of a list and where the next page starts
+type gqlConnection struct {
+	nodes     []interface{}
+	endCursor string
+	hasNext   bool
+	total     interface{} // Nil when the store cannot count cheaply
+}
+
+func (ctx *gqlContext) cacheBlock(block *gqlBlock) *gqlBlock {
+	ctx.blocks[block.hash] = block
+	ctx.heights[block.block.Header.Height] = block
+	return block
+}
+
+// blockByHash returns nil for a block that is not stored
+func (ctx *gqlContext) blockByHash(hash string) (*gqlBlock, error) {
+	if block, ok := ctx.blocks[hash]; ok {
+		return block, nil
+	}
+	block, err := ctx.store.GetBlock(hash)
+	if err == errNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ctx.cacheBlock(&gqlBlock{hash: hash, block: block}), nil
+}
+
+// blockByHeight returns nil for a block that is not stored
+func (ctx *gqlContext) blockByHeight(height uint64) (*gqlBlock, error) {
+	if block, ok := ctx.heights[height]; ok {
+		return block, nil
+	}
+	blocks, err := ctx.store.GetBlockRange(height, height)
+	if err != nil || len(blocks) == 0 {
+		return nil, err
+	}
+	return ctx.cacheBlock(&gqlBlock{hash: blocks[0].Hash, block: &blocks[0].Block}), nil
+}
+
+func (ctx *gqlContext) walletSummary(address string) (*WalletSummary, error) {
+	if summary, ok := ctx.wallets[address]; ok {
+		return summary, nil
+	}
+	summary, err := ctx.store.GetWalletSummary(address)
+	if err != nil {
+		return nil, err
+	}
+	ctx.wallets[address] = summary
+	return summary, nil
+}
+
+// label returns nil for an address without a label
+func (ctx *gqlContext) label(address string) (*AddressLabel, error) {
+	if label, ok := ctx.labels[address]; ok {
+		return label, nil
+	}
+	labels, err := lookupLabels(ctx.store, []string{address})
+	if err != nil {
+		return nil, err
+	}
+	ctx.labels[address] = labels[address]
+	return labels[address], nil
+}
+
+// token returns nil for SHADOW and for tokens that are not indexed
+func (ctx *gqlContext) token(tokenID string) (*TokenInfo, error) {
+	if tokenID == "" || tokenID == "SHADOW" {
+		return nil, nil
+	}
+	if token, ok := ctx.tokens[tokenID]; ok {
+		return token, nil
+	}
+	token, err := ctx.store.GetToken(tokenID)
+	if err == errNotFound {
+		token, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ctx.tokens[tokenID] = token
+	return token, nil
+}
+
+// pool looks a pool up by ID, then by L-address, returning nil when
+// neither matches
+func (ctx *gqlContext) pool(id string) (*LiquidityPool, error) {
+	pool, err := ctx.store.GetPool(id)
+	if err == errNotFound {
+		pool, err = ctx.store.GetPoolByAddress(id)
+	}
+	if err == errNotFound {
+		return nil, nil
+	}
+	return pool, err
+}
+
+// transactions decodes the transactions of a block. Coinbase transactions
+// carry base64-encoded JSON and are named after the block, as sync does.
+func (ctx *gqlContext) transactions(block *gqlBlock) []*gqlTransaction {
+	var txs []*gqlTransaction
+	for i := range block.block.Body.Transactions {
+		signed := &block.block.Body.Transactions[i]
+		tx, err := decodeSignedTransaction(signed)
+		if err != nil {
+			continue
+		}
+		hash := signed.TxHash
+		if signed.Algorithm == "coinbase" && hash == "transaction" {
+			hash = fmt.Sprintf("coinbase_%s", block.hash)
+		}
+		txs = append(txs, &gqlTransaction{hash: hash, signed: signed, tx: tx, block: block})
+	}
+	return txs
+}
+
+// transaction finds a transaction through the block the wallet index
+// recorded it in, returning nil when it is not indexed
+func (ctx *gqlContext) transaction(hash string) (*gqlTransaction, error) {
+	walletTx, err := ctx.store.GetTransaction(hash)
+	if err == errNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, err := ctx.blockByHash(walletTx.BlockHash)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	for _, tx := range ctx.transactions(block) {
+		if tx.hash == hash {
+			return tx, nil
+		}
+	}
+	return nil, nil
+}
+
+func decodeSignedTransaction(signed *SignedTransaction) (*Transaction, error) {
+	data := []byte(signed.Transaction)
+	if signed.Algorithm == "coinbase" {
+		encoded := string(data)
+		if strings.HasPrefix(encoded, `"`) {
+			if err := json.Unmarshal(data, &encoded); err != nil {
+				return nil, err
+			}
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+	var tx Transaction
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// pageSize reads the first argument of a connection
+func pageSize(args gqlArgs) (int, error) {
+	first := args.Int("first")
+	if first < 1 || first > maxGraphQLPage {
+		return 0, fmt.Errorf("first must be between 1 and %d", maxGraphQLPage)
+	}
+	return first, nil
+}
+
+// offsetCursor decodes the after argument of an offset-paged connection
+func offsetCursor(args gqlArgs) (int, error) {
+	key, err := decodeCursor(args.String("after"), []byte("offset:"))
+	if err != nil || key == nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(key), "offset:"))
+	if err != nil || offset < 0 {
+		return 0, errInvalidCursor
+	}
+	return offset, nil
+}
+
+// offsetConnection pages a list the store serves in numbered pages. fetch
+// returns one page and the size of the whole list; a window that straddles
+// two pages reads both.
+func offsetConnection(args gqlArgs, fetch func(page, perPage int) ([]interface{}, int64, error)) (*gqlConnection, error) {
+	first, err := pageSize(args)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := offsetCursor(args)
+	if err != nil {
+		return nil, err
+	}
+
+	page := offset/first + 1
+	items, total, err := fetch(page, first)
+	if err != nil {
+		return nil, err
+	}
+	skip := offset % first
+	if skip > 0 {
+		more, _, err := fetch(page+1, first)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, more...)
+	}
+	if skip > len(items) {
+		skip = len(items)
+	}
+	items = items[skip:]
+	if len(items) > first {
+		items = items[:first]
+	}
+
+	end := offset + len(items)
+	return &gqlConnection{
+		nodes:     items,
+		endCursor: encodeCursor([]byte("offset:" + strconv.Itoa(end))),
+		hasNext:   int64(end) < total,
+		total:     total,
+	}, nil
+}
+
+// blockConnection pages blocks by height, newest first, within
+// [from_height, to_height]
+func blockConnection(ctx *gqlContext, args gqlArgs) (*gqlConnection, error) {
+	first, err := pageSize(args)
+	if err != nil {
+		return nil, err
+	}
+	low := uint64(1)
+	if args.Int("from_height") > 1 {
+		low = uint64(args.Int("from_height"))
+	}
+	high, err := ctx.store.GetLatestHeight()
+	if err != nil {
+		return nil, err
+	}
+	if args.Has("to_height") && args.Int("to_height") >= 0 && uint64(args.Int("to_height")) < high {
+		high = uint64(args.Int("to_height"))
+	}
+
+	key, err := decodeCursor(args.String("after"), []byte("height:"))
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		after, err := strconv.ParseUint(strings.TrimPrefix(string(key), "height:"), 10, 64)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		if after <= high {
+			high = after - 1
+		}
+	}
+
+	connection := &gqlConnection{nodes: []interface{}{}}
+	if high < low {
+		return connection, nil
+	}
+	from := low
+	if high-low >= uint64(first) {
+		from = high - uint64(first) + 1
+	}
+	blocks, err := ctx.store.GetBlockRange(from, high)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		connection.nodes = append(connection.nodes, ctx.cacheBlock(&gqlBlock{hash: blocks[i].Hash, block: &blocks[i].Block}))
+	}
+	connection.endCursor = encodeCursor([]byte("height:" + strconv.FormatUint(from, 10)))
+	connection.hasNext = from > low
+	return connection, nil
+}
+
+// walletTransactionConnection pages an address's transactions, newest
+// first, optionally of one type. It passes the store's cursor through.
+func walletTransactionConnection(ctx *gqlContext, address string, args gqlArgs) (*gqlConnection, error) {
+	first, err := pageSize(args)
+	if err != nil {
+		return nil, err
+	}
+	txType := args.String("type")
+	connection := &gqlConnection{nodes: []interface{}{}}
+
+	cursor := args.String("after")
+	for {
+		page, err := ctx.store.GetWalletTransactionsPage(address, cursor, 1, first)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page.Transactions {
+			tx := &page.Transactions[i]
+			if txType != "" && tx.Type != txType {
+				continue
+			}
+			connection.nodes = append(connection.nodes, tx)
+			if len(connection.nodes) == first {
+				// The store's cursors point at a transaction, so a page
+				// cut short here resumes right after its last node
+				connection.endCursor = walletTxCursor(address, tx)
+				connection.hasNext = i < len(page.Transactions)-1 || page.NextCursor != ""
+				return connection, nil
+			}
+		}
+		connection.endCursor = page.NextCursor
+		if page.NextCursor == "" {
+			return connection, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// walletTxCursor is the cursor GetWalletTransactionsPage issues for tx
+func walletTxCursor(address string, tx *WalletTransaction) string {
+	return encodeCursor([]byte(fmt.Sprintf("addr_tx:%s:%016d:%s", address, tx.BlockHeight, tx.TxHash)))
+}
+
+func gqlList[T any](items []T) []interface{} {
+	list := make([]interface{}, len(items))
+	for i := range items {
+		list[i] = &items[i]
+	}
+	return list
+}
+
+// Resolver shorthands
+
+type gqlResolver = func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error)
+
+func gqlLeafField(resolve gqlResolver) *gqlField {
+	return &gqlField{resolve: resolve}
+}
+
+func gqlObjectField(typ *gqlType, resolve gqlResolver) *gqlField {
+	return &gqlField{typ: typ, resolve: resolve}
+}
+
+func gqlListField(typ *gqlType, resolve gqlResolver) *gqlField {
+	return &gqlField{typ: typ, list: true, resolve: resolve}
+}
+
+// connectionArgs are the arguments every connection takes
+func connectionArgs(extra map[string]gqlArgDef) map[string]gqlArgDef {
+	args := map[string]gqlArgDef{
+		"first": {typ: "Int", defaultVal: defaultGraphQLPage},
+		"after": {typ: "String"},
+	}
+	for name, def := range extra {
+		args[name] = def
+	}
+	return args
+}
+
+func walletField(address func(parent interface{}) string) *gqlField {
+	return gqlObjectField(gqlTypes["Wallet"], func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+		if a := address(parent); a != "" {
+			return &gqlWallet{address: a}, nil
+		}
+		return nil, nil
+	})
+}
+
+func blockField(hash func(parent interface{}) string) *gqlField {
+	return gqlObjectField(gqlTypes["Block"], func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+		return ctx.blockByHash(hash(parent))
+	})
+}
+
+func tokenField(tokenID func(parent interface{}) string) *gqlField {
+	return gqlObjectField(gqlTypes["Token"], func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+		return ctx.token(tokenID(parent))
+	})
+}
+
+func newGQLType(name string) *gqlType {
+	typ := &gqlType{name: name, fields: map[string]*gqlField{}}
+	gqlTypes[name] = typ
+	return typ
+}
+
+// connectionType builds the connection type listing node
+func connectionType(node *gqlType) *gqlType {
+	connection := newGQLType(node.name + "Connection")
+	pageInfo := gqlTypes["PageInfo"]
+	connection.fields = map[string]*gqlField{
+		"nodes": gqlListField(node, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlConnection).nodes, nil
+		}),
+		"page_info": gqlObjectField(pageInfo, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent, nil
+		}),
+		"total_count": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlConnection).total, nil
+		}),
+	}
+	return connection
+}
+
+func init() {
+	pageInfo := newGQLType("PageInfo")
+	pageInfo.fields = map[string]*gqlField{
+		"end_cursor": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlConnection).endCursor, nil
+		}),
+		"has_next_page": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlConnection).hasNext, nil
+		}),
+	}
+
+	block := newGQLType("Block")
+	transaction := newGQLType("Transaction")
+	output := newGQLType("Output")
+	tokenOp := newGQLType("TokenOp")
+	wallet := newGQLType("Wallet")
+	addressLabel := newGQLType("AddressLabel")
+	walletTx := newGQLType("WalletTransaction")
+	tokenBalance := newGQLType("TokenBalance")
+	minedBlock := newGQLType("MinedBlock")
+	token := newGQLType("Token")
+	tokenHolder := newGQLType("TokenHolder")
+	tokenTx := newGQLType("TokenTransaction")
+	pool := newGQLType("LiquidityPool")
+	poolTx := newGQLType("PoolTransaction")
+	query := newGQLType("Query")
+
+	blockConn := connectionType(block)
+	walletConn := connectionType(wallet)
+	walletTxConn := connectionType(walletTx)
+	minedBlockConn := connectionType(minedBlock)
+	tokenConn := connectionType(token)
+	tokenHolderConn := connectionType(tokenHolder)
+	poolConn := connectionType(pool)
+
+	// Block
+	block.fields = map[string]*gqlField{
+		"hash": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlBlock).hash, nil
+		}),
+		"tx_count": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return len(parent.(*gqlBlock).block.Body.Transactions), nil
+		}),
+		"transactions": gqlListField(transaction, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return gqlPointers(ctx.transactions(parent.(*gqlBlock))), nil
+		}),
+		"farmer": walletField(func(parent interface{}) string {
+			return parent.(*gqlBlock).block.Header.FarmerAddress
+		}),
+		"parent": gqlObjectField(block, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			header := parent.(*gqlBlock).block.Header
+			if header.Height == 0 {
+				return nil, nil
+			}
+			return ctx.blockByHeight(header.Height - 1)
+		}),
+	}
+	for name, field := range gqlStructFields(BlockHeader{}) {
+		resolve := field.resolve
+		block.fields[name] = gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return resolve(ctx, &parent.(*gqlBlock).block.Header, args)
+		})
+	}
+
+	// Transaction
+	transaction.fields = map[string]*gqlField{
+		"hash": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).hash, nil
+		}),
+		"algorithm": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).signed.Algorithm, nil
+		}),
+		"signer_key": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).signed.SignerKey, nil
+		}),
+		"signature": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).signed.Signature, nil
+		}),
+		"coinbase": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).signed.Algorithm == "coinbase", nil
+		}),
+		"version": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).tx.Version, nil
+		}),
+		"timestamp": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).tx.Timestamp, nil
+		}),
+		"nonce": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).tx.Nonce, nil
+		}),
+		"inputs": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).tx.Inputs, nil
+		}),
+		"block_hash": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).block.hash, nil
+		}),
+		"block_height": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).block.block.Header.Height, nil
+		}),
+		"block": gqlObjectField(block, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlTransaction).block, nil
+		}),
+		"outputs": gqlListField(output, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return gqlList(parent.(*gqlTransaction).tx.Outputs), nil
+		}),
+		"token_ops": gqlListField(tokenOp, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return gqlList(parent.(*gqlTransaction).tx.TokenOps), nil
+		}),
+		// Every address the transaction pays or moves tokens between
+		"addresses": gqlListField(wallet, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			tx := parent.(*gqlTransaction).tx
+			seen := map[string]bool{"": true}
+			addresses := []interface{}{}
+			add := func(address string) {
+				if !seen[address] {
+					seen[address] = true
+					addresses = append(addresses, &gqlWallet{address: address})
+				}
+			}
+			for _, out := range tx.Outputs {
+				add(out.Address)
+			}
+			for _, op := range tx.TokenOps {
+				add(op.From)
+				add(op.To)
+			}
+			return addresses, nil
+		}),
+	}
+
+	output.fields = gqlStructFields(TransactionOutput{})
+	output.fields["wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*TransactionOutput).Address
+	})
+
+	tokenOp.fields = gqlStructFields(TokenOperation{})
+	tokenOp.fields["type"] = gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+		return parent.(*TokenOperation).Type.String(), nil
+	})
+	tokenOp.fields["token"] = tokenField(func(parent interface{}) string {
+		return parent.(*TokenOperation).TokenID
+	})
+	tokenOp.fields["from_wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*TokenOperation).From
+	})
+	tokenOp.fields["to_wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*TokenOperation).To
+	})
+
+	// Wallet
+	wallet.fields = map[string]*gqlField{
+		"address": gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			return parent.(*gqlWallet).address, nil
+		}),
+		"token_balances": gqlListField(tokenBalance, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			balances, err := ctx.store.GetWalletTokenBalances(parent.(*gqlWallet).address)
+			if err != nil {
+				return nil, err
+			}
+			return gqlList(balances), nil
+		}),
+		"transactions": {
+			typ:  walletTxConn,
+			args: connectionArgs(map[string]gqlArgDef{"type": {typ: "String"}}),
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				return walletTransactionConnection(ctx, parent.(*gqlWallet).address, args)
+			},
+		},
+		"mined_blocks": {
+			typ:  minedBlockConn,
+			args: connectionArgs(nil),
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				address := parent.(*gqlWallet).address
+				return offsetConnection(args, func(page, perPage int) ([]interface{}, int64, error) {
+					mined, err := ctx.store.GetMinedBlocks(address, page, perPage)
+					if err != nil {
+						return nil, 0, err
+					}
+					return gqlList(mined.Blocks), mined.TotalBlocks, nil
+				})
+			},
+		},
+	}
+	wallet.fields["label"] = gqlObjectField(addressLabel, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+		label, err := ctx.label(parent.(*gqlWallet).address)
+		if label == nil {
+			return nil, err
+		}
+		return label, nil
+	})
+	for name, field := range gqlStructFields(WalletSummary{}) {
+		if name == "address" || name == "transactions" || name == "token_balances" || name == "label" {
+			continue
+		}
+		resolve := field.resolve
+		wallet.fields[name] = gqlLeafField(func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			summary, err := ctx.walletSummary(parent.(*gqlWallet).address)
+			if err != nil {
+				return nil, err
+			}
+			return resolve(ctx, summary, args)
+		})
+	}
+
+	addressLabel.fields = gqlStructFields(AddressLabel{})
+
+	// Labels are reached through the wallets, not the REST fields
+	walletTx.fields = gqlStructFields(WalletTransaction{})
+	delete(walletTx.fields, "from_label")
+	delete(walletTx.fields, "to_label")
+	walletTx.fields["block"] = blockField(func(parent interface{}) string {
+		return parent.(*WalletTransaction).BlockHash
+	})
+	walletTx.fields["transaction"] = gqlObjectField(transaction, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+		return ctx.transaction(parent.(*WalletTransaction).TxHash)
+	})
+	walletTx.fields["from_wallet"] = walletField(func(parent interface{}) string {
+		if from := parent.(*WalletTransaction).FromAddress; from != "unknown" {
+			return from
+		}
+		return ""
+	})
+	walletTx.fields["to_wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*WalletTransaction).ToAddress
+	})
+
+	tokenBalance.fields = gqlStructFields(TokenBalance{})
+	tokenBalance.fields["token"] = tokenField(func(parent interface{}) string {
+		return parent.(*TokenBalance).TokenID
+	})
+
+	minedBlock.fields = gqlStructFields(MinedBlock{})
+	minedBlock.fields["block"] = blockField(func(parent interface{}) string {
+		return parent.(*MinedBlock).Hash
+	})
+
+	// Token
+	token.fields = gqlStructFields(TokenInfo{})
+	token.fields["creator_wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*TokenInfo).Creator
+	})
+	token.fields["holders"] = &gqlField{
+		typ:  tokenHolderConn,
+		args: connectionArgs(nil),
+		resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			tokenID := parent.(*TokenInfo).TokenID
+			return offsetConnection(args, func(page, perPage int) ([]interface{}, int64, error) {
+				holders, err := ctx.store.GetTokenHoldersPage(tokenID, page, perPage)
+				if err != nil {
+					return nil, 0, err
+				}
+				return gqlList(holders.Holders), int64(holders.TotalHolders), nil
+			})
+		},
+	}
+	token.fields["transactions"] = &gqlField{
+		typ:  tokenTx,
+		list: true,
+		args: map[string]gqlArgDef{"first": {typ: "Int", defaultVal: defaultGraphQLPage}},
+		resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			first, err := pageSize(args)
+			if err != nil {
+				return nil, err
+			}
+			txs, err := ctx.store.GetTokenTransactions(parent.(*TokenInfo).TokenID, first)
+			if err != nil {
+				return nil, err
+			}
+			return gqlList(txs), nil
+		},
+	}
+	token.fields["pools"] = gqlListField(pool, func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+		pools, err := ctx.store.GetPoolsByToken(parent.(*TokenInfo).TokenID, 1, maxGraphQLPage)
+		if err != nil {
+			return nil, err
+		}
+		return gqlList(pools.Pools), nil
+	})
+
+	tokenHolder.fields = gqlStructFields(TokenHolder{})
+	delete(tokenHolder.fields, "label")
+	tokenHolder.fields["wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*TokenHolder).Address
+	})
+
+	tokenTx.fields = gqlStructFields(TokenTransaction{})
+	delete(tokenTx.fields, "from_label")
+	delete(tokenTx.fields, "to_label")
+	tokenTx.fields["block"] = blockField(func(parent interface{}) string {
+		return parent.(*TokenTransaction).BlockHash
+	})
+	tokenTx.fields["from_wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*TokenTransaction).FromAddress
+	})
+	tokenTx.fields["to_wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*TokenTransaction).ToAddress
+	})
+
+	// Pool
+	pool.fields = gqlStructFields(LiquidityPool{})
+	pool.fields["token_a_info"] = tokenField(func(parent interface{}) string {
+		return parent.(*LiquidityPool).TokenA
+	})
+	pool.fields["token_b_info"] = tokenField(func(parent interface{}) string {
+		return parent.(*LiquidityPool).TokenB
+	})
+	pool.fields["creator_wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*LiquidityPool).Creator
+	})
+	pool.fields["transactions"] = &gqlField{
+		typ:  poolTx,
+		list: true,
+		args: map[string]gqlArgDef{"first": {typ: "Int", defaultVal: defaultGraphQLPage}},
+		resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+			first, err := pageSize(args)
+			if err != nil {
+				return nil, err
+			}
+			txs, err := ctx.store.GetPoolTransactions(parent.(*LiquidityPool).PoolID, first)
+			if err != nil {
+				return nil, err
+			}
+			return gqlList(txs), nil
+		},
+	}
+
+	poolTx.fields = gqlStructFields(PoolTransaction{})
+	delete(poolTx.fields, "label")
+	poolTx.fields["block"] = blockField(func(parent interface{}) string {
+		return parent.(*PoolTransaction).BlockHash
+	})
+	poolTx.fields["wallet"] = walletField(func(parent interface{}) string {
+		return parent.(*PoolTransaction).Address
+	})
+
+	// Query root
+	query.fields = map[string]*gqlField{
+		"block": {
+			typ:  block,
+			args: map[string]gqlArgDef{"height": {typ: "Int"}, "hash": {typ: "String"}},
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				switch {
+				case args.Has("hash"):
+					return ctx.blockByHash(args.String("hash"))
+				case args.Has("height") && args.Int("height") >= 0:
+					return ctx.blockByHeight(uint64(args.Int("height")))
+				case args.Has("height"):
+					return nil, fmt.Errorf("height must not be negative")
+				}
+				latest, err := ctx.store.GetLatestHeight()
+				if err != nil {
+					return nil, err
+				}
+				return ctx.blockByHeight(latest)
+			},
+		},
+		"blocks": {
+			typ:  blockConn,
+			args: connectionArgs(map[string]gqlArgDef{"from_height": {typ: "Int"}, "to_height": {typ: "Int"}}),
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				return blockConnection(ctx, args)
+			},
+		},
+		"transaction": {
+			typ:  transaction,
+			args: map[string]gqlArgDef{"hash": {typ: "String", required: true}},
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				return ctx.transaction(args.String("hash"))
+			},
+		},
+		"wallet": {
+			typ:  wallet,
+			args: map[string]gqlArgDef{"address": {typ: "String", required: true}},
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				return &gqlWallet{address: args.String("address")}, nil
+			},
+		},
+		"wallets": {
+			typ:  walletConn,
+			args: connectionArgs(nil),
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				first, err := pageSize(args)
+				if err != nil {
+					return nil, err
+				}
+				offset, err := offsetCursor(args)
+				if err != nil {
+					return nil, err
+				}
+				wallets, total, err := ctx.store.GetAllWallets(first, offset)
+				if err != nil {
+					return nil, err
+				}
+				nodes := make([]interface{}, len(wallets))
+				for i := range wallets {
+					// The overview is the summary the wallet's leaves need
+					ctx.wallets[wallets[i].Address] = &WalletSummary{
+						Address:          wallets[i].Address,
+						Balance:          wallets[i].Balance,
+						TransactionCount: wallets[i].TransactionCount,
+						BlocksMined:      wallets[i].BlocksMined,
+						FirstActivity:    wallets[i].FirstActivity,
+						LastActivity:     wallets[i].LastActivity,
+					}
+					nodes[i] = &gqlWallet{address: wallets[i].Address}
+				}
+				end := offset + len(wallets)
+				return &gqlConnection{
+					nodes:     nodes,
+					endCursor: encodeCursor([]byte("offset:" + strconv.Itoa(end))),
+					hasNext:   int64(end) < total,
+					total:     total,
+				}, nil
+			},
+		},
+		"token": {
+			typ:  token,
+			args: map[string]gqlArgDef{"id": {typ: "String", required: true}},
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				return ctx.token(args.String("id"))
+			},
+		},
+		"tokens": {
+			typ:  tokenConn,
+			args: connectionArgs(map[string]gqlArgDef{"search": {typ: "String"}}),
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				return offsetConnection(args, func(page, perPage int) ([]interface{}, int64, error) {
+					tokens, err := ctx.store.GetTokens(page, perPage, args.String("search"))
+					if err != nil {
+						return nil, 0, err
+					}
+					return gqlList(tokens.Tokens), tokens.TotalTokens, nil
+				})
+			},
+		},
+		"pool": {
+			typ:  pool,
+			args: map[string]gqlArgDef{"id": {typ: "String", required: true}},
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				return ctx.pool(args.String("id"))
+			},
+		},
+		"pools": {
+			typ:  poolConn,
+			args: connectionArgs(map[string]gqlArgDef{"search": {typ: "String"}, "token": {typ: "String"}}),
+			resolve: func(ctx *gqlContext, parent interface{}, args gqlArgs) (interface{}, error) {
+				return offsetConnection(args, func(page, perPage int) ([]interface{}, int64, error) {
+					var pools *PaginatedPools
+					var err error
+					if args.Has("token") {
+						pools, err = ctx.store.GetPoolsByToken(args.String("token"), page, perPage)
+					} else {
+						pools, err = ctx.store.GetPools(page, perPage, args.String("search"))
+					}
+					if err != nil {
+						return nil, 0, err
+					}
+					return gqlList(pools.Pools), pools.TotalPools, nil
+				})
+			},
+		},
+	}
+
+	gqlQueryType = query
+}
+
+func gqlPointers(txs []*gqlTransaction) []interface{} {
+	list := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		list[i] = tx
+	}
+	return list
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// graphQL posts query to the endpoint and decodes the response
+func graphQL(t *testing.T, es *ExplorerServer, query string, variables map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	rec := httptest.NewRecorder()
+	es.handleGraphQL(rec, httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body))))
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid response %q: %v", rec.Body, err)
+	}
+	return rec.Code, resp
+}
+
+// gqlPath walks a decoded response by keys and list indexes
+func gqlPath(value interface{}, path ...interface{}) interface{} {
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			object, _ := value.(map[string]interface{})
+			value = object[step]
+		case int:
+			list, _ := value.([]interface{})
+			if step >= len(list) {
+				return nil
+			}
+			value = list[step]
+		}
+	}
+	return value
+}
+
+func TestGraphQLNestedQuery(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTokenBlocks(t, NewSyncService("", newTestNodeClient(), database))
+	es := &ExplorerServer{database: database}
+
+	code, resp := graphQL(t, es, `{
+		block(height: 2) {
+			hash
+			transactions {
+				hash
+				token_ops { type amount token { ticker holder_count } }
+				addresses { address transaction_count }
+				block { height }
+			}
+			parent { height }
+		}
+	}`, nil)
+	if code != http.StatusOK || resp["errors"] != nil {
+		t.Fatalf("Query failed with %d: %v", code, resp["errors"])
+	}
+
+	block := gqlPath(resp, "data", "block")
+	if gqlPath(block, "hash") != "hash_2" || gqlPath(block, "parent", "height") != 1.0 {
+		t.Errorf("Unexpected block %v", block)
+	}
+	tx := gqlPath(block, "transactions", 0)
+	if gqlPath(tx, "hash") != "tx2" || gqlPath(tx, "block", "height") != 2.0 {
+		t.Errorf("Unexpected transaction %v", tx)
+	}
+	if op := gqlPath(tx, "token_ops", 0); gqlPath(op, "type") != "TRANSFER" || gqlPath(op, "token", "ticker") != "ALP" {
+		t.Errorf("Unexpected token operation %v", op)
+	}
+	addresses := gqlPath(tx, "addresses").([]interface{})
+	if len(addresses) != 3 || gqlPath(addresses, 0, "address") != "dave" || gqlPath(addresses, 0, "transaction_count") != 3.0 {
+		t.Errorf("Expected dave, alice and bob with dave's summary, got %v", addresses)
+	}
+
+	// Looked up directly, through the wallet index
+	_, resp = graphQL(t, es, `{ transaction(hash: "tx3") { block_height outputs { value wallet { address } } } }`, nil)
+	if tx := gqlPath(resp, "data", "transaction"); gqlPath(tx, "block_height") != 3.0 || gqlPath(tx, "outputs", 0, "wallet", "address") != "dave" {
+		t.Errorf("Unexpected transaction %v", tx)
+	}
+	_, resp = graphQL(t, es, `{ transaction(hash: "missing") { hash } block(hash: "missing") { hash } }`, nil)
+	if data := gqlPath(resp, "data").(map[string]interface{}); data["transaction"] != nil || data["block"] != nil || resp["errors"] != nil {
+		t.Errorf("Expected missing records to be null, got %v", resp)
+	}
+}
+
+func TestGraphQLVariablesAndFragments(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTokenBlocks(t, NewSyncService("", newTestNodeClient(), database))
+	es := &ExplorerServer{database: database}
+
+	query := `
+		query Holders($id: String!, $withTxs: Boolean = false) {
+			alpha: token(id: $id) {
+				__typename
+				...TokenFields
+				transactions(first: 5) @include(if: $withTxs) { type }
+				holders(first: 2) { nodes { ... on TokenHolder { address balance } } total_count }
+			}
+		}
+		fragment TokenFields on Token { name ticker }`
+
+	code, resp := graphQL(t, es, query, map[string]interface{}{"id": "0a0a0a0a0a0a0a0a"})
+	if code != http.StatusOK || resp["errors"] != nil {
+		t.Fatalf("Query failed with %d: %v", code, resp["errors"])
+	}
+	token := gqlPath(resp, "data", "alpha").(map[string]interface{})
+	if token["__typename"] != "Token" || token["ticker"] != "ALP" || token["name"] != "Alpha" {
+		t.Errorf("Unexpected token %v", token)
+	}
+	if _, ok := token["transactions"]; ok {
+		t.Error("Expected transactions skipped by @include")
+	}
+	if gqlPath(token, "holders", "total_count") != 3.0 || gqlPath(token, "holders", "nodes", 0, "address") != "alice" {
+		t.Errorf("Unexpected holders %v", token["holders"])
+	}
+
+	_, resp = graphQL(t, es, query, map[string]interface{}{"id": "0a0a0a0a0a0a0a0a", "withTxs": true})
+	if txs, _ := gqlPath(resp, "data", "alpha", "transactions").([]interface{}); len(txs) != 3 {
+		t.Errorf("Expected 3 token transactions, got %v", txs)
+	}
+
+	// Fields keep the order they were selected in
+	rec := httptest.NewRecorder()
+	es.handleGraphQL(rec, httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ token(id: "0a0a0a0a0a0a0a0a") { ticker name } }`), nil))
+	if want := `{"data":{"token":{"ticker":"ALP","name":"Alpha"}}}`; strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("Expected %s, got %s", want, rec.Body)
+	}
+}
+
+func TestGraphQLPagination(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTokenBlocks(t, NewSyncService("", newTestNodeClient(), database))
+	es := &ExplorerServer{database: database}
+
+	var heights []interface{}
+	after := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Block pagination did not terminate")
+		}
+		_, resp := graphQL(t, es, `query($after: String) {
+			blocks(first: 2, after: $after) { nodes { height } page_info { end_cursor has_next_page } }
+		}`, map[string]interface{}{"after": after})
+		for _, node := range gqlPath(resp, "data", "blocks", "nodes").([]interface{}) {
+			heights = append(heights, gqlPath(node, "height"))
+		}
+		if gqlPath(resp, "data", "blocks", "page_info", "has_next_page") != true {
+			break
+		}
+		after = gqlPath(resp, "data", "blocks", "page_info", "end_cursor").(string)
+	}
+	if len(heights) != 3 || heights[0] != 3.0 || heights[2] != 1.0 {
+		t.Errorf("Expected blocks 3 to 1 newest first, got %v", heights)
+	}
+
+	// dave appears in each block; page through the transfers only
+	var hashes []interface{}
+	after = ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Wallet transaction pagination did not terminate")
+		}
+		_, resp := graphQL(t, es, `query($after: String) {
+			wallet(address: "dave") { transactions(first: 1, after: $after, type: "token_TRANSFER") {
+				nodes { tx_hash block { height } } page_info { end_cursor has_next_page }
+			} }
+		}`, map[string]interface{}{"after": after})
+		connection := gqlPath(resp, "data", "wallet", "transactions")
+		for _, node := range gqlPath(connection, "nodes").([]interface{}) {
+			hashes = append(hashes, gqlPath(node, "tx_hash"))
+		}
+		if gqlPath(connection, "page_info", "has_next_page") != true {
+			break
+		}
+		after = gqlPath(connection, "page_info", "end_cursor").(string)
+	}
+	if len(hashes) != 2 || hashes[0] != "tx3" || hashes[1] != "tx2" {
+		t.Errorf("Expected tx3 and tx2, got %v", hashes)
+	}
+
+	// An offset window that does not line up with the store's pages
+	_, resp := graphQL(t, es, `{ token(id: "0a0a0a0a0a0a0a0a") { holders(first: 2, after: "`+encodeCursor([]byte("offset:1"))+`") {
+		nodes { address } page_info { has_next_page } } } }`, nil)
+	holders := gqlPath(resp, "data", "token", "holders")
+	if gqlPath(holders, "nodes", 0, "address") != "bob" || gqlPath(holders, "nodes", 1, "address") != "carol" || gqlPath(holders, "page_info", "has_next_page") != false {
+		t.Errorf("Unexpected holders page %v", holders)
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTokenBlocks(t, NewSyncService("", newTestNodeClient(), database))
+	es := &ExplorerServer{database: database}
+
+	deep := "{ block(height: 3) { " + strings.Repeat("parent { ", 10) + "height" + strings.Repeat(" }", 10) + " } }"
+	for name, query := range map[string]string{
+		"syntax":           `{ block(height: 1) { hash }`,
+		"unknown field":    `{ block(height: 1) { size } }`,
+		"unknown argument": `{ block(number: 1) { hash } }`,
+		"leaf selection":   `{ block(height: 1) { hash { id } } }`,
+		"object no fields": `{ block(height: 1) }`,
+		"wrong type":       `{ block(height: "one") { hash } }`,
+		"missing argument": `{ wallet { address } }`,
+		"missing variable": `query($address: String!) { wallet(address: $address) { address } }`,
+		"unknown fragment": `{ block(height: 1) { ...Missing } }`,
+		"mutation":         `mutation { block(height: 1) { hash } }`,
+		"too deep":         deep,
+	} {
+		code, resp := graphQL(t, es, query, nil)
+		if code != http.StatusBadRequest || resp["errors"] == nil || resp["data"] != nil {
+			t.Errorf("%s: expected a 400 with errors, got %d %v", name, code, resp)
+		}
+	}
+
+	// A field that fails is null and reported with its path
+	code, resp := graphQL(t, es, `{ block(height: 1) { hash } tokens(first: 500) { total_count } }`, nil)
+	if code != http.StatusOK || gqlPath(resp, "data", "block", "hash") != "hash_1" || gqlPath(resp, "data", "tokens") != nil {
+		t.Fatalf("Expected the rest of the query resolved, got %d %v", code, resp)
+	}
+	if path := gqlPath(resp, "errors", 0, "path"); gqlPath(path, 0) != "tokens" {
+		t.Errorf("Expected the error at tokens, got %v", resp["errors"])
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{ block(height: 2) { height } }`))
+	req.Header.Set("Content-Type", "application/graphql")
+	es.handleGraphQL(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"height":2`) {
+		t.Errorf("Expected an application/graphql body accepted, got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DBHealthStatus describes the outcome of the latest database self-check
+type DBHealthStatus struct {
+	Healthy   bool      `json:"healthy"`
+	Message   string    `json:"message"`
+	LastCheck time.Time `json:"last_check"`
+}
+
+// DBHealthMonitor periodically verifies that the explorer database accepts
+// writes and retries opening it when it does not
+type DBHealthMonitor struct {
+	database Store
+	interval time.Duration
+
+	mu     sync.RWMutex
+	status DBHealthStatus
+
+	stopCh chan struct{}
+}
+
+// NewDBHealthMonitor creates a monitor that checks the database every interval
+func NewDBHealthMonitor(database Store, interval time.Duration) *DBHealthMonitor {
+	return &DBHealthMonitor{
+		database: database,
+		interval: interval,
+		status: DBHealthStatus{
+			Healthy: true,
+			Message: "not checked yet",
+		},
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start runs an initial check and then keeps checking in the background
+func (m *DBHealthMonitor) Start() {
+	m.Check()
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Check()
+			case <-m.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background checks
+func (m *DBHealthMonitor) Stop() {
+	close(m.stopCh)
+}
+
+// Check probes the database once, attempting a reopen if it is not writable
+func (m *DBHealthMonitor) Check() DBHealthStatus {
+	err := m.database.Probe()
+	if err != nil {
+		slog.Warn("Database health check failed", "err", err)
+		if reopenErr := m.database.Reopen(); reopenErr != nil {
+			slog.Error("Database reopen failed", "err", reopenErr)
+		} else {
+			err = m.database.Probe()
+		}
+	}
+
+	status := DBHealthStatus{
+		Healthy:   err == nil,
+		Message:   "database is writable",
+		LastCheck: time.Now().UTC(),
+	}
+	if err != nil {
+		status.Message = err.Error()
+	}
+
+	m.mu.Lock()
+	if m.status.Healthy != status.Healthy {
+		if status.Healthy {
+			slog.Info("Database healthy again")
+		} else {
+			slog.Warn("Database marked degraded", "reason", status.Message)
+		}
+	}
+	m.status = status
+	m.mu.Unlock()
+
+	return status
+}
+
+// Status returns the result of the most recent check
+func (m *DBHealthMonitor) Status() DBHealthStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// defaultMaxSyncLag is how many blocks the index may trail the node and
+// still be ready, unless EXPLORER_READY_MAX_LAG says otherwise
+const defaultMaxSyncLag = 50
+
+// probeTimeout bounds the node check of a readiness probe, so a hung node
+// fails the probe rather than outlasting it
+const probeTimeout = 3 * time.Second
+
+// maxSyncLagFromEnv reads EXPLORER_READY_MAX_LAG, falling back to the
+// default when unset or invalid
+func maxSyncLagFromEnv() uint64 {
+	value := os.Getenv("EXPLORER_READY_MAX_LAG")
+	if value == "" {
+		return defaultMaxSyncLag
+	}
+
+	lag, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		slog.Warn("Ignoring invalid EXPLORER_READY_MAX_LAG", "value", value, "default", defaultMaxSyncLag)
+		return defaultMaxSyncLag
+	}
+	return lag
+}
+
+// DependencyCheck is the outcome of checking one dependency for a probe
+type DependencyCheck struct {
+	Healthy   bool   `json:"healthy"`
+	Message   string `json:"message"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// ProbeResult is the body of the liveness and readiness probes
+type ProbeResult struct {
+	Status    string                     `json:"status"` // "ok" or "unavailable"
+	Checks    map[string]DependencyCheck `json:"checks"`
+	Timestamp time.Time                  `json:"timestamp"`
+}
+
+// checkDatabase writes and reads back a probe key
+func (es *ExplorerServer) checkDatabase() DependencyCheck {
+	start := time.Now()
+	err := es.database.Probe()
+	check := DependencyCheck{Healthy: err == nil, Message: "database is writable", LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Message = err.Error()
+	}
+	return check
+}
+
+// checkNodeAndLag asks the node being synced from for its height, then
+// compares the indexed height against it
+func (es *ExplorerServer) checkNodeAndLag() (DependencyCheck, DependencyCheck) {
+	if es.syncService == nil {
+		skipped := DependencyCheck{Healthy: true, Message: "sync is disabled"}
+		return skipped, skipped
+	}
+
+	nodeURL := es.syncService.nodes.Current()
+	start := time.Now()
+	stats, err := es.syncService.client.WithTimeout(probeTimeout).Status(nodeURL)
+	node := DependencyCheck{Healthy: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		node.Message = err.Error()
+		return node, DependencyCheck{Message: "node height unknown"}
+	}
+	node.Message = fmt.Sprintf("%s at height %d", nodeURL, stats.TipHeight)
+
+	indexed, err := es.database.GetLatestHeight()
+	if err != nil {
+		indexed = 0 // Nothing indexed yet
+	}
+	var lag uint64
+	if stats.TipHeight > indexed {
+		lag = stats.TipHeight - indexed
+	}
+	maxLag := es.maxSyncLag
+	if maxLag == 0 {
+		maxLag = defaultMaxSyncLag
+	}
+	return node, DependencyCheck{
+		Healthy: lag <= maxLag,
+		Message: fmt.Sprintf("indexed height %d is %d blocks behind the node (limit %d)", indexed, lag, maxLag),
+	}
+}
+
+// writeProbe answers a probe with 200 when every check passed and 503 otherwise
+func writeProbe(w http.ResponseWriter, checks map[string]DependencyCheck) {
+	result := ProbeResult{Status: "ok", Checks: checks, Timestamp: time.Now().UTC()}
+	httpStatus := http.StatusOK
+	for _, check := range checks {
+		if !check.Healthy {
+			result.Status = "unavailable"
+			httpStatus = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(result)
+}
+
+// Liveness probe: the explorer can write its own database. The database
+// monitor keeps trying to reopen a locked store, so failing here means a
+// restart is the remaining remedy.
+func (es *ExplorerServer) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, map[string]DependencyCheck{"database": es.checkDatabase()})
+}
+
+// Readiness probe: the database is writable, the node answers, and the
+// index is within EXPLORER_READY_MAX_LAG blocks of it, so pages are current
+func (es *ExplorerServer) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	node, syncLag := es.checkNodeAndLag()
+	writeProbe(w, map[string]DependencyCheck{
+		"database": es.checkDatabase(),
+		"node":     node,
+		"sync":     syncLag,
+	})
+}
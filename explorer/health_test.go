@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthyDatabasePassesProbe(t *testing.T) {
+	database, err := NewDatabase(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	status := NewDBHealthMonitor(database, time.Minute).Check()
+	if !status.Healthy {
+		t.Errorf("Writable database should be healthy, got: %s", status.Message)
+	}
+}
+
+func TestLockedDatabaseStartsDegraded(t *testing.T) {
+	dir := t.TempDir()
+
+	// A second writer, like another explorer on the same directory
+	writer, err := NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to open the other writer: %v", err)
+	}
+	storeTestBlocks(t, writer, 0, 2)
+	defer writer.Close()
+
+	database := openDatabase(dir)
+	defer database.Close()
+	if _, err := database.GetLatestHeight(); err != errDatabaseUnavailable {
+		t.Fatalf("Expected errDatabaseUnavailable while the writer holds the lock, got %v", err)
+	}
+
+	es := &ExplorerServer{
+		database: database,
+		dbHealth: NewDBHealthMonitor(database, 10*time.Millisecond),
+	}
+	status := es.dbHealth.Check()
+	if status.Healthy || !strings.Contains(status.Message, "not open") {
+		t.Fatalf("Expected a clear unhealthy status, got %+v", status)
+	}
+
+	rec := httptest.NewRecorder()
+	es.handleHealth(rec, httptest.NewRequest("GET", "/api/v1/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 from health endpoint, got %d", rec.Code)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode health response: %v", err)
+	}
+	if body["status"] != "degraded" {
+		t.Errorf("Expected status degraded, got %v", body["status"])
+	}
+
+	// The background retry opens the database once the writer is gone
+	es.dbHealth.Start()
+	defer es.dbHealth.Stop()
+	time.Sleep(50 * time.Millisecond)
+	if es.dbHealth.Status().Healthy {
+		t.Fatal("Database should stay degraded while the writer holds it")
+	}
+	writer.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for !es.dbHealth.Status().Healthy && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := es.dbHealth.Status(); !status.Healthy {
+		t.Fatalf("Database should open after the writer closed, got: %s", status.Message)
+	}
+	if height, err := database.GetLatestHeight(); err != nil || height != 2 {
+		t.Errorf("Expected the other writer's blocks, got %d, %v", height, err)
+	}
+}
+
+func TestUnopenedDatabaseIsUnavailable(t *testing.T) {
+	database := &Database{path: t.TempDir()}
+	if _, err := database.GetLatestHeight(); err != errDatabaseUnavailable {
+		t.Errorf("Expected errDatabaseUnavailable, got %v", err)
+	}
+
+	// The monitor's retry should be able to open it
+	status := NewDBHealthMonitor(database, time.Minute).Check()
+	if !status.Healthy {
+		t.Errorf("Expected database to open on retry, got: %s", status.Message)
+	}
+	database.Close()
+}
+
+func TestLivenessChecksDatabase(t *testing.T) {
+	es := &ExplorerServer{database: newTestDatabase(t)}
+	rec := httptest.NewRecorder()
+	es.handleLiveness(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	var result ProbeResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode probe: %v", err)
+	}
+	if rec.Code != http.StatusOK || result.Status != "ok" || !result.Checks["database"].Healthy {
+		t.Errorf("Expected a live explorer, got %d %+v", rec.Code, result)
+	}
+}
+
+func TestReadinessChecksNodeAndLag(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 0, 4)
+
+	probe := func(nodeURL string, maxLag uint64) (int, ProbeResult) {
+		es := &ExplorerServer{database: database, syncService: NewSyncService(nodeURL, newTestNodeClient(), database), maxSyncLag: maxLag}
+		rec := httptest.NewRecorder()
+		es.handleReadiness(rec, httptest.NewRequest("GET", "/readyz", nil))
+		var result ProbeResult
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode probe: %v", err)
+		}
+		return rec.Code, result
+	}
+
+	near, _ := newFakeNode(t, 6)
+	if code, result := probe(near.URL, 2); code != http.StatusOK || result.Status != "ok" {
+		t.Errorf("Expected ready two blocks behind, got %d %+v", code, result)
+	}
+
+	far, _ := newFakeNode(t, 100)
+	code, result := probe(far.URL, 10)
+	if code != http.StatusServiceUnavailable || !result.Checks["node"].Healthy || result.Checks["sync"].Healthy {
+		t.Errorf("Expected not ready 96 blocks behind, got %d %+v", code, result)
+	}
+	if !strings.Contains(result.Checks["sync"].Message, "96 blocks behind") {
+		t.Errorf("Expected the lag reported, got %q", result.Checks["sync"].Message)
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	code, result = probe(down.URL, 10)
+	if code != http.StatusServiceUnavailable || result.Checks["node"].Healthy || !result.Checks["database"].Healthy {
+		t.Errorf("Expected not ready with the node down, got %d %+v", code, result)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// httpCache buffers GET responses so it can tag them with an ETag, answer
+// conditional requests with 304 and gzip what it sends. Streams (SSE,
+// anything that flushes) and responses over maxCachedBody pass through
+// untouched.
+const (
+	maxCachedBody = 8 << 20
+	minGzipBody   = 1024
+
+	// Cache-Control for a block buried deep enough to be final
+	immutableCacheControl = "public, max-age=31536000, immutable"
+)
+
+func (es *ExplorerServer) httpCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &cachingWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+		cw.finish(r)
+	})
+}
+
+// cachingWriter holds a response until the handler returns, unless it
+// turns out to be one that must be streamed
+type cachingWriter struct {
+	http.ResponseWriter
+	status      int
+	buf         bytes.Buffer
+	passthrough bool
+}
+
+func (cw *cachingWriter) WriteHeader(status int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	contentType := cw.Header().Get("Content-Type")
+	if status != http.StatusOK || strings.HasPrefix(contentType, "text/event-stream") {
+		cw.startPassthrough()
+	}
+}
+
+func (cw *cachingWriter) Write(data []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.passthrough {
+		return cw.ResponseWriter.Write(data)
+	}
+	if cw.buf.Len()+len(data) > maxCachedBody {
+		cw.startPassthrough()
+		return cw.ResponseWriter.Write(data)
+	}
+	return cw.buf.Write(data)
+}
+
+// Flush means the handler is streaming, so whatever is buffered goes out
+// and the rest of the response is written directly
+func (cw *cachingWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	cw.startPassthrough()
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (cw *cachingWriter) startPassthrough() {
+	if cw.passthrough {
+		return
+	}
+	cw.passthrough = true
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if cw.buf.Len() > 0 {
+		cw.ResponseWriter.Write(cw.buf.Bytes())
+		cw.buf.Reset()
+	}
+}
+
+// finish sends a buffered response: 304 when the client's copy is
+// current, otherwise the body, compressed when the client accepts it
+func (cw *cachingWriter) finish(r *http.Request) {
+	if cw.passthrough {
+		return
+	}
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	header := cw.Header()
+	body := cw.buf.Bytes()
+
+	// Handlers that know their version cheaply set the ETag themselves
+	if header.Get("ETag") == "" {
+		sum := sha256.Sum256(body)
+		header.Set("ETag", `W/"`+hex.EncodeToString(sum[:16])+`"`)
+	}
+	header.Add("Vary", "Accept-Encoding")
+
+	if notModified(r, header) {
+		writeNotModified(cw.ResponseWriter)
+		return
+	}
+
+	if len(body) >= minGzipBody && isCompressible(header.Get("Content-Type")) && acceptsGzip(r) {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write(body)
+		gz.Close()
+		body = compressed.Bytes()
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if r.Method != http.MethodHead {
+		cw.ResponseWriter.Write(body)
+	}
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// none, against the response headers
+func notModified(r *http.Request, header http.Header) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		return etagMatches(match, header.Get("ETag"))
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	return err == nil && !modified.After(since)
+}
+
+// writeNotModified sends a 304, keeping only the headers it may carry
+func writeNotModified(w http.ResponseWriter) {
+	header := w.Header()
+	for _, name := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+		header.Del(name)
+	}
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// etagMatches compares an If-None-Match list with etag, weakly
+func etagMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isCompressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "text/")
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) == "gzip" && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNotModified lets a handler answer a conditional request before
+// doing the expensive part. It sets etag on the response and reports
+// whether the client's copy is current, in which case a 304 has been sent.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		writeNotModified(w)
+		return true
+	}
+	return false
+}
+
+// versionETag builds a weak ETag from the values a response depends on
+func versionETag(parts ...interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintln(parts...)))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// setBlockCaching marks a block response cacheable for good once the block
+// is final; until then clients revalidate, since a reorg can replace it
+func (es *ExplorerServer) setBlockCaching(w http.ResponseWriter, block *Block) {
+	w.Header().Set("Last-Modified", block.Header.Timestamp.UTC().Format(http.TimeFormat))
+
+	depth := es.finalityDepth
+	if depth == 0 {
+		depth = defaultFinalityDepth
+	}
+	tip, err := es.database.GetLatestHeight()
+	if err == nil && tip >= block.Header.Height && tip-block.Header.Height+1 >= depth {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestBlocksListConditional(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 1, 30)
+	es := &ExplorerServer{database: database}
+	handler := es.httpCache(http.HandlerFunc(es.handleBlocks))
+
+	get := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/blocks?per_page=20", nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get(http.Header{"Accept-Encoding": {"gzip, deflate"}})
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a gzipped 200 with an ETag, got %d %v", first.Code, first.Header())
+	}
+	gz, err := gzip.NewReader(first.Body)
+	if err != nil {
+		t.Fatalf("Invalid gzip body: %v", err)
+	}
+	body, _ := io.ReadAll(gz)
+	var blocks PaginatedBlocks
+	if err := json.Unmarshal(body, &blocks); err != nil || len(blocks.Blocks) != 20 {
+		t.Fatalf("Expected 20 blocks in the decompressed body, got %d (%v)", len(blocks.Blocks), err)
+	}
+
+	if rec := get(http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("Expected 304 for a current ETag, got %d", rec.Code)
+	}
+	if rec := get(nil); rec.Header().Get("Content-Encoding") != "" {
+		t.Error("Expected no compression without Accept-Encoding")
+	}
+
+	// A new tip changes the list
+	storeTestBlocks(t, database, 31, 31)
+	if rec := get(http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("Expected a fresh list after a new block, got %d", rec.Code)
+	}
+}
+
+func TestBlockDetailsCaching(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 1, 5)
+	es := &ExplorerServer{database: database, finalityDepth: 3}
+	handler := es.httpCache(http.HandlerFunc(es.handleBlockDetails))
+
+	get := func(height int, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/block/hash_%d", height), nil)
+		req = mux.SetURLVars(req, map[string]string{"hash": fmt.Sprintf("hash_%d", height)})
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get(2, "", ""); rec.Header().Get("Cache-Control") != immutableCacheControl || rec.Header().Get("ETag") == "" {
+		t.Errorf("Expected a final block cached for good, got %v", rec.Header())
+	}
+	if rec := get(4, "", ""); rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("Expected a recent block revalidated, got %v", rec.Header())
+	}
+
+	since := time.Unix(10, 0).UTC().Format(http.TimeFormat)
+	if rec := get(2, "If-Modified-Since", since); rec.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for a block older than If-Modified-Since, got %d", rec.Code)
+	}
+	if rec := get(2, "If-None-Match", `"stale"`); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a stale ETag, got %d", rec.Code)
+	}
+	if rec := get(9, "If-None-Match", "*"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing block to stay 404, got %d", rec.Code)
+	}
+}
+
+func TestHTTPCacheStreamsPassThrough(t *testing.T) {
+	es := &ExplorerServer{}
+	handler := es.httpCache(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: "+strings.Repeat("x", 2000)+"\n\n")
+		w.(http.Flusher).Flush()
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/pool/p/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !rec.Flushed || rec.Header().Get("ETag") != "" || rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected the stream passed through untouched, got %v", rec.Header())
+	}
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// Job records are kept at job:<id>. IDs start with the hex creation time,
+// so they sort oldest first. Records survive resets.
+const (
+	jobPrefix = "job:"
+
+	defaultJobWorkers   = 2
+	jobQueueSize        = 32
+	maxJobRecords       = 200 // Finished records beyond this are dropped, oldest first
+	jobProgressInterval = time.Second
+
+	defaultJobsLimit = 50
+)
+
+// Job statuses
+const (
+	jobQueued      = "queued"
+	jobRunning     = "running"
+	jobSucceeded   = "succeeded"
+	jobFailed      = "failed"
+	jobCancelled   = "cancelled"
+	jobInterrupted = "interrupted" // Queued or running when the explorer stopped
+)
+
+var (
+	// errJobRunning is returned when a job is submitted while another of
+	// the same kind is queued or running
+	errJobRunning = errors.New("a job of this kind is already queued or running")
+
+	// errJobQueueFull is returned when every queue slot is taken
+	errJobQueueFull = errors.New("job queue is full")
+
+	// errJobFinished is returned when cancelling a job that already ended
+	errJobFinished = errors.New("job has already finished")
+)
+
+// JobProgress counts the units of work a job has done, out of total when
+// the total is known
+type JobProgress struct {
+	Done  uint64 `json:"done"`
+	Total uint64 `json:"total,omitempty"`
+}
+
+// Job is the record of a background operation
+type Job struct {
+	ID         string            `json:"id"`
+	Kind       string            `json:"kind"` // "reset", "reindex", "prune" or "nft-metadata"
+	Params     map[string]string `json:"params,omitempty"`
+	Status     string            `json:"status"`
+	Progress   JobProgress       `json:"progress"`
+	Result     json.RawMessage   `json:"result,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	StartedAt  time.Time         `json:"started_at,omitempty"`
+	FinishedAt time.Time         `json:"finished_at,omitempty"`
+}
+
+// finished reports whether the job has ended one way or another
+func (j *Job) finished() bool {
+	return j.Status != jobQueued && j.Status != jobRunning
+}
+
+// JobFunc does a job's work, reporting progress as it goes. It is called
+// exactly once, with a context already cancelled if the job was cancelled
+// while queued, and should stop at its next checkpoint once ctx is done.
+// The result is stored with the job as JSON.
+type JobFunc func(ctx context.Context, progress func(done, total uint64)) (interface{}, error)
+
+// activeJob is a queued or running job and what is needed to run and
+// cancel it
+type activeJob struct {
+	job    Job // Guarded by JobQueue.mu
+	run    JobFunc
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	saved time.Time // When progress was last stored
+}
+
+// JobQueue runs long operations on a small pool of workers, one job of
+// each kind at a time, and keeps a record of each in the store
+type JobQueue struct {
+	store   Store
+	workers int
+	queue   chan *activeJob
+
+	mu     sync.Mutex
+	active map[string]*activeJob // By job ID
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewJobQueue creates a queue run by the given number of workers
+func NewJobQueue(store Store, workers int) *JobQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &JobQueue{
+		store:   store,
+		workers: workers,
+		queue:   make(chan *activeJob, jobQueueSize),
+		active:  make(map[string]*activeJob),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// newJobQueueFromEnv creates a queue with EXPLORER_JOB_WORKERS workers
+func newJobQueueFromEnv(store Store) *JobQueue {
+	workers := defaultJobWorkers
+	if value := os.Getenv("EXPLORER_JOB_WORKERS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			slog.Warn("Ignoring invalid EXPLORER_JOB_WORKERS", "value", value, "default", defaultJobWorkers)
+		} else {
+			workers = n
+		}
+	}
+	return NewJobQueue(store, workers)
+}
+
+// Start marks the jobs a previous run left unfinished as interrupted and
+// starts the workers
+func (q *JobQueue) Start() {
+	jobs, err := q.store.ListJobs(maxJobRecords)
+	if err != nil {
+		slog.Error("Failed to load job records", "err", err)
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.finished() {
+			continue
+		}
+		job.Status = jobInterrupted
+		job.FinishedAt = time.Now().UTC()
+		if err := q.store.StoreJob(job); err != nil {
+			slog.Error("Failed to mark job interrupted", "job_id", job.ID, "err", err)
+		}
+	}
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Stop cancels the queued and running jobs and waits for the workers to
+// finish with them
+func (q *JobQueue) Stop() {
+	q.mu.Lock()
+	for _, active := range q.active {
+		active.cancel()
+	}
+	q.mu.Unlock()
+
+	close(q.stopCh)
+	q.wg.Wait()
+}
+
+// work runs queued jobs until the queue stops
+func (q *JobQueue) work() {
+	defer q.wg.Done()
+	for {
+		select {
+		case active := <-q.queue:
+			q.execute(active)
+		case <-q.stopCh:
+			// Queued jobs that never started are left for Start to mark
+			// interrupted next time
+			return
+		}
+	}
+}
+
+// Submit queues a job of kind. Only one job of each kind is queued or
+// running at a time.
+func (q *JobQueue) Submit(kind string, params map[string]string, run JobFunc) (*Job, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	active := &activeJob{
+		job: Job{
+			ID:        fmt.Sprintf("%016x%s", time.Now().UnixNano(), randomHex(4)),
+			Kind:      kind,
+			Params:    params,
+			Status:    jobQueued,
+			CreatedAt: time.Now().UTC(),
+		},
+		run:    run,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, other := range q.active {
+		if other.job.Kind == kind {
+			cancel()
+			return nil, errJobRunning
+		}
+	}
+	if err := q.store.StoreJob(&active.job); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to store job: %w", err)
+	}
+	select {
+	case q.queue <- active:
+	default:
+		cancel()
+		active.job.Status = jobFailed
+		active.job.Error = errJobQueueFull.Error()
+		q.store.StoreJob(&active.job)
+		return nil, errJobQueueFull
+	}
+	q.active[active.job.ID] = active
+	job := active.job
+	return &job, nil
+}
+
+// execute runs one job and records how it ended
+func (q *JobQueue) execute(active *activeJob) {
+	q.mu.Lock()
+	active.job.Status = jobRunning
+	active.job.StartedAt = time.Now().UTC()
+	job := active.job
+	q.mu.Unlock()
+	q.save(&job)
+	slog.Info("Job started", "job_id", job.ID, "kind", job.Kind)
+
+	result, err := q.call(active)
+
+	q.mu.Lock()
+	delete(q.active, active.job.ID)
+	active.job.FinishedAt = time.Now().UTC()
+	switch {
+	case err != nil && active.ctx.Err() != nil:
+		active.job.Status = jobCancelled
+		active.job.Error = err.Error()
+	case err != nil:
+		active.job.Status = jobFailed
+		active.job.Error = err.Error()
+	default:
+		active.job.Status = jobSucceeded
+		if result != nil {
+			data, marshalErr := json.Marshal(result)
+			if marshalErr != nil {
+				active.job.Error = fmt.Sprintf("failed to marshal result: %v", marshalErr)
+			} else {
+				active.job.Result = data
+			}
+		}
+	}
+	job = active.job
+	q.mu.Unlock()
+	active.cancel()
+	q.save(&job)
+
+	if job.Status == jobSucceeded {
+		slog.Info("Job succeeded", "job_id", job.ID, "kind", job.Kind)
+	} else {
+		slog.Error("Job did not succeed", "job_id", job.ID, "kind", job.Kind, "status", job.Status, "err", job.Error)
+	}
+	if err := q.store.PruneJobs(maxJobRecords); err != nil {
+		slog.Error("Failed to drop old job records", "err", err)
+	}
+}
+
+// call runs a job's function, turning a panic into a failure so one bad
+// job cannot take the explorer down
+func (q *JobQueue) call(active *activeJob) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return active.run(active.ctx, func(done, total uint64) {
+		q.progress(active, done, total)
+	})
+}
+
+// progress updates a running job's counts, storing them at most once per
+// jobProgressInterval
+func (q *JobQueue) progress(active *activeJob, done, total uint64) {
+	q.mu.Lock()
+	active.job.Progress = JobProgress{Done: done, Total: total}
+	if time.Since(active.saved) < jobProgressInterval {
+		q.mu.Unlock()
+		return
+	}
+	active.saved = time.Now()
+	job := active.job
+	q.mu.Unlock()
+	q.save(&job)
+}
+
+// save stores a job record, logging failures; the job carries on either way
+func (q *JobQueue) save(job *Job) {
+	if err := q.store.StoreJob(job); err != nil {
+		slog.Error("Failed to store job", "job_id", job.ID, "err", err)
+	}
+}
+
+// Cancel asks a queued or running job to stop. A queued job stops before
+// it starts; a running one at its next checkpoint, so it may still finish.
+func (q *JobQueue) Cancel(id string) (*Job, error) {
+	q.mu.Lock()
+	active, ok := q.active[id]
+	if ok {
+		active.cancel()
+		job := active.job
+		q.mu.Unlock()
+		slog.Info("Cancelling job", "job_id", job.ID, "kind", job.Kind)
+		return &job, nil
+	}
+	q.mu.Unlock()
+
+	if _, err := q.store.GetJob(id); err != nil {
+		return nil, err
+	}
+	return nil, errJobFinished
+}
+
+// Get returns a job, with the latest progress if it is running
+func (q *JobQueue) Get(id string) (*Job, error) {
+	q.mu.Lock()
+	if active, ok := q.active[id]; ok {
+		job := active.job
+		q.mu.Unlock()
+		return &job, nil
+	}
+	q.mu.Unlock()
+	return q.store.GetJob(id)
+}
+
+// List returns the latest limit jobs, newest first
+func (q *JobQueue) List(limit int) ([]Job, error) {
+	jobs, err := q.store.ListJobs(limit)
+	if err != nil {
+		return nil, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range jobs {
+		if active, ok := q.active[jobs[i].ID]; ok {
+			jobs[i] = active.job
+		}
+	}
+	return jobs, nil
+}
+
+// StoreJob saves a job record, replacing any with the same ID
+func (d *Database) StoreJob(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(jobPrefix+job.ID), data)
+	})
+}
+
+// GetJob returns a job record, or errNotFound
+func (d *Database) GetJob(id string) (*Job, error) {
+	var job Job
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(jobPrefix + id))
+		if err == badger.ErrKeyNotFound {
+			return errNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &job)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs returns the latest limit job records, newest first
+func (d *Database) ListJobs(limit int) ([]Job, error) {
+	jobs := []Job{}
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(jobPrefix)
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(jobPrefix + "\xff")); it.Valid() && len(jobs) < limit; it.Next() {
+			var job Job
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &job)
+			}); err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+// PruneJobs drops the finished job records beyond the latest keep
+func (d *Database) PruneJobs(keep int) error {
+	return d.update(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(jobPrefix)
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var stale [][]byte
+		kept := 0
+		for it.Seek([]byte(jobPrefix + "\xff")); it.Valid(); it.Next() {
+			if kept < keep {
+				kept++
+				continue
+			}
+			var job Job
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &job)
+			}); err != nil {
+				return err
+			}
+			if job.finished() {
+				stale = append(stale, it.Item().KeyCopy(nil))
+			}
+		}
+		for _, key := range stale {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeJob responds with a job record
+func writeJob(w http.ResponseWriter, status int, job *Job) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(job)
+}
+
+// submitJob queues a job from an admin handler and responds 202 with its
+// record, or with why it was refused
+func (es *ExplorerServer) submitJob(w http.ResponseWriter, kind string, params map[string]string, run JobFunc) {
+	if es.jobs == nil {
+		http.Error(w, "Job queue not available", http.StatusServiceUnavailable)
+		return
+	}
+	job, err := es.jobs.Submit(kind, params, run)
+	switch {
+	case err == errJobRunning:
+		http.Error(w, fmt.Sprintf("A %s job is already queued or running", kind), http.StatusConflict)
+		return
+	case err == errJobQueueFull:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		slog.Error("Failed to queue job", "kind", kind, "err", err)
+		http.Error(w, "Failed to queue job", http.StatusInternalServerError)
+		return
+	}
+	writeJob(w, http.StatusAccepted, job)
+}
+
+// Jobs endpoint: the latest background jobs, newest first
+func (es *ExplorerServer) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	if es.jobs == nil {
+		http.Error(w, "Job queue not available", http.StatusServiceUnavailable)
+		return
+	}
+	limit := defaultJobsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxJobRecords {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxJobRecords), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	jobs, err := es.jobs.List(limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list jobs", "err", err)
+		http.Error(w, "Failed to list jobs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"jobs": jobs})
+}
+
+// Job endpoint: one job's status, progress and result
+func (es *ExplorerServer) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	if es.jobs == nil {
+		http.Error(w, "Job queue not available", http.StatusServiceUnavailable)
+		return
+	}
+	job, err := es.jobs.Get(mux.Vars(r)["id"])
+	if err == errNotFound {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get job", "err", err)
+		http.Error(w, "Failed to get job", http.StatusInternalServerError)
+		return
+	}
+	writeJob(w, http.StatusOK, job)
+}
+
+// Cancel job endpoint: ask a queued or running job to stop
+func (es *ExplorerServer) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	if es.jobs == nil {
+		http.Error(w, "Job queue not available", http.StatusServiceUnavailable)
+		return
+	}
+	job, err := es.jobs.Cancel(mux.Vars(r)["id"])
+	switch {
+	case err == errNotFound:
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	case err == errJobFinished:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to cancel job", "err", err)
+		http.Error(w, "Failed to cancel job", http.StatusInternalServerError)
+		return
+	}
+	writeJob(w, http.StatusAccepted, job)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestJobQueue starts a queue on database, stopped when the test ends
+func newTestJobQueue(t *testing.T, database Store) *JobQueue {
+	queue := NewJobQueue(database, 2)
+	queue.Start()
+	t.Cleanup(queue.Stop)
+	return queue
+}
+
+// waitForJob waits until a job has finished and returns its record
+func waitForJob(t *testing.T, queue *JobQueue, id string) *Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, err := queue.Get(id); err == nil && job.finished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for job %s", id)
+	return nil
+}
+
+func TestJobQueue(t *testing.T) {
+	database := newTestDatabase(t)
+	queue := newTestJobQueue(t, database)
+
+	started := make(chan struct{})
+	job, err := queue.Submit("slow", map[string]string{"n": "1"}, func(ctx context.Context, progress func(done, total uint64)) (interface{}, error) {
+		progress(1, 10)
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil || job.Status != jobQueued {
+		t.Fatalf("Expected a queued job, got %+v (%v)", job, err)
+	}
+	if _, err := queue.Submit("slow", nil, nil); err != errJobRunning {
+		t.Errorf("Expected a second job of the same kind refused, got %v", err)
+	}
+
+	<-started
+	if running, _ := queue.Get(job.ID); running.Status != jobRunning || running.Progress.Done != 1 || running.Progress.Total != 10 {
+		t.Errorf("Expected the job running with progress, got %+v", running)
+	}
+	if _, err := queue.Cancel(job.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if cancelled := waitForJob(t, queue, job.ID); cancelled.Status != jobCancelled || cancelled.FinishedAt.IsZero() {
+		t.Errorf("Expected the job cancelled, got %+v", cancelled)
+	}
+	if _, err := queue.Cancel(job.ID); err != errJobFinished {
+		t.Errorf("Expected a finished job not cancellable, got %v", err)
+	}
+
+	results := map[string]JobFunc{
+		"ok": func(ctx context.Context, progress func(done, total uint64)) (interface{}, error) {
+			return map[string]int{"answer": 42}, nil
+		},
+		"broken": func(ctx context.Context, progress func(done, total uint64)) (interface{}, error) {
+			return nil, errors.New("disk on fire")
+		},
+		"panics": func(ctx context.Context, progress func(done, total uint64)) (interface{}, error) {
+			panic("bad job")
+		},
+	}
+	want := map[string]string{"ok": jobSucceeded, "broken": jobFailed, "panics": jobFailed}
+	for kind, run := range results {
+		job, err := queue.Submit(kind, nil, run)
+		if err != nil {
+			t.Fatalf("Submit %s failed: %v", kind, err)
+		}
+		if done := waitForJob(t, queue, job.ID); done.Status != want[kind] {
+			t.Errorf("Expected %s to end %s, got %+v", kind, want[kind], done)
+		}
+	}
+
+	jobs, err := queue.List(10)
+	if err != nil || len(jobs) != 4 || jobs[0].CreatedAt.Before(jobs[3].CreatedAt) {
+		t.Fatalf("Expected four jobs newest first, got %+v (%v)", jobs, err)
+	}
+	stored, err := database.GetJob(jobs[3].ID)
+	if err != nil || stored.Params["n"] != "1" {
+		t.Errorf("Expected the first job's record stored, got %+v (%v)", stored, err)
+	}
+}
+
+func TestJobQueueMarksInterrupted(t *testing.T) {
+	database := newTestDatabase(t)
+	for _, job := range []*Job{{ID: "01", Kind: "reindex", Status: jobRunning}, {ID: "02", Kind: "prune", Status: jobSucceeded}} {
+		if err := database.StoreJob(job); err != nil {
+			t.Fatalf("StoreJob failed: %v", err)
+		}
+	}
+	if err := database.ResetDatabase(); err != nil {
+		t.Fatalf("ResetDatabase failed: %v", err)
+	}
+
+	newTestJobQueue(t, database)
+	if job, err := database.GetJob("01"); err != nil || job.Status != jobInterrupted {
+		t.Errorf("Expected the running job interrupted, got %+v (%v)", job, err)
+	}
+	if job, err := database.GetJob("02"); err != nil || job.Status != jobSucceeded {
+		t.Errorf("Expected the finished job left alone, got %+v (%v)", job, err)
+	}
+}
+
+func TestPruneJobs(t *testing.T) {
+	database := newTestDatabase(t)
+	for i := 1; i <= 5; i++ {
+		status := jobSucceeded
+		if i == 1 {
+			status = jobRunning
+		}
+		if err := database.StoreJob(&Job{ID: fmt.Sprintf("%02d", i), Status: status}); err != nil {
+			t.Fatalf("StoreJob failed: %v", err)
+		}
+	}
+	if err := database.PruneJobs(2); err != nil {
+		t.Fatalf("PruneJobs failed: %v", err)
+	}
+
+	jobs, _ := database.ListJobs(10)
+	if len(jobs) != 3 || jobs[0].ID != "05" || jobs[1].ID != "04" || jobs[2].ID != "01" {
+		t.Errorf("Expected the latest two and the unfinished job kept, got %+v", jobs)
+	}
+}
+
+func TestJobsAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 1, 3)
+	es := &ExplorerServer{database: database, jobs: newTestJobQueue(t, database)}
+
+	rec := httptest.NewRecorder()
+	es.handleReset(rec, httptest.NewRequest("POST", "/api/v1/admin/reset", nil))
+	var job Job
+	if err := json.NewDecoder(rec.Body).Decode(&job); err != nil || rec.Code != http.StatusAccepted || job.Kind != "reset" {
+		t.Fatalf("Expected the reset queued, got %d %+v (%v)", rec.Code, job, err)
+	}
+	if done := waitForJob(t, es.jobs, job.ID); done.Status != jobSucceeded {
+		t.Fatalf("Expected the reset to succeed, got %+v", done)
+	}
+	if height, _ := database.GetLatestHeight(); height != 0 {
+		t.Errorf("Expected the blocks gone after the reset, got height %d", height)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleListJobs(rec, httptest.NewRequest("GET", "/api/v1/admin/jobs", nil))
+	var list struct {
+		Jobs []Job `json:"jobs"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list.Jobs) != 1 || list.Jobs[0].Status != jobSucceeded {
+		t.Errorf("Expected the reset job kept across the reset, got %+v (%v)", list, err)
+	}
+
+	for _, tc := range []struct {
+		handler http.HandlerFunc
+		id      string
+		want    int
+	}{
+		{es.handleGetJob, job.ID, http.StatusOK},
+		{es.handleGetJob, "missing", http.StatusNotFound},
+		{es.handleCancelJob, job.ID, http.StatusConflict},
+		{es.handleCancelJob, "missing", http.StatusNotFound},
+	} {
+		rec = httptest.NewRecorder()
+		tc.handler(rec, mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"id": tc.id}))
+		if rec.Code != tc.want {
+			t.Errorf("Expected %d for job %s, got %d", tc.want, tc.id, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	(&ExplorerServer{database: database}).handleReset(rec, httptest.NewRequest("POST", "/api/v1/admin/reset", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a job queue, got %d", rec.Code)
+	}
+}
+
+func TestReindexRunsAsJob(t *testing.T) {
+	database := newTestDatabase(t)
+	svc := NewSyncService("", newTestNodeClient(), database)
+	storeTokenBlocks(t, svc)
+	svc.jobs = newTestJobQueue(t, database)
+
+	// Cancelled while waiting for the sync lock, it stops before clearing
+	// anything
+	svc.syncMu.Lock()
+	status, err := svc.Reindex("wallets", 1)
+	if err != nil || status.JobID == "" {
+		t.Fatalf("Expected the reindex queued as a job, got %+v (%v)", status, err)
+	}
+	if _, err := svc.jobs.Cancel(status.JobID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	svc.syncMu.Unlock()
+	if job := waitForJob(t, svc.jobs, status.JobID); job.Status != jobCancelled {
+		t.Errorf("Expected the reindex job cancelled, got %+v", job)
+	}
+	if reindex := waitForReindex(t, svc); reindex.Error == "" || reindex.Removed != nil {
+		t.Errorf("Expected the reindex stopped before clearing, got %+v", reindex)
+	}
+
+	status, err = svc.Reindex("wallets", 1)
+	if err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	job := waitForJob(t, svc.jobs, status.JobID)
+	var result ReindexStatus
+	if err := json.Unmarshal(job.Result, &result); err != nil || job.Status != jobSucceeded || result.Height != 3 || job.Progress.Done != 3 {
+		t.Errorf("Expected the reindex job to replay three blocks, got %+v (%v)", job, err)
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// Address labels (label:<address>) name known addresses wherever the API
+// returns them. They come from a curated file loaded at startup and from
+// the admin API, and survive resets. Pool L-addresses without a label are
+// named after their pool.
+const (
+	labelPrefix = "label:"
+
+	labelSourceCurated = "curated"
+	labelSourceAdmin   = "admin"
+	labelSourcePool    = "pool"
+
+	maxLabelName = 64
+	maxLabelNote = 256
+
+	// Most addresses one labels request may look up
+	maxLabelLookup = 100
+)
+
+// labelCategories are the kinds of address a label can name
+var labelCategories = []string{"exchange", "pool", "burn", "faucet", "other"}
+
+func labelKey(address string) []byte {
+	return []byte(labelPrefix + address)
+}
+
+// validateLabel trims label's fields and checks them
+func validateLabel(label *AddressLabel) error {
+	label.Address = strings.TrimSpace(label.Address)
+	label.Name = strings.TrimSpace(label.Name)
+	label.Category = strings.ToLower(strings.TrimSpace(label.Category))
+	label.Note = strings.TrimSpace(label.Note)
+
+	switch {
+	case label.Address == "" || len(label.Address) > 128 || strings.ContainsAny(label.Address, " \t\r\n/"):
+		return fmt.Errorf("invalid address %q", label.Address)
+	case label.Name == "" || len(label.Name) > maxLabelName:
+		return fmt.Errorf("name must be 1 to %d characters", maxLabelName)
+	case len(label.Note) > maxLabelNote:
+		return fmt.Errorf("note must be at most %d characters", maxLabelNote)
+	}
+	if label.Category == "" {
+		label.Category = "other"
+	}
+	for _, category := range labelCategories {
+		if label.Category == category {
+			return nil
+		}
+	}
+	return fmt.Errorf("category must be one of %s", strings.Join(labelCategories, ", "))
+}
+
+// StoreAddressLabel saves a label, replacing any on the same address
+func (d *Database) StoreAddressLabel(label *AddressLabel) error {
+	data, err := json.Marshal(label)
+	if err != nil {
+		return fmt.Errorf("failed to marshal address label: %w", err)
+	}
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set(labelKey(label.Address), data)
+	})
+}
+
+// GetAddressLabels returns the labels of those addresses that have one
+func (d *Database) GetAddressLabels(addresses []string) (map[string]AddressLabel, error) {
+	labels := make(map[string]AddressLabel)
+	err := d.view(func(txn *badger.Txn) error {
+		for _, address := range addresses {
+			item, err := txn.Get(labelKey(address))
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			var label AddressLabel
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &label)
+			}); err != nil {
+				return err
+			}
+			labels[address] = label
+		}
+		return nil
+	})
+	return labels, err
+}
+
+// ListAddressLabels returns every stored label, ordered by address
+func (d *Database) ListAddressLabels() ([]AddressLabel, error) {
+	labels := []AddressLabel{}
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(labelPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var label AddressLabel
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &label)
+			}); err != nil {
+				return err
+			}
+			labels = append(labels, label)
+		}
+		return nil
+	})
+	return labels, err
+}
+
+// DeleteAddressLabel removes an address's label
+func (d *Database) DeleteAddressLabel(address string) error {
+	return d.update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(labelKey(address)); err != nil {
+			return err
+		}
+		return txn.Delete(labelKey(address))
+	})
+}
+
+// loadCuratedLabels stores the labels in the JSON file at path, a list of
+// {address, name, category, note}. Labels set through the admin API are
+// kept; curated labels no longer in the file are left for an admin to remove.
+func loadCuratedLabels(store Store, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var curated []AddressLabel
+	if err := json.Unmarshal(data, &curated); err != nil {
+		return fmt.Errorf("invalid labels file: %w", err)
+	}
+
+	addresses := make([]string, len(curated))
+	for i := range curated {
+		if err := validateLabel(&curated[i]); err != nil {
+			return fmt.Errorf("label %d: %w", i+1, err)
+		}
+		addresses[i] = curated[i].Address
+	}
+	existing, err := store.GetAddressLabels(addresses)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	stored := 0
+	for i := range curated {
+		label := &curated[i]
+		if current, ok := existing[label.Address]; ok && current.Source == labelSourceAdmin {
+			continue
+		}
+		label.Source = labelSourceCurated
+		label.UpdatedAt = now
+		if err := store.StoreAddressLabel(label); err != nil {
+			return err
+		}
+		stored++
+	}
+	slog.Info("Loaded curated address labels", "labels", stored, "path", path)
+	return nil
+}
+
+// loadLabelsFromEnv loads the curated labels file at EXPLORER_LABELS_FILE,
+// if set
+func loadLabelsFromEnv(store Store) {
+	path := os.Getenv("EXPLORER_LABELS_FILE")
+	if path == "" {
+		return
+	}
+	if err := loadCuratedLabels(store, path); err != nil {
+		slog.Error("Failed to load address labels", "path", path, "err", err)
+	}
+}
+
+// labelMatches reports whether query, lowercased, occurs in the label's
+// name, address or category
+func labelMatches(label *AddressLabel, query string) bool {
+	return strings.Contains(strings.ToLower(label.Name), query) ||
+		strings.Contains(strings.ToLower(label.Address), query) ||
+		label.Category == query
+}
+
+// searchLabels resolves a query naming a labelled address, exactly and
+// ignoring case, to that wallet. Stores call it when nothing else matches.
+func searchLabels(store Store, result *SearchResult) (*SearchResult, error) {
+	labels, err := store.ListAddressLabels()
+	if err != nil {
+		return nil, err
+	}
+	for _, label := range labels {
+		if strings.EqualFold(label.Name, result.Query) {
+			result.Type = "wallet"
+			result.ID = label.Address
+			result.Redirect = "/wallet/" + url.PathEscape(label.Address)
+			return result, nil
+		}
+	}
+	return nil, errNoSearchMatch
+}
+
+// addressLabeler collects the label fields of a response and fills them in
+// with one store lookup
+type addressLabeler struct {
+	store Store
+	dests map[string][]**AddressLabel
+}
+
+func (es *ExplorerServer) newLabeler() *addressLabeler {
+	return &addressLabeler{store: es.database, dests: make(map[string][]**AddressLabel)}
+}
+
+// add sets *dest to address's label, if it has one, when apply is called
+func (l *addressLabeler) add(address string, dest **AddressLabel) {
+	if address == "" || address == "unknown" {
+		return
+	}
+	l.dests[address] = append(l.dests[address], dest)
+}
+
+// addTransactions labels the senders and recipients of txs
+func (l *addressLabeler) addTransactions(txs []WalletTransaction) {
+	for i := range txs {
+		l.add(txs[i].FromAddress, &txs[i].FromLabel)
+		l.add(txs[i].ToAddress, &txs[i].ToLabel)
+	}
+}
+
+// apply looks up the collected addresses and fills in their labels.
+// Labels are decoration, so a failed lookup only leaves them out.
+func (l *addressLabeler) apply() {
+	if len(l.dests) == 0 {
+		return
+	}
+	addresses := make([]string, 0, len(l.dests))
+	for address := range l.dests {
+		addresses = append(addresses, address)
+	}
+
+	labels, err := lookupLabels(l.store, addresses)
+	if err != nil {
+		slog.Warn("Failed to look up address labels", "err", err)
+		return
+	}
+	for address, label := range labels {
+		for _, dest := range l.dests[address] {
+			*dest = label
+		}
+	}
+}
+
+// lookupLabels returns the labels of addresses, naming unlabelled pool
+// L-addresses after their pool
+func lookupLabels(store Store, addresses []string) (map[string]*AddressLabel, error) {
+	stored, err := store.GetAddressLabels(addresses)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]*AddressLabel, len(stored))
+	for address, label := range stored {
+		label := label
+		labels[address] = &label
+	}
+
+	for _, address := range addresses {
+		if _, ok := labels[address]; ok || !strings.HasPrefix(address, "L") {
+			continue
+		}
+		pool, err := store.GetPoolByAddress(address)
+		if err != nil {
+			continue
+		}
+		pair := pool.TokenASymbol + "/" + pool.TokenBSymbol
+		if pool.TokenB == "" {
+			pair = pool.TokenASymbol + "/SHADOW"
+		}
+		labels[address] = &AddressLabel{
+			Address:  address,
+			Name:     pair + " pool",
+			Category: "pool",
+			Source:   labelSourcePool,
+		}
+	}
+	return labels, nil
+}
+
+// Labels API endpoint: every label, those matching q and category, or
+// those of the addresses given as address parameters, pools included
+func (es *ExplorerServer) handleLabels(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	category := strings.ToLower(r.URL.Query().Get("category"))
+	result := &AddressLabels{Labels: []AddressLabel{}}
+
+	if addresses := r.URL.Query()["address"]; len(addresses) > 0 {
+		if len(addresses) > maxLabelLookup {
+			http.Error(w, fmt.Sprintf("at most %d addresses may be looked up at once", maxLabelLookup), http.StatusBadRequest)
+			return
+		}
+		labels, err := lookupLabels(es.database, addresses)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to look up address labels", "err", err)
+			http.Error(w, "Failed to look up labels", http.StatusInternalServerError)
+			return
+		}
+		for _, label := range labels {
+			result.Labels = append(result.Labels, *label)
+		}
+	} else {
+		labels, err := es.database.ListAddressLabels()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to list address labels", "err", err)
+			http.Error(w, "Failed to list labels", http.StatusInternalServerError)
+			return
+		}
+		for i := range labels {
+			if (query == "" || labelMatches(&labels[i], query)) && (category == "" || labels[i].Category == category) {
+				result.Labels = append(result.Labels, labels[i])
+			}
+		}
+	}
+	sort.SliceStable(result.Labels, func(i, j int) bool {
+		return strings.ToLower(result.Labels[i].Name) < strings.ToLower(result.Labels[j].Name)
+	})
+	result.Total = len(result.Labels)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Label admin endpoint: set an address's label
+func (es *ExplorerServer) handlePutLabel(w http.ResponseWriter, r *http.Request) {
+	var label AddressLabel
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		http.Error(w, "Expected JSON with a name and optional category and note", http.StatusBadRequest)
+		return
+	}
+	label.Address = mux.Vars(r)["address"]
+	if err := validateLabel(&label); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	label.Source = labelSourceAdmin
+	label.UpdatedAt = time.Now().UTC()
+
+	if err := es.database.StoreAddressLabel(&label); err != nil {
+		http.Error(w, "Failed to store label", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Labelled address", "address", label.Address, "name", label.Name, "category", label.Category)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(label)
+}
+
+// Label admin endpoint: remove an address's label
+func (es *ExplorerServer) handleDeleteLabel(w http.ResponseWriter, r *http.Request) {
+	err := es.database.DeleteAddressLabel(mux.Vars(r)["address"])
+	if err == errNotFound {
+		http.Error(w, "Label not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete label", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// labelScriptHTML renders address labels on explorer pages. Label names
+// are set by operators, so they are inserted as text, never as HTML.
+const labelScriptHTML = `<script>
+        function labelBadge(label) {
+            if (!label) return '';
+            const badge = document.createElement('span');
+            badge.className = 'ml-2 px-2 py-0.5 rounded text-xs font-sans bg-indigo-900 text-indigo-300';
+            badge.title = label.category + (label.note ? ': ' + label.note : '');
+            badge.textContent = label.name;
+            return badge.outerHTML;
+        }
+
+        // Adds badges after the elements under root marked with
+        // data-label-address, for pages that render raw blocks
+        async function labelAddresses(root) {
+            const elements = [...root.querySelectorAll('[data-label-address]')];
+            const addresses = [...new Set(elements.map(el => el.dataset.labelAddress))].slice(0, 100);
+            if (addresses.length === 0) return;
+            const response = await fetch('/api/v1/labels?' + addresses.map(a => 'address=' + encodeURIComponent(a)).join('&'));
+            if (!response.ok) return;
+            const labels = {};
+            (await response.json()).labels.forEach(label => labels[label.address] = label);
+            elements.forEach(el => el.insertAdjacentHTML('afterend', labelBadge(labels[el.dataset.labelAddress])));
+        }
+    </script>`
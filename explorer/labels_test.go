@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// checkAddressLabels stores, looks up, searches and deletes labels
+func checkAddressLabels(t *testing.T, store Store) {
+	// Labels outlive resets, so clear what an earlier run left
+	existing, _ := store.ListAddressLabels()
+	for _, label := range existing {
+		store.DeleteAddressLabel(label.Address)
+	}
+
+	for _, label := range []AddressLabel{
+		{Address: "dave", Name: "Dave's Exchange", Category: "exchange", Source: labelSourceAdmin},
+		{Address: "bob", Name: "Faucet", Category: "faucet", Source: labelSourceCurated},
+	} {
+		if err := store.StoreAddressLabel(&label); err != nil {
+			t.Fatalf("StoreAddressLabel failed: %v", err)
+		}
+	}
+
+	labels, err := store.GetAddressLabels([]string{"dave", "carol"})
+	if err != nil || len(labels) != 1 || labels["dave"].Name != "Dave's Exchange" {
+		t.Fatalf("Expected only dave labelled, got %+v (%v)", labels, err)
+	}
+	if all, _ := store.ListAddressLabels(); len(all) != 2 || all[0].Address != "bob" {
+		t.Errorf("Expected both labels ordered by address, got %+v", all)
+	}
+
+	result, err := store.Search("dave's exchange")
+	if err != nil || result.Type != "wallet" || result.ID != "dave" {
+		t.Errorf("Expected the label name to find dave, got %+v (%v)", result, err)
+	}
+
+	if err := store.DeleteAddressLabel("bob"); err != nil {
+		t.Fatalf("DeleteAddressLabel failed: %v", err)
+	}
+	if err := store.DeleteAddressLabel("bob"); err != errNotFound {
+		t.Errorf("Expected errNotFound deleting twice, got %v", err)
+	}
+
+	if err := store.ResetDatabase(); err != nil {
+		t.Fatalf("ResetDatabase failed: %v", err)
+	}
+	if labels, _ := store.GetAddressLabels([]string{"dave"}); len(labels) != 1 {
+		t.Error("Expected labels to survive a reset")
+	}
+}
+
+func TestAddressLabels(t *testing.T) {
+	checkAddressLabels(t, newTestDatabase(t))
+}
+
+func TestLabelsInlineInResponses(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTokenBlocks(t, NewSyncService("", newTestNodeClient(), database))
+	if err := database.StorePool(&LiquidityPool{PoolID: "p1", LAddress: "Lpool", TokenASymbol: "ALP"}); err != nil {
+		t.Fatalf("StorePool failed: %v", err)
+	}
+	database.StoreAddressLabel(&AddressLabel{Address: "dave", Name: "Dave's Exchange", Category: "exchange", Source: labelSourceAdmin})
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleWalletAPI(rec, mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/wallet/dave", nil), map[string]string{"address": "dave"}))
+	var summary WalletSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Invalid wallet response: %v", err)
+	}
+	if summary.Label == nil || summary.Label.Name != "Dave's Exchange" {
+		t.Errorf("Expected dave's label inline, got %+v", summary.Label)
+	}
+	for _, tx := range summary.Transactions {
+		if tx.ToAddress == "dave" && (tx.ToLabel == nil || tx.ToLabel.Category != "exchange") {
+			t.Errorf("Expected the recipient labelled in %+v", tx)
+		}
+		if tx.FromAddress != "dave" && tx.FromLabel != nil {
+			t.Errorf("Expected no label for %s", tx.FromAddress)
+		}
+	}
+
+	_, resp := graphQL(t, es, `{ wallet(address: "dave") { label { name category } } carol: wallet(address: "carol") { label { name } } }`, nil)
+	if gqlPath(resp, "data", "wallet", "label", "name") != "Dave's Exchange" || gqlPath(resp, "data", "carol", "label") != nil {
+		t.Errorf("Unexpected wallet labels over GraphQL %v", resp)
+	}
+
+	// Pool L-addresses are named after their pool
+	rec = httptest.NewRecorder()
+	es.handleLabels(rec, httptest.NewRequest("GET", "/api/v1/labels?address=Lpool&address=dave&address=carol", nil))
+	var lookup AddressLabels
+	json.Unmarshal(rec.Body.Bytes(), &lookup)
+	if lookup.Total != 2 || lookup.Labels[0].Name != "ALP/SHADOW pool" || lookup.Labels[0].Source != labelSourcePool {
+		t.Errorf("Unexpected lookup %+v", lookup)
+	}
+}
+
+func TestLabelsAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database}
+
+	put := func(address, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/v1/admin/labels/"+address, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		es.handlePutLabel(rec, mux.SetURLVars(req, map[string]string{"address": address}))
+		return rec
+	}
+	if rec := put("Sburn", `{"name": " Burn address ", "category": "Burn"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the label stored, got %d %s", rec.Code, rec.Body)
+	}
+	put("Sfaucet", `{"name": "Testnet faucet", "category": "faucet", "note": "1 SHADOW a day"}`)
+	for _, body := range []string{`{"name": ""}`, `{"name": "x", "category": "casino"}`, `not json`} {
+		if rec := put("Sother", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+
+	get := func(query string) AddressLabels {
+		rec := httptest.NewRecorder()
+		es.handleLabels(rec, httptest.NewRequest("GET", "/api/v1/labels"+query, nil))
+		var labels AddressLabels
+		json.Unmarshal(rec.Body.Bytes(), &labels)
+		return labels
+	}
+	if all := get(""); all.Total != 2 || all.Labels[0].Name != "Burn address" || all.Labels[0].Category != "burn" || all.Labels[0].Source != labelSourceAdmin {
+		t.Errorf("Unexpected labels %+v", all)
+	}
+	if found := get("?q=FAUCET"); found.Total != 1 || found.Labels[0].Address != "Sfaucet" {
+		t.Errorf("Expected the faucet found, got %+v", found)
+	}
+	if found := get("?category=burn"); found.Total != 1 || found.Labels[0].Address != "Sburn" {
+		t.Errorf("Expected the burn address found, got %+v", found)
+	}
+
+	// A curated file does not override an admin's label
+	path := filepath.Join(t.TempDir(), "labels.json")
+	os.WriteFile(path, []byte(`[{"address": "Sburn", "name": "Curated burn", "category": "burn"},
+		{"address": "Sexchange", "name": "Big Exchange", "category": "exchange"}]`), 0o644)
+	if err := loadCuratedLabels(database, path); err != nil {
+		t.Fatalf("loadCuratedLabels failed: %v", err)
+	}
+	labels, _ := database.GetAddressLabels([]string{"Sburn", "Sexchange"})
+	if labels["Sburn"].Name != "Burn address" || labels["Sexchange"].Source != labelSourceCurated {
+		t.Errorf("Unexpected labels after loading the curated file %+v", labels)
+	}
+
+	rec := httptest.NewRecorder()
+	es.handleDeleteLabel(rec, mux.SetURLVars(httptest.NewRequest("DELETE", "/api/v1/admin/labels/Sburn", nil), map[string]string{"address": "Sburn"}))
+	if rec.Code != http.StatusNoContent || get("?q=burn").Total != 0 {
+		t.Errorf("Expected the label deleted, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+
+	requestIDHeader = "X-Request-ID"
+)
+
+// requestIDPattern bounds the request IDs accepted from clients and proxies
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestIDKey struct{}
+
+// requestIDFrom returns the ID of the request ctx belongs to, if any
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestID tags each request with an ID, taken from its X-Request-ID
+// header when it carries a sensible one, else generated. The ID is echoed
+// back in the response and added to everything logged with the request's
+// context.
+func (c *ServerConfig) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = randomHex(8)
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// contextHandler adds the request ID from a record's context to it
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestIDFrom(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// newLogger builds the logger the config asks for, writing to w
+func (c *ServerConfig) newLogger(w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{Level: c.logLevel}
+	var handler slog.Handler = slog.NewTextHandler(w, options)
+	if c.LogFormat == logFormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// setupLogging makes the configured logger the default. Lines the standard
+// log package still writes, such as the HTTP server's, go through it too.
+func (c *ServerConfig) setupLogging() {
+	slog.SetDefault(c.newLogger(os.Stderr))
+}
+
+// fatal logs an error and exits
+func fatal(message string, args ...any) {
+	slog.Error(message, args...)
+	os.Exit(1)
+}
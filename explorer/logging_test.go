@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := (&ServerConfig{}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestIDFrom(r.Context())
+	}))
+
+	for header, keep := range map[string]bool{"": false, "abc-123": true, "bad id\n": false, strings.Repeat("x", 65): false} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set(requestIDHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if id := rec.Header().Get(requestIDHeader); id == "" || id != seen || (id == header) != keep {
+			t.Errorf("Expected %q kept %v, got %q (handler saw %q)", header, keep, id, seen)
+		}
+	}
+}
+
+func TestLoggerFormatsAndLevels(t *testing.T) {
+	t.Setenv("EXPLORER_CONFIG", "")
+	config, err := loadServerConfig([]string{"-log-format", "json", "-log-level", "warn"})
+	if err != nil {
+		t.Fatalf("loadServerConfig failed: %v", err)
+	}
+	var out bytes.Buffer
+	logger := config.newLogger(&out)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	ctx := req.Context()
+	(&ServerConfig{}).requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	logger.InfoContext(ctx, "Synced blocks", "from", 1)
+	logger.ErrorContext(ctx, "Failed to sync batch", "from", 1, "to", 100)
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record above the level, got %q (%v)", out.String(), err)
+	}
+	if record["level"] != "ERROR" || record["msg"] != "Failed to sync batch" || record["to"] != float64(100) ||
+		record["request_id"] != requestIDFrom(ctx) {
+		t.Errorf("Unexpected record %v", record)
+	}
+
+	for _, args := range [][]string{{"-log-format", "xml"}, {"-log-level", "loud"}} {
+		if _, err := loadServerConfig(args); err == nil {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConfigReturnsRefreshInterval(t *testing.T) {
+	t.Setenv("EXPLORER_REFRESH_INTERVAL", "2m")
+	es := NewExplorerServer("", &Database{}, nil)
+
+	rec := httptest.NewRecorder()
+	es.handleConfig(rec, httptest.NewRequest("GET", "/api/v1/config", nil))
+
+	var config struct {
+		RefreshIntervalMS int64 `json:"refresh_interval_ms"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&config); err != nil {
+		t.Fatalf("Failed to decode config: %v", err)
+	}
+	if config.RefreshIntervalMS != 120000 {
+		t.Errorf("Expected 120000ms, got %d", config.RefreshIntervalMS)
+	}
+}
+
+func TestRefreshIntervalFallsBackToDefault(t *testing.T) {
+	for _, value := range []string{"", "soon", "10ms"} {
+		t.Setenv("EXPLORER_REFRESH_INTERVAL", value)
+		if got := refreshIntervalFromEnv(); got != defaultRefreshInterval {
+			t.Errorf("EXPLORER_REFRESH_INTERVAL=%q: expected %s, got %s", value, defaultRefreshInterval, got)
+		}
+	}
+
+	t.Setenv("EXPLORER_REFRESH_INTERVAL", "45s")
+	if got := refreshIntervalFromEnv(); got != 45*time.Second {
+		t.Errorf("Expected 45s, got %s", got)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// Token melts are kept by token and time (melt:<token>:<timestamp>:<hash>),
+// like token transactions. They are not pruned with old token transactions
+// since a token's melted total is summed from them.
+const (
+	meltPrefix = "melt:"
+
+	defaultTokenMeltsPerPage = 50
+	maxTokenMeltsPerPage     = 500
+)
+
+func meltKey(melt *MeltEvent) []byte {
+	return []byte(fmt.Sprintf("%s%s:%016d:%s", meltPrefix, melt.TokenID, melt.Timestamp.Unix(), melt.TxHash))
+}
+
+// newMeltEvent describes a melt of amount tokens by burner, which releases
+// the SHADOW locked behind each unit melted
+func newMeltEvent(token *TokenInfo, tx *TokenTransaction) *MeltEvent {
+	return &MeltEvent{
+		TxHash:         tx.TxHash,
+		TokenID:        token.TokenID,
+		BlockHeight:    tx.BlockHeight,
+		Timestamp:      tx.Timestamp,
+		Burner:         tx.FromAddress,
+		Amount:         tx.Amount,
+		ShadowReleased: tx.Amount * token.MeltValue,
+	}
+}
+
+// StoreMelt records a token melt
+func (d *Database) StoreMelt(melt *MeltEvent) error {
+	return d.update(func(txn *badger.Txn) error {
+		data, err := json.Marshal(melt)
+		if err != nil {
+			return fmt.Errorf("failed to marshal melt: %w", err)
+		}
+		if err := txn.Set(meltKey(melt), data); err != nil {
+			return err
+		}
+		return recordAtHeight(txn, melt.BlockHeight, string(meltKey(melt)))
+	})
+}
+
+// GetTokenMelts returns one page of a token's melts, newest first
+func (d *Database) GetTokenMelts(tokenID string, page, perPage int) (*PaginatedTokenMelts, error) {
+	if page < 1 {
+		page = 1
+	}
+	result := &PaginatedTokenMelts{TokenID: tokenID, Melts: []MeltEvent{}, CurrentPage: page, PerPage: perPage}
+	skip := (page - 1) * perPage
+
+	err := d.view(func(txn *badger.Txn) error {
+		prefix := []byte(meltPrefix + tokenID + ":")
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.Reverse = true // Newest first
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(append(prefix, 0xff)); it.Valid(); it.Next() {
+			var melt MeltEvent
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &melt)
+			}); err != nil {
+				continue
+			}
+			if result.TotalMelts >= skip && len(result.Melts) < perPage {
+				result.Melts = append(result.Melts, melt)
+			}
+			result.TotalMelts++
+			result.TotalMelted += melt.Amount
+			result.TotalShadowReleased += melt.ShadowReleased
+		}
+		return nil
+	})
+	result.TotalPages = (result.TotalMelts + perPage - 1) / perPage
+	return result, err
+}
+
+// sumTokenMelts totals the amounts of a token's melts inside txn
+func sumTokenMelts(txn *badger.Txn, tokenID string) (uint64, error) {
+	var melted uint64
+	_, err := collectKeys(txn, meltPrefix+tokenID+":", func(key string, val []byte) bool {
+		var melt MeltEvent
+		if json.Unmarshal(val, &melt) == nil {
+			melted += melt.Amount
+		}
+		return false
+	})
+	return melted, err
+}
+
+// GetTokenSupply totals a token's supply from the ledger: what its holders
+// hold, and what has been melted
+func (d *Database) GetTokenSupply(tokenID string) (circulating, melted uint64, err error) {
+	balances, err := d.getTokenHolderBalances(tokenID)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, balance := range balances {
+		circulating += balance
+	}
+	err = d.view(func(txn *badger.Txn) error {
+		melted, err = sumTokenMelts(txn, tokenID)
+		return err
+	})
+	return circulating, melted, err
+}
+
+// Token melts API endpoint: a token's melts, newest first, with the total
+// melted and SHADOW released
+func (es *ExplorerServer) handleTokenMelts(w http.ResponseWriter, r *http.Request) {
+	tokenID := mux.Vars(r)["tokenId"]
+	query := r.URL.Query()
+
+	page := 1
+	if value := query.Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "page must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+	perPage := defaultTokenMeltsPerPage
+	if value := query.Get("per_page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxTokenMeltsPerPage {
+			http.Error(w, fmt.Sprintf("per_page must be between 1 and %d", maxTokenMeltsPerPage), http.StatusBadRequest)
+			return
+		}
+		perPage = n
+	}
+
+	if _, err := es.database.GetToken(tokenID); err != nil {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	melts, err := es.database.GetTokenMelts(tokenID, page, perPage)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get token melts", "token_id", tokenID, "err", err)
+		http.Error(w, "Failed to get token melts", http.StatusInternalServerError)
+		return
+	}
+
+	labels := es.newLabeler()
+	for i := range melts.Melts {
+		labels.add(melts.Melts[i].Burner, &melts.Melts[i].BurnerLabel)
+	}
+	labels.apply()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(melts)
+}
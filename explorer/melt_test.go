@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const meltTokenID = "0b0b0b0b0b0b0b0b"
+
+// storeMeltBlocks indexes a token locking 100 satoshi per unit, minted to
+// alice, partly sent to bob, then melted by bob at height 3 and alice at 4
+func storeMeltBlocks(t *testing.T, svc *SyncService) {
+	ops := []TokenOperation{
+		{Type: TOKEN_CREATE, TokenID: meltTokenID, Amount: 100, To: "alice",
+			Metadata: &TokenMetadata{Name: "Beta", Ticker: "BET", LockAmount: 100}},
+		{Type: TOKEN_TRANSFER, TokenID: meltTokenID, Amount: 40, From: "alice", To: "bob"},
+		{Type: TOKEN_MELT, TokenID: meltTokenID, Amount: 15, From: "bob"},
+		{Type: TOKEN_MELT, TokenID: meltTokenID, Amount: 5, From: "alice"},
+	}
+	start := time.Unix(1700000000, 0).UTC()
+	for i, op := range ops {
+		height := uint64(i + 1)
+		timestamp := start.Add(time.Duration(height) * time.Minute)
+		tx, _ := json.Marshal(Transaction{Version: 1, TokenOps: []TokenOperation{op}, Timestamp: timestamp})
+		block := &Block{
+			Header: BlockHeader{Height: height, Timestamp: timestamp},
+			Body:   BlockBody{Transactions: []SignedTransaction{{Transaction: tx, TxHash: fmt.Sprintf("melt%d", height)}}},
+		}
+		hash := fmt.Sprintf("hash_%d", height)
+		if err := svc.database.StoreBlock(hash, block); err != nil {
+			t.Fatalf("Failed to store block %d: %v", height, err)
+		}
+		if _, err := svc.extractAndStoreTransactions(hash, block, indexAll); err != nil {
+			t.Fatalf("Failed to index block %d: %v", height, err)
+		}
+	}
+}
+
+// checkTokenMelts checks melts are indexed, totalled into the token's
+// supply and rolled back
+func checkTokenMelts(t *testing.T, store Store) {
+	storeMeltBlocks(t, NewSyncService("", newTestNodeClient(), store))
+
+	token, err := store.GetToken(meltTokenID)
+	if err != nil {
+		t.Fatalf("GetToken failed: %v", err)
+	}
+	if token.CirculatingSupply != 80 || token.TotalMelted != 20 {
+		t.Errorf("Expected 80 circulating and 20 melted, got %d/%d", token.CirculatingSupply, token.TotalMelted)
+	}
+
+	page, err := store.GetTokenMelts(meltTokenID, 1, 1)
+	if err != nil {
+		t.Fatalf("GetTokenMelts failed: %v", err)
+	}
+	if page.TotalMelts != 2 || page.TotalPages != 2 || page.TotalMelted != 20 || page.TotalShadowReleased != 2000 {
+		t.Errorf("Unexpected totals %+v", page)
+	}
+	if len(page.Melts) != 1 || page.Melts[0].Burner != "alice" || page.Melts[0].Amount != 5 ||
+		page.Melts[0].ShadowReleased != 500 || page.Melts[0].BlockHeight != 4 {
+		t.Errorf("Expected alice's melt first, got %+v", page.Melts)
+	}
+
+	// Rolling back alice's melt puts her tokens back in circulation
+	if _, err := store.RollbackIndex(indexTokens, 3); err != nil {
+		t.Fatalf("RollbackIndex failed: %v", err)
+	}
+	token, _ = store.GetToken(meltTokenID)
+	melts, _ := store.GetTokenMelts(meltTokenID, 1, 10)
+	if token.CirculatingSupply != 85 || token.TotalMelted != 15 || melts.TotalMelts != 1 || melts.Melts[0].Burner != "bob" {
+		t.Errorf("Expected bob's melt alone after the rollback, got %d/%d %+v",
+			token.CirculatingSupply, token.TotalMelted, melts.Melts)
+	}
+}
+
+func TestTokenMelts(t *testing.T) {
+	checkTokenMelts(t, newTestDatabase(t))
+}
+
+func TestTokenMeltsAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	storeMeltBlocks(t, NewSyncService("", newTestNodeClient(), database))
+
+	es := &ExplorerServer{database: database}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/token/{tokenId}/melts", es.handleTokenMelts)
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		return rec
+	}
+
+	rec := get("/api/v1/token/" + meltTokenID + "/melts?page=2&per_page=1")
+	var page PaginatedTokenMelts
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode melts: %v", err)
+	}
+	if page.CurrentPage != 2 || page.TotalMelted != 20 || len(page.Melts) != 1 || page.Melts[0].Burner != "bob" {
+		t.Errorf("Expected bob's melt on the second page, got %+v", page)
+	}
+
+	if rec := get("/api/v1/token/" + meltTokenID + "/melts?per_page=501"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an oversized page rejected, got %d", rec.Code)
+	}
+	if rec := get("/api/v1/token/ffff/melts"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown token to 404, got %d", rec.Code)
+	}
+}
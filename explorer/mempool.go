@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultNodeAPIURL = "http://localhost:8080"
+
+	mempoolPollInterval = 5 * time.Second
+	mempoolFetchLimit   = 100 // The most the node lists per request
+
+	// A transaction that left the node's listing is kept this long while
+	// the block that took it is indexed; the node also lists only its
+	// highest priority transactions, so absence alone does not mean dropped
+	mempoolDropAfter = 10 * time.Minute
+
+	mempoolConfirmedHistory = 100
+)
+
+// nodeAPIURL is the Shadowy node's HTTP API, which serves the mempool
+func nodeAPIURL() string {
+	if url := os.Getenv("EXPLORER_NODE_API_URL"); url != "" {
+		return url
+	}
+	return defaultNodeAPIURL
+}
+
+// nodeMempoolResponse is the node's /api/v1/mempool/transactions payload
+type nodeMempoolResponse struct {
+	Transactions []struct {
+		TxHash      string             `json:"tx_hash"`
+		Transaction *SignedTransaction `json:"transaction"`
+		Size        int                `json:"size"`
+		Fee         uint64             `json:"fee"`
+	} `json:"transactions"`
+}
+
+type mempoolEntry struct {
+	tx       PendingTransaction
+	lastSeen time.Time
+}
+
+// MempoolTracker polls the node's mempool, remembering when each pending
+// transaction was first seen and which block later confirmed it
+type MempoolTracker struct {
+	url      string
+	client   *NodeClient
+	interval time.Duration
+
+	mu        sync.RWMutex
+	pending   map[string]*mempoolEntry
+	confirmed []PendingTransaction // Newest first
+	lastPoll  time.Time
+	lastError string
+
+	stopCh chan struct{}
+}
+
+// NewMempoolTracker creates a tracker polling the node API at apiURL
+// through client
+func NewMempoolTracker(apiURL string, client *NodeClient, interval time.Duration) *MempoolTracker {
+	return &MempoolTracker{
+		url:      apiURL,
+		client:   client,
+		interval: interval,
+		pending:  make(map[string]*mempoolEntry),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start polls once and then keeps polling in the background
+func (m *MempoolTracker) Start() {
+	m.Poll()
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Poll()
+			case <-m.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background polling
+func (m *MempoolTracker) Stop() {
+	close(m.stopCh)
+}
+
+// Poll fetches the node's pending transactions once
+func (m *MempoolTracker) Poll() error {
+	txs, err := m.fetch()
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastPoll = now
+	if err != nil {
+		m.lastError = err.Error()
+		slog.Warn("Failed to poll mempool", "err", err)
+		return err
+	}
+	m.lastError = ""
+	m.merge(txs, now)
+	return nil
+}
+
+func (m *MempoolTracker) fetch() ([]PendingTransaction, error) {
+	body, err := m.client.Mempool(m.url, mempoolFetchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]PendingTransaction, 0, len(body.Transactions))
+	for _, entry := range body.Transactions {
+		pending := PendingTransaction{TxHash: entry.TxHash, Fee: entry.Fee, Size: entry.Size}
+		if entry.Transaction != nil {
+			var tx Transaction
+			if json.Unmarshal(entry.Transaction.Transaction, &tx) == nil {
+				pending.Outputs = len(tx.Outputs)
+				for _, output := range tx.Outputs {
+					pending.Amount += output.Value
+				}
+				if len(tx.Outputs) > 0 {
+					pending.ToAddress = tx.Outputs[0].Address
+				}
+			}
+		}
+		if pending.TxHash != "" {
+			txs = append(txs, pending)
+		}
+	}
+	return txs, nil
+}
+
+// merge records a poll's transactions and forgets those gone for too long.
+// Callers hold mu.
+func (m *MempoolTracker) merge(txs []PendingTransaction, now time.Time) {
+	for _, tx := range txs {
+		if m.isConfirmed(tx.TxHash) {
+			continue
+		}
+		if entry, ok := m.pending[tx.TxHash]; ok {
+			entry.lastSeen = now
+			continue
+		}
+		tx.FirstSeen = now
+		tx.Status = "pending"
+		m.pending[tx.TxHash] = &mempoolEntry{tx: tx, lastSeen: now}
+	}
+
+	for hash, entry := range m.pending {
+		if now.Sub(entry.lastSeen) > mempoolDropAfter {
+			delete(m.pending, hash)
+		}
+	}
+}
+
+func (m *MempoolTracker) isConfirmed(hash string) bool {
+	for _, tx := range m.confirmed {
+		if tx.TxHash == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Confirm promotes the pending transactions included in an indexed block.
+// It is a no-op on a nil tracker.
+func (m *MempoolTracker) Confirm(block *Block) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, signedTx := range block.Body.Transactions {
+		entry, ok := m.pending[signedTx.TxHash]
+		if !ok {
+			continue
+		}
+		delete(m.pending, signedTx.TxHash)
+
+		tx := entry.tx
+		height := block.Header.Height
+		tx.Status = "confirmed"
+		tx.BlockHeight = &height
+		tx.ConfirmedAt = block.Header.Timestamp
+		if wait := block.Header.Timestamp.Sub(tx.FirstSeen).Seconds(); wait > 0 {
+			tx.WaitSeconds = wait
+		}
+
+		m.confirmed = append([]PendingTransaction{tx}, m.confirmed...)
+		if len(m.confirmed) > mempoolConfirmedHistory {
+			m.confirmed = m.confirmed[:mempoolConfirmedHistory]
+		}
+	}
+}
+
+// Snapshot returns up to limit pending transactions, highest fee first,
+// with their ages as of now
+func (m *MempoolTracker) Snapshot(limit int, now time.Time) *MempoolSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := &MempoolSnapshot{
+		LastPoll:          m.lastPoll,
+		Error:             m.lastError,
+		Transactions:      []PendingTransaction{},
+		RecentlyConfirmed: append([]PendingTransaction{}, m.confirmed...),
+	}
+	for _, entry := range m.pending {
+		tx := entry.tx
+		tx.AgeSeconds = now.Sub(tx.FirstSeen).Seconds()
+		snapshot.Count++
+		snapshot.TotalBytes += tx.Size
+		snapshot.TotalFees += tx.Fee
+		snapshot.Transactions = append(snapshot.Transactions, tx)
+	}
+
+	sort.Slice(snapshot.Transactions, func(i, j int) bool {
+		a, b := snapshot.Transactions[i], snapshot.Transactions[j]
+		if a.Fee != b.Fee {
+			return a.Fee > b.Fee
+		}
+		if !a.FirstSeen.Equal(b.FirstSeen) {
+			return a.FirstSeen.Before(b.FirstSeen)
+		}
+		return a.TxHash < b.TxHash
+	})
+	if len(snapshot.Transactions) > limit {
+		snapshot.Transactions = snapshot.Transactions[:limit]
+	}
+	return snapshot
+}
+
+// Lookup finds a pending or recently confirmed transaction
+func (m *MempoolTracker) Lookup(hash string, now time.Time) (PendingTransaction, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if entry, ok := m.pending[hash]; ok {
+		tx := entry.tx
+		tx.AgeSeconds = now.Sub(tx.FirstSeen).Seconds()
+		return tx, true
+	}
+	for _, tx := range m.confirmed {
+		if tx.TxHash == hash {
+			return tx, true
+		}
+	}
+	return PendingTransaction{}, false
+}
+
+// Mempool endpoint: pending transactions with fee, size and age
+func (es *ExplorerServer) handleMempool(w http.ResponseWriter, r *http.Request) {
+	if es.mempool == nil {
+		http.Error(w, "Mempool tracking is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 1000 {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(es.mempool.Snapshot(limit, time.Now()))
+}
+
+// Mempool transaction endpoint: whether a transaction is still pending or
+// which block confirmed it
+func (es *ExplorerServer) handleMempoolTransaction(w http.ResponseWriter, r *http.Request) {
+	if es.mempool == nil {
+		http.Error(w, "Mempool tracking is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	tx, ok := es.mempool.Lookup(mux.Vars(r)["hash"], time.Now())
+	if !ok {
+		http.Error(w, "Transaction not seen in the mempool", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tx)
+}
+
+// Mempool page, reloading every few seconds
+func (es *ExplorerServer) handleMempoolPage(w http.ResponseWriter, r *http.Request) {
+	var snapshot *MempoolSnapshot
+	if es.mempool != nil {
+		snapshot = es.mempool.Snapshot(100, time.Now())
+	}
+	es.renderPage(w, r, http.StatusOK, "mempool", page{Title: "Mempool", Refresh: 5, Data: snapshot})
+}
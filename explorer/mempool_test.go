@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// newFakeMempool serves the node's mempool listing; set replaces what it lists
+func newFakeMempool(t *testing.T) (*httptest.Server, func(txs ...map[string]interface{})) {
+	var mu sync.Mutex
+	listed := []map[string]interface{}{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/mempool/transactions" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"transactions": listed, "count": len(listed)})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func(txs ...map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		listed = txs
+	}
+}
+
+func fakeMempoolTx(hash string, fee uint64, size int) map[string]interface{} {
+	return map[string]interface{}{
+		"tx_hash": hash,
+		"fee":     fee,
+		"size":    size,
+		"transaction": map[string]interface{}{
+			"tx_hash":     hash,
+			"transaction": map[string]interface{}{"outputs": []map[string]interface{}{{"value": 250, "address": "bob"}}},
+		},
+	}
+}
+
+func TestMempoolTrackerPromotesConfirmedTransactions(t *testing.T) {
+	node, set := newFakeMempool(t)
+	tracker := NewMempoolTracker(node.URL, newTestNodeClient(), time.Hour)
+
+	set(fakeMempoolTx("low", 1, 300), fakeMempoolTx("high", 9, 500))
+	if err := tracker.Poll(); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	first, _ := tracker.Lookup("high", time.Now())
+
+	// Polling again keeps the original first-seen time
+	if err := tracker.Poll(); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	snapshot := tracker.Snapshot(10, time.Now())
+	if snapshot.Count != 2 || snapshot.TotalBytes != 800 || snapshot.TotalFees != 10 {
+		t.Fatalf("Unexpected snapshot %+v", snapshot)
+	}
+	if tx := snapshot.Transactions[0]; tx.TxHash != "high" || !tx.FirstSeen.Equal(first.FirstSeen) ||
+		tx.Amount != 250 || tx.ToAddress != "bob" {
+		t.Errorf("Expected the higher fee first with its original first-seen time, got %+v", tx)
+	}
+
+	block := &Block{
+		Header: BlockHeader{Height: 7, Timestamp: first.FirstSeen.Add(30 * time.Second)},
+		Body:   BlockBody{Transactions: []SignedTransaction{{TxHash: "high"}, {TxHash: "unrelated"}}},
+	}
+	tracker.Confirm(block)
+
+	tx, ok := tracker.Lookup("high", time.Now())
+	if !ok || tx.Status != "confirmed" || tx.BlockHeight == nil || *tx.BlockHeight != 7 || tx.WaitSeconds != 30 {
+		t.Errorf("Expected high confirmed at 7 after 30s, got %+v", tx)
+	}
+
+	// The node may still list it until it processes the block
+	if err := tracker.Poll(); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	snapshot = tracker.Snapshot(10, time.Now())
+	if snapshot.Count != 1 || snapshot.Transactions[0].TxHash != "low" || len(snapshot.RecentlyConfirmed) != 1 {
+		t.Errorf("Expected only low pending and high confirmed, got %+v", snapshot)
+	}
+}
+
+func TestMempoolTrackerDropsVanishedTransactions(t *testing.T) {
+	tracker := NewMempoolTracker("", newTestNodeClient(), time.Hour)
+	start := time.Now()
+
+	tracker.merge([]PendingTransaction{{TxHash: "gone"}, {TxHash: "kept"}}, start)
+	tracker.merge([]PendingTransaction{{TxHash: "kept"}}, start.Add(mempoolDropAfter/2))
+	tracker.merge([]PendingTransaction{{TxHash: "kept"}}, start.Add(mempoolDropAfter+time.Second))
+
+	if _, ok := tracker.pending["gone"]; ok {
+		t.Error("Expected a transaction missing for longer than the grace period to be dropped")
+	}
+	if _, ok := tracker.pending["kept"]; !ok {
+		t.Error("Expected a still listed transaction to stay pending")
+	}
+}
+
+func TestMempoolAPI(t *testing.T) {
+	node, set := newFakeMempool(t)
+	set(fakeMempoolTx("abc", 5, 100))
+	es := &ExplorerServer{mempool: NewMempoolTracker(node.URL, newTestNodeClient(), time.Hour)}
+	es.mempool.Poll()
+
+	rec := httptest.NewRecorder()
+	es.handleMempool(rec, httptest.NewRequest("GET", "/api/v1/mempool", nil))
+	var snapshot MempoolSnapshot
+	if err := json.NewDecoder(rec.Body).Decode(&snapshot); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if snapshot.Count != 1 || snapshot.Transactions[0].Status != "pending" {
+		t.Errorf("Unexpected snapshot %+v", snapshot)
+	}
+
+	rec = httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/mempool/nope", nil), map[string]string{"hash": "nope"})
+	es.handleMempoolTransaction(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unseen transaction, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	(&ExplorerServer{}).handleMempool(rec, httptest.NewRequest("GET", "/api/v1/mempool", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a tracker, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// requestDurationBuckets are the latency histogram's upper bounds in seconds
+var requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type requestKey struct {
+	route  string
+	method string
+	code   int
+}
+
+type requestHistogram struct {
+	counts []uint64 // Per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+// HTTPMetrics records request latencies per route for /metrics
+type HTTPMetrics struct {
+	mu       sync.Mutex
+	requests map[requestKey]*requestHistogram
+}
+
+// NewHTTPMetrics creates an empty request recorder
+func NewHTTPMetrics() *HTTPMetrics {
+	return &HTTPMetrics{requests: make(map[requestKey]*requestHistogram)}
+}
+
+// Observe records one request served by route
+func (m *HTTPMetrics) Observe(route, method string, code int, duration time.Duration) {
+	key := requestKey{route: route, method: method, code: code}
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.requests[key]
+	if !ok {
+		h = &requestHistogram{counts: make([]uint64, len(requestDurationBuckets)+1)}
+		m.requests[key] = h
+	}
+	bucket := sort.SearchFloat64s(requestDurationBuckets, seconds)
+	h.counts[bucket]++
+	h.sum += seconds
+	h.count++
+}
+
+// statusRecorder remembers the status a handler wrote, passing flushes
+// through for event streams
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Middleware times every routed request under its route template, so
+// /wallet/{address} is one series however many addresses are looked up
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		m.Observe(route, r.Method, recorder.status, time.Since(start))
+	})
+}
+
+// writeTo renders the latency histograms in the Prometheus text format
+func (m *HTTPMetrics) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+
+	const name = "shadowy_explorer_http_request_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Time to serve HTTP requests by route\n# TYPE %s histogram\n", name, name)
+	for _, key := range keys {
+		h := m.requests[key]
+		labels := fmt.Sprintf(`route="%s",method="%s",code="%d"`, escapeLabel(key.route), key.method, key.code)
+
+		var cumulative uint64
+		for i, bound := range requestDurationBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", name, labels, h.sum)
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// Metrics endpoint: sync progress, database size and request latencies in
+// the Prometheus text format
+func (es *ExplorerServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	metric := func(kind, name, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name,
+			strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	gauge := func(name, help string, value float64) { metric("gauge", name, help, value) }
+	counter := func(name, help string, value float64) { metric("counter", name, help, value) }
+	boolValue := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	if height, err := es.database.GetLatestHeight(); err == nil {
+		gauge("shadowy_explorer_indexed_height", "Height of the last indexed block", float64(height))
+	}
+	if size, err := es.database.DiskUsage(); err == nil {
+		gauge("shadowy_explorer_db_size_bytes", "On-disk size of the explorer database", float64(size))
+	}
+	if es.dbHealth != nil {
+		gauge("shadowy_explorer_db_healthy", "Whether the database passed the last health check", boolValue(es.dbHealth.Status().Healthy))
+	}
+
+	if es.syncService != nil {
+		status := es.syncService.Status()
+		var lag uint64
+		if status.RemoteHeight > status.CurrentHeight {
+			lag = status.RemoteHeight - status.CurrentHeight
+		}
+		gauge("shadowy_explorer_node_height", "Chain height last reported by the node", float64(status.RemoteHeight))
+		gauge("shadowy_explorer_sync_lag_blocks", "Blocks the index is behind the node", float64(lag))
+		if !status.LastSync.IsZero() {
+			gauge("shadowy_explorer_last_sync_timestamp_seconds", "Unix time the last sync cycle completed", float64(status.LastSync.Unix()))
+		}
+		gauge("shadowy_explorer_sync_paused", "Whether block ingestion is paused", boolValue(status.Paused))
+		counter("shadowy_explorer_sync_errors_total", "Sync cycles or blocks that failed", float64(status.ErrorCount))
+		counter("shadowy_explorer_reorgs_total", "Chain reorganizations rolled back", float64(status.ReorgCount))
+	}
+
+	if es.mempool != nil {
+		snapshot := es.mempool.Snapshot(0, time.Now())
+		gauge("shadowy_explorer_mempool_transactions", "Pending transactions seen in the node's mempool", float64(snapshot.Count))
+	}
+
+	if es.metrics != nil {
+		es.metrics.writeTo(w)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHTTPMetricsHistogram(t *testing.T) {
+	metrics := NewHTTPMetrics()
+	metrics.Observe("/api/v1/stats", "GET", 200, 3*time.Millisecond)
+	metrics.Observe("/api/v1/stats", "GET", 200, 300*time.Millisecond)
+	metrics.Observe("/api/v1/stats", "GET", 200, 30*time.Second)
+
+	var out strings.Builder
+	metrics.writeTo(&out)
+	labels := `route="/api/v1/stats",method="GET",code="200"`
+	for _, want := range []string{
+		`_bucket{` + labels + `,le="0.005"} 1`,
+		`_bucket{` + labels + `,le="0.25"} 1`,
+		`_bucket{` + labels + `,le="0.5"} 2`,
+		`_bucket{` + labels + `,le="10"} 2`,
+		`_bucket{` + labels + `,le="+Inf"} 3`,
+		`_count{` + labels + `} 3`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %s in\n%s", want, out.String())
+		}
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 0, 4)
+	svc := NewSyncService("", newTestNodeClient(), database)
+	svc.currentHeight, svc.remoteHeight = 4, 10
+	svc.recordError(errReorgDetected)
+
+	es := &ExplorerServer{database: database, syncService: svc, metrics: NewHTTPMetrics()}
+	router := mux.NewRouter()
+	router.Use(es.metrics.Middleware)
+	router.HandleFunc("/wallet/{address}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "missing", http.StatusNotFound)
+	})
+	router.HandleFunc("/metrics", es.handleMetrics)
+
+	for _, path := range []string{"/wallet/alice", "/wallet/bob"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"shadowy_explorer_indexed_height 4\n",
+		"shadowy_explorer_node_height 10\n",
+		"shadowy_explorer_sync_lag_blocks 6\n",
+		"shadowy_explorer_sync_errors_total 1\n",
+		"# TYPE shadowy_explorer_db_size_bytes gauge",
+		`shadowy_explorer_http_request_duration_seconds_count{route="/wallet/{address}",method="GET",code="404"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in\n%s", want, body)
+		}
+	}
+}
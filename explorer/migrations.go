@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Changes to the format of existing keys, and indexes added since a
+// database was first indexed, are migrated when the database is opened.
+// Each migration runs once, recorded under its marker key, and is safe to
+// run again if it was interrupted. Deleting a marker has the migration run
+// again the next time migrate is called.
+const (
+	addressTxKeysMarker       = "index:addr_tx_padded"
+	heightIndexMarker         = "index:at_height"
+	poolTokenMarker           = "index:pool_token_sized"
+	tokenActivityMarker       = "index:token_addr"
+	firstSeenIndexMarker      = "index:first_seen"
+	balanceHistoryIndexMarker = "index:balance_history"
+	minedBlockIndexMarker     = "index:mined_blocks"
+	balanceIndexMarker        = "index:balances"
+	tokenHolderIndexMarker    = "index:token_holders"
+)
+
+// databaseMigration rewrites stored keys into the format the current code
+// reads, or builds an index from the data already stored
+type databaseMigration struct {
+	marker string
+	run    func(d *Database) (int, error) // Returns how many keys changed
+}
+
+var databaseMigrations = []databaseMigration{
+	{marker: addressTxKeysMarker, run: (*Database).padAddressTxKeys},
+	{marker: heightIndexMarker, run: (*Database).buildHeightIndex},
+	{marker: poolTokenMarker, run: (*Database).buildPoolTokenIndex},
+	{marker: tokenActivityMarker, run: (*Database).buildTokenActivityIndex},
+	{marker: firstSeenIndexMarker, run: (*Database).buildFirstSeenIndex},
+	{marker: balanceHistoryIndexMarker, run: (*Database).buildBalanceHistory},
+	{marker: minedBlockIndexMarker, run: (*Database).buildMinedBlockIndex},
+	{marker: balanceIndexMarker, run: (*Database).buildBalanceIndex},
+	{marker: tokenHolderIndexMarker, run: (*Database).buildTokenHolderIndex},
+}
+
+// migrate runs the migrations this database has not had yet
+func (d *Database) migrate() error {
+	for _, migration := range databaseMigrations {
+		done := false
+		if err := d.view(func(txn *badger.Txn) error {
+			_, err := txn.Get([]byte(migration.marker))
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			done = err == nil
+			return err
+		}); err != nil {
+			return err
+		}
+		if done {
+			continue
+		}
+
+		changed, err := migration.run(d)
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration.marker, err)
+		}
+		if err := d.update(func(txn *badger.Txn) error {
+			return txn.Set([]byte(migration.marker), []byte(time.Now().UTC().Format(time.RFC3339)))
+		}); err != nil {
+			return err
+		}
+		if changed > 0 {
+			slog.Info("Database migrated", "migration", migration.marker, "keys", changed)
+		}
+	}
+	return nil
+}
+
+// padAddressTxKeys rewrites addr_tx:<address>:<height>:<hash> keys written
+// with an unpadded height, which sort out of height order, to the
+// zero-padded form the cursor pagination relies on
+func (d *Database) padAddressTxKeys() (int, error) {
+	type rename struct {
+		from, to []byte
+		value    []byte
+	}
+	var renames []rename
+	err := d.view(func(txn *badger.Txn) error {
+		_, err := collectKeys(txn, "addr_tx:", func(key string, val []byte) bool {
+			parts := strings.Split(strings.TrimPrefix(key, "addr_tx:"), ":")
+			if len(parts) != 3 || len(parts[1]) == 16 {
+				return false
+			}
+			height, err := strconv.ParseUint(parts[1], 10, 64)
+			if err != nil {
+				return false
+			}
+			renames = append(renames, rename{
+				from:  []byte(key),
+				to:    []byte(fmt.Sprintf("addr_tx:%s:%016d:%s", parts[0], height, parts[2])),
+				value: val,
+			})
+			return false
+		})
+		return err
+	})
+	if err != nil || len(renames) == 0 {
+		return 0, err
+	}
+
+	err = d.writeBatch(func(batch *badger.WriteBatch) error {
+		for _, r := range renames {
+			if err := batch.Set(r.to, r.value); err != nil {
+				return err
+			}
+			if err := batch.Delete(r.from); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(renames), err
+}
+
+// heightIndexed are the records listed in the height index, with the
+// height each belongs to
+var heightIndexed = []struct {
+	prefix string
+	height func(val []byte) (uint64, bool)
+}{
+	{"tx:", func(val []byte) (uint64, bool) {
+		var tx WalletTransaction
+		return tx.BlockHeight, json.Unmarshal(val, &tx) == nil
+	}},
+	{"token_tx:", func(val []byte) (uint64, bool) {
+		var tx TokenTransaction
+		return tx.BlockHeight, json.Unmarshal(val, &tx) == nil
+	}},
+	{meltPrefix, func(val []byte) (uint64, bool) {
+		var melt MeltEvent
+		return melt.BlockHeight, json.Unmarshal(val, &melt) == nil
+	}},
+	{"token:", func(val []byte) (uint64, bool) {
+		var token TokenInfo
+		return token.CreationBlock, json.Unmarshal(val, &token) == nil
+	}},
+	{"pool_tx:", func(val []byte) (uint64, bool) {
+		var tx PoolTransaction
+		return tx.BlockHeight, json.Unmarshal(val, &tx) == nil
+	}},
+	{"pool:", func(val []byte) (uint64, bool) {
+		var pool LiquidityPool
+		return pool.CreationBlock, json.Unmarshal(val, &pool) == nil
+	}},
+}
+
+// buildHeightIndex lists every stored transaction, token and pool under
+// the height of its block, for databases indexed before rollbacks used the
+// height index
+func (d *Database) buildHeightIndex() (int, error) {
+	indexed := 0
+	err := d.writeBatch(func(batch *badger.WriteBatch) error {
+		// writeBatch holds the handle, so read from it directly
+		return d.db.View(func(txn *badger.Txn) error {
+			var setErr error
+			for _, family := range heightIndexed {
+				_, err := collectKeys(txn, family.prefix, func(key string, val []byte) bool {
+					if height, ok := family.height(val); ok && setErr == nil {
+						if setErr = batch.Set(atHeightKey(height, key), nil); setErr == nil {
+							indexed++
+						}
+					}
+					return false
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return setErr
+		})
+	})
+	return indexed, err
+}
+
+// buildPoolTokenIndex indexes every stored pool under its tokens' ids and
+// symbols, for databases with pools stored before pools were found by token
+// or before the token in the key was length prefixed. The index is rebuilt
+// from scratch, as keys in the old format cannot be told from new ones.
+func (d *Database) buildPoolTokenIndex() (int, error) {
+	indexed := 0
+	err := d.writeBatch(func(batch *badger.WriteBatch) error {
+		// writeBatch holds the handle, so read from it directly
+		return d.db.View(func(txn *badger.Txn) error {
+			stale, err := collectKeys(txn, "pool_token:", func(string, []byte) bool { return true })
+			if err != nil {
+				return err
+			}
+			for _, key := range stale {
+				if err := batch.Delete(key); err != nil {
+					return err
+				}
+			}
+
+			var setErr error
+			_, err = collectKeys(txn, "pool:", func(key string, val []byte) bool {
+				var pool LiquidityPool
+				if setErr != nil || json.Unmarshal(val, &pool) != nil {
+					return false
+				}
+				for _, token := range poolTokenKeys(&pool) {
+					if setErr = batch.Set(poolTokenKey(token, pool.PoolID), []byte(pool.PoolID)); setErr != nil {
+						return false
+					}
+					indexed++
+				}
+				return false
+			})
+			if err != nil {
+				return err
+			}
+			return setErr
+		})
+	})
+	return indexed, err
+}
+
+// buildTokenActivityIndex replays the stored token transactions into the
+// per-address token activity index, for databases with token transactions
+// stored before it. An entry is only replaced when the replay counts more
+// transactions, so activity whose transactions were pruned since is kept.
+func (d *Database) buildTokenActivityIndex() (int, error) {
+	replayed := make(map[string]*TokenActivity)
+	existing := make(map[string]int)
+	err := d.view(func(txn *badger.Txn) error {
+		if _, err := collectKeys(txn, "token_addr:", func(key string, val []byte) bool {
+			var activity TokenActivity
+			if json.Unmarshal(val, &activity) == nil {
+				existing[key] = activity.TxCount
+			}
+			return false
+		}); err != nil {
+			return err
+		}
+
+		_, err := collectKeys(txn, "token_tx:", func(key string, val []byte) bool {
+			tokenID, _, ok := strings.Cut(strings.TrimPrefix(key, "token_tx:"), ":")
+			var tx TokenTransaction
+			if !ok || json.Unmarshal(val, &tx) != nil {
+				return false
+			}
+			for _, address := range tokenActivityAddresses(&tx) {
+				activityKey := fmt.Sprintf("token_addr:%s:%s", address, tokenID)
+				activity, ok := replayed[activityKey]
+				if !ok {
+					activity = &TokenActivity{TokenID: tokenID}
+					replayed[activityKey] = activity
+				}
+				activity.add(address, &tx)
+			}
+			return false
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	err = d.writeBatch(func(batch *badger.WriteBatch) error {
+		for key, activity := range replayed {
+			if count, ok := existing[key]; ok && count >= activity.TxCount {
+				continue
+			}
+			data, err := json.Marshal(activity)
+			if err != nil {
+				return err
+			}
+			if err := batch.Set([]byte(key), data); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
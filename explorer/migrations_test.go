@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestMigrationPadsLegacyAddressTxKeys(t *testing.T) {
+	dir := t.TempDir()
+	database, err := NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	for _, height := range []uint64{9, 10, 100, 2} {
+		tx := &WalletTransaction{TxHash: fmt.Sprintf("tx_%d", height), BlockHeight: height, Type: "transfer", Amount: height, FromAddress: "alice", ToAddress: "bob"}
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store transaction: %v", err)
+		}
+	}
+
+	// Rewrite the index the way older versions stored it
+	err = database.update(func(txn *badger.Txn) error {
+		keys, err := collectKeys(txn, "addr_tx:", func(string, []byte) bool { return true })
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			parts := strings.Split(string(key), ":")
+			var height uint64
+			fmt.Sscanf(parts[2], "%d", &height)
+			legacy := fmt.Sprintf("addr_tx:%s:%d:%s", parts[1], height, parts[3])
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+			if err := txn.Set([]byte(legacy), []byte(parts[3])); err != nil {
+				return err
+			}
+		}
+		return txn.Delete([]byte(addressTxKeysMarker))
+	})
+	if err != nil {
+		t.Fatalf("Failed to write legacy keys: %v", err)
+	}
+	database.Close()
+
+	database, err = NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer database.Close()
+
+	page, err := database.GetWalletTransactionsPage("bob", "", 1, 10)
+	if err != nil {
+		t.Fatalf("GetWalletTransactionsPage failed: %v", err)
+	}
+	var heights []uint64
+	for _, tx := range page.Transactions {
+		heights = append(heights, tx.BlockHeight)
+	}
+	if fmt.Sprint(heights) != "[100 10 9 2]" {
+		t.Errorf("Expected migrated keys newest first, got %v", heights)
+	}
+
+	database.view(func(txn *badger.Txn) error {
+		keys, _ := collectKeys(txn, "addr_tx:", func(key string, _ []byte) bool {
+			return len(strings.Split(key, ":")[2]) != 16
+		})
+		if len(keys) != 0 {
+			t.Errorf("Expected no unpadded keys left, got %q", keys)
+		}
+		return nil
+	})
+}
+
+func TestMigrationIndexesPoolsByToken(t *testing.T) {
+	dir := t.TempDir()
+	database, err := NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	storeTestPools(t, database)
+
+	// Drop the index the way databases from before it lack it
+	err = database.update(func(txn *badger.Txn) error {
+		keys, err := collectKeys(txn, "pool_token:", func(string, []byte) bool { return true })
+		if err != nil {
+			return err
+		}
+		if err := deleteKeys(txn, keys); err != nil {
+			return err
+		}
+		// A key from before tokens were length prefixed
+		if err := txn.Set([]byte("pool_token:gold:pool_gold_silver"), []byte("pool_gold_silver")); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(poolTokenMarker))
+	})
+	if err != nil {
+		t.Fatalf("Failed to drop the index: %v", err)
+	}
+	if pools, _ := database.GetPoolsByToken("gold", 1, 10); pools.TotalPools != 0 {
+		t.Fatalf("Expected no pools without the index, got %d", pools.TotalPools)
+	}
+	database.Close()
+
+	database, err = NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer database.Close()
+
+	for token, want := range map[string]int64{"gold": 2, "tok_copper": 2, "shadow": 1} {
+		pools, err := database.GetPoolsByToken(token, 1, 10)
+		if err != nil || pools.TotalPools != want {
+			t.Errorf("Expected %d pools for %s after the migration, got %+v (%v)", want, token, pools, err)
+		}
+	}
+	database.view(func(txn *badger.Txn) error {
+		if _, err := txn.Get([]byte("pool_token:gold:pool_gold_silver")); err != badger.ErrKeyNotFound {
+			t.Errorf("Expected the old format key removed, got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestMigrationBuildsTokenActivity(t *testing.T) {
+	dir := t.TempDir()
+	database, err := NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	start := time.Unix(1700000000, 0).UTC()
+	txs := []*TokenTransaction{
+		{TxHash: "t1", BlockHeight: 1, Timestamp: start, Type: "create", Amount: 100, ToAddress: "alice"},
+		{TxHash: "t2", BlockHeight: 2, Timestamp: start.Add(time.Minute), Type: "transfer", Amount: 40, FromAddress: "alice", ToAddress: "bob"},
+		{TxHash: "t3", BlockHeight: 3, Timestamp: start.Add(2 * time.Minute), Type: "transfer", Amount: 10, FromAddress: "bob", ToAddress: "alice"},
+	}
+	for _, tx := range txs {
+		if err := database.StoreTokenTransaction("0a0a0a0a0a0a0a0a", tx); err != nil {
+			t.Fatalf("Failed to store token transaction: %v", err)
+		}
+	}
+
+	// As if the index only started with the last transaction
+	err = database.update(func(txn *badger.Txn) error {
+		keys, err := collectKeys(txn, "token_addr:", func(string, []byte) bool { return true })
+		if err != nil {
+			return err
+		}
+		if err := deleteKeys(txn, keys); err != nil {
+			return err
+		}
+		if err := updateTokenActivity(txn, "0a0a0a0a0a0a0a0a", txs[2]); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(tokenActivityMarker))
+	})
+	if err != nil {
+		t.Fatalf("Failed to drop the index: %v", err)
+	}
+	database.Close()
+
+	database, err = NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer database.Close()
+
+	alice, err := database.GetTokenActivity("alice")
+	if err != nil || len(alice) != 1 {
+		t.Fatalf("Expected alice's activity, got %+v (%v)", alice, err)
+	}
+	if a := alice[0]; a.Created != 1 || a.Sent != 1 || a.Received != 1 || a.TxCount != 3 || !a.FirstActivity.Equal(start) {
+		t.Errorf("Unexpected activity for alice %+v", a)
+	}
+	if bob, _ := database.GetTokenActivity("bob"); len(bob) != 1 || bob[0].TxCount != 2 {
+		t.Errorf("Unexpected activity for bob %+v", bob)
+	}
+}
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+const (
+	nftMetaPrefix  = "nft:meta:"
+	nftImagePrefix = "nft:image:"
+
+	defaultIPFSGateway    = "https://ipfs.io/ipfs/"
+	defaultArweaveGateway = "https://arweave.net/"
+
+	nftResolveInterval = 30 * time.Second
+	nftResolveBatch    = 20
+
+	maxNFTMetadataBytes = 256 << 10
+	maxNFTImageBytes    = 4 << 20
+
+	// Failed fetches are retried after 1m, 2m, 4m, ... up to 8 attempts,
+	// about four hours in all
+	nftRetryBase   = time.Minute
+	nftMaxAttempts = 8
+)
+
+// isNFT reports whether a token is non-fungible: a single indivisible unit,
+// or a token pointing at metadata
+func isNFT(token *TokenInfo) bool {
+	return token.URI != "" || (token.TotalSupply == 1 && token.Decimals == 0)
+}
+
+// NFTResolver fetches and caches the metadata document and image behind
+// each NFT's URI in the background
+type NFTResolver struct {
+	database       Store
+	client         *http.Client
+	interval       time.Duration
+	ipfsGateway    string
+	arweaveGateway string
+	jobs           *JobQueue // Runs each pass with work due as a job when set
+
+	stopCh chan struct{}
+}
+
+// NewNFTResolver creates a resolver. URIs on private and loopback
+// addresses are refused unless allowPrivate is set.
+func NewNFTResolver(database Store, allowPrivate bool) *NFTResolver {
+	return &NFTResolver{
+		database:       database,
+		client:         newOutboundClient(20*time.Second, allowPrivate),
+		interval:       nftResolveInterval,
+		ipfsGateway:    defaultIPFSGateway,
+		arweaveGateway: defaultArweaveGateway,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// newNFTResolverFromEnv creates the resolver with the gateways from
+// EXPLORER_IPFS_GATEWAY and EXPLORER_ARWEAVE_GATEWAY
+func newNFTResolverFromEnv(database Store) *NFTResolver {
+	resolver := NewNFTResolver(database, os.Getenv("EXPLORER_NFT_ALLOW_PRIVATE") == "true")
+	if gateway := os.Getenv("EXPLORER_IPFS_GATEWAY"); gateway != "" {
+		resolver.ipfsGateway = strings.TrimSuffix(gateway, "/") + "/"
+	}
+	if gateway := os.Getenv("EXPLORER_ARWEAVE_GATEWAY"); gateway != "" {
+		resolver.arweaveGateway = strings.TrimSuffix(gateway, "/") + "/"
+	}
+	return resolver
+}
+
+// Start queues NFTs indexed before the resolver ran, resolves what is due,
+// and keeps resolving in the background
+func (nr *NFTResolver) Start() {
+	nr.discover()
+	nr.ResolveDue(time.Now())
+
+	go func() {
+		ticker := time.NewTicker(nr.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				nr.ResolveDue(time.Now())
+			case <-nr.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops background resolution
+func (nr *NFTResolver) Stop() {
+	close(nr.stopCh)
+}
+
+// discover tracks every stored NFT that has no metadata record yet
+func (nr *NFTResolver) discover() {
+	for page := 1; ; page++ {
+		tokens, err := nr.database.GetTokens(page, 100, "")
+		if err != nil {
+			slog.Error("Failed to list tokens for NFT metadata", "err", err)
+			return
+		}
+		for i := range tokens.Tokens {
+			nr.Track(&tokens.Tokens[i])
+		}
+		if page >= tokens.TotalPages {
+			return
+		}
+	}
+}
+
+// Track queues a newly indexed token for resolution if it is an NFT that
+// has not been seen before. It is a no-op on a nil resolver.
+func (nr *NFTResolver) Track(token *TokenInfo) {
+	if nr == nil || !isNFT(token) {
+		return
+	}
+	if _, err := nr.database.GetNFTMetadata(token.TokenID); err != errNotFound {
+		return
+	}
+
+	meta := &NFTMetadata{TokenID: token.TokenID, Creator: token.Creator, URI: token.URI, Status: "pending"}
+	if token.URI == "" {
+		meta.Status = "failed"
+		meta.Error = "token has no metadata URI"
+	}
+	if err := nr.database.StoreNFTMetadata(meta); err != nil {
+		slog.Error("Failed to queue NFT for metadata", "token_id", token.TokenID, "err", err)
+	}
+}
+
+// ResolveDue fetches the metadata of pending NFTs whose next attempt is
+// due, as a job when the resolver has a job queue
+func (nr *NFTResolver) ResolveDue(now time.Time) {
+	due, err := nr.database.DueNFTMetadata(now, nftResolveBatch)
+	if err != nil {
+		slog.Error("Failed to load pending NFT metadata", "err", err)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+	if nr.jobs == nil {
+		nr.resolveAll(context.Background(), due, now, func(done, total uint64) {})
+		return
+	}
+	_, err = nr.jobs.Submit("nft-metadata", map[string]string{"tokens": strconv.Itoa(len(due))},
+		func(ctx context.Context, progress func(done, total uint64)) (interface{}, error) {
+			return nil, nr.resolveAll(ctx, due, now, progress)
+		})
+	if err != nil && err != errJobRunning {
+		slog.Error("Failed to queue NFT metadata fetches", "err", err)
+	}
+}
+
+// resolveAll resolves each due NFT in turn, stopping early once ctx is done
+func (nr *NFTResolver) resolveAll(ctx context.Context, due []NFTMetadata, now time.Time, progress func(done, total uint64)) error {
+	for i := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		nr.resolve(&due[i], now)
+		progress(uint64(i+1), uint64(len(due)))
+	}
+	return nil
+}
+
+// resolve fetches one NFT's metadata and image, rescheduling on failure
+func (nr *NFTResolver) resolve(meta *NFTMetadata, now time.Time) {
+	err := nr.fetchMetadata(meta)
+	if err == nil && meta.Image != "" {
+		if imageErr := nr.fetchImage(meta); imageErr != nil {
+			// The metadata stands on its own; the original image link is
+			// still returned
+			slog.Warn("Failed to cache NFT image", "token_id", meta.TokenID, "err", imageErr)
+		}
+	}
+
+	meta.Attempts++
+	if err == nil {
+		meta.Status = "resolved"
+		meta.FetchedAt = now
+		meta.NextAttempt = time.Time{}
+		meta.Error = ""
+	} else {
+		meta.Error = err.Error()
+		if meta.Attempts >= nftMaxAttempts {
+			meta.Status = "failed"
+			meta.NextAttempt = time.Time{}
+		} else {
+			meta.NextAttempt = now.Add(nftRetryBase << (meta.Attempts - 1))
+		}
+	}
+	if err := nr.database.StoreNFTMetadata(meta); err != nil {
+		slog.Error("Failed to store NFT metadata", "token_id", meta.TokenID, "err", err)
+	}
+}
+
+// resolveURI maps ipfs:// and ar:// URIs onto their gateways
+func (nr *NFTResolver) resolveURI(uri string) (string, error) {
+	switch {
+	case strings.HasPrefix(uri, "ipfs://"):
+		path := strings.TrimPrefix(strings.TrimPrefix(uri, "ipfs://"), "ipfs/")
+		return nr.ipfsGateway + path, nil
+	case strings.HasPrefix(uri, "ar://"):
+		return nr.arweaveGateway + strings.TrimPrefix(uri, "ar://"), nil
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("unsupported URI %q", uri)
+	}
+	return uri, nil
+}
+
+// fetch reads a resolved URI or an inline data: URI, up to limit bytes
+func (nr *NFTResolver) fetch(uri string, limit int64) ([]byte, string, error) {
+	if strings.HasPrefix(uri, "data:") {
+		return decodeDataURI(uri, limit)
+	}
+
+	target, err := nr.resolveURI(uri)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := nr.client.Get(target)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s returned status %d", target, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > limit {
+		return nil, "", fmt.Errorf("%s is larger than %d bytes", target, limit)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// decodeDataURI decodes data:[<type>][;base64],<data>
+func decodeDataURI(uri string, limit int64) ([]byte, string, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, "", fmt.Errorf("malformed data URI")
+	}
+	contentType := strings.TrimSuffix(header, ";base64")
+
+	var data []byte
+	if strings.HasSuffix(header, ";base64") {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(payload); err != nil {
+			return nil, "", fmt.Errorf("malformed data URI: %w", err)
+		}
+	} else {
+		unescaped, err := url.PathUnescape(payload)
+		if err != nil {
+			return nil, "", fmt.Errorf("malformed data URI: %w", err)
+		}
+		data = []byte(unescaped)
+	}
+	if int64(len(data)) > limit {
+		return nil, "", fmt.Errorf("data URI is larger than %d bytes", limit)
+	}
+	return data, contentType, nil
+}
+
+// fetchMetadata reads the metadata document and picks out the fields of
+// the common NFT metadata standards
+func (nr *NFTResolver) fetchMetadata(meta *NFTMetadata) error {
+	data, _, err := nr.fetch(meta.URI, maxNFTMetadataBytes)
+	if err != nil {
+		return err
+	}
+
+	var document struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Image       string          `json:"image"`
+		ImageURL    string          `json:"image_url"`
+		ExternalURL string          `json:"external_url"`
+		Attributes  json.RawMessage `json:"attributes"`
+		Traits      json.RawMessage `json:"traits"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("metadata is not a JSON object: %w", err)
+	}
+
+	meta.Name = document.Name
+	meta.Description = document.Description
+	meta.Image = document.Image
+	if meta.Image == "" {
+		meta.Image = document.ImageURL
+	}
+	meta.ExternalURL = document.ExternalURL
+	meta.Attributes = document.Attributes
+	if meta.Attributes == nil {
+		meta.Attributes = document.Traits
+	}
+	meta.Document = json.RawMessage(data)
+	return nil
+}
+
+// fetchImage caches the metadata's image so pages do not hotlink it
+func (nr *NFTResolver) fetchImage(meta *NFTMetadata) error {
+	data, contentType, err := nr.fetch(meta.Image, maxNFTImageBytes)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("image is %s, not an image", contentType)
+	}
+
+	if err := nr.database.StoreNFTImage(meta.TokenID, contentType, data); err != nil {
+		return err
+	}
+	meta.ImageURL = "/api/v1/nft/" + meta.TokenID + "/image"
+	return nil
+}
+
+// nftImageRecord is how a cached image is stored in Badger
+type nftImageRecord struct {
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// StoreNFTMetadata saves an NFT's metadata record
+func (d *Database) StoreNFTMetadata(meta *NFTMetadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal NFT metadata: %w", err)
+	}
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(nftMetaPrefix+meta.TokenID), data)
+	})
+}
+
+// GetNFTMetadata finds an NFT's metadata record
+func (d *Database) GetNFTMetadata(tokenID string) (*NFTMetadata, error) {
+	var meta NFTMetadata
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(nftMetaPrefix + tokenID))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &meta)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// DueNFTMetadata returns up to limit pending records due for a fetch,
+// longest waiting first
+func (d *Database) DueNFTMetadata(now time.Time, limit int) ([]NFTMetadata, error) {
+	var due []NFTMetadata
+	err := d.eachNFTMetadata(func(meta *NFTMetadata) {
+		if meta.Status == "pending" && !meta.NextAttempt.After(now) {
+			due = append(due, *meta)
+		}
+	})
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttempt.Before(due[j].NextAttempt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, err
+}
+
+// ListNFTMetadata returns every NFT's metadata, or a creator's, ordered by
+// creator and token
+func (d *Database) ListNFTMetadata(creator string) ([]NFTMetadata, error) {
+	nfts := []NFTMetadata{}
+	err := d.eachNFTMetadata(func(meta *NFTMetadata) {
+		if creator == "" || meta.Creator == creator {
+			meta.Document = nil
+			nfts = append(nfts, *meta)
+		}
+	})
+	sort.Slice(nfts, func(i, j int) bool {
+		if nfts[i].Creator != nfts[j].Creator {
+			return nfts[i].Creator < nfts[j].Creator
+		}
+		return nfts[i].TokenID < nfts[j].TokenID
+	})
+	return nfts, err
+}
+
+func (d *Database) eachNFTMetadata(fn func(meta *NFTMetadata)) error {
+	return d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(nftMetaPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var meta NFTMetadata
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &meta)
+			}); err != nil {
+				continue
+			}
+			fn(&meta)
+		}
+		return nil
+	})
+}
+
+// StoreNFTImage caches an NFT's image
+func (d *Database) StoreNFTImage(tokenID, contentType string, image []byte) error {
+	data, err := json.Marshal(nftImageRecord{ContentType: contentType, Data: image})
+	if err != nil {
+		return err
+	}
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(nftImagePrefix+tokenID), data)
+	})
+}
+
+// GetNFTImage returns an NFT's cached image and its content type
+func (d *Database) GetNFTImage(tokenID string) (string, []byte, error) {
+	var record nftImageRecord
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(nftImagePrefix + tokenID))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &record)
+		})
+	})
+	return record.ContentType, record.Data, err
+}
+
+// NFT endpoint: an NFT's resolved metadata and current owner
+func (es *ExplorerServer) handleNFT(w http.ResponseWriter, r *http.Request) {
+	tokenID := mux.Vars(r)["tokenId"]
+
+	token, err := es.database.GetToken(tokenID)
+	if err != nil || !isNFT(token) {
+		http.Error(w, "NFT not found", http.StatusNotFound)
+		return
+	}
+	details := NFTDetails{Token: *token}
+	if holders, err := es.database.GetTokenHolders(tokenID, 1); err == nil && len(holders) > 0 {
+		details.Owner = holders[0].Address
+	}
+
+	details.Metadata, err = es.database.GetNFTMetadata(tokenID)
+	if err == errNotFound {
+		details.Metadata = &NFTMetadata{TokenID: tokenID, Creator: token.Creator, URI: token.URI, Status: "pending"}
+	} else if err != nil {
+		http.Error(w, "Failed to load NFT metadata", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// NFT image endpoint: the cached copy of an NFT's image
+func (es *ExplorerServer) handleNFTImage(w http.ResponseWriter, r *http.Request) {
+	contentType, data, err := es.database.GetNFTImage(mux.Vars(r)["tokenId"])
+	if err != nil {
+		http.Error(w, "Image not cached", http.StatusNotFound)
+		return
+	}
+
+	// Images are untrusted; an SVG must not run scripts on our origin
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// NFT collections endpoint: NFTs grouped by creator
+func (es *ExplorerServer) handleNFTCollections(w http.ResponseWriter, r *http.Request) {
+	nfts, err := es.database.ListNFTMetadata(r.URL.Query().Get("creator"))
+	if err != nil {
+		http.Error(w, "Failed to list NFTs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groupNFTCollections(nfts))
+}
+
+// groupNFTCollections groups NFTs, listed by creator, into one collection
+// per creator
+func groupNFTCollections(nfts []NFTMetadata) []NFTCollection {
+	collections := []NFTCollection{}
+	for _, nft := range nfts {
+		if len(collections) == 0 || collections[len(collections)-1].Creator != nft.Creator {
+			collections = append(collections, NFTCollection{Creator: nft.Creator})
+		}
+		last := &collections[len(collections)-1]
+		last.Items = append(last.Items, nft)
+	}
+	return collections
+}
+
+// NFT gallery page
+func (es *ExplorerServer) handleNFTGalleryPage(w http.ResponseWriter, r *http.Request) {
+	nfts, err := es.database.ListNFTMetadata("")
+	if err != nil {
+		http.Error(w, "Failed to list NFTs", http.StatusInternalServerError)
+		return
+	}
+	es.renderPage(w, r, http.StatusOK, "nfts", page{Title: "NFTs", Data: groupNFTCollections(nfts)})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// pngHeader is enough of a PNG for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestNFTResolveURI(t *testing.T) {
+	resolver := NewNFTResolver(newTestDatabase(t), false)
+	for uri, want := range map[string]string{
+		"ipfs://bafy123/meta.json":      "https://ipfs.io/ipfs/bafy123/meta.json",
+		"ipfs://ipfs/bafy123":           "https://ipfs.io/ipfs/bafy123",
+		"ar://tx123":                    "https://arweave.net/tx123",
+		"https://example.com/meta.json": "https://example.com/meta.json",
+	} {
+		if got, err := resolver.resolveURI(uri); err != nil || got != want {
+			t.Errorf("resolveURI(%q) = %q, %v; want %q", uri, got, err, want)
+		}
+	}
+	if _, err := resolver.resolveURI("file:///etc/passwd"); err == nil {
+		t.Error("Expected file URIs to be refused")
+	}
+
+	data, contentType, err := decodeDataURI(`data:application/json;base64,eyJuYW1lIjoiQSJ9`, 100)
+	if err != nil || contentType != "application/json" || string(data) != `{"name":"A"}` {
+		t.Errorf("Unexpected data URI decoding %q %q %v", data, contentType, err)
+	}
+}
+
+func TestNFTResolverFetchesAndCaches(t *testing.T) {
+	database := newTestDatabase(t)
+	failing := true
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case failing:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/meta.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":       "Shadow #1",
+				"image":      server.URL + "/image",
+				"attributes": []map[string]string{{"trait_type": "Color", "value": "Black"}},
+			})
+		case r.URL.Path == "/image":
+			w.Write(pngHeader)
+		}
+	}))
+	defer server.Close()
+
+	token := &TokenInfo{TokenID: "nft1", Name: "Shadow", Creator: "artist", TotalSupply: 1, URI: server.URL + "/meta.json"}
+	database.StoreToken(token)
+	database.UpdateTokenHolder("nft1", "collector", 1)
+	database.StoreToken(&TokenInfo{TokenID: "fungible", TotalSupply: 1000000, Decimals: 6})
+
+	resolver := NewNFTResolver(database, true)
+	resolver.discover()
+	if _, err := database.GetNFTMetadata("fungible"); err != errNotFound {
+		t.Errorf("Fungible tokens must not be tracked, got %v", err)
+	}
+
+	now := time.Now()
+	resolver.ResolveDue(now)
+	meta, _ := database.GetNFTMetadata("nft1")
+	if meta.Status != "pending" || meta.Attempts != 1 || !meta.NextAttempt.Equal(now.Add(nftRetryBase)) {
+		t.Fatalf("Expected a retry in a minute, got %+v", meta)
+	}
+
+	failing = false
+	resolver.ResolveDue(now.Add(nftRetryBase))
+	meta, _ = database.GetNFTMetadata("nft1")
+	if meta.Status != "resolved" || meta.Name != "Shadow #1" || meta.ImageURL != "/api/v1/nft/nft1/image" ||
+		!strings.Contains(string(meta.Attributes), "Black") {
+		t.Fatalf("Unexpected metadata %+v", meta)
+	}
+
+	es := &ExplorerServer{database: database}
+	vars := map[string]string{"tokenId": "nft1"}
+	rec := httptest.NewRecorder()
+	es.handleNFT(rec, mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/nft/nft1", nil), vars))
+	var details NFTDetails
+	json.NewDecoder(rec.Body).Decode(&details)
+	if rec.Code != http.StatusOK || details.Owner != "collector" || details.Metadata.Name != "Shadow #1" {
+		t.Errorf("Unexpected details %d %+v", rec.Code, details)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleNFTImage(rec, mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/nft/nft1/image", nil), vars))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" ||
+		!strings.Contains(rec.Header().Get("Content-Security-Policy"), "sandbox") || rec.Body.String() != string(pngHeader) {
+		t.Errorf("Unexpected image response %d %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleNFT(rec, mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"tokenId": "fungible"}))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a fungible token, got %d", rec.Code)
+	}
+}
+
+func TestNFTCollectionsGroupByCreator(t *testing.T) {
+	database := newTestDatabase(t)
+	for _, meta := range []*NFTMetadata{
+		{TokenID: "b2", Creator: "bob", Status: "resolved", Document: json.RawMessage(`{}`)},
+		{TokenID: "a1", Creator: "alice", Status: "pending"},
+		{TokenID: "b1", Creator: "bob", Status: "failed"},
+	} {
+		database.StoreNFTMetadata(meta)
+	}
+
+	es := &ExplorerServer{database: database}
+	rec := httptest.NewRecorder()
+	es.handleNFTCollections(rec, httptest.NewRequest("GET", "/api/v1/nfts", nil))
+	var collections []NFTCollection
+	json.NewDecoder(rec.Body).Decode(&collections)
+	if len(collections) != 2 || collections[0].Creator != "alice" || len(collections[1].Items) != 2 ||
+		collections[1].Items[0].TokenID != "b1" || collections[1].Items[1].Document != nil {
+		t.Errorf("Unexpected collections %+v", collections)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleNFTCollections(rec, httptest.NewRequest("GET", "/api/v1/nfts?creator=alice", nil))
+	collections = nil
+	json.NewDecoder(rec.Body).Decode(&collections)
+	if len(collections) != 1 || collections[0].Creator != "alice" {
+		t.Errorf("Expected only alice's collection, got %+v", collections)
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults for talking to the Shadowy node, each overridable from the
+// environment (see nodeClientConfigFromEnv)
+const (
+	defaultNodeTimeout          = 30 * time.Second
+	defaultNodeRetries          = 2
+	defaultNodeBackoff          = 500 * time.Millisecond
+	maxNodeBackoff              = 10 * time.Second
+	defaultNodeBreakerThreshold = 5
+	defaultNodeBreakerCooldown  = 30 * time.Second
+)
+
+// NodeClientConfig tunes how the explorer calls the node
+type NodeClientConfig struct {
+	Timeout          time.Duration // Per attempt
+	Retries          int           // Attempts after the first for retryable failures
+	Backoff          time.Duration // Wait before the first retry, doubling up to maxNodeBackoff
+	BreakerThreshold int           // Consecutive failures that open a node's circuit; 0 never opens it
+	BreakerCooldown  time.Duration // How long an open circuit refuses calls before letting one through
+}
+
+// DefaultNodeClientConfig returns the configuration used when the
+// environment sets nothing
+func DefaultNodeClientConfig() NodeClientConfig {
+	return NodeClientConfig{
+		Timeout:          defaultNodeTimeout,
+		Retries:          defaultNodeRetries,
+		Backoff:          defaultNodeBackoff,
+		BreakerThreshold: defaultNodeBreakerThreshold,
+		BreakerCooldown:  defaultNodeBreakerCooldown,
+	}
+}
+
+// nodeClientConfigFromEnv reads EXPLORER_NODE_TIMEOUT, EXPLORER_NODE_RETRIES,
+// EXPLORER_NODE_BACKOFF, EXPLORER_NODE_BREAKER_THRESHOLD and
+// EXPLORER_NODE_BREAKER_COOLDOWN, keeping the default for any that is
+// unset or invalid
+func nodeClientConfigFromEnv() NodeClientConfig {
+	config := DefaultNodeClientConfig()
+	durations := map[string]*time.Duration{
+		"EXPLORER_NODE_TIMEOUT":          &config.Timeout,
+		"EXPLORER_NODE_BACKOFF":          &config.Backoff,
+		"EXPLORER_NODE_BREAKER_COOLDOWN": &config.BreakerCooldown,
+	}
+	for name, target := range durations {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			slog.Warn("Ignoring invalid setting", "name", name, "value", value, "default", *target)
+			continue
+		}
+		*target = parsed
+	}
+	counts := map[string]*int{
+		"EXPLORER_NODE_RETRIES":           &config.Retries,
+		"EXPLORER_NODE_BREAKER_THRESHOLD": &config.BreakerThreshold,
+	}
+	for name, target := range counts {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			slog.Warn("Ignoring invalid setting", "name", name, "value", value, "default", *target)
+			continue
+		}
+		*target = parsed
+	}
+	return config
+}
+
+// NodeErrorKind classifies why a node call failed
+type NodeErrorKind string
+
+const (
+	NodeUnreachable NodeErrorKind = "unreachable"  // No HTTP response, including timeouts
+	NodeBadStatus   NodeErrorKind = "bad_status"   // A response other than 200
+	NodeBadResponse NodeErrorKind = "bad_response" // A 200 whose body does not decode
+	NodeCircuitOpen NodeErrorKind = "circuit_open" // Refused without calling, after repeated failures
+)
+
+// NodeError is a failed call to a node
+type NodeError struct {
+	Node       string // Base URL of the node
+	Op         string // What was asked for, e.g. "block 42"
+	Kind       NodeErrorKind
+	StatusCode int // For NodeBadStatus
+	Err        error
+}
+
+func (e *NodeError) Error() string {
+	switch e.Kind {
+	case NodeBadStatus:
+		return fmt.Sprintf("%s %s: node returned status %d", e.Node, e.Op, e.StatusCode)
+	case NodeCircuitOpen:
+		return fmt.Sprintf("%s %s: circuit open after repeated failures", e.Node, e.Op)
+	}
+	return fmt.Sprintf("%s %s: %s: %v", e.Node, e.Op, e.Kind, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// retryable reports whether trying again might succeed: the node was not
+// reached, or it was overloaded or failing
+func (e *NodeError) retryable() bool {
+	switch e.Kind {
+	case NodeUnreachable:
+		return true
+	case NodeBadStatus:
+		return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// IsNodeError reports whether err is a node failure of the given kind
+func IsNodeError(err error, kind NodeErrorKind) bool {
+	var nodeErr *NodeError
+	return errors.As(err, &nodeErr) && nodeErr.Kind == kind
+}
+
+// circuitBreaker tracks a node's consecutive failures
+type circuitBreaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+// NodeClient makes every call the explorer makes to Shadowy nodes: each
+// attempt is bounded by a timeout, retryable failures are retried with
+// exponential backoff, and a node that keeps failing has its circuit
+// opened so callers fail fast until it cools down. One client is shared by
+// the sync service, the node pool, the mempool tracker and the handlers,
+// so they all see the same circuit state.
+type NodeClient struct {
+	config NodeClientConfig
+	http   *http.Client
+
+	mu       *sync.Mutex
+	breakers map[string]*circuitBreaker // By node base URL
+
+	sleep func(time.Duration) // Replaced in tests
+}
+
+// NewNodeClient creates a client with the given configuration
+func NewNodeClient(config NodeClientConfig) *NodeClient {
+	return &NodeClient{
+		config:   config,
+		http:     &http.Client{},
+		mu:       &sync.Mutex{},
+		breakers: make(map[string]*circuitBreaker),
+		sleep:    time.Sleep,
+	}
+}
+
+// WithTimeout returns a client sharing c's circuits that makes a single
+// attempt bounded by timeout, for callers such as probes that must answer
+// quickly
+func (c *NodeClient) WithTimeout(timeout time.Duration) *NodeClient {
+	probe := *c
+	probe.config.Timeout = timeout
+	probe.config.Retries = 0
+	return &probe
+}
+
+// allow reports whether node's circuit lets a call through. Once the
+// cooldown has passed one call is let through; its outcome closes or
+// reopens the circuit.
+func (c *NodeClient) allow(node string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	breaker := c.breakers[node]
+	if breaker == nil || c.config.BreakerThreshold == 0 || breaker.failures < c.config.BreakerThreshold {
+		return true
+	}
+	if time.Now().Before(breaker.openUntil) {
+		return false
+	}
+	breaker.openUntil = time.Now().Add(c.config.BreakerCooldown)
+	return true
+}
+
+// record updates node's circuit with the outcome of a call. Only failures
+// that say something about the node count; a 404 for a block not yet
+// produced does not.
+func (c *NodeClient) record(node string, err *NodeError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil || !(err.retryable() || err.Kind == NodeBadResponse) {
+		delete(c.breakers, node)
+		return
+	}
+	breaker := c.breakers[node]
+	if breaker == nil {
+		breaker = &circuitBreaker{}
+		c.breakers[node] = breaker
+	}
+	breaker.failures++
+	if c.config.BreakerThreshold > 0 && breaker.failures == c.config.BreakerThreshold {
+		slog.Warn("Opening circuit to node", "node", node, "failures", breaker.failures, "err", err)
+		breaker.openUntil = time.Now().Add(c.config.BreakerCooldown)
+	}
+}
+
+// backoff returns the wait before retry attempt (1 for the first retry):
+// the base doubled per attempt and capped, half of it jittered so clients
+// do not retry in lockstep
+func (c *NodeClient) backoff(attempt int) time.Duration {
+	wait := c.config.Backoff << (attempt - 1)
+	if wait > maxNodeBackoff || wait <= 0 {
+		wait = maxNodeBackoff
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// getJSON fetches node+path and decodes the response into v, retrying and
+// circuit breaking as configured
+func (c *NodeClient) getJSON(node, path, op string, v interface{}) error {
+	var err *NodeError
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			c.sleep(c.backoff(attempt))
+		}
+		if !c.allow(node) {
+			return &NodeError{Node: node, Op: op, Kind: NodeCircuitOpen}
+		}
+		err = c.attempt(node+path, v)
+		c.record(node, err)
+		if err == nil {
+			return nil
+		}
+		err.Node, err.Op = node, op
+		if !err.retryable() || attempt >= c.config.Retries {
+			return err
+		}
+	}
+}
+
+// attempt makes one bounded request
+func (c *NodeClient) attempt(url string, v interface{}) *NodeError {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &NodeError{Kind: NodeUnreachable, Err: err}
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return &NodeError{Kind: NodeUnreachable, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &NodeError{Kind: NodeBadStatus, StatusCode: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return &NodeError{Kind: NodeBadResponse, Err: err}
+	}
+	return nil
+}
+
+// Status fetches the chain tip from a node's Tendermint /status
+func (c *NodeClient) Status(nodeURL string) (*BlockchainStats, error) {
+	var tendermintResp TendermintStatusResponse
+	if err := c.getJSON(nodeURL, "/status", "status", &tendermintResp); err != nil {
+		return nil, err
+	}
+
+	// Parse height from string
+	height, err := strconv.ParseUint(tendermintResp.Result.SyncInfo.LatestBlockHeight, 10, 64)
+	if err != nil {
+		return nil, &NodeError{Node: nodeURL, Op: "status", Kind: NodeBadResponse, Err: fmt.Errorf("failed to parse height: %w", err)}
+	}
+
+	return &BlockchainStats{
+		TipHeight: height,
+		TipHash:   tendermintResp.Result.SyncInfo.LatestBlockHash,
+	}, nil
+}
+
+// Block fetches the block at height from a node's Tendermint /block
+func (c *NodeClient) Block(nodeURL string, height uint64) (*TendermintBlockResponse, error) {
+	var tmBlockResp TendermintBlockResponse
+	if err := c.getJSON(nodeURL, fmt.Sprintf("/block?height=%d", height), fmt.Sprintf("block %d", height), &tmBlockResp); err != nil {
+		return nil, err
+	}
+	return &tmBlockResp, nil
+}
+
+// Mempool fetches up to limit pending transactions from the node API at
+// apiURL
+func (c *NodeClient) Mempool(apiURL string, limit int) (*nodeMempoolResponse, error) {
+	var body nodeMempoolResponse
+	if err := c.getJSON(apiURL, fmt.Sprintf("/api/v1/mempool/transactions?limit=%d", limit), "mempool", &body); err != nil {
+		return nil, err
+	}
+	return &body, nil
+}
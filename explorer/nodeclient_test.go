@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestNodeClient makes single attempts with the circuit breaker off, so
+// tests see each node failure as it happens
+func newTestNodeClient() *NodeClient {
+	return NewNodeClient(NodeClientConfig{Timeout: 5 * time.Second})
+}
+
+// newFlakyNode serves /status, failing the first failures requests with
+// status code
+func newFlakyNode(t *testing.T, failures int64, code int) (*httptest.Server, *int64) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) <= failures {
+			http.Error(w, "unavailable", code)
+			return
+		}
+		fmt.Fprint(w, `{"result":{"sync_info":{"latest_block_height":"7","latest_block_hash":"tip7"}}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestNodeClientRetries(t *testing.T) {
+	node, requests := newFlakyNode(t, 2, http.StatusServiceUnavailable)
+	client := NewNodeClient(NodeClientConfig{Timeout: time.Second, Retries: 2, Backoff: 100 * time.Millisecond})
+	var waits []time.Duration
+	client.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	stats, err := client.Status(node.URL)
+	if err != nil || stats.TipHeight != 7 {
+		t.Fatalf("Expected the third attempt to succeed, got %+v (%v)", stats, err)
+	}
+	if *requests != 3 || len(waits) != 2 {
+		t.Fatalf("Expected 3 requests and 2 waits, got %d and %v", *requests, waits)
+	}
+	// Each wait is jittered within the upper half of its doubling backoff
+	if waits[0] < 50*time.Millisecond || waits[0] > 100*time.Millisecond || waits[1] < 100*time.Millisecond || waits[1] > 200*time.Millisecond {
+		t.Errorf("Unexpected backoff %v", waits)
+	}
+}
+
+func TestNodeClientErrorKinds(t *testing.T) {
+	client := newTestNodeClient()
+
+	missing, requests := newFlakyNode(t, 10, http.StatusNotFound)
+	client.config.Retries = 3
+	client.sleep = func(time.Duration) {}
+	_, err := client.Block(missing.URL, 5)
+	if !IsNodeError(err, NodeBadStatus) || *requests != 1 {
+		t.Errorf("Expected a 404 without retries, got %v after %d requests", err, *requests)
+	}
+
+	garbage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "not json")
+	}))
+	defer garbage.Close()
+	if _, err := client.Status(garbage.URL); !IsNodeError(err, NodeBadResponse) {
+		t.Errorf("Expected a bad response, got %v", err)
+	}
+
+	garbage.Close()
+	if _, err := client.Status(garbage.URL); !IsNodeError(err, NodeUnreachable) {
+		t.Errorf("Expected an unreachable node, got %v", err)
+	}
+
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hung.Close()
+	start := time.Now()
+	_, err = client.WithTimeout(50 * time.Millisecond).Status(hung.URL)
+	if !IsNodeError(err, NodeUnreachable) || time.Since(start) > time.Second {
+		t.Errorf("Expected the probe to time out quickly, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestNodeClientCircuitBreaker(t *testing.T) {
+	node, requests := newFlakyNode(t, 3, http.StatusInternalServerError)
+	client := NewNodeClient(NodeClientConfig{Timeout: time.Second, BreakerThreshold: 3, BreakerCooldown: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Status(node.URL); !IsNodeError(err, NodeBadStatus) {
+			t.Fatalf("Attempt %d: expected a bad status, got %v", i, err)
+		}
+	}
+	if _, err := client.Status(node.URL); !IsNodeError(err, NodeCircuitOpen) || *requests != 3 {
+		t.Fatalf("Expected the open circuit to refuse the call, got %v after %d requests", err, *requests)
+	}
+
+	// Probes share the circuit
+	if _, err := client.WithTimeout(time.Second).Status(node.URL); !IsNodeError(err, NodeCircuitOpen) {
+		t.Errorf("Expected the probe refused too, got %v", err)
+	}
+
+	// Once cooled down one call goes through, and its success closes the circuit
+	client.mu.Lock()
+	client.breakers[node.URL].openUntil = time.Now()
+	client.mu.Unlock()
+	if _, err := client.Status(node.URL); err != nil {
+		t.Fatalf("Expected the trial call to succeed, got %v", err)
+	}
+	if _, err := client.Status(node.URL); err != nil || len(client.breakers) != 0 {
+		t.Errorf("Expected the circuit closed, got %v", err)
+	}
+}
+
+func TestNodeClientConfigFromEnv(t *testing.T) {
+	t.Setenv("EXPLORER_NODE_TIMEOUT", "5s")
+	t.Setenv("EXPLORER_NODE_RETRIES", "0")
+	t.Setenv("EXPLORER_NODE_BREAKER_THRESHOLD", "many")
+	config := nodeClientConfigFromEnv()
+	if config.Timeout != 5*time.Second || config.Retries != 0 || config.BreakerThreshold != defaultNodeBreakerThreshold ||
+		config.Backoff != defaultNodeBackoff {
+		t.Errorf("Unexpected config %+v", config)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NodeHealth is the result of the latest health check of a node
+type NodeHealth struct {
+	URL         string    `json:"url"`
+	Healthy     bool      `json:"healthy"`
+	Height      uint64    `json:"height"`
+	TipHash     string    `json:"tip_hash,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastChecked time.Time `json:"last_checked,omitempty"`
+	LatencyMs   int64     `json:"latency_ms"`
+}
+
+// NodePool is the set of Tendermint nodes the sync service can read from.
+// Every sync cycle checks them all and syncs from the healthy one with the
+// highest height; when that node fails mid-cycle, reads fail over to the
+// next best.
+type NodePool struct {
+	client *NodeClient
+
+	mu      sync.RWMutex
+	health  []NodeHealth // In configured order
+	current int          // Index into health of the node read from
+}
+
+// parseNodeURLs splits a comma-separated list of node URLs, dropping blanks,
+// duplicates and trailing slashes
+func parseNodeURLs(list string) []string {
+	urls := []string{}
+	seen := make(map[string]bool)
+	for _, url := range strings.Split(list, ",") {
+		url = strings.TrimSuffix(strings.TrimSpace(url), "/")
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+// NewNodePool creates a pool of the nodes in urls, a comma-separated list.
+// Until the first health check the first node is used.
+func NewNodePool(urls string, client *NodeClient) *NodePool {
+	pool := &NodePool{client: client}
+	for _, url := range parseNodeURLs(urls) {
+		// Assumed healthy until checked, so reads before the first check go somewhere
+		pool.health = append(pool.health, NodeHealth{URL: url, Healthy: true})
+	}
+	return pool
+}
+
+// Current returns the URL of the node being synced from, empty when the
+// pool has none
+func (p *NodePool) Current() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.health) == 0 {
+		return ""
+	}
+	return p.health[p.current].URL
+}
+
+// Health returns the latest health check of every node
+func (p *NodePool) Health() []NodeHealth {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]NodeHealth(nil), p.health...)
+}
+
+// CheckAll queries the status of every node at once, switches to the
+// healthy node with the highest height and returns its stats. The current
+// node is kept on ties, so the pool does not flap between nodes in step.
+func (p *NodePool) CheckAll() (*BlockchainStats, error) {
+	p.mu.RLock()
+	urls := make([]string, len(p.health))
+	for i, node := range p.health {
+		urls[i] = node.URL
+	}
+	p.mu.RUnlock()
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no nodes configured")
+	}
+
+	results := make([]NodeHealth, len(urls))
+	stats := make([]*BlockchainStats, len(urls))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			start := time.Now()
+			result, err := p.client.Status(url)
+			results[i] = NodeHealth{URL: url, LastChecked: time.Now(), LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				results[i].LastError = err.Error()
+				return
+			}
+			results[i].Healthy = true
+			results[i].Height = result.TipHeight
+			results[i].TipHash = result.TipHash
+			stats[i] = result
+		}(i, url)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.health = results
+	best := p.bestLocked(p.current)
+	if best < 0 {
+		return nil, fmt.Errorf("no healthy node: %s", results[p.current].LastError)
+	}
+	if best != p.current {
+		slog.Info("Switching sync node", "from", results[p.current].URL, "to", results[best].URL, "height", results[best].Height)
+		p.current = best
+	}
+	return stats[best], nil
+}
+
+// bestLocked returns the healthy node with the highest height, preferring
+// prefer on ties, or -1 when no node is healthy
+func (p *NodePool) bestLocked(prefer int) int {
+	best := -1
+	for i, node := range p.health {
+		if !node.Healthy {
+			continue
+		}
+		if best < 0 || node.Height > p.health[best].Height || (node.Height == p.health[best].Height && i == prefer) {
+			best = i
+		}
+	}
+	return best
+}
+
+// MarkFailed records that a read from url failed and, if it was the current
+// node, fails over to the best remaining healthy one. It reports whether
+// there is another node to try.
+func (p *NodePool) MarkFailed(url string, err error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.health) == 0 {
+		return false
+	}
+
+	for i := range p.health {
+		if p.health[i].URL == url {
+			p.health[i].Healthy = false
+			p.health[i].LastError = err.Error()
+			p.health[i].LastChecked = time.Now()
+		}
+	}
+	if p.health[p.current].URL != url {
+		return p.health[p.current].Healthy
+	}
+
+	best := p.bestLocked(-1)
+	if best < 0 {
+		return false
+	}
+	slog.Warn("Node failed, failing over", "node", url, "to", p.health[best].URL, "err", err)
+	p.current = best
+	return true
+}
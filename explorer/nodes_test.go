@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseNodeURLs(t *testing.T) {
+	got := parseNodeURLs(" http://a:26657/, ,http://b:26657,http://a:26657")
+	want := []string{"http://a:26657", "http://b:26657"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestNodePoolPicksHighestHealthyNode(t *testing.T) {
+	behind, _ := newFakeNode(t, 3)
+	ahead, _ := newFakeNode(t, 5)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	pool := NewNodePool(down.URL+","+behind.URL+","+ahead.URL, newTestNodeClient())
+	stats, err := pool.CheckAll()
+	if err != nil {
+		t.Fatalf("CheckAll failed: %v", err)
+	}
+	if stats.TipHeight != 5 || pool.Current() != ahead.URL {
+		t.Errorf("Expected the node at 5 chosen, got %s at %d", pool.Current(), stats.TipHeight)
+	}
+
+	health := pool.Health()
+	if health[0].Healthy || health[0].LastError == "" || !health[1].Healthy || health[1].Height != 3 {
+		t.Errorf("Unexpected health %+v", health)
+	}
+
+	// Losing the current node moves reads to the best one left
+	if !pool.MarkFailed(ahead.URL, errReorgDetected) || pool.Current() != behind.URL {
+		t.Errorf("Expected failover to %s, got %s", behind.URL, pool.Current())
+	}
+	if pool.MarkFailed(behind.URL, errReorgDetected) {
+		t.Error("Expected no node left to fail over to")
+	}
+}
+
+func TestSyncFailsOverWhenNodeDies(t *testing.T) {
+	primary, _ := newFakeNode(t, 4)
+	backup, _ := newFakeNode(t, 4)
+	database := newTestDatabase(t)
+	svc := NewSyncService(primary.URL+","+backup.URL, newTestNodeClient(), database)
+
+	svc.syncOnce()
+	if height, _ := database.GetLatestHeight(); height != 4 {
+		t.Fatalf("Expected height 4 synced from the primary, at %d", height)
+	}
+	if node := svc.Status().Node; node != primary.URL {
+		t.Errorf("Expected to sync from the primary on a tie, got %s", node)
+	}
+
+	// Blocks are fetched from the backup once the primary stops answering
+	primary.Close()
+	if _, err := database.RollbackToHeight(2); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	if _, err := svc.fetchBlock(3); err != nil {
+		t.Fatalf("Expected fetchBlock to fail over, got %v", err)
+	}
+	svc.syncOnce()
+	if height, _ := database.GetLatestHeight(); height != 4 {
+		t.Errorf("Expected height 4 re-synced from the backup, at %d", height)
+	}
+	status := svc.Status()
+	if status.Node != backup.URL || status.LastError != "" || len(status.Nodes) != 2 || status.Nodes[0].Healthy {
+		t.Errorf("Unexpected status after failover %+v", status)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Blocks that lose their height, to a reorg or to a competing block synced
+// at the same height, are moved to orphan:<height>:<hash> rather than
+// dropped, so links to them keep working and the network's stale rate can
+// be followed. Storing the block again restores it.
+const (
+	orphanPrefix = "orphan:"
+
+	orphanReorg    = "reorg"
+	orphanReplaced = "replaced"
+
+	defaultOrphansLimit = 50
+	maxOrphansLimit     = 500
+)
+
+func orphanKey(height uint64, hash string) []byte {
+	return []byte(fmt.Sprintf("%s%016d:%s", orphanPrefix, height, hash))
+}
+
+// newOrphanBlock describes block, stored under hash, as displaced
+func newOrphanBlock(hash, nodeHash string, block *Block, reason string) *OrphanBlock {
+	return &OrphanBlock{
+		Height:        block.Header.Height,
+		Hash:          hash,
+		NodeHash:      nodeHash,
+		Timestamp:     block.Header.Timestamp,
+		FarmerAddress: block.Header.FarmerAddress,
+		TxCount:       len(block.Body.Transactions),
+		Reason:        reason,
+		OrphanedAt:    time.Now().UTC(),
+		Block:         block,
+	}
+}
+
+// orphanBlock moves the block stored under hash at height to the orphan
+// keyspace, with the node's hash for it
+func orphanBlock(txn *badger.Txn, hash string, height uint64, reason string) error {
+	item, err := txn.Get([]byte("block:" + hash))
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var block Block
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &block)
+	}); err != nil {
+		return err
+	}
+
+	var nodeHash string
+	if item, err := txn.Get(nodeHashKey(height)); err == nil {
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		nodeHash = string(value)
+	} else if err != badger.ErrKeyNotFound {
+		return err
+	}
+
+	data, err := json.Marshal(newOrphanBlock(hash, nodeHash, &block, reason))
+	if err != nil {
+		return fmt.Errorf("failed to marshal orphan block: %w", err)
+	}
+	if err := txn.Set(orphanKey(height, hash), data); err != nil {
+		return err
+	}
+	slog.Warn("Block orphaned", "height", height, "hash", hash, "reason", reason)
+	return txn.Delete([]byte("block:" + hash))
+}
+
+// GetOrphanBlock returns the orphaned block stored under hash, with its body
+func (d *Database) GetOrphanBlock(hash string) (*OrphanBlock, error) {
+	var orphan *OrphanBlock
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// Orphans are few, so a scan beats keeping a second index
+		for it.Seek([]byte(orphanPrefix)); it.ValidForPrefix([]byte(orphanPrefix)); it.Next() {
+			if !strings.HasSuffix(string(it.Item().Key()), ":"+hash) {
+				continue
+			}
+			orphan = &OrphanBlock{}
+			return it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, orphan)
+			})
+		}
+		return badger.ErrKeyNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphan, nil
+}
+
+// GetOrphanBlocks returns up to limit orphaned blocks, highest first and
+// without their bodies, and how many there are in all
+func (d *Database) GetOrphanBlocks(limit int) (*OrphanBlocks, error) {
+	result := &OrphanBlocks{Orphans: []OrphanBlock{}}
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(orphanPrefix)
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(orphanPrefix + "\xff")); it.Valid(); it.Next() {
+			result.Total++
+			if len(result.Orphans) >= limit {
+				continue
+			}
+			var orphan OrphanBlock
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &orphan)
+			}); err != nil {
+				return err
+			}
+			orphan.Block = nil
+			result.Orphans = append(result.Orphans, orphan)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Orphans API endpoint: blocks displaced by reorgs and competing blocks
+func (es *ExplorerServer) handleOrphans(w http.ResponseWriter, r *http.Request) {
+	limit := defaultOrphansLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxOrphansLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxOrphansLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	orphans, err := es.database.GetOrphanBlocks(limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get orphan blocks", "err", err)
+		http.Error(w, "Failed to get orphan blocks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orphans)
+}
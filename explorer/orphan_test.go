@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// checkOrphanTracking runs a reorg and a competing block against store
+func checkOrphanTracking(t *testing.T, store Store) {
+	storeTestBlocks(t, store, 1, 5)
+	if err := store.SetNodeHash(5, "NODE5"); err != nil {
+		t.Fatalf("SetNodeHash failed: %v", err)
+	}
+
+	if _, err := store.RollbackToHeight(3); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	orphans, err := store.GetOrphanBlocks(10)
+	if err != nil {
+		t.Fatalf("GetOrphanBlocks failed: %v", err)
+	}
+	if orphans.Total != 2 || len(orphans.Orphans) != 2 {
+		t.Fatalf("Expected blocks 4 and 5 orphaned, got %+v", orphans)
+	}
+	if top := orphans.Orphans[0]; top.Height != 5 || top.Hash != "hash_5" || top.NodeHash != "NODE5" || top.Reason != orphanReorg || top.Block != nil {
+		t.Errorf("Unexpected orphan %+v", top)
+	}
+	if _, err := store.GetBlock("hash_5"); err != errNotFound {
+		t.Errorf("Expected hash_5 off the chain, got %v", err)
+	}
+	orphan, err := store.GetOrphanBlock("hash_5")
+	if err != nil || orphan.Block == nil || orphan.Block.Header.Height != 5 {
+		t.Fatalf("Expected the orphan with its body, got %+v (%v)", orphan, err)
+	}
+
+	// A competing block at height 3 displaces the one stored there
+	competitor := &Block{Header: BlockHeader{Height: 3, Timestamp: time.Unix(3, 0)}}
+	if err := store.StoreBlock("hash_3b", competitor); err != nil {
+		t.Fatalf("StoreBlock failed: %v", err)
+	}
+	if orphan, err := store.GetOrphanBlock("hash_3"); err != nil || orphan.Reason != orphanReplaced {
+		t.Errorf("Expected hash_3 replaced, got %+v (%v)", orphan, err)
+	}
+
+	// The original winning again swaps them back
+	storeTestBlocks(t, store, 3, 3)
+	if _, err := store.GetOrphanBlock("hash_3"); err != errNotFound {
+		t.Errorf("Expected hash_3 restored, got %v", err)
+	}
+	if orphans, _ := store.GetOrphanBlocks(0); orphans.Total != 3 || len(orphans.Orphans) != 0 {
+		t.Errorf("Expected 3 orphans counted and none listed, got %+v", orphans)
+	}
+}
+
+func TestOrphanTracking(t *testing.T) {
+	checkOrphanTracking(t, newTestDatabase(t))
+}
+
+func TestOrphanedBlockDetails(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 1, 3)
+	if _, err := database.RollbackToHeight(2); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/block/hash_3", nil), map[string]string{"hash": "hash_3"})
+	es.handleBlockDetails(rec, req)
+	var body struct {
+		Header BlockHeader  `json:"header"`
+		Orphan *OrphanBlock `json:"orphan"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected the orphan served, got %d %s", rec.Code, rec.Body)
+	}
+	if body.Header.Height != 3 || body.Orphan == nil || body.Orphan.Reason != orphanReorg || body.Orphan.Block != nil {
+		t.Errorf("Unexpected orphan details %+v", body)
+	}
+
+	for query, want := range map[string]int{"": http.StatusOK, "?limit=0": http.StatusBadRequest, "?limit=501": http.StatusBadRequest} {
+		rec := httptest.NewRecorder()
+		es.handleOrphans(rec, httptest.NewRequest("GET", "/api/v1/orphans"+query, nil))
+		if rec.Code != want {
+			t.Errorf("Expected %d for %q, got %d", want, query, rec.Code)
+		}
+	}
+}
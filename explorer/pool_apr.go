@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// defaultPoolFeeRate is the swap fee in basis points for pools indexed
+// without a fee tier (30 = 0.3%, the chain's default)
+const defaultPoolFeeRate = 30
+
+// feeAPRWindow is the trading window annualized into fee APR. A week
+// smooths out single-day spikes while still tracking recent activity.
+const feeAPRWindow = 7 * 24 * time.Hour
+
+// PoolActivity is recent trading volume and the APR it implies for
+// liquidity providers. Volume24h and Volume7d are in token B units; the
+// shadow volumes value each swap in SHADOW, like TVL.
+type PoolActivity struct {
+	Volume24h       uint64  `json:"volume_24h"`
+	Volume7d        uint64  `json:"volume_7d"`
+	Volume24hShadow uint64  `json:"volume_24h_shadow"`
+	Volume7dShadow  uint64  `json:"volume_7d_shadow"`
+	FeeAPR          float64 `json:"fee_apr"`    // Percent, from swap fees over the last 7 days
+	RewardAPR       float64 `json:"reward_apr"` // Percent, from liquidity incentives
+}
+
+// poolFeeRate returns the pool's fee tier, falling back to the default
+func poolFeeRate(pool *LiquidityPool) uint64 {
+	if pool.FeeRate == 0 {
+		return defaultPoolFeeRate
+	}
+	return pool.FeeRate
+}
+
+// GetPoolSwaps returns the pool's swaps from from through to, oldest
+// first. Pool transaction keys are ordered by unix timestamp, so the scan
+// starts at from and stops past to.
+func (d *Database) GetPoolSwaps(poolID string, from, to time.Time) ([]PoolTransaction, error) {
+	swaps := []PoolTransaction{}
+	prefix := []byte(fmt.Sprintf("pool_tx:%s:", poolID))
+	start := []byte(fmt.Sprintf("pool_tx:%s:%016d:", poolID, from.Unix()))
+	end := []byte(fmt.Sprintf("pool_tx:%s:%016d:", poolID, to.Unix()+1))
+
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(start); it.ValidForPrefix(prefix) && bytes.Compare(it.Item().Key(), end) < 0; it.Next() {
+			var poolTx PoolTransaction
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &poolTx)
+			}); err != nil {
+				continue
+			}
+			if poolTx.Type == "swap" && !poolTx.Timestamp.Before(from) && !poolTx.Timestamp.After(to) {
+				swaps = append(swaps, poolTx)
+			}
+		}
+		return nil
+	})
+	return swaps, err
+}
+
+// computePoolActivity totals swap volume over the last 24 hours and 7 days
+// before now and annualizes the fees earned over the last week, valued in
+// SHADOW, against TVL. Pools with no TVL or no trades report zero APR.
+func computePoolActivity(pool *LiquidityPool, swaps []PoolTransaction, now time.Time) PoolActivity {
+	var activity PoolActivity
+	feeRate := poolFeeRate(pool)
+
+	dayAgo := now.Add(-24 * time.Hour)
+	weekAgo := now.Add(-feeAPRWindow)
+	for i := range swaps {
+		swap := &swaps[i]
+		if swap.Timestamp.Before(weekAgo) || swap.Timestamp.After(now) {
+			continue
+		}
+		value := swapShadowValue(pool, swap)
+		activity.Volume7d += swap.AmountB
+		activity.Volume7dShadow += value
+		if !swap.Timestamp.Before(dayAgo) {
+			activity.Volume24h += swap.AmountB
+			activity.Volume24hShadow += value
+		}
+	}
+
+	if pool.TVL > 0 {
+		fees := float64(activity.Volume7dShadow) * float64(feeRate) / 10000
+		periodsPerYear := float64(365*24*time.Hour) / float64(feeAPRWindow)
+		activity.FeeAPR = fees * periodsPerYear / float64(pool.TVL) * 100
+	}
+
+	// No liquidity incentives are paid on chain yet
+	activity.RewardAPR = 0
+
+	return activity
+}
+
+// GetPoolActivity computes a pool's recent volume and APR as of now
+func (d *Database) GetPoolActivity(pool *LiquidityPool, now time.Time) (PoolActivity, error) {
+	swaps, err := d.GetPoolSwaps(pool.PoolID, now.Add(-feeAPRWindow), now)
+	if err != nil {
+		return PoolActivity{}, err
+	}
+	return computePoolActivity(pool, swaps, now), nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComputePoolActivityWindows(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	pool := &LiquidityPool{PoolID: "pool_gold", TVL: 1_000_000}
+	swaps := []PoolTransaction{
+		{Type: "swap", Timestamp: now.Add(-time.Hour), AmountB: 10_000},
+		{Type: "swap", Timestamp: now.Add(-23 * time.Hour), AmountB: 5_000},
+		{Type: "swap", Timestamp: now.Add(-3 * 24 * time.Hour), AmountB: 20_000},
+		{Type: "swap", Timestamp: now.Add(-8 * 24 * time.Hour), AmountB: 99_000}, // Outside both windows
+	}
+
+	activity := computePoolActivity(pool, swaps, now)
+	if activity.Volume24h != 15_000 {
+		t.Errorf("Expected 24h volume 15000, got %d", activity.Volume24h)
+	}
+	if activity.Volume7d != 35_000 {
+		t.Errorf("Expected 7d volume 35000, got %d", activity.Volume7d)
+	}
+
+	// 35000 * 0.3% = 105 fees a week, * 365/7 weeks, over 1,000,000 TVL
+	want := 105.0 * 365 / 7 / 1_000_000 * 100
+	if math.Abs(activity.FeeAPR-want) > 1e-9 {
+		t.Errorf("Expected fee APR %.6f, got %.6f", want, activity.FeeAPR)
+	}
+
+	// A higher fee tier scales the APR
+	pool.FeeRate = 100
+	if higher := computePoolActivity(pool, swaps, now); math.Abs(higher.FeeAPR-want*100/30) > 1e-9 {
+		t.Errorf("Expected fee APR to scale with the fee tier, got %.6f", higher.FeeAPR)
+	}
+}
+
+func TestComputePoolActivityIdlePool(t *testing.T) {
+	now := time.Now()
+	activity := computePoolActivity(&LiquidityPool{TVL: 1_000_000}, nil, now)
+	if activity.Volume24h != 0 || activity.Volume7d != 0 || activity.FeeAPR != 0 || activity.RewardAPR != 0 {
+		t.Errorf("Idle pool should report zeros, got %+v", activity)
+	}
+
+	// Volume without liquidity must not divide by zero
+	swaps := []PoolTransaction{{Type: "swap", Timestamp: now, AmountB: 500}}
+	if activity := computePoolActivity(&LiquidityPool{}, swaps, now); activity.FeeAPR != 0 || activity.Volume24h != 500 {
+		t.Errorf("Pool without TVL should report volume but no APR, got %+v", activity)
+	}
+}
+
+func TestPoolDetailsIncludeActivity(t *testing.T) {
+	database := newTestDatabase(t)
+	now := time.Now()
+	pool := &LiquidityPool{PoolID: "pool_gold", TokenASymbol: "GOLD", TokenBSymbol: "SHADOW", ReserveA: 1000, ReserveB: 2000, TVL: 1_000_000, APR: 12.5, CreationTime: now.Add(-30 * 24 * time.Hour)}
+	if err := database.StorePool(pool); err != nil {
+		t.Fatalf("Failed to store pool: %v", err)
+	}
+
+	txs := []*PoolTransaction{
+		{TxHash: "create", Type: "create", Timestamp: pool.CreationTime, AmountA: 1000, AmountB: 2000},
+		{TxHash: "old_swap", Type: "swap", Timestamp: now.Add(-10 * 24 * time.Hour), AmountB: 50_000},
+		{TxHash: "week_swap", Type: "swap", Timestamp: now.Add(-2 * 24 * time.Hour), AmountB: 4_000},
+		{TxHash: "day_swap", Type: "swap", Timestamp: now.Add(-2 * time.Hour), AmountB: 3_000},
+		{TxHash: "liquidity", Type: "add_liquidity", Timestamp: now.Add(-time.Hour), AmountB: 70_000},
+	}
+	for _, tx := range txs {
+		if err := database.StorePoolTransaction(pool.PoolID, tx); err != nil {
+			t.Fatalf("Failed to store pool transaction: %v", err)
+		}
+	}
+
+	details, err := database.GetPoolDetails(pool.PoolID)
+	if err != nil {
+		t.Fatalf("GetPoolDetails failed: %v", err)
+	}
+	if details.Volume24h != 3_000 || details.Volume7d != 7_000 {
+		t.Errorf("Expected volumes 3000/7000 from swaps only, got %d/%d", details.Volume24h, details.Volume7d)
+	}
+	if details.FeeRate != defaultPoolFeeRate {
+		t.Errorf("Expected default fee rate, got %d", details.FeeRate)
+	}
+	if details.FeeAPR <= 0 || details.APR != details.FeeAPR+details.RewardAPR {
+		t.Errorf("APR should be derived from trading, got apr=%f fee_apr=%f", details.APR, details.FeeAPR)
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Swap directions recorded on swap pool transactions
+const (
+	swapAToB = "a_to_b"
+	swapBToA = "b_to_a"
+)
+
+const (
+	defaultPoolCandleInterval = "1h"
+	defaultPoolCandles        = 100
+	maxPoolCandles            = 1000
+)
+
+// poolCandleIntervals are the candle widths the candles endpoint serves
+var poolCandleIntervals = map[string]time.Duration{
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// sameToken reports whether two token ids name the same asset, treating
+// "SHADOW" and the empty id of SHADOW pairs as one
+func sameToken(a, b string) bool {
+	if a == "SHADOW" {
+		a = ""
+	}
+	if b == "SHADOW" {
+		b = ""
+	}
+	return a == b
+}
+
+// poolSpotPrice is the pool's price in token B per token A, zero while it
+// holds no token A
+func poolSpotPrice(pool *LiquidityPool) float64 {
+	if pool.ReserveA == 0 {
+		return 0
+	}
+	return float64(pool.ReserveB) / float64(pool.ReserveA)
+}
+
+// poolSwapOutput is what amountIn buys from a constant-product pool after
+// its fee, computed exactly as the chain's token executor does
+func poolSwapOutput(inReserve, outReserve, amountIn, feeRate uint64) uint64 {
+	denominator := (inReserve + amountIn) * 10000
+	if denominator == 0 {
+		return 0
+	}
+	return outReserve * amountIn * (10000 - feeRate) / denominator
+}
+
+// applyPoolSwap trades amountIn of inputToken through pool, updating its
+// reserves and statistics, and returns the swap with the price move it
+// caused as a pool transaction
+func applyPoolSwap(pool *LiquidityPool, inputToken string, amountIn uint64, timestamp time.Time) (*PoolTransaction, error) {
+	tx := &PoolTransaction{Type: "swap", Timestamp: timestamp, PriceBefore: poolSpotPrice(pool)}
+	switch {
+	case sameToken(inputToken, pool.TokenA):
+		tx.Direction = swapAToB
+		tx.AmountA = amountIn
+		tx.AmountB = poolSwapOutput(pool.ReserveA, pool.ReserveB, amountIn, poolFeeRate(pool))
+		if tx.AmountB == 0 {
+			return nil, fmt.Errorf("swap of %d %s yields nothing", amountIn, pool.TokenASymbol)
+		}
+	case sameToken(inputToken, pool.TokenB):
+		tx.Direction = swapBToA
+		tx.AmountB = amountIn
+		tx.AmountA = poolSwapOutput(pool.ReserveB, pool.ReserveA, amountIn, poolFeeRate(pool))
+		if tx.AmountA == 0 {
+			return nil, fmt.Errorf("swap of %d %s yields nothing", amountIn, pool.TokenBSymbol)
+		}
+	default:
+		return nil, fmt.Errorf("pool %s does not trade token %s", pool.PoolID, inputToken)
+	}
+
+	recordPoolSwap(pool, tx)
+	tx.PriceAfter = poolSpotPrice(pool)
+	if tx.PriceBefore > 0 {
+		tx.PriceImpact = math.Abs(tx.PriceAfter-tx.PriceBefore) / tx.PriceBefore * 100
+	}
+	return tx, nil
+}
+
+// recordPoolSwap moves a swap's amounts through the pool's reserves and
+// counts it in the pool's statistics
+func recordPoolSwap(pool *LiquidityPool, tx *PoolTransaction) {
+	pool.VolumeShadow += swapShadowValue(pool, tx)
+	if tx.Direction == swapAToB {
+		pool.ReserveA += tx.AmountA
+		pool.ReserveB -= tx.AmountB
+	} else {
+		pool.ReserveB += tx.AmountB
+		pool.ReserveA -= tx.AmountA
+	}
+	pool.TradeCount++
+	pool.VolumeA += tx.AmountA
+	pool.VolumeB += tx.AmountB
+	pool.LastActivity = tx.Timestamp
+	refreshPoolValue(pool)
+}
+
+// replayPoolHistory recomputes a pool's reserves and statistics from its
+// transactions in order. Pools whose creation is not in history are left
+// as they are, since there is nothing to replay from.
+func replayPoolHistory(pool *LiquidityPool, history []PoolTransaction) {
+	created := false
+	for i := range history {
+		tx := &history[i]
+		switch tx.Type {
+		case "create":
+			created = true
+			pool.ReserveA, pool.ReserveB = tx.AmountA, tx.AmountB
+			pool.TradeCount, pool.VolumeA, pool.VolumeB, pool.VolumeShadow = 0, 0, 0, 0
+			pool.LastActivity = tx.Timestamp
+			refreshPoolValue(pool)
+		case "swap":
+			// Swaps indexed before directions were recorded cannot be replayed
+			if created && tx.Direction != "" {
+				recordPoolSwap(pool, tx)
+			}
+		}
+	}
+}
+
+// buildPoolCandles buckets swaps into candles interval wide, from the
+// interval of the first swap through the one containing end. Each candle
+// opens at the price before its first swap; intervals without trades carry
+// the previous close.
+func buildPoolCandles(swaps []PoolTransaction, interval time.Duration, end time.Time) []PoolCandle {
+	priced := make([]PoolTransaction, 0, len(swaps))
+	for _, swap := range swaps {
+		if swap.PriceAfter > 0 {
+			priced = append(priced, swap)
+		}
+	}
+	sort.SliceStable(priced, func(i, j int) bool {
+		if !priced[i].Timestamp.Equal(priced[j].Timestamp) {
+			return priced[i].Timestamp.Before(priced[j].Timestamp)
+		}
+		return priced[i].BlockHeight < priced[j].BlockHeight
+	})
+
+	candles := []PoolCandle{}
+	if len(priced) == 0 {
+		return candles
+	}
+
+	last := end.UTC().Truncate(interval)
+	next := 0
+	for start := priced[0].Timestamp.UTC().Truncate(interval); !start.After(last); start = start.Add(interval) {
+		candle := PoolCandle{Time: start}
+		if len(candles) > 0 {
+			previous := candles[len(candles)-1].Close
+			candle.Open, candle.High, candle.Low, candle.Close = previous, previous, previous, previous
+		}
+
+		for ; next < len(priced) && priced[next].Timestamp.Before(start.Add(interval)); next++ {
+			swap := priced[next]
+			open := swap.PriceBefore
+			if open == 0 {
+				open = swap.PriceAfter
+			}
+			if candle.Trades == 0 {
+				candle.Open, candle.High, candle.Low = open, open, open
+			}
+			candle.High = math.Max(candle.High, math.Max(open, swap.PriceAfter))
+			candle.Low = math.Min(candle.Low, math.Min(open, swap.PriceAfter))
+			candle.Close = swap.PriceAfter
+			candle.VolumeA += swap.AmountA
+			candle.VolumeB += swap.AmountB
+			candle.Trades++
+		}
+		candles = append(candles, candle)
+	}
+	return candles
+}
+
+// Pool candles API endpoint: OHLCV price history of a pool
+func (es *ExplorerServer) handlePoolCandles(w http.ResponseWriter, r *http.Request) {
+	poolID := mux.Vars(r)["poolId"]
+	query := r.URL.Query()
+
+	name := query.Get("interval")
+	if name == "" {
+		name = defaultPoolCandleInterval
+	}
+	interval, ok := poolCandleIntervals[name]
+	if !ok {
+		http.Error(w, "interval must be one of 5m, 15m, 1h, 4h or 1d", http.StatusBadRequest)
+		return
+	}
+	limit := defaultPoolCandles
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPoolCandles {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxPoolCandles), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	if _, err := es.database.GetPool(poolID); err != nil {
+		http.Error(w, "Pool not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now().UTC()
+	from := now.Truncate(interval).Add(-time.Duration(limit-1) * interval)
+	swaps, err := es.database.GetPoolSwaps(poolID, from, now)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get pool swaps", "pool_id", poolID, "err", err)
+		http.Error(w, "Failed to get pool candles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PoolCandles{
+		PoolID:   poolID,
+		Interval: name,
+		Candles:  buildPoolCandles(swaps, interval, now),
+	})
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestApplyPoolSwapMatchesChain(t *testing.T) {
+	now := time.Now()
+	pool := &LiquidityPool{PoolID: "p1", TokenA: "gold", TokenB: "", TokenASymbol: "GOLD", TokenBSymbol: "SHADOW",
+		ReserveA: 1_000_000, ReserveB: 2_000_000, FeeRate: 30}
+
+	tx, err := applyPoolSwap(pool, "gold", 10_000, now)
+	if err != nil {
+		t.Fatalf("applyPoolSwap failed: %v", err)
+	}
+	// (y * dx * (10000 - fee)) / ((x + dx) * 10000), as the token executor computes it
+	wantOut := uint64(2_000_000 * 10_000 * 9970 / (1_010_000 * 10000))
+	if tx.Direction != swapAToB || tx.AmountA != 10_000 || tx.AmountB != wantOut {
+		t.Errorf("Expected a_to_b 10000 -> %d, got %s %d -> %d", wantOut, tx.Direction, tx.AmountA, tx.AmountB)
+	}
+	if pool.ReserveA != 1_010_000 || pool.ReserveB != 2_000_000-wantOut {
+		t.Errorf("Unexpected reserves %d/%d", pool.ReserveA, pool.ReserveB)
+	}
+	if tx.PriceBefore != 2 || tx.PriceAfter >= tx.PriceBefore || tx.PriceImpact <= 0 {
+		t.Errorf("Selling A should lower the price, got %f -> %f (%f%%)", tx.PriceBefore, tx.PriceAfter, tx.PriceImpact)
+	}
+	if pool.TradeCount != 1 || pool.VolumeA != 10_000 || pool.VolumeB != wantOut || !pool.LastActivity.Equal(now) {
+		t.Errorf("Unexpected statistics %+v", pool)
+	}
+
+	// SHADOW is the empty side of the pair
+	back, err := applyPoolSwap(pool, "SHADOW", 50_000, now)
+	if err != nil {
+		t.Fatalf("applyPoolSwap failed: %v", err)
+	}
+	if back.Direction != swapBToA || back.AmountB != 50_000 || back.PriceAfter <= back.PriceBefore {
+		t.Errorf("Buying A should raise the price, got %+v", back)
+	}
+
+	if _, err := applyPoolSwap(pool, "silver", 10, now); err == nil {
+		t.Error("Expected a swap of a token outside the pair to fail")
+	}
+	if _, err := applyPoolSwap(&LiquidityPool{TokenA: "gold"}, "gold", 10, now); err == nil {
+		t.Error("Expected a swap against an empty pool to fail")
+	}
+}
+
+func TestBuildPoolCandles(t *testing.T) {
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	swaps := []PoolTransaction{
+		{Type: "swap", Timestamp: base.Add(5 * time.Minute), PriceBefore: 2.0, PriceAfter: 2.2, AmountA: 10, AmountB: 21},
+		{Type: "swap", Timestamp: base.Add(20 * time.Minute), PriceBefore: 2.2, PriceAfter: 1.9, AmountA: 30, AmountB: 60},
+		{Type: "swap", Timestamp: base.Add(40 * time.Minute), PriceBefore: 1.9, PriceAfter: 2.0, AmountA: 5, AmountB: 10},
+		{Type: "swap", Timestamp: base.Add(150 * time.Minute), PriceBefore: 2.0, PriceAfter: 2.5, AmountA: 1, AmountB: 2},
+		{Type: "swap", Timestamp: base.Add(170 * time.Minute), AmountA: 99, AmountB: 99}, // Indexed without prices
+	}
+
+	candles := buildPoolCandles(swaps, time.Hour, base.Add(3*time.Hour+time.Minute))
+	if len(candles) != 4 {
+		t.Fatalf("Expected hourly candles from 10:00 through 13:00, got %d", len(candles))
+	}
+
+	first := candles[0]
+	if !first.Time.Equal(base) || first.Open != 2.0 || first.High != 2.2 || first.Low != 1.9 || first.Close != 2.0 {
+		t.Errorf("Unexpected first candle %+v", first)
+	}
+	if first.VolumeA != 45 || first.VolumeB != 91 || first.Trades != 3 {
+		t.Errorf("Unexpected first candle volume %+v", first)
+	}
+
+	// The quiet hour carries the previous close
+	if gap := candles[1]; gap.Open != 2.0 || gap.Close != 2.0 || gap.High != 2.0 || gap.Low != 2.0 || gap.Trades != 0 {
+		t.Errorf("Expected a flat candle for the quiet hour, got %+v", gap)
+	}
+	if third := candles[2]; third.Open != 2.0 || third.High != 2.5 || third.Close != 2.5 || third.Trades != 1 {
+		t.Errorf("Unexpected third candle %+v", third)
+	}
+	if last := candles[3]; last.Close != 2.5 || last.Trades != 0 {
+		t.Errorf("Expected the current hour to carry the last close, got %+v", last)
+	}
+
+	if candles := buildPoolCandles(nil, time.Hour, base); len(candles) != 0 {
+		t.Errorf("Expected no candles without swaps, got %d", len(candles))
+	}
+}
+
+func TestPoolSwapsIndexedAndRolledBack(t *testing.T) {
+	database := newTestDatabase(t)
+	svc := NewSyncService("", newTestNodeClient(), database)
+	start := time.Unix(1700000000, 0).UTC()
+
+	ops := []struct {
+		height uint64
+		op     *TokenOperation
+	}{
+		{2, &TokenOperation{Type: POOL_CREATE, TokenID: "pool0000000000000001", Amount: 1000, To: "alice",
+			Metadata: &TokenMetadata{LiquidityPool: &LiquidityPoolData{TokenA: "gold0000", TokenB: "SHADOW",
+				InitialRatioA: 1_000_000, InitialRatioB: 2_000_000, FeeRate: 30, LAddress: "L_pool"}}}},
+		{3, &TokenOperation{Type: POOL_SWAP, TokenID: "gold0000", Amount: 10_000, From: "bob", To: "L_pool",
+			Metadata: &TokenMetadata{PoolSwap: &PoolSwapData{PoolLAddress: "L_pool", InputTokenID: "gold0000",
+				OutputTokenID: "SHADOW", SwapperAddress: "bob"}}}},
+		{5, &TokenOperation{Type: POOL_SWAP, TokenID: "SHADOW", Amount: 40_000, From: "carol", To: "L_pool",
+			Metadata: &TokenMetadata{PoolSwap: &PoolSwapData{PoolLAddress: "L_pool", InputTokenID: "SHADOW",
+				OutputTokenID: "gold0000", SwapperAddress: "carol"}}}},
+	}
+	for i, o := range ops {
+		block := &Block{Header: BlockHeader{Height: o.height}}
+		if err := svc.processTokenOperation("hash", block, "ptx"+string(rune('a'+i)), o.op,
+			start.Add(time.Duration(o.height)*time.Minute), indexAll); err != nil {
+			t.Fatalf("Failed to process op %d: %v", i, err)
+		}
+	}
+
+	pool, err := database.GetPoolByAddress("L_pool")
+	if err != nil {
+		t.Fatalf("GetPoolByAddress failed: %v", err)
+	}
+	if pool.PoolID != "pool0000000000000001" || pool.TokenB != "" || pool.TokenBSymbol != "SHADOW" || pool.FeeRate != 30 {
+		t.Errorf("Pool not created from its definition: %+v", pool)
+	}
+	if pool.TradeCount != 2 {
+		t.Errorf("Expected 2 trades, got %d", pool.TradeCount)
+	}
+
+	swaps, err := database.GetPoolSwaps(pool.PoolID, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetPoolSwaps failed: %v", err)
+	}
+	if len(swaps) != 2 || swaps[0].Address != "bob" || swaps[0].PriceImpact <= 0 || swaps[1].Direction != swapBToA {
+		t.Errorf("Unexpected swaps %+v", swaps)
+	}
+	afterFirst := *pool
+	replayPoolHistory(&afterFirst, []PoolTransaction{
+		{Type: "create", AmountA: 1_000_000, AmountB: 2_000_000},
+		swaps[0],
+	})
+
+	// Repricing must not leave the pool listed twice under its old TVL
+	if pools, err := database.GetPools(1, 10, ""); err != nil || pools.TotalPools != 1 {
+		t.Errorf("Expected the pool listed once, got %+v (%v)", pools, err)
+	}
+
+	if _, err := database.RollbackToHeight(4); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	pool, err = database.GetPool(pool.PoolID)
+	if err != nil {
+		t.Fatalf("GetPool failed: %v", err)
+	}
+	if pool.ReserveA != afterFirst.ReserveA || pool.ReserveB != afterFirst.ReserveB || pool.TradeCount != 1 {
+		t.Errorf("Expected the pool back after its first swap (%d/%d), got %d/%d with %d trades",
+			afterFirst.ReserveA, afterFirst.ReserveB, pool.ReserveA, pool.ReserveB, pool.TradeCount)
+	}
+}
+
+func TestPoolCandlesEndpoint(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database}
+	now := time.Now().UTC()
+	if err := database.StorePool(&LiquidityPool{PoolID: "p1", TokenASymbol: "GOLD", TokenBSymbol: "SHADOW"}); err != nil {
+		t.Fatalf("Failed to store pool: %v", err)
+	}
+	for i, minutes := range []int{30, 10} {
+		tx := &PoolTransaction{TxHash: string(rune('a' + i)), Type: "swap", Timestamp: now.Add(-time.Duration(minutes) * time.Minute),
+			PriceBefore: 1, PriceAfter: 1.5, AmountA: 10, AmountB: 15}
+		if err := database.StorePoolTransaction("p1", tx); err != nil {
+			t.Fatalf("Failed to store swap: %v", err)
+		}
+	}
+
+	get := func(poolID, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/pool/"+poolID+"/candles"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"poolId": poolID})
+		rec := httptest.NewRecorder()
+		es.handlePoolCandles(rec, req)
+		return rec
+	}
+
+	rec := get("p1", "?interval=1d")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result PoolCandles
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	trades := 0
+	for _, candle := range result.Candles {
+		trades += candle.Trades
+	}
+	if result.Interval != "1d" || trades != 2 {
+		t.Errorf("Expected both swaps in daily candles, got %+v", result)
+	}
+
+	if rec := get("p1", "?interval=2h"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported interval, got %d", rec.Code)
+	}
+	if rec := get("p1", "?limit=0"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a zero limit, got %d", rec.Code)
+	}
+	if rec := get("missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown pool, got %d", rec.Code)
+	}
+}
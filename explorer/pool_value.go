@@ -0,0 +1,208 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+)
+
+// maxPricePathHops bounds how many token/token pools a price is routed
+// through on its way to SHADOW
+const maxPricePathHops = 3
+
+// Which side of a pool holds SHADOW, if either
+const (
+	shadowSideNone = iota
+	shadowSideA
+	shadowSideB
+)
+
+// poolShadowSide reports which side of a pool is SHADOW. Token B is empty
+// for SHADOW pairs; token A is only ever SHADOW by name.
+func poolShadowSide(pool *LiquidityPool) int {
+	switch {
+	case pool.TokenA == "SHADOW":
+		return shadowSideA
+	case sameToken(pool.TokenB, "SHADOW"):
+		return shadowSideB
+	}
+	return shadowSideNone
+}
+
+// priceKey normalizes a token id for the price table, SHADOW being ""
+func priceKey(tokenID string) string {
+	if sameToken(tokenID, "SHADOW") {
+		return ""
+	}
+	return tokenID
+}
+
+// poolPrices returns what one base unit of each side of a pool is worth in
+// SHADOW base units. A SHADOW pair prices its token at its own spot price;
+// a token/token pair carries the prices of its last valuation.
+func poolPrices(pool *LiquidityPool) (priceA, priceB float64) {
+	switch poolShadowSide(pool) {
+	case shadowSideA:
+		if pool.ReserveB == 0 {
+			return 1, 0
+		}
+		return 1, float64(pool.ReserveA) / float64(pool.ReserveB)
+	case shadowSideB:
+		return poolSpotPrice(pool), 1
+	}
+	return pool.PriceA, pool.PriceB
+}
+
+// poolTVL values a pool's reserves in SHADOW. A SHADOW pair holds equal
+// value on both sides at its spot price, so it is worth twice its SHADOW
+// reserve; a token/token pair is valued at its token prices.
+func poolTVL(pool *LiquidityPool) uint64 {
+	switch poolShadowSide(pool) {
+	case shadowSideA:
+		return 2 * pool.ReserveA
+	case shadowSideB:
+		return 2 * pool.ReserveB
+	}
+	return uint64(math.Round(float64(pool.ReserveA)*pool.PriceA + float64(pool.ReserveB)*pool.PriceB))
+}
+
+// swapShadowValue is what a swap traded, in SHADOW: its SHADOW leg in a
+// SHADOW pair, otherwise the mean of both legs at the pool's prices
+func swapShadowValue(pool *LiquidityPool, swap *PoolTransaction) uint64 {
+	switch poolShadowSide(pool) {
+	case shadowSideA:
+		return swap.AmountA
+	case shadowSideB:
+		return swap.AmountB
+	}
+	return uint64(math.Round((float64(swap.AmountA)*pool.PriceA + float64(swap.AmountB)*pool.PriceB) / 2))
+}
+
+// poolTokenPrices prices every token connected to SHADOW through pools, in
+// SHADOW base units per token base unit. Each token takes the price of its
+// deepest connecting pool, depth being the SHADOW value of the side
+// already priced. Tokens paired only with other tokens are priced through
+// those, up to maxPricePathHops pools from SHADOW.
+func poolTokenPrices(pools []LiquidityPool) map[string]float64 {
+	prices := map[string]float64{"": 1}
+
+	type candidate struct {
+		price, depth float64
+	}
+	for hop := 0; hop < maxPricePathHops; hop++ {
+		found := make(map[string]candidate)
+		for i := range pools {
+			pool := &pools[i]
+			if pool.ReserveA == 0 || pool.ReserveB == 0 {
+				continue
+			}
+			a, b := priceKey(pool.TokenA), priceKey(pool.TokenB)
+			priceA, knownA := prices[a]
+			priceB, knownB := prices[b]
+			switch {
+			case knownA && !knownB:
+				depth := float64(pool.ReserveA) * priceA
+				if depth > found[b].depth {
+					found[b] = candidate{price: depth / float64(pool.ReserveB), depth: depth}
+				}
+			case knownB && !knownA:
+				depth := float64(pool.ReserveB) * priceB
+				if depth > found[a].depth {
+					found[a] = candidate{price: depth / float64(pool.ReserveA), depth: depth}
+				}
+			}
+		}
+		if len(found) == 0 {
+			break
+		}
+		for token, c := range found {
+			prices[token] = c.price
+		}
+	}
+	return prices
+}
+
+// refreshPoolValue updates a pool's valuation after its reserves moved. A
+// SHADOW pair is repriced at its new spot price; a token/token pair keeps
+// its prices until pools are next revalued.
+func refreshPoolValue(pool *LiquidityPool) {
+	if poolShadowSide(pool) != shadowSideNone {
+		pool.PriceA, pool.PriceB = poolPrices(pool)
+	}
+	pool.TVL = poolTVL(pool)
+}
+
+// valuePool sets a token/token pool's prices from the price table and
+// recomputes its TVL. A side without a price is valued like the other, at
+// the pool's own ratio; a pool with neither side priced is worth nothing
+// until one is.
+func valuePool(pool *LiquidityPool, prices map[string]float64) {
+	if poolShadowSide(pool) == shadowSideNone {
+		priceA, knownA := prices[priceKey(pool.TokenA)]
+		priceB, knownB := prices[priceKey(pool.TokenB)]
+		switch {
+		case knownA && !knownB && pool.ReserveB > 0:
+			priceB = priceA * float64(pool.ReserveA) / float64(pool.ReserveB)
+		case knownB && !knownA && pool.ReserveA > 0:
+			priceA = priceB * float64(pool.ReserveB) / float64(pool.ReserveA)
+		}
+		pool.PriceA, pool.PriceB = priceA, priceB
+	} else {
+		pool.PriceA, pool.PriceB = poolPrices(pool)
+	}
+	pool.TVL = poolTVL(pool)
+}
+
+// listAllPools reads every pool, a page at a time
+func listAllPools(store Store) ([]LiquidityPool, error) {
+	var pools []LiquidityPool
+	for page := 1; ; page++ {
+		result, err := store.GetPools(page, 100, "")
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, result.Pools...)
+		if page >= result.TotalPages {
+			return pools, nil
+		}
+	}
+}
+
+// revaluePools reprices every pool from the current reserves of all of
+// them and stores those whose valuation moved. A swap in one SHADOW pool
+// moves the value of every token/token pool routed through it.
+func revaluePools(store Store) error {
+	pools, err := listAllPools(store)
+	if err != nil {
+		return err
+	}
+	prices := poolTokenPrices(pools)
+	for i := range pools {
+		pool := &pools[i]
+		tvl, priceA, priceB := pool.TVL, pool.PriceA, pool.PriceB
+		valuePool(pool, prices)
+		if pool.TVL == tvl && pool.PriceA == priceA && pool.PriceB == priceB {
+			continue
+		}
+		if err := store.StorePool(pool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolsTotals sums the SHADOW value locked in and traded through pools
+func poolsTotals(pools []LiquidityPool) (tvl, volume uint64) {
+	for i := range pools {
+		tvl += pools[i].TVL
+		volume += pools[i].VolumeShadow
+	}
+	return tvl, volume
+}
+
+// revaluePools revalues pools after a block touched them; a failure
+// leaves the old valuations until the next one
+func (s *SyncService) revaluePools() {
+	if err := revaluePools(s.database); err != nil {
+		slog.Error("Failed to revalue pools", "err", err)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevaluePoolsRoutesThroughShadow(t *testing.T) {
+	database := newTestDatabase(t)
+	pools := []*LiquidityPool{
+		{PoolID: "gold_shadow", TokenA: "tok_gold", TokenB: "", ReserveA: 1000, ReserveB: 2000},
+		{PoolID: "gold_shadow_shallow", TokenA: "tok_gold", TokenB: "SHADOW", ReserveA: 10, ReserveB: 50},
+		{PoolID: "shadow_iron", TokenA: "SHADOW", TokenB: "tok_iron", ReserveA: 300, ReserveB: 100},
+		{PoolID: "silver_gold", TokenA: "tok_silver", TokenB: "tok_gold", ReserveA: 4000, ReserveB: 1000},
+		{PoolID: "copper_silver", TokenA: "tok_copper", TokenB: "tok_silver", ReserveA: 100, ReserveB: 200},
+		{PoolID: "lead_tin", TokenA: "tok_lead", TokenB: "tok_tin", ReserveA: 100, ReserveB: 100, TVL: 999},
+	}
+	for _, pool := range pools {
+		if err := database.StorePool(pool); err != nil {
+			t.Fatalf("StorePool failed: %v", err)
+		}
+	}
+	if err := revaluePools(database); err != nil {
+		t.Fatalf("revaluePools failed: %v", err)
+	}
+
+	// Gold is priced by its deepest SHADOW pool at 2, silver through gold at
+	// 0.5 and copper through silver at 1; lead and tin have no path
+	want := map[string]struct {
+		tvl            uint64
+		priceA, priceB float64
+	}{
+		"gold_shadow":         {4000, 2, 1},
+		"gold_shadow_shallow": {100, 5, 1},
+		"shadow_iron":         {600, 1, 3},
+		"silver_gold":         {4000, 0.5, 2},
+		"copper_silver":       {200, 1, 0.5},
+		"lead_tin":            {0, 0, 0},
+	}
+	for id, w := range want {
+		pool, err := database.GetPool(id)
+		if err != nil {
+			t.Fatalf("GetPool %s failed: %v", id, err)
+		}
+		if pool.TVL != w.tvl || pool.PriceA != w.priceA || pool.PriceB != w.priceB {
+			t.Errorf("Expected %s valued at %d (%.2f/%.2f), got %d (%.2f/%.2f)",
+				id, w.tvl, w.priceA, w.priceB, pool.TVL, pool.PriceA, pool.PriceB)
+		}
+	}
+}
+
+func TestPoolActivityValuesTokenPairsInShadow(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	pool := &LiquidityPool{TokenA: "tok_silver", TokenB: "tok_gold", PriceA: 0.5, PriceB: 2, TVL: 4000}
+	swaps := []PoolTransaction{{Type: "swap", Timestamp: now.Add(-time.Hour), Direction: swapAToB, AmountA: 400, AmountB: 100}}
+
+	activity := computePoolActivity(pool, swaps, now)
+	if activity.Volume24h != 100 || activity.Volume24hShadow != 200 || activity.Volume7dShadow != 200 {
+		t.Errorf("Expected 100 gold worth 200 SHADOW traded, got %+v", activity)
+	}
+
+	// A swap keeps a token pair's prices and counts its SHADOW value
+	pool.ReserveA, pool.ReserveB = 4000, 1000
+	recordPoolSwap(pool, &swaps[0])
+	if pool.VolumeShadow != 200 || pool.PriceA != 0.5 || pool.TVL != 4400*0.5+900*2 {
+		t.Errorf("Unexpected pool after swap %+v", pool)
+	}
+}
@@ -0,0 +1,3325 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgresStore keeps the explorer index in PostgreSQL. Each record is
+// stored as a JSONB document next to the columns the explorer filters,
+// sorts and aggregates on, so wallet rankings, token search and the daily
+// statistics run as SQL instead of full key scans.
+type PostgresStore struct {
+	db   *sql.DB
+	feed *FeedBroker // live updates for indexed data
+
+	tipMu sync.Mutex
+	tip   *LatestBlock // cached chain tip, cleared whenever a block is stored
+}
+
+// postgresSchema creates the explorer tables. Hash and id columns use the
+// "C" collation so they order bytewise, the same as Badger keys and cursors.
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS blocks (
+	height         BIGINT PRIMARY KEY,
+	hash           TEXT COLLATE "C" NOT NULL,
+	timestamp      TIMESTAMPTZ NOT NULL,
+	size           INTEGER NOT NULL,
+	data           JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS blocks_hash_idx ON blocks (hash);
+CREATE INDEX IF NOT EXISTS blocks_timestamp_idx ON blocks (timestamp);
+
+CREATE TABLE IF NOT EXISTS node_hashes (
+	height BIGINT PRIMARY KEY,
+	hash   TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS block_alerts (
+	height BIGINT PRIMARY KEY,
+	data   JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS orphan_blocks (
+	height BIGINT NOT NULL,
+	hash   TEXT COLLATE "C" NOT NULL,
+	data   JSONB NOT NULL,
+	PRIMARY KEY (height, hash)
+);
+CREATE INDEX IF NOT EXISTS orphan_blocks_hash_idx ON orphan_blocks (hash);
+
+CREATE TABLE IF NOT EXISTS transactions (
+	tx_hash      TEXT COLLATE "C" PRIMARY KEY,
+	block_height BIGINT NOT NULL,
+	timestamp    TIMESTAMPTZ NOT NULL,
+	type         TEXT NOT NULL,
+	amount       BIGINT NOT NULL,
+	fee          BIGINT NOT NULL,
+	from_address TEXT COLLATE "C" NOT NULL,
+	to_address   TEXT COLLATE "C" NOT NULL,
+	data         JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS transactions_height_idx ON transactions (block_height);
+CREATE INDEX IF NOT EXISTS transactions_timestamp_idx ON transactions (timestamp);
+
+CREATE TABLE IF NOT EXISTS address_transactions (
+	address      TEXT COLLATE "C" NOT NULL,
+	block_height BIGINT NOT NULL,
+	tx_hash      TEXT COLLATE "C" NOT NULL,
+	PRIMARY KEY (address, block_height, tx_hash)
+);
+CREATE INDEX IF NOT EXISTS address_transactions_height_idx ON address_transactions (block_height);
+
+CREATE TABLE IF NOT EXISTS mined_blocks (
+	address TEXT COLLATE "C" NOT NULL,
+	height  BIGINT NOT NULL,
+	data    JSONB NOT NULL,
+	PRIMARY KEY (address, height)
+);
+CREATE INDEX IF NOT EXISTS mined_blocks_height_idx ON mined_blocks (height);
+
+CREATE TABLE IF NOT EXISTS balances (
+	address TEXT COLLATE "C" PRIMARY KEY,
+	balance BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS balances_balance_idx ON balances (balance, address);
+
+CREATE TABLE IF NOT EXISTS first_seen (
+	address TEXT COLLATE "C" PRIMARY KEY,
+	day     DATE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS first_seen_day_idx ON first_seen (day);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	key_hash TEXT PRIMARY KEY,
+	data     JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS admin_audit (
+	id   BIGSERIAL PRIMARY KEY,
+	time TIMESTAMPTZ NOT NULL,
+	data JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS jobs (
+	id   TEXT COLLATE "C" PRIMARY KEY,
+	data JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS address_labels (
+	address TEXT COLLATE "C" PRIMARY KEY,
+	data    JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS nft_metadata (
+	token_id     TEXT COLLATE "C" PRIMARY KEY,
+	creator      TEXT COLLATE "C" NOT NULL,
+	status       TEXT NOT NULL,
+	next_attempt TIMESTAMPTZ NOT NULL,
+	data         JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS nft_metadata_due_idx ON nft_metadata (next_attempt) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS nft_metadata_creator_idx ON nft_metadata (creator, token_id);
+
+CREATE TABLE IF NOT EXISTS nft_images (
+	token_id     TEXT COLLATE "C" PRIMARY KEY,
+	content_type TEXT NOT NULL,
+	data         BYTEA NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS watch_subscriptions (
+	id      TEXT PRIMARY KEY,
+	address TEXT NOT NULL,
+	data    JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS watch_subscriptions_address_idx ON watch_subscriptions (address);
+
+CREATE TABLE IF NOT EXISTS watch_notifications (
+	id           TEXT PRIMARY KEY,
+	next_attempt TIMESTAMPTZ NOT NULL,
+	data         JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS watch_notifications_due_idx ON watch_notifications (next_attempt);
+
+CREATE TABLE IF NOT EXISTS chart_samples (
+	height BIGINT PRIMARY KEY,
+	data   JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS chart_rollups (
+	granularity TEXT NOT NULL,
+	bucket      TIMESTAMPTZ NOT NULL,
+	data        JSONB NOT NULL,
+	PRIMARY KEY (granularity, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS chart_addresses (
+	granularity TEXT NOT NULL,
+	bucket      TIMESTAMPTZ NOT NULL,
+	address     TEXT COLLATE "C" NOT NULL,
+	refs        BIGINT NOT NULL,
+	PRIMARY KEY (granularity, bucket, address)
+);
+
+CREATE TABLE IF NOT EXISTS daily_stats (
+	day  TEXT PRIMARY KEY,
+	data JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tokens (
+	token_id       TEXT COLLATE "C" PRIMARY KEY,
+	ticker         TEXT COLLATE "C" NOT NULL,
+	creation_time  BIGINT NOT NULL,
+	creation_block BIGINT NOT NULL,
+	total_supply   BIGINT NOT NULL,
+	melt_value     BIGINT NOT NULL,
+	data           JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS tokens_ticker_idx ON tokens (ticker, token_id);
+CREATE INDEX IF NOT EXISTS tokens_time_idx ON tokens (creation_time, token_id);
+
+CREATE TABLE IF NOT EXISTS token_holders (
+	token_id TEXT COLLATE "C" NOT NULL,
+	address  TEXT COLLATE "C" NOT NULL,
+	balance  BIGINT NOT NULL,
+	PRIMARY KEY (token_id, address)
+);
+CREATE INDEX IF NOT EXISTS token_holders_address_idx ON token_holders (address);
+CREATE INDEX IF NOT EXISTS token_holders_rank_idx ON token_holders (token_id, balance DESC, address);
+
+CREATE TABLE IF NOT EXISTS token_transactions (
+	token_id     TEXT COLLATE "C" NOT NULL,
+	unix_time    BIGINT NOT NULL,
+	tx_hash      TEXT COLLATE "C" NOT NULL,
+	block_height BIGINT NOT NULL,
+	timestamp    TIMESTAMPTZ NOT NULL,
+	type         TEXT NOT NULL,
+	from_address TEXT COLLATE "C" NOT NULL,
+	to_address   TEXT COLLATE "C" NOT NULL,
+	data         JSONB NOT NULL,
+	PRIMARY KEY (token_id, unix_time, tx_hash)
+);
+CREATE INDEX IF NOT EXISTS token_transactions_from_idx ON token_transactions (from_address);
+CREATE INDEX IF NOT EXISTS token_transactions_to_idx ON token_transactions (to_address);
+CREATE INDEX IF NOT EXISTS token_transactions_height_idx ON token_transactions (block_height);
+CREATE INDEX IF NOT EXISTS token_transactions_time_idx ON token_transactions (unix_time);
+
+CREATE TABLE IF NOT EXISTS token_melts (
+	token_id        TEXT COLLATE "C" NOT NULL,
+	unix_time       BIGINT NOT NULL,
+	tx_hash         TEXT COLLATE "C" NOT NULL,
+	block_height    BIGINT NOT NULL,
+	amount          BIGINT NOT NULL,
+	shadow_released BIGINT NOT NULL,
+	data            JSONB NOT NULL,
+	PRIMARY KEY (token_id, unix_time, tx_hash)
+);
+CREATE INDEX IF NOT EXISTS token_melts_height_idx ON token_melts (block_height);
+
+CREATE TABLE IF NOT EXISTS pools (
+	pool_id        TEXT COLLATE "C" PRIMARY KEY,
+	pair           TEXT COLLATE "C" NOT NULL,
+	token_a        TEXT NOT NULL,
+	token_b        TEXT NOT NULL,
+	token_a_symbol TEXT NOT NULL,
+	token_b_symbol TEXT NOT NULL,
+	creation_block BIGINT NOT NULL,
+	tvl            BIGINT NOT NULL,
+	data           JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS pools_pair_idx ON pools (pair, pool_id);
+CREATE INDEX IF NOT EXISTS pools_tvl_idx ON pools (tvl, pool_id);
+CREATE INDEX IF NOT EXISTS pools_l_address_idx ON pools ((data->>'l_address'));
+
+CREATE TABLE IF NOT EXISTS pool_transactions (
+	pool_id      TEXT COLLATE "C" NOT NULL,
+	unix_time    BIGINT NOT NULL,
+	tx_hash      TEXT COLLATE "C" NOT NULL,
+	block_height BIGINT NOT NULL,
+	type         TEXT NOT NULL,
+	data         JSONB NOT NULL,
+	PRIMARY KEY (pool_id, unix_time, tx_hash)
+);
+CREATE INDEX IF NOT EXISTS pool_transactions_height_idx ON pool_transactions (block_height);
+`
+
+// postgresTables lists every table ResetDatabase clears
+const postgresTables = "meta, blocks, node_hashes, block_alerts, orphan_blocks, transactions, address_transactions, mined_blocks, " +
+	"balances, chart_samples, chart_rollups, chart_addresses, first_seen, daily_stats, tokens, token_holders, token_transactions, token_melts, pools, pool_transactions, nft_metadata, nft_images"
+
+// NewPostgresStore connects to the database at dsn and creates the
+// explorer tables if they do not exist yet
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	if _, err := db.Exec(postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create postgres schema: %w", err)
+	}
+
+	store := &PostgresStore{db: db, feed: NewFeedBroker()}
+	if err := store.ensureBalanceIndex(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to build balance index: %w", err)
+	}
+
+	slog.Info("Using PostgreSQL explorer store")
+	return store, nil
+}
+
+// Feed returns the broker that publishes live updates as data is indexed
+func (p *PostgresStore) Feed() *FeedBroker {
+	return p.feed
+}
+
+// Close closes the connection pool
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// DiskUsage reports the size of the database on the server
+func (p *PostgresStore) DiskUsage() (int64, error) {
+	var size int64
+	err := p.db.QueryRow(`SELECT pg_database_size(current_database())`).Scan(&size)
+	return size, err
+}
+
+// Probe performs a tiny write/read round trip to verify the database is writable
+func (p *PostgresStore) Probe() error {
+	stamp := time.Now().UTC().Format(time.RFC3339Nano)
+	if err := setMeta(p.db, "health_probe", stamp); err != nil {
+		return fmt.Errorf("database write failed: %w", err)
+	}
+
+	readBack, err := getMeta(p.db, "health_probe")
+	if err != nil {
+		return fmt.Errorf("database read failed: %w", err)
+	}
+	if readBack != stamp {
+		return fmt.Errorf("database read back %q, expected %q", readBack, stamp)
+	}
+	return nil
+}
+
+// Reopen checks the server is reachable again; the pool reconnects on its own
+func (p *PostgresStore) Reopen() error {
+	return p.db.Ping()
+}
+
+// sqlExecer is satisfied by both *sql.DB and *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// inTx runs fn in a transaction, committing when it returns nil
+func (p *PostgresStore) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func setMeta(db sqlExecer, key, value string) error {
+	_, err := db.Exec(`INSERT INTO meta (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value`, key, value)
+	return err
+}
+
+// getMeta returns the value stored under key, or "" when there is none
+func getMeta(db sqlExecer, key string) (string, error) {
+	var value string
+	err := db.QueryRow(`SELECT value FROM meta WHERE key = $1`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// getDocument unmarshals the single JSONB document query selects into dest
+func getDocument(db sqlExecer, dest interface{}, query string, args ...interface{}) error {
+	var data []byte
+	err := db.QueryRow(query, args...).Scan(&data)
+	if err == sql.ErrNoRows {
+		return errNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// eachDocument calls fn with every JSONB document query selects, in order
+func eachDocument(db sqlExecer, fn func(data []byte) error, query string, args ...interface{}) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// likePrefix builds a LIKE pattern matching values that start with prefix
+func likePrefix(prefix string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(prefix) + "%"
+}
+
+// clampHeight keeps heights within BIGINT range for query parameters
+func clampHeight(height uint64) uint64 {
+	if height > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return height
+}
+
+// utcDay formats a timestamp as the UTC day it falls on
+func utcDay(t time.Time) string {
+	return t.UTC().Format(dailyDateFormat)
+}
+
+// StoreBlock stores a block and moves the latest height to it
+func (p *PostgresStore) StoreBlock(blockHash string, block *Block) error {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("failed to marshal block: %w", err)
+	}
+
+	err = p.inTx(func(tx *sql.Tx) error {
+		// A different block already at this height loses it
+		if err := orphanPostgresBlocks(tx, orphanReplaced, `b.height = $1 AND b.hash <> $2`, block.Header.Height, blockHash); err != nil {
+			return fmt.Errorf("failed to orphan block: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM orphan_blocks WHERE height = $1 AND hash = $2`, block.Header.Height, blockHash); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO blocks (height, hash, timestamp, size, data)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (height) DO UPDATE SET hash = EXCLUDED.hash, timestamp = EXCLUDED.timestamp,
+				size = EXCLUDED.size, data = EXCLUDED.data`,
+			block.Header.Height, blockHash, block.Header.Timestamp, len(data), string(data)); err != nil {
+			return fmt.Errorf("failed to store block: %w", err)
+		}
+		if err := setMeta(tx, "latest_height", strconv.FormatUint(block.Header.Height, 10)); err != nil {
+			return fmt.Errorf("failed to update latest height: %w", err)
+		}
+		return nil
+	})
+	p.invalidateTip()
+
+	return err
+}
+
+// GetBlock retrieves a block by hash
+func (p *PostgresStore) GetBlock(blockHash string) (*Block, error) {
+	var block Block
+	if err := getDocument(p.db, &block, `SELECT data FROM blocks WHERE hash = $1 ORDER BY height DESC LIMIT 1`, blockHash); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// GetBlockByHeight retrieves a block by height
+func (p *PostgresStore) GetBlockByHeight(height uint64) (*Block, error) {
+	var block Block
+	if err := getDocument(p.db, &block, `SELECT data FROM blocks WHERE height = $1`, clampHeight(height)); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// GetBlockRange returns the full blocks from height from to height to
+// inclusive, skipping heights that are not indexed
+func (p *PostgresStore) GetBlockRange(from, to uint64) ([]BlockWithHash, error) {
+	blocks := []BlockWithHash{}
+	if from > math.MaxInt64 {
+		return blocks, nil
+	}
+
+	rows, err := p.db.Query(`SELECT hash, data FROM blocks WHERE height BETWEEN $1 AND $2 ORDER BY height`,
+		from, clampHeight(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry BlockWithHash
+		var data []byte
+		if err := rows.Scan(&entry.Hash, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &entry.Block); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, entry)
+	}
+	return blocks, rows.Err()
+}
+
+// GetLatestHeight returns the latest block height
+func (p *PostgresStore) GetLatestHeight() (uint64, error) {
+	value, err := getMeta(p.db, "latest_height")
+	if err != nil || value == "" {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// GetLatestBlock returns the current chain tip, served from cache until
+// the next block is stored
+func (p *PostgresStore) GetLatestBlock() (*LatestBlock, error) {
+	p.tipMu.Lock()
+	defer p.tipMu.Unlock()
+
+	if p.tip != nil {
+		return p.tip, nil
+	}
+
+	height, err := p.GetLatestHeight()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := p.blockInfos(`WHERE height = $1`, clampHeight(height))
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, errNotFound
+	}
+	block, err := p.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	p.tip = &LatestBlock{Hash: infos[0].Hash, Header: block.Header, Summary: infos[0]}
+	return p.tip, nil
+}
+
+// invalidateTip drops the cached chain tip
+func (p *PostgresStore) invalidateTip() {
+	p.tipMu.Lock()
+	p.tip = nil
+	p.tipMu.Unlock()
+}
+
+// blockInfos loads the explorer summaries of the blocks selected by the
+// filter and ordering in clause
+func (p *PostgresStore) blockInfos(clause string, args ...interface{}) ([]BlockInfo, error) {
+	rows, err := p.db.Query(`SELECT hash, size, data FROM blocks `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var infos []BlockInfo
+	for rows.Next() {
+		var hash string
+		var size int
+		var data []byte
+		if err := rows.Scan(&hash, &size, &data); err != nil {
+			return nil, err
+		}
+		var block Block
+		if err := json.Unmarshal(data, &block); err != nil {
+			slog.Error("Failed to process block", "hash", hash, "err", err)
+			continue
+		}
+		infos = append(infos, BlockInfo{
+			Hash:          hash,
+			Height:        block.Header.Height,
+			Timestamp:     block.Header.Timestamp,
+			TxCount:       int(block.Body.TxCount),
+			FarmerAddress: block.Header.FarmerAddress,
+			Size:          size,
+		})
+	}
+	return infos, rows.Err()
+}
+
+// GetBlocks retrieves blocks with pagination
+func (p *PostgresStore) GetBlocks(page, perPage int) (*PaginatedBlocks, error) {
+	latestHeight, err := p.GetLatestHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	totalBlocks := int64(latestHeight + 1) // Heights start from 0
+	totalPages := int((totalBlocks + int64(perPage) - 1) / int64(perPage))
+
+	if page < 1 {
+		page = 1
+	}
+	if page > totalPages && totalPages > 0 {
+		page = totalPages
+	}
+
+	// Newest first, perPage heights down from the top of the page
+	startHeight := latestHeight - uint64((page-1)*perPage)
+	var lowest uint64
+	if startHeight >= uint64(perPage) {
+		lowest = startHeight - uint64(perPage) + 1
+	}
+	blocks, err := p.blockInfos(`WHERE height BETWEEN $1 AND $2 ORDER BY height DESC`, lowest, clampHeight(startHeight))
+	if err != nil {
+		return nil, err
+	}
+
+	// Let page-based clients switch to a cursor from here
+	var nextCursor string
+	if len(blocks) > 0 && blocks[len(blocks)-1].Height > 0 {
+		nextCursor = encodeCursor([]byte(fmt.Sprintf("height:%016d", blocks[len(blocks)-1].Height)))
+	}
+
+	return &PaginatedBlocks{
+		Blocks:      blocks,
+		CurrentPage: page,
+		TotalPages:  totalPages,
+		TotalBlocks: totalBlocks,
+		PerPage:     perPage,
+		NextCursor:  nextCursor,
+	}, nil
+}
+
+// GetBlocksByCursor retrieves up to limit blocks, newest first, below the
+// block a previous page ended on. An empty cursor starts at the tip.
+func (p *PostgresStore) GetBlocksByCursor(cursor string, limit int) (*PaginatedBlocks, error) {
+	cursorKey, err := decodeCursor(cursor, []byte("height:"))
+	if err != nil {
+		return nil, err
+	}
+	below := uint64(math.MaxInt64)
+	if cursorKey != nil {
+		height, ok := heightFromKey(string(cursorKey), "height:")
+		if !ok {
+			return nil, errInvalidCursor
+		}
+		below = clampHeight(height)
+	}
+
+	totalBlocks, err := p.GetBlockCount()
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra row tells whether another page follows
+	blocks, err := p.blockInfos(`WHERE height < $1 ORDER BY height DESC LIMIT $2`, below, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	var nextCursor string
+	if len(blocks) > limit {
+		blocks = blocks[:limit]
+		nextCursor = encodeCursor([]byte(fmt.Sprintf("height:%016d", blocks[len(blocks)-1].Height)))
+	}
+	if blocks == nil {
+		blocks = []BlockInfo{}
+	}
+
+	return &PaginatedBlocks{
+		Blocks:      blocks,
+		TotalBlocks: totalBlocks,
+		PerPage:     limit,
+		NextCursor:  nextCursor,
+	}, nil
+}
+
+// GetBlockCount returns the total number of blocks
+func (p *PostgresStore) GetBlockCount() (int64, error) {
+	height, err := p.GetLatestHeight()
+	if err != nil {
+		return 0, err
+	}
+	return int64(height + 1), nil
+}
+
+// SetLastSyncTime stores the last sync timestamp
+func (p *PostgresStore) SetLastSyncTime(t time.Time) error {
+	return setMeta(p.db, "last_sync", t.Format(time.RFC3339Nano))
+}
+
+// GetLastSyncTime retrieves the last sync timestamp
+func (p *PostgresStore) GetLastSyncTime() (time.Time, error) {
+	value, err := getMeta(p.db, "last_sync")
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// SetNodeHash records the node's hash for the block at height
+func (p *PostgresStore) SetNodeHash(height uint64, hash string) error {
+	_, err := p.db.Exec(`INSERT INTO node_hashes (height, hash) VALUES ($1, $2)
+		ON CONFLICT (height) DO UPDATE SET hash = EXCLUDED.hash`, clampHeight(height), hash)
+	return err
+}
+
+// GetNodeHash returns the node's hash for the block at height, or "" when
+// none was recorded
+func (p *PostgresStore) GetNodeHash(height uint64) (string, error) {
+	var hash string
+	err := p.db.QueryRow(`SELECT hash FROM node_hashes WHERE height = $1`, clampHeight(height)).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+// StoreBlockAlert saves the alert for a block, replacing any earlier one
+func (p *PostgresStore) StoreBlockAlert(alert *BlockAlert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO block_alerts (height, data) VALUES ($1, $2)
+		ON CONFLICT (height) DO UPDATE SET data = EXCLUDED.data`, clampHeight(alert.Height), string(data))
+	return err
+}
+
+// GetBlockAlert returns the alert for the block at height
+func (p *PostgresStore) GetBlockAlert(height uint64) (*BlockAlert, error) {
+	var alert BlockAlert
+	if err := getDocument(p.db, &alert, `SELECT data FROM block_alerts WHERE height = $1`, clampHeight(height)); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// GetBlockAlerts returns up to limit alerts, highest block first, and how
+// many there are in all
+func (p *PostgresStore) GetBlockAlerts(limit int) (*BlockAlerts, error) {
+	result := &BlockAlerts{Alerts: []BlockAlert{}}
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM block_alerts`).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+	err := eachDocument(p.db, func(data []byte) error {
+		var alert BlockAlert
+		if err := json.Unmarshal(data, &alert); err != nil {
+			return err
+		}
+		result.Alerts = append(result.Alerts, alert)
+		return nil
+	}, `SELECT data FROM block_alerts ORDER BY height DESC LIMIT $1`, limit)
+	return result, err
+}
+
+// orphanPostgresBlocks copies the blocks matching where (over blocks b) to
+// orphan_blocks, with the node's hash for each. The caller removes them.
+func orphanPostgresBlocks(tx *sql.Tx, reason, where string, args ...interface{}) error {
+	rows, err := tx.Query(`SELECT b.hash, COALESCE(n.hash, ''), b.data
+		FROM blocks b LEFT JOIN node_hashes n ON n.height = b.height
+		WHERE `+where, args...)
+	if err != nil {
+		return err
+	}
+	var orphans []*OrphanBlock
+	for rows.Next() {
+		var hash, nodeHash string
+		var data []byte
+		if err := rows.Scan(&hash, &nodeHash, &data); err != nil {
+			rows.Close()
+			return err
+		}
+		var block Block
+		if err := json.Unmarshal(data, &block); err != nil {
+			rows.Close()
+			return err
+		}
+		orphans = append(orphans, newOrphanBlock(hash, nodeHash, &block, reason))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, orphan := range orphans {
+		data, err := json.Marshal(orphan)
+		if err != nil {
+			return fmt.Errorf("failed to marshal orphan block: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO orphan_blocks (height, hash, data) VALUES ($1, $2, $3)
+			ON CONFLICT (height, hash) DO UPDATE SET data = EXCLUDED.data`,
+			orphan.Height, orphan.Hash, string(data)); err != nil {
+			return err
+		}
+		slog.Warn("Block orphaned", "height", orphan.Height, "hash", orphan.Hash, "reason", reason)
+	}
+	return nil
+}
+
+// GetOrphanBlock returns the orphaned block stored under hash, with its body
+func (p *PostgresStore) GetOrphanBlock(hash string) (*OrphanBlock, error) {
+	var orphan OrphanBlock
+	if err := getDocument(p.db, &orphan, `SELECT data FROM orphan_blocks WHERE hash = $1 ORDER BY height DESC LIMIT 1`, hash); err != nil {
+		return nil, err
+	}
+	return &orphan, nil
+}
+
+// GetOrphanBlocks returns up to limit orphaned blocks, highest first and
+// without their bodies, and how many there are in all
+func (p *PostgresStore) GetOrphanBlocks(limit int) (*OrphanBlocks, error) {
+	result := &OrphanBlocks{Orphans: []OrphanBlock{}}
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM orphan_blocks`).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+	err := eachDocument(p.db, func(data []byte) error {
+		var orphan OrphanBlock
+		if err := json.Unmarshal(data, &orphan); err != nil {
+			return err
+		}
+		result.Orphans = append(result.Orphans, orphan)
+		return nil
+	}, `SELECT data - 'block' FROM orphan_blocks ORDER BY height DESC, hash LIMIT $1`, limit)
+	return result, err
+}
+
+// ResetDatabase clears all explorer data for fresh sync
+func (p *PostgresStore) ResetDatabase() error {
+	defer p.invalidateTip()
+	_, err := p.db.Exec(`TRUNCATE ` + postgresTables)
+	return err
+}
+
+// StoreTransaction stores an individual transaction with address indexing
+func (p *PostgresStore) StoreTransaction(tx *WalletTransaction) error {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transaction: %w", err)
+	}
+
+	err = p.inTx(func(dbTx *sql.Tx) error {
+		// Re-indexing a transaction replaces its effect on the balance index
+		if err := adjustPostgresBalances(dbTx, -1, `tx_hash = $1`, tx.TxHash); err != nil {
+			return fmt.Errorf("failed to update balance index: %w", err)
+		}
+
+		if _, err := dbTx.Exec(`INSERT INTO transactions
+			(tx_hash, block_height, timestamp, type, amount, fee, from_address, to_address, data)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (tx_hash) DO UPDATE SET block_height = EXCLUDED.block_height,
+				timestamp = EXCLUDED.timestamp, type = EXCLUDED.type, amount = EXCLUDED.amount,
+				fee = EXCLUDED.fee, from_address = EXCLUDED.from_address,
+				to_address = EXCLUDED.to_address, data = EXCLUDED.data`,
+			tx.TxHash, tx.BlockHeight, tx.Timestamp, tx.Type, tx.Amount, tx.Fee,
+			tx.FromAddress, tx.ToAddress, string(data)); err != nil {
+			return fmt.Errorf("failed to store transaction: %w", err)
+		}
+		if err := adjustPostgresBalances(dbTx, 1, `tx_hash = $1`, tx.TxHash); err != nil {
+			return fmt.Errorf("failed to update balance index: %w", err)
+		}
+
+		for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+			if address == "" {
+				continue
+			}
+			if _, err := dbTx.Exec(`INSERT INTO address_transactions (address, block_height, tx_hash)
+				VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, address, tx.BlockHeight, tx.TxHash); err != nil {
+				return fmt.Errorf("failed to store address index: %w", err)
+			}
+			// Track the day each address first appeared
+			if _, err := dbTx.Exec(`INSERT INTO first_seen (address, day) VALUES ($1, $2)
+				ON CONFLICT (address) DO UPDATE SET day = LEAST(first_seen.day, EXCLUDED.day)`,
+				address, utcDay(tx.Timestamp)); err != nil {
+				return fmt.Errorf("failed to update first-seen index: %w", err)
+			}
+		}
+
+		// Mining rewards feed the farmer index
+		if tx.Type == "mining_reward" && tx.ToAddress != "" {
+			mined, err := json.Marshal(MinedBlock{
+				Height:    tx.BlockHeight,
+				Hash:      tx.BlockHash,
+				Timestamp: tx.Timestamp,
+				Reward:    tx.Amount,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to marshal mined block: %w", err)
+			}
+			if _, err := dbTx.Exec(`INSERT INTO mined_blocks (address, height, data) VALUES ($1, $2, $3)
+				ON CONFLICT (address, height) DO UPDATE SET data = EXCLUDED.data`,
+				tx.ToAddress, tx.BlockHeight, string(mined)); err != nil {
+				return fmt.Errorf("failed to store farmer index: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Wake wallet long-polls waiting on either side
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		if address != "" {
+			p.feed.Publish(walletFeedTopic(address), FeedEvent{Type: "transaction", Data: tx.TxHash})
+		}
+	}
+	return nil
+}
+
+// GetTransaction returns the wallet transaction stored under txHash
+func (p *PostgresStore) GetTransaction(txHash string) (*WalletTransaction, error) {
+	var tx WalletTransaction
+	if err := getDocument(p.db, &tx, `SELECT data FROM transactions WHERE tx_hash = $1`, txHash); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// GetFarmerBlocks returns every block address farmed, oldest first
+func (p *PostgresStore) GetFarmerBlocks(address string) ([]MinedBlock, error) {
+	blocks := []MinedBlock{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var block MinedBlock
+		if json.Unmarshal(data, &block) == nil {
+			block.Address = address
+			blocks = append(blocks, block)
+		}
+		return nil
+	}, `SELECT data FROM mined_blocks WHERE address = $1 ORDER BY height`, address)
+	return blocks, err
+}
+
+// GetMinedBlocksSince returns every block won at or after since with its
+// farmer, oldest first
+func (p *PostgresStore) GetMinedBlocksSince(since time.Time) ([]MinedBlock, error) {
+	blocks := []MinedBlock{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var block MinedBlock
+		if json.Unmarshal(data, &block) == nil {
+			blocks = append(blocks, block)
+		}
+		return nil
+	}, `SELECT m.data || jsonb_build_object('address', m.address) FROM mined_blocks m
+		JOIN blocks b ON b.height = m.height WHERE b.timestamp >= $1 ORDER BY m.height`, since)
+	return blocks, err
+}
+
+// GetMinedBlocks retrieves the blocks farmed by an address, newest first
+func (p *PostgresStore) GetMinedBlocks(address string, page, perPage int) (*PaginatedMinedBlocks, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	var totalBlocks int64
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM mined_blocks WHERE address = $1`, address).Scan(&totalBlocks); err != nil {
+		return nil, err
+	}
+
+	blocks := []MinedBlock{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var block MinedBlock
+		if err := json.Unmarshal(data, &block); err != nil {
+			slog.Error("Failed to unmarshal mined block", "address", address, "err", err)
+			return nil
+		}
+		blocks = append(blocks, block)
+		return nil
+	}, `SELECT data FROM mined_blocks WHERE address = $1 ORDER BY height DESC LIMIT $2 OFFSET $3`,
+		address, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedMinedBlocks{
+		Address:     address,
+		Blocks:      blocks,
+		CurrentPage: page,
+		TotalPages:  int((totalBlocks + int64(perPage) - 1) / int64(perPage)),
+		TotalBlocks: totalBlocks,
+		PerPage:     perPage,
+	}, nil
+}
+
+// addressTransactionsQuery selects an address's transaction documents
+// through the address index; callers append the filter and order
+const addressTransactionsQuery = `SELECT a.block_height, a.tx_hash, t.data
+	FROM address_transactions a JOIN transactions t ON t.tx_hash = a.tx_hash
+	WHERE a.address = $1 `
+
+// queryWalletTransactions runs an addressTransactionsQuery, returning the
+// transactions and the index position of the last one
+func (p *PostgresStore) queryWalletTransactions(query string, args ...interface{}) ([]WalletTransaction, uint64, string, error) {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	defer rows.Close()
+
+	var transactions []WalletTransaction
+	var lastHeight uint64
+	var lastHash string
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&lastHeight, &lastHash, &data); err != nil {
+			return nil, 0, "", err
+		}
+		var tx WalletTransaction
+		if err := json.Unmarshal(data, &tx); err != nil {
+			slog.Error("Failed to unmarshal transaction", "tx_hash", lastHash, "err", err)
+			continue
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, lastHeight, lastHash, rows.Err()
+}
+
+// GetWalletTransactions retrieves transactions for an address, newest first
+func (p *PostgresStore) GetWalletTransactions(address string, limit int) ([]WalletTransaction, error) {
+	transactions, _, _, err := p.queryWalletTransactions(addressTransactionsQuery+
+		`ORDER BY a.block_height DESC, a.tx_hash DESC LIMIT $2`, address, limit)
+	return transactions, err
+}
+
+// GetWalletTransactionsPage retrieves up to limit transactions for an
+// address, newest first. With a cursor it continues below the transaction a
+// previous page ended on; otherwise page selects an offset from the newest.
+// Cursors use the same key format as the Badger store.
+func (p *PostgresStore) GetWalletTransactionsPage(address, cursor string, page, limit int) (*PaginatedTransactions, error) {
+	prefix := fmt.Sprintf("addr_tx:%s:", address)
+	cursorKey, err := decodeCursor(cursor, []byte(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	belowHeight, belowHash := uint64(math.MaxInt64), ""
+	skip := 0
+	if cursorKey != nil {
+		rest := strings.TrimPrefix(string(cursorKey), prefix)
+		parts := strings.SplitN(rest, ":", 2)
+		height, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil || len(parts) != 2 {
+			return nil, errInvalidCursor
+		}
+		belowHeight, belowHash = clampHeight(height), parts[1]
+	} else if page > 1 {
+		skip = (page - 1) * limit
+	}
+
+	// One extra row tells whether another page follows
+	transactions, _, _, err := p.queryWalletTransactions(addressTransactionsQuery+
+		`AND (a.block_height, a.tx_hash) < ($2, $3)
+		ORDER BY a.block_height DESC, a.tx_hash DESC LIMIT $4 OFFSET $5`,
+		address, belowHeight, belowHash, limit+1, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextCursor string
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[len(transactions)-1]
+		nextCursor = encodeCursor([]byte(fmt.Sprintf("%s%016d:%s", prefix, last.BlockHeight, last.TxHash)))
+	}
+	if transactions == nil {
+		transactions = []WalletTransaction{}
+	}
+
+	return &PaginatedTransactions{
+		Address:      address,
+		Transactions: transactions,
+		PerPage:      limit,
+		NextCursor:   nextCursor,
+	}, nil
+}
+
+// GetWalletTransactionsAfter returns up to limit transactions for an
+// address in blocks above height, oldest first
+func (p *PostgresStore) GetWalletTransactionsAfter(address string, height uint64, limit int) ([]WalletTransaction, error) {
+	if height >= math.MaxInt64 {
+		return []WalletTransaction{}, nil
+	}
+	transactions, _, _, err := p.queryWalletTransactions(addressTransactionsQuery+
+		`AND a.block_height > $2 ORDER BY a.block_height, a.tx_hash LIMIT $3`, address, height, limit)
+	if transactions == nil {
+		transactions = []WalletTransaction{}
+	}
+	return transactions, err
+}
+
+// walletAggregates are the per-address totals GetWalletSummary derives in
+// Go, computed over address_transactions a joined to transactions t
+const walletAggregates = `COUNT(*) AS tx_count,
+	COALESCE(SUM(CASE WHEN t.to_address = a.address THEN t.amount ELSE 0 END), 0)
+		- COALESCE(SUM(CASE WHEN t.from_address = a.address THEN t.amount + t.fee ELSE 0 END), 0) AS balance,
+	COUNT(*) FILTER (WHERE t.from_address = '' AND t.to_address = a.address) AS blocks_mined,
+	MIN(t.timestamp) AS first_activity,
+	MAX(t.timestamp) AS last_activity`
+
+// scanWalletAggregates reads the walletAggregates columns into overview
+func scanWalletAggregates(scan func(dest ...interface{}) error, prefix []interface{}, overview *WalletOverview) error {
+	var balance int64
+	var first, last sql.NullTime
+	dest := append(prefix, &overview.TransactionCount, &balance, &overview.BlocksMined, &first, &last)
+	if err := scan(dest...); err != nil {
+		return err
+	}
+
+	// Over-spent wallets wrap like the unsigned sums in the Badger store
+	overview.Balance = uint64(balance)
+	if first.Valid {
+		overview.FirstActivity = first.Time.UTC()
+	}
+	if last.Valid {
+		overview.LastActivity = last.Time.UTC()
+	}
+	return nil
+}
+
+// EachWalletTransaction calls fn for every transaction of an address,
+// oldest first, as rows arrive. It stops at the first error fn returns.
+func (p *PostgresStore) EachWalletTransaction(address string, fn func(tx *WalletTransaction) error) error {
+	return eachDocument(p.db, func(data []byte) error {
+		var tx WalletTransaction
+		if err := json.Unmarshal(data, &tx); err != nil {
+			slog.Error("Failed to unmarshal transaction", "address", address, "err", err)
+			return nil
+		}
+		return fn(&tx)
+	}, `SELECT t.data FROM address_transactions a JOIN transactions t ON t.tx_hash = a.tx_hash
+		WHERE a.address = $1 ORDER BY a.block_height, a.tx_hash`, address)
+}
+
+// GetWalletSummary gets wallet statistics
+func (p *PostgresStore) GetWalletSummary(address string) (*WalletSummary, error) {
+	// Recent transactions for display
+	transactions, err := p.GetWalletTransactions(address, 50)
+	if err != nil {
+		return nil, err
+	}
+
+	var overview WalletOverview
+	row := p.db.QueryRow(`SELECT `+walletAggregates+`
+		FROM address_transactions a JOIN transactions t ON t.tx_hash = a.tx_hash
+		WHERE a.address = $1`, address)
+	if err := scanWalletAggregates(row.Scan, nil, &overview); err != nil {
+		return nil, err
+	}
+
+	tokenBalances, err := p.GetWalletTokenBalances(address)
+	if err != nil {
+		slog.Error("Failed to get token balances", "address", address, "err", err)
+		tokenBalances = []TokenBalance{} // Continue with empty token balances
+	}
+
+	return &WalletSummary{
+		Address:          address,
+		Balance:          overview.Balance,
+		TransactionCount: overview.TransactionCount,
+		BlocksMined:      overview.BlocksMined,
+		FirstActivity:    overview.FirstActivity,
+		LastActivity:     overview.LastActivity,
+		Transactions:     transactions,
+		TokenBalances:    tokenBalances,
+	}, nil
+}
+
+// GetAllWallets gets a page of wallets, richest first
+func (p *PostgresStore) GetAllWallets(limit int, offset int) ([]WalletOverview, int64, error) {
+	var total int64
+	if err := p.db.QueryRow(`SELECT COUNT(DISTINCT address) FROM address_transactions`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	wallets, err := p.queryWallets(`GROUP BY a.address
+		ORDER BY balance DESC, a.address
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return wallets, total, nil
+}
+
+// GetWalletsByCursor retrieves up to limit wallets in address order, after
+// the wallet a previous page ended on. An empty cursor starts at the first.
+func (p *PostgresStore) GetWalletsByCursor(cursor string, limit int) (*PaginatedWallets, error) {
+	after, err := walletCursorAddress(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := p.db.QueryRow(`SELECT COUNT(DISTINCT address) FROM address_transactions`).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// One extra row tells whether another page follows
+	wallets, err := p.queryWallets(`WHERE a.address > $1
+		GROUP BY a.address
+		ORDER BY a.address
+		LIMIT $2`, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	var nextCursor string
+	if len(wallets) > limit {
+		wallets = wallets[:limit]
+		nextCursor = walletCursor(wallets[len(wallets)-1].Address)
+	}
+
+	return &PaginatedWallets{
+		Wallets:      wallets,
+		TotalWallets: total,
+		PerPage:      limit,
+		NextCursor:   nextCursor,
+	}, nil
+}
+
+// queryWallets summarizes the addresses selected by clauses, which follow
+// the join of address_transactions a with transactions t, and adds their
+// token balances
+func (p *PostgresStore) queryWallets(clauses string, args ...interface{}) ([]WalletOverview, error) {
+	rows, err := p.db.Query(`SELECT a.address, `+walletAggregates+`
+		FROM address_transactions a JOIN transactions t ON t.tx_hash = a.tx_hash
+		`+clauses, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wallets := []WalletOverview{}
+	for rows.Next() {
+		var wallet WalletOverview
+		if err := scanWalletAggregates(rows.Scan, []interface{}{&wallet.Address}, &wallet); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range wallets {
+		tokenBalances, err := p.GetWalletTokenBalances(wallets[i].Address)
+		if err != nil {
+			slog.Error("Failed to get token balances", "address", wallets[i].Address, "err", err)
+			tokenBalances = []TokenBalance{} // Continue with empty token balances
+		}
+		wallets[i].TokenBalances = tokenBalances
+	}
+	return wallets, nil
+}
+
+// ListAddresses returns up to limit addresses with transactions, in
+// order, starting after the given address ("" for the first)
+func (p *PostgresStore) ListAddresses(after string, limit int) ([]string, error) {
+	addresses := []string{}
+	err := eachDocument(p.db, func(data []byte) error {
+		addresses = append(addresses, string(data))
+		return nil
+	}, `SELECT DISTINCT address FROM address_transactions WHERE address > $1 ORDER BY address LIMIT $2`, after, limit)
+	return addresses, err
+}
+
+// adjustPostgresBalances adds (sign 1) or removes (sign -1) the balance
+// effect of the transactions matching filter, whose only parameter is arg:
+// recipients gain the amount and senders pay the amount plus the fee
+func adjustPostgresBalances(db sqlExecer, sign int, filter string, arg interface{}) error {
+	_, err := db.Exec(fmt.Sprintf(`INSERT INTO balances (address, balance)
+		SELECT address, %[1]d * SUM(delta) FROM (
+			SELECT to_address AS address, amount AS delta FROM transactions
+				WHERE %[2]s AND to_address <> ''
+			UNION ALL
+			SELECT from_address, -(amount + fee) FROM transactions
+				WHERE %[2]s AND from_address <> ''
+		) deltas GROUP BY address
+		ON CONFLICT (address) DO UPDATE SET balance = balances.balance + EXCLUDED.balance`, sign, filter), arg)
+	return err
+}
+
+// ensureBalanceIndex fills the balance table from stored transactions
+// unless it has been built before
+func (p *PostgresStore) ensureBalanceIndex() error {
+	built, err := getMeta(p.db, "index:balances")
+	if err != nil || built != "" {
+		return err
+	}
+
+	return p.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM balances`); err != nil {
+			return err
+		}
+		if err := adjustPostgresBalances(tx, 1, `block_height >= $1`, 0); err != nil {
+			return err
+		}
+		return setMeta(tx, "index:balances", time.Now().UTC().Format(time.RFC3339))
+	})
+}
+
+// GetRichList returns the limit wallets with the largest SHADOW balances
+func (p *PostgresStore) GetRichList(limit int) (*RichList, error) {
+	list := &RichList{Holders: []RichListEntry{}}
+	if err := p.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(balance), 0) FROM balances WHERE balance > 0`).
+		Scan(&list.TotalHolders, &list.TotalBalance); err != nil {
+		return nil, err
+	}
+
+	// Ties order like the Badger index, which reads addresses in reverse
+	rows, err := p.db.Query(`SELECT address, balance FROM balances WHERE balance > 0
+		ORDER BY balance DESC, address DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := RichListEntry{Rank: len(list.Holders) + 1}
+		if err := rows.Scan(&entry.Address, &entry.Balance); err != nil {
+			return nil, err
+		}
+		list.Holders = append(list.Holders, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list.computeShares()
+	return list, nil
+}
+
+// GetBalanceDistribution buckets every positive wallet balance and
+// measures how concentrated they are, aggregating in SQL
+func (p *PostgresStore) GetBalanceDistribution() (*BalanceDistribution, error) {
+	dist := &BalanceDistribution{Buckets: emptyBalanceBuckets()}
+
+	bounds := make([]string, len(balanceBucketBounds))
+	for i, bound := range balanceBucketBounds {
+		bounds[i] = strconv.FormatUint(bound, 10)
+	}
+	// width_bucket counts the bounds at or below each balance, which is
+	// the bucket index balanceBucketIndex computes
+	rows, err := p.db.Query(`SELECT width_bucket(balance, ARRAY[` + strings.Join(bounds, ",") + `]::bigint[]),
+			COUNT(*), SUM(balance)
+		FROM balances WHERE balance > 0 GROUP BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var index, holders int
+		var balance uint64
+		if err := rows.Scan(&index, &holders, &balance); err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= len(dist.Buckets) {
+			continue
+		}
+		dist.Buckets[index].Holders = holders
+		dist.Buckets[index].Balance = balance
+		dist.TotalHolders += holders
+		dist.TotalBalance += balance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	dist.computeShares()
+
+	if dist.TotalBalance == 0 {
+		return dist, nil
+	}
+
+	// Gini over ascending balances: 2*sum(i*x_i)/(n*sum(x)) - (n+1)/n,
+	// the same formula holderConcentration applies in memory
+	var weighted, top10 float64
+	if err := p.db.QueryRow(`SELECT COALESCE(SUM(rank * balance), 0) FROM (
+			SELECT balance, ROW_NUMBER() OVER (ORDER BY balance) AS rank FROM balances WHERE balance > 0
+		) ranked`).Scan(&weighted); err != nil {
+		return nil, err
+	}
+	if err := p.db.QueryRow(`SELECT COALESCE(SUM(balance), 0) FROM (
+			SELECT balance FROM balances WHERE balance > 0 ORDER BY balance DESC LIMIT 10
+		) top`).Scan(&top10); err != nil {
+		return nil, err
+	}
+
+	n, total := float64(dist.TotalHolders), float64(dist.TotalBalance)
+	dist.Gini = 2*weighted/(n*total) - (n+1)/n
+	dist.Top10Share = top10 / total
+	return dist, nil
+}
+
+// GetWalletTokenBalances gets all token balances for a wallet address
+func (p *PostgresStore) GetWalletTokenBalances(address string) ([]TokenBalance, error) {
+	rows, err := p.db.Query(`SELECT h.token_id, h.balance, t.data
+		FROM token_holders h JOIN tokens t ON t.token_id = h.token_id
+		WHERE h.address = $1 AND h.balance > 0
+		ORDER BY h.token_id`, address)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []TokenBalance
+	for rows.Next() {
+		var balance TokenBalance
+		var data []byte
+		if err := rows.Scan(&balance.TokenID, &balance.Balance, &data); err != nil {
+			return nil, err
+		}
+		var token TokenInfo
+		if err := json.Unmarshal(data, &token); err != nil {
+			slog.Error("Failed to get token info", "token_id", balance.TokenID, "err", err)
+			continue
+		}
+		balance.TokenName = token.Name
+		balance.TokenTicker = token.Ticker
+		balance.Decimals = token.Decimals
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// GetBalanceDeltas returns every change to address's balance, by block in
+// height order, summed from the address index
+func (p *PostgresStore) GetBalanceDeltas(address string) ([]BalanceDelta, error) {
+	rows, err := p.db.Query(`SELECT t.block_height, MAX(t.timestamp),
+			SUM(CASE WHEN t.to_address = $1 THEN t.amount ELSE 0 END)
+				- SUM(CASE WHEN t.from_address = $1 THEN t.amount + t.fee ELSE 0 END) AS delta
+		FROM address_transactions a JOIN transactions t ON t.tx_hash = a.tx_hash
+		WHERE a.address = $1
+		GROUP BY t.block_height
+		HAVING SUM(CASE WHEN t.to_address = $1 THEN t.amount ELSE 0 END)
+			<> SUM(CASE WHEN t.from_address = $1 THEN t.amount + t.fee ELSE 0 END)
+		ORDER BY t.block_height`, address)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deltas := []BalanceDelta{}
+	for rows.Next() {
+		var delta BalanceDelta
+		if err := rows.Scan(&delta.Height, &delta.Timestamp, &delta.Delta); err != nil {
+			return nil, err
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas, rows.Err()
+}
+
+// CheckSupply recomputes circulating supply from the transaction table and
+// compares it with the sum of wallet balances derived through the address
+// index, like the Badger store's check
+func (p *PostgresStore) CheckSupply() (*SupplyCheck, error) {
+	check := &SupplyCheck{CheckedAt: time.Now().UTC()}
+
+	err := p.db.QueryRow(`SELECT COUNT(*),
+		COALESCE(SUM(amount) FILTER (WHERE type = 'mining_reward'), 0),
+		COALESCE(SUM(fee) FILTER (WHERE type <> 'mining_reward'), 0)
+			+ COALESCE(SUM(amount) FILTER (WHERE type <> 'mining_reward' AND to_address = ''), 0)
+		FROM transactions`).Scan(&check.TransactionsChecked, &check.Emission, &check.Burned)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.Query(`SELECT a.address,
+		COALESCE(SUM(CASE WHEN t.to_address = a.address THEN t.amount ELSE 0 END), 0)
+			- COALESCE(SUM(CASE WHEN t.from_address = a.address THEN t.amount + t.fee ELSE 0 END), 0)
+		FROM address_transactions a JOIN transactions t ON t.tx_hash = a.tx_hash
+		GROUP BY a.address`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make(map[string]int64)
+	for rows.Next() {
+		var address string
+		var balance int64
+		if err := rows.Scan(&address, &balance); err != nil {
+			return nil, err
+		}
+		balances[address] = balance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	check.reconcile(balances)
+	return check, nil
+}
+
+// StoreToken stores token information
+func (p *PostgresStore) StoreToken(token *TokenInfo) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	_, err = p.db.Exec(`INSERT INTO tokens
+		(token_id, ticker, creation_time, creation_block, total_supply, melt_value, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (token_id) DO UPDATE SET ticker = EXCLUDED.ticker,
+			creation_time = EXCLUDED.creation_time, creation_block = EXCLUDED.creation_block,
+			total_supply = EXCLUDED.total_supply, melt_value = EXCLUDED.melt_value, data = EXCLUDED.data`,
+		token.TokenID, token.Ticker, token.CreationTime.Unix(), token.CreationBlock,
+		token.TotalSupply, token.MeltValue, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store token: %w", err)
+	}
+	return nil
+}
+
+// GetTokens retrieves tokens with pagination and optional search. A search
+// matches ticker prefixes; otherwise tokens are listed newest first.
+func (p *PostgresStore) GetTokens(page, perPage int, search string) (*PaginatedTokens, error) {
+	where, order := ``, `ORDER BY creation_time DESC, token_id DESC`
+	args := []interface{}{}
+	if search != "" {
+		where, order = `WHERE ticker LIKE $1`, `ORDER BY ticker, token_id`
+		args = append(args, likePrefix(search))
+	}
+
+	var totalTokens int64
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM tokens `+where, args...).Scan(&totalTokens); err != nil {
+		return nil, err
+	}
+
+	totalPages := int((totalTokens + int64(perPage) - 1) / int64(perPage))
+	if page < 1 {
+		page = 1
+	}
+	if page > totalPages && totalPages > 0 {
+		page = totalPages
+	}
+
+	var tokens []TokenInfo
+	n := len(args)
+	err := eachDocument(p.db, func(data []byte) error {
+		var token TokenInfo
+		if err := json.Unmarshal(data, &token); err != nil {
+			slog.Error("Failed to unmarshal token", "err", err)
+			return nil // Skip invalid tokens
+		}
+		tokens = append(tokens, token)
+		return nil
+	}, fmt.Sprintf(`SELECT data FROM tokens %s %s LIMIT $%d OFFSET $%d`, where, order, n+1, n+2),
+		append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregates, err := p.GetTokenAggregates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedTokens{
+		Tokens:      tokens,
+		CurrentPage: page,
+		TotalPages:  totalPages,
+		TotalTokens: totalTokens,
+		PerPage:     perPage,
+		Aggregates:  *aggregates,
+	}, nil
+}
+
+// GetTokensByCursor retrieves up to limit tokens after the token a previous
+// page ended on, in the same order as GetTokens. Cursors name the Badger
+// index key of the last token, so they work with either backend.
+func (p *PostgresStore) GetTokensByCursor(search, cursor string, limit int) (*PaginatedTokens, error) {
+	prefix := "token_time:"
+	if search != "" {
+		prefix = "token_ticker:" + search
+	}
+	cursorKey, err := decodeCursor(cursor, []byte(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions []string
+	order := `ORDER BY creation_time DESC, token_id DESC`
+	args := []interface{}{}
+	if search != "" {
+		conditions = append(conditions, `ticker LIKE $1`)
+		order = `ORDER BY ticker, token_id`
+		args = append(args, likePrefix(search))
+	}
+
+	var totalTokens int64
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM tokens `+whereAll(conditions), args...).Scan(&totalTokens); err != nil {
+		return nil, err
+	}
+
+	if cursorKey != nil {
+		if search == "" {
+			created, tokenID, ok := cursorKeyParts(cursorKey, "token_time:")
+			unix, err := strconv.ParseInt(created, 10, 64)
+			if !ok || err != nil {
+				return nil, errInvalidCursor
+			}
+			conditions = append(conditions, fmt.Sprintf(`(creation_time, token_id) < ($%d, $%d)`, len(args)+1, len(args)+2))
+			args = append(args, unix, tokenID)
+		} else {
+			ticker, tokenID, ok := cursorKeyParts(cursorKey, "token_ticker:")
+			if !ok {
+				return nil, errInvalidCursor
+			}
+			conditions = append(conditions, fmt.Sprintf(`(ticker, token_id) > ($%d, $%d)`, len(args)+1, len(args)+2))
+			args = append(args, ticker, tokenID)
+		}
+	}
+
+	tokens := []TokenInfo{}
+	err = eachDocument(p.db, func(data []byte) error {
+		var token TokenInfo
+		if err := json.Unmarshal(data, &token); err != nil {
+			slog.Error("Failed to unmarshal token", "err", err)
+			return nil // Skip invalid tokens
+		}
+		tokens = append(tokens, token)
+		return nil
+	}, fmt.Sprintf(`SELECT data FROM tokens %s %s LIMIT $%d`, whereAll(conditions), order, len(args)+1),
+		append(args, limit+1)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextCursor string
+	if len(tokens) > limit {
+		tokens = tokens[:limit]
+		last := tokens[len(tokens)-1]
+		if search == "" {
+			nextCursor = encodeCursor([]byte(fmt.Sprintf("token_time:%016d:%s", last.CreationTime.Unix(), last.TokenID)))
+		} else {
+			nextCursor = encodeCursor([]byte(fmt.Sprintf("token_ticker:%s:%s", last.Ticker, last.TokenID)))
+		}
+	}
+
+	aggregates, err := p.GetTokenAggregates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedTokens{
+		Tokens:      tokens,
+		TotalTokens: totalTokens,
+		PerPage:     limit,
+		Aggregates:  *aggregates,
+		NextCursor:  nextCursor,
+	}, nil
+}
+
+// GetTokenAggregates computes totals across all stored tokens
+func (p *PostgresStore) GetTokenAggregates() (*TokenAggregates, error) {
+	var aggregates TokenAggregates
+	err := p.db.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE total_supply > 0), COALESCE(SUM(melt_value), 0)
+		FROM tokens`).Scan(&aggregates.TotalTokens, &aggregates.ActiveTokens, &aggregates.TotalValueLocked)
+	if err != nil {
+		return nil, err
+	}
+	return &aggregates, nil
+}
+
+// GetToken retrieves a single token by ID
+func (p *PostgresStore) GetToken(tokenID string) (*TokenInfo, error) {
+	var token TokenInfo
+	if err := getDocument(p.db, &token, `SELECT data FROM tokens WHERE token_id = $1`, tokenID); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetTrendingTokens returns up to limit tokens ranked by their transfers
+// since the given time, counting the same transfers as the Badger buckets
+func (p *PostgresStore) GetTrendingTokens(since time.Time, limit int) ([]TrendingToken, error) {
+	rows, err := p.db.Query(`WITH w AS (
+			SELECT token_id, from_address, to_address FROM token_transactions
+			WHERE unix_time >= $1 AND lower(type) = 'transfer'
+		)
+		SELECT t.token_id, t.transfers, COALESCE(a.holders, 0)
+		FROM (SELECT token_id, COUNT(*) AS transfers FROM w GROUP BY token_id) t
+		LEFT JOIN (
+			SELECT token_id, COUNT(DISTINCT address) AS holders FROM (
+				SELECT token_id, from_address AS address FROM w
+				UNION SELECT token_id, to_address FROM w
+			) u WHERE address <> '' GROUP BY token_id
+		) a ON a.token_id = t.token_id
+		ORDER BY t.transfers DESC, 3 DESC, t.token_id
+		LIMIT $2`, since.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []TrendingToken
+	for rows.Next() {
+		var trending TrendingToken
+		if err := rows.Scan(&trending.Token.TokenID, &trending.Transfers, &trending.UniqueHolders); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, trending)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return fillTrendingTokens(p, tokens), nil
+}
+
+// GetTokenDetails retrieves detailed token information including holders and transactions
+func (p *PostgresStore) GetTokenDetails(tokenID string) (*TokenDetails, error) {
+	token, err := p.GetToken(tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := p.GetTokenTransactions(tokenID, 20)
+	if err != nil {
+		slog.Error("Failed to get token transactions", "err", err)
+		transactions = []TokenTransaction{} // Continue with empty list
+	}
+
+	holders, err := p.GetTokenHolders(tokenID, 50)
+	if err != nil {
+		slog.Error("Failed to get token holders", "err", err)
+		holders = []TokenHolder{} // Continue with empty list
+	}
+
+	details := &TokenDetails{
+		TokenInfo:    *token,
+		Holders:      holders,
+		Transactions: transactions,
+	}
+
+	var balances []uint64
+	rows, err := p.db.Query(`SELECT balance FROM token_holders WHERE token_id = $1 AND balance > 0`, tokenID)
+	if err == nil {
+		for rows.Next() {
+			var balance uint64
+			if rows.Scan(&balance) == nil {
+				balances = append(balances, balance)
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+	}
+	if err != nil {
+		slog.Error("Failed to get token holder balances", "err", err)
+	}
+	details.Top10Share, details.Gini = holderConcentration(balances)
+	details.HighlyConcentrated = details.Top10Share >= highConcentrationShare
+
+	return details, nil
+}
+
+// GetTokenTransactions retrieves transactions for a specific token, newest first
+func (p *PostgresStore) GetTokenTransactions(tokenID string, limit int) ([]TokenTransaction, error) {
+	var transactions []TokenTransaction
+	err := eachDocument(p.db, func(data []byte) error {
+		var tx TokenTransaction
+		if json.Unmarshal(data, &tx) == nil {
+			transactions = append(transactions, tx)
+		}
+		return nil
+	}, `SELECT data FROM token_transactions WHERE token_id = $1 ORDER BY unix_time DESC, tx_hash DESC LIMIT $2`,
+		tokenID, limit)
+	return transactions, err
+}
+
+// GetTokenTransfers returns one page of a token's transactions matching
+// filter, newest first
+func (p *PostgresStore) GetTokenTransfers(tokenID string, filter TokenTransferFilter, page, perPage int) (*PaginatedTokenTransfers, error) {
+	if page < 1 {
+		page = 1
+	}
+	where := `token_id = $1`
+	args := []interface{}{tokenID}
+	if filter.From != "" {
+		args = append(args, filter.From)
+		where += fmt.Sprintf(` AND from_address = $%d`, len(args))
+	}
+	if filter.To != "" {
+		args = append(args, filter.To)
+		where += fmt.Sprintf(` AND to_address = $%d`, len(args))
+	}
+	if filter.Type != "" {
+		args = append(args, strings.ToUpper(filter.Type))
+		where += fmt.Sprintf(` AND upper(type) = $%d`, len(args))
+	}
+
+	var total int
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM token_transactions WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	result := &PaginatedTokenTransfers{
+		TokenID:        tokenID,
+		Transfers:      []TokenTransaction{},
+		CurrentPage:    page,
+		TotalPages:     (total + perPage - 1) / perPage,
+		TotalTransfers: total,
+		PerPage:        perPage,
+	}
+	args = append(args, perPage, (page-1)*perPage)
+	err := eachDocument(p.db, func(data []byte) error {
+		var tx TokenTransaction
+		if err := json.Unmarshal(data, &tx); err != nil {
+			return err
+		}
+		result.Transfers = append(result.Transfers, tx)
+		return nil
+	}, fmt.Sprintf(`SELECT data FROM token_transactions WHERE %s
+		ORDER BY unix_time DESC, tx_hash DESC LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args)), args...)
+	return result, err
+}
+
+// GetTokenHolders retrieves the largest holders of a token
+func (p *PostgresStore) GetTokenHolders(tokenID string, limit int) ([]TokenHolder, error) {
+	page, err := p.GetTokenHoldersPage(tokenID, 1, limit)
+	if err != nil {
+		return nil, err
+	}
+	return page.Holders, nil
+}
+
+// GetTokenHoldersPage returns one page of a token's holders, largest
+// balance first, walking the rank index
+func (p *PostgresStore) GetTokenHoldersPage(tokenID string, page, perPage int) (*PaginatedTokenHolders, error) {
+	if page < 1 {
+		page = 1
+	}
+	total, err := p.CountTokenHolders(tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PaginatedTokenHolders{
+		TokenID:      tokenID,
+		Holders:      []TokenHolder{},
+		CurrentPage:  page,
+		TotalPages:   (total + perPage - 1) / perPage,
+		TotalHolders: total,
+		PerPage:      perPage,
+	}
+	offset := (page - 1) * perPage
+	rows, err := p.db.Query(`SELECT address, balance FROM token_holders
+		WHERE token_id = $1 AND balance > 0 ORDER BY balance DESC, address LIMIT $2 OFFSET $3`, tokenID, perPage, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		holder := TokenHolder{Rank: offset + len(result.Holders) + 1}
+		if err := rows.Scan(&holder.Address, &holder.Balance); err != nil {
+			return nil, err
+		}
+		result.Holders = append(result.Holders, holder)
+	}
+	return result, rows.Err()
+}
+
+// GetTokenBalance returns an address's balance of a token, zero if it has
+// never held any
+func (p *PostgresStore) GetTokenBalance(tokenID, address string) (uint64, error) {
+	var balance uint64
+	err := p.db.QueryRow(`SELECT balance FROM token_holders WHERE token_id = $1 AND address = $2`,
+		tokenID, address).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return balance, err
+}
+
+// CountTokenHolders returns how many addresses hold a positive balance of
+// a token
+func (p *PostgresStore) CountTokenHolders(tokenID string) (int, error) {
+	var count int
+	err := p.db.QueryRow(`SELECT COUNT(*) FROM token_holders WHERE token_id = $1 AND balance > 0`, tokenID).Scan(&count)
+	return count, err
+}
+
+// StoreTokenTransaction stores a token transaction. Per-address activity is
+// aggregated from this table when read, so replays cannot double count.
+func (p *PostgresStore) StoreTokenTransaction(tokenID string, tx *TokenTransaction) error {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token transaction: %w", err)
+	}
+
+	_, err = p.db.Exec(`INSERT INTO token_transactions
+		(token_id, unix_time, tx_hash, block_height, timestamp, type, from_address, to_address, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (token_id, unix_time, tx_hash) DO UPDATE SET block_height = EXCLUDED.block_height,
+			timestamp = EXCLUDED.timestamp, type = EXCLUDED.type, from_address = EXCLUDED.from_address,
+			to_address = EXCLUDED.to_address, data = EXCLUDED.data`,
+		tokenID, tx.Timestamp.Unix(), tx.TxHash, tx.BlockHeight, tx.Timestamp, tx.Type,
+		tx.FromAddress, tx.ToAddress, string(data))
+	return err
+}
+
+// StoreMelt records a token melt
+func (p *PostgresStore) StoreMelt(melt *MeltEvent) error {
+	data, err := json.Marshal(melt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal melt: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO token_melts (token_id, unix_time, tx_hash, block_height, amount, shadow_released, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (token_id, unix_time, tx_hash) DO UPDATE SET block_height = EXCLUDED.block_height,
+			amount = EXCLUDED.amount, shadow_released = EXCLUDED.shadow_released, data = EXCLUDED.data`,
+		melt.TokenID, melt.Timestamp.Unix(), melt.TxHash, melt.BlockHeight, melt.Amount, melt.ShadowReleased, string(data))
+	return err
+}
+
+// GetTokenMelts returns one page of a token's melts, newest first
+func (p *PostgresStore) GetTokenMelts(tokenID string, page, perPage int) (*PaginatedTokenMelts, error) {
+	if page < 1 {
+		page = 1
+	}
+	result := &PaginatedTokenMelts{TokenID: tokenID, Melts: []MeltEvent{}, CurrentPage: page, PerPage: perPage}
+	if err := p.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(amount), 0), COALESCE(SUM(shadow_released), 0)
+		FROM token_melts WHERE token_id = $1`, tokenID).Scan(
+		&result.TotalMelts, &result.TotalMelted, &result.TotalShadowReleased); err != nil {
+		return nil, err
+	}
+	result.TotalPages = (result.TotalMelts + perPage - 1) / perPage
+
+	err := eachDocument(p.db, func(data []byte) error {
+		var melt MeltEvent
+		if err := json.Unmarshal(data, &melt); err != nil {
+			return err
+		}
+		result.Melts = append(result.Melts, melt)
+		return nil
+	}, `SELECT data FROM token_melts WHERE token_id = $1 ORDER BY unix_time DESC, tx_hash DESC LIMIT $2 OFFSET $3`,
+		tokenID, perPage, (page-1)*perPage)
+	return result, err
+}
+
+// GetTokenSupply totals a token's supply from the ledger: what its holders
+// hold, and what has been melted
+func (p *PostgresStore) GetTokenSupply(tokenID string) (circulating, melted uint64, err error) {
+	err = p.db.QueryRow(`SELECT
+			(SELECT COALESCE(SUM(balance), 0) FROM token_holders WHERE token_id = $1 AND balance > 0),
+			(SELECT COALESCE(SUM(amount), 0) FROM token_melts WHERE token_id = $1)`, tokenID).Scan(&circulating, &melted)
+	return circulating, melted, err
+}
+
+// GetTokenActivity returns every token an address has sent, received,
+// created or melted, including tokens it no longer holds, most recent first.
+// The counts follow updateTokenActivity: a self-transfer counts as sent.
+func (p *PostgresStore) GetTokenActivity(address string) ([]TokenActivity, error) {
+	rows, err := p.db.Query(`SELECT x.token_id, COALESCE(k.data->>'name', ''), COALESCE(k.ticker, ''),
+			COALESCE(h.balance, 0), x.created, x.sent, x.received, x.melted, x.tx_count,
+			x.first_activity, x.last_activity
+		FROM (
+			SELECT token_id,
+				COUNT(*) FILTER (WHERE lower(type) = 'create' AND to_address = $1) AS created,
+				COUNT(*) FILTER (WHERE lower(type) = 'transfer' AND from_address = $1) AS sent,
+				COUNT(*) FILTER (WHERE lower(type) = 'transfer' AND to_address = $1 AND from_address <> $1) AS received,
+				COUNT(*) FILTER (WHERE lower(type) = 'melt' AND from_address = $1) AS melted,
+				COUNT(*) AS tx_count,
+				MIN(timestamp) AS first_activity,
+				MAX(timestamp) AS last_activity
+			FROM token_transactions
+			WHERE from_address = $1 OR to_address = $1
+			GROUP BY token_id
+		) x
+		LEFT JOIN tokens k ON k.token_id = x.token_id
+		LEFT JOIN token_holders h ON h.token_id = x.token_id AND h.address = $1
+		ORDER BY x.last_activity DESC, x.token_id`, address)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []TokenActivity{}
+	for rows.Next() {
+		var a TokenActivity
+		if err := rows.Scan(&a.TokenID, &a.TokenName, &a.TokenTicker, &a.Balance, &a.Created, &a.Sent,
+			&a.Received, &a.Melted, &a.TxCount, &a.FirstActivity, &a.LastActivity); err != nil {
+			return nil, err
+		}
+		a.FirstActivity, a.LastActivity = a.FirstActivity.UTC(), a.LastActivity.UTC()
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
+
+// UpdateTokenHolder updates token holder balance
+func (p *PostgresStore) UpdateTokenHolder(tokenID, address string, balance uint64) error {
+	_, err := p.db.Exec(`INSERT INTO token_holders (token_id, address, balance) VALUES ($1, $2, $3)
+		ON CONFLICT (token_id, address) DO UPDATE SET balance = EXCLUDED.balance`, tokenID, address, balance)
+	return err
+}
+
+// StorePool stores liquidity pool information
+func (p *PostgresStore) StorePool(pool *LiquidityPool) error {
+	data, err := json.Marshal(pool)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pool: %w", err)
+	}
+
+	_, err = p.db.Exec(`INSERT INTO pools
+		(pool_id, pair, token_a, token_b, token_a_symbol, token_b_symbol, creation_block, tvl, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (pool_id) DO UPDATE SET pair = EXCLUDED.pair, token_a = EXCLUDED.token_a,
+			token_b = EXCLUDED.token_b, token_a_symbol = EXCLUDED.token_a_symbol,
+			token_b_symbol = EXCLUDED.token_b_symbol, creation_block = EXCLUDED.creation_block,
+			tvl = EXCLUDED.tvl, data = EXCLUDED.data`,
+		pool.PoolID, pool.TokenA+"_"+pool.TokenB, pool.TokenA, pool.TokenB, pool.TokenASymbol,
+		pool.TokenBSymbol, pool.CreationBlock, pool.TVL, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store pool: %w", err)
+	}
+	return nil
+}
+
+// queryPools pages through the pools matching where (whose arguments are
+// args) in order, returning the page and the total number of matches
+func (p *PostgresStore) queryPools(where, order string, args []interface{}, page, perPage int) ([]LiquidityPool, int64, int, error) {
+	var totalPools int64
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM pools `+where, args...).Scan(&totalPools); err != nil {
+		return nil, 0, page, err
+	}
+
+	pools := []LiquidityPool{}
+	n := len(args)
+	err := eachDocument(p.db, func(data []byte) error {
+		var pool LiquidityPool
+		if err := json.Unmarshal(data, &pool); err != nil {
+			slog.Error("Failed to unmarshal pool", "err", err)
+			return nil
+		}
+		pools = append(pools, pool)
+		return nil
+	}, fmt.Sprintf(`SELECT data FROM pools %s %s LIMIT $%d OFFSET $%d`, where, order, n+1, n+2),
+		append(args, perPage, (page-1)*perPage)...)
+	return pools, totalPools, page, err
+}
+
+// GetPools retrieves pools with pagination and optional search. A search
+// matches "<tokenA>_<tokenB>" pair prefixes; otherwise the highest TVL
+// comes first.
+func (p *PostgresStore) GetPools(page, perPage int, search string) (*PaginatedPools, error) {
+	where, order := ``, `ORDER BY tvl DESC, pool_id DESC`
+	args := []interface{}{}
+	if search != "" {
+		where, order = `WHERE pair LIKE $1`, `ORDER BY pair, pool_id`
+		args = append(args, likePrefix(search))
+	}
+
+	var totalPools int64
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM pools `+where, args...).Scan(&totalPools); err != nil {
+		return nil, err
+	}
+	totalPages := int((totalPools + int64(perPage) - 1) / int64(perPage))
+	if page < 1 {
+		page = 1
+	}
+	if page > totalPages && totalPages > 0 {
+		page = totalPages
+	}
+
+	pools, _, page, err := p.queryPools(where, order, args, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedPools{
+		Pools:       pools,
+		CurrentPage: page,
+		TotalPages:  totalPages,
+		TotalPools:  totalPools,
+		PerPage:     perPage,
+	}, nil
+}
+
+// GetPoolsByCursor retrieves up to limit pools after the pool a previous
+// page ended on, in the same order as GetPools. Like token cursors, these
+// name the Badger index key of the last pool.
+func (p *PostgresStore) GetPoolsByCursor(search, cursor string, limit int) (*PaginatedPools, error) {
+	prefix := "pool_tvl:"
+	if search != "" {
+		prefix = "pool_pair:" + search
+	}
+	cursorKey, err := decodeCursor(cursor, []byte(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions []string
+	order := `ORDER BY tvl DESC, pool_id DESC`
+	args := []interface{}{}
+	if search != "" {
+		conditions = append(conditions, `pair LIKE $1`)
+		order = `ORDER BY pair, pool_id`
+		args = append(args, likePrefix(search))
+	}
+
+	var totalPools int64
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM pools `+whereAll(conditions), args...).Scan(&totalPools); err != nil {
+		return nil, err
+	}
+
+	if cursorKey != nil {
+		if search == "" {
+			value, poolID, ok := cursorKeyParts(cursorKey, "pool_tvl:")
+			tvl, err := strconv.ParseInt(value, 10, 64)
+			if !ok || err != nil {
+				return nil, errInvalidCursor
+			}
+			conditions = append(conditions, fmt.Sprintf(`(tvl, pool_id) < ($%d, $%d)`, len(args)+1, len(args)+2))
+			args = append(args, tvl, poolID)
+		} else {
+			pair, poolID, ok := cursorKeyParts(cursorKey, "pool_pair:")
+			if !ok {
+				return nil, errInvalidCursor
+			}
+			conditions = append(conditions, fmt.Sprintf(`(pair, pool_id) > ($%d, $%d)`, len(args)+1, len(args)+2))
+			args = append(args, pair, poolID)
+		}
+	}
+
+	pools := []LiquidityPool{}
+	err = eachDocument(p.db, func(data []byte) error {
+		var pool LiquidityPool
+		if err := json.Unmarshal(data, &pool); err != nil {
+			slog.Error("Failed to unmarshal pool", "err", err)
+			return nil
+		}
+		pools = append(pools, pool)
+		return nil
+	}, fmt.Sprintf(`SELECT data FROM pools %s %s LIMIT $%d`, whereAll(conditions), order, len(args)+1),
+		append(args, limit+1)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextCursor string
+	if len(pools) > limit {
+		pools = pools[:limit]
+		last := pools[len(pools)-1]
+		if search == "" {
+			nextCursor = encodeCursor([]byte(fmt.Sprintf("pool_tvl:%016d:%s", last.TVL, last.PoolID)))
+		} else {
+			nextCursor = encodeCursor([]byte(fmt.Sprintf("pool_pair:%s_%s:%s", last.TokenA, last.TokenB, last.PoolID)))
+		}
+	}
+
+	return &PaginatedPools{
+		Pools:      pools,
+		TotalPools: totalPools,
+		PerPage:    limit,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// cursorKeyParts splits a cursor key of the form <prefix><sort value>:<id>
+func cursorKeyParts(key []byte, prefix string) (string, string, bool) {
+	rest := strings.TrimPrefix(string(key), prefix)
+	i := strings.LastIndexByte(rest, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+// whereAll joins conditions into a WHERE clause, or "" when there are none
+func whereAll(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// GetPoolsByToken retrieves all pools containing a token, matched by id or
+// symbol on either side of the pair
+func (p *PostgresStore) GetPoolsByToken(token string, page, perPage int) (*PaginatedPools, error) {
+	token = strings.ToLower(strings.TrimSpace(token))
+	if page < 1 {
+		page = 1
+	}
+
+	pools, totalPools, page, err := p.queryPools(`WHERE $1 IN (lower(btrim(token_a)), lower(btrim(token_a_symbol)),
+		lower(btrim(token_b)), lower(btrim(token_b_symbol)))`, `ORDER BY pool_id`,
+		[]interface{}{token}, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedPools{
+		Pools:       pools,
+		CurrentPage: page,
+		TotalPages:  int((totalPools + int64(perPage) - 1) / int64(perPage)),
+		TotalPools:  totalPools,
+		PerPage:     perPage,
+	}, nil
+}
+
+// GetPool retrieves a single pool by ID
+func (p *PostgresStore) GetPool(poolID string) (*LiquidityPool, error) {
+	var pool LiquidityPool
+	if err := getDocument(p.db, &pool, `SELECT data FROM pools WHERE pool_id = $1`, poolID); err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// GetPoolByAddress retrieves the pool whose L-address is address
+func (p *PostgresStore) GetPoolByAddress(address string) (*LiquidityPool, error) {
+	var pool LiquidityPool
+	if err := getDocument(p.db, &pool, `SELECT data FROM pools WHERE data->>'l_address' = $1`, address); err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// GetPoolDetails retrieves detailed pool information including transactions
+func (p *PostgresStore) GetPoolDetails(poolID string) (*PoolDetails, error) {
+	pool, err := p.GetPool(poolID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := p.GetPoolTransactions(poolID, 20)
+	if err != nil {
+		slog.Error("Failed to get pool transactions", "err", err)
+		transactions = []PoolTransaction{}
+	}
+
+	details := &PoolDetails{
+		LiquidityPool: *pool,
+		Transactions:  transactions,
+	}
+
+	// APR comes from recent trading rather than the stored figure
+	activity, err := p.GetPoolActivity(pool, time.Now())
+	if err != nil {
+		slog.Error("Failed to compute pool activity", "err", err)
+	}
+	details.PoolActivity = activity
+	details.FeeRate = poolFeeRate(pool)
+	details.APR = activity.FeeAPR + activity.RewardAPR
+
+	return details, nil
+}
+
+// GetPoolTransactions retrieves transactions for a specific pool, newest first
+func (p *PostgresStore) GetPoolTransactions(poolID string, limit int) ([]PoolTransaction, error) {
+	var transactions []PoolTransaction
+	err := eachDocument(p.db, func(data []byte) error {
+		var tx PoolTransaction
+		if json.Unmarshal(data, &tx) == nil {
+			transactions = append(transactions, tx)
+		}
+		return nil
+	}, `SELECT data FROM pool_transactions WHERE pool_id = $1 ORDER BY unix_time DESC, tx_hash DESC LIMIT $2`,
+		poolID, limit)
+	return transactions, err
+}
+
+// StorePoolTransaction stores a pool transaction and pushes the pool's
+// current price to live subscribers
+func (p *PostgresStore) StorePoolTransaction(poolID string, tx *PoolTransaction) error {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pool transaction: %w", err)
+	}
+
+	_, err = p.db.Exec(`INSERT INTO pool_transactions (pool_id, unix_time, tx_hash, block_height, type, data)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (pool_id, unix_time, tx_hash) DO UPDATE SET block_height = EXCLUDED.block_height,
+			type = EXCLUDED.type, data = EXCLUDED.data`,
+		poolID, tx.Timestamp.Unix(), tx.TxHash, tx.BlockHeight, tx.Type, string(data))
+	if err != nil {
+		return err
+	}
+
+	publishPoolPrice(p, poolID, tx)
+	return nil
+}
+
+// GetPoolSwaps returns the pool's swaps from from through to, oldest first
+func (p *PostgresStore) GetPoolSwaps(poolID string, from, to time.Time) ([]PoolTransaction, error) {
+	swaps := []PoolTransaction{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var tx PoolTransaction
+		if json.Unmarshal(data, &tx) == nil && !tx.Timestamp.Before(from) && !tx.Timestamp.After(to) {
+			swaps = append(swaps, tx)
+		}
+		return nil
+	}, `SELECT data FROM pool_transactions WHERE pool_id = $1 AND unix_time BETWEEN $2 AND $3 AND type = 'swap'
+		ORDER BY unix_time, tx_hash`, poolID, from.Unix(), to.Unix())
+	return swaps, err
+}
+
+// GetPoolActivity computes a pool's recent volume and APR as of now
+func (p *PostgresStore) GetPoolActivity(pool *LiquidityPool, now time.Time) (PoolActivity, error) {
+	swaps, err := p.GetPoolSwaps(pool.PoolID, now.Add(-feeAPRWindow), now)
+	if err != nil {
+		return PoolActivity{}, err
+	}
+	return computePoolActivity(pool, swaps, now), nil
+}
+
+// GetDailyStats returns per-day aggregates for the days days ending with
+// the UTC day containing now, oldest first. Finalized days are cached in
+// daily_stats like the Badger store does.
+func (p *PostgresStore) GetDailyStats(days int, now time.Time) ([]DailyStats, error) {
+	today := dayStart(now)
+	first := today.AddDate(0, 0, -(days - 1))
+
+	cached := make(map[string]DailyStats)
+	err := eachDocument(p.db, func(data []byte) error {
+		var day DailyStats
+		if err := json.Unmarshal(data, &day); err != nil {
+			return err
+		}
+		cached[day.Date] = day
+		return nil
+	}, `SELECT data FROM daily_stats WHERE day BETWEEN $1 AND $2`, utcDay(first), utcDay(today))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]DailyStats, days)
+	pending := make(map[string]*DailyStats)
+	for i := range stats {
+		date := utcDay(first.AddDate(0, 0, i))
+		if day, ok := cached[date]; ok {
+			stats[i] = day
+			continue
+		}
+		stats[i] = DailyStats{Date: date}
+		pending[date] = &stats[i]
+	}
+	if len(pending) == 0 {
+		return stats, nil
+	}
+
+	indexedUntil, err := p.computeDailyStats(pending, first, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	for date, day := range finalizeDailyStats(pending, now, indexedUntil) {
+		data, err := json.Marshal(day)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.db.Exec(`INSERT INTO daily_stats (day, data) VALUES ($1, $2)
+			ON CONFLICT (day) DO UPDATE SET data = EXCLUDED.data`, date, string(data)); err != nil {
+			slog.Warn("Failed to cache daily stats", "err", err)
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+// utcDayColumn formats a timestamptz column as its UTC day
+const utcDayColumn = `to_char(%s AT TIME ZONE 'UTC', 'YYYY-MM-DD')`
+
+// computeDailyStats fills the pending buckets between from and to from the
+// block and transaction tables and returns the timestamp of the newest
+// indexed block. Mining rewards count towards active and new addresses but
+// not towards transactions, volume or fees.
+func (p *PostgresStore) computeDailyStats(pending map[string]*DailyStats, from, to time.Time) (time.Time, error) {
+	var newest sql.NullTime
+	if err := p.db.QueryRow(`SELECT MAX(timestamp) FROM blocks`).Scan(&newest); err != nil {
+		return time.Time{}, err
+	}
+
+	day := fmt.Sprintf(utcDayColumn, "timestamp")
+	queries := []struct {
+		query  string
+		args   []interface{}
+		fields func(stats *DailyStats) []interface{}
+	}{
+		{
+			fmt.Sprintf(`SELECT %s, COUNT(*) FROM blocks
+				WHERE timestamp >= $1 AND timestamp < $2 GROUP BY 1`, day),
+			[]interface{}{from, to},
+			func(stats *DailyStats) []interface{} { return []interface{}{&stats.Blocks} },
+		},
+		{
+			fmt.Sprintf(`SELECT %s,
+					COUNT(*) FILTER (WHERE type <> 'mining_reward'),
+					COALESCE(SUM(amount) FILTER (WHERE type <> 'mining_reward'), 0),
+					COALESCE(AVG(fee) FILTER (WHERE type <> 'mining_reward'), 0)::float8
+				FROM transactions WHERE timestamp >= $1 AND timestamp < $2 GROUP BY 1`, day),
+			[]interface{}{from, to},
+			func(stats *DailyStats) []interface{} {
+				return []interface{}{&stats.Transactions, &stats.Volume, &stats.AverageFee}
+			},
+		},
+		{
+			fmt.Sprintf(`SELECT day, COUNT(DISTINCT address) FROM (
+					SELECT %[1]s AS day, from_address AS address FROM transactions
+						WHERE timestamp >= $1 AND timestamp < $2
+					UNION ALL
+					SELECT %[1]s, to_address FROM transactions
+						WHERE timestamp >= $1 AND timestamp < $2
+				) active WHERE address <> '' GROUP BY day`, day),
+			[]interface{}{from, to},
+			func(stats *DailyStats) []interface{} { return []interface{}{&stats.ActiveAddresses} },
+		},
+		{
+			`SELECT to_char(day, 'YYYY-MM-DD'), COUNT(*) FROM first_seen
+				WHERE day >= $1::date AND day < $2::date GROUP BY day`,
+			[]interface{}{utcDay(from), utcDay(to)},
+			func(stats *DailyStats) []interface{} { return []interface{}{&stats.NewAddresses} },
+		},
+	}
+
+	for _, q := range queries {
+		if err := p.fillDays(pending, q.query, q.args, q.fields); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if !newest.Valid {
+		return time.Time{}, nil
+	}
+	return newest.Time.UTC(), nil
+}
+
+// fillDays runs a query whose first column is a day and scans the rest of
+// each row into the fields of that day's pending bucket
+func (p *PostgresStore) fillDays(pending map[string]*DailyStats, query string, args []interface{},
+	fields func(stats *DailyStats) []interface{}) error {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var date string
+		var row DailyStats
+		scanned := fields(&row)
+		if err := rows.Scan(append([]interface{}{&date}, scanned...)...); err != nil {
+			return err
+		}
+
+		// Cached days in the range are already final; leave them alone
+		stats, ok := pending[date]
+		if !ok {
+			continue
+		}
+		for i, dest := range fields(stats) {
+			switch dest := dest.(type) {
+			case *int:
+				*dest = *scanned[i].(*int)
+			case *uint64:
+				*dest = *scanned[i].(*uint64)
+			case *float64:
+				*dest = *scanned[i].(*float64)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// GetAddressGrowth returns, for each of the days days ending with the UTC
+// day containing now, how many addresses first appeared that day and how
+// many distinct addresses had been seen by the end of it
+func (p *PostgresStore) GetAddressGrowth(days int, now time.Time) ([]AddressGrowthPoint, error) {
+	first := dayStart(now).AddDate(0, 0, -(days - 1))
+
+	var before uint64
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM first_seen WHERE day < $1::date`, utcDay(first)).Scan(&before); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.Query(`SELECT to_char(day, 'YYYY-MM-DD'), COUNT(*) FROM first_seen
+		WHERE day >= $1::date GROUP BY day`, utcDay(first))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perDay := make(map[string]uint64)
+	for rows.Next() {
+		var day string
+		var count uint64
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, err
+		}
+		perDay[day] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return addressGrowthPoints(first, days, before, perDay), nil
+}
+
+// applyPostgresChartSample adds or removes a block's contribution to the
+// rollups of every granularity
+func applyPostgresChartSample(tx *sql.Tx, sample *ChartSample, sign int) error {
+	for _, granularity := range chartGranularities {
+		start := chartBucketStart(granularity, sample.Timestamp)
+
+		rollup := ChartRollup{Start: start}
+		err := getDocument(tx, &rollup, `SELECT data FROM chart_rollups
+			WHERE granularity = $1 AND bucket = $2 FOR UPDATE`, granularity, start)
+		if err != nil && err != errNotFound {
+			return err
+		}
+		rollup.apply(sample, sign)
+
+		for _, address := range sample.Addresses {
+			var refs int64
+			if err := tx.QueryRow(`INSERT INTO chart_addresses (granularity, bucket, address, refs)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (granularity, bucket, address) DO UPDATE SET refs = chart_addresses.refs + EXCLUDED.refs
+				RETURNING refs`, granularity, start, address, sign).Scan(&refs); err != nil {
+				return err
+			}
+
+			before := refs - int64(sign)
+			if before <= 0 && refs > 0 {
+				rollup.ActiveAddresses++
+			} else if before > 0 && refs <= 0 {
+				rollup.ActiveAddresses--
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM chart_addresses WHERE granularity = $1 AND bucket = $2 AND refs <= 0`,
+			granularity, start); err != nil {
+			return err
+		}
+
+		if rollup.Blocks <= 0 {
+			if _, err := tx.Exec(`DELETE FROM chart_rollups WHERE granularity = $1 AND bucket = $2`,
+				granularity, start); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := json.Marshal(rollup)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO chart_rollups (granularity, bucket, data) VALUES ($1, $2, $3)
+			ON CONFLICT (granularity, bucket) DO UPDATE SET data = EXCLUDED.data`,
+			granularity, start, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordChartSample folds a block into the chart rollups, replacing what
+// an earlier sync of the same height contributed
+func (p *PostgresStore) RecordChartSample(sample *ChartSample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chart sample: %w", err)
+	}
+
+	return p.inTx(func(tx *sql.Tx) error {
+		var previous ChartSample
+		err := getDocument(tx, &previous, `SELECT data FROM chart_samples WHERE height = $1 FOR UPDATE`,
+			clampHeight(sample.Height))
+		if err == nil {
+			if err := applyPostgresChartSample(tx, &previous, -1); err != nil {
+				return err
+			}
+		} else if err != errNotFound {
+			return err
+		}
+
+		if err := applyPostgresChartSample(tx, sample, 1); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO chart_samples (height, data) VALUES ($1, $2)
+			ON CONFLICT (height) DO UPDATE SET data = EXCLUDED.data`, clampHeight(sample.Height), string(data))
+		return err
+	})
+}
+
+// removePostgresChartSamplesAbove subtracts the blocks above height from
+// the chart rollups and forgets them
+func removePostgresChartSamplesAbove(tx *sql.Tx, height uint64) error {
+	var samples []ChartSample
+	if err := eachDocument(tx, func(data []byte) error {
+		var sample ChartSample
+		if json.Unmarshal(data, &sample) == nil {
+			samples = append(samples, sample)
+		}
+		return nil
+	}, `SELECT data FROM chart_samples WHERE height > $1 ORDER BY height`, height); err != nil {
+		return err
+	}
+
+	for i := range samples {
+		if err := applyPostgresChartSample(tx, &samples[i], -1); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(`DELETE FROM chart_samples WHERE height > $1`, height)
+	return err
+}
+
+// GetChartRollups returns the rollups of a granularity whose buckets start
+// between from and to inclusive, oldest first
+func (p *PostgresStore) GetChartRollups(granularity string, from, to time.Time) ([]ChartRollup, error) {
+	var rollups []ChartRollup
+	err := eachDocument(p.db, func(data []byte) error {
+		var rollup ChartRollup
+		if err := json.Unmarshal(data, &rollup); err != nil {
+			return err
+		}
+		rollups = append(rollups, rollup)
+		return nil
+	}, `SELECT data FROM chart_rollups WHERE granularity = $1 AND bucket BETWEEN $2 AND $3 ORDER BY bucket`,
+		granularity, from, to)
+	return rollups, err
+}
+
+// StoreAPIKey saves a key under the hash of its secret
+func (p *PostgresStore) StoreAPIKey(key *APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO api_keys (key_hash, data) VALUES ($1, $2)
+		ON CONFLICT (key_hash) DO UPDATE SET data = EXCLUDED.data`, key.KeyHash, string(data))
+	return err
+}
+
+// GetAPIKey finds the key whose secret hashes to hash
+func (p *PostgresStore) GetAPIKey(hash string) (*APIKey, error) {
+	var key APIKey
+	if err := getDocument(p.db, &key, `SELECT data FROM api_keys WHERE key_hash = $1`, hash); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns every API key, oldest first
+func (p *PostgresStore) ListAPIKeys() ([]APIKey, error) {
+	keys := []APIKey{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var key APIKey
+		if err := json.Unmarshal(data, &key); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		return nil
+	}, `SELECT data FROM api_keys ORDER BY data->>'created_at'`)
+	return keys, err
+}
+
+// DeleteAPIKey revokes the key with the given ID
+func (p *PostgresStore) DeleteAPIKey(id string) error {
+	result, err := p.db.Exec(`DELETE FROM api_keys WHERE key_hash LIKE $1`, likePrefix(id))
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errNotFound
+	}
+	return err
+}
+
+// AppendAuditEntry adds an entry to the audit log
+func (p *PostgresStore) AppendAuditEntry(entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO admin_audit (time, data) VALUES ($1, $2)`, entry.Time, string(data))
+	return err
+}
+
+// GetAuditLog returns the latest limit audit entries, newest first
+func (p *PostgresStore) GetAuditLog(limit int) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var entry AuditEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	}, `SELECT data FROM admin_audit ORDER BY id DESC LIMIT $1`, limit)
+	return entries, err
+}
+
+// StoreJob saves a job record, replacing any with the same ID
+func (p *PostgresStore) StoreJob(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO jobs (id, data) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data`, job.ID, string(data))
+	return err
+}
+
+// GetJob returns a job record, or errNotFound
+func (p *PostgresStore) GetJob(id string) (*Job, error) {
+	var job Job
+	if err := getDocument(p.db, &job, `SELECT data FROM jobs WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs returns the latest limit job records, newest first
+func (p *PostgresStore) ListJobs(limit int) ([]Job, error) {
+	jobs := []Job{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			return err
+		}
+		jobs = append(jobs, job)
+		return nil
+	}, `SELECT data FROM jobs ORDER BY id DESC LIMIT $1`, limit)
+	return jobs, err
+}
+
+// PruneJobs drops the finished job records beyond the latest keep
+func (p *PostgresStore) PruneJobs(keep int) error {
+	_, err := p.db.Exec(`DELETE FROM jobs WHERE data->>'status' NOT IN ('queued', 'running')
+		AND id < (SELECT MIN(id) FROM (SELECT id FROM jobs ORDER BY id DESC LIMIT $1) latest)`, keep)
+	return err
+}
+
+// StoreAddressLabel saves a label, replacing any on the same address
+func (p *PostgresStore) StoreAddressLabel(label *AddressLabel) error {
+	data, err := json.Marshal(label)
+	if err != nil {
+		return fmt.Errorf("failed to marshal address label: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO address_labels (address, data) VALUES ($1, $2)
+		ON CONFLICT (address) DO UPDATE SET data = EXCLUDED.data`, label.Address, string(data))
+	return err
+}
+
+// GetAddressLabels returns the labels of those addresses that have one
+func (p *PostgresStore) GetAddressLabels(addresses []string) (map[string]AddressLabel, error) {
+	labels := make(map[string]AddressLabel)
+	err := eachDocument(p.db, func(data []byte) error {
+		var label AddressLabel
+		if err := json.Unmarshal(data, &label); err != nil {
+			return err
+		}
+		labels[label.Address] = label
+		return nil
+	}, `SELECT data FROM address_labels WHERE address = ANY($1)`, pq.Array(addresses))
+	return labels, err
+}
+
+// ListAddressLabels returns every stored label, ordered by address
+func (p *PostgresStore) ListAddressLabels() ([]AddressLabel, error) {
+	labels := []AddressLabel{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var label AddressLabel
+		if err := json.Unmarshal(data, &label); err != nil {
+			return err
+		}
+		labels = append(labels, label)
+		return nil
+	}, `SELECT data FROM address_labels ORDER BY address`)
+	return labels, err
+}
+
+// DeleteAddressLabel removes an address's label
+func (p *PostgresStore) DeleteAddressLabel(address string) error {
+	result, err := p.db.Exec(`DELETE FROM address_labels WHERE address = $1`, address)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errNotFound
+	}
+	return err
+}
+
+// StoreNFTMetadata saves an NFT's metadata record
+func (p *PostgresStore) StoreNFTMetadata(meta *NFTMetadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal NFT metadata: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO nft_metadata (token_id, creator, status, next_attempt, data) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (token_id) DO UPDATE SET creator = EXCLUDED.creator, status = EXCLUDED.status,
+			next_attempt = EXCLUDED.next_attempt, data = EXCLUDED.data`,
+		meta.TokenID, meta.Creator, meta.Status, meta.NextAttempt, string(data))
+	return err
+}
+
+// GetNFTMetadata finds an NFT's metadata record
+func (p *PostgresStore) GetNFTMetadata(tokenID string) (*NFTMetadata, error) {
+	var meta NFTMetadata
+	if err := getDocument(p.db, &meta, `SELECT data FROM nft_metadata WHERE token_id = $1`, tokenID); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// DueNFTMetadata returns up to limit pending records due for a fetch,
+// longest waiting first
+func (p *PostgresStore) DueNFTMetadata(now time.Time, limit int) ([]NFTMetadata, error) {
+	var due []NFTMetadata
+	err := eachDocument(p.db, func(data []byte) error {
+		var meta NFTMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		due = append(due, meta)
+		return nil
+	}, `SELECT data FROM nft_metadata WHERE status = 'pending' AND next_attempt <= $1
+		ORDER BY next_attempt LIMIT $2`, now, limit)
+	return due, err
+}
+
+// ListNFTMetadata returns every NFT's metadata, or a creator's, ordered by
+// creator and token
+func (p *PostgresStore) ListNFTMetadata(creator string) ([]NFTMetadata, error) {
+	nfts := []NFTMetadata{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var meta NFTMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		nfts = append(nfts, meta)
+		return nil
+	}, `SELECT data - 'document' FROM nft_metadata WHERE $1 = '' OR creator = $1 ORDER BY creator, token_id`, creator)
+	return nfts, err
+}
+
+// StoreNFTImage caches an NFT's image
+func (p *PostgresStore) StoreNFTImage(tokenID, contentType string, image []byte) error {
+	_, err := p.db.Exec(`INSERT INTO nft_images (token_id, content_type, data) VALUES ($1, $2, $3)
+		ON CONFLICT (token_id) DO UPDATE SET content_type = EXCLUDED.content_type, data = EXCLUDED.data`,
+		tokenID, contentType, image)
+	return err
+}
+
+// GetNFTImage returns an NFT's cached image and its content type
+func (p *PostgresStore) GetNFTImage(tokenID string) (string, []byte, error) {
+	var contentType string
+	var data []byte
+	err := p.db.QueryRow(`SELECT content_type, data FROM nft_images WHERE token_id = $1`, tokenID).Scan(&contentType, &data)
+	if err == sql.ErrNoRows {
+		return "", nil, errNotFound
+	}
+	return contentType, data, err
+}
+
+// StoreWatchSubscription saves a subscription
+func (p *PostgresStore) StoreWatchSubscription(sub *WatchSubscription) error {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO watch_subscriptions (id, address, data) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET address = EXCLUDED.address, data = EXCLUDED.data`, sub.ID, sub.Address, string(data))
+	return err
+}
+
+// GetWatchSubscription finds a subscription by ID
+func (p *PostgresStore) GetWatchSubscription(id string) (*WatchSubscription, error) {
+	var sub WatchSubscription
+	if err := getDocument(p.db, &sub, `SELECT data FROM watch_subscriptions WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetWatchSubscriptions returns the subscriptions watching an address
+func (p *PostgresStore) GetWatchSubscriptions(address string) ([]WatchSubscription, error) {
+	subs := []WatchSubscription{}
+	err := eachDocument(p.db, func(data []byte) error {
+		var sub WatchSubscription
+		if err := json.Unmarshal(data, &sub); err != nil {
+			return err
+		}
+		subs = append(subs, sub)
+		return nil
+	}, `SELECT data FROM watch_subscriptions WHERE address = $1`, address)
+	return subs, err
+}
+
+// CountWatchSubscriptions counts the subscriptions a client created
+func (p *PostgresStore) CountWatchSubscriptions(owner string) (int, error) {
+	var count int
+	err := p.db.QueryRow(`SELECT count(*) FROM watch_subscriptions WHERE data->>'owner' = $1`, owner).Scan(&count)
+	return count, err
+}
+
+// DeleteWatchSubscription removes a subscription
+func (p *PostgresStore) DeleteWatchSubscription(id string) error {
+	result, err := p.db.Exec(`DELETE FROM watch_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errNotFound
+	}
+	return err
+}
+
+// QueueWatchNotification adds or reschedules a notification
+func (p *PostgresStore) QueueWatchNotification(n *WatchNotification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	_, err = p.db.Exec(`INSERT INTO watch_notifications (id, next_attempt, data) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET next_attempt = EXCLUDED.next_attempt, data = EXCLUDED.data`,
+		n.ID, n.NextAttempt, string(data))
+	return err
+}
+
+// DueWatchNotifications returns up to limit notifications due by now,
+// longest waiting first
+func (p *PostgresStore) DueWatchNotifications(now time.Time, limit int) ([]WatchNotification, error) {
+	var due []WatchNotification
+	err := eachDocument(p.db, func(data []byte) error {
+		var n WatchNotification
+		if err := json.Unmarshal(data, &n); err != nil {
+			return err
+		}
+		due = append(due, n)
+		return nil
+	}, `SELECT data FROM watch_notifications WHERE next_attempt <= $1 ORDER BY next_attempt LIMIT $2`, now, limit)
+	return due, err
+}
+
+// DeleteWatchNotification removes a delivered or abandoned notification
+func (p *PostgresStore) DeleteWatchNotification(id string) error {
+	_, err := p.db.Exec(`DELETE FROM watch_notifications WHERE id = $1`, id)
+	return err
+}
+
+// Search resolves a block height, block hash, transaction hash, wallet
+// address, token ID, pool ID or address label the same way the Badger
+// store does
+func (p *PostgresStore) Search(query string) (*SearchResult, error) {
+	q := strings.TrimSpace(query)
+	if q == "" {
+		return nil, errNoSearchMatch
+	}
+	result := &SearchResult{Query: q}
+
+	if height, err := strconv.ParseUint(q, 10, 64); err == nil {
+		var hash string
+		err := p.db.QueryRow(`SELECT hash FROM blocks WHERE height = $1`, clampHeight(height)).Scan(&hash)
+		if err == sql.ErrNoRows {
+			return nil, errNoSearchMatch
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Type = "block"
+		result.ID = hash
+		result.Redirect = "/block/" + url.PathEscape(hash)
+		return result, nil
+	}
+
+	if isAddressQuery(q) {
+		result.Type = "wallet"
+		result.ID = q
+		result.Redirect = "/wallet/" + q
+		return result, nil
+	}
+
+	// Hashes and IDs are indexed lowercase; try the query as typed first
+	candidates := []string{q}
+	if lower := strings.ToLower(q); lower != q {
+		candidates = append(candidates, lower)
+	}
+
+	for _, candidate := range candidates {
+		if _, err := p.GetBlock(candidate); err == nil {
+			result.Type = "block"
+			result.ID = candidate
+			result.Redirect = "/block/" + url.PathEscape(candidate)
+			return result, nil
+		} else if err != errNotFound {
+			return nil, err
+		}
+
+		var tx WalletTransaction
+		err := getDocument(p.db, &tx, `SELECT data FROM transactions WHERE tx_hash = $1`, candidate)
+		if err == nil {
+			// Transactions are shown on the block that includes them
+			result.Type = "transaction"
+			result.ID = candidate
+			result.Redirect = "/block/" + url.PathEscape(tx.BlockHash)
+			return result, nil
+		} else if err != errNotFound {
+			return nil, err
+		}
+	}
+
+	for _, candidate := range candidates {
+		if token, err := p.GetToken(candidate); err == nil {
+			result.Type = "token"
+			result.ID = token.TokenID
+			result.Redirect = "/token/" + url.PathEscape(token.TokenID)
+			return result, nil
+		}
+		if pool, err := p.GetPool(candidate); err == nil {
+			result.Type = "pool"
+			result.ID = pool.PoolID
+			result.Redirect = "/pool/" + url.PathEscape(pool.PoolID)
+			return result, nil
+		}
+	}
+	return searchLabels(p, result)
+}
+
+// RollbackToHeight removes every block above height together with the
+// transactions, tokens, pools and token holder balances they produced, so
+// sync can re-index the winning branch from height+1
+func (p *PostgresStore) RollbackToHeight(height uint64) (*RollbackSummary, error) {
+	summary := &RollbackSummary{ForkHeight: height}
+	h := clampHeight(height)
+
+	err := p.inTx(func(tx *sql.Tx) error {
+		// Blocks, remembering the first day whose cached stats they touched
+		var firstDay sql.NullString
+		if err := tx.QueryRow(`SELECT to_char(MIN(timestamp) AT TIME ZONE 'UTC', 'YYYY-MM-DD')
+			FROM blocks WHERE height > $1`, h).Scan(&firstDay); err != nil {
+			return err
+		}
+		if err := orphanPostgresBlocks(tx, orphanReorg, `b.height > $1`, h); err != nil {
+			return err
+		}
+		var err error
+		if summary.Blocks, err = execCount(tx, `DELETE FROM blocks WHERE height > $1`, h); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM node_hashes WHERE height > $1`, h); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM block_alerts WHERE height > $1`, h); err != nil {
+			return err
+		}
+
+		// Wallet transactions, their effect on balances and their address
+		// and farmer indexes
+		if err := removePostgresChartSamplesAbove(tx, h); err != nil {
+			return err
+		}
+		if err := rollbackPostgresWalletIndex(tx, h, summary); err != nil {
+			return err
+		}
+
+		// Tokens and pools, replaying what is left of the ones touched
+		if err := rollbackPostgresTokenIndex(tx, h, summary); err != nil {
+			return err
+		}
+		if err := rollbackPostgresPoolIndex(tx, h, summary); err != nil {
+			return err
+		}
+
+		// Derived daily data
+		if firstDay.Valid {
+			if _, err := tx.Exec(`DELETE FROM daily_stats WHERE day >= $1`, firstDay.String); err != nil {
+				return err
+			}
+		}
+
+		return setMeta(tx, "latest_height", strconv.FormatUint(height, 10))
+	})
+	p.invalidateTip()
+
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// RollbackIndex removes what the index families in scope derived from
+// blocks above height, leaving the blocks themselves, so they can be
+// replayed. Tokens and pools that survive are rebuilt from what is left.
+func (p *PostgresStore) RollbackIndex(scope indexScope, height uint64) (*RollbackSummary, error) {
+	summary := &RollbackSummary{ForkHeight: height}
+	h := clampHeight(height)
+
+	err := p.inTx(func(tx *sql.Tx) error {
+		if scope&indexWallets != 0 {
+			if err := rollbackPostgresWalletIndex(tx, h, summary); err != nil {
+				return err
+			}
+			// Cached days from the first block replayed on
+			if _, err := tx.Exec(`DELETE FROM daily_stats WHERE day >= (
+				SELECT to_char(MIN(timestamp) AT TIME ZONE 'UTC', 'YYYY-MM-DD') FROM blocks WHERE height > $1)`, h); err != nil {
+				return err
+			}
+		}
+		if scope&indexTokens != 0 {
+			if err := rollbackPostgresTokenIndex(tx, h, summary); err != nil {
+				return err
+			}
+		}
+		if scope&indexPools != 0 {
+			if err := rollbackPostgresPoolIndex(tx, h, summary); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// execCount runs a statement and returns the number of rows it affected
+func execCount(tx *sql.Tx, query string, args ...interface{}) (int, error) {
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// queryColumn returns the single text column of every row query returns
+func queryColumn(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// rollbackPostgresWalletIndex removes the wallet transactions of blocks
+// above h with their effect on balances and their address and farmer
+// indexes, rebuilding the first-seen index if anything was removed
+func rollbackPostgresWalletIndex(tx *sql.Tx, h uint64, summary *RollbackSummary) error {
+	if err := adjustPostgresBalances(tx, -1, `block_height > $1`, h); err != nil {
+		return err
+	}
+	var err error
+	if summary.Transactions, err = execCount(tx, `DELETE FROM transactions WHERE block_height > $1`, h); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM address_transactions WHERE block_height > $1`, h); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM mined_blocks WHERE height > $1`, h); err != nil {
+		return err
+	}
+
+	if summary.Transactions == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`DELETE FROM first_seen`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO first_seen (address, day)
+		SELECT address, MIN((timestamp AT TIME ZONE 'UTC')::date) FROM (
+			SELECT from_address AS address, timestamp FROM transactions
+			UNION ALL
+			SELECT to_address, timestamp FROM transactions
+		) seen WHERE address <> '' GROUP BY address`)
+	return err
+}
+
+// rollbackPostgresTokenIndex removes the token transactions of blocks above
+// h and the tokens created there, then rebuilds the holders and statistics
+// of every other token they touched
+func rollbackPostgresTokenIndex(tx *sql.Tx, h uint64, summary *RollbackSummary) error {
+	affected, err := queryColumn(tx, `DELETE FROM token_transactions WHERE block_height > $1 RETURNING token_id`, h)
+	if err != nil {
+		return err
+	}
+	melted, err := queryColumn(tx, `DELETE FROM token_melts WHERE block_height > $1 RETURNING token_id`, h)
+	if err != nil {
+		return err
+	}
+	affected = append(affected, melted...)
+	removed, err := queryColumn(tx, `DELETE FROM tokens WHERE creation_block > $1 RETURNING token_id`, h)
+	if err != nil {
+		return err
+	}
+	summary.TokensRemoved = len(removed)
+	if _, err := tx.Exec(`DELETE FROM nft_metadata WHERE token_id NOT IN (SELECT token_id FROM tokens)`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM nft_images WHERE token_id NOT IN (SELECT token_id FROM tokens)`); err != nil {
+		return err
+	}
+
+	// Replay what is left of each touched token's history
+	tokenSet := make(map[string]bool)
+	for _, tokenID := range append(affected, removed...) {
+		tokenSet[tokenID] = true
+	}
+	tokenIDs := make([]string, 0, len(tokenSet))
+	for tokenID := range tokenSet {
+		tokenIDs = append(tokenIDs, tokenID)
+	}
+	sort.Strings(tokenIDs)
+	for _, tokenID := range tokenIDs {
+		if err := rebuildPostgresTokenState(tx, tokenID); err != nil {
+			return fmt.Errorf("failed to rebuild token %s: %w", tokenID, err)
+		}
+	}
+	summary.TokensRebuilt = len(tokenIDs) - len(removed)
+	return nil
+}
+
+// rollbackPostgresPoolIndex removes the pool transactions of blocks above h
+// and the pools created there, then replays the swaps left in every other
+// pool they touched
+func rollbackPostgresPoolIndex(tx *sql.Tx, h uint64, summary *RollbackSummary) error {
+	affected, err := queryColumn(tx, `DELETE FROM pool_transactions WHERE block_height > $1 RETURNING pool_id`, h)
+	if err != nil {
+		return err
+	}
+	if summary.PoolsRemoved, err = execCount(tx, `DELETE FROM pools WHERE creation_block > $1`, h); err != nil {
+		return err
+	}
+
+	poolSet := make(map[string]bool)
+	for _, poolID := range affected {
+		poolSet[poolID] = true
+	}
+	for poolID := range poolSet {
+		if err := rebuildPostgresPoolState(tx, poolID); err != nil {
+			return fmt.Errorf("failed to rebuild pool %s: %w", poolID, err)
+		}
+	}
+	return nil
+}
+
+// rebuildPostgresPoolState recomputes a pool's reserves and statistics
+// from the pool transactions still stored
+func rebuildPostgresPoolState(tx *sql.Tx, poolID string) error {
+	var pool LiquidityPool
+	err := getDocument(tx, &pool, `SELECT data FROM pools WHERE pool_id = $1`, poolID)
+	if err == errNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var history []PoolTransaction
+	if err := eachDocument(tx, func(data []byte) error {
+		var poolTx PoolTransaction
+		if json.Unmarshal(data, &poolTx) == nil {
+			history = append(history, poolTx)
+		}
+		return nil
+	}, `SELECT data FROM pool_transactions WHERE pool_id = $1 ORDER BY unix_time, tx_hash`, poolID); err != nil {
+		return err
+	}
+
+	replayPoolHistory(&pool, history)
+	data, err := json.Marshal(pool)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE pools SET tvl = $2, data = $3 WHERE pool_id = $1`, poolID, pool.TVL, string(data))
+	return err
+}
+
+// rebuildPostgresTokenState recomputes a token's holder balances and
+// statistics from the token transactions still stored
+func rebuildPostgresTokenState(tx *sql.Tx, tokenID string) error {
+	if _, err := tx.Exec(`DELETE FROM token_holders WHERE token_id = $1`, tokenID); err != nil {
+		return err
+	}
+
+	var history []TokenTransaction
+	if err := eachDocument(tx, func(data []byte) error {
+		var tokenTx TokenTransaction
+		if json.Unmarshal(data, &tokenTx) == nil {
+			history = append(history, tokenTx)
+		}
+		return nil
+	}, `SELECT data FROM token_transactions WHERE token_id = $1 ORDER BY unix_time, tx_hash`, tokenID); err != nil {
+		return err
+	}
+
+	balances, order, transfers := replayTokenHistory(history)
+	for _, address := range order {
+		if _, err := tx.Exec(`INSERT INTO token_holders (token_id, address, balance) VALUES ($1, $2, $3)`,
+			tokenID, address, balances[address]); err != nil {
+			return err
+		}
+	}
+
+	// Refresh statistics on the token record if the token still exists
+	var token TokenInfo
+	err := getDocument(tx, &token, `SELECT data FROM tokens WHERE token_id = $1`, tokenID)
+	if err == errNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token.HolderCount = len(order)
+	token.TransferCount = transfers
+	if len(history) > 0 {
+		token.LastActivity = history[len(history)-1].Timestamp
+	}
+	token.CirculatingSupply = 0
+	for _, address := range order {
+		token.CirculatingSupply += balances[address]
+	}
+	if err := tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM token_melts WHERE token_id = $1`,
+		tokenID).Scan(&token.TotalMelted); err != nil {
+		return err
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE tokens SET data = $2 WHERE token_id = $1`, tokenID, string(data))
+	return err
+}
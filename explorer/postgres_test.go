@@ -0,0 +1,523 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestPostgresStore connects to EXPLORER_TEST_POSTGRES_DSN and empties
+// it, skipping the test when no server is configured
+func newTestPostgresStore(t *testing.T) *PostgresStore {
+	dsn := os.Getenv("EXPLORER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("EXPLORER_TEST_POSTGRES_DSN not set")
+	}
+	store, err := NewPostgresStore(dsn)
+	if err != nil {
+		t.Fatalf("Failed to open postgres store: %v", err)
+	}
+	if err := store.ResetDatabase(); err != nil {
+		t.Fatalf("Failed to reset postgres store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestOpenStoreFromEnvRejectsUnknownBackend(t *testing.T) {
+	t.Setenv("EXPLORER_DB_BACKEND", "mysql")
+	if _, err := openStoreFromEnv(); err == nil {
+		t.Fatal("Expected an error for an unknown backend")
+	}
+
+	t.Setenv("EXPLORER_DB_BACKEND", "postgres")
+	t.Setenv("EXPLORER_POSTGRES_DSN", "")
+	if _, err := openStoreFromEnv(); err == nil {
+		t.Fatal("Expected an error for postgres without a DSN")
+	}
+}
+
+func TestLikePrefixEscapesWildcards(t *testing.T) {
+	if got := likePrefix(`50%_a\b`); got != `50\%\_a\\b%` {
+		t.Errorf("Unexpected pattern %q", got)
+	}
+}
+
+func TestPostgresBlocksAndCursor(t *testing.T) {
+	store := newTestPostgresStore(t)
+	storeTestBlocks(t, store, 0, 9)
+
+	if height, err := store.GetLatestHeight(); err != nil || height != 9 {
+		t.Fatalf("Expected latest height 9, got %d (%v)", height, err)
+	}
+	tip, err := store.GetLatestBlock()
+	if err != nil || tip.Hash != "hash_9" {
+		t.Fatalf("Expected tip hash_9, got %+v (%v)", tip, err)
+	}
+
+	var heights []uint64
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := store.GetBlocksByCursor(cursor, 4)
+		if err != nil {
+			t.Fatalf("GetBlocksByCursor failed: %v", err)
+		}
+		for _, block := range page.Blocks {
+			heights = append(heights, block.Height)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(heights) != 10 || heights[0] != 9 || heights[9] != 0 {
+		t.Errorf("Expected heights 9 down to 0, got %v", heights)
+	}
+}
+
+func TestPostgresWalletRanking(t *testing.T) {
+	store := newTestPostgresStore(t)
+	start := time.Unix(1700000000, 0).UTC()
+
+	txs := []*WalletTransaction{
+		{TxHash: "r1", Type: "mining_reward", BlockHeight: 1, ToAddress: "alice", Amount: 100},
+		{TxHash: "r2", Type: "mining_reward", BlockHeight: 2, ToAddress: "bob", Amount: 100},
+		{TxHash: "t1", Type: "transfer", BlockHeight: 3, FromAddress: "alice", ToAddress: "bob", Amount: 30, Fee: 1},
+	}
+	for i, tx := range txs {
+		tx.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if err := store.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store %s: %v", tx.TxHash, err)
+		}
+	}
+
+	summary, err := store.GetWalletSummary("alice")
+	if err != nil {
+		t.Fatalf("GetWalletSummary failed: %v", err)
+	}
+	if summary.Balance != 69 || summary.TransactionCount != 2 || summary.BlocksMined != 1 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	wallets, total, err := store.GetAllWallets(10, 0)
+	if err != nil {
+		t.Fatalf("GetAllWallets failed: %v", err)
+	}
+	if total != 2 || len(wallets) != 2 || wallets[0].Address != "bob" || wallets[0].Balance != 130 {
+		t.Errorf("Expected bob first with 130, got %d wallets %+v", total, wallets)
+	}
+
+	list, err := store.GetRichList(10)
+	if err != nil {
+		t.Fatalf("GetRichList failed: %v", err)
+	}
+	if got := richAddresses(list); got != "[bob=130 alice=69]" {
+		t.Errorf("Unexpected rich list %s", got)
+	}
+	dist, err := store.GetBalanceDistribution()
+	if err != nil {
+		t.Fatalf("GetBalanceDistribution failed: %v", err)
+	}
+	if dist.TotalHolders != 2 || dist.TotalBalance != 199 || dist.Buckets[0].Holders != 2 {
+		t.Errorf("Unexpected distribution %+v", dist)
+	}
+
+	check, err := store.CheckSupply()
+	if err != nil {
+		t.Fatalf("CheckSupply failed: %v", err)
+	}
+	if !check.Consistent {
+		t.Errorf("Expected a consistent ledger, got %+v", check)
+	}
+}
+
+func TestPostgresPoolsByToken(t *testing.T) {
+	store := newTestPostgresStore(t)
+	storeTestPools(t, store)
+
+	result, err := store.GetPoolsByToken("gold", 1, 10)
+	if err != nil {
+		t.Fatalf("GetPoolsByToken failed: %v", err)
+	}
+	if result.TotalPools != 2 {
+		t.Errorf("Expected 2 GOLD pools, got %d", result.TotalPools)
+	}
+}
+
+func TestPostgresPoolsByTokenWithColons(t *testing.T) {
+	checkPoolsByTokenWithColons(t, newTestPostgresStore(t))
+}
+
+func TestPostgresRollbackRebuildsTokens(t *testing.T) {
+	store := newTestPostgresStore(t)
+	svc := NewSyncService("", newTestNodeClient(), store)
+	storeTestBlocks(t, store, 1, 6)
+	start := time.Unix(1700000000, 0).UTC()
+
+	ops := []struct {
+		height uint64
+		op     *TokenOperation
+	}{
+		{2, &TokenOperation{Type: TOKEN_CREATE, TokenID: "0a0a0a0a0a0a0a0a", Amount: 100, To: "alice",
+			Metadata: &TokenMetadata{Name: "Alpha", Ticker: "ALP"}}},
+		{5, &TokenOperation{Type: TOKEN_TRANSFER, TokenID: "0a0a0a0a0a0a0a0a", Amount: 40, From: "alice", To: "bob"}},
+	}
+	for i, o := range ops {
+		block := &Block{Header: BlockHeader{Height: o.height}}
+		if err := svc.processTokenOperation(fmt.Sprintf("hash_%d", o.height), block, fmt.Sprintf("ttx%d", i), o.op,
+			start.Add(time.Duration(o.height)*time.Minute), indexAll); err != nil {
+			t.Fatalf("Failed to process op %d: %v", i, err)
+		}
+	}
+
+	summary, err := store.RollbackToHeight(3)
+	if err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	if summary.Blocks != 3 || summary.TokensRebuilt != 1 {
+		t.Errorf("Unexpected rollback summary %+v", summary)
+	}
+
+	alice, err := store.GetTokenActivity("alice")
+	if err != nil {
+		t.Fatalf("GetTokenActivity failed: %v", err)
+	}
+	if len(alice) != 1 || alice[0].Balance != 100 || alice[0].Sent != 0 {
+		t.Errorf("Expected alice back at 100 ALP with no sends, got %+v", alice)
+	}
+	if bob, _ := store.GetTokenActivity("bob"); len(bob) != 0 {
+		t.Errorf("Bob only appeared after the fork, got %+v", bob)
+	}
+}
+
+func TestPostgresPoolSwapsRolledBack(t *testing.T) {
+	store := newTestPostgresStore(t)
+	svc := NewSyncService("", newTestNodeClient(), store)
+	start := time.Unix(1700000000, 0).UTC()
+
+	ops := []struct {
+		height uint64
+		op     *TokenOperation
+	}{
+		{2, &TokenOperation{Type: POOL_CREATE, TokenID: "pool0000000000000001", Amount: 1000, To: "alice",
+			Metadata: &TokenMetadata{LiquidityPool: &LiquidityPoolData{TokenA: "gold0000", TokenB: "SHADOW",
+				InitialRatioA: 1_000_000, InitialRatioB: 2_000_000, LAddress: "L_pool"}}}},
+		{3, &TokenOperation{Type: POOL_SWAP, TokenID: "gold0000", Amount: 10_000, From: "bob", To: "L_pool"}},
+		{5, &TokenOperation{Type: POOL_SWAP, TokenID: "SHADOW", Amount: 40_000, From: "carol", To: "L_pool"}},
+	}
+	for i, o := range ops {
+		block := &Block{Header: BlockHeader{Height: o.height}}
+		if err := svc.processTokenOperation(fmt.Sprintf("hash_%d", o.height), block, fmt.Sprintf("ptx%d", i), o.op,
+			start.Add(time.Duration(o.height)*time.Minute), indexAll); err != nil {
+			t.Fatalf("Failed to process op %d: %v", i, err)
+		}
+	}
+
+	swaps, err := store.GetPoolSwaps("pool0000000000000001", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetPoolSwaps failed: %v", err)
+	}
+	if len(swaps) != 2 || swaps[0].PriceAfter >= swaps[0].PriceBefore {
+		t.Fatalf("Unexpected swaps %+v", swaps)
+	}
+
+	if _, err := store.RollbackToHeight(4); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	pool, err := store.GetPoolByAddress("L_pool")
+	if err != nil {
+		t.Fatalf("GetPoolByAddress failed: %v", err)
+	}
+	if pool.TradeCount != 1 || pool.ReserveA != 1_010_000 || pool.ReserveB != 2_000_000-swaps[0].AmountB {
+		t.Errorf("Expected the pool back after its first swap, got %+v", pool)
+	}
+}
+
+func TestPostgresFarmerBlocks(t *testing.T) {
+	store := newTestPostgresStore(t)
+	storeTestRewards(t, store, []string{"alice", "bob", "alice"}, time.Now().UTC())
+
+	blocks, err := store.GetMinedBlocksSince(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetMinedBlocksSince failed: %v", err)
+	}
+	if len(blocks) != 3 || blocks[0].Address != "alice" || blocks[1].Address != "bob" {
+		t.Errorf("Expected every block with its farmer, oldest first, got %+v", blocks)
+	}
+	farmed, err := store.GetFarmerBlocks("alice")
+	if err != nil {
+		t.Fatalf("GetFarmerBlocks failed: %v", err)
+	}
+	if len(farmed) != 2 || farmed[0].Height != 1 || farmed[1].Height != 3 {
+		t.Errorf("Expected alice's blocks 1 and 3, got %+v", farmed)
+	}
+}
+
+func TestPostgresChartRollups(t *testing.T) {
+	store := newTestPostgresStore(t)
+	storeTestBlocks(t, store, 1, 3)
+	hour := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	for i, addresses := range [][]string{{"alice", "bob"}, {"alice"}, {"carol"}} {
+		sample := &ChartSample{Height: uint64(i + 1), Timestamp: hour.Add(time.Duration(i) * 20 * time.Minute),
+			Transactions: 1, Addresses: addresses}
+		if err := store.RecordChartSample(sample); err != nil {
+			t.Fatalf("RecordChartSample failed: %v", err)
+		}
+	}
+
+	hours, err := store.GetChartRollups(chartHour, hour, hour)
+	if err != nil || len(hours) != 1 {
+		t.Fatalf("Expected 1 hourly rollup, got %+v (%v)", hours, err)
+	}
+	if hours[0].Blocks != 3 || hours[0].ActiveAddresses != 3 {
+		t.Errorf("Unexpected hour %+v", hours[0])
+	}
+
+	if _, err := store.RollbackToHeight(2); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	hours, _ = store.GetChartRollups(chartHour, hour, hour)
+	if len(hours) != 1 || hours[0].Blocks != 2 || hours[0].ActiveAddresses != 2 {
+		t.Errorf("Unexpected hour after rollback %+v", hours)
+	}
+}
+
+func TestPostgresAPIKeys(t *testing.T) {
+	store := newTestPostgresStore(t)
+	hash := hashAPIKey("sxk_test")
+	key := &APIKey{ID: hash[:12], Name: "indexer", KeyHash: hash, RatePerMinute: 600, Burst: 50, CreatedAt: time.Now()}
+	if err := store.StoreAPIKey(key); err != nil {
+		t.Fatalf("StoreAPIKey failed: %v", err)
+	}
+	if err := store.ResetDatabase(); err != nil {
+		t.Fatalf("ResetDatabase failed: %v", err)
+	}
+
+	found, err := store.GetAPIKey(key.KeyHash)
+	if err != nil || found.Name != "indexer" {
+		t.Fatalf("Expected the key to survive a reset, got %+v (%v)", found, err)
+	}
+	if err := store.DeleteAPIKey(key.ID); err != nil {
+		t.Fatalf("DeleteAPIKey failed: %v", err)
+	}
+	if _, err := store.GetAPIKey(key.KeyHash); err != errNotFound {
+		t.Errorf("Expected errNotFound after delete, got %v", err)
+	}
+}
+
+func TestPostgresWatchlist(t *testing.T) {
+	store := newTestPostgresStore(t)
+	sub := &WatchSubscription{ID: "sub1", Address: "bob", WebhookURL: "https://example.com/hook", Events: []string{"received"}, Owner: "client1"}
+	if err := store.StoreWatchSubscription(sub); err != nil {
+		t.Fatalf("StoreWatchSubscription failed: %v", err)
+	}
+	if subs, err := store.GetWatchSubscriptions("bob"); err != nil || len(subs) != 1 {
+		t.Fatalf("Expected bob's subscription, got %+v (%v)", subs, err)
+	}
+	if count, err := store.CountWatchSubscriptions("client1"); err != nil || count != 1 {
+		t.Errorf("Expected one subscription for client1, got %d (%v)", count, err)
+	}
+
+	now := time.Now()
+	later := &WatchNotification{ID: "n2", Event: WatchEvent{SubscriptionID: "sub1"}, NextAttempt: now.Add(time.Minute)}
+	for _, n := range []*WatchNotification{{ID: "n1", Event: WatchEvent{SubscriptionID: "sub1"}, NextAttempt: now}, later} {
+		if err := store.QueueWatchNotification(n); err != nil {
+			t.Fatalf("QueueWatchNotification failed: %v", err)
+		}
+	}
+	if due, err := store.DueWatchNotifications(now, 10); err != nil || len(due) != 1 || due[0].ID != "n1" {
+		t.Errorf("Expected only n1 due, got %+v (%v)", due, err)
+	}
+
+	if err := store.DeleteWatchSubscription("sub1"); err != nil {
+		t.Fatalf("DeleteWatchSubscription failed: %v", err)
+	}
+	if err := store.DeleteWatchSubscription("sub1"); err != errNotFound {
+		t.Errorf("Expected errNotFound, got %v", err)
+	}
+}
+
+func TestPostgresTokenHoldersPage(t *testing.T) {
+	store := newTestPostgresStore(t)
+	for i := 1; i <= 3; i++ {
+		if err := store.UpdateTokenHolder("tok", fmt.Sprintf("holder_%d", i), uint64(i*10)); err != nil {
+			t.Fatalf("UpdateTokenHolder failed: %v", err)
+		}
+	}
+	store.UpdateTokenHolder("tok", "holder_2", 0)
+
+	page, err := store.GetTokenHoldersPage("tok", 2, 1)
+	if err != nil {
+		t.Fatalf("GetTokenHoldersPage failed: %v", err)
+	}
+	if page.TotalHolders != 2 || page.TotalPages != 2 || len(page.Holders) != 1 ||
+		page.Holders[0].Address != "holder_1" || page.Holders[0].Rank != 2 {
+		t.Errorf("Unexpected page %+v", page)
+	}
+	if balance, err := store.GetTokenBalance("tok", "holder_3"); err != nil || balance != 30 {
+		t.Errorf("Expected 30, got %d (%v)", balance, err)
+	}
+}
+
+func TestPostgresNFTMetadata(t *testing.T) {
+	store := newTestPostgresStore(t)
+	now := time.Now()
+	for _, meta := range []*NFTMetadata{
+		{TokenID: "nft1", Creator: "artist", Status: "pending", NextAttempt: now.Add(-time.Minute), Document: json.RawMessage(`{"name":"A"}`)},
+		{TokenID: "nft2", Creator: "artist", Status: "pending", NextAttempt: now.Add(time.Hour)},
+		{TokenID: "nft3", Creator: "other", Status: "resolved"},
+	} {
+		if err := store.StoreNFTMetadata(meta); err != nil {
+			t.Fatalf("StoreNFTMetadata failed: %v", err)
+		}
+	}
+
+	if due, err := store.DueNFTMetadata(now, 10); err != nil || len(due) != 1 || due[0].TokenID != "nft1" {
+		t.Errorf("Expected only nft1 due, got %+v (%v)", due, err)
+	}
+	nfts, err := store.ListNFTMetadata("artist")
+	if err != nil || len(nfts) != 2 || nfts[0].Document != nil {
+		t.Errorf("Expected artist's two NFTs without documents, got %+v (%v)", nfts, err)
+	}
+
+	if err := store.StoreNFTImage("nft1", "image/png", pngHeader); err != nil {
+		t.Fatalf("StoreNFTImage failed: %v", err)
+	}
+	if contentType, data, err := store.GetNFTImage("nft1"); err != nil || contentType != "image/png" || string(data) != string(pngHeader) {
+		t.Errorf("Unexpected image %q %q %v", contentType, data, err)
+	}
+	if _, _, err := store.GetNFTImage("nft2"); err != errNotFound {
+		t.Errorf("Expected errNotFound, got %v", err)
+	}
+}
+
+func TestPostgresBlockAlerts(t *testing.T) {
+	store := newTestPostgresStore(t)
+	storeTestBlocks(t, store, 1, 3)
+	for height := uint64(2); height <= 3; height++ {
+		if err := store.StoreBlockAlert(&BlockAlert{Height: height, Problems: []string{"merkle root mismatch"}}); err != nil {
+			t.Fatalf("StoreBlockAlert failed: %v", err)
+		}
+	}
+
+	alerts, err := store.GetBlockAlerts(1)
+	if err != nil || alerts.Total != 2 || len(alerts.Alerts) != 1 || alerts.Alerts[0].Height != 3 {
+		t.Errorf("Expected the latest of 2 alerts, got %+v (%v)", alerts, err)
+	}
+	if _, err := store.RollbackToHeight(2); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	if _, err := store.GetBlockAlert(3); err != errNotFound {
+		t.Errorf("Expected the alert above the fork removed, got %v", err)
+	}
+	if alert, err := store.GetBlockAlert(2); err != nil || alert.Problems[0] != "merkle root mismatch" {
+		t.Errorf("Expected block 2's alert kept, got %+v (%v)", alert, err)
+	}
+}
+
+func TestPostgresAuditLog(t *testing.T) {
+	store := newTestPostgresStore(t)
+	for _, path := range []string{"/api/v1/admin/reset", "/api/v1/admin/sync/pause"} {
+		if err := store.AppendAuditEntry(&AuditEntry{Time: time.Now(), Actor: "token", Method: "POST", Path: path, Status: 200}); err != nil {
+			t.Fatalf("AppendAuditEntry failed: %v", err)
+		}
+	}
+	if err := store.ResetDatabase(); err != nil {
+		t.Fatalf("ResetDatabase failed: %v", err)
+	}
+
+	entries, err := store.GetAuditLog(1)
+	if err != nil || len(entries) != 1 || entries[0].Path != "/api/v1/admin/sync/pause" {
+		t.Errorf("Expected the latest entry kept across a reset, got %+v (%v)", entries, err)
+	}
+}
+
+func TestPostgresReindexTokens(t *testing.T) {
+	store := newTestPostgresStore(t)
+	svc := NewSyncService("", newTestNodeClient(), store)
+	storeTokenBlocks(t, svc)
+	if err := store.UpdateTokenHolder("0a0a0a0a0a0a0a0a", "bob", 999); err != nil {
+		t.Fatalf("UpdateTokenHolder failed: %v", err)
+	}
+
+	if _, err := svc.Reindex("tokens", 2); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	if status := waitForReindex(t, svc); status.Error != "" || status.Height != 3 {
+		t.Fatalf("Unexpected reindex status %+v", status)
+	}
+	for address, want := range map[string]uint64{"alice": 60, "bob": 30, "carol": 10} {
+		if balance, _ := store.GetTokenBalance("0a0a0a0a0a0a0a0a", address); balance != want {
+			t.Errorf("Expected %s to hold %d after the reindex, got %d", address, want, balance)
+		}
+	}
+}
+
+func TestPostgresOrphanTracking(t *testing.T) {
+	checkOrphanTracking(t, newTestPostgresStore(t))
+}
+
+func TestPostgresAddressLabels(t *testing.T) {
+	checkAddressLabels(t, newTestPostgresStore(t))
+}
+
+func TestPostgresTokenTransfers(t *testing.T) {
+	store := newTestPostgresStore(t)
+	storeTestTokenTransfers(t, store)
+
+	page, err := store.GetTokenTransfers("tok", TokenTransferFilter{From: "alice", To: "bob", Type: "transfer"}, 2, 2)
+	if err != nil {
+		t.Fatalf("GetTokenTransfers failed: %v", err)
+	}
+	if page.TotalTransfers != 3 || page.TotalPages != 2 || len(page.Transfers) != 1 || page.Transfers[0].TxHash != "tx_1" {
+		t.Errorf("Unexpected page %+v", page)
+	}
+}
+
+func TestPostgresTokenMelts(t *testing.T) {
+	checkTokenMelts(t, newTestPostgresStore(t))
+}
+
+func TestPostgresListCursors(t *testing.T) {
+	checkListCursors(t, newTestPostgresStore(t))
+}
+
+func TestPostgresTrendingTokens(t *testing.T) {
+	store := newTestPostgresStore(t)
+	now := time.Now()
+	storeTrendingTokens(t, store, now)
+	checkTrendingTokens(t, store, now)
+}
+
+func TestPostgresBalanceHistory(t *testing.T) {
+	store := newTestPostgresStore(t)
+	storeTestBalanceHistory(t, store)
+	checkBalanceHistory(t, store)
+}
+
+func TestPostgresJobs(t *testing.T) {
+	store := newTestPostgresStore(t)
+	prefix := fmt.Sprintf("%016x", time.Now().UnixNano())
+	for i, status := range []string{jobSucceeded, jobRunning} {
+		if err := store.StoreJob(&Job{ID: fmt.Sprintf("%s%02d", prefix, i), Kind: "reset", Status: status}); err != nil {
+			t.Fatalf("StoreJob failed: %v", err)
+		}
+	}
+	if err := store.ResetDatabase(); err != nil {
+		t.Fatalf("ResetDatabase failed: %v", err)
+	}
+
+	jobs, err := store.ListJobs(1)
+	if err != nil || len(jobs) != 1 || jobs[0].ID != prefix+"01" || jobs[0].Status != jobRunning {
+		t.Fatalf("Expected the latest job kept across a reset, got %+v (%v)", jobs, err)
+	}
+	if err := store.PruneJobs(0); err != nil {
+		t.Fatalf("PruneJobs failed: %v", err)
+	}
+	if _, err := store.GetJob(prefix + "01"); err != nil {
+		t.Errorf("Expected the running job kept, got %v", err)
+	}
+}
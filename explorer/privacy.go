@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// onionCSP keeps pages served for Tor from fetching anything off-site.
+// Pages carry inline scripts and styles, and data: images for QR codes.
+const onionCSP = "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; " +
+	"script-src 'self' 'unsafe-inline'; connect-src 'self'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// redactedClient stands in for a client address in logs in privacy mode
+const redactedClient = "[redacted]"
+
+// privacyMode reports whether client addresses are kept out of logs
+func (c *ServerConfig) privacyMode() bool {
+	return c != nil && (c.Privacy || c.Onion)
+}
+
+// onionMode reports whether pages are served without absolute URLs or
+// off-site assets
+func (c *ServerConfig) onionMode() bool {
+	return c != nil && c.Onion
+}
+
+// clientAddr is the client address to store with a request, e.g. in the
+// audit log; empty in privacy mode
+func (c *ServerConfig) clientAddr(r *http.Request) string {
+	if c.privacyMode() {
+		return ""
+	}
+	return r.RemoteAddr
+}
+
+// logClient is the client address to log for a request
+func (c *ServerConfig) logClient(r *http.Request) string {
+	if c.privacyMode() {
+		return redactedClient
+	}
+	return r.RemoteAddr
+}
+
+// privacyHeaders asks browsers not to send referrers off-site in privacy
+// mode and, in onion mode, not to load anything from other origins.
+// Handlers setting their own policy, like NFT images, override it.
+func (c *ServerConfig) privacyHeaders(next http.Handler) http.Handler {
+	if !c.privacyMode() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		if c.onionMode() {
+			w.Header().Set("Content-Security-Policy", onionCSP)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsAllowed reports whether browsers on origin may call the explorer
+func (c *ServerConfig) corsAllowed(origin string) bool {
+	for _, allowed := range c.CORSOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// cors lets pages on the allowed origins call the API from browsers,
+// answering preflight requests itself since routes only accept their own
+// methods. Credentials are never allowed; API keys and admin tokens are
+// sent as headers.
+func (c *ServerConfig) cors(next http.Handler) http.Handler {
+	if len(c.CORSOrigins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !c.corsAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Expose-Headers", "ETag, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			header.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-API-Key")
+			header.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// accessLog logs each request's method, path, status and duration, and
+// its client outside privacy mode. Query strings are left out since they
+// may carry API keys.
+func (c *ServerConfig) accessLog(next http.Handler) http.Handler {
+	if !c.AccessLog {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		args := []any{"method", r.Method, "path", r.URL.Path, "status", recorder.status, "duration", time.Since(start)}
+		if !c.privacyMode() {
+			args = append(args, "client", r.RemoteAddr)
+		}
+		slog.InfoContext(r.Context(), "Request", args...)
+	})
+}
+
+// connClientPattern matches the client address net/http puts in its
+// connection errors, e.g. "TLS handshake error from 1.2.3.4:5678: EOF"
+var connClientPattern = regexp.MustCompile(`(from|serving) \S+:\d+: `)
+
+// redactingWriter removes client addresses from the server's error log
+type redactingWriter struct {
+	io.Writer
+}
+
+func (w redactingWriter) Write(data []byte) (int, error) {
+	if _, err := w.Writer.Write(connClientPattern.ReplaceAll(data, []byte("${1} "+redactedClient+": "))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// errorLog is the HTTP server's error log: the standard logger, with
+// client addresses removed in privacy mode
+func (c *ServerConfig) errorLog() *log.Logger {
+	if !c.privacyMode() {
+		return nil
+	}
+	return log.New(redactingWriter{log.Writer()}, log.Prefix(), log.Flags())
+}
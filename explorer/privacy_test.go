@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadServerConfigPrivacy(t *testing.T) {
+	t.Setenv("EXPLORER_CONFIG", "")
+	t.Setenv("EXPLORER_CORS_ORIGINS", "https://wallet.example.com/, *")
+	config, err := loadServerConfig([]string{"-onion", "-access-log"})
+	if err != nil {
+		t.Fatalf("loadServerConfig failed: %v", err)
+	}
+	if !config.Onion || !config.Privacy || !config.AccessLog || config.CORSOrigins[0] != "https://wallet.example.com" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	t.Setenv("EXPLORER_CORS_ORIGINS", "")
+	t.Setenv("EXPLORER_PRIVACY", "maybe")
+	if _, err := loadServerConfig(nil); err == nil {
+		t.Error("Expected an invalid EXPLORER_PRIVACY to be rejected")
+	}
+	t.Setenv("EXPLORER_PRIVACY", "")
+	for _, args := range [][]string{
+		{"-onion", "-assets", "cdn"},
+		{"-cors-origins", "wallet.example.com"},
+		{"-cors-origins", "https://wallet.example.com/app"},
+	} {
+		if _, err := loadServerConfig(args); err == nil {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	config := &ServerConfig{CORSOrigins: []string{"https://wallet.example.com"}}
+	handler := config.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	preflight := httptest.NewRequest("OPTIONS", "/api/v1/blocks", nil)
+	preflight.Header.Set("Origin", "https://wallet.example.com")
+	preflight.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, preflight)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "https://wallet.example.com" ||
+		!strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), "X-API-Key") {
+		t.Errorf("Expected the preflight answered, got %d %v", rec.Code, rec.Header())
+	}
+
+	for origin, allowed := range map[string]bool{"https://wallet.example.com": true, "https://evil.example.com": false} {
+		req := httptest.NewRequest("GET", "/api/v1/blocks", nil)
+		req.Header.Set("Origin", origin)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin") != ""; got != allowed || rec.Body.String() != "ok" {
+			t.Errorf("Expected %s allowed %v, got %v", origin, allowed, rec.Header())
+		}
+	}
+}
+
+func TestPrivacyModeKeepsClientsOutOfLogs(t *testing.T) {
+	var logs bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(output) })
+
+	config := &ServerConfig{Privacy: true, AccessLog: true}
+	handler := config.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	req := httptest.NewRequest("GET", "/api/v1/blocks?api_key=secret", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Referrer-Policy") != "no-referrer" || rec.Header().Get("Content-Security-Policy") != "" {
+		t.Errorf("Expected referrers stripped without an onion policy, got %v", rec.Header())
+	}
+
+	config.errorLog().Print("http: TLS handshake error from 203.0.113.7:5555: EOF")
+	if out := logs.String(); strings.Contains(out, "203.0.113.7") || strings.Contains(out, "secret") ||
+		!strings.Contains(out, "path=/api/v1/blocks status=418") || !strings.Contains(out, "handshake error from "+redactedClient) {
+		t.Errorf("Expected requests logged without clients or query strings, got %q", out)
+	}
+
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database, adminToken: "token", config: config}
+	es.requireAdmin(func(w http.ResponseWriter, r *http.Request) {})(httptest.NewRecorder(), req)
+	if entries, _ := database.GetAuditLog(1); len(entries) != 1 || entries[0].RemoteAddr != "" {
+		t.Errorf("Expected the audit entry stored without the client, got %+v", entries)
+	}
+}
+
+func TestOnionPagesUseRelativeURLs(t *testing.T) {
+	config := &ServerConfig{Onion: true}
+	if err := config.validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	es := &ExplorerServer{database: newTestDatabase(t), config: config}
+
+	rec := httptest.NewRecorder()
+	config.Handler(http.HandlerFunc(es.handleTokensPage)).ServeHTTP(rec, httptest.NewRequest("GET", "/tokens", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || strings.Contains(body, "http://") || strings.Contains(body, "https://") {
+		t.Errorf("Expected no absolute URLs in onion pages, got %d %s", rec.Code, body)
+	}
+	if rec.Header().Get("Content-Security-Policy") != onionCSP || rec.Header().Get("Referrer-Policy") != "no-referrer" {
+		t.Errorf("Expected the onion policies, got %v", rec.Header())
+	}
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Pruning drops the transaction detail of blocks more than keep blocks
+// below the tip. What the dropped records added up to is carried forward so
+// wallet summaries and the supply check stay whole: per address under
+// addr_pruned:<address>, for the chain under pruned_supply. Blocks below
+// prune_height keep their header but not their transactions.
+const (
+	prunedAddressPrefix = "addr_pruned:"
+	prunedSupplyKey     = "pruned_supply"
+	pruneHeightKey      = "prune_height"
+
+	defaultPruneInterval = time.Hour
+
+	// pruneBatchSize is how many records each write transaction prunes,
+	// keeping transactions well below Badger's size limit
+	pruneBatchSize = 1000
+)
+
+// prunedHistory is what an address's pruned transactions added up to
+type prunedHistory struct {
+	Balance       int64     `json:"balance"`
+	Transactions  int       `json:"transactions"`
+	BlocksMined   int       `json:"blocks_mined"`
+	FirstActivity time.Time `json:"first_activity"`
+	LastActivity  time.Time `json:"last_activity"`
+}
+
+// add folds tx into the history the same way GetWalletSummary derives a
+// wallet's statistics
+func (h *prunedHistory) add(address string, tx *WalletTransaction) {
+	if h.Transactions == 0 || tx.Timestamp.After(h.LastActivity) {
+		h.LastActivity = tx.Timestamp
+	}
+	if h.Transactions == 0 || tx.Timestamp.Before(h.FirstActivity) {
+		h.FirstActivity = tx.Timestamp
+	}
+	h.Transactions++
+
+	if tx.ToAddress == address {
+		h.Balance += int64(tx.Amount)
+	}
+	if tx.FromAddress == address {
+		h.Balance -= int64(tx.Amount + tx.Fee)
+	}
+	if tx.FromAddress == "" && tx.ToAddress == address {
+		h.BlocksMined++
+	}
+}
+
+// prunedSupply is what the pruned transactions added to the supply check
+type prunedSupply struct {
+	Emission     uint64 `json:"emission"`
+	Burned       uint64 `json:"burned"`
+	Transactions int    `json:"transactions"`
+}
+
+// PruneSummary describes what one pruning pass removed
+type PruneSummary struct {
+	PrunedBelow       uint64 `json:"pruned_below"` // Blocks below this height hold no transaction detail
+	Blocks            int    `json:"blocks"`
+	Transactions      int    `json:"transactions"`
+	AddressEntries    int    `json:"address_entries"`
+	TokenTransfers    int    `json:"token_transfers"`
+	ReclaimedLogFiles int    `json:"reclaimed_log_files"`
+}
+
+// readJSON decodes the value at key into v, leaving v untouched when the
+// key does not exist
+func readJSON(txn *badger.Txn, key string, v interface{}) error {
+	item, err := txn.Get([]byte(key))
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return item.Value(func(val []byte) error {
+		return json.Unmarshal(val, v)
+	})
+}
+
+func writeJSON(txn *badger.Txn, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return txn.Set([]byte(key), data)
+}
+
+// getPrunedHistory returns what the pruned transactions of address added
+// up to, which is empty unless pruning has reached them
+func getPrunedHistory(txn *badger.Txn, address string) (prunedHistory, error) {
+	var history prunedHistory
+	err := readJSON(txn, prunedAddressPrefix+address, &history)
+	return history, err
+}
+
+// GetPruneHeight returns the height below which blocks hold no transaction
+// detail, or 0 when nothing has been pruned
+func (d *Database) GetPruneHeight() (uint64, error) {
+	var height uint64
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(pruneHeightKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			if len(val) == 8 {
+				height = binary.BigEndian.Uint64(val)
+			}
+			return nil
+		})
+	})
+	return height, err
+}
+
+// pruneKeys deletes, pruneBatchSize per write transaction, every record
+// under prefix that old selects, handing each to fold in the transaction
+// that deletes it. It returns how many records were pruned.
+func (d *Database) pruneKeys(prefix string, old func(key string, val []byte) bool, fold func(txn *badger.Txn, key string, val []byte) error) (int, error) {
+	pruned := 0
+	seek := []byte(prefix)
+	for {
+		done := true
+		err := d.update(func(txn *badger.Txn) error {
+			type record struct {
+				key string
+				val []byte
+			}
+			var batch []record
+
+			it := txn.NewIterator(badger.DefaultIteratorOptions)
+			for it.Seek(seek); it.ValidForPrefix([]byte(prefix)); it.Next() {
+				if len(batch) == pruneBatchSize {
+					seek = it.Item().KeyCopy(nil)
+					done = false
+					break
+				}
+				val, err := it.Item().ValueCopy(nil)
+				if err != nil {
+					it.Close()
+					return err
+				}
+				key := string(it.Item().Key())
+				if old(key, val) {
+					batch = append(batch, record{key, val})
+				}
+			}
+			it.Close()
+
+			for _, r := range batch {
+				if fold != nil {
+					if err := fold(txn, r.key, r.val); err != nil {
+						return err
+					}
+				}
+				if err := txn.Delete([]byte(r.key)); err != nil {
+					return err
+				}
+			}
+			pruned += len(batch)
+			return nil
+		})
+		if err != nil || done {
+			return pruned, err
+		}
+	}
+}
+
+// pruneCutoff returns the height pruning may advance to, at most target.
+// It stops at the first block of the current UTC day, whose daily stats are
+// not cached yet and still need that day's transactions.
+func (d *Database) pruneCutoff(from, target uint64, now time.Time) (uint64, error) {
+	today := dayStart(now)
+	cutoff := target
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte("height:")
+		for it.Seek([]byte(fmt.Sprintf("height:%016d", from))); it.ValidForPrefix(prefix); it.Next() {
+			height, ok := heightFromKey(string(it.Item().Key()), "height:")
+			if !ok || height >= target {
+				return nil
+			}
+			blockHash, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			var block Block
+			if err := readJSON(txn, "block:"+string(blockHash), &block); err != nil {
+				return err
+			}
+			if !block.Header.Timestamp.Before(today) {
+				cutoff = height
+				return nil
+			}
+		}
+		return nil
+	})
+	return cutoff, err
+}
+
+// PruneBefore drops the transaction detail of every block below height,
+// stopping early at the current UTC day. Wallet totals, the supply check,
+// daily stats, the first-seen index and balance history are kept: the last
+// three are built first, and the first two fold in what was pruned.
+// Current balances, token holders and pool history are left alone.
+func (d *Database) PruneBefore(height uint64, now time.Time) (*PruneSummary, error) {
+	previous, err := d.GetPruneHeight()
+	if err != nil {
+		return nil, err
+	}
+	cutoff, err := d.pruneCutoff(previous, height, now)
+	if err != nil {
+		return nil, err
+	}
+	summary := &PruneSummary{PrunedBelow: previous}
+	if cutoff <= previous {
+		return summary, nil
+	}
+
+	// Fill the cache that is otherwise rebuilt from transaction detail
+	if _, err := d.GetDailyStats(maxDailyStatsRange, now); err != nil {
+		return nil, fmt.Errorf("failed to cache daily stats: %w", err)
+	}
+
+	// Address entries first, while the transactions they point at remain.
+	// Format: addr_tx:address:blockheight:txhash
+	summary.AddressEntries, err = d.pruneKeys("addr_tx:", func(key string, val []byte) bool {
+		parts := strings.SplitN(key, ":", 4)
+		if len(parts) < 4 {
+			return false
+		}
+		h, err := strconv.ParseUint(parts[2], 10, 64)
+		return err == nil && h < cutoff
+	}, func(txn *badger.Txn, key string, val []byte) error {
+		address := strings.SplitN(key, ":", 3)[1]
+		var tx WalletTransaction
+		if err := readJSON(txn, "tx:"+string(val), &tx); err != nil {
+			return err
+		}
+		if tx.TxHash == "" {
+			slog.Warn("Pruning found a wallet index pointing at a missing transaction", "address", address, "tx_hash", val)
+			return nil
+		}
+		history, err := getPrunedHistory(txn, address)
+		if err != nil {
+			return err
+		}
+		history.add(address, &tx)
+		return writeJSON(txn, prunedAddressPrefix+address, history)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune address index: %w", err)
+	}
+
+	summary.Transactions, err = d.pruneKeys("tx:", func(key string, val []byte) bool {
+		var tx WalletTransaction
+		return json.Unmarshal(val, &tx) == nil && tx.BlockHeight < cutoff
+	}, func(txn *badger.Txn, key string, val []byte) error {
+		var tx WalletTransaction
+		if err := json.Unmarshal(val, &tx); err != nil {
+			return err
+		}
+		var supply prunedSupply
+		if err := readJSON(txn, prunedSupplyKey, &supply); err != nil {
+			return err
+		}
+		supply.Transactions++
+		if tx.Type == "mining_reward" {
+			supply.Emission += tx.Amount
+		} else {
+			supply.Burned += tx.Fee
+			if tx.ToAddress == "" {
+				supply.Burned += tx.Amount
+			}
+		}
+		return writeJSON(txn, prunedSupplyKey, supply)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune transactions: %w", err)
+	}
+
+	summary.TokenTransfers, err = d.pruneKeys("token_tx:", func(key string, val []byte) bool {
+		var tx TokenTransaction
+		return json.Unmarshal(val, &tx) == nil && tx.BlockHeight < cutoff
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune token transfers: %w", err)
+	}
+
+	// Blocks keep their header and counts; the height moves last so an
+	// interrupted pass is picked up again
+	for start := previous; start < cutoff; start += pruneBatchSize {
+		end := start + pruneBatchSize
+		if end > cutoff {
+			end = cutoff
+		}
+		err := d.update(func(txn *badger.Txn) error {
+			for h := start; h < end; h++ {
+				item, err := txn.Get([]byte(fmt.Sprintf("height:%016d", h)))
+				if err == badger.ErrKeyNotFound {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				blockHash, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				key := "block:" + string(blockHash)
+				item, err = txn.Get([]byte(key))
+				if err != nil {
+					return err
+				}
+				val, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				var block Block
+				if err := json.Unmarshal(val, &block); err != nil {
+					return err
+				}
+				if block.Body.Pruned {
+					continue
+				}
+				block.Body.Transactions = nil
+				block.Body.Pruned = true
+				block.Body.PrunedSize = len(val)
+				if err := writeJSON(txn, key, &block); err != nil {
+					return err
+				}
+				summary.Blocks++
+			}
+			if end == cutoff {
+				value := make([]byte, 8)
+				binary.BigEndian.PutUint64(value, cutoff)
+				return txn.Set([]byte(pruneHeightKey), value)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune blocks: %w", err)
+		}
+	}
+	summary.PrunedBelow = cutoff
+
+	summary.ReclaimedLogFiles = d.compact()
+	return summary, nil
+}
+
+// compact runs Badger's value log garbage collection until it finds
+// nothing left to rewrite, returning how many log files it reclaimed
+func (d *Database) compact() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.db == nil {
+		return 0
+	}
+	reclaimed := 0
+	for d.db.RunValueLogGC(0.5) == nil {
+		reclaimed++
+	}
+	return reclaimed
+}
+
+// Pruner keeps a Badger index to the last keep blocks of transaction
+// detail, pruning and compacting in the background
+type Pruner struct {
+	database *Database
+	keep     uint64
+	interval time.Duration
+	jobs     *JobQueue // Runs each pass as a job when set
+
+	stopCh chan struct{}
+}
+
+// NewPruner creates a pruner that runs every interval
+func NewPruner(database *Database, keep uint64, interval time.Duration) *Pruner {
+	return &Pruner{
+		database: database,
+		keep:     keep,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// newPrunerFromEnv creates a pruner keeping EXPLORER_PRUNE_KEEP_BLOCKS
+// blocks of detail and running every EXPLORER_PRUNE_INTERVAL, or nil when
+// pruning is off. Blocks within the finality depth are always kept so a
+// reorg never has to roll back pruned blocks.
+func newPrunerFromEnv(store Store) *Pruner {
+	value := os.Getenv("EXPLORER_PRUNE_KEEP_BLOCKS")
+	if value == "" || value == "0" {
+		return nil
+	}
+	keep, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		slog.Warn("Ignoring invalid EXPLORER_PRUNE_KEEP_BLOCKS, keeping every block", "value", value)
+		return nil
+	}
+	database, ok := store.(*Database)
+	if !ok {
+		slog.Warn("Pruning needs the badger backend, keeping every block")
+		return nil
+	}
+	if depth := max(finalityDepthFromEnv(), maxReorgDepth); keep < depth {
+		slog.Warn("EXPLORER_PRUNE_KEEP_BLOCKS is below the reorg window", "value", keep, "keeping", depth)
+		keep = depth
+	}
+
+	interval := defaultPruneInterval
+	if value := os.Getenv("EXPLORER_PRUNE_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < time.Minute {
+			slog.Warn("Ignoring invalid EXPLORER_PRUNE_INTERVAL", "value", value, "default", defaultPruneInterval)
+		} else {
+			interval = parsed
+		}
+	}
+	return NewPruner(database, keep, interval)
+}
+
+// Start prunes in the background, first straight away
+func (p *Pruner) Start() {
+	go func() {
+		p.run()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.run()
+			case <-p.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background pruning
+func (p *Pruner) Stop() {
+	close(p.stopCh)
+}
+
+// run prunes once, as a job when the pruner has a job queue
+func (p *Pruner) run() {
+	if !p.database.isOpen() {
+		return
+	}
+	if p.jobs == nil {
+		p.prune(context.Background())
+		return
+	}
+	_, err := p.jobs.Submit("prune", map[string]string{"keep_blocks": strconv.FormatUint(p.keep, 10)},
+		func(ctx context.Context, progress func(done, total uint64)) (interface{}, error) {
+			return p.prune(ctx)
+		})
+	if err != nil && err != errJobRunning {
+		slog.Error("Failed to queue pruning", "err", err)
+	}
+}
+
+// prune runs one pass and logs what it removed. A pass cannot stop once
+// it has started, so ctx is only checked beforehand.
+func (p *Pruner) prune(ctx context.Context) (*PruneSummary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	summary, err := p.Prune(time.Now())
+	if err != nil {
+		slog.Error("Pruning failed", "err", err)
+		return nil, err
+	}
+	if summary.Blocks > 0 {
+		slog.Info("Pruned blocks", "blocks", summary.Blocks, "below_height", summary.PrunedBelow, "transactions", summary.Transactions,
+			"token_transfers", summary.TokenTransfers, "reclaimed_log_files", summary.ReclaimedLogFiles)
+	}
+	return summary, nil
+}
+
+// Prune drops the detail of every block more than keep blocks below the tip
+func (p *Pruner) Prune(now time.Time) (*PruneSummary, error) {
+	tip, err := p.database.GetLatestHeight()
+	if err != nil {
+		return nil, err
+	}
+	if tip < p.keep {
+		return &PruneSummary{}, nil
+	}
+	return p.database.PruneBefore(tip-p.keep+1, now)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// storeTestHistory stores blocks 1-10 with a mining reward to alice in each,
+// a payment from alice to bob at height 3 and a token transfer at height 2
+func storeTestHistory(t *testing.T, database *Database) {
+	for height := uint64(1); height <= 10; height++ {
+		hash := fmt.Sprintf("hash_%d", height)
+		block := &Block{Header: BlockHeader{Height: height, Timestamp: time.Unix(int64(height), 0)}}
+		block.Body.Transactions = []SignedTransaction{{TxHash: fmt.Sprintf("reward_%d", height), Transaction: []byte(`{}`)}}
+		block.Body.TxCount = 1
+		if err := database.StoreBlock(hash, block); err != nil {
+			t.Fatalf("Failed to store block %d: %v", height, err)
+		}
+		reward := &WalletTransaction{TxHash: fmt.Sprintf("reward_%d", height), BlockHash: hash, BlockHeight: height,
+			Type: "mining_reward", Amount: 100, ToAddress: "alice", Timestamp: block.Header.Timestamp}
+		if err := database.StoreTransaction(reward); err != nil {
+			t.Fatalf("Failed to store reward: %v", err)
+		}
+	}
+	payment := &WalletTransaction{TxHash: "pay", BlockHash: "hash_3", BlockHeight: 3, Type: "transfer",
+		Amount: 30, Fee: 1, FromAddress: "alice", ToAddress: "bob", Timestamp: time.Unix(3, 0)}
+	if err := database.StoreTransaction(payment); err != nil {
+		t.Fatalf("Failed to store payment: %v", err)
+	}
+	transfer := &TokenTransaction{TxHash: "gold", BlockHeight: 2, Timestamp: time.Unix(2, 0), Type: "transfer", Amount: 5}
+	if err := database.StoreTokenTransaction("70ce", transfer); err != nil {
+		t.Fatalf("Failed to store token transfer: %v", err)
+	}
+}
+
+func TestPruneKeepsTotals(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestHistory(t, database)
+	now := time.Now()
+
+	before, err := database.GetWalletSummary("alice")
+	if err != nil {
+		t.Fatalf("GetWalletSummary failed: %v", err)
+	}
+	supplyBefore, err := database.CheckSupply()
+	if err != nil {
+		t.Fatalf("CheckSupply failed: %v", err)
+	}
+
+	summary, err := database.PruneBefore(6, now)
+	if err != nil {
+		t.Fatalf("PruneBefore failed: %v", err)
+	}
+	if summary.PrunedBelow != 6 || summary.Blocks != 5 || summary.Transactions != 6 || summary.TokenTransfers != 1 {
+		t.Errorf("Unexpected prune summary %+v", summary)
+	}
+
+	after, err := database.GetWalletSummary("alice")
+	if err != nil {
+		t.Fatalf("GetWalletSummary failed: %v", err)
+	}
+	if after.Balance != before.Balance || after.TransactionCount != before.TransactionCount || after.BlocksMined != before.BlocksMined ||
+		!after.FirstActivity.Equal(before.FirstActivity) || !after.LastActivity.Equal(before.LastActivity) {
+		t.Errorf("Pruning changed alice's totals: before %+v, after %+v", before, after)
+	}
+	if after.PrunedTransactions != 6 || len(after.Transactions) != 5 {
+		t.Errorf("Expected 6 pruned and 5 listed transactions, got %d and %d", after.PrunedTransactions, len(after.Transactions))
+	}
+
+	supplyAfter, err := database.CheckSupply()
+	if err != nil {
+		t.Fatalf("CheckSupply failed: %v", err)
+	}
+	if !supplyAfter.Consistent || supplyAfter.Emission != supplyBefore.Emission || supplyAfter.Burned != supplyBefore.Burned ||
+		supplyAfter.TransactionsChecked != supplyBefore.TransactionsChecked {
+		t.Errorf("Pruning changed the supply check: before %+v, after %+v", supplyBefore, supplyAfter)
+	}
+
+	// bob's only transaction was pruned, but he is still a wallet
+	addresses, err := database.ListAddresses("", 10)
+	if err != nil || len(addresses) != 2 || addresses[1] != "bob" {
+		t.Errorf("Expected alice and bob, got %v (%v)", addresses, err)
+	}
+	bob, err := database.GetWalletSummary("bob")
+	if err != nil || bob.Balance != 30 || bob.TransactionCount != 1 {
+		t.Errorf("Unexpected summary for bob %+v (%v)", bob, err)
+	}
+
+	block, err := database.GetBlock("hash_2")
+	if err != nil || !block.Body.Pruned || block.Body.Transactions != nil || block.Body.TxCount != 1 {
+		t.Errorf("Expected block 2 pruned to its header and counts, got %+v (%v)", block, err)
+	}
+	block, err = database.GetBlock("hash_6")
+	if err != nil || block.Body.Pruned || len(block.Body.Transactions) != 1 {
+		t.Errorf("Block 6 should keep its transactions, got %+v (%v)", block, err)
+	}
+
+	again, err := database.PruneBefore(6, now)
+	if err != nil || again.Blocks != 0 || again.PrunedBelow != 6 {
+		t.Errorf("A second pass should find nothing to prune, got %+v (%v)", again, err)
+	}
+}
+
+func TestPruneStopsAtToday(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestHistory(t, database)
+	now := time.Unix(7, 0).Add(time.Hour)
+
+	// Blocks 1-10 all fall on the first day of 1970
+	summary, err := database.PruneBefore(10, now)
+	if err != nil || summary.Blocks != 0 {
+		t.Errorf("Blocks of the current day should not be pruned, got %+v (%v)", summary, err)
+	}
+}
+
+func TestPrunerKeepsLastBlocks(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestHistory(t, database)
+
+	summary, err := NewPruner(database, 3, time.Hour).Prune(time.Now())
+	if err != nil || summary.PrunedBelow != 8 {
+		t.Fatalf("Expected blocks 8-10 kept, got %+v (%v)", summary, err)
+	}
+	if height, _ := database.GetPruneHeight(); height != 8 {
+		t.Errorf("Expected prune height 8, got %d", height)
+	}
+}
+
+func TestPrunerFromEnv(t *testing.T) {
+	t.Setenv("EXPLORER_PRUNE_KEEP_BLOCKS", "")
+	if newPrunerFromEnv(newTestDatabase(t)) != nil {
+		t.Error("Pruning should be off by default")
+	}
+
+	t.Setenv("EXPLORER_PRUNE_KEEP_BLOCKS", "10")
+	t.Setenv("EXPLORER_FINALITY_DEPTH", "200")
+	pruner := newPrunerFromEnv(newTestDatabase(t))
+	if pruner == nil || pruner.keep != 200 || pruner.interval != defaultPruneInterval {
+		t.Errorf("Expected the finality depth kept every hour, got %+v", pruner)
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+const (
+	apiKeyPrefix = "apikey:"
+
+	defaultRateLimit = 120 // Anonymous requests per minute per IP
+	defaultRateBurst = 40
+
+	defaultAPIKeyRate  = 1200
+	defaultAPIKeyBurst = 200
+
+	// Looked-up keys are remembered this long, so deletions take effect
+	// within a minute on other instances sharing the database
+	apiKeyCacheTTL = time.Minute
+
+	// Buckets idle this long are full again and can be forgotten
+	rateBucketIdle = 10 * time.Minute
+)
+
+// rateLimitFromEnv reads EXPLORER_RATE_LIMIT (anonymous requests per minute
+// per IP, 0 disables limiting) and EXPLORER_RATE_BURST
+func rateLimitFromEnv() (int, int) {
+	limit, burst := defaultRateLimit, defaultRateBurst
+	if value := os.Getenv("EXPLORER_RATE_LIMIT"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			slog.Warn("Ignoring invalid EXPLORER_RATE_LIMIT", "value", value, "default", defaultRateLimit)
+		} else {
+			limit = n
+		}
+	}
+	if value := os.Getenv("EXPLORER_RATE_BURST"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			slog.Warn("Ignoring invalid EXPLORER_RATE_BURST", "value", value, "default", defaultRateBurst)
+		} else {
+			burst = n
+		}
+	}
+	return limit, burst
+}
+
+// newRateLimiterFromEnv creates the limiter configured by the environment,
+// or nil when limiting is disabled
+func newRateLimiterFromEnv(database Store) *RateLimiter {
+	limit, burst := rateLimitFromEnv()
+	if limit == 0 {
+		return nil
+	}
+	return NewRateLimiter(database, limit, burst)
+}
+
+// hashAPIKey is how a key's secret is stored and looked up
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type cachedAPIKey struct {
+	key     *APIKey // nil for a secret that matches no key
+	expires time.Time
+}
+
+// RateLimiter keeps a token bucket per client: per API key for requests
+// carrying one, otherwise per IP
+type RateLimiter struct {
+	database      Store
+	ratePerMinute int
+	burst         int
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	keys      map[string]cachedAPIKey
+	lastSweep time.Time
+}
+
+// NewRateLimiter limits anonymous clients to ratePerMinute with bursts of
+// up to burst requests
+func NewRateLimiter(database Store, ratePerMinute, burst int) *RateLimiter {
+	return &RateLimiter{
+		database:      database,
+		ratePerMinute: ratePerMinute,
+		burst:         burst,
+		buckets:       make(map[string]*tokenBucket),
+		keys:          make(map[string]cachedAPIKey),
+	}
+}
+
+// take spends a token from client's bucket, returning whether the request
+// may proceed, the whole tokens left and how long until the next one
+func (l *RateLimiter) take(client string, ratePerMinute, burst int, now time.Time) (bool, int, time.Duration) {
+	perSecond := float64(ratePerMinute) / 60
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > rateBucketIdle {
+		for id, bucket := range l.buckets {
+			if now.Sub(bucket.last) > rateBucketIdle {
+				delete(l.buckets, id)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[client] = bucket
+	}
+	bucket.tokens = math.Min(float64(burst), bucket.tokens+now.Sub(bucket.last).Seconds()*perSecond)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+		return false, 0, wait
+	}
+	bucket.tokens--
+	return true, int(bucket.tokens), 0
+}
+
+// lookupKey resolves an API key secret, caching hits and misses
+func (l *RateLimiter) lookupKey(secret string, now time.Time) (*APIKey, error) {
+	hash := hashAPIKey(secret)
+
+	l.mu.Lock()
+	cached, ok := l.keys[hash]
+	l.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	key, err := l.database.GetAPIKey(hash)
+	if err == errNotFound {
+		key, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.keys[hash] = cachedAPIKey{key: key, expires: now.Add(apiKeyCacheTTL)}
+	l.mu.Unlock()
+	return key, nil
+}
+
+// forget drops cached keys so a deletion takes effect immediately
+func (l *RateLimiter) forget() {
+	l.mu.Lock()
+	l.keys = make(map[string]cachedAPIKey)
+	l.mu.Unlock()
+}
+
+// apiKeyFromRequest reads a key from the X-API-Key header or api_key parameter
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// rateLimit throttles API requests per client. Admin requests are exempt,
+// and an unknown API key is rejected rather than treated as anonymous.
+func (es *ExplorerServer) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := es.rateLimiter
+		if limiter == nil || es.isAdminRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		client, rate, burst := "", limiter.ratePerMinute, limiter.burst
+		if secret := apiKeyFromRequest(r); secret != "" {
+			key, err := limiter.lookupKey(secret, now)
+			if err != nil {
+				http.Error(w, "Failed to check API key", http.StatusInternalServerError)
+				return
+			}
+			if key == nil {
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+			client, rate, burst = "key:"+key.ID, key.RatePerMinute, key.Burst
+		} else {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			client = "ip:" + host
+		}
+
+		allowed, remaining, wait := limiter.take(client, rate, burst, now)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rate))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Rate limit exceeded; retry later or use an API key", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// StoreAPIKey saves a key under the hash of its secret
+func (d *Database) StoreAPIKey(key *APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key: %w", err)
+	}
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(apiKeyPrefix+key.KeyHash), data)
+	})
+}
+
+// GetAPIKey finds the key whose secret hashes to hash
+func (d *Database) GetAPIKey(hash string) (*APIKey, error) {
+	var key APIKey
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(apiKeyPrefix + hash))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &key)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns every API key, oldest first
+func (d *Database) ListAPIKeys() ([]APIKey, error) {
+	keys := []APIKey{}
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(apiKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var key APIKey
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &key)
+			}); err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, err
+}
+
+// DeleteAPIKey revokes the key with the given ID
+func (d *Database) DeleteAPIKey(id string) error {
+	return d.update(func(txn *badger.Txn) error {
+		keys, err := collectKeys(txn, apiKeyPrefix, func(key string, val []byte) bool {
+			return strings.HasPrefix(strings.TrimPrefix(key, apiKeyPrefix), id)
+		})
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return errNotFound
+		}
+		return deleteKeys(txn, keys)
+	})
+}
+
+// API key admin endpoint: create a key. The secret is only ever returned here.
+func (es *ExplorerServer) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name          string `json:"name"`
+		RatePerMinute int    `json:"rate_per_minute"`
+		Burst         int    `json:"burst"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Expected JSON with a name and optional rate_per_minute and burst", http.StatusBadRequest)
+		return
+	}
+	if req.RatePerMinute < 0 || req.Burst < 0 {
+		http.Error(w, "rate_per_minute and burst must not be negative", http.StatusBadRequest)
+		return
+	}
+	if req.RatePerMinute == 0 {
+		req.RatePerMinute = defaultAPIKeyRate
+	}
+	if req.Burst == 0 {
+		req.Burst = defaultAPIKeyBurst
+	}
+
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		http.Error(w, "Failed to generate key", http.StatusInternalServerError)
+		return
+	}
+	secret := "sxk_" + hex.EncodeToString(random)
+	key := &APIKey{
+		Name:          strings.TrimSpace(req.Name),
+		KeyHash:       hashAPIKey(secret),
+		RatePerMinute: req.RatePerMinute,
+		Burst:         req.Burst,
+		CreatedAt:     time.Now().UTC(),
+	}
+	key.ID = key.KeyHash[:12]
+	if err := es.database.StoreAPIKey(key); err != nil {
+		http.Error(w, "Failed to store key", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Created API key", "key_id", key.ID, "name", key.Name, "rate_per_minute", key.RatePerMinute)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		*APIKey
+		Key string `json:"key"`
+	}{key, secret})
+}
+
+// API key admin endpoint: list keys
+func (es *ExplorerServer) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := es.database.ListAPIKeys()
+	if err != nil {
+		http.Error(w, "Failed to list keys", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// API key admin endpoint: revoke a key
+func (es *ExplorerServer) handleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) < 8 {
+		http.Error(w, "Key ID too short", http.StatusBadRequest)
+		return
+	}
+
+	err := es.database.DeleteAPIKey(id)
+	if err == errNotFound {
+		http.Error(w, "Key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete key", http.StatusInternalServerError)
+		return
+	}
+	if es.rateLimiter != nil {
+		es.rateLimiter.forget()
+	}
+	slog.Info("Revoked API key", "key_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
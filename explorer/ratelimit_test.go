@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTokenBucketRefills(t *testing.T) {
+	limiter := NewRateLimiter(nil, 60, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := limiter.take("ip:a", 60, 2, now); !ok {
+			t.Fatalf("Request %d within the burst was refused", i+1)
+		}
+	}
+	ok, _, wait := limiter.take("ip:a", 60, 2, now)
+	if ok || wait != time.Second {
+		t.Fatalf("Expected a refusal with a 1s wait, got %v %v", ok, wait)
+	}
+	if ok, _, _ := limiter.take("ip:b", 60, 2, now); !ok {
+		t.Error("Another client must have its own bucket")
+	}
+	if ok, _, _ := limiter.take("ip:a", 60, 2, now.Add(time.Second)); !ok {
+		t.Error("Expected a token after one second at 60/min")
+	}
+}
+
+func TestRateLimitMiddlewareAndAPIKeys(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database, adminToken: "secret", rateLimiter: NewRateLimiter(database, 60, 1)}
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(es.rateLimit)
+	api.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {})
+	api.HandleFunc("/admin/api-keys", es.requireAdmin(es.handleCreateAPIKey)).Methods("POST")
+	api.HandleFunc("/admin/api-keys/{id}", es.requireAdmin(es.handleDeleteAPIKey)).Methods("DELETE")
+
+	do := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("GET", "/api/v1/stats", "", nil); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "60" {
+		t.Fatalf("Expected the first request through, got %d", rec.Code)
+	}
+	rec := do("GET", "/api/v1/stats", "", nil)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("Expected 429 with Retry-After, got %d %v", rec.Code, rec.Header())
+	}
+
+	// Admin requests skip the limit, and can issue keys with their own quota
+	admin := map[string]string{"Authorization": "Bearer secret"}
+	rec = do("POST", "/api/v1/admin/api-keys", `{"name": "indexer", "rate_per_minute": 600, "burst": 5}`, admin)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	json.NewDecoder(rec.Body).Decode(&created)
+
+	withKey := map[string]string{"X-API-Key": created.Key}
+	for i := 0; i < 5; i++ {
+		if rec := do("GET", "/api/v1/stats", "", withKey); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "600" {
+			t.Fatalf("Keyed request %d refused: %d", i+1, rec.Code)
+		}
+	}
+	if rec := do("GET", "/api/v1/stats", "", map[string]string{"X-API-Key": "sxk_bogus"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown key, got %d", rec.Code)
+	}
+
+	// Keys survive a resync reset but not revocation
+	if err := database.ResetDatabase(); err != nil {
+		t.Fatalf("ResetDatabase failed: %v", err)
+	}
+	if keys, _ := database.ListAPIKeys(); len(keys) != 1 || keys[0].Name != "indexer" {
+		t.Fatalf("Expected the key to survive a reset, got %+v", keys)
+	}
+	if rec := do("DELETE", "/api/v1/admin/api-keys/"+created.ID, "", admin); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	if rec := do("GET", "/api/v1/stats", "", withKey); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a revoked key to be rejected, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// indexScope selects the index families derived from a block's
+// transactions: wallets (wallet transactions, balances and farmer
+// records), tokens (token transactions, tokens and their holders) and
+// pools (liquidity pools and their trades). Sync indexes all of them; a
+// reindex re-derives one from the blocks already stored.
+type indexScope int
+
+const (
+	indexWallets indexScope = 1 << iota
+	indexTokens
+	indexPools
+
+	indexAll = indexWallets | indexTokens | indexPools
+)
+
+// Blocks replayed per read while reindexing
+const reindexBatchSize = 100
+
+var (
+	// errReindexRunning is returned when a reindex is requested while
+	// another one has not finished
+	errReindexRunning = errors.New("a reindex is already running")
+
+	// errInvalidReindex wraps the reasons a reindex request is refused
+	errInvalidReindex = errors.New("invalid reindex request")
+)
+
+var indexScopeNames = map[string]indexScope{
+	"wallets": indexWallets,
+	"tokens":  indexTokens,
+	"pools":   indexPools,
+}
+
+// parseIndexScope parses the name of a single index family
+func parseIndexScope(name string) (indexScope, error) {
+	scope, ok := indexScopeNames[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: scope must be one of tokens, pools or wallets", errInvalidReindex)
+	}
+	return scope, nil
+}
+
+// tokenOpScope returns the index family a token operation feeds
+func tokenOpScope(opType TokenOpType) indexScope {
+	switch opType {
+	case POOL_CREATE, POOL_SWAP:
+		return indexPools
+	}
+	return indexTokens
+}
+
+// ReindexStatus reports the progress of the latest reindex
+type ReindexStatus struct {
+	Scope      string           `json:"scope"`
+	FromHeight uint64           `json:"from_height"`
+	ToHeight   uint64           `json:"to_height"` // Local tip when the replay started
+	Height     uint64           `json:"height"`    // Last block replayed
+	Running    bool             `json:"running"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at,omitempty"`
+	Removed    *RollbackSummary `json:"removed,omitempty"` // What was cleared before the replay
+	Error      string           `json:"error,omitempty"`
+	JobID      string           `json:"job_id,omitempty"` // Job running the replay, when the explorer has a job queue
+}
+
+// Reindex starts re-deriving one index family from the blocks already
+// stored, from fromHeight to the local tip, in the background: as a job
+// when the service has a job queue, so it can be cancelled. Sync waits
+// until it finishes. Progress is reported in Status.
+func (s *SyncService) Reindex(scopeName string, fromHeight uint64) (ReindexStatus, error) {
+	scope, err := parseIndexScope(scopeName)
+	if err != nil {
+		return ReindexStatus{}, err
+	}
+	if fromHeight == 0 {
+		fromHeight = 1
+	}
+	tip, err := s.database.GetLatestHeight()
+	if err != nil {
+		return ReindexStatus{}, err
+	}
+	if fromHeight > tip {
+		return ReindexStatus{}, fmt.Errorf("%w: from_height %d is above the local tip %d", errInvalidReindex, fromHeight, tip)
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.reindex != nil && s.reindex.Running {
+		return ReindexStatus{}, errReindexRunning
+	}
+
+	run := func(ctx context.Context, progress func(done, total uint64)) (interface{}, error) {
+		err := s.replayIndex(ctx, scope, fromHeight, progress)
+
+		s.stateMu.Lock()
+		s.reindex.Running = false
+		s.reindex.FinishedAt = time.Now().UTC()
+		if err != nil {
+			s.reindex.Error = err.Error()
+		}
+		status := *s.reindex
+		s.stateMu.Unlock()
+
+		if err != nil {
+			slog.Error("Reindex failed", "scope", scopeName, "from", fromHeight, "err", err)
+			return nil, err
+		}
+		slog.Info("Reindexed", "scope", scopeName, "from", fromHeight)
+		return status, nil
+	}
+
+	status := ReindexStatus{
+		Scope:      scopeName,
+		FromHeight: fromHeight,
+		Running:    true,
+		StartedAt:  time.Now().UTC(),
+	}
+	if s.jobs == nil {
+		s.reindex = &status
+		go run(context.Background(), func(done, total uint64) {})
+		return status, nil
+	}
+
+	params := map[string]string{"scope": scopeName, "from_height": strconv.FormatUint(fromHeight, 10)}
+	job, err := s.jobs.Submit("reindex", params, run)
+	if err == errJobRunning {
+		return ReindexStatus{}, errReindexRunning
+	}
+	if err != nil {
+		return ReindexStatus{}, err
+	}
+	status.JobID = job.ID
+	s.reindex = &status
+	return status, nil
+}
+
+// replayIndex clears what scope derived from blocks at fromHeight and
+// above, then rebuilds it from the stored blocks in order. Cancelling ctx
+// stops it between batches, leaving the blocks above the last one
+// replayed unindexed until the next reindex.
+func (s *SyncService) replayIndex(ctx context.Context, scope indexScope, fromHeight uint64, progress func(done, total uint64)) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tip, err := s.database.GetLatestHeight()
+	if err != nil {
+		return err
+	}
+	removed, err := s.database.RollbackIndex(scope, fromHeight-1)
+	if err != nil {
+		return fmt.Errorf("failed to clear index: %w", err)
+	}
+	s.stateMu.Lock()
+	s.reindex.ToHeight = tip
+	s.reindex.Removed = removed
+	s.stateMu.Unlock()
+	slog.Info("Reindexing blocks", "from", fromHeight, "to", tip, "cleared_transactions", removed.Transactions,
+		"cleared_tokens", removed.TokensRemoved, "cleared_pools", removed.PoolsRemoved)
+
+	for from := fromHeight; from <= tip; from += reindexBatchSize {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped before block %d: %w", from, err)
+		}
+		to := from + reindexBatchSize - 1
+		if to > tip || to < from {
+			to = tip
+		}
+		blocks, err := s.database.GetBlockRange(from, to)
+		if err != nil {
+			return fmt.Errorf("failed to read blocks %d to %d: %w", from, to, err)
+		}
+		for i := range blocks {
+			if _, err := s.extractAndStoreTransactions(blocks[i].Hash, &blocks[i].Block, scope); err != nil {
+				return fmt.Errorf("failed to reindex block %d: %w", blocks[i].Block.Header.Height, err)
+			}
+		}
+
+		s.stateMu.Lock()
+		s.reindex.Height = to
+		s.stateMu.Unlock()
+		progress(to-fromHeight+1, tip-fromHeight+1)
+		if to == tip {
+			break
+		}
+	}
+	return nil
+}
+
+// RollbackIndex removes what the index families in scope derived from
+// blocks above height, leaving the blocks themselves, so they can be
+// replayed. Tokens and pools that survive are rebuilt from what is left.
+func (d *Database) RollbackIndex(scope indexScope, height uint64) (*RollbackSummary, error) {
+	summary := &RollbackSummary{ForkHeight: height}
+
+	// One block per transaction, as in RollbackToHeight
+	tip, err := d.rollbackTip()
+	if err != nil {
+		return nil, err
+	}
+	for h := tip; h > height; h-- {
+		if err := d.update(func(txn *badger.Txn) error {
+			return rollbackHeightIndex(txn, h, scope, summary)
+		}); err != nil {
+			return nil, fmt.Errorf("failed to roll back the index of block %d: %w", h, err)
+		}
+	}
+
+	if scope&indexWallets != 0 {
+		// Cached days from the first block replayed on
+		err := d.update(func(txn *badger.Txn) error {
+			block, err := readBlockAtHeight(txn, height+1)
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return removeDailyStatsFrom(txn, block.Block.Header.Timestamp.UTC().Format(dailyDateFormat))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := d.rebuildAffected(summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// Reindex endpoint: re-derive one index family from the stored blocks
+func (es *ExplorerServer) handleReindex(w http.ResponseWriter, r *http.Request) {
+	if es.syncService == nil {
+		http.Error(w, "Sync service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var fromHeight uint64
+	if value := r.URL.Query().Get("from_height"); value != "" {
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			http.Error(w, "from_height must be a block height", http.StatusBadRequest)
+			return
+		}
+		fromHeight = n
+	}
+
+	status, err := es.syncService.Reindex(r.URL.Query().Get("scope"), fromHeight)
+	if err == errReindexRunning {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, errInvalidReindex) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to start reindex", "err", err)
+		http.Error(w, "Failed to start reindex", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Reindexing", "scope", status.Scope, "from", status.FromHeight)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
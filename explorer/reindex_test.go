@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// storeTokenBlocks stores and indexes blocks 1 to 3: a token minted to
+// alice, then moved on to bob and carol, each with a SHADOW payment
+func storeTokenBlocks(t *testing.T, svc *SyncService) {
+	const tokenID = "0a0a0a0a0a0a0a0a"
+	ops := []TokenOperation{
+		{Type: TOKEN_CREATE, TokenID: tokenID, Amount: 100, To: "alice", Metadata: &TokenMetadata{Name: "Alpha", Ticker: "ALP"}},
+		{Type: TOKEN_TRANSFER, TokenID: tokenID, Amount: 40, From: "alice", To: "bob"},
+		{Type: TOKEN_TRANSFER, TokenID: tokenID, Amount: 10, From: "bob", To: "carol"},
+	}
+	start := time.Unix(1700000000, 0).UTC()
+	for i, op := range ops {
+		height := uint64(i + 1)
+		timestamp := start.Add(time.Duration(height) * time.Minute)
+		tx, _ := json.Marshal(Transaction{
+			Version:   1,
+			Outputs:   []TransactionOutput{{Value: 5, Address: "dave"}},
+			TokenOps:  []TokenOperation{op},
+			Timestamp: timestamp,
+		})
+		block := &Block{
+			Header: BlockHeader{Height: height, Timestamp: timestamp},
+			Body:   BlockBody{Transactions: []SignedTransaction{{Transaction: tx, TxHash: fmt.Sprintf("tx%d", height)}}},
+		}
+		hash := fmt.Sprintf("hash_%d", height)
+		if err := svc.database.StoreBlock(hash, block); err != nil {
+			t.Fatalf("Failed to store block %d: %v", height, err)
+		}
+		if _, err := svc.extractAndStoreTransactions(hash, block, indexAll); err != nil {
+			t.Fatalf("Failed to index block %d: %v", height, err)
+		}
+	}
+}
+
+// waitForReindex waits until the reindex started on svc finishes
+func waitForReindex(t *testing.T, svc *SyncService) ReindexStatus {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := svc.Status().Reindex; status != nil && !status.Running {
+			return *status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for the reindex")
+	return ReindexStatus{}
+}
+
+func TestReindexRebuildsOneScope(t *testing.T) {
+	database := newTestDatabase(t)
+	svc := NewSyncService("", newTestNodeClient(), database)
+	storeTokenBlocks(t, svc)
+	before, _ := database.GetWalletTransactions("dave", 100)
+
+	// A buggy indexer left bob with the wrong balance
+	if err := database.UpdateTokenHolder("0a0a0a0a0a0a0a0a", "bob", 999); err != nil {
+		t.Fatalf("UpdateTokenHolder failed: %v", err)
+	}
+
+	if _, err := svc.Reindex("tokens", 2); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	status := waitForReindex(t, svc)
+	if status.Error != "" || status.Height != 3 || status.ToHeight != 3 || status.Removed == nil || status.Removed.TokensRebuilt != 1 {
+		t.Errorf("Unexpected reindex status %+v", status)
+	}
+
+	for address, want := range map[string]uint64{"alice": 60, "bob": 30, "carol": 10} {
+		if balance, _ := database.GetTokenBalance("0a0a0a0a0a0a0a0a", address); balance != want {
+			t.Errorf("Expected %s to hold %d after the reindex, got %d", address, want, balance)
+		}
+	}
+	if token, err := database.GetToken("0a0a0a0a0a0a0a0a"); err != nil || token.TransferCount != 2 || token.HolderCount != 3 {
+		t.Errorf("Expected each transfer counted once, got %+v (%v)", token, err)
+	}
+	// Other index families are left alone
+	if after, _ := database.GetWalletTransactions("dave", 100); len(after) != len(before) {
+		t.Errorf("Expected %d wallet transactions untouched, got %d", len(before), len(after))
+	}
+}
+
+func TestReindexRequests(t *testing.T) {
+	database := newTestDatabase(t)
+	svc := NewSyncService("", newTestNodeClient(), database)
+	storeTokenBlocks(t, svc)
+	es := &ExplorerServer{database: database, syncService: svc}
+
+	for query, want := range map[string]int{
+		"scope=blocks":                  http.StatusBadRequest,
+		"scope=wallets&from_height=abc": http.StatusBadRequest,
+		"scope=wallets&from_height=4":   http.StatusBadRequest,
+		"scope=wallets":                 http.StatusAccepted,
+	} {
+		rec := httptest.NewRecorder()
+		es.handleReindex(rec, httptest.NewRequest("POST", "/api/v1/admin/reindex?"+query, nil))
+		if rec.Code != want {
+			t.Errorf("Expected %d for %q, got %d: %s", want, query, rec.Code, rec.Body)
+		}
+	}
+
+	if status := waitForReindex(t, svc); status.Error != "" || status.FromHeight != 1 || status.Removed.Transactions == 0 {
+		t.Errorf("Unexpected reindex status %+v", status)
+	}
+	if txs, _ := database.GetWalletTransactions("dave", 100); len(txs) != 3 {
+		t.Errorf("Expected the wallet transactions replayed, got %d", len(txs))
+	}
+
+	// Only one reindex runs at a time
+	svc.syncMu.Lock()
+	if _, err := svc.Reindex("pools", 1); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	if _, err := svc.Reindex("pools", 1); err != errReindexRunning {
+		t.Errorf("Expected a second reindex refused, got %v", err)
+	}
+	svc.syncMu.Unlock()
+	waitForReindex(t, svc)
+}
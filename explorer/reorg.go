@@ -0,0 +1,712 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// maxReorgDepth is how far back the sync service looks for a common
+// ancestor before giving up and asking for a full resync
+const maxReorgDepth = 100
+
+// errReorgDetected is returned while syncing when a fetched block does not
+// build on the block stored below it
+var errReorgDetected = errors.New("chain reorganization detected")
+
+// Everything a block added to the wallet, token and pool indexes is listed
+// under its height (at_height:<height>:<key>), so a rollback visits only
+// the blocks it removes. A rollback under way is recorded under
+// rollbackTargetKey, and the tokens and pools it still has to rebuild under
+// rollbackRebuildPrefix, so one that is interrupted can be finished.
+const (
+	atHeightPrefix        = "at_height:"
+	rollbackTargetKey     = "rollback_target"
+	rollbackRebuildPrefix = "rollback_rebuild:"
+)
+
+func atHeightKey(height uint64, key string) []byte {
+	return []byte(fmt.Sprintf("%s%016d:%s", atHeightPrefix, height, key))
+}
+
+// recordAtHeight lists key among what the block at height added
+func recordAtHeight(txn *badger.Txn, height uint64, key string) error {
+	return txn.Set(atHeightKey(height, key), nil)
+}
+
+// nodeHashPrefix maps a height to the node's own hash for the block stored
+// there (node_hash:<height> -> hash). Our block keys use a hash derived from
+// the header, so the node's hash is kept separately to compare parents.
+const nodeHashPrefix = "node_hash:"
+
+func nodeHashKey(height uint64) []byte {
+	return []byte(fmt.Sprintf("%s%016d", nodeHashPrefix, height))
+}
+
+// SetNodeHash records the node's hash for the block at height
+func (d *Database) SetNodeHash(height uint64, hash string) error {
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set(nodeHashKey(height), []byte(hash))
+	})
+}
+
+// GetNodeHash returns the node's hash for the block at height, or "" when
+// none was recorded (blocks indexed before reorg tracking)
+func (d *Database) GetNodeHash(height uint64) (string, error) {
+	var hash string
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(nodeHashKey(height))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		hash = string(value)
+		return err
+	})
+	return hash, err
+}
+
+// RollbackSummary describes what RollbackToHeight removed
+type RollbackSummary struct {
+	ForkHeight    uint64 `json:"fork_height"`
+	Blocks        int    `json:"blocks"`
+	Transactions  int    `json:"transactions"`
+	TokensRemoved int    `json:"tokens_removed"`
+	PoolsRemoved  int    `json:"pools_removed"`
+	TokensRebuilt int    `json:"tokens_rebuilt"`
+}
+
+// heightFromKey parses the zero-padded height that follows prefix in key
+func heightFromKey(key, prefix string) (uint64, bool) {
+	rest := strings.TrimPrefix(key, prefix)
+	if i := strings.IndexByte(rest, ':'); i >= 0 {
+		rest = rest[:i]
+	}
+	height, err := strconv.ParseUint(rest, 10, 64)
+	return height, err == nil
+}
+
+// collectKeys returns copies of every key under prefix for which keep is true
+func collectKeys(txn *badger.Txn, prefix string, keep func(key string, val []byte) bool) ([][]byte, error) {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	var keys [][]byte
+	for it.Seek([]byte(prefix)); it.ValidForPrefix([]byte(prefix)); it.Next() {
+		item := it.Item()
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		if keep(string(item.Key()), val) {
+			keys = append(keys, item.KeyCopy(nil))
+		}
+	}
+	return keys, nil
+}
+
+func deleteKeys(txn *badger.Txn, keys [][]byte) error {
+	for _, key := range keys {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RollbackToHeight removes every block above height together with the
+// transactions, tokens, pools and token holder balances they produced, so
+// sync can re-index the winning branch from height+1. The blocks themselves
+// are kept as orphans. Derived indexes that cannot be undone piecewise are
+// invalidated: the first-seen index is rebuilt by its migration once the
+// rollback finishes, cached daily stats on next use.
+//
+// Blocks are removed one per transaction from the tip down, visiting only
+// what the height index lists for them, so a deep rollback is not bounded
+// by Badger's transaction size. The target is recorded first: a rollback
+// that is interrupted is finished when the database is next opened.
+func (d *Database) RollbackToHeight(height uint64) (*RollbackSummary, error) {
+	defer d.invalidateTip()
+	summary := &RollbackSummary{ForkHeight: height}
+
+	if err := d.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(rollbackTargetKey), []byte(strconv.FormatUint(height, 10)))
+	}); err != nil {
+		return nil, err
+	}
+
+	tip, err := d.rollbackTip()
+	if err != nil {
+		return nil, err
+	}
+	for h := tip; h > height; h-- {
+		err := d.update(func(txn *badger.Txn) error {
+			if err := rollbackHeightIndex(txn, h, indexAll, summary); err != nil {
+				return err
+			}
+			if err := rollbackBlock(txn, h, summary); err != nil {
+				return err
+			}
+			heightBytes := make([]byte, 8)
+			binary.BigEndian.PutUint64(heightBytes, h-1)
+			return txn.Set([]byte("latest_height"), heightBytes)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to roll back block %d: %w", h, err)
+		}
+		d.invalidateTip()
+	}
+
+	if err := d.rebuildAffected(summary); err != nil {
+		return nil, err
+	}
+	if err := d.update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(rollbackTargetKey))
+	}); err != nil {
+		return nil, err
+	}
+	if err := d.migrate(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// rollbackTip is the highest height anything is stored at: the latest
+// block, or an indexed record or chart sample above it
+func (d *Database) rollbackTip() (uint64, error) {
+	tip, err := d.GetLatestHeight()
+	if err != nil {
+		return 0, err
+	}
+	err = d.view(func(txn *badger.Txn) error {
+		for _, prefix := range []string{"height:", atHeightPrefix, chartSamplePrefix} {
+			if h, ok := lastHeight(txn, prefix); ok && h > tip {
+				tip = h
+			}
+		}
+		return nil
+	})
+	return tip, err
+}
+
+// lastHeight returns the height of the last key under prefix
+func lastHeight(txn *badger.Txn, prefix string) (uint64, bool) {
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = true
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	// Reverse iteration starts at the last key not greater than the seek key
+	it.Seek(append([]byte(prefix), 0xff))
+	if !it.ValidForPrefix([]byte(prefix)) {
+		return 0, false
+	}
+	return heightFromKey(string(it.Item().Key()), prefix)
+}
+
+// resumeRollback finishes a rollback that was interrupted
+func (d *Database) resumeRollback() error {
+	var target []byte
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(rollbackTargetKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil || target == nil {
+		return err
+	}
+
+	height, err := strconv.ParseUint(string(target), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid rollback target %q", target)
+	}
+	slog.Info("Resuming interrupted rollback", "height", height)
+	summary, err := d.RollbackToHeight(height)
+	if err != nil {
+		return fmt.Errorf("failed to resume rollback to %d: %w", height, err)
+	}
+	slog.Info("Finished interrupted rollback", "blocks", summary.Blocks, "height", height)
+	return nil
+}
+
+// rollbackBlock orphans the block at height and drops its height mapping,
+// node hash, verification alert and chart sample, with the cached daily
+// stats of its day and every day after it
+func rollbackBlock(txn *badger.Txn, height uint64, summary *RollbackSummary) error {
+	if err := removeChartSample(txn, height); err != nil {
+		return err
+	}
+	heightKey := []byte(fmt.Sprintf("height:%016d", height))
+	item, err := txn.Get(heightKey)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	blockHash, err := item.ValueCopy(nil)
+	if err != nil {
+		return err
+	}
+
+	if info, err := readBlockInfo(txn, string(blockHash)); err == nil {
+		if err := removeDailyStatsFrom(txn, info.Timestamp.UTC().Format(dailyDateFormat)); err != nil {
+			return err
+		}
+	}
+	if err := orphanBlock(txn, string(blockHash), height, orphanReorg); err != nil {
+		return err
+	}
+	summary.Blocks++
+	return deleteKeys(txn, [][]byte{heightKey, []byte("block:" + string(blockHash)), nodeHashKey(height), blockAlertKey(height)})
+}
+
+// rollbackHeightIndex removes what the block at height added to the index
+// families in scope, as listed in the height index. Tokens and pools it
+// touched are queued for rebuildAffected.
+func rollbackHeightIndex(txn *badger.Txn, height uint64, scope indexScope, summary *RollbackSummary) error {
+	prefix := fmt.Sprintf("%s%016d:", atHeightPrefix, height)
+	entries, err := collectKeys(txn, prefix, func(string, []byte) bool { return true })
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		key := strings.TrimPrefix(string(entry), prefix)
+		var family indexScope
+		var remove func(val []byte) error
+		switch {
+		case strings.HasPrefix(key, "tx:"):
+			family = indexWallets
+			remove = func(val []byte) error { return removeWalletTransaction(txn, key, val, height, summary) }
+		case strings.HasPrefix(key, "token_tx:"):
+			family = indexTokens
+			remove = func(val []byte) error {
+				var tx TokenTransaction
+				if json.Unmarshal(val, &tx) != nil || tx.BlockHeight != height {
+					return nil
+				}
+				// token_tx:<token>:<timestamp>:<hash>
+				return removeAndRebuild(txn, key, "token", strings.Split(key, ":")[1])
+			}
+		case strings.HasPrefix(key, meltPrefix):
+			family = indexTokens
+			remove = func(val []byte) error {
+				var melt MeltEvent
+				if json.Unmarshal(val, &melt) != nil || melt.BlockHeight != height {
+					return nil
+				}
+				return removeAndRebuild(txn, key, "token", melt.TokenID)
+			}
+		case strings.HasPrefix(key, "token:"):
+			family = indexTokens
+			remove = func(val []byte) error {
+				var token TokenInfo
+				if json.Unmarshal(val, &token) != nil || token.CreationBlock != height {
+					return nil
+				}
+				summary.TokensRemoved++
+				if err := removeToken(txn, &token); err != nil {
+					return err
+				}
+				return txn.Set([]byte(rollbackRebuildPrefix+"token:"+token.TokenID), nil)
+			}
+		case strings.HasPrefix(key, "pool_tx:"):
+			family = indexPools
+			remove = func(val []byte) error {
+				var tx PoolTransaction
+				if json.Unmarshal(val, &tx) != nil || tx.BlockHeight != height {
+					return nil
+				}
+				// pool_tx:<pool>:<timestamp>:<hash>
+				return removeAndRebuild(txn, key, "pool", strings.Split(key, ":")[1])
+			}
+		case strings.HasPrefix(key, "pool:"):
+			family = indexPools
+			remove = func(val []byte) error {
+				var pool LiquidityPool
+				if json.Unmarshal(val, &pool) != nil || pool.CreationBlock != height {
+					return nil
+				}
+				summary.PoolsRemoved++
+				return removePool(txn, &pool)
+			}
+		}
+		if family == 0 || scope&family == 0 {
+			continue
+		}
+
+		// Entries outlive records that were pruned or re-indexed elsewhere
+		item, err := txn.Get([]byte(key))
+		if err == nil {
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if err := remove(val); err != nil {
+				return err
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err := txn.Delete(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeWalletTransaction removes a wallet transaction of the block at
+// height with its effect on balances and its address and farmer indexes,
+// invalidating the first-seen index
+func removeWalletTransaction(txn *badger.Txn, key string, val []byte, height uint64, summary *RollbackSummary) error {
+	var tx WalletTransaction
+	if json.Unmarshal(val, &tx) != nil || tx.BlockHeight != height {
+		return nil
+	}
+	if err := applyBalanceDeltas(txn, &tx, -1); err != nil {
+		return err
+	}
+
+	keys := [][]byte{[]byte(key), []byte(firstSeenIndexMarker)}
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		if address != "" {
+			keys = append(keys, []byte(fmt.Sprintf("addr_tx:%s:%016d:%s", address, tx.BlockHeight, tx.TxHash)))
+		}
+	}
+	if tx.Type == "mining_reward" && tx.ToAddress != "" {
+		keys = append(keys, []byte(fmt.Sprintf("farmer:%s:%016d", tx.ToAddress, tx.BlockHeight)), minedBlockKey(tx.BlockHeight))
+	}
+	summary.Transactions++
+	return deleteKeys(txn, keys)
+}
+
+// removeAndRebuild deletes key and queues the token or pool it belonged to
+// for rebuildAffected
+func removeAndRebuild(txn *badger.Txn, key, kind, id string) error {
+	if err := txn.Delete([]byte(key)); err != nil {
+		return err
+	}
+	return txn.Set([]byte(rollbackRebuildPrefix+kind+":"+id), nil)
+}
+
+// rebuildAffected replays what is left of every token and pool a rollback
+// touched, one per transaction, taking each off the queue once rebuilt
+func (d *Database) rebuildAffected(summary *RollbackSummary) error {
+	var queued [][]byte
+	if err := d.view(func(txn *badger.Txn) error {
+		var err error
+		queued, err = collectKeys(txn, rollbackRebuildPrefix, func(string, []byte) bool { return true })
+		return err
+	}); err != nil {
+		return err
+	}
+
+	for _, key := range queued {
+		kind, id, _ := strings.Cut(strings.TrimPrefix(string(key), rollbackRebuildPrefix), ":")
+		err := d.update(func(txn *badger.Txn) error {
+			switch kind {
+			case "token":
+				if _, err := txn.Get([]byte("token:" + id)); err == nil {
+					summary.TokensRebuilt++
+				}
+				if err := rebuildTokenState(txn, id); err != nil {
+					return fmt.Errorf("failed to rebuild token %s: %w", id, err)
+				}
+			case "pool":
+				if err := rebuildPoolState(txn, id); err != nil {
+					return fmt.Errorf("failed to rebuild pool %s: %w", id, err)
+				}
+			}
+			return txn.Delete(key)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeDailyStatsFrom deletes the cached daily stats of day and every day
+// after it; an empty day removes nothing
+func removeDailyStatsFrom(txn *badger.Txn, day string) error {
+	if day == "" {
+		return nil
+	}
+	staleDays, err := collectKeys(txn, dailyStatsPrefix, func(key string, val []byte) bool {
+		return strings.TrimPrefix(key, dailyStatsPrefix) >= day
+	})
+	if err != nil {
+		return err
+	}
+	return deleteKeys(txn, staleDays)
+}
+
+// removeToken deletes a token with its search indexes and NFT metadata
+func removeToken(txn *badger.Txn, token *TokenInfo) error {
+	return deleteKeys(txn, [][]byte{
+		[]byte(fmt.Sprintf("token:%s", token.TokenID)),
+		[]byte(fmt.Sprintf("token_ticker:%s:%s", token.Ticker, token.TokenID)),
+		[]byte(fmt.Sprintf("token_name:%s:%s", token.Name, token.TokenID)),
+		[]byte(fmt.Sprintf("token_time:%016d:%s", token.CreationTime.Unix(), token.TokenID)),
+		[]byte(nftMetaPrefix + token.TokenID),
+		[]byte(nftImagePrefix + token.TokenID),
+	})
+}
+
+// removePool deletes a pool with its indexes
+func removePool(txn *badger.Txn, pool *LiquidityPool) error {
+	keys := [][]byte{
+		[]byte(fmt.Sprintf("pool:%s", pool.PoolID)),
+		[]byte(fmt.Sprintf("pool_pair:%s_%s:%s", pool.TokenA, pool.TokenB, pool.PoolID)),
+		[]byte(fmt.Sprintf("pool_time:%016d:%s", pool.CreationTime.Unix(), pool.PoolID)),
+		[]byte(fmt.Sprintf("pool_tvl:%016d:%s", pool.TVL, pool.PoolID)),
+		[]byte(fmt.Sprintf("pool_addr:%s", pool.LAddress)),
+	}
+	for _, token := range poolTokenKeys(pool) {
+		keys = append(keys, poolTokenKey(token, pool.PoolID))
+	}
+	return deleteKeys(txn, keys)
+}
+
+// rebuildTokenState recomputes a token's holder balances, per-address
+// activity and statistics from the token transactions still stored
+func rebuildTokenState(txn *badger.Txn, tokenID string) error {
+	var holderKeys [][]byte
+	for _, prefix := range []string{tokenHolderPrefix + tokenID + ":", tokenRankPrefix + tokenID + ":"} {
+		keys, err := collectKeys(txn, prefix, func(string, []byte) bool { return true })
+		if err != nil {
+			return err
+		}
+		holderKeys = append(holderKeys, keys...)
+	}
+	holderKeys = append(holderKeys, []byte(tokenHolderCountPrefix+tokenID))
+	if err := deleteKeys(txn, holderKeys); err != nil {
+		return err
+	}
+
+	activityKeys, err := collectKeys(txn, "token_addr:", func(key string, val []byte) bool {
+		return strings.HasSuffix(key, ":"+tokenID)
+	})
+	if err != nil {
+		return err
+	}
+	if err := deleteKeys(txn, activityKeys); err != nil {
+		return err
+	}
+	if err := deleteTokenTrend(txn, tokenID); err != nil {
+		return err
+	}
+
+	// Token transaction keys sort by timestamp, so this replays in order
+	var history []TokenTransaction
+	if _, err := collectKeys(txn, fmt.Sprintf("token_tx:%s:", tokenID), func(key string, val []byte) bool {
+		var tx TokenTransaction
+		if json.Unmarshal(val, &tx) == nil {
+			history = append(history, tx)
+		}
+		return false
+	}); err != nil {
+		return err
+	}
+
+	balances, order, transfers := replayTokenHistory(history)
+	for i := range history {
+		if err := updateTokenActivity(txn, tokenID, &history[i]); err != nil {
+			return err
+		}
+		if err := recordTokenTrend(txn, tokenID, &history[i]); err != nil {
+			return err
+		}
+	}
+
+	for _, address := range order {
+		if err := setTokenHolder(txn, tokenID, address, balances[address]); err != nil {
+			return err
+		}
+	}
+
+	// Refresh statistics on the token record if the token still exists
+	item, err := txn.Get([]byte("token:" + tokenID))
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var token TokenInfo
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &token)
+	}); err != nil {
+		return err
+	}
+	token.HolderCount = len(order)
+	token.TransferCount = transfers
+	if len(history) > 0 {
+		token.LastActivity = history[len(history)-1].Timestamp
+	}
+	token.CirculatingSupply = 0
+	for _, address := range order {
+		token.CirculatingSupply += balances[address]
+	}
+	if token.TotalMelted, err = sumTokenMelts(txn, tokenID); err != nil {
+		return err
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return txn.Set([]byte("token:"+tokenID), data)
+}
+
+// rebuildPoolState recomputes a pool's reserves and statistics from the
+// pool transactions still stored, if the pool still exists
+func rebuildPoolState(txn *badger.Txn, poolID string) error {
+	item, err := txn.Get([]byte("pool:" + poolID))
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var pool LiquidityPool
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &pool)
+	}); err != nil {
+		return err
+	}
+
+	// Pool transaction keys sort by timestamp, so this replays in order
+	var history []PoolTransaction
+	if _, err := collectKeys(txn, fmt.Sprintf("pool_tx:%s:", poolID), func(key string, val []byte) bool {
+		var tx PoolTransaction
+		if json.Unmarshal(val, &tx) == nil {
+			history = append(history, tx)
+		}
+		return false
+	}); err != nil {
+		return err
+	}
+
+	replayPoolHistory(&pool, history)
+	return storePool(txn, &pool)
+}
+
+// replayTokenHistory derives holder balances from a token's transactions in
+// order, returning the balances, holders in order of first appearance and
+// the number of transfers
+func replayTokenHistory(history []TokenTransaction) (map[string]uint64, []string, int) {
+	balances := make(map[string]uint64)
+	var order []string
+	credit := func(address string, amount uint64, add bool) {
+		if address == "" {
+			return
+		}
+		if _, seen := balances[address]; !seen {
+			order = append(order, address)
+		}
+		if add {
+			balances[address] += amount
+		} else if balances[address] >= amount {
+			balances[address] -= amount
+		} else {
+			balances[address] = 0
+		}
+	}
+
+	transfers := 0
+	for _, tx := range history {
+		switch strings.ToUpper(tx.Type) {
+		case "CREATE":
+			credit(tx.ToAddress, tx.Amount, true)
+		case "TRANSFER":
+			credit(tx.FromAddress, tx.Amount, false)
+			credit(tx.ToAddress, tx.Amount, true)
+			transfers++
+		case "MELT":
+			credit(tx.FromAddress, tx.Amount, false)
+		}
+	}
+	return balances, order, transfers
+}
+
+// findForkPoint walks back from from until the node's hash at a height
+// matches the one stored for it, returning that height. Heights indexed
+// before node hashes were recorded are trusted as-is.
+func (s *SyncService) findForkPoint(from uint64) (uint64, error) {
+	for height := from; height > 0; height-- {
+		if from-height >= maxReorgDepth {
+			return 0, fmt.Errorf("no common ancestor within %d blocks of %d; a full resync is required", maxReorgDepth, from)
+		}
+
+		localHash, err := s.database.GetNodeHash(height)
+		if err != nil {
+			return 0, err
+		}
+		if localHash == "" {
+			return height, nil
+		}
+
+		remote, err := s.fetchBlock(height)
+		if err != nil {
+			return 0, err
+		}
+		if remote.Result.BlockID.Hash == localHash {
+			return height, nil
+		}
+		slog.Warn("Block differs from the node", "height", height, "local_hash", localHash, "node_hash", remote.Result.BlockID.Hash)
+	}
+	return 0, nil
+}
+
+// reconcileTip rolls local data back to the last block shared with the
+// node, covering both a replaced tip and a node whose chain is now shorter.
+// It returns the height to continue syncing from.
+func (s *SyncService) reconcileTip(localHeight, remoteHeight uint64) (uint64, error) {
+	if localHeight == 0 {
+		return 0, nil
+	}
+
+	from := localHeight
+	if remoteHeight < from {
+		from = remoteHeight
+	}
+	fork, err := s.findForkPoint(from)
+	if err != nil {
+		return localHeight, err
+	}
+	if fork == localHeight {
+		return localHeight, nil
+	}
+
+	slog.Warn("Reorg, rolling back to the common ancestor", "from", localHeight, "ancestor", fork)
+	summary, err := s.database.RollbackToHeight(fork)
+	if err != nil {
+		return localHeight, fmt.Errorf("rollback to %d failed: %w", fork, err)
+	}
+	slog.Info("Rolled back blocks", "blocks", summary.Blocks, "transactions", summary.Transactions,
+		"tokens_removed", summary.TokensRemoved, "pools_removed", summary.PoolsRemoved, "tokens_rebuilt", summary.TokensRebuilt)
+
+	s.stateMu.Lock()
+	s.currentHeight = fork
+	s.lastReorg = summary
+	s.reorgCount++
+	s.stateMu.Unlock()
+	return fork, nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestRollbackToHeightUndoesAbandonedBranch(t *testing.T) {
+	database := newTestDatabase(t)
+	svc := NewSyncService("", newTestNodeClient(), database)
+	storeTestBlocks(t, database, 1, 6)
+	start := time.Unix(1700000000, 0).UTC()
+
+	for height := uint64(1); height <= 6; height++ {
+		tx := &WalletTransaction{TxHash: fmt.Sprintf("wtx%d", height), BlockHash: fmt.Sprintf("hash_%d", height),
+			BlockHeight: height, Timestamp: start.Add(time.Duration(height) * time.Minute), ToAddress: "alice", Amount: 10}
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store transaction %d: %v", height, err)
+		}
+		if err := database.SetNodeHash(height, fmt.Sprintf("node_%d", height)); err != nil {
+			t.Fatalf("Failed to store node hash: %v", err)
+		}
+	}
+
+	// A token minted before the fork and moved after it, and a token and
+	// pool that only exist on the abandoned branch
+	ops := []struct {
+		height uint64
+		op     *TokenOperation
+	}{
+		{2, &TokenOperation{Type: TOKEN_CREATE, TokenID: "0a0a0a0a0a0a0a0a", Amount: 100, To: "alice",
+			Metadata: &TokenMetadata{Name: "Alpha", Ticker: "ALP"}}},
+		{5, &TokenOperation{Type: TOKEN_TRANSFER, TokenID: "0a0a0a0a0a0a0a0a", Amount: 40, From: "alice", To: "bob"}},
+		{5, &TokenOperation{Type: TOKEN_CREATE, TokenID: "0b0b0b0b0b0b0b0b", Amount: 50, To: "bob",
+			Metadata: &TokenMetadata{Name: "Beta", Ticker: "BET"}}},
+	}
+	for i, o := range ops {
+		block := &Block{Header: BlockHeader{Height: o.height}}
+		if err := svc.processTokenOperation(fmt.Sprintf("hash_%d", o.height), block, fmt.Sprintf("ttx%d", i), o.op,
+			start.Add(time.Duration(o.height)*time.Minute), indexAll); err != nil {
+			t.Fatalf("Failed to process op %d: %v", i, err)
+		}
+	}
+	if err := database.StorePool(&LiquidityPool{PoolID: "p1", TokenA: "0a0a0a0a0a0a0a0a", TokenB: "0b0b0b0b0b0b0b0b", CreationBlock: 5}); err != nil {
+		t.Fatalf("Failed to store pool: %v", err)
+	}
+
+	summary, err := database.RollbackToHeight(3)
+	if err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	if summary.Blocks != 3 || summary.Transactions != 3 || summary.TokensRemoved != 1 || summary.PoolsRemoved != 1 {
+		t.Errorf("Unexpected rollback summary %+v", summary)
+	}
+
+	if height, _ := database.GetLatestHeight(); height != 3 {
+		t.Errorf("Expected latest height 3, got %d", height)
+	}
+	if _, err := database.GetBlock("hash_4"); err == nil {
+		t.Error("Block 4 should have been removed")
+	}
+	if hash, _ := database.GetNodeHash(4); hash != "" {
+		t.Errorf("Node hash for 4 should have been removed, got %q", hash)
+	}
+	if hash, _ := database.GetNodeHash(3); hash != "node_3" {
+		t.Errorf("Node hash for 3 should be kept, got %q", hash)
+	}
+
+	txs, err := database.GetWalletTransactions("alice", 100)
+	if err != nil {
+		t.Fatalf("GetWalletTransactions failed: %v", err)
+	}
+	if len(txs) != 3 {
+		t.Errorf("Expected alice's 3 transactions up to the fork, got %d", len(txs))
+	}
+
+	if _, err := database.GetToken("0b0b0b0b0b0b0b0b"); err == nil {
+		t.Error("Token created after the fork should have been removed")
+	}
+	if _, err := database.GetPool("p1"); err == nil {
+		t.Error("Pool created after the fork should have been removed")
+	}
+
+	// The transfer after the fork is undone
+	alice, err := database.GetTokenActivity("alice")
+	if err != nil {
+		t.Fatalf("GetTokenActivity failed: %v", err)
+	}
+	if len(alice) != 1 || alice[0].Balance != 100 || alice[0].Sent != 0 {
+		t.Errorf("Expected alice back at 100 ALP with no sends, got %+v", alice)
+	}
+	bob, err := database.GetTokenActivity("bob")
+	if err != nil {
+		t.Fatalf("GetTokenActivity failed: %v", err)
+	}
+	if len(bob) != 0 {
+		t.Errorf("Bob only appeared after the fork, got %+v", bob)
+	}
+	token, err := database.GetToken("0a0a0a0a0a0a0a0a")
+	if err != nil {
+		t.Fatalf("GetToken failed: %v", err)
+	}
+	if token.HolderCount != 1 || token.TransferCount != 0 {
+		t.Errorf("Expected 1 holder and no transfers, got %d/%d", token.HolderCount, token.TransferCount)
+	}
+}
+
+func TestRollbackResumesAfterInterruption(t *testing.T) {
+	dir := t.TempDir()
+	database, err := NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	storeTestBlocks(t, database, 1, 6)
+	for height := uint64(1); height <= 6; height++ {
+		tx := &WalletTransaction{TxHash: fmt.Sprintf("wtx%d", height), BlockHash: fmt.Sprintf("hash_%d", height),
+			BlockHeight: height, Timestamp: time.Unix(int64(height), 0), ToAddress: "alice", Amount: 10}
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store transaction %d: %v", height, err)
+		}
+	}
+
+	// A rollback to 3 that stopped after removing block 6
+	err = database.update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte(rollbackTargetKey), []byte("3")); err != nil {
+			return err
+		}
+		summary := &RollbackSummary{}
+		if err := rollbackHeightIndex(txn, 6, indexAll, summary); err != nil {
+			return err
+		}
+		return rollbackBlock(txn, 6, summary)
+	})
+	if err != nil {
+		t.Fatalf("Failed to start rollback: %v", err)
+	}
+	database.Close()
+
+	database, err = NewDatabase(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer database.Close()
+
+	if height, _ := database.GetLatestHeight(); height != 3 {
+		t.Errorf("Expected the rollback to finish at 3, got %d", height)
+	}
+	if _, err := database.GetBlock("hash_4"); err == nil {
+		t.Error("Block 4 should have been removed")
+	}
+	if txs, _ := database.GetWalletTransactions("alice", 100); len(txs) != 3 {
+		t.Errorf("Expected alice's 3 transactions up to the fork, got %d", len(txs))
+	}
+	err = database.view(func(txn *badger.Txn) error {
+		if _, err := txn.Get([]byte(rollbackTargetKey)); err != badger.ErrKeyNotFound {
+			return fmt.Errorf("rollback target still set: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// newForkingNode serves a chain up to tip whose blocks above fork switch to
+// a different branch once reorged is set
+func newForkingNode(t *testing.T, tip, fork uint64, reorged *int32) *httptest.Server {
+	genesis := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	hashAt := func(height uint64) string {
+		if height > fork && atomic.LoadInt32(reorged) == 1 {
+			return fmt.Sprintf("B%d", height)
+		}
+		return fmt.Sprintf("A%d", height)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"result":{"sync_info":{"latest_block_height":"%d","latest_block_hash":"%s"}}}`, tip, hashAt(tip))
+	})
+	mux.HandleFunc("/block", func(w http.ResponseWriter, r *http.Request) {
+		height, err := strconv.ParseUint(r.URL.Query().Get("height"), 10, 64)
+		if err != nil || height == 0 || height > tip {
+			http.Error(w, "no such block", http.StatusNotFound)
+			return
+		}
+
+		var resp TendermintBlockResponse
+		resp.Result.BlockID.Hash = hashAt(height)
+		resp.Result.Block.Header.Height = strconv.FormatUint(height, 10)
+		resp.Result.Block.Header.Time = genesis.Add(time.Duration(height) * time.Minute)
+		resp.Result.Block.Header.ChainID = "shadowy-test"
+		if height > 1 {
+			resp.Result.Block.Header.LastBlockID.Hash = hashAt(height - 1)
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSyncRollsBackReplacedTip(t *testing.T) {
+	var reorged int32
+	node := newForkingNode(t, 5, 3, &reorged)
+	database := newTestDatabase(t)
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+
+	svc.syncOnce()
+	if hash, _ := database.GetNodeHash(5); hash != "A5" {
+		t.Fatalf("Expected branch A at 5, got %q", hash)
+	}
+
+	atomic.StoreInt32(&reorged, 1)
+	svc.syncOnce()
+
+	for height := uint64(1); height <= 5; height++ {
+		want := fmt.Sprintf("A%d", height)
+		if height > 3 {
+			want = fmt.Sprintf("B%d", height)
+		}
+		if hash, _ := database.GetNodeHash(height); hash != want {
+			t.Errorf("Height %d: expected %s, got %q", height, want, hash)
+		}
+	}
+	if height, _ := database.GetLatestHeight(); height != 5 {
+		t.Errorf("Expected re-index up to 5, got %d", height)
+	}
+
+	status := svc.Status()
+	if status.LastReorg == nil || status.LastReorg.ForkHeight != 3 || status.LastReorg.Blocks != 2 {
+		t.Errorf("Expected a rollback of 2 blocks to 3, got %+v", status.LastReorg)
+	}
+	if status.LastError != "" {
+		t.Errorf("Unexpected sync error: %s", status.LastError)
+	}
+}
+
+func TestSyncBlockRejectsMismatchedParent(t *testing.T) {
+	var reorged int32
+	node := newForkingNode(t, 5, 3, &reorged)
+	database := newTestDatabase(t)
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+
+	for height := uint64(1); height <= 4; height++ {
+		if err := svc.syncBlock(height); err != nil {
+			t.Fatalf("Failed to sync block %d: %v", height, err)
+		}
+	}
+
+	// Block 5 on the new branch builds on B4, not the A4 we hold
+	atomic.StoreInt32(&reorged, 1)
+	if err := svc.syncBlock(5); !errors.Is(err, errReorgDetected) {
+		t.Fatalf("Expected a reorg error, got %v", err)
+	}
+	if height, _ := database.GetLatestHeight(); height != 4 {
+		t.Errorf("Mismatched block must not be stored, at %d", height)
+	}
+}
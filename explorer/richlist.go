@@ -0,0 +1,366 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// The balance index holds every wallet's SHADOW balance
+// (balance:<address> -> big-endian int64) and orders the positive ones
+// (rich:<balance>:<address>, balance zero-padded to 20 digits) so the rich
+// list is a reverse prefix scan. StoreTransaction and RollbackToHeight keep
+// it current; databases indexed before it existed are backfilled by a migration.
+const (
+	balancePrefix       = "balance:"
+	richPrefix          = "rich:"
+	defaultRichListSize = 100
+	maxRichListSize     = 1000
+)
+
+// balanceBucketBounds are the upper bounds of the distribution buckets in
+// base units (1 SHADOW = 1e8); the last bucket is open-ended
+var balanceBucketBounds = []uint64{1e8, 1e9, 1e10, 1e11, 1e12, 1e13}
+
+var balanceBucketLabels = []string{"< 1", "1 - 10", "10 - 100", "100 - 1K", "1K - 10K", "10K - 100K", ">= 100K"}
+
+func richKey(balance int64, address string) []byte {
+	return []byte(fmt.Sprintf("%s%020d:%s", richPrefix, balance, address))
+}
+
+// adjustBalance moves address's balance by delta, keeping the rich index
+// in step
+func adjustBalance(txn *badger.Txn, address string, delta int64) error {
+	if address == "" || delta == 0 {
+		return nil
+	}
+	key := []byte(balancePrefix + address)
+
+	var balance int64
+	item, err := txn.Get(key)
+	switch {
+	case err == badger.ErrKeyNotFound:
+	case err != nil:
+		return err
+	default:
+		if err := item.Value(func(val []byte) error {
+			if len(val) == 8 {
+				balance = int64(binary.BigEndian.Uint64(val))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if balance > 0 {
+		if err := txn.Delete(richKey(balance, address)); err != nil {
+			return err
+		}
+	}
+	balance += delta
+	if balance > 0 {
+		if err := txn.Set(richKey(balance, address), nil); err != nil {
+			return err
+		}
+	}
+
+	if balance == 0 {
+		return txn.Delete(key)
+	}
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(balance))
+	return txn.Set(key, value)
+}
+
+// applyBalanceDeltas adds (sign 1) or removes (sign -1) a transaction's
+// effect on the balance index and balance history: the recipient gains the
+// amount and the sender pays the amount plus the fee
+func applyBalanceDeltas(txn *badger.Txn, tx *WalletTransaction, sign int64) error {
+	if err := adjustBalance(txn, tx.ToAddress, sign*int64(tx.Amount)); err != nil {
+		return err
+	}
+	if err := adjustBalance(txn, tx.FromAddress, -sign*int64(tx.Amount+tx.Fee)); err != nil {
+		return err
+	}
+	return applyBalanceHistory(txn, tx, sign)
+}
+
+// replaceBalanceEffect applies tx to the balance index, first undoing the
+// transaction previously stored under txKey if there is one
+func replaceBalanceEffect(txn *badger.Txn, txKey string, tx *WalletTransaction) error {
+	item, err := txn.Get([]byte(txKey))
+	switch {
+	case err == badger.ErrKeyNotFound:
+	case err != nil:
+		return err
+	default:
+		var previous WalletTransaction
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &previous)
+		}); err == nil {
+			if err := applyBalanceDeltas(txn, &previous, -1); err != nil {
+				return err
+			}
+		}
+	}
+	return applyBalanceDeltas(txn, tx, 1)
+}
+
+// buildBalanceIndex rebuilds the balance index from stored transactions
+func (d *Database) buildBalanceIndex() (int, error) {
+	changed := 0
+	err := d.update(func(txn *badger.Txn) error {
+		// Drop partial entries written since the upgrade, then replay
+		var stale [][]byte
+		for _, prefix := range []string{balancePrefix, richPrefix} {
+			keys, err := collectKeys(txn, prefix, func(string, []byte) bool { return true })
+			if err != nil {
+				return err
+			}
+			stale = append(stale, keys...)
+		}
+		if err := deleteKeys(txn, stale); err != nil {
+			return err
+		}
+
+		balances := make(map[string]int64)
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		txPrefix := []byte("tx:")
+		for it.Seek(txPrefix); it.ValidForPrefix(txPrefix); it.Next() {
+			var tx WalletTransaction
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				continue
+			}
+			if tx.ToAddress != "" {
+				balances[tx.ToAddress] += int64(tx.Amount)
+			}
+			if tx.FromAddress != "" {
+				balances[tx.FromAddress] -= int64(tx.Amount + tx.Fee)
+			}
+		}
+		it.Close()
+
+		for address, balance := range balances {
+			if err := adjustBalance(txn, address, balance); err != nil {
+				return err
+			}
+		}
+
+		changed = len(balances)
+		return nil
+	})
+	return changed, err
+}
+
+// scanRichIndex calls fn for every positive balance, largest first
+func (d *Database) scanRichIndex(fn func(address string, balance uint64)) error {
+	return d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(richPrefix)
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// Reverse iteration has to start past the last key under the prefix
+		for it.Seek([]byte(richPrefix + "\xff")); it.Valid(); it.Next() {
+			rest := strings.TrimPrefix(string(it.Item().Key()), richPrefix)
+			parts := strings.SplitN(rest, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			balance, err := strconv.ParseUint(parts[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			fn(parts[1], balance)
+		}
+		return nil
+	})
+}
+
+// GetRichList returns the limit wallets with the largest SHADOW balances
+func (d *Database) GetRichList(limit int) (*RichList, error) {
+	list := &RichList{Holders: []RichListEntry{}}
+	err := d.scanRichIndex(func(address string, balance uint64) {
+		if len(list.Holders) < limit {
+			list.Holders = append(list.Holders, RichListEntry{
+				Rank:    len(list.Holders) + 1,
+				Address: address,
+				Balance: balance,
+			})
+		}
+		list.TotalHolders++
+		list.TotalBalance += balance
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list.computeShares()
+	return list, nil
+}
+
+// computeShares fills in each holder's percentage of the total balance
+func (list *RichList) computeShares() {
+	if list.TotalBalance == 0 {
+		return
+	}
+	for i := range list.Holders {
+		list.Holders[i].Share = float64(list.Holders[i].Balance) / float64(list.TotalBalance) * 100
+	}
+}
+
+// GetBalanceDistribution buckets every positive wallet balance and
+// measures how concentrated they are
+func (d *Database) GetBalanceDistribution() (*BalanceDistribution, error) {
+	var balances []uint64
+	err := d.scanRichIndex(func(address string, balance uint64) {
+		balances = append(balances, balance)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balanceDistribution(balances), nil
+}
+
+// balanceDistribution summarizes a set of positive balances
+func balanceDistribution(balances []uint64) *BalanceDistribution {
+	dist := &BalanceDistribution{TotalHolders: len(balances), Buckets: emptyBalanceBuckets()}
+	for _, balance := range balances {
+		bucket := &dist.Buckets[balanceBucketIndex(balance)]
+		bucket.Holders++
+		bucket.Balance += balance
+		dist.TotalBalance += balance
+	}
+
+	dist.computeShares()
+	dist.Top10Share, dist.Gini = holderConcentration(balances)
+	return dist
+}
+
+// emptyBalanceBuckets returns the distribution buckets with nothing in them
+func emptyBalanceBuckets() []BalanceBucket {
+	buckets := make([]BalanceBucket, len(balanceBucketLabels))
+	var lower uint64
+	for i, label := range balanceBucketLabels {
+		buckets[i] = BalanceBucket{Label: label, Min: lower}
+		if i < len(balanceBucketBounds) {
+			buckets[i].Max = balanceBucketBounds[i]
+			lower = buckets[i].Max
+		}
+	}
+	return buckets
+}
+
+// balanceBucketIndex returns the bucket a balance falls in
+func balanceBucketIndex(balance uint64) int {
+	i := 0
+	for i < len(balanceBucketBounds) && balance >= balanceBucketBounds[i] {
+		i++
+	}
+	return i
+}
+
+// computeShares fills in each bucket's percentage of the total balance
+func (dist *BalanceDistribution) computeShares() {
+	if dist.TotalBalance == 0 {
+		return
+	}
+	for i := range dist.Buckets {
+		dist.Buckets[i].Share = float64(dist.Buckets[i].Balance) / float64(dist.TotalBalance) * 100
+	}
+}
+
+// Rich list endpoint: the wallets holding the most SHADOW
+func (es *ExplorerServer) handleRichList(w http.ResponseWriter, r *http.Request) {
+	limit := defaultRichListSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxRichListSize {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxRichListSize), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	list, err := es.database.GetRichList(limit)
+	if err != nil {
+		http.Error(w, "Failed to get rich list", http.StatusInternalServerError)
+		return
+	}
+
+	labels := es.newLabeler()
+	for i := range list.Holders {
+		labels.add(list.Holders[i].Address, &list.Holders[i].Label)
+	}
+	labels.apply()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Distribution endpoint: balance buckets and concentration across wallets
+func (es *ExplorerServer) handleBalanceDistribution(w http.ResponseWriter, r *http.Request) {
+	dist, err := es.database.GetBalanceDistribution()
+	if err != nil {
+		http.Error(w, "Failed to get balance distribution", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dist)
+}
+
+// chartBucket is a balance bucket with the width of its holders bar, as
+// a percent of the largest bucket
+type chartBucket struct {
+	BalanceBucket
+	HolderBar float64
+}
+
+// Charts page: rich list and balance distribution
+func (es *ExplorerServer) handleChartsPage(w http.ResponseWriter, r *http.Request) {
+	dist, err := es.database.GetBalanceDistribution()
+	if err != nil {
+		http.Error(w, "Failed to get balance distribution", http.StatusInternalServerError)
+		return
+	}
+	list, err := es.database.GetRichList(25)
+	if err != nil {
+		http.Error(w, "Failed to get rich list", http.StatusInternalServerError)
+		return
+	}
+
+	labels := es.newLabeler()
+	for i := range list.Holders {
+		labels.add(list.Holders[i].Address, &list.Holders[i].Label)
+	}
+	labels.apply()
+
+	maxHolders := 1
+	for _, bucket := range dist.Buckets {
+		maxHolders = max(maxHolders, bucket.Holders)
+	}
+	buckets := make([]chartBucket, len(dist.Buckets))
+	for i, bucket := range dist.Buckets {
+		buckets[i] = chartBucket{BalanceBucket: bucket, HolderBar: float64(bucket.Holders) / float64(maxHolders) * 100}
+	}
+
+	es.renderPage(w, r, http.StatusOK, "charts", page{
+		Title: "Charts",
+		Data: struct {
+			Distribution *BalanceDistribution
+			Buckets      []chartBucket
+			RichList     *RichList
+		}{dist, buckets, list},
+	})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func storeTestPayment(t *testing.T, database Store, hash, from, to string, height, amount, fee uint64) {
+	tx := &WalletTransaction{
+		TxHash:      hash,
+		BlockHeight: height,
+		Timestamp:   time.Unix(int64(height), 0).UTC(),
+		Type:        "transfer",
+		Amount:      amount,
+		Fee:         fee,
+		FromAddress: from,
+		ToAddress:   to,
+	}
+	if from == "" {
+		tx.Type = "mining_reward"
+	}
+	if err := database.StoreTransaction(tx); err != nil {
+		t.Fatalf("Failed to store %s: %v", hash, err)
+	}
+}
+
+func richAddresses(list *RichList) string {
+	var out []string
+	for _, h := range list.Holders {
+		out = append(out, fmt.Sprintf("%s=%d", h.Address, h.Balance))
+	}
+	return fmt.Sprint(out)
+}
+
+func TestRichListTracksTransfers(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestPayment(t, database, "r1", "", "alice", 1, 500, 0)
+	storeTestPayment(t, database, "r2", "", "bob", 2, 300, 0)
+	storeTestPayment(t, database, "t1", "alice", "carol", 3, 350, 10)
+
+	list, err := database.GetRichList(10)
+	if err != nil {
+		t.Fatalf("GetRichList failed: %v", err)
+	}
+	if got := richAddresses(list); got != "[carol=350 bob=300 alice=140]" {
+		t.Errorf("Unexpected rich list %s", got)
+	}
+	if list.TotalHolders != 3 || list.TotalBalance != 790 {
+		t.Errorf("Expected 3 holders with 790, got %d/%d", list.TotalHolders, list.TotalBalance)
+	}
+
+	// Storing a transaction again must not count it twice
+	storeTestPayment(t, database, "t1", "alice", "carol", 3, 350, 10)
+	if list, _ := database.GetRichList(1); richAddresses(list) != "[carol=350]" || list.Holders[0].Rank != 1 {
+		t.Errorf("Re-stored transaction changed the top holder: %s", richAddresses(list))
+	}
+
+	// Rolling back the transfer restores alice and drops carol
+	if _, err := database.RollbackToHeight(2); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	if list, _ := database.GetRichList(10); richAddresses(list) != "[alice=500 bob=300]" {
+		t.Errorf("Unexpected rich list after rollback %s", richAddresses(list))
+	}
+}
+
+func TestBalanceIndexBackfillsExistingTransactions(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestPayment(t, database, "r1", "", "alice", 1, 500, 0)
+	storeTestPayment(t, database, "t1", "alice", "bob", 2, 200, 0)
+
+	// Simulate a database indexed before the balance index existed
+	err := database.update(func(txn *badger.Txn) error {
+		keys, err := collectKeys(txn, balancePrefix, func(string, []byte) bool { return true })
+		if err != nil {
+			return err
+		}
+		if err := deleteKeys(txn, keys); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(balanceIndexMarker))
+	})
+	if err != nil {
+		t.Fatalf("Failed to drop balance index: %v", err)
+	}
+	if err := database.migrate(); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	list, err := database.GetRichList(10)
+	if err != nil {
+		t.Fatalf("GetRichList failed: %v", err)
+	}
+	if got := richAddresses(list); got != "[alice=300 bob=200]" {
+		t.Errorf("Unexpected rebuilt rich list %s", got)
+	}
+}
+
+func TestBalanceDistributionBuckets(t *testing.T) {
+	const shadow = 100000000
+	dist := balanceDistribution([]uint64{shadow / 2, 5 * shadow, 5 * shadow, 200000 * shadow})
+
+	if dist.TotalHolders != 4 || len(dist.Buckets) != len(balanceBucketLabels) {
+		t.Fatalf("Unexpected distribution %+v", dist)
+	}
+	holders := make([]int, len(dist.Buckets))
+	for i, bucket := range dist.Buckets {
+		holders[i] = bucket.Holders
+	}
+	if fmt.Sprint(holders) != "[1 2 0 0 0 0 1]" {
+		t.Errorf("Unexpected bucket counts %v", holders)
+	}
+	if last := dist.Buckets[len(dist.Buckets)-1]; last.Max != 0 || last.Share < 99 {
+		t.Errorf("Expected the open top bucket to hold almost everything, got %+v", last)
+	}
+	if dist.Gini <= 0.5 || dist.Top10Share != 1 {
+		t.Errorf("Expected a concentrated distribution, got gini %.2f top10 %.2f", dist.Gini, dist.Top10Share)
+	}
+}
+
+func TestRichListAPI(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestPayment(t, database, "r1", "", "alice", 1, 500, 0)
+	storeTestPayment(t, database, "r2", "", "bob", 2, 300, 0)
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleRichList(rec, httptest.NewRequest("GET", "/api/v1/analytics/rich-list?limit=1", nil))
+	var list RichList
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Holders) != 1 || list.Holders[0].Address != "alice" || list.Holders[0].Share != 62.5 {
+		t.Errorf("Unexpected rich list %+v", list)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleRichList(rec, httptest.NewRequest("GET", "/api/v1/analytics/rich-list?limit=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for limit=0, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleBalanceDistribution(rec, httptest.NewRequest("GET", "/api/v1/analytics/distribution", nil))
+	var dist BalanceDistribution
+	if err := json.NewDecoder(rec.Body).Decode(&dist); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if dist.TotalHolders != 2 || dist.TotalBalance != 800 || dist.Buckets[0].Holders != 2 {
+		t.Errorf("Unexpected distribution %+v", dist)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// errNoSearchMatch is returned when a query matches nothing indexed
+var errNoSearchMatch = errors.New("no match")
+
+// isHex reports whether s is a non-empty even-length hex string
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// isAddressQuery reports whether q looks like an S- or L-address
+func isAddressQuery(q string) bool {
+	switch {
+	case strings.HasPrefix(q, "S"):
+		return len(q) == 51 && isHex(q[1:])
+	case strings.HasPrefix(q, "L"):
+		return len(q) == 41 && isHex(q[1:])
+	}
+	return false
+}
+
+// Search resolves a block height, block hash, transaction hash, wallet
+// address, token ID, pool ID or address label to the entity it names and
+// the explorer page that shows it. Hashes are tried in that order.
+func (d *Database) Search(query string) (*SearchResult, error) {
+	q := strings.TrimSpace(query)
+	if q == "" {
+		return nil, errNoSearchMatch
+	}
+	result := &SearchResult{Query: q}
+
+	if height, err := strconv.ParseUint(q, 10, 64); err == nil {
+		var block *BlockWithHash
+		err := d.view(func(txn *badger.Txn) error {
+			var err error
+			block, err = readBlockAtHeight(txn, height)
+			return err
+		})
+		if err == badger.ErrKeyNotFound {
+			return nil, errNoSearchMatch
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Type = "block"
+		result.ID = block.Hash
+		result.Redirect = "/block/" + url.PathEscape(block.Hash)
+		return result, nil
+	}
+
+	if isAddressQuery(q) {
+		result.Type = "wallet"
+		result.ID = q
+		result.Redirect = "/wallet/" + q
+		return result, nil
+	}
+
+	// Hashes and IDs are indexed lowercase; try the query as typed first
+	candidates := []string{q}
+	if lower := strings.ToLower(q); lower != q {
+		candidates = append(candidates, lower)
+	}
+
+	err := d.view(func(txn *badger.Txn) error {
+		for _, candidate := range candidates {
+			if _, err := txn.Get([]byte("block:" + candidate)); err == nil {
+				result.Type = "block"
+				result.ID = candidate
+				result.Redirect = "/block/" + url.PathEscape(candidate)
+				return nil
+			} else if err != badger.ErrKeyNotFound {
+				return err
+			}
+
+			item, err := txn.Get([]byte("tx:" + candidate))
+			if err == nil {
+				var tx WalletTransaction
+				if err := item.Value(func(val []byte) error {
+					return json.Unmarshal(val, &tx)
+				}); err != nil {
+					return err
+				}
+				// Transactions are shown on the block that includes them
+				result.Type = "transaction"
+				result.ID = candidate
+				result.Redirect = "/block/" + url.PathEscape(tx.BlockHash)
+				return nil
+			} else if err != badger.ErrKeyNotFound {
+				return err
+			}
+		}
+		return errNoSearchMatch
+	})
+	if err == nil {
+		return result, nil
+	}
+	if err != errNoSearchMatch {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		if token, err := d.GetToken(candidate); err == nil {
+			result.Type = "token"
+			result.ID = token.TokenID
+			result.Redirect = "/token/" + url.PathEscape(token.TokenID)
+			return result, nil
+		}
+		if pool, err := d.GetPool(candidate); err == nil {
+			result.Type = "pool"
+			result.ID = pool.PoolID
+			result.Redirect = "/pool/" + url.PathEscape(pool.PoolID)
+			return result, nil
+		}
+	}
+	return searchLabels(d, result)
+}
+
+// Universal search API endpoint
+func (es *ExplorerServer) handleSearchAPI(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if strings.TrimSpace(q) == "" {
+		http.Error(w, "Missing q", http.StatusBadRequest)
+		return
+	}
+
+	result, err := es.database.Search(q)
+	w.Header().Set("Content-Type", "application/json")
+	if err == errNoSearchMatch {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"query": strings.TrimSpace(q),
+			"error": "No block, transaction, wallet, token, pool or label matches this query",
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, "Search failed", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// Search box target: redirects to the matching page so the search form
+// works without JavaScript
+func (es *ExplorerServer) handleSearchPage(w http.ResponseWriter, r *http.Request) {
+	result, err := es.database.Search(r.URL.Query().Get("q"))
+	if err == nil {
+		http.Redirect(w, r, es.config.pagePath(result.Redirect), http.StatusFound)
+		return
+	}
+	if err != errNoSearchMatch {
+		http.Error(w, "Search failed", http.StatusInternalServerError)
+		return
+	}
+
+	es.renderPage(w, r, http.StatusNotFound, "search", page{
+		Title:   "No results",
+		NoIndex: true,
+		Data:    struct{ Query string }{strings.TrimSpace(r.URL.Query().Get("q"))},
+	})
+}
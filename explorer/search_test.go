@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchResolvesEntities(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 1, 3)
+
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: "abcdef01", BlockHash: "hash_2", BlockHeight: 2, Timestamp: time.Unix(2, 0)}); err != nil {
+		t.Fatalf("Failed to store transaction: %v", err)
+	}
+	if err := database.StoreToken(&TokenInfo{TokenID: "70ce", Name: "Gold", Ticker: "GOLD"}); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+	if err := database.StorePool(&LiquidityPool{PoolID: "9001", TokenASymbol: "GOLD", TokenBSymbol: "SHADOW"}); err != nil {
+		t.Fatalf("Failed to store pool: %v", err)
+	}
+
+	address := "S42618a7524a82df51c8a2406321e161de65073008806f042f0"
+	cases := []struct {
+		query, kind, redirect string
+	}{
+		{"2", "block", "/block/hash_2"},
+		{" hash_3 ", "block", "/block/hash_3"},
+		{"ABCDEF01", "transaction", "/block/hash_2"},
+		{address, "wallet", "/wallet/" + address},
+		{"70ce", "token", "/token/70ce"},
+		{"9001", "block", ""}, // Numeric queries are heights first
+	}
+	for _, tc := range cases {
+		result, err := database.Search(tc.query)
+		if tc.redirect == "" {
+			if err != errNoSearchMatch {
+				t.Errorf("%q: expected no match, got %+v, %v", tc.query, result, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: search failed: %v", tc.query, err)
+			continue
+		}
+		if result.Type != tc.kind || result.Redirect != tc.redirect {
+			t.Errorf("%q: expected %s at %s, got %+v", tc.query, tc.kind, tc.redirect, result)
+		}
+	}
+
+	if err := database.StorePool(&LiquidityPool{PoolID: "pool_gold", TokenASymbol: "GOLD", TokenBSymbol: "SHADOW"}); err != nil {
+		t.Fatalf("Failed to store pool: %v", err)
+	}
+	if result, err := database.Search("pool_gold"); err != nil || result.Type != "pool" || result.Redirect != "/pool/pool_gold" {
+		t.Errorf("Expected the pool, got %+v, %v", result, err)
+	}
+}
+
+func TestSearchEndpoints(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 1, 2)
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleSearchAPI(rec, httptest.NewRequest("GET", "/api/v1/search?q=1", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"redirect":"/block/hash_1"`) {
+		t.Errorf("Expected the block at height 1, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleSearchAPI(rec, httptest.NewRequest("GET", "/api/v1/search?q=nothing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown query, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleSearchAPI(rec, httptest.NewRequest("GET", "/api/v1/search", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without q, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleSearchPage(rec, httptest.NewRequest("GET", "/search?q=2", nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/block/hash_2" {
+		t.Errorf("Search page should redirect to the block, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestPagesIncludeSearchBox(t *testing.T) {
+	es := &ExplorerServer{database: newTestDatabase(t)}
+	rec := httptest.NewRecorder()
+	es.handleBlocksPage(rec, httptest.NewRequest("GET", "/blocks", nil))
+	if !strings.Contains(rec.Body.String(), `action="/search"`) {
+		t.Error("Blocks page should include the search box")
+	}
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	defaultListenAddr       = ":10001"
+	defaultAutocertCache    = "./autocert"
+	defaultAutocertHTTPAddr = ":80"
+
+	assetsEmbedded = "embedded"
+	assetsCDN      = "cdn"
+)
+
+// ServerConfig controls how the explorer listens and where it is mounted.
+// Values come from the JSON file named by -config or EXPLORER_CONFIG, then
+// EXPLORER_* environment variables, then command-line flags, each
+// overriding the last.
+type ServerConfig struct {
+	ListenAddr string `json:"listen_addr"` // Default :10001
+
+	// Serve HTTPS from a certificate and key, or from certificates obtained
+	// from Let's Encrypt for the autocert domains
+	TLSCert          string   `json:"tls_cert"`
+	TLSKey           string   `json:"tls_key"`
+	AutocertDomains  []string `json:"autocert_domains"`
+	AutocertCache    string   `json:"autocert_cache"`     // Default ./autocert
+	AutocertHTTPAddr string   `json:"autocert_http_addr"` // HTTP-01 challenges, default :80
+
+	// Peers (IPs or CIDRs) whose X-Forwarded-For and X-Real-IP headers are
+	// believed. Requests from anyone else keep their socket address.
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// Path prefix the explorer is served under, e.g. /explorer
+	BasePath string `json:"base_path"`
+
+	// External URL of the explorer's root, base path included, e.g.
+	// https://explorer.example.com. Sitemaps and link previews use it;
+	// unset, it is taken from each request's host.
+	PublicURL string `json:"public_url"`
+
+	// Production removes the test and debug admin routes and the loopback
+	// fallback for admin access, leaving the admin token and client
+	// certificates
+	Production bool `json:"production"`
+
+	// PEM bundle of CAs whose client certificates are admitted to admin
+	// routes (mutual TLS); requires TLS
+	AdminClientCA string `json:"admin_client_ca"`
+
+	// Where pages get their styles: "embedded" (default) serves them from
+	// the binary, "cdn" loads Tailwind from its CDN
+	Assets string `json:"assets"`
+
+	// Privacy keeps client addresses out of logs and the admin audit log
+	// and asks browsers not to send referrers. Rate limits still count per
+	// address, in memory only.
+	Privacy bool `json:"privacy"`
+
+	// Onion serves the explorer as a Tor onion service: pages carry no
+	// absolute URLs, sitemaps are off and browsers are told not to load
+	// anything from other origins. Implies privacy and embedded assets.
+	Onion bool `json:"onion"`
+
+	// Origins whose pages may call the explorer from browsers (CORS), e.g.
+	// https://wallet.example.com; "*" allows any
+	CORSOrigins []string `json:"cors_origins"`
+
+	// AccessLog logs every request with its status and duration
+	AccessLog bool `json:"access_log"`
+
+	// Log output: "text" (default) or "json" lines for log shippers, at
+	// LogLevel (debug, info (default), warn or error) and above
+	LogFormat string `json:"log_format"`
+	LogLevel  string `json:"log_level"`
+
+	// Chains served side by side, each under /chain/{id}. The first is
+	// also served at the root, from the store a single-chain explorer
+	// uses; the others each get a keyspace of their own. Unset, the
+	// explorer serves the one chain of SHADOWY_NODE_URL.
+	Chains []ChainConfig `json:"chains"`
+
+	trustedNets []*net.IPNet
+	clientCAs   *x509.CertPool
+	logLevel    slog.Level
+	chainPrefix string // /chain/{id} when BasePath is a chain's, see forChain
+}
+
+// loadServerConfig builds the server config from the config file, the
+// environment and args, in increasing precedence
+func loadServerConfig(args []string) (*ServerConfig, error) {
+	fs := flag.NewFlagSet("explorer", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("EXPLORER_CONFIG"), "JSON server config file")
+	listen := fs.String("listen", "", "listen address (default "+defaultListenAddr+")")
+	tlsCert := fs.String("tls-cert", "", "TLS certificate file")
+	tlsKey := fs.String("tls-key", "", "TLS private key file")
+	autocertDomains := fs.String("autocert", "", "comma-separated domains to obtain Let's Encrypt certificates for")
+	trustedProxies := fs.String("trusted-proxies", "", "comma-separated proxy IPs or CIDRs whose forwarding headers are trusted")
+	basePath := fs.String("base-path", "", "path prefix to serve under, e.g. /explorer")
+	publicURL := fs.String("public-url", "", "external URL of the explorer for sitemaps and link previews")
+	production := fs.Bool("production", false, "disable test and debug admin routes and loopback admin access")
+	adminClientCA := fs.String("admin-client-ca", "", "PEM file of CAs whose client certificates may use admin routes")
+	assets := fs.String("assets", "", "where pages load styles from: embedded (default) or cdn")
+	privacy := fs.Bool("privacy", false, "keep client addresses out of logs and strip referrers")
+	onion := fs.Bool("onion", false, "serve as a Tor onion service: no absolute URLs or off-site assets")
+	corsOrigins := fs.String("cors-origins", "", "comma-separated origins allowed to call the API from browsers, or *")
+	accessLog := fs.Bool("access-log", false, "log every request")
+	logFormat := fs.String("log-format", "", "log output: text (default) or json")
+	logLevel := fs.String("log-level", "", "lowest level logged: debug, info (default), warn or error")
+	chains := fs.String("chains", "", "chains to serve as id=node_url[,node_url];id=node_url, the first also at the root")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	config := &ServerConfig{}
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %w", *configFile, err)
+		}
+	}
+
+	setString := func(target *string, values ...string) {
+		for _, value := range values {
+			if value != "" {
+				*target = value
+			}
+		}
+	}
+	setList := func(target *[]string, values ...string) {
+		for _, value := range values {
+			if value != "" {
+				*target = splitList(value)
+			}
+		}
+	}
+	setString(&config.ListenAddr, os.Getenv("EXPLORER_LISTEN_ADDR"), *listen)
+	setString(&config.TLSCert, os.Getenv("EXPLORER_TLS_CERT"), *tlsCert)
+	setString(&config.TLSKey, os.Getenv("EXPLORER_TLS_KEY"), *tlsKey)
+	setList(&config.AutocertDomains, os.Getenv("EXPLORER_AUTOCERT_DOMAINS"), *autocertDomains)
+	setString(&config.AutocertCache, os.Getenv("EXPLORER_AUTOCERT_CACHE"))
+	setString(&config.AutocertHTTPAddr, os.Getenv("EXPLORER_AUTOCERT_HTTP_ADDR"))
+	setList(&config.TrustedProxies, os.Getenv("EXPLORER_TRUSTED_PROXIES"), *trustedProxies)
+	setString(&config.BasePath, os.Getenv("EXPLORER_BASE_PATH"), *basePath)
+	setString(&config.PublicURL, os.Getenv("EXPLORER_PUBLIC_URL"), *publicURL)
+	setString(&config.AdminClientCA, os.Getenv("EXPLORER_ADMIN_CLIENT_CA"), *adminClientCA)
+	setString(&config.Assets, os.Getenv("EXPLORER_ASSETS"), *assets)
+	setList(&config.CORSOrigins, os.Getenv("EXPLORER_CORS_ORIGINS"), *corsOrigins)
+	setString(&config.LogFormat, os.Getenv("EXPLORER_LOG_FORMAT"), *logFormat)
+	setString(&config.LogLevel, os.Getenv("EXPLORER_LOG_LEVEL"), *logLevel)
+	for _, value := range []string{os.Getenv("EXPLORER_CHAINS"), *chains} {
+		if value != "" {
+			parsed, err := parseChains(value)
+			if err != nil {
+				return nil, err
+			}
+			config.Chains = parsed
+		}
+	}
+	for _, setting := range []struct {
+		target *bool
+		env    string
+		flag   bool
+	}{
+		{&config.Production, "EXPLORER_PRODUCTION", *production},
+		{&config.Privacy, "EXPLORER_PRIVACY", *privacy},
+		{&config.Onion, "EXPLORER_ONION", *onion},
+		{&config.AccessLog, "EXPLORER_ACCESS_LOG", *accessLog},
+	} {
+		if value := os.Getenv(setting.env); value != "" {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", setting.env, value)
+			}
+			*setting.target = enabled
+		}
+		if setting.flag {
+			*setting.target = true
+		}
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validate fills in defaults and rejects inconsistent settings
+func (c *ServerConfig) validate() error {
+	if c.ListenAddr == "" {
+		c.ListenAddr = defaultListenAddr
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("tls_cert and tls_key must be set together")
+	}
+	if c.TLSCert != "" && len(c.AutocertDomains) > 0 {
+		return fmt.Errorf("use either a TLS certificate or autocert, not both")
+	}
+	if c.AutocertCache == "" {
+		c.AutocertCache = defaultAutocertCache
+	}
+	if c.AutocertHTTPAddr == "" {
+		c.AutocertHTTPAddr = defaultAutocertHTTPAddr
+	}
+	switch c.Assets {
+	case "":
+		c.Assets = assetsEmbedded
+	case assetsEmbedded, assetsCDN:
+	default:
+		return fmt.Errorf("assets must be %q or %q, not %q", assetsEmbedded, assetsCDN, c.Assets)
+	}
+	switch c.LogFormat {
+	case "":
+		c.LogFormat = logFormatText
+	case logFormatText, logFormatJSON:
+	default:
+		return fmt.Errorf("log_format must be %q or %q, not %q", logFormatText, logFormatJSON, c.LogFormat)
+	}
+	if c.LogLevel == "" {
+		c.LogLevel = "info"
+	}
+	if err := c.logLevel.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("invalid log_level %q", c.LogLevel)
+	}
+
+	if c.Onion {
+		if c.Assets == assetsCDN {
+			return fmt.Errorf("onion mode serves embedded assets only")
+		}
+		c.Privacy = true
+	}
+
+	if err := validateChains(c.Chains); err != nil {
+		return err
+	}
+
+	for i, origin := range c.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		parsed, err := url.Parse(origin)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || strings.Trim(parsed.Path, "/") != "" {
+			return fmt.Errorf("invalid CORS origin %q", origin)
+		}
+		c.CORSOrigins[i] = parsed.Scheme + "://" + parsed.Host
+	}
+
+	c.trustedNets = nil
+	for _, proxy := range c.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			c.trustedNets = append(c.trustedNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		c.trustedNets = append(c.trustedNets, network)
+	}
+
+	if c.BasePath != "" {
+		c.BasePath = "/" + strings.Trim(c.BasePath, "/")
+		if c.BasePath == "/" {
+			c.BasePath = ""
+		}
+	}
+
+	if c.PublicURL != "" {
+		parsed, err := url.Parse(c.PublicURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid public_url %q", c.PublicURL)
+		}
+		c.PublicURL = strings.TrimSuffix(c.PublicURL, "/")
+	}
+
+	c.clientCAs = nil
+	if c.AdminClientCA != "" {
+		if !c.TLSEnabled() {
+			return fmt.Errorf("admin_client_ca requires TLS")
+		}
+		pem, err := os.ReadFile(c.AdminClientCA)
+		if err != nil {
+			return fmt.Errorf("failed to read admin client CA: %w", err)
+		}
+		c.clientCAs = x509.NewCertPool()
+		if !c.clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in admin client CA %s", c.AdminClientCA)
+		}
+	}
+	return nil
+}
+
+// cdnAssets reports whether pages load their styles from a CDN
+func (c *ServerConfig) cdnAssets() bool {
+	return c != nil && c.Assets == assetsCDN
+}
+
+// TLSEnabled reports whether the explorer serves HTTPS
+func (c *ServerConfig) TLSEnabled() bool {
+	return c.TLSCert != "" || len(c.AutocertDomains) > 0
+}
+
+// publicURL returns the external URL of the explorer's root, from the
+// config or else from the request's scheme and host
+func (c *ServerConfig) publicURL(r *http.Request) string {
+	if c != nil && c.PublicURL != "" {
+		return c.PublicURL
+	}
+	scheme := r.URL.Scheme // Set from X-Forwarded-Proto for trusted proxies
+	if scheme == "" {
+		scheme = "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+	}
+	basePath := ""
+	if c != nil {
+		basePath = c.BasePath
+	}
+	return scheme + "://" + r.Host + basePath
+}
+
+// pagePath is the path pages link to for a site-absolute path
+func (c *ServerConfig) pagePath(path string) string {
+	if c == nil {
+		return path
+	}
+	return c.BasePath + path
+}
+
+// sitePath is the base path of the whole site, above any chain's prefix
+func (c *ServerConfig) sitePath() string {
+	if c == nil {
+		return ""
+	}
+	return strings.TrimSuffix(c.BasePath, c.chainPrefix)
+}
+
+// isTrustedProxy reports whether a socket address belongs to a trusted proxy
+func (c *ServerConfig) isTrustedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range c.trustedNets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClient picks the client address out of a trusted proxy's
+// headers: the nearest X-Forwarded-For hop that is not itself a trusted
+// proxy, else X-Real-IP
+func (c *ServerConfig) forwardedClient(r *http.Request) string {
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := splitList(strings.Join(forwarded, ","))
+		for i := len(hops) - 1; i >= 0; i-- {
+			if net.ParseIP(hops[i]) == nil {
+				break
+			}
+			if i == 0 || !c.isTrustedProxy(hops[i]) {
+				return hops[i]
+			}
+		}
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return ""
+}
+
+// proxyHeaders replaces the socket address of requests relayed by a trusted
+// proxy with the client's, so logging and the loopback-only admin check see
+// the real caller
+func (c *ServerConfig) proxyHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.isTrustedProxy(r.RemoteAddr) {
+			if client := c.forwardedClient(r); client != "" {
+				r.RemoteAddr = net.JoinHostPort(client, "0")
+			}
+			if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+				r.URL.Scheme = proto
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withBasePath serves next under the base path, redirecting the bare
+// prefix to its trailing-slash form. Pages and redirects add the base path
+// to their links themselves, see pagePath.
+func (c *ServerConfig) withBasePath(next http.Handler) http.Handler {
+	if c.BasePath == "" {
+		return next
+	}
+
+	stripped := http.StripPrefix(c.BasePath, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == c.BasePath {
+			http.Redirect(w, r, c.BasePath+"/", http.StatusMovedPermanently)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, c.BasePath+"/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		stripped.ServeHTTP(w, r)
+	})
+}
+
+// Handler wraps the explorer's routes for this deployment
+func (c *ServerConfig) Handler(router http.Handler) http.Handler {
+	return c.proxyHeaders(c.requestID(c.accessLog(c.privacyHeaders(c.cors(c.withBasePath(router))))))
+}
+
+// requestClientCerts asks TLS clients for a certificate from the admin
+// client CAs. Clients without one are still served, just not as admins.
+func (c *ServerConfig) requestClientCerts(config *tls.Config) *tls.Config {
+	if c.clientCAs != nil {
+		config.ClientCAs = c.clientCAs
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config
+}
+
+// listenAndServe serves handler over HTTP or HTTPS as configured
+func (c *ServerConfig) listenAndServe(handler http.Handler) error {
+	server := &http.Server{Addr: c.ListenAddr, Handler: handler, ErrorLog: c.errorLog()}
+
+	switch {
+	case c.TLSCert != "":
+		server.TLSConfig = c.requestClientCerts(&tls.Config{})
+		return server.ListenAndServeTLS(c.TLSCert, c.TLSKey)
+
+	case len(c.AutocertDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(c.AutocertDomains...),
+			Cache:      autocert.DirCache(c.AutocertCache),
+		}
+		// Answer HTTP-01 challenges and send everything else to HTTPS
+		go func() {
+			if err := http.ListenAndServe(c.AutocertHTTPAddr, manager.HTTPHandler(nil)); err != nil {
+				slog.Warn("Autocert HTTP listener stopped", "addr", c.AutocertHTTPAddr, "err", err)
+			}
+		}()
+		server.TLSConfig = c.requestClientCerts(manager.TLSConfig())
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
+
+// URL is where the explorer can be reached, for the startup log
+func (c *ServerConfig) URL() string {
+	scheme := "http"
+	if c.TLSEnabled() {
+		scheme = "https"
+	}
+	host := c.ListenAddr
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+	if len(c.AutocertDomains) > 0 {
+		host = c.AutocertDomains[0]
+	}
+	return fmt.Sprintf("%s://%s%s/", scheme, host, c.BasePath)
+}
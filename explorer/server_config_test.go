@@ -0,0 +1,190 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadServerConfigPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "explorer.json")
+	if err := os.WriteFile(file, []byte(`{"listen_addr": ":9000", "base_path": "explorer/", "trusted_proxies": ["10.0.0.0/8"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EXPLORER_CONFIG", file)
+	t.Setenv("EXPLORER_LISTEN_ADDR", ":9100")
+
+	config, err := loadServerConfig([]string{"-base-path", "/x/"})
+	if err != nil {
+		t.Fatalf("loadServerConfig failed: %v", err)
+	}
+	if config.ListenAddr != ":9100" || config.BasePath != "/x" || len(config.TrustedProxies) != 1 {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if config.TLSEnabled() || config.URL() != "http://localhost:9100/x/" {
+		t.Errorf("Unexpected URL %s", config.URL())
+	}
+
+	t.Setenv("EXPLORER_CONFIG", "")
+	t.Setenv("EXPLORER_LISTEN_ADDR", "")
+	for _, args := range [][]string{
+		{"-tls-cert", "cert.pem"},
+		{"-tls-cert", "cert.pem", "-tls-key", "key.pem", "-autocert", "example.com"},
+		{"-trusted-proxies", "nginx"},
+	} {
+		if _, err := loadServerConfig(args); err == nil {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestLoadServerConfigAdminSettings(t *testing.T) {
+	t.Setenv("EXPLORER_CONFIG", "")
+	t.Setenv("EXPLORER_PRODUCTION", "true")
+	config, err := loadServerConfig(nil)
+	if err != nil {
+		t.Fatalf("loadServerConfig failed: %v", err)
+	}
+	if !config.Production {
+		t.Error("Expected EXPLORER_PRODUCTION to enable production mode")
+	}
+
+	t.Setenv("EXPLORER_PRODUCTION", "")
+	if _, err := loadServerConfig([]string{"-admin-client-ca", "ca.pem"}); err == nil {
+		t.Error("Expected an admin client CA without TLS to be rejected")
+	}
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(ca, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadServerConfig([]string{"-tls-cert", "c", "-tls-key", "k", "-admin-client-ca", ca}); err == nil {
+		t.Error("Expected a CA file without certificates to be rejected")
+	}
+}
+
+func TestProxyHeadersOnlyTrustedPeers(t *testing.T) {
+	config := &ServerConfig{TrustedProxies: []string{"127.0.0.1", "10.0.0.0/8"}}
+	if err := config.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen string
+	handler := config.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.RemoteAddr
+	}))
+
+	for _, tc := range []struct{ peer, forwarded, want string }{
+		{"127.0.0.1:5000", "203.0.113.9", "203.0.113.9:0"},
+		{"127.0.0.1:5000", "198.51.100.1, 203.0.113.9, 10.1.2.3", "203.0.113.9:0"},
+		{"192.0.2.7:5000", "127.0.0.1", "192.0.2.7:5000"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.peer
+		req.Header.Set("X-Forwarded-For", tc.forwarded)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if seen != tc.want {
+			t.Errorf("peer %s forwarding %q: got %s, want %s", tc.peer, tc.forwarded, seen, tc.want)
+		}
+	}
+
+	// A spoofed loopback address from an untrusted peer must not pass the admin check
+	es := &ExplorerServer{config: config}
+	req := httptest.NewRequest("GET", "/api/v1/admin/sync/status", nil)
+	req.RemoteAddr = "192.0.2.7:5000"
+	req.Header.Set("X-Forwarded-For", "127.0.0.1")
+	rec := httptest.NewRecorder()
+	config.Handler(es.requireAdmin(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the spoofed request to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestBasePathLinksPagesAndRedirects(t *testing.T) {
+	config := &ServerConfig{BasePath: "/explorer"}
+	if err := config.validate(); err != nil {
+		t.Fatal(err)
+	}
+	database := newTestDatabase(t)
+	if err := database.StoreToken(&TokenInfo{TokenID: "70ce", Name: "Gold", Ticker: "GOLD"}); err != nil {
+		t.Fatal(err)
+	}
+	es := NewExplorerServer("http://node", database, nil)
+	es.config = config
+	handler := config.Handler(es.routes())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	page := get("/explorer/tokens").Body.String()
+	for _, want := range []string{`href="/explorer/"`, `href="/explorer/token/70ce"`, `href="/explorer/static/css/explorer.css"`, `action="/explorer/search"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %s in the tokens page %s", want, page)
+		}
+	}
+	if body := get("/explorer/wallet/S" + strings.Repeat("a", 50)).Body.String(); !strings.Contains(body, `const apiBase = "/explorer/api/v1"`) {
+		t.Errorf("Expected the wallet page's scripts to fetch below the base path, got %s", body)
+	}
+
+	address := "S" + strings.Repeat("b", 50)
+	if location := get("/explorer/search?q=" + address).Header().Get("Location"); location != "/explorer/wallet/"+address {
+		t.Errorf("Expected a prefixed redirect, got %q", location)
+	}
+	if location := get("/explorer").Header().Get("Location"); location != "/explorer/" {
+		t.Errorf("Expected the bare prefix to redirect, got %q", location)
+	}
+	if rec := get("/tokens"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 outside the base path, got %d", rec.Code)
+	}
+}
+
+func TestPublicURL(t *testing.T) {
+	t.Setenv("EXPLORER_CONFIG", "")
+	t.Setenv("EXPLORER_PUBLIC_URL", "https://explorer.example.com/")
+	config, err := loadServerConfig(nil)
+	if err != nil {
+		t.Fatalf("loadServerConfig failed: %v", err)
+	}
+	req := httptest.NewRequest("GET", "/blocks", nil)
+	if got := config.publicURL(req); got != "https://explorer.example.com" {
+		t.Errorf("Expected the configured URL, got %s", got)
+	}
+
+	t.Setenv("EXPLORER_PUBLIC_URL", "explorer.example.com")
+	if _, err := loadServerConfig(nil); err == nil {
+		t.Error("Expected a public URL without a scheme to be rejected")
+	}
+
+	// Unset, the request's host and the base path are used
+	req.Host = "example.com"
+	req.URL.Scheme = "https"
+	if got := (&ServerConfig{BasePath: "/x"}).publicURL(req); got != "https://example.com/x" {
+		t.Errorf("Expected the request origin, got %s", got)
+	}
+	var none *ServerConfig
+	req.URL.Scheme = ""
+	if got := none.publicURL(req); got != "http://example.com" {
+		t.Errorf("Expected http without TLS, got %s", got)
+	}
+}
+
+func TestLoadServerConfigAssets(t *testing.T) {
+	t.Setenv("EXPLORER_CONFIG", "")
+	config, err := loadServerConfig(nil)
+	if err != nil || config.Assets != assetsEmbedded || config.cdnAssets() {
+		t.Errorf("Expected embedded assets by default, got %+v (%v)", config, err)
+	}
+
+	t.Setenv("EXPLORER_ASSETS", "cdn")
+	if config, err := loadServerConfig(nil); err != nil || !config.cdnAssets() {
+		t.Errorf("Expected EXPLORER_ASSETS to select the CDN, got %+v (%v)", config, err)
+	}
+	if _, err := loadServerConfig([]string{"-assets", "local"}); err == nil {
+		t.Error("Expected unknown assets modes to be rejected")
+	}
+}
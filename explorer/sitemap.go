@@ -0,0 +1,327 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	sitemapRefreshInterval = time.Hour
+
+	// sitemapMaxURLs is how many URLs go in each sitemap file, the limit
+	// set by the sitemap protocol
+	sitemapMaxURLs = 50000
+
+	// sitemapBatchSize is how many records each store read fetches
+	sitemapBatchSize = 1000
+)
+
+// sitemapSets are the entity kinds with sitemaps, in index order
+var sitemapSets = []string{"pages", "blocks", "tokens", "addresses"}
+
+// sitemapPages are the listing pages every sitemap index includes
+var sitemapPages = []string{"/", "/blocks", "/wallets", "/tokens", "/pools", "/nfts", "/charts", "/storage"}
+
+// sitemapEntry is one page in a sitemap, by path from the explorer's root
+type sitemapEntry struct {
+	Path    string
+	LastMod time.Time // Zero when unknown
+}
+
+// SitemapGenerator lists the explorer's pages for search engines. It walks
+// the index in the background and serves the last listing it built, split
+// into files of at most sitemapMaxURLs.
+type SitemapGenerator struct {
+	database Store
+	interval time.Duration
+
+	mu          sync.RWMutex
+	sets        map[string][][]sitemapEntry // Files of each set
+	generatedAt time.Time
+
+	stopCh chan struct{}
+}
+
+// NewSitemapGenerator creates a generator that rebuilds every interval
+func NewSitemapGenerator(database Store, interval time.Duration) *SitemapGenerator {
+	return &SitemapGenerator{
+		database: database,
+		interval: interval,
+		sets:     make(map[string][][]sitemapEntry),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// newSitemapGeneratorFromEnv creates the generator, rebuilding every
+// EXPLORER_SITEMAP_INTERVAL (e.g. "6h"), or nil when that is "off"
+func newSitemapGeneratorFromEnv(database Store) *SitemapGenerator {
+	value := os.Getenv("EXPLORER_SITEMAP_INTERVAL")
+	if value == "off" {
+		return nil
+	}
+	interval := sitemapRefreshInterval
+	if value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < time.Minute {
+			slog.Warn("Ignoring invalid EXPLORER_SITEMAP_INTERVAL", "value", value, "default", sitemapRefreshInterval)
+		} else {
+			interval = parsed
+		}
+	}
+	return NewSitemapGenerator(database, interval)
+}
+
+// Start builds the sitemaps in the background and keeps rebuilding them
+func (sg *SitemapGenerator) Start() {
+	go func() {
+		sg.Generate()
+
+		ticker := time.NewTicker(sg.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sg.Generate()
+			case <-sg.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background rebuilds
+func (sg *SitemapGenerator) Stop() {
+	close(sg.stopCh)
+}
+
+// Generate rebuilds every sitemap from the index. A set that fails to
+// build keeps its previous files.
+func (sg *SitemapGenerator) Generate() {
+	builders := map[string]func() ([]sitemapEntry, error){
+		"pages":     sg.pageEntries,
+		"blocks":    sg.blockEntries,
+		"tokens":    sg.tokenEntries,
+		"addresses": sg.addressEntries,
+	}
+
+	sets := make(map[string][][]sitemapEntry)
+	urls := 0
+	for _, name := range sitemapSets {
+		entries, err := builders[name]()
+		if err != nil {
+			slog.Warn("Failed to build sitemap", "sitemap", name, "err", err)
+			sg.mu.RLock()
+			sets[name] = sg.sets[name]
+			sg.mu.RUnlock()
+			continue
+		}
+		for len(entries) > 0 {
+			n := min(len(entries), sitemapMaxURLs)
+			sets[name] = append(sets[name], entries[:n])
+			entries = entries[n:]
+			urls += n
+		}
+	}
+
+	sg.mu.Lock()
+	sg.sets = sets
+	sg.generatedAt = time.Now().UTC()
+	sg.mu.Unlock()
+	slog.Info("Generated sitemaps", "urls", urls)
+}
+
+func (sg *SitemapGenerator) pageEntries() ([]sitemapEntry, error) {
+	entries := make([]sitemapEntry, len(sitemapPages))
+	for i, path := range sitemapPages {
+		entries[i] = sitemapEntry{Path: path}
+	}
+	return entries, nil
+}
+
+// blockEntries lists every block, newest first
+func (sg *SitemapGenerator) blockEntries() ([]sitemapEntry, error) {
+	var entries []sitemapEntry
+	cursor := ""
+	for {
+		blocks, err := sg.database.GetBlocksByCursor(cursor, sitemapBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range blocks.Blocks {
+			entries = append(entries, sitemapEntry{Path: "/block/" + block.Hash, LastMod: block.Timestamp})
+		}
+		if blocks.NextCursor == "" {
+			return entries, nil
+		}
+		cursor = blocks.NextCursor
+	}
+}
+
+func (sg *SitemapGenerator) tokenEntries() ([]sitemapEntry, error) {
+	var entries []sitemapEntry
+	for page := 1; ; page++ {
+		tokens, err := sg.database.GetTokens(page, 100, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range tokens.Tokens {
+			entries = append(entries, sitemapEntry{Path: "/token/" + token.TokenID, LastMod: token.LastActivity})
+		}
+		if page >= tokens.TotalPages {
+			return entries, nil
+		}
+	}
+}
+
+func (sg *SitemapGenerator) addressEntries() ([]sitemapEntry, error) {
+	var entries []sitemapEntry
+	after := ""
+	for {
+		addresses, err := sg.database.ListAddresses(after, sitemapBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			entries = append(entries, sitemapEntry{Path: "/wallet/" + address})
+		}
+		if len(addresses) < sitemapBatchSize {
+			return entries, nil
+		}
+		after = addresses[len(addresses)-1]
+	}
+}
+
+// file returns one file of a set, numbered from 1
+func (sg *SitemapGenerator) file(set string, number int) ([]sitemapEntry, bool) {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	files := sg.sets[set]
+	if number < 1 || number > len(files) {
+		return nil, false
+	}
+	return files[number-1], true
+}
+
+// fileNames returns the name of every sitemap file, such as blocks-2.xml
+func (sg *SitemapGenerator) fileNames() ([]string, time.Time) {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	var names []string
+	for _, set := range sitemapSets {
+		for i := range sg.sets[set] {
+			names = append(names, fmt.Sprintf("%s-%d.xml", set, i+1))
+		}
+	}
+	return names, sg.generatedAt
+}
+
+type sitemapIndexXML struct {
+	XMLName  xml.Name        `xml:"sitemapindex"`
+	XMLNS    string          `xml:"xmlns,attr"`
+	Sitemaps []sitemapRefXML `xml:"sitemap"`
+}
+
+type sitemapRefXML struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSetXML struct {
+	XMLName xml.Name        `xml:"urlset"`
+	XMLNS   string          `xml:"xmlns,attr"`
+	URLs    []sitemapRefXML `xml:"url"`
+}
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// writeXML writes an XML document with its declaration
+func writeXML(w http.ResponseWriter, doc interface{}) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		slog.Error("Failed to write sitemap", "err", err)
+	}
+}
+
+// Sitemap index endpoint, linking every sitemap file
+func (es *ExplorerServer) handleSitemapIndex(w http.ResponseWriter, r *http.Request) {
+	if es.sitemaps == nil {
+		http.Error(w, "Sitemaps are disabled", http.StatusNotFound)
+		return
+	}
+
+	names, generatedAt := es.sitemaps.fileNames()
+	if generatedAt.IsZero() {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "Sitemaps are still being generated", http.StatusServiceUnavailable)
+		return
+	}
+
+	origin := es.config.publicURL(r)
+	index := sitemapIndexXML{XMLNS: sitemapNamespace}
+	for _, name := range names {
+		index.Sitemaps = append(index.Sitemaps, sitemapRefXML{
+			Loc:     origin + "/sitemaps/" + name,
+			LastMod: generatedAt.Format(time.RFC3339),
+		})
+	}
+	writeXML(w, index)
+}
+
+// Sitemap file endpoint, e.g. /sitemaps/blocks-1.xml
+func (es *ExplorerServer) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	if es.sitemaps == nil {
+		http.Error(w, "Sitemaps are disabled", http.StatusNotFound)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	dash := strings.LastIndex(name, "-")
+	number, err := strconv.Atoi(name[dash+1:])
+	if dash < 0 || err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	entries, ok := es.sitemaps.file(name[:dash], number)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	origin := es.config.publicURL(r)
+	urlset := sitemapURLSetXML{XMLNS: sitemapNamespace, URLs: make([]sitemapRefXML, len(entries))}
+	for i, entry := range entries {
+		urlset.URLs[i].Loc = origin + entry.Path
+		if !entry.LastMod.IsZero() {
+			urlset.URLs[i].LastMod = entry.LastMod.UTC().Format(time.RFC3339)
+		}
+	}
+	writeXML(w, urlset)
+}
+
+// robots.txt: pages may be crawled, the API may not, and the sitemap index
+// lists the pages
+func (es *ExplorerServer) handleRobots(w http.ResponseWriter, r *http.Request) {
+	basePath := ""
+	if es.config != nil {
+		basePath = es.config.BasePath
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	// NFT images stay crawlable for the link previews that show them
+	fmt.Fprintf(w, "User-agent: *\nAllow: %s/api/v1/nft/\nDisallow: %s/api/\n", basePath, basePath)
+	if es.sitemaps != nil {
+		fmt.Fprintf(w, "Sitemap: %s/sitemap.xml\n", es.config.publicURL(r))
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestListAddressesInOrder(t *testing.T) {
+	database := newTestDatabase(t)
+	for i, address := range []string{"carol", "alice", "bob", "alice"} {
+		tx := &WalletTransaction{TxHash: string(rune('a' + i)), FromAddress: address, ToAddress: address, BlockHeight: uint64(i), Timestamp: time.Unix(int64(i), 0)}
+		if err := database.StoreTransaction(tx); err != nil {
+			t.Fatalf("Failed to store transaction: %v", err)
+		}
+	}
+
+	addresses, err := database.ListAddresses("", 2)
+	if err != nil || strings.Join(addresses, ",") != "alice,bob" {
+		t.Fatalf("Expected alice,bob, got %v, %v", addresses, err)
+	}
+	addresses, err = database.ListAddresses("bob", 2)
+	if err != nil || strings.Join(addresses, ",") != "carol" {
+		t.Errorf("Expected carol after bob, got %v, %v", addresses, err)
+	}
+}
+
+func TestSitemapsListEntities(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 0, 2)
+	if err := database.StoreToken(&TokenInfo{TokenID: "70ce", Name: "Gold", Ticker: "GOLD"}); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: "t1", ToAddress: "alice", Timestamp: time.Unix(1, 0)}); err != nil {
+		t.Fatalf("Failed to store transaction: %v", err)
+	}
+
+	es := &ExplorerServer{database: database, config: &ServerConfig{PublicURL: "https://explorer.example.com"}}
+	rec := httptest.NewRecorder()
+	es.handleSitemapIndex(rec, httptest.NewRequest("GET", "/sitemap.xml", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 with sitemaps disabled, got %d", rec.Code)
+	}
+
+	es.sitemaps = NewSitemapGenerator(database, time.Hour)
+	rec = httptest.NewRecorder()
+	es.handleSitemapIndex(rec, httptest.NewRequest("GET", "/sitemap.xml", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before the first generation, got %d", rec.Code)
+	}
+
+	es.sitemaps.Generate()
+	rec = httptest.NewRecorder()
+	es.handleSitemapIndex(rec, httptest.NewRequest("GET", "/sitemap.xml", nil))
+	for _, name := range []string{"pages-1", "blocks-1", "tokens-1", "addresses-1"} {
+		if !strings.Contains(rec.Body.String(), "<loc>https://explorer.example.com/sitemaps/"+name+".xml</loc>") {
+			t.Errorf("Index should link %s, got %s", name, rec.Body.String())
+		}
+	}
+
+	cases := map[string][]string{
+		"blocks-1":    {"https://explorer.example.com/block/hash_2", "https://explorer.example.com/block/hash_0", "<lastmod>1970-01-01T00:00:02Z</lastmod>"},
+		"tokens-1":    {"https://explorer.example.com/token/70ce"},
+		"addresses-1": {"https://explorer.example.com/wallet/alice"},
+	}
+	for name, want := range cases {
+		rec = httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/sitemaps/"+name+".xml", nil), map[string]string{"name": name})
+		es.handleSitemap(rec, req)
+		if rec.Header().Get("Content-Type") != "application/xml; charset=utf-8" {
+			t.Errorf("%s: unexpected content type %q", name, rec.Header().Get("Content-Type"))
+		}
+		for _, s := range want {
+			if !strings.Contains(rec.Body.String(), s) {
+				t.Errorf("%s should contain %s, got %s", name, s, rec.Body.String())
+			}
+		}
+	}
+
+	for _, name := range []string{"blocks-2", "nothing-1", "blocks"} {
+		rec = httptest.NewRecorder()
+		es.handleSitemap(rec, mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"name": name}))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestRobotsPointsAtSitemap(t *testing.T) {
+	es := &ExplorerServer{
+		config:   &ServerConfig{BasePath: "/explorer"},
+		sitemaps: NewSitemapGenerator(newTestDatabase(t), time.Hour),
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/robots.txt", nil)
+	req.Host = "example.com"
+	es.handleRobots(rec, req)
+	body := rec.Body.String()
+	if !strings.Contains(body, "Disallow: /explorer/api/") || !strings.Contains(body, "Sitemap: http://example.com/explorer/sitemap.xml") {
+		t.Errorf("Unexpected robots.txt %q", body)
+	}
+}
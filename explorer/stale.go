@@ -0,0 +1,76 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultStaleLag is how many blocks the index may trail the node
+	// before its data is flagged stale, unless EXPLORER_STALE_LAG says
+	// otherwise
+	defaultStaleLag = 10
+
+	// staleSyncAge is how long the index may go without catching up with
+	// the node before its data is flagged stale
+	staleSyncAge = 5 * time.Minute
+
+	staleDataHeader = "X-Data-Stale"
+)
+
+// staleLagFromEnv reads EXPLORER_STALE_LAG, in blocks
+func staleLagFromEnv() uint64 {
+	value := os.Getenv("EXPLORER_STALE_LAG")
+	if value == "" {
+		return defaultStaleLag
+	}
+	lag, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		slog.Warn("Ignoring invalid EXPLORER_STALE_LAG", "value", value, "default", defaultStaleLag)
+		return defaultStaleLag
+	}
+	return lag
+}
+
+// SyncLag describes how far the index trails the node it syncs from
+type SyncLag struct {
+	NodeHeight         uint64    `json:"node_height"`    // As of the last sync cycle; 0 if unknown
+	IndexedHeight      uint64    `json:"indexed_height"` // Updated as batches are synced
+	BehindByBlocks     uint64    `json:"behind_by_blocks"`
+	LastSuccessfulSync time.Time `json:"last_successful_sync"` // Last time the index caught up with the node
+	Stale              bool      `json:"stale"`
+}
+
+// Lag reports how far the index trails the node. It is stale when more
+// than the stale lag behind, or when it last caught up over staleSyncAge
+// ago; before the first cycle ends, the previous run's last sync counts.
+func (s *SyncService) Lag() SyncLag {
+	s.stateMu.RLock()
+	lag := SyncLag{NodeHeight: s.remoteHeight, IndexedHeight: s.currentHeight, LastSuccessfulSync: s.lastCaughtUp}
+	limit := s.staleLag
+	s.stateMu.RUnlock()
+
+	if limit == 0 {
+		limit = defaultStaleLag
+	}
+	if lag.NodeHeight > lag.IndexedHeight {
+		lag.BehindByBlocks = lag.NodeHeight - lag.IndexedHeight
+	}
+	lag.Stale = lag.BehindByBlocks > limit ||
+		(!lag.LastSuccessfulSync.IsZero() && time.Since(lag.LastSuccessfulSync) > staleSyncAge)
+	return lag
+}
+
+// staleHeader marks responses served while the index is stale with
+// X-Data-Stale: true, so API clients can warn that the data is old
+func (es *ExplorerServer) staleHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if es.syncService != nil && es.syncService.Lag().Stale {
+			w.Header().Set(staleDataHeader, "true")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
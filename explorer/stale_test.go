@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncLagFlagsStaleData(t *testing.T) {
+	node, _ := newFakeNode(t, 3)
+	database := newTestDatabase(t)
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+	svc.syncOnce()
+
+	lag := svc.Lag()
+	if lag.NodeHeight != 3 || lag.IndexedHeight != 3 || lag.BehindByBlocks != 0 || lag.Stale ||
+		time.Since(lag.LastSuccessfulSync) > time.Minute {
+		t.Fatalf("Expected a caught-up index, got %+v", lag)
+	}
+
+	es := &ExplorerServer{database: database, syncService: svc}
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		es.staleHeader(http.HandlerFunc(es.handleStats)).ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/stats", nil))
+		return rec
+	}
+	if rec := get(); rec.Header().Get(staleDataHeader) != "" {
+		t.Errorf("Expected no stale header while caught up, got %v", rec.Header())
+	}
+
+	// The node moved well ahead
+	svc.stateMu.Lock()
+	svc.remoteHeight = 3 + defaultStaleLag + 1
+	svc.stateMu.Unlock()
+	rec := get()
+	var stats map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if rec.Header().Get(staleDataHeader) != "true" || stats["behind_by_blocks"] != float64(defaultStaleLag+1) ||
+		stats["node_height"] != float64(14) || stats["indexed_height"] != float64(3) || stats["stale"] != true {
+		t.Errorf("Expected stale stats, got %v %v", rec.Header(), stats)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleHome(rec, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rec.Body.String(), "Data may be out of date: the index is 11 blocks behind") {
+		t.Error("Expected the stale banner on pages")
+	}
+
+	// Caught up by height but not heard from the node in a while
+	svc.stateMu.Lock()
+	svc.remoteHeight = 3
+	svc.lastCaughtUp = time.Now().Add(-staleSyncAge - time.Minute)
+	svc.stateMu.Unlock()
+	if lag := svc.Lag(); !lag.Stale || lag.BehindByBlocks != 0 {
+		t.Errorf("Expected an old sync flagged stale, got %+v", lag)
+	}
+}
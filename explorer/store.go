@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Store is the explorer index: what the sync service writes and the HTTP
+// handlers read. Database keeps it in Badger, PostgresStore in PostgreSQL.
+type Store interface {
+	Feed() *FeedBroker
+	Close() error
+
+	// Health
+	Probe() error
+	Reopen() error
+	DiskUsage() (int64, error)
+
+	// Blocks and sync state
+	StoreBlock(blockHash string, block *Block) error
+	GetBlock(blockHash string) (*Block, error)
+	GetBlockByHeight(height uint64) (*Block, error)
+	GetBlockRange(from, to uint64) ([]BlockWithHash, error)
+	GetLatestHeight() (uint64, error)
+	GetLatestBlock() (*LatestBlock, error)
+	GetBlocks(page, perPage int) (*PaginatedBlocks, error)
+	GetBlocksByCursor(cursor string, limit int) (*PaginatedBlocks, error)
+	GetBlockCount() (int64, error)
+	SetLastSyncTime(t time.Time) error
+	GetLastSyncTime() (time.Time, error)
+	SetNodeHash(height uint64, hash string) error
+	GetNodeHash(height uint64) (string, error)
+	RollbackToHeight(height uint64) (*RollbackSummary, error)
+	RollbackIndex(scope indexScope, height uint64) (*RollbackSummary, error)
+	StoreBlockAlert(alert *BlockAlert) error
+	GetBlockAlert(height uint64) (*BlockAlert, error)
+	GetBlockAlerts(limit int) (*BlockAlerts, error)
+	GetOrphanBlock(hash string) (*OrphanBlock, error)
+	GetOrphanBlocks(limit int) (*OrphanBlocks, error)
+	ResetDatabase() error
+
+	// Wallets
+	StoreTransaction(tx *WalletTransaction) error
+	GetTransaction(txHash string) (*WalletTransaction, error)
+	GetMinedBlocks(address string, page, perPage int) (*PaginatedMinedBlocks, error)
+	GetFarmerBlocks(address string) ([]MinedBlock, error)
+	GetMinedBlocksSince(since time.Time) ([]MinedBlock, error)
+	GetWalletTransactions(address string, limit int) ([]WalletTransaction, error)
+	GetWalletTransactionsPage(address, cursor string, page, limit int) (*PaginatedTransactions, error)
+	GetWalletTransactionsAfter(address string, height uint64, limit int) ([]WalletTransaction, error)
+	EachWalletTransaction(address string, fn func(tx *WalletTransaction) error) error
+	GetWalletSummary(address string) (*WalletSummary, error)
+	GetAllWallets(limit int, offset int) ([]WalletOverview, int64, error)
+	GetWalletsByCursor(cursor string, limit int) (*PaginatedWallets, error)
+	ListAddresses(after string, limit int) ([]string, error)
+	GetWalletTokenBalances(address string) ([]TokenBalance, error)
+	GetBalanceDeltas(address string) ([]BalanceDelta, error)
+	CheckSupply() (*SupplyCheck, error)
+	GetRichList(limit int) (*RichList, error)
+	GetBalanceDistribution() (*BalanceDistribution, error)
+
+	// Tokens
+	StoreToken(token *TokenInfo) error
+	GetTokens(page, perPage int, search string) (*PaginatedTokens, error)
+	GetTokensByCursor(search, cursor string, limit int) (*PaginatedTokens, error)
+	GetTrendingTokens(since time.Time, limit int) ([]TrendingToken, error)
+	GetTokenAggregates() (*TokenAggregates, error)
+	GetToken(tokenID string) (*TokenInfo, error)
+	GetTokenDetails(tokenID string) (*TokenDetails, error)
+	GetTokenTransactions(tokenID string, limit int) ([]TokenTransaction, error)
+	GetTokenTransfers(tokenID string, filter TokenTransferFilter, page, perPage int) (*PaginatedTokenTransfers, error)
+	GetTokenHolders(tokenID string, limit int) ([]TokenHolder, error)
+	GetTokenHoldersPage(tokenID string, page, perPage int) (*PaginatedTokenHolders, error)
+	GetTokenBalance(tokenID, address string) (uint64, error)
+	CountTokenHolders(tokenID string) (int, error)
+	StoreTokenTransaction(tokenID string, tx *TokenTransaction) error
+	GetTokenActivity(address string) ([]TokenActivity, error)
+	UpdateTokenHolder(tokenID, address string, balance uint64) error
+	StoreMelt(melt *MeltEvent) error
+	GetTokenMelts(tokenID string, page, perPage int) (*PaginatedTokenMelts, error)
+	GetTokenSupply(tokenID string) (circulating, melted uint64, err error)
+
+	// NFT metadata resolved from token URIs
+	StoreNFTMetadata(meta *NFTMetadata) error
+	GetNFTMetadata(tokenID string) (*NFTMetadata, error)
+	DueNFTMetadata(now time.Time, limit int) ([]NFTMetadata, error)
+	ListNFTMetadata(creator string) ([]NFTMetadata, error)
+	StoreNFTImage(tokenID, contentType string, image []byte) error
+	GetNFTImage(tokenID string) (string, []byte, error)
+
+	// Pools
+	StorePool(pool *LiquidityPool) error
+	GetPools(page, perPage int, search string) (*PaginatedPools, error)
+	GetPoolsByCursor(search, cursor string, limit int) (*PaginatedPools, error)
+	GetPoolsByToken(token string, page, perPage int) (*PaginatedPools, error)
+	GetPool(poolID string) (*LiquidityPool, error)
+	GetPoolByAddress(address string) (*LiquidityPool, error)
+	GetPoolDetails(poolID string) (*PoolDetails, error)
+	GetPoolTransactions(poolID string, limit int) ([]PoolTransaction, error)
+	StorePoolTransaction(poolID string, tx *PoolTransaction) error
+	GetPoolSwaps(poolID string, from, to time.Time) ([]PoolTransaction, error)
+	GetPoolActivity(pool *LiquidityPool, now time.Time) (PoolActivity, error)
+
+	// API keys
+	StoreAPIKey(key *APIKey) error
+	GetAPIKey(hash string) (*APIKey, error)
+	ListAPIKeys() ([]APIKey, error)
+	DeleteAPIKey(id string) error
+
+	// Admin audit log
+	AppendAuditEntry(entry *AuditEntry) error
+	GetAuditLog(limit int) ([]AuditEntry, error)
+
+	// Background job records
+	StoreJob(job *Job) error
+	GetJob(id string) (*Job, error)
+	ListJobs(limit int) ([]Job, error)
+	PruneJobs(keep int) error
+
+	// Address labels
+	StoreAddressLabel(label *AddressLabel) error
+	GetAddressLabels(addresses []string) (map[string]AddressLabel, error)
+	ListAddressLabels() ([]AddressLabel, error)
+	DeleteAddressLabel(address string) error
+
+	// Watchlist subscriptions and their delivery queue
+	StoreWatchSubscription(sub *WatchSubscription) error
+	GetWatchSubscription(id string) (*WatchSubscription, error)
+	GetWatchSubscriptions(address string) ([]WatchSubscription, error)
+	CountWatchSubscriptions(owner string) (int, error)
+	DeleteWatchSubscription(id string) error
+	QueueWatchNotification(n *WatchNotification) error
+	DueWatchNotifications(now time.Time, limit int) ([]WatchNotification, error)
+	DeleteWatchNotification(id string) error
+
+	// Analytics and search
+	GetDailyStats(days int, now time.Time) ([]DailyStats, error)
+	GetAddressGrowth(days int, now time.Time) ([]AddressGrowthPoint, error)
+	RecordChartSample(sample *ChartSample) error
+	GetChartRollups(granularity string, from, to time.Time) ([]ChartRollup, error)
+	Search(query string) (*SearchResult, error)
+}
+
+var (
+	_ Store = (*Database)(nil)
+	_ Store = (*PostgresStore)(nil)
+)
+
+// errNotFound is returned by every store for a record that does not exist.
+// It is Badger's sentinel so existing checks against it work for both.
+var errNotFound = badger.ErrKeyNotFound
+
+const defaultBadgerPath = "./explorer_data"
+
+// openStoreFromEnv opens the backend selected by EXPLORER_DB_BACKEND:
+// "badger" (the default, at EXPLORER_DB_PATH) or "postgres" (at
+// EXPLORER_POSTGRES_DSN). A Badger store that cannot be opened starts
+// degraded and is retried by the health monitor.
+func openStoreFromEnv() (Store, error) {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("EXPLORER_DB_BACKEND")))
+	switch backend {
+	case "", "badger":
+		dbPath := os.Getenv("EXPLORER_DB_PATH")
+		if dbPath == "" {
+			dbPath = defaultBadgerPath
+		}
+		return openDatabase(dbPath), nil
+
+	case "postgres", "postgresql":
+		dsn := os.Getenv("EXPLORER_POSTGRES_DSN")
+		if dsn == "" {
+			return nil, fmt.Errorf("EXPLORER_POSTGRES_DSN is required for the postgres backend")
+		}
+		return NewPostgresStore(dsn)
+	}
+	return nil, fmt.Errorf("unknown EXPLORER_DB_BACKEND %q (want badger or postgres)", backend)
+}
@@ -5,41 +5,121 @@ import (
     "encoding/base64"
     "encoding/hex"
     "encoding/json"
+    "errors"
     "fmt"
-    "log"
-    "net/http"
+    "log/slog"
     "strconv"
+    "sync"
     "time"
 )
 
 // SyncService handles background synchronization with the Shadowy node
 type SyncService struct {
-    nodeURL  string
-    database *Database
-    client   *http.Client
+    nodes    *NodePool // Nodes to sync from, failed over between
+    database Store
+    client   *NodeClient // Shared with the pool, mempool tracker and probes
     stopCh   chan struct{}
+    syncCh   chan struct{} // Requests an immediate sync cycle
+
+    // syncMu is held for a whole sync cycle, and by anything that rewrites
+    // synced data (reset), so they never interleave
+    syncMu sync.Mutex
+
+    stateMu       sync.RWMutex
+    running       bool
+    paused        bool
+    syncing       bool
+    currentHeight uint64
+    remoteHeight  uint64
+    lastError     string
+    lastErrorTime time.Time
+    lastSync      time.Time
+    lastCaughtUp  time.Time // Last cycle that left the index at the node's height
+    lastReorg     *RollbackSummary
+    errorCount    uint64
+    reorgCount    uint64
+
+    // Tracker whose cached netspace is sampled once per sync cycle; nil
+    // disables it. The sample is only touched while syncMu is held.
+    tracker    *TrackerClient
+    netspace   uint64
+    netspaceAt time.Time
+
+    // Pending transactions promoted as their blocks are indexed; optional
+    mempool *MempoolTracker
+
+    // Notified of transactions touching watched addresses; optional
+    watchlist *Watchlist
+
+    // Told of newly created tokens so NFT metadata gets resolved; optional
+    nfts *NFTResolver
+
+    // Verify blocks before storing them, flagging any that fail
+    verify bool
+
+    // Block size limit recorded with each block for the fullness chart;
+    // 0 means defaultMaxBlockSize
+    maxBlockSize int
+
+    // Latest reindex of stored blocks, guarded by stateMu; nil if none ran
+    reindex *ReindexStatus
+
+    // Runs reindexes as jobs; when nil they run on their own goroutine
+    jobs *JobQueue
+
+    // Blocks the index may trail the node before its data is flagged
+    // stale; 0 means defaultStaleLag
+    staleLag uint64
 }
 
-// NewSyncService creates a new sync service
-func NewSyncService(nodeURL string, database *Database) *SyncService {
+// SyncStatus reports the state of the sync service for operators
+type SyncStatus struct {
+    Running       bool      `json:"running"`
+    Paused        bool      `json:"paused"`
+    Syncing       bool      `json:"syncing"`
+    CurrentHeight uint64    `json:"current_height"`
+    RemoteHeight  uint64    `json:"remote_height"`
+    LastError     string    `json:"last_error,omitempty"`
+    LastErrorTime time.Time `json:"last_error_time,omitempty"`
+    LastSync      time.Time `json:"last_sync,omitempty"`
+    LastReorg     *RollbackSummary `json:"last_reorg,omitempty"` // Most recent rollback to a common ancestor
+    ErrorCount    uint64    `json:"error_count"`                 // Failures since start
+    ReorgCount    uint64    `json:"reorg_count"`                 // Rollbacks since start
+    Node          string       `json:"node"`  // Node currently synced from
+    Nodes         []NodeHealth `json:"nodes"` // Latest health check of every node
+    Reindex       *ReindexStatus `json:"reindex,omitempty"` // Latest reindex, running or finished
+}
+
+// NewSyncService creates a new sync service reading from the nodes in
+// nodeURLs, a comma-separated list, through client
+func NewSyncService(nodeURLs string, client *NodeClient, database Store) *SyncService {
     return &SyncService{
-        nodeURL:  nodeURL,
+        nodes:    NewNodePool(nodeURLs, client),
         database: database,
-        client: &http.Client{
-            Timeout: 30 * time.Second,
-        },
-        stopCh: make(chan struct{}),
+        client:   client,
+        stopCh:   make(chan struct{}),
+        syncCh:   make(chan struct{}, 1),
     }
 }
 
 // Start begins the background synchronization
 func (s *SyncService) Start() {
-    log.Printf("🔄 Starting background sync service...")
+    slog.Info("Starting background sync service")
+
+    // Until a cycle catches up, the data is as fresh as the last run left it
+    lastSync, err := s.database.GetLastSyncTime()
+
+    s.stateMu.Lock()
+    s.running = true
+    if err == nil && s.lastCaughtUp.IsZero() {
+        s.lastCaughtUp = lastSync
+    }
+    s.stateMu.Unlock()
 
     // Initial sync
     go s.syncOnce()
 
-    // Periodic sync every minute
+    // Periodic sync every minute, or sooner when requested
     go func() {
         ticker := time.NewTicker(1 * time.Minute)
         defer ticker.Stop()
@@ -48,8 +128,13 @@ func (s *SyncService) Start() {
             select {
             case <-ticker.C:
                 s.syncOnce()
+            case <-s.syncCh:
+                s.syncOnce()
             case <-s.stopCh:
-                log.Printf("🛑 Sync service stopped")
+                s.stateMu.Lock()
+                s.running = false
+                s.stateMu.Unlock()
+                slog.Info("Sync service stopped")
                 return
             }
         }
@@ -61,35 +146,177 @@ func (s *SyncService) Stop() {
     close(s.stopCh)
 }
 
+// Pause stops ingesting blocks until Resume is called. A cycle already in
+// progress stops after its current batch.
+func (s *SyncService) Pause() {
+    s.stateMu.Lock()
+    s.paused = true
+    s.stateMu.Unlock()
+    slog.Info("Sync service paused")
+}
+
+// Resume restarts ingestion and triggers an immediate sync cycle
+func (s *SyncService) Resume() {
+    s.stateMu.Lock()
+    s.paused = false
+    s.stateMu.Unlock()
+    slog.Info("Sync service resumed")
+    s.TriggerSync()
+}
+
+// TriggerSync asks the background loop to run a sync cycle now
+func (s *SyncService) TriggerSync() {
+    select {
+    case s.syncCh <- struct{}{}:
+    default:
+        // A cycle is already queued
+    }
+}
+
+// Reset wipes all synced data, waiting for any in-flight cycle to finish first
+func (s *SyncService) Reset() error {
+    s.syncMu.Lock()
+    defer s.syncMu.Unlock()
+
+    if err := s.database.ResetDatabase(); err != nil {
+        return err
+    }
+
+    s.stateMu.Lock()
+    s.currentHeight = 0
+    s.stateMu.Unlock()
+    return nil
+}
+
+// Resync forces an immediate sync cycle, optionally wiping synced data first
+// so the chain is re-ingested from genesis
+func (s *SyncService) Resync(reset bool) error {
+    if reset {
+        if err := s.Reset(); err != nil {
+            return err
+        }
+    }
+    s.TriggerSync()
+    return nil
+}
+
+// Status returns a snapshot of the sync service state
+func (s *SyncService) Status() SyncStatus {
+    s.stateMu.RLock()
+    defer s.stateMu.RUnlock()
+    var reindex *ReindexStatus
+    if s.reindex != nil {
+        copied := *s.reindex
+        reindex = &copied
+    }
+    return SyncStatus{
+        Running:       s.running,
+        Paused:        s.paused,
+        Syncing:       s.syncing,
+        CurrentHeight: s.currentHeight,
+        RemoteHeight:  s.remoteHeight,
+        LastError:     s.lastError,
+        LastErrorTime: s.lastErrorTime,
+        LastSync:      s.lastSync,
+        LastReorg:     s.lastReorg,
+        ErrorCount:    s.errorCount,
+        ReorgCount:    s.reorgCount,
+        Node:          s.nodes.Current(),
+        Nodes:         s.nodes.Health(),
+        Reindex:       reindex,
+    }
+}
+
+// IsPaused reports whether ingestion is paused
+func (s *SyncService) IsPaused() bool {
+    s.stateMu.RLock()
+    defer s.stateMu.RUnlock()
+    return s.paused
+}
+
+// recordError remembers the most recent sync failure for the status endpoint
+func (s *SyncService) recordError(err error) {
+    s.stateMu.Lock()
+    s.lastError = err.Error()
+    s.lastErrorTime = time.Now()
+    s.errorCount++
+    s.stateMu.Unlock()
+}
+
 // syncOnce performs a single synchronization cycle
 func (s *SyncService) syncOnce() {
-    log.Printf("🔄 Syncing with Shadowy node...")
+    if s.IsPaused() {
+        slog.Debug("Sync paused, skipping cycle")
+        return
+    }
+
+    s.syncMu.Lock()
+    defer s.syncMu.Unlock()
+
+    s.stateMu.Lock()
+    s.syncing = true
+    s.stateMu.Unlock()
+    defer func() {
+        s.stateMu.Lock()
+        s.syncing = false
+        s.stateMu.Unlock()
+    }()
+
+    slog.Debug("Syncing with Shadowy node")
 
     // Get blockchain stats from the node
     stats, err := s.getBlockchainStats()
     if err != nil {
-        log.Printf("❌ Failed to get blockchain stats: %v", err)
+        slog.Error("Failed to get blockchain stats", "err", err)
+        s.recordError(err)
         return
     }
 
     // Get our latest height
     localHeight, err := s.database.GetLatestHeight()
     if err != nil {
-        log.Printf("❌ Failed to get local height: %v", err)
+        slog.Error("Failed to get local height", "err", err)
+        s.recordError(err)
         return
     }
 
-    log.Printf("📊 Local height: %d, Remote height: %d", localHeight, stats.TipHeight)
+    s.stateMu.Lock()
+    s.currentHeight = localHeight
+    s.remoteHeight = stats.TipHeight
+    s.stateMu.Unlock()
+
+    // Roll back to the last block we share with the node if our tip was replaced
+    localHeight, err = s.reconcileTip(localHeight, stats.TipHeight)
+    if err != nil {
+        slog.Error("Failed to reconcile chain tip", "err", err)
+        s.recordError(err)
+        return
+    }
+
+    slog.Debug("Comparing heights", "local", localHeight, "remote", stats.TipHeight)
 
     // Sync missing blocks
     if stats.TipHeight > localHeight {
+        s.sampleNetspace()
         s.syncBlocks(localHeight+1, stats.TipHeight)
     }
 
     // Update last sync time
-    s.database.SetLastSyncTime(time.Now())
+    now := time.Now()
+    s.database.SetLastSyncTime(now)
+    indexed, err := s.database.GetLatestHeight()
+
+    s.stateMu.Lock()
+    s.lastSync = now
+    if err == nil {
+        s.currentHeight = indexed
+        if indexed >= stats.TipHeight {
+            s.lastCaughtUp = now
+        }
+    }
+    s.stateMu.Unlock()
 
-    log.Printf("✅ Sync completed")
+    slog.Debug("Sync completed")
 }
 
 // BlockchainStats represents the stats from the Tendermint node
@@ -111,11 +338,18 @@ type TendermintStatusResponse struct {
 // TendermintBlockResponse represents Tendermint /block response
 type TendermintBlockResponse struct {
     Result struct {
+        BlockID struct {
+            Hash string `json:"hash"`
+        } `json:"block_id"`
         Block struct {
             Header struct {
                 Height string    `json:"height"`
                 Time   time.Time `json:"time"`
                 ChainID string   `json:"chain_id"`
+                DataHash string  `json:"data_hash"` // Merkle root of the raw transactions
+                LastBlockID struct {
+                    Hash string `json:"hash"`
+                } `json:"last_block_id"`
             } `json:"header"`
             Data struct {
                 Txs []string `json:"txs"` // Base64 encoded transactions
@@ -134,16 +368,18 @@ func (s *SyncService) convertTendermintBlock(tmResp *TendermintBlockResponse) (*
 
     // Convert transactions from base64
     var transactions []*SignedTransaction
+    var rawTxs [][]byte
     for _, txB64 := range tmResp.Result.Block.Data.Txs {
         txBytes, err := base64.StdEncoding.DecodeString(txB64)
         if err != nil {
-            log.Printf("❌ Failed to decode transaction from base64: %v", err)
+            slog.Error("Failed to decode transaction from base64", "err", err)
             continue
         }
+        rawTxs = append(rawTxs, txBytes)
 
         var signedTx SignedTransaction
         if err := json.Unmarshal(txBytes, &signedTx); err != nil {
-            log.Printf("❌ Failed to unmarshal signed transaction: %v", err)
+            slog.Error("Failed to unmarshal signed transaction", "err", err)
             continue
         }
 
@@ -160,8 +396,8 @@ func (s *SyncService) convertTendermintBlock(tmResp *TendermintBlockResponse) (*
     block := &Block{
         Header: BlockHeader{
             Version:           1,
-            PreviousBlockHash: "", // Will be filled by hash calculation if needed
-            MerkleRoot:        "", // Will be calculated
+            PreviousBlockHash: tmResp.Result.Block.Header.LastBlockID.Hash, // Node's hash of the parent
+            MerkleRoot:        hex.EncodeToString(merkleRoot(rawTxs)),
             Timestamp:         tmResp.Result.Block.Header.Time,
             Height:            height,
             Nonce:             0,  // Not used in Tendermint
@@ -188,57 +424,46 @@ func (s *SyncService) convertTendermintBlock(tmResp *TendermintBlockResponse) (*
     return block, nil
 }
 
-// getBlockchainStats fetches blockchain statistics from Tendermint
+// getBlockchainStats health-checks every node and returns the chain stats
+// of the one now synced from, the healthy node with the highest height
 func (s *SyncService) getBlockchainStats() (*BlockchainStats, error) {
-    theURL := fmt.Sprintf("%s/status", s.nodeURL)
-    resp, err := s.client.Get(theURL)
-    if err != nil {
-        return nil, fmt.Errorf("failed to fetch stats: %w", err)
-    }
-    defer resp.Body.Close()
-
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("%s returned status %d", theURL, resp.StatusCode)
-    }
-
-    var tendermintResp TendermintStatusResponse
-    if err := json.NewDecoder(resp.Body).Decode(&tendermintResp); err != nil {
-        return nil, fmt.Errorf("failed to decode Tendermint status: %w", err)
-    }
-
-    // Parse height from string
-    height, err := strconv.ParseUint(tendermintResp.Result.SyncInfo.LatestBlockHeight, 10, 64)
-    if err != nil {
-        return nil, fmt.Errorf("failed to parse height: %w", err)
-    }
-
-    stats := &BlockchainStats{
-        TipHeight: height,
-        TipHash:   tendermintResp.Result.SyncInfo.LatestBlockHash,
-    }
-
-    return stats, nil
+    return s.nodes.CheckAll()
 }
 
 // syncBlocks syncs blocks from startHeight to endHeight
 func (s *SyncService) syncBlocks(startHeight, endHeight uint64) {
-    log.Printf("📥 Syncing blocks %d to %d", startHeight, endHeight)
+    slog.Info("Syncing blocks", "from", startHeight, "to", endHeight)
 
     // Sync in batches to avoid overwhelming the node
     batchSize := uint64(10)
 
     for height := startHeight; height <= endHeight; height += batchSize {
+        if s.IsPaused() {
+            slog.Info("Sync paused", "height", height-1)
+            return
+        }
+
         endBatch := height + batchSize - 1
         if endBatch > endHeight {
             endBatch = endHeight
         }
 
         if err := s.syncBlockBatch(height, endBatch); err != nil {
-            log.Printf("❌ Failed to sync batch %d-%d: %v", height, endBatch, err)
+            if errors.Is(err, errReorgDetected) {
+                // Roll back on the next cycle, before ingesting anything else
+                slog.Warn("Reconciling chain tip", "err", err)
+                s.TriggerSync()
+                return
+            }
+            slog.Error("Failed to sync batch", "from", height, "to", endBatch, "err", err)
+            s.recordError(err)
             continue
         }
 
-        log.Printf("✅ Synced blocks %d-%d", height, endBatch)
+        slog.Info("Synced blocks", "from", height, "to", endBatch)
+        s.stateMu.Lock()
+        s.currentHeight = endBatch
+        s.stateMu.Unlock()
 
         // Small delay to be nice to the node
         time.Sleep(100 * time.Millisecond)
@@ -255,44 +480,88 @@ func (s *SyncService) syncBlockBatch(startHeight, endHeight uint64) error {
     return nil
 }
 
+// fetchBlock gets the block at height from Tendermint, failing over to
+// the other nodes when the current one cannot serve it
+func (s *SyncService) fetchBlock(height uint64) (*TendermintBlockResponse, error) {
+    for {
+        nodeURL := s.nodes.Current()
+        if nodeURL == "" {
+            return nil, fmt.Errorf("no nodes configured")
+        }
+        block, err := s.client.Block(nodeURL, height)
+        if err == nil {
+            return block, nil
+        }
+        if !s.nodes.MarkFailed(nodeURL, err) {
+            return nil, err
+        }
+    }
+}
+
 // syncBlock syncs a single block from Tendermint
 func (s *SyncService) syncBlock(height uint64) error {
-    // Get block from Tendermint
-    resp, err := s.client.Get(fmt.Sprintf("%s/block?height=%d", s.nodeURL, height))
+    tmBlockResp, err := s.fetchBlock(height)
     if err != nil {
-        return fmt.Errorf("failed to fetch block: %w", err)
-    }
-    defer resp.Body.Close()
-
-    if resp.StatusCode != http.StatusOK {
-        return fmt.Errorf("Tendermint returned status %d", resp.StatusCode)
-    }
-
-    var tmBlockResp TendermintBlockResponse
-    if err := json.NewDecoder(resp.Body).Decode(&tmBlockResp); err != nil {
-        return fmt.Errorf("failed to decode Tendermint block: %w", err)
+        return err
     }
 
     // Convert Tendermint block to our Block format
-    block, err := s.convertTendermintBlock(&tmBlockResp)
+    block, err := s.convertTendermintBlock(tmBlockResp)
     if err != nil {
         return fmt.Errorf("failed to convert Tendermint block: %w", err)
     }
 
+    // The block must build on the one we hold below it
+    var parentHash string
+    if height > 1 {
+        parentHash, err = s.database.GetNodeHash(height - 1)
+        if err != nil {
+            return err
+        }
+        if block.Header.PreviousBlockHash != "" && parentHash != "" && parentHash != block.Header.PreviousBlockHash {
+            return fmt.Errorf("%w at height %d", errReorgDetected, height)
+        }
+    }
+
     // Calculate block hash from Tendermint data
     blockHash := s.calculateBlockHash(block)
 
+    // Flag, rather than silently index, a block that fails verification
+    if s.verify {
+        if problems := verifyBlock(tmBlockResp, block, height, parentHash); len(problems) > 0 {
+            s.flagBlock(blockHash, tmBlockResp.Result.BlockID.Hash, block, problems)
+        }
+    }
+
     // Store in database
     if err := s.database.StoreBlock(blockHash, block); err != nil {
         return fmt.Errorf("failed to store block: %w", err)
     }
+    if nodeHash := tmBlockResp.Result.BlockID.Hash; nodeHash != "" {
+        if err := s.database.SetNodeHash(height, nodeHash); err != nil {
+            return fmt.Errorf("failed to store node hash: %w", err)
+        }
+    }
     
     // Extract and store individual transactions
-    if err := s.extractAndStoreTransactions(blockHash, block); err != nil {
-        log.Printf("❌ Failed to extract transactions from block %d: %v", block.Header.Height, err)
+    stored, err := s.extractAndStoreTransactions(blockHash, block, indexAll)
+    if err != nil {
+        slog.Error("Failed to extract transactions", "height", block.Header.Height, "err", err)
         // Don't fail the entire sync for transaction parsing errors
     }
 
+    s.mempool.Confirm(block)
+    s.watchlist.Notify(block, stored)
+
+    // Fold the block into the hourly and daily chart rollups
+    if err := s.database.RecordChartSample(s.chartSample(block, stored)); err != nil {
+        slog.Error("Failed to update chart rollups", "height", block.Header.Height, "err", err)
+    }
+
+    s.stateMu.Lock()
+    s.currentHeight = block.Header.Height
+    s.stateMu.Unlock()
+
     return nil
 }
 
@@ -324,25 +593,41 @@ func (s *SyncService) GetNetworkStats() (*NetworkStats, error) {
     }
 
     syncStatus := "active"
-    if time.Since(lastSync) > 5*time.Minute {
+    if time.Since(lastSync) > staleSyncAge {
         syncStatus = "stale"
     }
 
+    orphans, err := s.database.GetOrphanBlocks(0)
+    if err != nil {
+        return nil, err
+    }
+
     return &NetworkStats{
         Height:      localHeight,
         TotalBlocks: totalBlocks,
         LastSync:    lastSync,
         SyncStatus:  syncStatus,
-        NodeURL:     s.nodeURL,
+        NodeURL:     s.nodes.Current(),
+        OrphanCount: orphans.Total,
+        SyncLag:     s.Lag(),
     }, nil
 }
 
-// extractAndStoreTransactions parses and stores individual transactions from a block
-func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block) error {
-    log.Printf("📦 Block %d: Processing %d transactions", block.Header.Height, len(block.Body.Transactions))
+// extractAndStoreTransactions parses and stores individual transactions from
+// a block into the index families in scope, returning the wallet
+// transactions it stored
+func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block, scope indexScope) ([]*WalletTransaction, error) {
+    var stored []*WalletTransaction
+    poolsTouched := false
+    slog.Debug("Processing block transactions", "height", block.Header.Height, "transactions", len(block.Body.Transactions))
     for _, signedTx := range block.Body.Transactions {
         // Handle special case for coinbase transactions
         if signedTx.Algorithm == "coinbase" {
+            // Mining rewards only feed the wallet index
+            if scope&indexWallets == 0 {
+                continue
+            }
+
             // For coinbase transactions, the Transaction field is base64-encoded JSON
             // First, handle the json.RawMessage - it might be a quoted JSON string
             transactionStr := string(signedTx.Transaction)
@@ -351,7 +636,7 @@ func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block
             if transactionStr[0] == '"' && transactionStr[len(transactionStr)-1] == '"' {
                 var quotedStr string
                 if err := json.Unmarshal(signedTx.Transaction, &quotedStr); err != nil {
-                    log.Printf("❌ Failed to unmarshal quoted transaction: %v", err)
+                    slog.Error("Failed to unmarshal quoted transaction", "err", err)
                     continue
                 }
                 transactionStr = quotedStr
@@ -364,18 +649,18 @@ func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block
                 actualTxHash = fmt.Sprintf("coinbase_%s", blockHash)
             }
 
-            log.Printf("🔍 Debug coinbase: TxHash='%s' -> '%s', Algorithm='%s', Transaction='%s'",
-                signedTx.TxHash, actualTxHash, signedTx.Algorithm, transactionStr[:100])
+            slog.Debug("Decoding coinbase", "tx_hash", signedTx.TxHash, "hash", actualTxHash,
+                "algorithm", signedTx.Algorithm, "transaction", transactionStr[:100])
 
             txBytes, err := base64.StdEncoding.DecodeString(transactionStr)
             if err != nil {
-                log.Printf("❌ Failed to decode base64 transaction %s: %v", actualTxHash, err)
+                slog.Error("Failed to decode base64 transaction", "tx_hash", actualTxHash, "err", err)
                 continue
             }
 
             var tx Transaction
             if err := json.Unmarshal(txBytes, &tx); err != nil {
-                log.Printf("❌ Failed to parse decoded transaction %s: %v", actualTxHash, err)
+                slog.Error("Failed to parse decoded transaction", "tx_hash", actualTxHash, "err", err)
                 continue
             }
             
@@ -395,9 +680,10 @@ func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block
                     }
                     
                     if err := s.database.StoreTransaction(walletTx); err != nil {
-                        log.Printf("❌ Failed to store coinbase transaction: %v", err)
+                        slog.Error("Failed to store coinbase transaction", "err", err)
                     } else {
-                        log.Printf("💰 Stored mining reward: %.8f SHADOW to %s", float64(output.Value)/100000000.0, output.Address)
+                        stored = append(stored, walletTx)
+                        slog.Debug("Stored mining reward", "amount", output.Value, "address", output.Address)
                     }
                 }
             }
@@ -407,13 +693,20 @@ func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block
         // Parse regular (non-coinbase) transactions
         var tx Transaction
         if err := json.Unmarshal(signedTx.Transaction, &tx); err != nil {
-            log.Printf("❌ Failed to parse transaction %s: %v", signedTx.TxHash, err)
+            slog.Error("Failed to parse transaction", "tx_hash", signedTx.TxHash, "err", err)
             continue
         }
         
+        // Every wallet record of the transaction carries its token balance
+        // changes, whichever of them is kept
+        var changes []AssetChange
+        if scope&indexWallets != 0 {
+            changes = s.assetChanges(tx.TokenOps)
+        }
+        
         // Process regular transaction outputs
         for _, output := range tx.Outputs {
-            if output.Address != "" {
+            if output.Address != "" && scope&indexWallets != 0 {
                 walletTx := &WalletTransaction{
                     TxHash:      signedTx.TxHash,
                     BlockHash:   blockHash,
@@ -424,6 +717,7 @@ func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block
                     Fee:         0, // We'll calculate this below
                     FromAddress: "", // We'll try to determine this from inputs
                     ToAddress:   output.Address,
+                    AssetChanges: changes,
                 }
                 
                 // Try to determine from address from inputs
@@ -435,16 +729,18 @@ func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block
                 
                 // Store the transaction
                 if err := s.database.StoreTransaction(walletTx); err != nil {
-                    log.Printf("❌ Failed to store transaction %s: %v", signedTx.TxHash, err)
+                    slog.Error("Failed to store transaction", "tx_hash", signedTx.TxHash, "err", err)
+                } else {
+                    stored = append(stored, walletTx)
                 }
             }
         }
         
         // Process token operations
-        log.Printf("🔍 Block %d: Processing %d token operations", block.Header.Height, len(tx.TokenOps))
+        slog.Debug("Processing token operations", "height", block.Header.Height, "operations", len(tx.TokenOps))
         for i, tokenOp := range tx.TokenOps {
-            log.Printf("🪙 Token Op %d: Type=%d (%s), TokenID=%.8s, Amount=%d, From=%s, To=%s", 
-                i, int(tokenOp.Type), tokenOp.Type.String(), tokenOp.TokenID, tokenOp.Amount, tokenOp.From, tokenOp.To)
+            slog.Debug("Token operation", "index", i, "type", tokenOp.Type.String(), "token_id", tokenOp.TokenID,
+                "amount", tokenOp.Amount, "from", tokenOp.From, "to", tokenOp.To)
             
             if tokenOp.To != "" || tokenOp.From != "" {
                 walletTx := &WalletTransaction{
@@ -457,29 +753,41 @@ func (s *SyncService) extractAndStoreTransactions(blockHash string, block *Block
                     Fee:         0,
                     FromAddress: tokenOp.From,
                     ToAddress:   tokenOp.To,
-                    TokenSymbol: tokenOp.TokenID,
-                    TokenAmount: tokenOp.Amount,
+                    AssetChanges: changes,
                 }
                 
-                if err := s.database.StoreTransaction(walletTx); err != nil {
-                    log.Printf("❌ Failed to store token transaction %s: %v", signedTx.TxHash, err)
+                if scope&indexWallets != 0 {
+                    if err := s.database.StoreTransaction(walletTx); err != nil {
+                        slog.Error("Failed to store token transaction", "tx_hash", signedTx.TxHash, "err", err)
+                    } else {
+                        stored = append(stored, walletTx)
+                    }
                 }
                 
                 // Process token-specific operations
-                if err := s.processTokenOperation(blockHash, block, signedTx.TxHash, &tokenOp, tx.Timestamp); err != nil {
-                    log.Printf("❌ Failed to process token operation %s: %v", signedTx.TxHash, err)
+                if err := s.processTokenOperation(blockHash, block, signedTx.TxHash, &tokenOp, tx.Timestamp, scope); err != nil {
+                    slog.Error("Failed to process token operation", "tx_hash", signedTx.TxHash, "err", err)
+                }
+                if tokenOpScope(tokenOp.Type) == indexPools && scope&indexPools != 0 {
+                    poolsTouched = true
                 }
             }
         }
     }
 
     // Mining rewards are now processed as coinbase transactions above, so no separate mining reward needed
+
+    // Token/token pools are valued through the SHADOW pools this block moved
+    if poolsTouched {
+        s.revaluePools()
+    }
     
-    return nil
+    return stored, nil
 }
 
-// processTokenOperation handles token-specific operations and updates token records
-func (s *SyncService) processTokenOperation(blockHash string, block *Block, txHash string, tokenOp *TokenOperation, timestamp time.Time) error {
+// processTokenOperation handles token-specific operations and updates token
+// records, skipping the index families not in scope
+func (s *SyncService) processTokenOperation(blockHash string, block *Block, txHash string, tokenOp *TokenOperation, timestamp time.Time, scope indexScope) error {
     tokenID := tokenOp.TokenID
     
     // Store token transaction
@@ -494,8 +802,13 @@ func (s *SyncService) processTokenOperation(blockHash string, block *Block, txHa
         ToAddress:   tokenOp.To,
     }
     
-    if err := s.database.StoreTokenTransaction(tokenID, tokenTx); err != nil {
-        return fmt.Errorf("failed to store token transaction: %w", err)
+    if scope&indexTokens != 0 {
+        if err := s.database.StoreTokenTransaction(tokenID, tokenTx); err != nil {
+            return fmt.Errorf("failed to store token transaction: %w", err)
+        }
+    }
+    if scope&tokenOpScope(tokenOp.Type) == 0 {
+        return nil
     }
     
     // Handle different token operation types
@@ -532,12 +845,16 @@ func (s *SyncService) processTokenOperation(blockHash string, block *Block, txHa
             CirculatingSupply: tokenOp.Amount,
             MeltValue:         meltValue,
         }
+        if tokenOp.Metadata != nil {
+            token.URI = tokenOp.Metadata.URI
+        }
         
         if err := s.database.StoreToken(token); err != nil {
             return fmt.Errorf("failed to store new token: %w", err)
         }
+        s.nfts.Track(token)
         
-        log.Printf("✅ Created token: %s (%s) - ID: %.8s", token.Name, token.Ticker, token.TokenID)
+        slog.Info("Created token", "name", token.Name, "ticker", token.Ticker, "token_id", token.TokenID)
         
         // Create initial holder record
         if err := s.database.UpdateTokenHolder(tokenID, tokenOp.To, tokenOp.Amount); err != nil {
@@ -577,11 +894,21 @@ func (s *SyncService) processTokenOperation(blockHash string, block *Block, txHa
         
         // Update token statistics
         if err := s.updateTokenStats(tokenID, timestamp, "transfer"); err != nil {
-            log.Printf("❌ Failed to update token stats: %v", err)
+            slog.Error("Failed to update token stats", "err", err)
         }
         
     case TOKEN_MELT:
-        // Reduce circulating supply
+        // Record the melt with the SHADOW it released; a token not indexed
+        // has no known lock, but its melt still counts
+        token, err := s.database.GetToken(tokenID)
+        if err != nil {
+            token = &TokenInfo{TokenID: tokenID}
+        }
+        if err := s.database.StoreMelt(newMeltEvent(token, tokenTx)); err != nil {
+            return fmt.Errorf("failed to store melt: %w", err)
+        }
+        
+        // Reduce the burner's balance
         if tokenOp.From != "" {
             // Get current balance and subtract
             fromBalance, err := s.getTokenBalance(tokenID, tokenOp.From)
@@ -600,7 +927,7 @@ func (s *SyncService) processTokenOperation(blockHash string, block *Block, txHa
         
         // Update token statistics
         if err := s.updateTokenStats(tokenID, timestamp, "melt"); err != nil {
-            log.Printf("❌ Failed to update token stats: %v", err)
+            slog.Error("Failed to update token stats", "err", err)
         }
         
     case POOL_CREATE:
@@ -608,6 +935,12 @@ func (s *SyncService) processTokenOperation(blockHash string, block *Block, txHa
         if err := s.processPoolCreation(blockHash, block, txHash, tokenOp, timestamp); err != nil {
             return fmt.Errorf("failed to process pool creation: %w", err)
         }
+        
+    case POOL_SWAP:
+        // Trade through a pool, moving its price
+        if err := s.processPoolSwap(blockHash, block, txHash, tokenOp, timestamp); err != nil {
+            return fmt.Errorf("failed to process pool swap: %w", err)
+        }
     }
     
     return nil
@@ -615,18 +948,7 @@ func (s *SyncService) processTokenOperation(blockHash string, block *Block, txHa
 
 // getTokenBalance retrieves current token balance for an address
 func (s *SyncService) getTokenBalance(tokenID, address string) (uint64, error) {
-    holders, err := s.database.GetTokenHolders(tokenID, 1000) // Get more holders to find this one
-    if err != nil {
-        return 0, err
-    }
-    
-    for _, holder := range holders {
-        if holder.Address == address {
-            return holder.Balance, nil
-        }
-    }
-    
-    return 0, nil // Not found = 0 balance
+    return s.database.GetTokenBalance(tokenID, address)
 }
 
 // updateTokenStats updates token statistics
@@ -643,15 +965,19 @@ func (s *SyncService) updateTokenStats(tokenID string, timestamp time.Time, opTy
     switch opType {
     case "transfer":
         token.TransferCount++
-    case "melt":
-        // Would need to track total melted amount
     }
     
-    // Recalculate holder count (simplified)
-    holders, err := s.database.GetTokenHolders(tokenID, 1000)
-    if err == nil {
-        token.HolderCount = len(holders)
+    // Holder count is maintained with the holder index
+    if count, err := s.database.CountTokenHolders(tokenID); err == nil {
+        token.HolderCount = count
+    }
+    
+    // Supply comes from the ledger rather than counters kept on the token
+    circulating, melted, err := s.database.GetTokenSupply(tokenID)
+    if err != nil {
+        return fmt.Errorf("failed to total token supply: %w", err)
     }
+    token.CirculatingSupply, token.TotalMelted = circulating, melted
     
     return s.database.StoreToken(token)
 }
@@ -665,9 +991,21 @@ func (s *SyncService) processPoolCreation(blockHash string, block *Block, txHash
     var tokenASymbol, tokenBSymbol string = "SHADOW", "SHADOW" // Default symbols
     var reserveA, reserveB uint64 = 0, 0
     var totalLiquidity uint64 = tokenOp.Amount
+    var lAddress string
+    var feeRate uint64
     
     // Parse pool metadata if available
-    if tokenOp.Metadata != nil {
+    if tokenOp.Metadata != nil && tokenOp.Metadata.LiquidityPool != nil {
+        // Full pool definition as created on chain
+        definition := tokenOp.Metadata.LiquidityPool
+        tokenA, tokenB = definition.TokenA, definition.TokenB
+        if sameToken(tokenB, "SHADOW") {
+            tokenB = "" // Empty means SHADOW pair
+        }
+        tokenASymbol, tokenBSymbol = s.poolTokenSymbol(tokenA), s.poolTokenSymbol(tokenB)
+        reserveA, reserveB = definition.InitialRatioA, definition.InitialRatioB
+        lAddress, feeRate = definition.LAddress, definition.FeeRate
+    } else if tokenOp.Metadata != nil {
         // For pool creation, metadata might contain pool parameters
         // This is simplified - actual implementation would need to parse pool-specific metadata
         tokenA = tokenOp.Metadata.Creator // Using creator field for tokenA ID
@@ -697,11 +1035,9 @@ func (s *SyncService) processPoolCreation(blockHash string, block *Block, txHash
         reserveB = 1000000 // Default SHADOW reserve
     }
     
-    // Calculate initial TVL (simplified - in SHADOW units)
-    tvl := reserveB + (reserveA / 1000) // Very basic conversion
-    
     pool := &LiquidityPool{
         PoolID:         poolID,
+        LAddress:       lAddress,
         TokenA:         tokenA,
         TokenB:         tokenB,
         TokenASymbol:   tokenASymbol,
@@ -719,14 +1055,15 @@ func (s *SyncService) processPoolCreation(blockHash string, block *Block, txHash
         VolumeB:      0,
         LastActivity: timestamp,
         APR:          0.0,
-        TVL:          tvl,
+        FeeRate:      feeRate,
     }
+    refreshPoolValue(pool)
     
     if err := s.database.StorePool(pool); err != nil {
         return fmt.Errorf("failed to store new pool: %w", err)
     }
     
-    log.Printf("✅ Created liquidity pool: %s/%s - ID: %.8s", pool.TokenASymbol, pool.TokenBSymbol, pool.PoolID)
+    slog.Info("Created liquidity pool", "pair", pool.TokenASymbol+"/"+pool.TokenBSymbol, "pool_id", pool.PoolID)
     
     // Store pool creation transaction
     poolTx := &PoolTransaction{
@@ -747,3 +1084,60 @@ func (s *SyncService) processPoolCreation(blockHash string, block *Block, txHash
     
     return nil
 }
+
+// poolTokenSymbol returns the ticker of one side of a pool
+func (s *SyncService) poolTokenSymbol(tokenID string) string {
+    if sameToken(tokenID, "SHADOW") {
+        return "SHADOW"
+    }
+    if token, err := s.database.GetToken(tokenID); err == nil {
+        return token.Ticker
+    }
+    if len(tokenID) >= 4 {
+        return "TKN" + tokenID[:4] // Fallback
+    }
+    return tokenID
+}
+
+// processPoolSwap applies a POOL_SWAP to its pool's reserves and records
+// the trade with the price move it caused
+func (s *SyncService) processPoolSwap(blockHash string, block *Block, txHash string, tokenOp *TokenOperation, timestamp time.Time) error {
+    poolAddress, inputToken, swapper := tokenOp.To, tokenOp.TokenID, tokenOp.From
+    if tokenOp.Metadata != nil && tokenOp.Metadata.PoolSwap != nil {
+        swap := tokenOp.Metadata.PoolSwap
+        if swap.PoolLAddress != "" {
+            poolAddress = swap.PoolLAddress
+        }
+        if swap.InputTokenID != "" {
+            inputToken = swap.InputTokenID
+        }
+        if swap.SwapperAddress != "" {
+            swapper = swap.SwapperAddress
+        }
+    }
+    
+    pool, err := s.database.GetPoolByAddress(poolAddress)
+    if err != nil {
+        return fmt.Errorf("no pool at %s: %w", poolAddress, err)
+    }
+    
+    poolTx, err := applyPoolSwap(pool, inputToken, tokenOp.Amount, timestamp)
+    if err != nil {
+        return err
+    }
+    poolTx.TxHash = txHash
+    poolTx.BlockHash = blockHash
+    poolTx.BlockHeight = block.Header.Height
+    poolTx.Address = swapper
+    
+    if err := s.database.StorePool(pool); err != nil {
+        return fmt.Errorf("failed to update pool: %w", err)
+    }
+    if err := s.database.StorePoolTransaction(pool.PoolID, poolTx); err != nil {
+        return fmt.Errorf("failed to store pool swap: %w", err)
+    }
+    
+    slog.Debug("Pool swap", "pair", pool.TokenASymbol+"/"+pool.TokenBSymbol, "amount_a", poolTx.AmountA,
+        "amount_b", poolTx.AmountB, "price_impact", poolTx.PriceImpact)
+    return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFakeNode serves the subset of the Tendermint RPC the sync service uses,
+// with a chain of empty blocks up to tip
+func newFakeNode(t *testing.T, tip uint64) (*httptest.Server, *int64) {
+	var blockRequests int64
+	genesis := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"result":{"sync_info":{"latest_block_height":"%d","latest_block_hash":"tip%d"}}}`, tip, tip)
+	})
+	mux.HandleFunc("/block", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&blockRequests, 1)
+		height, err := strconv.ParseUint(r.URL.Query().Get("height"), 10, 64)
+		if err != nil || height == 0 || height > tip {
+			http.Error(w, "no such block", http.StatusNotFound)
+			return
+		}
+
+		var resp TendermintBlockResponse
+		resp.Result.Block.Header.Height = strconv.FormatUint(height, 10)
+		resp.Result.Block.Header.Time = genesis.Add(time.Duration(height) * time.Minute)
+		resp.Result.Block.Header.ChainID = "shadowy-test"
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &blockRequests
+}
+
+func waitForHeight(t *testing.T, database *Database, want uint64) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if height, _ := database.GetLatestHeight(); height == want {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	height, _ := database.GetLatestHeight()
+	t.Fatalf("Timed out waiting for height %d, at %d", want, height)
+}
+
+func TestSyncPauseStopsIngestion(t *testing.T) {
+	node, blockRequests := newFakeNode(t, 3)
+	database := newTestDatabase(t)
+
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+	svc.Pause()
+	svc.Start()
+	defer svc.Stop()
+
+	// Neither the initial cycle nor an explicit one may ingest while paused
+	svc.syncOnce()
+	svc.TriggerSync()
+	time.Sleep(200 * time.Millisecond)
+
+	if height, _ := database.GetLatestHeight(); height != 0 {
+		t.Fatalf("Paused sync ingested blocks up to %d", height)
+	}
+	if n := atomic.LoadInt64(blockRequests); n != 0 {
+		t.Errorf("Paused sync fetched %d blocks", n)
+	}
+
+	status := svc.Status()
+	if !status.Running || !status.Paused {
+		t.Errorf("Expected running and paused, got %+v", status)
+	}
+}
+
+func TestSyncResumeRestartsIngestion(t *testing.T) {
+	node, _ := newFakeNode(t, 3)
+	database := newTestDatabase(t)
+
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+	svc.Pause()
+	svc.Start()
+	defer svc.Stop()
+
+	svc.Resume()
+	waitForHeight(t, database, 3)
+
+	status := svc.Status()
+	if status.Paused {
+		t.Error("Status should not report paused after resume")
+	}
+	if status.CurrentHeight != 3 || status.RemoteHeight != 3 {
+		t.Errorf("Expected current and remote height 3, got %d/%d", status.CurrentHeight, status.RemoteHeight)
+	}
+	if status.LastError != "" {
+		t.Errorf("Unexpected sync error: %s", status.LastError)
+	}
+}
+
+func TestSyncResyncWithReset(t *testing.T) {
+	node, _ := newFakeNode(t, 2)
+	database := newTestDatabase(t)
+
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+	svc.Start()
+	defer svc.Stop()
+	waitForHeight(t, database, 2)
+
+	svc.Pause()
+	if err := svc.Resync(true); err != nil {
+		t.Fatalf("Resync failed: %v", err)
+	}
+	if height, _ := database.GetLatestHeight(); height != 0 {
+		t.Fatalf("Reset should clear synced blocks, at %d", height)
+	}
+
+	svc.Resume()
+	waitForHeight(t, database, 2)
+}
+
+func TestSyncAdminEndpointsRequireAuth(t *testing.T) {
+	node, _ := newFakeNode(t, 1)
+	database := newTestDatabase(t)
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+
+	es := &ExplorerServer{database: database, syncService: svc, adminToken: "secret"}
+	handler := es.requireAdmin(es.handleSyncPause)
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("POST", "/api/v1/admin/sync/pause", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without token, got %d", rec.Code)
+	}
+	if svc.IsPaused() {
+		t.Fatal("Unauthorized request must not pause sync")
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/sync/pause", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 with token, got %d", rec.Code)
+	}
+
+	var status SyncStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if !status.Paused {
+		t.Error("Pause endpoint should report paused")
+	}
+}
+
+func TestAdminLoopbackFallback(t *testing.T) {
+	es := &ExplorerServer{}
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/sync/status", nil)
+	req.RemoteAddr = "127.0.0.1:5555"
+	if !es.isAdminRequest(req) {
+		t.Error("Loopback client should be allowed when no token is configured")
+	}
+
+	req.RemoteAddr = "203.0.113.7:5555"
+	if es.isAdminRequest(req) {
+		t.Error("Remote client should be rejected when no token is configured")
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pages are rendered on the server from the templates embedded below, with
+// their data filled in, so they work without JavaScript and can be
+// crawled. layout.html wraps every page: each page template defines
+// "content" and may define "head" and "scripts", all given page.Data.
+//
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// Pages style themselves from the files under static, embedded so the
+// binary serves them at /static/ with nothing fetched from elsewhere.
+// Setting assets to "cdn" loads Tailwind from its CDN instead.
+//
+//go:embed static
+var staticFS embed.FS
+
+const tailwindCDN = "https://cdn.tailwindcss.com"
+
+// staticHandler serves the embedded static files under /static/
+func staticHandler() http.Handler {
+	files, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		panic(err)
+	}
+	fileServer := http.StripPrefix("/static/", http.FileServer(http.FS(files)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// pageFuncs are the formatting helpers available to every template
+var pageFuncs = template.FuncMap{
+	"short":     shortHash,
+	"abbrev":    abbreviate,
+	"shadow":    func(units uint64) string { return formatShadowAmount(int64(units)) },
+	"units":     formatUnits,
+	"scale":     scaleUnits,
+	"percent":   percentOf,
+	"fraction":  func(f float64) float64 { return f * 100 },
+	"bytes":     formatBytes,
+	"kb":        func(size int) string { return fmt.Sprintf("%.1f KB", float64(size)/1024) },
+	"date":      formatDate,
+	"datetime":  formatDateTime,
+	"age":       formatAge,
+	"add":       func(a, b int) int { return a + b },
+	"sum":       func(a, b uint64) uint64 { return a + b },
+	"odd":       func(i int) bool { return i%2 == 1 },
+	"hasPrefix": strings.HasPrefix,
+	"upper":     strings.ToUpper,
+	"replace":   func(s, old, new string) string { return strings.ReplaceAll(s, old, new) },
+}
+
+// linkFuncs make the url and chainURL template functions, which prefix
+// site-absolute paths with the base path of the chain shown and link to a
+// chain below the site's base path
+func linkFuncs(basePath, sitePath string) template.FuncMap {
+	return template.FuncMap{
+		"url": func(parts ...interface{}) string {
+			return basePath + fmt.Sprint(parts...)
+		},
+		"chainURL": func(id string) string {
+			return sitePath + chainPathPrefix + id + "/"
+		},
+	}
+}
+
+// pages holds each page template parsed together with the layout. They are
+// never executed, only cloned by pagesFor.
+var pages = mustParsePages()
+
+// linkedPages caches the pages cloned for each base path
+var (
+	linkedPagesMu sync.Mutex
+	linkedPages   = make(map[string]map[string]*template.Template)
+)
+
+// pagesFor returns the pages with their links under c's base path
+func pagesFor(c *ServerConfig) map[string]*template.Template {
+	basePath, sitePath := c.pagePath(""), c.sitePath()
+	key := basePath + " " + sitePath
+	linkedPagesMu.Lock()
+	defer linkedPagesMu.Unlock()
+	if linked, ok := linkedPages[key]; ok {
+		return linked
+	}
+
+	linked := make(map[string]*template.Template, len(pages))
+	for name, tmpl := range pages {
+		linked[name] = template.Must(tmpl.Clone()).Funcs(linkFuncs(basePath, sitePath))
+	}
+	linkedPages[key] = linked
+	return linked
+}
+
+func mustParsePages() map[string]*template.Template {
+	names, err := templateFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+
+	parsed := make(map[string]*template.Template)
+	for _, entry := range names {
+		name := strings.TrimSuffix(entry.Name(), ".html")
+		if name == "layout" {
+			continue
+		}
+		parsed[name] = template.Must(template.New("layout.html").Funcs(pageFuncs).Funcs(linkFuncs("", "")).ParseFS(templateFS,
+			"templates/layout.html", path.Join("templates", entry.Name())))
+	}
+	return parsed
+}
+
+// page is what the layout renders around a page's content
+type page struct {
+	Title   string
+	Refresh int         // Seconds between reloads; 0 never reloads
+	NodeURL string      // Shown in the footer
+	Data    interface{} // Passed to the page's content template
+
+	// Description summarizes the page for search results and link
+	// previews; the site's description when empty
+	Description string
+	Image       string   // Preview image path, e.g. an NFT's
+	NoIndex     bool     // Keep the page out of search indexes
+	URL         string   // Canonical absolute URL, filled in by renderPage; empty in onion mode
+	Origin      string   // Absolute URL of the explorer's root; empty in onion mode
+	CDN         string   // Tailwind script to load instead of the embedded styles
+	Stale       *SyncLag // Set while the index trails the node, for the banner
+	Chain       string   // Chain shown; empty for a single-chain explorer
+	Chains      []string // Chains to switch between, the primary first
+}
+
+// renderPage renders the named page into a buffer first, so a template
+// error becomes a 500 rather than half a page
+func (es *ExplorerServer) renderPage(w http.ResponseWriter, r *http.Request, status int, name string, p page) {
+	tmpl, ok := pagesFor(es.config)[name]
+	if !ok {
+		slog.Error("No page template", "name", name)
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	p.NodeURL = es.shadowyNodeURL
+	p.Chain, p.Chains = es.chainID, es.chainIDs()
+	if !es.config.onionMode() { // Onion pages link relatively, whatever address they are reached at
+		p.Origin = es.config.publicURL(r)
+		p.URL = p.Origin + r.URL.Path
+	}
+	if es.config.cdnAssets() {
+		p.CDN = tailwindCDN
+	}
+	if es.syncService != nil {
+		if lag := es.syncService.Lag(); lag.Stale {
+			p.Stale = &lag
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, p); err != nil {
+		slog.Error("Failed to render page", "name", name, "err", err)
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// notFoundPage is the content of the notfound page
+type notFoundPage struct {
+	Heading string
+	Detail  string
+	Back    string // Link to a page listing what was not found
+	BackTo  string
+}
+
+// renderNotFound renders a 404 page pointing back to a listing
+func (es *ExplorerServer) renderNotFound(w http.ResponseWriter, r *http.Request, data notFoundPage) {
+	es.renderPage(w, r, http.StatusNotFound, "notfound", page{Title: "Not Found", NoIndex: true, Data: data})
+}
+
+// refreshSeconds is how often auto-refreshing pages reload
+func (es *ExplorerServer) refreshSeconds() int {
+	interval := es.refreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	return int(interval.Seconds())
+}
+
+// pageParams reads ?page= and ?per_page= for a listing page
+func pageParams(r *http.Request, perPage int) (int, int) {
+	page := 1
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if pp, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && pp > 0 && pp <= 100 {
+		perPage = pp
+	}
+	return page, perPage
+}
+
+// pager links the pages of a listing, keeping the rest of its query
+type pager struct {
+	Current int
+	Total   int
+	path    string
+	query   url.Values
+}
+
+func newPager(r *http.Request, current, total int) pager {
+	return pager{Current: current, Total: total, path: r.URL.Path, query: r.URL.Query()}
+}
+
+// Pages returns the page numbers linked around the current page
+func (p pager) Pages() []int {
+	var numbers []int
+	for i := max(1, p.Current-2); i <= min(p.Total, p.Current+2); i++ {
+		numbers = append(numbers, i)
+	}
+	return numbers
+}
+
+// URL links to page n of the listing
+func (p pager) URL(n int) string {
+	query := url.Values{}
+	for key, values := range p.query {
+		query[key] = values
+	}
+	query.Set("page", strconv.Itoa(n))
+	return p.path + "?" + query.Encode()
+}
+
+// AveragePerHolder is the circulating supply split evenly across holders
+func (t TokenInfo) AveragePerHolder() uint64 {
+	if t.HolderCount == 0 {
+		return 0
+	}
+	return t.CirculatingSupply / uint64(t.HolderCount)
+}
+
+// shortHash shortens hashes and addresses to their first 16 characters
+func shortHash(s string) string {
+	if len(s) <= 16 {
+		return s
+	}
+	return s[:16] + "..."
+}
+
+// abbreviate keeps both ends of a long hash or address
+func abbreviate(s string) string {
+	if len(s) <= 20 {
+		return s
+	}
+	return s[:10] + "..." + s[len(s)-8:]
+}
+
+// scaleUnits divides value by 10^decimals
+func scaleUnits(value uint64, decimals int) float64 {
+	return float64(value) / math.Pow10(decimals)
+}
+
+// formatUnits formats a token amount with its decimals, grouping
+// thousands and dropping trailing zeros
+func formatUnits(value uint64, decimals uint8) string {
+	divisor := uint64(math.Pow10(int(decimals)))
+	whole := strconv.FormatUint(value/divisor, 10)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	if decimals == 0 || value%divisor == 0 {
+		return whole
+	}
+	fraction := fmt.Sprintf("%0*d", decimals, value%divisor)
+	return whole + "." + strings.TrimRight(fraction, "0")
+}
+
+// percentOf returns part as a percent of whole, 0 when whole is 0
+func percentOf(part, whole uint64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+// formatBytes formats a size in binary units
+func formatBytes(size uint64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	value := float64(size)
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64) + " " + units[i]
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "Never"
+	}
+	return t.UTC().Format("2006-01-02")
+}
+
+func formatDateTime(t time.Time) string {
+	if t.IsZero() {
+		return "Never"
+	}
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// formatAge formats a wait in seconds as 42s, 3m 5s or 2h 10m
+func formatAge(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestEveryPageTemplateParses(t *testing.T) {
+	for _, name := range []string{"home", "blocks", "block", "wallets", "wallet", "tokens", "token",
+		"pools", "pool", "storage", "mempool", "nfts", "charts", "search", "forks", "notfound"} {
+		if pages[name] == nil {
+			t.Errorf("No %s page template", name)
+		}
+	}
+}
+
+func TestTokenPagesRenderServerSide(t *testing.T) {
+	database := newTestDatabase(t)
+	token := &TokenInfo{TokenID: "70ce", Name: "Gold <b>", Ticker: "GOLD", Decimals: 2,
+		TotalSupply: 123456, CirculatingSupply: 123456, Creator: "alice"}
+	if err := database.StoreToken(token); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+	if err := database.UpdateTokenHolder("70ce", "bob", 123456); err != nil {
+		t.Fatalf("Failed to store holder: %v", err)
+	}
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleTokensPage(rec, httptest.NewRequest("GET", "/tokens", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `href="/token/70ce"`) || !strings.Contains(body, "1,234.56") {
+		t.Errorf("Tokens page should list the token without JavaScript, got %d %s", rec.Code, body)
+	}
+	if strings.Contains(body, "Gold <b>") || !strings.Contains(body, "Gold &lt;b&gt;") {
+		t.Error("Token names should be escaped")
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/token/70ce", nil)
+	es.handleTokenDetailsPage(rec, mux.SetURLVars(req, map[string]string{"tokenId": "70ce"}))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `href="/wallet/bob"`) {
+		t.Errorf("Token page should list its holders, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/token/missing", nil)
+	es.handleTokenDetailsPage(rec, mux.SetURLVars(req, map[string]string{"tokenId": "missing"}))
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "Token not found") {
+		t.Errorf("Expected a 404 page for an unknown token, got %d", rec.Code)
+	}
+}
+
+func TestPoolPagesRenderServerSide(t *testing.T) {
+	database := newTestDatabase(t)
+	if err := database.StorePool(&LiquidityPool{PoolID: "pool_gold", TokenASymbol: "GOLD", TokenBSymbol: "SHADOW", TVL: 2500000}); err != nil {
+		t.Fatalf("Failed to store pool: %v", err)
+	}
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handlePoolsPage(rec, httptest.NewRequest("GET", "/pools", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "GOLD/SHADOW") || !strings.Contains(rec.Body.String(), "2.50 SHADOW") {
+		t.Errorf("Pools page should list the pool and its TVL, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/pool/pool_gold", nil)
+	es.handlePoolDetailsPage(rec, mux.SetURLVars(req, map[string]string{"poolId": "pool_gold"}))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "interval=5m") || strings.Index(body, "interval=5m") > strings.Index(body, "interval=1d") {
+		t.Errorf("Pool page should link candle intervals shortest first, got %d %s", rec.Code, body)
+	}
+}
+
+func TestStoragePageWithoutTracker(t *testing.T) {
+	es := &ExplorerServer{database: newTestDatabase(t)}
+	rec := httptest.NewRecorder()
+	es.handleStoragePage(rec, httptest.NewRequest("GET", "/storage", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Tracker unreachable") {
+		t.Errorf("Storage page should flag the missing tracker, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPagerKeepsQuery(t *testing.T) {
+	p := newPager(httptest.NewRequest("GET", "/tokens?search=gold&page=2", nil), 2, 10)
+	if got := p.URL(3); got != "/tokens?page=3&search=gold" {
+		t.Errorf("Expected the search kept, got %s", got)
+	}
+	if pages := p.Pages(); len(pages) != 4 || pages[0] != 1 || pages[3] != 4 {
+		t.Errorf("Expected pages 1-4 around page 2, got %v", pages)
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	cases := []struct {
+		value    uint64
+		decimals uint8
+		want     string
+	}{
+		{0, 0, "0"},
+		{1234567, 0, "1,234,567"},
+		{123456, 2, "1,234.56"},
+		{100050, 3, "100.05"},
+		{5000, 3, "5"},
+	}
+	for _, tc := range cases {
+		if got := formatUnits(tc.value, tc.decimals); got != tc.want {
+			t.Errorf("formatUnits(%d, %d) = %s, want %s", tc.value, tc.decimals, got, tc.want)
+		}
+	}
+}
+
+func TestEntityPagesHaveLinkPreviews(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestBlocks(t, database, 7, 7)
+	es := &ExplorerServer{database: database, config: &ServerConfig{PublicURL: "https://explorer.example.com"}}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/block/hash_7", nil)
+	es.handleBlockDetailsPage(rec, mux.SetURLVars(req, map[string]string{"hash": "hash_7"}))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<meta property="og:title" content="Block 7">`,
+		`<meta property="og:url" content="https://explorer.example.com/block/hash_7">`,
+		`<link rel="canonical" href="https://explorer.example.com/block/hash_7">`,
+		`content="Shadowy block 7, farmed by`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Block page should contain %s", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/block/missing", nil)
+	es.handleBlockDetailsPage(rec, mux.SetURLVars(req, map[string]string{"hash": "missing"}))
+	if !strings.Contains(rec.Body.String(), `<meta name="robots" content="noindex">`) {
+		t.Error("Not found pages should not be indexed")
+	}
+}
+
+// templateActions matches template actions, so the literal text around
+// them can be searched for class names
+var templateActions = regexp.MustCompile(`{{.*?}}`)
+
+func TestEmbeddedStylesCoverTemplates(t *testing.T) {
+	css, err := staticFS.ReadFile("static/css/explorer.css")
+	if err != nil {
+		t.Fatalf("Failed to read embedded styles: %v", err)
+	}
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sources strings.Builder
+	for _, entry := range entries {
+		data, err := templateFS.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources.Write(data)
+	}
+	text := templateActions.ReplaceAllString(sources.String(), " ")
+
+	classAttrs := regexp.MustCompile(`class="([^"]*)"`)
+	toggled := regexp.MustCompile(`classList\.toggle\('([^']+)'`)
+	var classes []string
+	for _, match := range classAttrs.FindAllStringSubmatch(text, -1) {
+		classes = append(classes, strings.Fields(match[1])...)
+	}
+	for _, match := range toggled.FindAllStringSubmatch(text, -1) {
+		classes = append(classes, match[1])
+	}
+
+	escaper := strings.NewReplacer(":", `\:`, ".", `\.`)
+	for _, class := range classes {
+		// Page-specific classes are styled or looked up by the page itself
+		if strings.Contains(text, "."+class) {
+			continue
+		}
+		if !strings.Contains(string(css), "."+escaper.Replace(class)+" ") && !strings.Contains(string(css), "."+escaper.Replace(class)+":") {
+			t.Errorf("Class %q is used by a template but missing from explorer.css", class)
+		}
+	}
+}
+
+func TestPagesLoadEmbeddedStyles(t *testing.T) {
+	es := &ExplorerServer{database: newTestDatabase(t)}
+	rec := httptest.NewRecorder()
+	es.handleSearchPage(rec, httptest.NewRequest("GET", "/search", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `href="/static/css/explorer.css"`) || strings.Contains(body, tailwindCDN) {
+		t.Error("Pages should load the embedded styles by default")
+	}
+
+	es.config = &ServerConfig{Assets: assetsCDN}
+	rec = httptest.NewRecorder()
+	es.handleSearchPage(rec, httptest.NewRequest("GET", "/search", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `<script src="`+tailwindCDN+`">`) {
+		t.Error("CDN mode should load Tailwind from its CDN")
+	}
+
+	rec = httptest.NewRecorder()
+	staticHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/static/css/explorer.css", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/css") {
+		t.Errorf("Expected the embedded stylesheet, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// Each token's holders are ranked by balance
+// (token_rank:<token>:<balance>:<address>, balance zero-padded to 20
+// digits) and counted (token_holder_count:<token>), beside the holder
+// records themselves (token_holder:<token>:<address>). setTokenHolder keeps
+// all three in step; databases indexed before the ranking existed are
+// backfilled by a migration.
+const (
+	tokenHolderPrefix      = "token_holder:"
+	tokenRankPrefix        = "token_rank:"
+	tokenHolderCountPrefix = "token_holder_count:"
+
+	defaultTokenHoldersPerPage = 50
+	maxTokenHoldersPerPage     = 500
+)
+
+func tokenRankKey(tokenID string, balance uint64, address string) []byte {
+	return []byte(fmt.Sprintf("%s%s:%020d:%s", tokenRankPrefix, tokenID, balance, address))
+}
+
+// readTokenHolderCount reads a token's holder count inside txn
+func readTokenHolderCount(txn *badger.Txn, tokenID string) (uint64, error) {
+	item, err := txn.Get([]byte(tokenHolderCountPrefix + tokenID))
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var count uint64
+	err = item.Value(func(val []byte) error {
+		if len(val) == 8 {
+			count = binary.BigEndian.Uint64(val)
+		}
+		return nil
+	})
+	return count, err
+}
+
+// setTokenHolder records address's balance of a token, moving its rank
+// entry and counting it in or out when it starts or stops holding
+func setTokenHolder(txn *badger.Txn, tokenID, address string, balance uint64) error {
+	holderKey := []byte(tokenHolderPrefix + tokenID + ":" + address)
+
+	var previous TokenHolder
+	item, err := txn.Get(holderKey)
+	switch {
+	case err == badger.ErrKeyNotFound:
+	case err != nil:
+		return err
+	default:
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &previous)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if previous.Balance > 0 {
+		if err := txn.Delete(tokenRankKey(tokenID, previous.Balance, address)); err != nil {
+			return err
+		}
+	}
+	if balance > 0 {
+		if err := txn.Set(tokenRankKey(tokenID, balance, address), nil); err != nil {
+			return err
+		}
+	}
+
+	if (previous.Balance > 0) != (balance > 0) {
+		count, err := readTokenHolderCount(txn, tokenID)
+		if err != nil {
+			return err
+		}
+		if balance > 0 {
+			count++
+		} else if count > 0 {
+			count--
+		}
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, count)
+		if err := txn.Set([]byte(tokenHolderCountPrefix+tokenID), value); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(TokenHolder{Address: address, Balance: balance})
+	if err != nil {
+		return fmt.Errorf("failed to marshal token holder: %w", err)
+	}
+	return txn.Set(holderKey, data)
+}
+
+// buildTokenHolderIndex ranks and counts every token's holders from their
+// records
+func (d *Database) buildTokenHolderIndex() (int, error) {
+	changed := 0
+	err := d.update(func(txn *badger.Txn) error {
+		// Drop partial entries written since the upgrade, then rebuild
+		var stale [][]byte
+		for _, prefix := range []string{tokenRankPrefix, tokenHolderCountPrefix} {
+			keys, err := collectKeys(txn, prefix, func(string, []byte) bool { return true })
+			if err != nil {
+				return err
+			}
+			stale = append(stale, keys...)
+		}
+		if err := deleteKeys(txn, stale); err != nil {
+			return err
+		}
+
+		type tokenHolder struct {
+			tokenID string
+			holder  TokenHolder
+		}
+		var holders []tokenHolder
+		if _, err := collectKeys(txn, tokenHolderPrefix, func(key string, val []byte) bool {
+			var holder TokenHolder
+			if json.Unmarshal(val, &holder) == nil && holder.Balance > 0 {
+				tokenID := strings.TrimSuffix(strings.TrimPrefix(key, tokenHolderPrefix), ":"+holder.Address)
+				holders = append(holders, tokenHolder{tokenID, holder})
+			}
+			return false
+		}); err != nil {
+			return err
+		}
+
+		counts := make(map[string]uint64)
+		for _, h := range holders {
+			if err := txn.Set(tokenRankKey(h.tokenID, h.holder.Balance, h.holder.Address), nil); err != nil {
+				return err
+			}
+			counts[h.tokenID]++
+		}
+		for tokenID, count := range counts {
+			value := make([]byte, 8)
+			binary.BigEndian.PutUint64(value, count)
+			if err := txn.Set([]byte(tokenHolderCountPrefix+tokenID), value); err != nil {
+				return err
+			}
+		}
+
+		changed = len(holders) + len(counts)
+		return nil
+	})
+	return changed, err
+}
+
+// GetTokenHoldersPage returns one page of a token's holders, largest
+// balance first, read from the rank index
+func (d *Database) GetTokenHoldersPage(tokenID string, page, perPage int) (*PaginatedTokenHolders, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	result := &PaginatedTokenHolders{TokenID: tokenID, Holders: []TokenHolder{}, CurrentPage: page, PerPage: perPage}
+	err := d.view(func(txn *badger.Txn) error {
+		count, err := readTokenHolderCount(txn, tokenID)
+		if err != nil {
+			return err
+		}
+		result.TotalHolders = int(count)
+		result.TotalPages = (result.TotalHolders + perPage - 1) / perPage
+
+		prefix := tokenRankPrefix + tokenID + ":"
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		offset := (page - 1) * perPage
+		rank := 0
+		for it.Seek([]byte(prefix + "\xff")); it.Valid() && len(result.Holders) < perPage; it.Next() {
+			rank++
+			if rank <= offset {
+				continue
+			}
+			parts := strings.SplitN(strings.TrimPrefix(string(it.Item().Key()), prefix), ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			balance, err := strconv.ParseUint(parts[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			result.Holders = append(result.Holders, TokenHolder{Address: parts[1], Balance: balance, Rank: rank})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetTokenBalance returns an address's balance of a token, zero if it has
+// never held any
+func (d *Database) GetTokenBalance(tokenID, address string) (uint64, error) {
+	var holder TokenHolder
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(tokenHolderPrefix + tokenID + ":" + address))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &holder)
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	return holder.Balance, err
+}
+
+// CountTokenHolders returns how many addresses hold a positive balance of
+// a token
+func (d *Database) CountTokenHolders(tokenID string) (int, error) {
+	var count uint64
+	err := d.view(func(txn *badger.Txn) error {
+		var err error
+		count, err = readTokenHolderCount(txn, tokenID)
+		return err
+	})
+	return int(count), err
+}
+
+// Token holders API endpoint: every holder of a token, largest first, a
+// page at a time
+func (es *ExplorerServer) handleTokenHolders(w http.ResponseWriter, r *http.Request) {
+	tokenID := mux.Vars(r)["tokenId"]
+	query := r.URL.Query()
+
+	page := 1
+	if value := query.Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "page must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+	perPage := defaultTokenHoldersPerPage
+	if value := query.Get("per_page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxTokenHoldersPerPage {
+			http.Error(w, fmt.Sprintf("per_page must be between 1 and %d", maxTokenHoldersPerPage), http.StatusBadRequest)
+			return
+		}
+		perPage = n
+	}
+
+	if _, err := es.database.GetToken(tokenID); err != nil {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	holders, err := es.database.GetTokenHoldersPage(tokenID, page, perPage)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get token holders", "token_id", tokenID, "err", err)
+		http.Error(w, "Failed to get token holders", http.StatusInternalServerError)
+		return
+	}
+
+	labels := es.newLabeler()
+	for i := range holders.Holders {
+		labels.add(holders.Holders[i].Address, &holders.Holders[i].Label)
+	}
+	labels.apply()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(holders)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+func TestTokenHoldersRankedAndPaged(t *testing.T) {
+	database := newTestDatabase(t)
+	for i := 1; i <= 5; i++ {
+		if err := database.UpdateTokenHolder("tok", fmt.Sprintf("holder_%d", i), uint64(i*100)); err != nil {
+			t.Fatalf("UpdateTokenHolder failed: %v", err)
+		}
+	}
+	// holder_1 overtakes everyone and holder_5 sells out
+	database.UpdateTokenHolder("tok", "holder_1", 1000)
+	database.UpdateTokenHolder("tok", "holder_5", 0)
+
+	page, err := database.GetTokenHoldersPage("tok", 1, 2)
+	if err != nil {
+		t.Fatalf("GetTokenHoldersPage failed: %v", err)
+	}
+	if page.TotalHolders != 4 || page.TotalPages != 2 {
+		t.Fatalf("Expected 4 holders over 2 pages, got %+v", page)
+	}
+	if len(page.Holders) != 2 || page.Holders[0].Address != "holder_1" || page.Holders[0].Balance != 1000 ||
+		page.Holders[1].Address != "holder_4" || page.Holders[1].Rank != 2 {
+		t.Errorf("Unexpected first page %+v", page.Holders)
+	}
+
+	page, _ = database.GetTokenHoldersPage("tok", 2, 2)
+	if len(page.Holders) != 2 || page.Holders[0].Address != "holder_3" || page.Holders[0].Rank != 3 ||
+		page.Holders[1].Address != "holder_2" {
+		t.Errorf("Unexpected second page %+v", page.Holders)
+	}
+
+	if balance, _ := database.GetTokenBalance("tok", "holder_3"); balance != 300 {
+		t.Errorf("Expected holder_3 to hold 300, got %d", balance)
+	}
+	if balance, err := database.GetTokenBalance("tok", "stranger"); balance != 0 || err != nil {
+		t.Errorf("Expected 0 for a non-holder, got %d (%v)", balance, err)
+	}
+}
+
+func TestTokenHolderIndexBackfill(t *testing.T) {
+	database := newTestDatabase(t)
+
+	// Holder records written before the rank index existed
+	err := database.update(func(txn *badger.Txn) error {
+		for i, balance := range []uint64{50, 0, 70} {
+			data, _ := json.Marshal(TokenHolder{Address: fmt.Sprintf("old_%d", i), Balance: balance})
+			if err := txn.Set([]byte(tokenHolderPrefix+"legacy:"+fmt.Sprintf("old_%d", i)), data); err != nil {
+				return err
+			}
+		}
+		return txn.Delete([]byte(tokenHolderIndexMarker))
+	})
+	if err != nil {
+		t.Fatalf("Failed to write legacy holders: %v", err)
+	}
+	if err := database.migrate(); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	if count, err := database.CountTokenHolders("legacy"); err != nil || count != 2 {
+		t.Fatalf("Expected 2 holders after the backfill, got %d (%v)", count, err)
+	}
+	holders, _ := database.GetTokenHolders("legacy", 10)
+	if len(holders) != 2 || holders[0].Address != "old_2" || holders[1].Address != "old_0" {
+		t.Errorf("Unexpected backfilled ranking %+v", holders)
+	}
+}
+
+func TestTokenHoldersEndpoint(t *testing.T) {
+	database := newTestDatabase(t)
+	database.StoreToken(&TokenInfo{TokenID: "tok", Name: "Token", Ticker: "TOK"})
+	for i := 0; i < 120; i++ {
+		database.UpdateTokenHolder("tok", fmt.Sprintf("holder_%03d", i), uint64(i+1))
+	}
+	es := &ExplorerServer{database: database}
+
+	get := func(tokenID, query string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/token/"+tokenID+"/holders"+query, nil), map[string]string{"tokenId": tokenID})
+		rec := httptest.NewRecorder()
+		es.handleTokenHolders(rec, req)
+		return rec
+	}
+
+	rec := get("tok", "?page=3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var page PaginatedTokenHolders
+	json.NewDecoder(rec.Body).Decode(&page)
+	if page.TotalHolders != 120 || page.TotalPages != 3 || len(page.Holders) != 20 || page.Holders[0].Rank != 101 ||
+		page.Holders[19].Address != "holder_000" {
+		t.Errorf("Unexpected last page: %d holders of %d, first %+v", len(page.Holders), page.TotalHolders, page.Holders[0])
+	}
+
+	if rec := get("tok", "?per_page=1000"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an oversized page, got %d", rec.Code)
+	}
+	if rec := get("missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown token, got %d", rec.Code)
+	}
+}
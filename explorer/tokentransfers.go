@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultTokenTransfersPerPage = 50
+	maxTokenTransfersPerPage     = 500
+)
+
+// tokenTransferTypes maps the type filter of the transfer log to the
+// operation types stored by sync
+var tokenTransferTypes = map[string]string{
+	"mint":     "CREATE",
+	"create":   "CREATE",
+	"transfer": "TRANSFER",
+	"melt":     "MELT",
+}
+
+// matches reports whether a token transaction passes the filter
+func (f TokenTransferFilter) matches(tx *TokenTransaction) bool {
+	return (f.From == "" || tx.FromAddress == f.From) &&
+		(f.To == "" || tx.ToAddress == f.To) &&
+		(f.Type == "" || strings.EqualFold(tx.Type, f.Type))
+}
+
+// GetTokenTransfers returns one page of a token's transactions matching
+// filter, newest first, walking the token_tx: index written during sync
+func (d *Database) GetTokenTransfers(tokenID string, filter TokenTransferFilter, page, perPage int) (*PaginatedTokenTransfers, error) {
+	if page < 1 {
+		page = 1
+	}
+	result := &PaginatedTokenTransfers{
+		TokenID:     tokenID,
+		Transfers:   []TokenTransaction{},
+		CurrentPage: page,
+		PerPage:     perPage,
+	}
+	skip := (page - 1) * perPage
+
+	err := d.view(func(txn *badger.Txn) error {
+		prefix := []byte(fmt.Sprintf("token_tx:%s:", tokenID))
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.Reverse = true // Newest first
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(append(prefix, 0xff)); it.Valid(); it.Next() {
+			var tx TokenTransaction
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				continue // Skip invalid transactions
+			}
+			if !filter.matches(&tx) {
+				continue
+			}
+			if result.TotalTransfers >= skip && len(result.Transfers) < perPage {
+				result.Transfers = append(result.Transfers, tx)
+			}
+			result.TotalTransfers++
+		}
+		return nil
+	})
+	result.TotalPages = (result.TotalTransfers + perPage - 1) / perPage
+	return result, err
+}
+
+// Token transfers API endpoint: a token's transaction log, newest first,
+// optionally only those from or to an address or of one type
+func (es *ExplorerServer) handleTokenTransfers(w http.ResponseWriter, r *http.Request) {
+	tokenID := mux.Vars(r)["tokenId"]
+	query := r.URL.Query()
+
+	filter := TokenTransferFilter{From: query.Get("from"), To: query.Get("to")}
+	if value := query.Get("type"); value != "" {
+		opType, ok := tokenTransferTypes[strings.ToLower(value)]
+		if !ok {
+			http.Error(w, "type must be mint, transfer or melt", http.StatusBadRequest)
+			return
+		}
+		filter.Type = opType
+	}
+
+	page := 1
+	if value := query.Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "page must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+	perPage := defaultTokenTransfersPerPage
+	if value := query.Get("per_page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxTokenTransfersPerPage {
+			http.Error(w, fmt.Sprintf("per_page must be between 1 and %d", maxTokenTransfersPerPage), http.StatusBadRequest)
+			return
+		}
+		perPage = n
+	}
+
+	if _, err := es.database.GetToken(tokenID); err != nil {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	transfers, err := es.database.GetTokenTransfers(tokenID, filter, page, perPage)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get token transfers", "token_id", tokenID, "err", err)
+		http.Error(w, "Failed to get token transfers", http.StatusInternalServerError)
+		return
+	}
+
+	labels := es.newLabeler()
+	for i := range transfers.Transfers {
+		tx := &transfers.Transfers[i]
+		labels.add(tx.FromAddress, &tx.FromLabel)
+		labels.add(tx.ToAddress, &tx.ToLabel)
+	}
+	labels.apply()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// storeTestTokenTransfers indexes a mint to alice, then transfers from
+// alice to bob and carol in turn, then a melt by bob
+func storeTestTokenTransfers(t *testing.T, database Store) {
+	transfers := []TokenTransaction{{Type: "CREATE", ToAddress: "alice", Amount: 1000}}
+	for i := 0; i < 6; i++ {
+		to := "bob"
+		if i%2 == 1 {
+			to = "carol"
+		}
+		transfers = append(transfers, TokenTransaction{Type: "TRANSFER", FromAddress: "alice", ToAddress: to, Amount: 10})
+	}
+	transfers = append(transfers, TokenTransaction{Type: "MELT", FromAddress: "bob", Amount: 5})
+
+	for i := range transfers {
+		tx := &transfers[i]
+		tx.TxHash = fmt.Sprintf("tx_%d", i)
+		tx.BlockHeight = uint64(i + 1)
+		tx.Timestamp = time.Unix(int64(1000+i), 0)
+		if err := database.StoreTokenTransaction("tok", tx); err != nil {
+			t.Fatalf("StoreTokenTransaction failed: %v", err)
+		}
+	}
+}
+
+func TestTokenTransfersFiltered(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTestTokenTransfers(t, database)
+
+	all, err := database.GetTokenTransfers("tok", TokenTransferFilter{}, 1, 3)
+	if err != nil {
+		t.Fatalf("GetTokenTransfers failed: %v", err)
+	}
+	if all.TotalTransfers != 8 || all.TotalPages != 3 || len(all.Transfers) != 3 || all.Transfers[0].TxHash != "tx_7" {
+		t.Errorf("Expected 8 transfers newest first, got %+v", all)
+	}
+
+	toBob, _ := database.GetTokenTransfers("tok", TokenTransferFilter{From: "alice", To: "bob"}, 2, 2)
+	if toBob.TotalTransfers != 3 || len(toBob.Transfers) != 1 || toBob.Transfers[0].TxHash != "tx_1" {
+		t.Errorf("Expected the oldest of 3 transfers to bob on page 2, got %+v", toBob)
+	}
+
+	melts, _ := database.GetTokenTransfers("tok", TokenTransferFilter{Type: "melt"}, 1, 10)
+	if melts.TotalTransfers != 1 || melts.Transfers[0].FromAddress != "bob" {
+		t.Errorf("Expected bob's melt, got %+v", melts)
+	}
+
+	none, _ := database.GetTokenTransfers("other", TokenTransferFilter{}, 1, 10)
+	if none.TotalTransfers != 0 || none.Transfers == nil {
+		t.Errorf("Expected an empty log for another token, got %+v", none)
+	}
+}
+
+func TestTokenTransfersEndpoint(t *testing.T) {
+	database := newTestDatabase(t)
+	database.StoreToken(&TokenInfo{TokenID: "tok", Name: "Token", Ticker: "TOK"})
+	storeTestTokenTransfers(t, database)
+	es := &ExplorerServer{database: database}
+
+	get := func(tokenID, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/token/"+tokenID+"/transfers"+query, nil)
+		es.handleTokenTransfers(rec, mux.SetURLVars(req, map[string]string{"tokenId": tokenID}))
+		return rec
+	}
+
+	rec := get("tok", "?type=mint")
+	var page PaginatedTokenTransfers
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected a page, got %d (%v)", rec.Code, err)
+	}
+	if page.TotalTransfers != 1 || page.Transfers[0].ToAddress != "alice" || page.PerPage != defaultTokenTransfersPerPage {
+		t.Errorf("Expected the mint to alice, got %+v", page)
+	}
+
+	rec = get("tok", "?to=carol&page=2&per_page=2")
+	json.NewDecoder(rec.Body).Decode(&page)
+	if page.TotalTransfers != 3 || page.CurrentPage != 2 || len(page.Transfers) != 1 {
+		t.Errorf("Expected the last of carol's 3 transfers, got %+v", page)
+	}
+
+	for query, status := range map[string]int{
+		"?type=swap":    http.StatusBadRequest,
+		"?page=0":       http.StatusBadRequest,
+		"?per_page=501": http.StatusBadRequest,
+		"?from=nobody":  http.StatusOK,
+	} {
+		if rec := get("tok", query); rec.Code != status {
+			t.Errorf("%s: expected %d, got %d", query, status, rec.Code)
+		}
+	}
+	if rec := get("missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown token, got %d", rec.Code)
+	}
+}
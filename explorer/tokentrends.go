@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// The trending index counts token transfers in hourly buckets
+// (token_trend:<hour>:<token> -> big-endian count) and marks the addresses
+// that sent or received each token in the hour
+// (token_trend_addr:<hour>:<token>:<address>). Hours are zero-padded unix
+// hours, so both sort oldest first. StoreTokenTransaction keeps the buckets
+// current and drops those older than the longest window.
+const (
+	tokenTrendPrefix        = "token_trend:"
+	tokenTrendAddressPrefix = "token_trend_addr:"
+	tokenTrendRetention     = 7 * 24 // Hours
+	defaultTrendingTokens   = 10
+	maxTrendingTokens       = 100
+)
+
+// tokenTrendWindows are the windows trending tokens can be ranked over
+var tokenTrendWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// TrendingToken is a token with its transfer activity over a window
+type TrendingToken struct {
+	Token         TokenInfo `json:"token"`
+	Transfers     int       `json:"transfers"`
+	UniqueHolders int       `json:"unique_holders"` // Distinct addresses that sent or received it
+}
+
+// TrendingTokens ranks tokens by transfers, then unique holders, over a window
+type TrendingTokens struct {
+	Window string          `json:"window"`
+	Since  time.Time       `json:"since"`
+	Tokens []TrendingToken `json:"tokens"`
+}
+
+func unixHour(t time.Time) int64 {
+	return t.Unix() / 3600
+}
+
+func tokenTrendKey(hour int64, tokenID string) []byte {
+	return []byte(fmt.Sprintf("%s%010d:%s", tokenTrendPrefix, hour, tokenID))
+}
+
+func tokenTrendAddressKey(hour int64, tokenID, address string) []byte {
+	return []byte(fmt.Sprintf("%s%010d:%s:%s", tokenTrendAddressPrefix, hour, tokenID, address))
+}
+
+// recordTokenTrend counts a transfer in its hour's bucket and expires the
+// buckets that have fallen out of the longest window
+func recordTokenTrend(txn *badger.Txn, tokenID string, tx *TokenTransaction) error {
+	if strings.ToLower(tx.Type) != "transfer" {
+		return nil
+	}
+	hour := unixHour(tx.Timestamp)
+
+	key := tokenTrendKey(hour, tokenID)
+	var count uint64
+	item, err := txn.Get(key)
+	if err != nil && err != badger.ErrKeyNotFound {
+		return err
+	}
+	if err == nil {
+		if err := item.Value(func(val []byte) error {
+			if len(val) == 8 {
+				count = binary.BigEndian.Uint64(val)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, count+1)
+	if err := txn.Set(key, value); err != nil {
+		return fmt.Errorf("failed to store token trend: %w", err)
+	}
+
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		if address == "" {
+			continue
+		}
+		if err := txn.Set(tokenTrendAddressKey(hour, tokenID, address), nil); err != nil {
+			return fmt.Errorf("failed to store token trend: %w", err)
+		}
+	}
+
+	// Keys sort by hour, so the expired ones are at the front
+	expired := fmt.Sprintf("%010d", hour-tokenTrendRetention)
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	for _, prefix := range []string{tokenTrendPrefix, tokenTrendAddressPrefix} {
+		var keys [][]byte
+		it := txn.NewIterator(opts)
+		for it.Seek([]byte(prefix)); it.ValidForPrefix([]byte(prefix)); it.Next() {
+			if strings.TrimPrefix(string(it.Item().Key()), prefix) >= expired {
+				break
+			}
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		it.Close()
+		if err := deleteKeys(txn, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTokenTrend drops every trending bucket of tokenID, before its
+// history is replayed
+func deleteTokenTrend(txn *badger.Txn, tokenID string) error {
+	for _, prefix := range []string{tokenTrendPrefix, tokenTrendAddressPrefix} {
+		keys, err := collectKeys(txn, prefix, func(key string, val []byte) bool {
+			// <prefix><hour>:<token>[:<address>]
+			parts := strings.Split(strings.TrimPrefix(key, prefix), ":")
+			return len(parts) >= 2 && parts[1] == tokenID
+		})
+		if err != nil {
+			return err
+		}
+		if err := deleteKeys(txn, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rankTrendingTokens orders tokens by transfers, then unique holders, and
+// keeps the first limit
+func rankTrendingTokens(tokens []TrendingToken, limit int) []TrendingToken {
+	sort.Slice(tokens, func(i, j int) bool {
+		a, b := tokens[i], tokens[j]
+		if a.Transfers != b.Transfers {
+			return a.Transfers > b.Transfers
+		}
+		if a.UniqueHolders != b.UniqueHolders {
+			return a.UniqueHolders > b.UniqueHolders
+		}
+		return a.Token.TokenID < b.Token.TokenID
+	})
+	if len(tokens) > limit {
+		tokens = tokens[:limit]
+	}
+	return tokens
+}
+
+// GetTrendingTokens returns up to limit tokens ranked by their transfers in
+// the hourly buckets from since onwards
+func (d *Database) GetTrendingTokens(since time.Time, limit int) ([]TrendingToken, error) {
+	first := fmt.Sprintf("%010d", unixHour(since))
+	transfers := make(map[string]int)
+	holders := make(map[string]map[string]bool)
+
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(tokenTrendPrefix)
+		for it.Seek([]byte(tokenTrendPrefix + first)); it.ValidForPrefix(prefix); it.Next() {
+			// token_trend:<hour>:<token>
+			parts := strings.SplitN(strings.TrimPrefix(string(it.Item().Key()), tokenTrendPrefix), ":", 2)
+			if len(parts) < 2 {
+				continue
+			}
+			if err := it.Item().Value(func(val []byte) error {
+				if len(val) == 8 {
+					transfers[parts[1]] += int(binary.BigEndian.Uint64(val))
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		prefix = []byte(tokenTrendAddressPrefix)
+		for it.Seek([]byte(tokenTrendAddressPrefix + first)); it.ValidForPrefix(prefix); it.Next() {
+			// token_trend_addr:<hour>:<token>:<address>
+			parts := strings.SplitN(strings.TrimPrefix(string(it.Item().Key()), tokenTrendAddressPrefix), ":", 3)
+			if len(parts) < 3 {
+				continue
+			}
+			if holders[parts[1]] == nil {
+				holders[parts[1]] = make(map[string]bool)
+			}
+			holders[parts[1]][parts[2]] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]TrendingToken, 0, len(transfers))
+	for tokenID, count := range transfers {
+		tokens = append(tokens, TrendingToken{
+			Token:         TokenInfo{TokenID: tokenID},
+			Transfers:     count,
+			UniqueHolders: len(holders[tokenID]),
+		})
+	}
+	return fillTrendingTokens(d, rankTrendingTokens(tokens, limit)), nil
+}
+
+// fillTrendingTokens replaces each ranked token ID with the token's
+// details, dropping tokens no longer indexed
+func fillTrendingTokens(store Store, tokens []TrendingToken) []TrendingToken {
+	filled := []TrendingToken{}
+	for _, trending := range tokens {
+		token, err := store.GetToken(trending.Token.TokenID)
+		if err != nil {
+			continue
+		}
+		trending.Token = *token
+		filled = append(filled, trending)
+	}
+	return filled
+}
+
+// tokenListLimit parses the limit parameter of the token feeds
+func tokenListLimit(r *http.Request, fallback, max int) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("limit must be between 1 and %d", max)
+	}
+	return n, nil
+}
+
+// Recent tokens API endpoint: newest tokens first, paged with cursor
+func (es *ExplorerServer) handleRecentTokensAPI(w http.ResponseWriter, r *http.Request) {
+	limit, err := tokenListLimit(r, defaultTrendingTokens, maxTrendingTokens)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := es.database.GetTokensByCursor("", r.URL.Query().Get("cursor"), limit)
+	if err == errInvalidCursor {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Trending tokens API endpoint: tokens ranked by transfers and unique
+// holders over the last 24h (default) or 7d
+func (es *ExplorerServer) handleTrendingTokensAPI(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "24h"
+	}
+	duration, ok := tokenTrendWindows[window]
+	if !ok {
+		http.Error(w, "window must be 24h or 7d", http.StatusBadRequest)
+		return
+	}
+	limit, err := tokenListLimit(r, defaultTrendingTokens, maxTrendingTokens)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	trending, err := es.trendingTokens(window, duration, limit)
+	if err != nil {
+		http.Error(w, "Failed to get trending tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trending)
+}
+
+// trendingTokens ranks tokens over the window ending now, starting on the
+// hour so the buckets it covers are whole
+func (es *ExplorerServer) trendingTokens(window string, duration time.Duration, limit int) (*TrendingTokens, error) {
+	since := time.Now().Add(-duration).Truncate(time.Hour).Add(time.Hour).UTC()
+	tokens, err := es.database.GetTrendingTokens(since, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &TrendingTokens{Window: window, Since: since, Tokens: tokens}, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storeTestTransfers stores a transfer of tokenID between each pair of
+// addresses at the given time and height
+func storeTestTransfers(t *testing.T, database Store, tokenID string, at time.Time, height uint64, pairs ...[2]string) {
+	for i, pair := range pairs {
+		tx := &TokenTransaction{TxHash: fmt.Sprintf("%s_%d_%d", tokenID, at.Unix(), i), BlockHeight: height, Timestamp: at,
+			Type: "transfer", Amount: 1, FromAddress: pair[0], ToAddress: pair[1]}
+		if err := database.StoreTokenTransaction(tokenID, tx); err != nil {
+			t.Fatalf("Failed to store transfer: %v", err)
+		}
+	}
+}
+
+func storeTrendingTokens(t *testing.T, database Store, now time.Time) {
+	for i, id := range []string{"gold", "silver", "copper"} {
+		token := &TokenInfo{TokenID: id, Name: id, Ticker: strings.ToUpper(id), CreationTime: now.Add(time.Duration(i-10) * 24 * time.Hour)}
+		if err := database.StoreToken(token); err != nil {
+			t.Fatalf("Failed to store token: %v", err)
+		}
+	}
+
+	// copper only traded before the longest window
+	storeTestTransfers(t, database, "copper", now.Add(-8*24*time.Hour), 1, [2]string{"a", "b"})
+	storeTestTransfers(t, database, "gold", now.Add(-3*24*time.Hour), 2, [2]string{"a", "b"}, [2]string{"a", "b"}, [2]string{"a", "b"})
+	storeTestTransfers(t, database, "gold", now, 3, [2]string{"a", "b"}, [2]string{"b", "a"}, [2]string{"a", "b"})
+	storeTestTransfers(t, database, "silver", now, 4, [2]string{"a", "b"}, [2]string{"c", "d"}, [2]string{"e", "f"})
+}
+
+// checkTrendingTokens ranks the tokens stored by storeTrendingTokens
+func checkTrendingTokens(t *testing.T, database Store, now time.Time) {
+	// Equal transfers over 24h, so silver's wider spread of holders wins
+	day, err := database.GetTrendingTokens(now.Add(-23*time.Hour).Truncate(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetTrendingTokens failed: %v", err)
+	}
+	if len(day) != 2 || day[0].Token.TokenID != "silver" || day[0].UniqueHolders != 6 || day[1].Transfers != 3 || day[1].UniqueHolders != 2 {
+		t.Errorf("Unexpected 24h ranking %+v", day)
+	}
+	if day[0].Token.Ticker != "SILVER" {
+		t.Error("Trending tokens should carry the token's details")
+	}
+
+	week, err := database.GetTrendingTokens(now.Add(-7*24*time.Hour), 10)
+	if err != nil || len(week) != 2 || week[0].Token.TokenID != "gold" || week[0].Transfers != 6 {
+		t.Errorf("Unexpected 7d ranking %+v (%v)", week, err)
+	}
+}
+
+func TestTrendingTokensRanking(t *testing.T) {
+	database := newTestDatabase(t)
+	now := time.Now()
+	storeTrendingTokens(t, database, now)
+	checkTrendingTokens(t, database, now)
+
+	// copper's bucket expired as newer transfers were recorded
+	all, err := database.GetTrendingTokens(now.Add(-30*24*time.Hour), 10)
+	if err != nil || len(all) != 2 {
+		t.Errorf("Expected copper's expired bucket dropped, got %+v (%v)", all, err)
+	}
+}
+
+func TestTrendingTokensRollBack(t *testing.T) {
+	database := newTestDatabase(t)
+	now := time.Now()
+	storeTrendingTokens(t, database, now)
+
+	if _, err := database.RollbackToHeight(3); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	day, err := database.GetTrendingTokens(now.Add(-23*time.Hour).Truncate(time.Hour), 10)
+	if err != nil || len(day) != 1 || day[0].Token.TokenID != "gold" {
+		t.Errorf("Expected silver's rolled back transfers gone, got %+v (%v)", day, err)
+	}
+}
+
+func TestTokenFeedAPIs(t *testing.T) {
+	database := newTestDatabase(t)
+	storeTrendingTokens(t, database, time.Now())
+	es := &ExplorerServer{database: database}
+
+	rec := httptest.NewRecorder()
+	es.handleTrendingTokensAPI(rec, httptest.NewRequest("GET", "/api/v1/tokens/trending?window=7d&limit=1", nil))
+	var trending TrendingTokens
+	if err := json.NewDecoder(rec.Body).Decode(&trending); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if trending.Window != "7d" || len(trending.Tokens) != 1 || trending.Tokens[0].Token.TokenID != "gold" {
+		t.Errorf("Unexpected trending response %+v", trending)
+	}
+
+	for _, query := range []string{"window=30d", "limit=0"} {
+		rec = httptest.NewRecorder()
+		es.handleTrendingTokensAPI(rec, httptest.NewRequest("GET", "/api/v1/tokens/trending?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleRecentTokensAPI(rec, httptest.NewRequest("GET", "/api/v1/tokens/recent?limit=2", nil))
+	var recent PaginatedTokens
+	if err := json.NewDecoder(rec.Body).Decode(&recent); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(recent.Tokens) != 2 || recent.Tokens[0].TokenID != "copper" || recent.NextCursor == "" {
+		t.Errorf("Expected the newest tokens with a cursor, got %+v", recent)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleTokensPage(rec, httptest.NewRequest("GET", "/tokens", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "Hot in the last 24h") || !strings.Contains(body, "3 transfers · 6 holders") {
+		t.Errorf("Tokens page should show the new and hot sections, got %s", body)
+	}
+}
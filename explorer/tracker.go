@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTrackerURL = "https://playatarot.com"
+
+	trackerRefreshInterval = 30 * time.Second
+
+	// Tracker data older than this is served flagged stale
+	trackerCacheTTL = 2 * time.Minute
+)
+
+// trackerURL is the base URL of the network tracker
+func trackerURL() string {
+	if url := os.Getenv("EXPLORER_TRACKER_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return defaultTrackerURL
+}
+
+// trackerStatsURL is the tracker endpoint reporting network totals,
+// including netspace
+func trackerStatsURL() string {
+	if url := os.Getenv("EXPLORER_TRACKER_STATS_URL"); url != "" {
+		return url
+	}
+	return trackerURL() + "/api/v1/stats"
+}
+
+// TrackerStats are the network totals reported by the tracker
+type TrackerStats struct {
+	TotalNodes      int    `json:"total_nodes"`
+	OnlineNodes     int    `json:"online_nodes"`
+	TotalNetspace   uint64 `json:"total_netspace_bytes"`
+	ConsensusHeight uint64 `json:"consensus_height"`
+}
+
+// TrackerNode is a farming node registered with the tracker
+type TrackerNode struct {
+	NodeID        string    `json:"node_id"`
+	MiningAddress string    `json:"mining_address"`
+	TotalPlotSize uint64    `json:"total_plot_size_bytes"`
+	Status        string    `json:"status"`
+	ChainHeight   uint64    `json:"chain_height"`
+	ChainHash     string    `json:"chain_hash"`
+	LastBlockTime time.Time `json:"last_block_time"`
+}
+
+// TrackerSnapshot is the tracker data last fetched successfully
+type TrackerSnapshot struct {
+	Stats     TrackerStats
+	Nodes     []TrackerNode // By node id
+	FetchedAt time.Time     // Zero until the first successful fetch
+	LastError string        // Why the latest refresh failed, if it did
+}
+
+// TrackerClient keeps a cached copy of the tracker's network stats and
+// node list, refreshed in the background, so pages never wait on the
+// tracker and keep the last known data while it is down
+type TrackerClient struct {
+	statsURL string
+	nodesURL string
+	client   *http.Client
+	interval time.Duration
+	ttl      time.Duration
+
+	mu       sync.RWMutex
+	snapshot TrackerSnapshot
+
+	stopCh chan struct{}
+}
+
+// NewTrackerClient creates a client for the tracker API at baseURL
+func NewTrackerClient(baseURL string, interval time.Duration) *TrackerClient {
+	return &TrackerClient{
+		statsURL: baseURL + "/api/v1/stats",
+		nodesURL: baseURL + "/api/v1/nodes",
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: interval,
+		ttl:      trackerCacheTTL,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// newTrackerClientFromEnv creates the tracker client configured by
+// EXPLORER_TRACKER_URL and EXPLORER_TRACKER_STATS_URL
+func newTrackerClientFromEnv() *TrackerClient {
+	tc := NewTrackerClient(trackerURL(), trackerRefreshInterval)
+	tc.statsURL = trackerStatsURL()
+	return tc
+}
+
+// Start refreshes once and then keeps refreshing in the background
+func (tc *TrackerClient) Start() {
+	tc.Refresh()
+
+	go func() {
+		ticker := time.NewTicker(tc.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				tc.Refresh()
+			case <-tc.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background refresh
+func (tc *TrackerClient) Stop() {
+	close(tc.stopCh)
+}
+
+// Refresh fetches the tracker's stats and nodes once. On failure the
+// previous data is kept and the error recorded.
+func (tc *TrackerClient) Refresh() error {
+	var stats TrackerStats
+	err := tc.fetch(tc.statsURL, &stats)
+
+	var listing struct {
+		Nodes map[string]TrackerNode `json:"nodes"`
+	}
+	if err == nil {
+		err = tc.fetch(tc.nodesURL, &listing)
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if err != nil {
+		slog.Warn("Failed to refresh tracker data", "err", err)
+		tc.snapshot.LastError = err.Error()
+		return err
+	}
+
+	nodes := make([]TrackerNode, 0, len(listing.Nodes))
+	for id, node := range listing.Nodes {
+		if node.NodeID == "" {
+			node.NodeID = id
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+
+	tc.snapshot = TrackerSnapshot{Stats: stats, Nodes: nodes, FetchedAt: time.Now()}
+	return nil
+}
+
+// fetch decodes the JSON served at url into dest
+func (tc *TrackerClient) fetch(url string, dest interface{}) error {
+	resp, err := tc.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// Snapshot returns the cached tracker data and whether it is stale:
+// never fetched, or last fetched longer ago than the cache TTL. A nil
+// client has no data.
+func (tc *TrackerClient) Snapshot() (TrackerSnapshot, bool) {
+	if tc == nil {
+		return TrackerSnapshot{LastError: "tracker client disabled"}, true
+	}
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return tc.snapshot, tc.snapshot.FetchedAt.IsZero() || time.Since(tc.snapshot.FetchedAt) > tc.ttl
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestTracker serves tracker stats and nodes until down is set
+func newTestTracker(t *testing.T, down *bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if *down {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/stats":
+			w.Write([]byte(`{"total_nodes":2,"online_nodes":1,"total_netspace_bytes":4000,"consensus_height":42}`))
+		case "/api/v1/nodes":
+			w.Write([]byte(`{"nodes":{
+				"node-b":{"mining_address":"bob","total_plot_size_bytes":1000,"status":"offline"},
+				"node-a":{"node_id":"node-a","mining_address":"alice","total_plot_size_bytes":3000,"status":"online"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTrackerClientRefresh(t *testing.T) {
+	down := false
+	server := newTestTracker(t, &down)
+	tc := NewTrackerClient(server.URL, time.Hour)
+
+	if _, stale := tc.Snapshot(); !stale {
+		t.Error("Expected data never fetched to be stale")
+	}
+	if err := tc.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	snapshot, stale := tc.Snapshot()
+	if stale || snapshot.Stats.TotalNetspace != 4000 || snapshot.Stats.ConsensusHeight != 42 {
+		t.Errorf("Unexpected snapshot %+v (stale %v)", snapshot, stale)
+	}
+	if len(snapshot.Nodes) != 2 || snapshot.Nodes[0].NodeID != "node-a" || snapshot.Nodes[1].NodeID != "node-b" {
+		t.Errorf("Expected nodes sorted by id, named by their key when unnamed, got %+v", snapshot.Nodes)
+	}
+
+	// A failed refresh keeps serving the last data until it ages out
+	down = true
+	if err := tc.Refresh(); err == nil {
+		t.Fatal("Expected refresh to fail while the tracker is down")
+	}
+	snapshot, stale = tc.Snapshot()
+	if stale || len(snapshot.Nodes) != 2 || snapshot.LastError == "" {
+		t.Errorf("Expected the previous data with the error recorded, got %+v (stale %v)", snapshot, stale)
+	}
+	tc.ttl = 0
+	if _, stale := tc.Snapshot(); !stale {
+		t.Error("Expected data older than the cache TTL to be stale")
+	}
+
+	var disabled *TrackerClient
+	if snapshot, stale := disabled.Snapshot(); !stale || len(snapshot.Nodes) != 0 {
+		t.Errorf("Expected a nil client to report stale and empty, got %+v", snapshot)
+	}
+}
+
+func TestStorageAPIWithoutTracker(t *testing.T) {
+	down := true
+	server := newTestTracker(t, &down)
+	es := &ExplorerServer{database: newTestDatabase(t), tracker: NewTrackerClient(server.URL, time.Hour)}
+	es.tracker.Refresh()
+
+	rec := httptest.NewRecorder()
+	es.handleStorageAPI(rec, httptest.NewRequest("GET", "/api/v1/storage", nil))
+	var result struct {
+		Stale      bool                     `json:"stale"`
+		TotalNodes int                      `json:"total_nodes"`
+		Nodes      []map[string]interface{} `json:"nodes"`
+		Error      string                   `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if !result.Stale || result.TotalNodes != 0 || len(result.Nodes) != 0 || result.Error == "" {
+		t.Errorf("Expected an empty stale response instead of made-up nodes, got %+v", result)
+	}
+
+	down = false
+	es.tracker.Refresh()
+	rec = httptest.NewRecorder()
+	es.handleStorageAPI(rec, httptest.NewRequest("GET", "/api/v1/storage", nil))
+	result.Error = ""
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if result.Stale || result.TotalNodes != 2 || len(result.Nodes) != 2 || result.Nodes[0]["mining_address"] != "alice" {
+		t.Errorf("Expected the tracker's nodes once it answers, got %+v", result)
+	}
+}
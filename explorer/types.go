@@ -32,6 +32,10 @@ type BlockBody struct {
 	Transactions     []SignedTransaction `json:"transactions"`
 	TxCount          uint32              `json:"tx_count"`
 	TransactionsHash string              `json:"transactions_hash"`
+	// Pruned blocks have had their transactions dropped to save space;
+	// PrunedSize is how large the block was before
+	Pruned     bool `json:"pruned,omitempty"`
+	PrunedSize int  `json:"pruned_size,omitempty"`
 }
 
 // SignedTransaction represents a signed transaction
@@ -81,6 +85,7 @@ const (
 	TRADE_EXECUTE                   // Execute/accept a trade offer
 	SYNDICATE_JOIN                  // Join a mining syndicate (creates membership NFT)
 	POOL_CREATE                     // Create a new liquidity pool NFT
+	POOL_SWAP                       // Swap tokens through a liquidity pool
 )
 
 // String returns the string representation of TokenOpType
@@ -100,6 +105,8 @@ func (t TokenOpType) String() string {
 		return "SYNDICATE_JOIN"
 	case POOL_CREATE:
 		return "POOL_CREATE"
+	case POOL_SWAP:
+		return "POOL_SWAP"
 	default:
 		return "UNKNOWN"
 	}
@@ -115,6 +122,29 @@ type TokenMetadata struct {
 	Creator      string `json:"creator"`       // Address of token creator
 	CreationTime int64  `json:"creation_time"` // Unix timestamp of creation
 	URI          string `json:"uri,omitempty"` // Optional URI for metadata/NFT content (max 128 chars)
+
+	LiquidityPool *LiquidityPoolData `json:"liquidity_pool,omitempty"` // Pool parameters (for POOL_CREATE)
+	PoolSwap      *PoolSwapData      `json:"pool_swap,omitempty"`      // Swap parameters (for POOL_SWAP)
+}
+
+// LiquidityPoolData is the pool definition carried by a POOL_CREATE operation
+type LiquidityPoolData struct {
+	TokenA        string `json:"token_a"`         // First token ID in the pair (or "SHADOW")
+	TokenB        string `json:"token_b"`         // Second token ID in the pair (or "SHADOW")
+	InitialRatioA uint64 `json:"initial_ratio_a"` // Initial amount of token A
+	InitialRatioB uint64 `json:"initial_ratio_b"` // Initial amount of token B
+	FeeRate       uint64 `json:"fee_rate"`        // Fee rate in basis points
+	LAddress      string `json:"l_address"`       // Pool's L-address, the target of swaps
+	Creator       string `json:"creator"`         // Pool creator address
+}
+
+// PoolSwapData is the swap carried by a POOL_SWAP operation
+type PoolSwapData struct {
+	PoolLAddress   string `json:"pool_l_address"`         // L-address of the target pool
+	InputTokenID   string `json:"input_token_id"`         // Token being swapped from (or "SHADOW")
+	OutputTokenID  string `json:"output_token_id"`        // Token being swapped to (or "SHADOW")
+	MinReceived    uint64 `json:"min_received,omitempty"` // Minimum output amount
+	SwapperAddress string `json:"swapper_address"`        // Address performing the swap
 }
 
 // TokenOperation represents a token-related operation
@@ -138,8 +168,34 @@ type WalletTransaction struct {
 	Fee         uint64    `json:"fee"`
 	FromAddress string    `json:"from_address"`
 	ToAddress   string    `json:"to_address"`
-	TokenSymbol string    `json:"token_symbol,omitempty"`
-	TokenAmount uint64    `json:"token_amount,omitempty"`
+
+	// What the transaction's token operations did to each address's token
+	// balances, one entry per address and token
+	AssetChanges []AssetChange `json:"asset_changes,omitempty"`
+
+	// Derived from the canonical tip when served, not meaningful in storage
+	Confirmations uint64 `json:"confirmations"`
+	Final         bool   `json:"final"`
+
+	// Filled in from the address labels when served
+	FromLabel *AddressLabel `json:"from_label,omitempty"`
+	ToLabel   *AddressLabel `json:"to_label,omitempty"`
+}
+
+// AssetChange is one address's net change in one token's balance from a
+// transaction
+type AssetChange struct {
+	Address string `json:"address"`
+	TokenID string `json:"token_id"`
+	Symbol  string `json:"symbol"`
+	Delta   int64  `json:"delta"` // Token base units; negative when the address gave tokens up
+}
+
+// WalletExportRow is one transaction in a wallet history export
+type WalletExportRow struct {
+	WalletTransaction
+	Change  int64 `json:"change"`  // Effect on the address's balance
+	Balance int64 `json:"balance"` // Balance after this transaction
 }
 
 // WalletSummary represents wallet statistics
@@ -150,8 +206,10 @@ type WalletSummary struct {
 	BlocksMined        int                 `json:"blocks_mined"`
 	FirstActivity      time.Time           `json:"first_activity"`
 	LastActivity       time.Time           `json:"last_activity"`
+	PrunedTransactions int                 `json:"pruned_transactions,omitempty"` // Counted but no longer listed
 	Transactions       []WalletTransaction `json:"transactions"`
 	TokenBalances      []TokenBalance      `json:"token_balances"`
+	Label              *AddressLabel       `json:"label,omitempty"`
 }
 
 // TokenInfo represents token statistics for the explorer
@@ -175,19 +233,92 @@ type TokenInfo struct {
 	MeltValue      uint64    `json:"melt_value"` // Total SHADOW locked
 }
 
+// NFTMetadata is the resolved metadata document of an NFT's URI
+type NFTMetadata struct {
+	TokenID     string          `json:"token_id"`
+	Creator     string          `json:"creator"`
+	URI         string          `json:"uri"`
+	Status      string          `json:"status"` // "pending", "resolved" or "failed"
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Image       string          `json:"image,omitempty"`     // As given in the metadata
+	ImageURL    string          `json:"image_url,omitempty"` // Cached copy served by the explorer
+	ExternalURL string          `json:"external_url,omitempty"`
+	Attributes  json.RawMessage `json:"attributes,omitempty"`
+	Document    json.RawMessage `json:"document,omitempty"` // The whole metadata JSON
+	FetchedAt   time.Time       `json:"fetched_at,omitempty"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// NFTDetails is an NFT with its current owner and resolved metadata
+type NFTDetails struct {
+	Token    TokenInfo    `json:"token"`
+	Owner    string       `json:"owner,omitempty"`
+	Metadata *NFTMetadata `json:"metadata"`
+}
+
+// NFTCollection groups the NFTs minted by one creator
+type NFTCollection struct {
+	Creator string        `json:"creator"`
+	Items   []NFTMetadata `json:"items"`
+}
+
 // PaginatedTokens represents a paginated response of tokens
 type PaginatedTokens struct {
-	Tokens      []TokenInfo `json:"tokens"`
-	CurrentPage int         `json:"current_page"`
-	TotalPages  int         `json:"total_pages"`
-	TotalTokens int64       `json:"total_tokens"`
-	PerPage     int         `json:"per_page"`
+	Tokens      []TokenInfo     `json:"tokens"`
+	CurrentPage int             `json:"current_page"`
+	TotalPages  int             `json:"total_pages"`
+	TotalTokens int64           `json:"total_tokens"` // Tokens matching the search
+	PerPage     int             `json:"per_page"`
+	Aggregates  TokenAggregates `json:"aggregates"`
+	NextCursor  string          `json:"next_cursor,omitempty"` // Pass as ?cursor= for the next page
+}
+
+// TokenAggregates summarizes every token, independent of search and paging
+type TokenAggregates struct {
+	TotalTokens      int64  `json:"total_tokens"`
+	ActiveTokens     int64  `json:"active_tokens"`      // Tokens with non-zero supply
+	TotalValueLocked uint64 `json:"total_value_locked"` // Sum of melt values in satoshis
 }
 
 // TokenHolder represents someone who holds a token
 type TokenHolder struct {
 	Address string `json:"address"`
 	Balance uint64 `json:"balance"`
+	Rank    int    `json:"rank,omitempty"` // 1 for the largest holder, in ranked listings
+
+	Label *AddressLabel `json:"label,omitempty"`
+}
+
+// PaginatedTokenHolders is one page of a token's holders, largest first
+type PaginatedTokenHolders struct {
+	TokenID      string        `json:"token_id"`
+	Holders      []TokenHolder `json:"holders"`
+	CurrentPage  int           `json:"current_page"`
+	TotalPages   int           `json:"total_pages"`
+	TotalHolders int           `json:"total_holders"` // Addresses with a positive balance
+	PerPage      int           `json:"per_page"`
+}
+
+// TokenTransferFilter narrows a token's transfer log; empty fields match
+// every transfer
+type TokenTransferFilter struct {
+	From string // Sender address
+	To   string // Recipient address
+	Type string // Operation type as stored, e.g. "TRANSFER"; matched case-insensitively
+}
+
+// PaginatedTokenTransfers is one page of a token's transfer log, newest
+// first
+type PaginatedTokenTransfers struct {
+	TokenID        string             `json:"token_id"`
+	Transfers      []TokenTransaction `json:"transfers"`
+	CurrentPage    int                `json:"current_page"`
+	TotalPages     int                `json:"total_pages"`
+	TotalTransfers int                `json:"total_transfers"` // Transfers matching the filter
+	PerPage        int                `json:"per_page"`
 }
 
 // TokenTransaction represents a token-specific transaction
@@ -200,6 +331,52 @@ type TokenTransaction struct {
 	Amount      uint64    `json:"amount"`
 	FromAddress string    `json:"from_address"`
 	ToAddress   string    `json:"to_address"`
+
+	// Filled in from the address labels when served
+	FromLabel *AddressLabel `json:"from_label,omitempty"`
+	ToLabel   *AddressLabel `json:"to_label,omitempty"`
+}
+
+// MeltEvent records tokens melted back into the SHADOW locked behind them
+type MeltEvent struct {
+	TxHash         string    `json:"tx_hash"`
+	TokenID        string    `json:"token_id"`
+	BlockHeight    uint64    `json:"block_height"`
+	Timestamp      time.Time `json:"timestamp"`
+	Burner         string    `json:"burner"`
+	Amount         uint64    `json:"amount"`          // Token units melted
+	ShadowReleased uint64    `json:"shadow_released"` // Satoshi returned to the burner
+
+	// Filled in from the address labels when served
+	BurnerLabel *AddressLabel `json:"burner_label,omitempty"`
+}
+
+// PaginatedTokenMelts is one page of a token's melts, newest first, with
+// totals over all of them
+type PaginatedTokenMelts struct {
+	TokenID             string      `json:"token_id"`
+	Melts               []MeltEvent `json:"melts"`
+	CurrentPage         int         `json:"current_page"`
+	TotalPages          int         `json:"total_pages"`
+	TotalMelts          int         `json:"total_melts"`
+	PerPage             int         `json:"per_page"`
+	TotalMelted         uint64      `json:"total_melted"`
+	TotalShadowReleased uint64      `json:"total_shadow_released"`
+}
+
+// TokenActivity summarizes one address's history with one token
+type TokenActivity struct {
+	TokenID       string    `json:"token_id"`
+	TokenName     string    `json:"token_name"`
+	TokenTicker   string    `json:"token_ticker"`
+	Balance       uint64    `json:"balance"` // Current balance, may be zero
+	Created       int       `json:"created"`
+	Sent          int       `json:"sent"`
+	Received      int       `json:"received"`
+	Melted        int       `json:"melted"`
+	TxCount       int       `json:"tx_count"`
+	FirstActivity time.Time `json:"first_activity"`
+	LastActivity  time.Time `json:"last_activity"`
 }
 
 // TokenDetails represents detailed token information
@@ -207,6 +384,11 @@ type TokenDetails struct {
 	TokenInfo
 	Holders      []TokenHolder      `json:"holders"`
 	Transactions []TokenTransaction `json:"recent_transactions"`
+
+	// Concentration over every holder, not just the returned slice
+	Top10Share         float64 `json:"top10_share"`         // Fraction of the held supply owned by the ten largest holders
+	Gini               float64 `json:"gini"`                // 0 for equal balances, approaching 1 when one holder owns everything
+	HighlyConcentrated bool    `json:"highly_concentrated"` // Top10Share at or above highConcentrationShare
 }
 
 // JOSEHeader for JWT-style signing
@@ -223,6 +405,64 @@ type BlockInfo struct {
 	TxCount       int       `json:"tx_count"`
 	FarmerAddress string    `json:"farmer_address"`
 	Size          int       `json:"size"`
+
+	FarmerLabel *AddressLabel `json:"farmer_label,omitempty"`
+}
+
+// BlockWithHash is a full block alongside the hash it is stored under
+type BlockWithHash struct {
+	Hash  string `json:"hash"`
+	Block Block  `json:"block"`
+}
+
+// BlockAlert flags a synced block that failed verification
+type BlockAlert struct {
+	Height     uint64    `json:"height"`
+	Hash       string    `json:"hash"`      // Hash the block is stored under
+	NodeHash   string    `json:"node_hash"` // Tendermint block ID
+	Node       string    `json:"node"`      // Node the block was fetched from
+	Problems   []string  `json:"problems"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// BlockAlerts is the payload of /api/v1/alerts
+type BlockAlerts struct {
+	Alerts []BlockAlert `json:"alerts"` // Highest block first
+	Total  int          `json:"total"`
+}
+
+// OrphanBlock is a block that lost its height to a competing block, kept
+// after the reorg or replacement that displaced it
+type OrphanBlock struct {
+	Height        uint64    `json:"height"`
+	Hash          string    `json:"hash"`                // Hash the block was stored under
+	NodeHash      string    `json:"node_hash,omitempty"` // Tendermint block ID
+	Timestamp     time.Time `json:"timestamp"`
+	FarmerAddress string    `json:"farmer_address"`
+	TxCount       int       `json:"tx_count"`
+	Reason        string    `json:"reason"` // "reorg" or "replaced"
+	OrphanedAt    time.Time `json:"orphaned_at"`
+	Block         *Block    `json:"block,omitempty"` // Only when looked up by hash
+}
+
+// OrphanBlocks is the payload of /api/v1/orphans
+type OrphanBlocks struct {
+	Orphans []OrphanBlock `json:"orphans"` // Highest block first
+	Total   int           `json:"total"`
+}
+
+// BlockRange is the payload of /api/v1/blocks/range
+type BlockRange struct {
+	From   uint64          `json:"from"`
+	To     uint64          `json:"to"`
+	Blocks []BlockWithHash `json:"blocks"`
+}
+
+// LatestBlock is the chain tip as served by /api/v1/block/latest
+type LatestBlock struct {
+	Hash    string      `json:"hash"`
+	Header  BlockHeader `json:"header"`
+	Summary BlockInfo   `json:"summary"`
 }
 
 // PaginatedBlocks represents a paginated response of blocks
@@ -232,6 +472,82 @@ type PaginatedBlocks struct {
 	TotalPages  int         `json:"total_pages"`
 	TotalBlocks int64       `json:"total_blocks"`
 	PerPage     int         `json:"per_page"`
+	NextCursor  string      `json:"next_cursor,omitempty"` // Pass as ?cursor= for the next page
+}
+
+// PaginatedTransactions represents a page of an address's transactions
+type PaginatedTransactions struct {
+	Address      string              `json:"address"`
+	Transactions []WalletTransaction `json:"transactions"`
+	PerPage      int                 `json:"per_page"`
+	NextCursor   string              `json:"next_cursor,omitempty"`
+	Label        *AddressLabel       `json:"label,omitempty"`
+}
+
+// MinedBlock is a block farmed by an address, as recorded in the farmer index
+type MinedBlock struct {
+	Height    uint64    `json:"height"`
+	Hash      string    `json:"hash"`
+	Timestamp time.Time `json:"timestamp"`
+	Reward    uint64    `json:"reward"`
+	Address   string    `json:"address,omitempty"` // Farmer, when listing blocks of every farmer
+}
+
+// FarmerSummary is one farmer's standing on the leaderboard
+type FarmerSummary struct {
+	Rank          int       `json:"rank"`
+	Address       string    `json:"address"`
+	Blocks        int       `json:"blocks"`
+	Rewards       uint64    `json:"rewards"`
+	Share         float64   `json:"share"`          // Percent of the window's blocks, an estimate of netspace share
+	LongestStreak int       `json:"longest_streak"` // Most consecutive blocks won within the window
+	LastHeight    uint64    `json:"last_height"`
+	LastBlock     time.Time `json:"last_block"`
+
+	Label *AddressLabel `json:"label,omitempty"`
+}
+
+// FarmerLeaderboard ranks farmers by blocks won over a rolling window
+type FarmerLeaderboard struct {
+	Window      string          `json:"window"`
+	Since       time.Time       `json:"since"`
+	TotalBlocks int             `json:"total_blocks"`
+	Farmers     []FarmerSummary `json:"farmers"`
+}
+
+// FarmerDay is the number of blocks a farmer won on one UTC day
+type FarmerDay struct {
+	Date   string `json:"date"`
+	Blocks int    `json:"blocks"`
+}
+
+// FarmerStats summarizes an address's farming history
+type FarmerStats struct {
+	Address       string      `json:"address"`
+	TotalBlocks   int         `json:"total_blocks"`
+	TotalRewards  uint64      `json:"total_rewards"`
+	FirstBlock    time.Time   `json:"first_block"`
+	LastBlock     time.Time   `json:"last_block"`
+	LastHeight    uint64      `json:"last_height"`
+	Blocks24h     int         `json:"blocks_24h"`
+	Blocks7d      int         `json:"blocks_7d"`
+	Blocks30d     int         `json:"blocks_30d"`
+	NetspaceShare float64     `json:"netspace_share"` // Percent of the last 7 days' blocks
+	CurrentStreak int         `json:"current_streak"` // Consecutive blocks won up to the tip
+	LongestStreak int         `json:"longest_streak"`
+	DailyBlocks   []FarmerDay `json:"daily_blocks"` // Last 30 days, oldest first
+
+	Label *AddressLabel `json:"label,omitempty"`
+}
+
+// PaginatedMinedBlocks represents a paginated response of blocks farmed by an address
+type PaginatedMinedBlocks struct {
+	Address     string       `json:"address"`
+	Blocks      []MinedBlock `json:"blocks"`
+	CurrentPage int          `json:"current_page"`
+	TotalPages  int          `json:"total_pages"`
+	TotalBlocks int64        `json:"total_blocks"`
+	PerPage     int          `json:"per_page"`
 }
 
 // NetworkStats represents blockchain network statistics
@@ -241,11 +557,42 @@ type NetworkStats struct {
 	LastSync     time.Time `json:"last_sync"`
 	SyncStatus   string    `json:"sync_status"`
 	NodeURL      string    `json:"node_url"`
+	OrphanCount  int       `json:"orphan_count"` // Blocks displaced by reorgs and competing blocks
+
+	// How far the index trails the node, for stale-data warnings
+	SyncLag
+}
+
+// StorageNode is a farming node as shown on the storage page
+type StorageNode struct {
+	NodeID        string  `json:"node_id"`
+	MiningAddress string  `json:"mining_address"`
+	PlotSize      uint64  `json:"plot_size"`
+	Status        string  `json:"status"`
+	SuccessRate   float64 `json:"success_rate"` // Percent of the blocks its plots should have won, last 7 days
+	NetspaceShare float64 `json:"netspace_share"`
+	BlocksFound   int     `json:"blocks_found"`
+	LastBlockTime string  `json:"last_block_time"` // RFC 3339, empty if it never won a block
+}
+
+// StorageOverview is the payload of /api/v1/storage. Stale means the
+// tracker has not answered lately and the rest is the last data it gave.
+type StorageOverview struct {
+	TotalNodes      int           `json:"total_nodes"`
+	OnlineNodes     int           `json:"online_nodes"`
+	TotalNetspace   uint64        `json:"total_netspace"`
+	ConsensusHeight uint64        `json:"consensus_height"`
+	AvgSuccessRate  float64       `json:"avg_success_rate"`
+	Nodes           []StorageNode `json:"nodes"`
+	Stale           bool          `json:"stale"`
+	UpdatedAt       *time.Time    `json:"updated_at,omitempty"`
+	Error           string        `json:"error,omitempty"`
 }
 
 // LiquidityPool represents a liquidity pool
 type LiquidityPool struct {
 	PoolID         string    `json:"pool_id"`
+	LAddress       string    `json:"l_address,omitempty"` // Pool's L-address, where swaps are sent
 	TokenA         string    `json:"token_a"`          // First token ID
 	TokenB         string    `json:"token_b"`          // Second token ID (empty for SHADOW pairs)
 	TokenASymbol   string    `json:"token_a_symbol"`   // Token A ticker
@@ -263,7 +610,15 @@ type LiquidityPool struct {
 	VolumeB        uint64    `json:"volume_b"`         // Total volume in token B
 	LastActivity   time.Time `json:"last_activity"`
 	APR            float64   `json:"apr"`              // Annual percentage return
-	TVL            uint64    `json:"tvl"`              // Total value locked in SHADOW
+	FeeRate        uint64    `json:"fee_rate"`         // Swap fee in basis points; 0 means defaultPoolFeeRate
+	TVL            uint64    `json:"tvl"`              // Total value locked, in SHADOW base units
+
+	// SHADOW-equivalent valuation: what a base unit of each token is worth
+	// in SHADOW base units, routed through other pools for token/token
+	// pairs; zero while a token has no path to SHADOW
+	PriceA         float64   `json:"price_a_shadow"`
+	PriceB         float64   `json:"price_b_shadow"`
+	VolumeShadow   uint64    `json:"volume_shadow"`    // Total volume, each swap valued in SHADOW when it traded
 }
 
 // PaginatedPools represents a paginated response of pools
@@ -273,6 +628,7 @@ type PaginatedPools struct {
 	TotalPages  int             `json:"total_pages"`
 	TotalPools  int64           `json:"total_pools"`
 	PerPage     int             `json:"per_page"`
+	NextCursor  string          `json:"next_cursor,omitempty"` // Pass as ?cursor= for the next page
 }
 
 // PoolTransaction represents a pool-related transaction
@@ -286,11 +642,53 @@ type PoolTransaction struct {
 	AmountB     uint64    `json:"amount_b"`    // Amount of token B
 	Address     string    `json:"address"`     // User address
 	LPTokens    uint64    `json:"lp_tokens"`   // LP tokens minted/burned
+
+	// Swaps only
+	Direction   string    `json:"direction,omitempty"`    // "a_to_b" or "b_to_a"
+	PriceBefore float64   `json:"price_before,omitempty"` // Spot price (token B per token A) before the swap
+	PriceAfter  float64   `json:"price_after,omitempty"`  // Spot price after the swap
+	PriceImpact float64   `json:"price_impact,omitempty"` // Percent the swap moved the spot price
+
+	Label *AddressLabel `json:"label,omitempty"` // Filled in from the address labels when served
+}
+
+// PoolCandle is one interval of a pool's price (token B per token A) and
+// trading volume
+type PoolCandle struct {
+	Time    time.Time `json:"time"` // Start of the interval
+	Open    float64   `json:"open"`
+	High    float64   `json:"high"`
+	Low     float64   `json:"low"`
+	Close   float64   `json:"close"`
+	VolumeA uint64    `json:"volume_a"`
+	VolumeB uint64    `json:"volume_b"`
+	Trades  int       `json:"trades"`
+}
+
+// PoolCandles is a pool's price history at one interval, oldest first
+type PoolCandles struct {
+	PoolID   string       `json:"pool_id"`
+	Interval string       `json:"interval"`
+	Candles  []PoolCandle `json:"candles"`
+}
+
+// PoolPriceUpdate is pushed to live pool feeds whenever a pool event is indexed
+type PoolPriceUpdate struct {
+	PoolID       string    `json:"pool_id"`
+	TokenASymbol string    `json:"token_a_symbol"`
+	TokenBSymbol string    `json:"token_b_symbol"`
+	ReserveA     uint64    `json:"reserve_a"`
+	ReserveB     uint64    `json:"reserve_b"`
+	SpotPrice    float64   `json:"spot_price"` // Token B per token A
+	TxHash       string    `json:"tx_hash,omitempty"`
+	TxType       string    `json:"tx_type,omitempty"` // Pool transaction that moved the price
+	Timestamp    time.Time `json:"timestamp"`
 }
 
 // PoolDetails represents detailed pool information
 type PoolDetails struct {
 	LiquidityPool
+	PoolActivity
 	Transactions []PoolTransaction `json:"recent_transactions"`
 }
 
@@ -303,6 +701,283 @@ type WalletOverview struct {
 	FirstActivity     time.Time              `json:"first_activity"`
 	LastActivity      time.Time              `json:"last_activity"`
 	TokenBalances     []TokenBalance         `json:"token_balances"`
+	Label             *AddressLabel          `json:"label,omitempty"`
+}
+
+// SupplyCheck is the result of reconciling wallet balances against the
+// supply implied by mining emission and burns
+type SupplyCheck struct {
+	Emission            uint64    `json:"emission"`             // Sum of mining rewards
+	Burned              uint64    `json:"burned"`               // Fees and outputs with no recipient
+	CirculatingSupply   int64     `json:"circulating_supply"`   // Emission minus burns
+	WalletBalanceTotal  int64     `json:"wallet_balance_total"` // Sum of every indexed wallet balance
+	Discrepancy         int64     `json:"discrepancy"`          // Wallet total minus circulating supply
+	Consistent          bool      `json:"consistent"`
+	TransactionsChecked int       `json:"transactions_checked"`
+	WalletsChecked      int       `json:"wallets_checked"`
+	NegativeBalances    []string  `json:"negative_balances,omitempty"` // Wallets that spent more than they received
+	CheckedAt           time.Time `json:"checked_at"`
+}
+
+// DailyStats aggregates chain activity for one UTC day
+type DailyStats struct {
+	Date            string  `json:"date"` // YYYY-MM-DD
+	Blocks          int     `json:"blocks"`
+	Transactions    int     `json:"transactions"`     // Excludes mining rewards
+	NewAddresses    int     `json:"new_addresses"`    // Addresses first seen on this day
+	ActiveAddresses int     `json:"active_addresses"` // Distinct senders and recipients
+	Volume          uint64  `json:"volume"`
+	AverageFee      float64 `json:"average_fee"`
+	Finalized       bool    `json:"finalized"` // Day is complete and served from cache
+}
+
+// DailyStatsResponse is the payload of the daily stats endpoint
+type DailyStatsResponse struct {
+	RangeDays int          `json:"range_days"`
+	Days      []DailyStats `json:"days"`
+}
+
+// AddressGrowthPoint is the address count at the end of one UTC day
+type AddressGrowthPoint struct {
+	Date           string `json:"date"` // YYYY-MM-DD
+	NewAddresses   uint64 `json:"new_addresses"`
+	TotalAddresses uint64 `json:"total_addresses"` // Distinct addresses seen up to and including this day
+}
+
+// AddressGrowthResponse is the payload of the address growth endpoint
+type AddressGrowthResponse struct {
+	RangeDays int                  `json:"range_days"`
+	Days      []AddressGrowthPoint `json:"days"`
+}
+
+// RichListEntry is one wallet in the rich list
+type RichListEntry struct {
+	Rank    int     `json:"rank"`
+	Address string  `json:"address"`
+	Balance uint64  `json:"balance"`
+	Share   float64 `json:"share"` // Percent of all positive balances
+
+	Label *AddressLabel `json:"label,omitempty"`
+}
+
+// RichList is the payload of the rich list endpoint
+type RichList struct {
+	Holders      []RichListEntry `json:"holders"`
+	TotalHolders int             `json:"total_holders"` // Wallets with a positive balance
+	TotalBalance uint64          `json:"total_balance"`
+}
+
+// BalanceBucket counts the wallets whose balance falls in [Min, Max)
+type BalanceBucket struct {
+	Label   string  `json:"label"`
+	Min     uint64  `json:"min"`
+	Max     uint64  `json:"max"` // 0 for the open-ended top bucket
+	Holders int     `json:"holders"`
+	Balance uint64  `json:"balance"`
+	Share   float64 `json:"share"` // Percent of all positive balances held in the bucket
+}
+
+// BalanceDistribution summarizes how SHADOW is spread across wallets
+type BalanceDistribution struct {
+	TotalHolders int             `json:"total_holders"`
+	TotalBalance uint64          `json:"total_balance"`
+	Gini         float64         `json:"gini"`
+	Top10Share   float64         `json:"top10_share"` // Fraction held by the ten largest wallets
+	Buckets      []BalanceBucket `json:"buckets"`
+}
+
+// ChartSample is one block's contribution to the chart rollups
+type ChartSample struct {
+	Height          uint64    `json:"height"`
+	Timestamp       time.Time `json:"timestamp"`
+	IntervalSeconds float64   `json:"interval_seconds"` // Since the previous block; 0 when unknown
+	HasInterval     bool      `json:"has_interval"`
+	Transactions    int       `json:"transactions"` // Excludes mining rewards
+	Fees            uint64    `json:"fees"`
+	Addresses       []string  `json:"addresses"`          // Distinct senders and recipients
+	Netspace        uint64    `json:"netspace,omitempty"` // Bytes, sampled from the tracker near the tip
+	Size            int       `json:"size,omitempty"`     // Block bytes as stored
+	MaxSize         int       `json:"max_size,omitempty"` // Block size limit when synced
+	TxBytes         int       `json:"tx_bytes,omitempty"` // Bytes of the transactions counted in Fees
+}
+
+// ChartRollup aggregates the blocks whose timestamps fall in one hour or day
+type ChartRollup struct {
+	Start           time.Time `json:"start"`
+	Blocks          int       `json:"blocks"`
+	IntervalSeconds float64   `json:"interval_seconds"` // Sum over Intervals blocks
+	Intervals       int       `json:"intervals"`
+	Transactions    int       `json:"transactions"`
+	Fees            uint64    `json:"fees"`
+	ActiveAddresses int       `json:"active_addresses"`
+	NetspaceSum     uint64    `json:"netspace_sum"` // Sum over NetspaceSamples blocks
+	NetspaceSamples int       `json:"netspace_samples"`
+	SizedBlocks     int       `json:"sized_blocks"`  // Blocks synced with their size recorded
+	Bytes           uint64    `json:"bytes"`         // Sum over SizedBlocks
+	MaxBytes        uint64    `json:"max_bytes"`     // Sum of their size limits
+	TxBytes         uint64    `json:"tx_bytes"`      // Transaction bytes of the blocks that had any
+	TxBytesFees     uint64    `json:"tx_bytes_fees"` // Fees paid by those bytes
+}
+
+// ChartPoint is one bucket of a chart series; Value is null when the
+// bucket has no data for the metric
+type ChartPoint struct {
+	Time  time.Time `json:"time"`
+	Value *float64  `json:"value"`
+}
+
+// ChartSeries is the payload of the chart endpoint
+type ChartSeries struct {
+	Metric      string       `json:"metric"`
+	Granularity string       `json:"granularity"` // "hour" or "day"
+	Points      []ChartPoint `json:"points"`      // Oldest first
+}
+
+// BlockFullnessPoint is one bucket of the block fullness chart; the
+// averages are null when the bucket has no sized blocks or transactions
+type BlockFullnessPoint struct {
+	Time         time.Time `json:"time"`
+	Blocks       int       `json:"blocks"`
+	Transactions int       `json:"transactions"`
+	AvgSize      *float64  `json:"avg_size"`     // Bytes per block
+	Fullness     *float64  `json:"fullness"`     // Share of the block size limit used, 0-1
+	FeePerByte   *float64  `json:"fee_per_byte"` // Base units per transaction byte
+}
+
+// BlockFullness is the payload of the block fullness chart endpoint
+type BlockFullness struct {
+	Granularity  string               `json:"granularity"`    // "hour" or "day"
+	MaxBlockSize int                  `json:"max_block_size"` // Current limit, bytes
+	Points       []BlockFullnessPoint `json:"points"`         // Oldest first
+
+	// Transactions over the last 168 hours by UTC weekday (Sunday first)
+	// and hour of day
+	Heatmap [7][24]int `json:"heatmap"`
+}
+
+// PendingTransaction is a transaction seen in the node's mempool
+type PendingTransaction struct {
+	TxHash     string    `json:"tx_hash"`
+	Fee        uint64    `json:"fee"`
+	Size       int       `json:"size"`   // Bytes
+	Amount     uint64    `json:"amount"` // Sum of outputs
+	Outputs    int       `json:"outputs"`
+	ToAddress  string    `json:"to_address,omitempty"` // First output
+	FirstSeen  time.Time `json:"first_seen"`
+	AgeSeconds float64   `json:"age_seconds"`
+	Status     string    `json:"status"` // "pending" or "confirmed"
+
+	// Set once the transaction lands in an indexed block
+	BlockHeight *uint64   `json:"block_height,omitempty"`
+	ConfirmedAt time.Time `json:"confirmed_at,omitempty"`
+	WaitSeconds float64   `json:"wait_seconds,omitempty"` // From first seen to the block
+}
+
+// MempoolSnapshot is the payload of the mempool endpoint
+type MempoolSnapshot struct {
+	Count             int                  `json:"count"`
+	TotalBytes        int                  `json:"total_bytes"`
+	TotalFees         uint64               `json:"total_fees"`
+	LastPoll          time.Time            `json:"last_poll"`
+	Error             string               `json:"error,omitempty"` // Why the last poll failed
+	Transactions      []PendingTransaction `json:"transactions"`       // Highest fee first
+	RecentlyConfirmed []PendingTransaction `json:"recently_confirmed"` // Newest first
+}
+
+// APIKey grants its holder a higher API quota than anonymous clients.
+// Only the SHA-256 of the secret is stored.
+type APIKey struct {
+	ID            string    `json:"id"` // Leading characters of the hash
+	Name          string    `json:"name"`
+	KeyHash       string    `json:"key_hash"`
+	RatePerMinute int       `json:"rate_per_minute"`
+	Burst         int       `json:"burst"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AuditEntry records a request to an admin route
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"` // "token", "client-cert:<CN>" or "loopback"; empty when rejected
+	RemoteAddr string    `json:"remote_addr"` // Empty in privacy mode
+	Method     string    `json:"method"`
+	Path       string    `json:"path"` // Including the query
+	Status     int       `json:"status"`
+}
+
+// WatchSubscription asks for a notification when an address receives or
+// sends funds or mines a block. Notifications go to a webhook or an email.
+// An email subscription stays pending, and notifies no one, until the link
+// mailed to the address is followed.
+type WatchSubscription struct {
+	ID           string    `json:"id"` // Random; whoever holds it can delete the subscription
+	Address      string    `json:"address"`
+	WebhookURL   string    `json:"webhook_url,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	Events       []string  `json:"events"`           // "received", "sent" and/or "mined"
+	Secret       string    `json:"secret,omitempty"` // Signs webhook bodies
+	Pending      bool      `json:"pending,omitempty"`
+	ConfirmToken string    `json:"confirm_token,omitempty"` // Mailed to confirm; never served
+	Owner        string    `json:"owner,omitempty"`         // Hash of the creating client; never served
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WatchEvent is the body of a watchlist notification
+type WatchEvent struct {
+	SubscriptionID string    `json:"subscription_id"`
+	Event          string    `json:"event"`
+	Address        string    `json:"address"`
+	TxHash         string    `json:"tx_hash"`
+	BlockHeight    uint64    `json:"block_height"`
+	BlockHash      string    `json:"block_hash"`
+	Timestamp      time.Time `json:"timestamp"`
+	Amount         uint64    `json:"amount"`
+	TokenSymbol    string    `json:"token_symbol,omitempty"`
+	TokenAmount    uint64    `json:"token_amount,omitempty"`
+	Counterparty   string    `json:"counterparty,omitempty"`
+}
+
+// WatchNotification is a queued delivery of an event, retried until it
+// succeeds or runs out of attempts
+type WatchNotification struct {
+	ID          string     `json:"id"`
+	Event       WatchEvent `json:"event"`
+	Attempts    int        `json:"attempts"`
+	NextAttempt time.Time  `json:"next_attempt"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// SearchResult is the entity a search query resolved to
+type SearchResult struct {
+	Query    string `json:"query"`
+	Type     string `json:"type"` // "block", "transaction", "wallet", "token" or "pool"
+	ID       string `json:"id"`
+	Redirect string `json:"redirect"` // Explorer page showing the entity
+}
+
+// AddressLabel names a known address, such as an exchange, a pool's
+// L-address, a burn address or a faucet
+type AddressLabel struct {
+	Address   string    `json:"address"`
+	Name      string    `json:"name"`
+	Category  string    `json:"category"`       // "exchange", "pool", "burn", "faucet" or "other"
+	Note      string    `json:"note,omitempty"`
+	Source    string    `json:"source"`         // "curated", "admin", or "pool" when derived from a pool
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// AddressLabels is the payload of the labels endpoint
+type AddressLabels struct {
+	Labels []AddressLabel `json:"labels"`
+	Total  int            `json:"total"`
+}
+
+// WalletWaitResponse is the payload of the wallet long-poll endpoint
+type WalletWaitResponse struct {
+	Address      string              `json:"address"`
+	SinceHeight  uint64              `json:"since_height"`
+	Transactions []WalletTransaction `json:"transactions"` // Oldest first; empty on timeout
+	TimedOut     bool                `json:"timed_out"`
 }
 
 // TokenBalance represents a token balance for a wallet
@@ -321,4 +996,5 @@ type PaginatedWallets struct {
 	TotalPages  int              `json:"total_pages"`
 	TotalWallets int64           `json:"total_wallets"`
 	PerPage     int              `json:"per_page"`
+	NextCursor  string           `json:"next_cursor,omitempty"` // Pass as ?cursor= for the next page
 }
\ No newline at end of file
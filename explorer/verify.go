@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"golang.org/x/crypto/sha3"
+)
+
+// With EXPLORER_VERIFY_BLOCKS=true each block is checked before it is
+// stored: its transactions against the header's merkle root, its link to
+// the block below, and the farmer its coinbase pays. Blocks that fail are
+// still indexed, so sync keeps up, but flagged with an alert that the API
+// and block page show.
+const (
+	blockAlertPrefix = "alert:block:"
+
+	defaultAlertsLimit = 50
+	maxAlertsLimit     = 500
+
+	// Layout of a Shadowy S-address: version, 20-byte hash, checksum
+	shadowyAddressVersion  = 0x42
+	shadowyAddressChecksum = 4
+	shadowyAddressLen      = 1 + 20 + shadowyAddressChecksum
+)
+
+// blockVerificationEnabled reports whether EXPLORER_VERIFY_BLOCKS asks for
+// blocks to be verified during sync
+func blockVerificationEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("EXPLORER_VERIFY_BLOCKS"))
+	return enabled
+}
+
+func blockAlertKey(height uint64) []byte {
+	return []byte(fmt.Sprintf("%s%016d", blockAlertPrefix, height))
+}
+
+// merkleRoot computes Tendermint's merkle root of items (RFC 6962: leaves
+// and inner nodes are hashed with distinct prefixes, and the tree splits at
+// the largest power of two below its size), as used for a block's data hash
+func merkleRoot(items [][]byte) []byte {
+	switch len(items) {
+	case 0:
+		empty := sha256.Sum256(nil)
+		return empty[:]
+	case 1:
+		leaf := sha256.Sum256(append([]byte{0}, items[0]...))
+		return leaf[:]
+	}
+
+	split := 1
+	for split*2 < len(items) {
+		split *= 2
+	}
+	inner := append([]byte{1}, merkleRoot(items[:split])...)
+	inner = append(inner, merkleRoot(items[split:])...)
+	hash := sha256.Sum256(inner)
+	return hash[:]
+}
+
+// decodeTendermintTxs base64-decodes a block's transactions, returning the
+// index of the first that does not decode
+func decodeTendermintTxs(txs []string) ([][]byte, int, error) {
+	raw := make([][]byte, 0, len(txs))
+	for i, tx := range txs {
+		data, err := base64.StdEncoding.DecodeString(tx)
+		if err != nil {
+			return raw, i, err
+		}
+		raw = append(raw, data)
+	}
+	return raw, -1, nil
+}
+
+// isShadowyAddress reports whether address is a well-formed S-address
+// whose checksum matches
+func isShadowyAddress(address string) bool {
+	if !strings.HasPrefix(address, "S") || len(address) != 1+shadowyAddressLen*2 {
+		return false
+	}
+	decoded, err := hex.DecodeString(address[1:])
+	if err != nil || decoded[0] != shadowyAddressVersion {
+		return false
+	}
+
+	payload := decoded[:shadowyAddressLen-shadowyAddressChecksum]
+	first := sha3.NewLegacyKeccak256()
+	first.Write(payload)
+	second := sha3.NewLegacyKeccak256()
+	second.Write(first.Sum(nil))
+	return bytes.Equal(decoded[len(payload):], second.Sum(nil)[:shadowyAddressChecksum])
+}
+
+// verifyBlock checks a block fetched for height before it is stored and
+// returns what is wrong with it, if anything. parentHash is the node hash
+// indexed at height-1, empty when none is.
+func verifyBlock(tmResp *TendermintBlockResponse, block *Block, height uint64, parentHash string) []string {
+	var problems []string
+	header := tmResp.Result.Block.Header
+
+	if block.Header.Height != height {
+		problems = append(problems, fmt.Sprintf("requested height %d but received %d", height, block.Header.Height))
+	}
+	if hash, err := hex.DecodeString(tmResp.Result.BlockID.Hash); err != nil || len(hash) != sha256.Size {
+		problems = append(problems, fmt.Sprintf("malformed block hash %q", tmResp.Result.BlockID.Hash))
+	}
+
+	// Hash linkage; a different parent is a reorg, handled before this
+	if height > 1 {
+		switch {
+		case header.LastBlockID.Hash == "":
+			problems = append(problems, "missing previous block hash")
+		case parentHash == "":
+			problems = append(problems, fmt.Sprintf("previous block %d is not indexed, link unverified", height-1))
+		}
+	}
+
+	// Merkle root of the transactions
+	raw, bad, err := decodeTendermintTxs(tmResp.Result.Block.Data.Txs)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("transaction %d is not valid base64: %v", bad, err))
+	} else if root := hex.EncodeToString(merkleRoot(raw)); !strings.EqualFold(root, header.DataHash) {
+		problems = append(problems, fmt.Sprintf("merkle root mismatch: header %q, transactions %s", header.DataHash, root))
+	}
+	if len(block.Body.Transactions) != len(tmResp.Result.Block.Data.Txs) {
+		problems = append(problems, fmt.Sprintf("%d of %d transactions could not be parsed",
+			len(tmResp.Result.Block.Data.Txs)-len(block.Body.Transactions), len(tmResp.Result.Block.Data.Txs)))
+	}
+
+	return append(problems, verifyFarmerProof(block)...)
+}
+
+// verifyFarmerProof checks the farmer fields of a block: a single coinbase
+// signed by a valid address and paying only that address, and well-formed
+// proof hashes when the header carries them
+func verifyFarmerProof(block *Block) []string {
+	var problems []string
+
+	coinbases := 0
+	for _, signedTx := range block.Body.Transactions {
+		if signedTx.Algorithm != "coinbase" {
+			continue
+		}
+		coinbases++
+
+		if !isShadowyAddress(signedTx.SignerKey) {
+			problems = append(problems, fmt.Sprintf("coinbase farmer %q is not a valid address", signedTx.SignerKey))
+		}
+		var tx Transaction
+		if err := json.Unmarshal(signedTx.Transaction, &tx); err != nil {
+			problems = append(problems, fmt.Sprintf("coinbase %s does not parse: %v", signedTx.TxHash, err))
+			continue
+		}
+		if len(tx.Inputs) > 0 {
+			problems = append(problems, fmt.Sprintf("coinbase %s spends inputs", signedTx.TxHash))
+		}
+		for _, output := range tx.Outputs {
+			if output.Address != signedTx.SignerKey {
+				problems = append(problems, fmt.Sprintf("coinbase %s pays %s, not its farmer", signedTx.TxHash, output.Address))
+			}
+		}
+	}
+	if coinbases > 1 {
+		problems = append(problems, fmt.Sprintf("%d coinbase transactions", coinbases))
+	}
+
+	if seed := block.Header.ChallengeSeed; seed != "" && !isHex(seed) {
+		problems = append(problems, fmt.Sprintf("challenge seed %q is not hex", seed))
+	}
+	if proof := block.Header.ProofHash; proof != "" && !isHex(proof) {
+		problems = append(problems, fmt.Sprintf("proof hash %q is not hex", proof))
+	}
+	return problems
+}
+
+// flagBlock records an alert for a block that failed verification
+func (s *SyncService) flagBlock(blockHash, nodeHash string, block *Block, problems []string) {
+	alert := &BlockAlert{
+		Height:     block.Header.Height,
+		Hash:       blockHash,
+		NodeHash:   nodeHash,
+		Node:       s.nodes.Current(),
+		Problems:   problems,
+		DetectedAt: time.Now().UTC(),
+	}
+	slog.Warn("Block failed verification", "height", alert.Height, "problems", strings.Join(problems, "; "))
+	if err := s.database.StoreBlockAlert(alert); err != nil {
+		slog.Error("Failed to store block alert", "height", alert.Height, "err", err)
+	}
+}
+
+// StoreBlockAlert saves the alert for a block, replacing any earlier one
+func (d *Database) StoreBlockAlert(alert *BlockAlert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set(blockAlertKey(alert.Height), data)
+	})
+}
+
+// GetBlockAlert returns the alert for the block at height
+func (d *Database) GetBlockAlert(height uint64) (*BlockAlert, error) {
+	var alert BlockAlert
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(blockAlertKey(height))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &alert)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// GetBlockAlerts returns up to limit alerts, highest block first, and how
+// many there are in all
+func (d *Database) GetBlockAlerts(limit int) (*BlockAlerts, error) {
+	result := &BlockAlerts{Alerts: []BlockAlert{}}
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(blockAlertPrefix)
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(blockAlertPrefix + "\xff")); it.Valid(); it.Next() {
+			result.Total++
+			if len(result.Alerts) >= limit {
+				continue
+			}
+			var alert BlockAlert
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &alert)
+			}); err != nil {
+				return err
+			}
+			result.Alerts = append(result.Alerts, alert)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Alerts API endpoint: blocks that failed verification during sync
+func (es *ExplorerServer) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAlertsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxAlertsLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxAlertsLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	alerts, err := es.database.GetBlockAlerts(limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get block alerts", "err", err)
+		http.Error(w, "Failed to get alerts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
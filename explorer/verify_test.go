@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/sha3"
+)
+
+// testShadowyAddress builds a valid S-address around a 20-byte hash of seed
+func testShadowyAddress(seed string) string {
+	hash := sha256.Sum256([]byte(seed))
+	payload := append([]byte{shadowyAddressVersion}, hash[:20]...)
+	first := sha3.NewLegacyKeccak256()
+	first.Write(payload)
+	second := sha3.NewLegacyKeccak256()
+	second.Write(first.Sum(nil))
+	return "S" + hex.EncodeToString(append(payload, second.Sum(nil)[:shadowyAddressChecksum]...))
+}
+
+// testTendermintBlock is a block at height holding txs whose header is
+// consistent with them
+func testTendermintBlock(height uint64, txs ...[]byte) *TendermintBlockResponse {
+	var resp TendermintBlockResponse
+	resp.Result.BlockID.Hash = strings.Repeat("ab", 32)
+	resp.Result.Block.Header.Height = strconv.FormatUint(height, 10)
+	if height > 1 {
+		resp.Result.Block.Header.LastBlockID.Hash = strings.Repeat("cd", 32)
+	}
+	for _, tx := range txs {
+		resp.Result.Block.Data.Txs = append(resp.Result.Block.Data.Txs, base64.StdEncoding.EncodeToString(tx))
+	}
+	resp.Result.Block.Header.DataHash = strings.ToUpper(hex.EncodeToString(merkleRoot(txs)))
+	return &resp
+}
+
+func TestMerkleRoot(t *testing.T) {
+	if got := hex.EncodeToString(merkleRoot(nil)); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("Expected the hash of nothing for an empty tree, got %s", got)
+	}
+
+	leaf := func(item string) []byte {
+		hash := sha256.Sum256(append([]byte{0}, item...))
+		return hash[:]
+	}
+	inner := func(left, right []byte) []byte {
+		hash := sha256.Sum256(append(append([]byte{1}, left...), right...))
+		return hash[:]
+	}
+	// Three leaves split after the first two
+	want := inner(inner(leaf("a"), leaf("b")), leaf("c"))
+	if got := merkleRoot([][]byte{[]byte("a"), []byte("b"), []byte("c")}); hex.EncodeToString(got) != hex.EncodeToString(want) {
+		t.Errorf("Unexpected root of three leaves %x", got)
+	}
+}
+
+func TestIsShadowyAddress(t *testing.T) {
+	address := testShadowyAddress("farmer")
+	if !isShadowyAddress(address) {
+		t.Fatalf("Expected %s to be valid", address)
+	}
+	corrupted := address[:10] + string("0123456789abcdef"[(strings.IndexByte("0123456789abcdef", address[10])+1)%16]) + address[11:]
+	for _, bad := range []string{corrupted, "L" + address[1:], address[:50], "not an address"} {
+		if isShadowyAddress(bad) {
+			t.Errorf("Expected %q to be invalid", bad)
+		}
+	}
+}
+
+func TestVerifyBlock(t *testing.T) {
+	svc := NewSyncService("", newTestNodeClient(), newTestDatabase(t))
+	farmer := testShadowyAddress("farmer")
+	coinbase := func(signer, payee string) []byte {
+		tx, _ := json.Marshal(Transaction{Version: 1, Outputs: []TransactionOutput{{Value: 50, Address: payee}}})
+		signed, _ := json.Marshal(SignedTransaction{Transaction: tx, TxHash: "cb", SignerKey: signer, Algorithm: "coinbase"})
+		return signed
+	}
+
+	good := testTendermintBlock(2, coinbase(farmer, farmer))
+	block, err := svc.convertTendermintBlock(good)
+	if err != nil {
+		t.Fatalf("Failed to convert block: %v", err)
+	}
+	if problems := verifyBlock(good, block, 2, strings.Repeat("cd", 32)); len(problems) != 0 {
+		t.Errorf("Expected a consistent block to pass, got %v", problems)
+	}
+	if !strings.EqualFold(block.Header.MerkleRoot, good.Result.Block.Header.DataHash) {
+		t.Errorf("Expected the merkle root recorded on the block, got %q", block.Header.MerkleRoot)
+	}
+
+	// Transactions that do not match the header, an unlinked parent and a
+	// coinbase paying someone else
+	bad := testTendermintBlock(2, coinbase(farmer, testShadowyAddress("thief")))
+	bad.Result.Block.Header.DataHash = good.Result.Block.Header.DataHash
+	block, _ = svc.convertTendermintBlock(bad)
+	problems := strings.Join(verifyBlock(bad, block, 2, ""), "\n")
+	for _, want := range []string{"merkle root mismatch", "not indexed", "not its farmer"} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("Expected a problem mentioning %q, got:\n%s", want, problems)
+		}
+	}
+
+	forged := testTendermintBlock(1, coinbase("Sforged", "Sforged"))
+	block, _ = svc.convertTendermintBlock(forged)
+	if problems := verifyBlock(forged, block, 1, ""); len(problems) != 1 || !strings.Contains(problems[0], "not a valid address") {
+		t.Errorf("Expected only the invalid farmer address flagged, got %v", problems)
+	}
+}
+
+func TestSyncFlagsUnverifiedBlocks(t *testing.T) {
+	// The fake node serves blocks without hashes, so every one fails
+	node, _ := newFakeNode(t, 3)
+	database := newTestDatabase(t)
+	svc := NewSyncService(node.URL, newTestNodeClient(), database)
+	svc.verify = true
+	svc.syncOnce()
+
+	if height, _ := database.GetLatestHeight(); height != 3 {
+		t.Fatalf("Expected flagged blocks still indexed up to 3, at %d", height)
+	}
+	alerts, err := database.GetBlockAlerts(2)
+	if err != nil {
+		t.Fatalf("GetBlockAlerts failed: %v", err)
+	}
+	if alerts.Total != 3 || len(alerts.Alerts) != 2 || alerts.Alerts[0].Height != 3 || alerts.Alerts[0].Node != node.URL {
+		t.Errorf("Unexpected alerts %+v", alerts)
+	}
+
+	es := &ExplorerServer{database: database}
+	block, _ := database.GetBlockByHeight(2)
+	hash := svc.calculateBlockHash(block)
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/block/"+hash, nil), map[string]string{"hash": hash})
+	rec := httptest.NewRecorder()
+	es.handleBlockDetails(rec, req)
+	var details struct {
+		Header BlockHeader `json:"header"`
+		Alert  *BlockAlert `json:"alert"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &details); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if details.Header.Height != 2 || details.Alert == nil || len(details.Alert.Problems) == 0 {
+		t.Errorf("Expected block 2 with its alert, got %+v", details)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleAlerts(rec, httptest.NewRequest("GET", "/api/v1/alerts?limit=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a zero limit, got %d", rec.Code)
+	}
+
+	if _, err := database.RollbackToHeight(1); err != nil {
+		t.Fatalf("RollbackToHeight failed: %v", err)
+	}
+	if alerts, _ := database.GetBlockAlerts(10); alerts.Total != 1 {
+		t.Errorf("Expected alerts above the fork removed, got %+v", alerts)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultWalletWaitTimeout is how long a wallet long-poll waits unless
+	// the client asks for less
+	defaultWalletWaitTimeout = 30 * time.Second
+	maxWalletWaitTimeout     = 60 * time.Second
+
+	// maxWalletWaitTransactions caps how many transactions one poll returns
+	maxWalletWaitTransactions = 100
+)
+
+// walletFeedTopic is the feed topic announcing an address's new transactions
+func walletFeedTopic(address string) string {
+	return "wallet:" + address
+}
+
+// GetWalletTransactionsAfter returns up to limit transactions for an
+// address in blocks above height, oldest first
+func (d *Database) GetWalletTransactionsAfter(address string, height uint64, limit int) ([]WalletTransaction, error) {
+	transactions := []WalletTransaction{}
+	if height == ^uint64(0) {
+		return transactions, nil
+	}
+
+	prefix := []byte(fmt.Sprintf("addr_tx:%s:", address))
+	start := []byte(fmt.Sprintf("addr_tx:%s:%016d:", address, height+1))
+
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(start); it.ValidForPrefix(prefix) && len(transactions) < limit; it.Next() {
+			txHash, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			item, err := txn.Get([]byte(fmt.Sprintf("tx:%s", txHash)))
+			if err != nil {
+				slog.Error("Wallet index points at a missing transaction", "address", address, "tx_hash", txHash)
+				continue
+			}
+			var tx WalletTransaction
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &tx)
+			}); err != nil {
+				continue
+			}
+			transactions = append(transactions, tx)
+		}
+		return nil
+	})
+	return transactions, err
+}
+
+// parseWaitTimeout accepts a Go duration ("10s") or whole seconds ("10"),
+// capped at maxWalletWaitTimeout
+func parseWaitTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultWalletWaitTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, err
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("timeout must be positive")
+	}
+	if timeout > maxWalletWaitTimeout {
+		timeout = maxWalletWaitTimeout
+	}
+	return timeout, nil
+}
+
+// Wallet long-poll: returns as soon as the address has transactions above
+// since_height, or an empty list once the timeout passes
+func (es *ExplorerServer) handleWalletWaitAPI(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	timeout, err := parseWaitTimeout(r.URL.Query().Get("timeout"))
+	if err != nil {
+		http.Error(w, "Invalid timeout", http.StatusBadRequest)
+		return
+	}
+
+	// Subscribe before the first read so no transaction slips in between
+	events, unsubscribe := es.database.Feed().Subscribe(walletFeedTopic(address))
+	defer unsubscribe()
+
+	var sinceHeight uint64
+	if value := r.URL.Query().Get("since_height"); value != "" {
+		sinceHeight, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid since_height", http.StatusBadRequest)
+			return
+		}
+	} else if tip, err := es.database.GetLatestBlock(); err == nil {
+		// Without a height, wait for anything after the current tip
+		sinceHeight = tip.Header.Height
+	}
+
+	response := WalletWaitResponse{Address: address, SinceHeight: sinceHeight}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		transactions, err := es.database.GetWalletTransactionsAfter(address, sinceHeight, maxWalletWaitTransactions)
+		if err != nil {
+			http.Error(w, "Failed to get transactions", http.StatusInternalServerError)
+			return
+		}
+		if len(transactions) > 0 {
+			es.annotateFinality(transactions)
+			labels := es.newLabeler()
+			labels.addTransactions(transactions)
+			labels.apply()
+			response.Transactions = transactions
+			break
+		}
+
+		select {
+		case <-r.Context().Done():
+			// Client went away; the deferred unsubscribe releases the waiter
+			return
+		case <-events:
+			continue
+		case <-timer.C:
+			response.Transactions = []WalletTransaction{}
+			response.TimedOut = true
+		}
+		break
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
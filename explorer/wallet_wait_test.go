@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const waitTestAddress = "S42618a7524a82df51c8a2406321e161de65073008806f042f0"
+
+func serveWalletWait(es *ExplorerServer, query string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/wallet/{address}/wait", es.handleWalletWaitAPI)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/wallet/"+waitTestAddress+"/wait?"+query, nil))
+	return rec
+}
+
+func decodeWalletWait(t *testing.T, rec *httptest.ResponseRecorder) WalletWaitResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp WalletWaitResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestWalletWaitUnblocksOnNewTransaction(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database}
+
+	// Already-seen history at or below since_height must not wake the poll
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: "old", BlockHeight: 5, ToAddress: waitTestAddress, Timestamp: time.Unix(5, 0)}); err != nil {
+		t.Fatalf("Failed to store transaction: %v", err)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serveWalletWait(es, "since_height=5&timeout=10s") }()
+
+	// Wait until the poll is subscribed, then index a transaction
+	deadline := time.Now().Add(5 * time.Second)
+	for database.Feed().SubscriberCount(walletFeedTopic(waitTestAddress)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Long-poll never subscribed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: "new", BlockHeight: 6, FromAddress: waitTestAddress, Timestamp: time.Unix(6, 0)}); err != nil {
+		t.Fatalf("Failed to store transaction: %v", err)
+	}
+
+	select {
+	case rec := <-done:
+		resp := decodeWalletWait(t, rec)
+		if resp.TimedOut || len(resp.Transactions) != 1 || resp.Transactions[0].TxHash != "new" {
+			t.Errorf("Expected only the new transaction, got %+v", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Long-poll was not woken by the new transaction")
+	}
+
+	if database.Feed().SubscriberCount(walletFeedTopic(waitTestAddress)) != 0 {
+		t.Error("Waiter should unsubscribe once answered")
+	}
+}
+
+func TestWalletWaitReturnsImmediatelyWhenBehind(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database}
+	if err := database.StoreTransaction(&WalletTransaction{TxHash: "tx", BlockHeight: 9, ToAddress: waitTestAddress, Timestamp: time.Unix(9, 0)}); err != nil {
+		t.Fatalf("Failed to store transaction: %v", err)
+	}
+
+	resp := decodeWalletWait(t, serveWalletWait(es, "since_height=3&timeout=10s"))
+	if len(resp.Transactions) != 1 || resp.Transactions[0].TxHash != "tx" {
+		t.Errorf("Expected the already-indexed transaction, got %+v", resp)
+	}
+}
+
+func TestWalletWaitTimesOutEmpty(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database}
+
+	start := time.Now()
+	resp := decodeWalletWait(t, serveWalletWait(es, "since_height=0&timeout=50ms"))
+	if !resp.TimedOut || resp.Transactions == nil || len(resp.Transactions) != 0 {
+		t.Errorf("Expected an empty timed-out result, got %+v", resp)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Returned before the timeout: %v", elapsed)
+	}
+	if database.Feed().SubscriberCount(walletFeedTopic(waitTestAddress)) != 0 {
+		t.Error("Waiter should unsubscribe on timeout")
+	}
+
+	if rec := serveWalletWait(es, "timeout=soon"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad timeout, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,741 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+const (
+	watchPrefix      = "watch:" // Everything below survives a reset
+	watchSubPrefix   = "watch:sub:"
+	watchAddrPrefix  = "watch:addr:"
+	watchQueuePrefix = "watch:queue:"
+	watchOwnerPrefix = "watch:owner:"
+
+	watchDeliveryInterval = 10 * time.Second
+	watchDeliveryBatch    = 100
+
+	// Subscriptions one client, by IP address or API key, may hold
+	defaultWatchMaxPerClient = 10
+
+	// A failed delivery is retried after 30s, 1m, 2m, ... up to 8 attempts,
+	// about two hours in all
+	watchRetryBase   = 30 * time.Second
+	watchMaxAttempts = 8
+
+	// Blocks older than this when they sync are history being caught up
+	// on, not news, and notify no one
+	watchNotifyWindow = time.Hour
+)
+
+// watchEventTypes are the events a subscription can ask for
+var watchEventTypes = []string{"received", "sent", "mined"}
+
+// errPrivateAddress refuses outbound requests, such as webhooks and NFT
+// metadata fetches, that would reach the explorer's own network
+var errPrivateAddress = errors.New("refusing to connect to a private address")
+
+// Watchlist turns synced transactions touching watched addresses into
+// notifications, and delivers them in the background with retries
+type Watchlist struct {
+	database Store
+	client   *http.Client
+	interval time.Duration
+
+	// sendMail delivers email notifications; nil when SMTP is not set up
+	sendMail func(to, subject, body string) error
+
+	// maxPerClient caps the subscriptions one client may create; admin
+	// requests are exempt
+	maxPerClient int
+
+	stopCh chan struct{}
+}
+
+// NewWatchlist creates a watchlist delivering with sendMail, which may be
+// nil. Webhooks to private and loopback addresses are refused unless
+// allowPrivate is set.
+func NewWatchlist(database Store, sendMail func(to, subject, body string) error, allowPrivate bool) *Watchlist {
+	client := newOutboundClient(15*time.Second, allowPrivate)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &Watchlist{
+		database:     database,
+		client:       client,
+		interval:     watchDeliveryInterval,
+		sendMail:     sendMail,
+		maxPerClient: defaultWatchMaxPerClient,
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// newOutboundClient creates a client for URLs supplied by users. Unless
+// allowPrivate is set it only connects to public addresses, checked after
+// resolution so DNS cannot point a public name at an internal service.
+func newOutboundClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast())
+}
+
+// newWatchlistFromEnv creates the watchlist, sending email through the SMTP
+// server at EXPLORER_SMTP_ADDR (host:port) from EXPLORER_SMTP_FROM,
+// authenticating when EXPLORER_SMTP_USERNAME is set, and allowing each
+// client EXPLORER_WATCH_MAX_PER_CLIENT subscriptions
+func newWatchlistFromEnv(database Store) *Watchlist {
+	var sendMail func(to, subject, body string) error
+	addr, from := os.Getenv("EXPLORER_SMTP_ADDR"), os.Getenv("EXPLORER_SMTP_FROM")
+	if addr != "" && from != "" {
+		var auth smtp.Auth
+		if username := os.Getenv("EXPLORER_SMTP_USERNAME"); username != "" {
+			host, _, _ := net.SplitHostPort(addr)
+			auth = smtp.PlainAuth("", username, os.Getenv("EXPLORER_SMTP_PASSWORD"), host)
+		}
+		sendMail = func(to, subject, body string) error {
+			message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+				from, to, subject, body)
+			return smtp.SendMail(addr, auth, from, []string{to}, []byte(message))
+		}
+	}
+	watchlist := NewWatchlist(database, sendMail, os.Getenv("EXPLORER_WATCH_ALLOW_PRIVATE") == "true")
+	if value := os.Getenv("EXPLORER_WATCH_MAX_PER_CLIENT"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			watchlist.maxPerClient = n
+		} else {
+			slog.Warn("Ignoring invalid EXPLORER_WATCH_MAX_PER_CLIENT", "value", value)
+		}
+	}
+	return watchlist
+}
+
+// Start delivers due notifications now and then in the background
+func (wl *Watchlist) Start() {
+	wl.DeliverDue(time.Now())
+
+	go func() {
+		ticker := time.NewTicker(wl.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				wl.DeliverDue(time.Now())
+			case <-wl.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops background delivery
+func (wl *Watchlist) Stop() {
+	close(wl.stopCh)
+}
+
+// watchEvents lists what happened to each address in a block's stored
+// transactions. Outputs of one transaction to the same address add up to
+// one event, and each token a transaction moves for an address is one
+// event with the net amount.
+func watchEvents(block *Block, stored []*WalletTransaction) []WatchEvent {
+	type eventKey struct{ address, event, txHash, token string }
+	index := make(map[eventKey]int)
+	var events []WatchEvent
+
+	add := func(address, event, counterparty string, tx *WalletTransaction, amount uint64, token string, tokenAmount uint64) {
+		if address == "" || address == "unknown" {
+			return
+		}
+		if counterparty == "unknown" {
+			counterparty = ""
+		}
+		key := eventKey{address, event, tx.TxHash, token}
+		if i, ok := index[key]; ok {
+			events[i].Amount += amount
+			events[i].TokenAmount += tokenAmount
+			return
+		}
+		index[key] = len(events)
+		events = append(events, WatchEvent{
+			Event:        event,
+			Address:      address,
+			TxHash:       tx.TxHash,
+			BlockHeight:  block.Header.Height,
+			BlockHash:    tx.BlockHash,
+			Timestamp:    tx.Timestamp,
+			Amount:       amount,
+			TokenSymbol:  token,
+			TokenAmount:  tokenAmount,
+			Counterparty: counterparty,
+		})
+	}
+
+	tokensSeen := make(map[string]bool)
+	for _, tx := range stored {
+		// Every record of a transaction carries all its token changes
+		if !tokensSeen[tx.TxHash] {
+			tokensSeen[tx.TxHash] = true
+			for _, change := range tx.AssetChanges {
+				if change.Delta > 0 {
+					add(change.Address, "received", "", tx, 0, change.Symbol, uint64(change.Delta))
+				} else {
+					add(change.Address, "sent", "", tx, 0, change.Symbol, uint64(-change.Delta))
+				}
+			}
+		}
+
+		switch {
+		case tx.Type == "mining_reward":
+			add(tx.ToAddress, "mined", "", tx, tx.Amount, "", 0)
+		case !strings.HasPrefix(tx.Type, "token_"):
+			add(tx.ToAddress, "received", tx.FromAddress, tx, tx.Amount, "", 0)
+			add(tx.FromAddress, "sent", tx.ToAddress, tx, tx.Amount, "", 0)
+		}
+	}
+	return events
+}
+
+// Notify queues notifications for the subscribers to addresses a newly
+// synced block touched. It is a no-op on a nil watchlist.
+func (wl *Watchlist) Notify(block *Block, stored []*WalletTransaction) {
+	if wl == nil || time.Since(block.Header.Timestamp) > watchNotifyWindow {
+		return
+	}
+
+	now := time.Now()
+	subscriptions := make(map[string][]WatchSubscription)
+	for _, event := range watchEvents(block, stored) {
+		subs, ok := subscriptions[event.Address]
+		if !ok {
+			var err error
+			if subs, err = wl.database.GetWatchSubscriptions(event.Address); err != nil {
+				slog.Error("Failed to load watchers", "address", event.Address, "err", err)
+				continue
+			}
+			subscriptions[event.Address] = subs
+		}
+
+		for _, sub := range subs {
+			if sub.Pending || !containsString(sub.Events, event.Event) {
+				continue
+			}
+			event.SubscriptionID = sub.ID
+			notification := &WatchNotification{ID: randomHex(16), Event: event, NextAttempt: now}
+			if err := wl.database.QueueWatchNotification(notification); err != nil {
+				slog.Error("Failed to queue watch notification", "event", event.Event, "address", event.Address, "err", err)
+			}
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHex(n int) string {
+	random := make([]byte, n)
+	if _, err := rand.Read(random); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(random)
+}
+
+// DeliverDue sends the notifications due by now, rescheduling failures
+func (wl *Watchlist) DeliverDue(now time.Time) {
+	due, err := wl.database.DueWatchNotifications(now, watchDeliveryBatch)
+	if err != nil {
+		slog.Error("Failed to load due notifications", "err", err)
+		return
+	}
+
+	for i := range due {
+		n := &due[i]
+		sub, err := wl.database.GetWatchSubscription(n.Event.SubscriptionID)
+		if err == nil {
+			err = wl.deliver(sub, &n.Event)
+			if err == nil {
+				wl.database.DeleteWatchNotification(n.ID)
+				continue
+			}
+		} else if err == errNotFound {
+			// Unsubscribed since the event was queued
+			wl.database.DeleteWatchNotification(n.ID)
+			continue
+		}
+
+		n.Attempts++
+		n.LastError = err.Error()
+		if n.Attempts >= watchMaxAttempts {
+			slog.Warn("Giving up on watch notification", "event", n.Event.Event, "address", n.Event.Address,
+				"attempts", n.Attempts, "err", err)
+			wl.database.DeleteWatchNotification(n.ID)
+			continue
+		}
+		n.NextAttempt = now.Add(watchRetryBase << (n.Attempts - 1))
+		if err := wl.database.QueueWatchNotification(n); err != nil {
+			slog.Error("Failed to reschedule notification", "notification_id", n.ID, "err", err)
+		}
+	}
+}
+
+// deliver posts an event to the subscription's webhook or emails it
+func (wl *Watchlist) deliver(sub *WatchSubscription, event *WatchEvent) error {
+	if sub.Email != "" {
+		if wl.sendMail == nil {
+			return fmt.Errorf("email is not configured")
+		}
+		return wl.sendMail(sub.Email, watchEmailSubject(event), watchEmailBody(event))
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), "POST", sub.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, []byte(sub.Secret))
+	mac.Write(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Shadowy-Event", event.Event)
+	req.Header.Set("X-Shadowy-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := wl.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func watchEmailSubject(event *WatchEvent) string {
+	switch event.Event {
+	case "mined":
+		return fmt.Sprintf("%s mined block %d", event.Address, event.BlockHeight)
+	case "sent":
+		return fmt.Sprintf("%s sent funds in block %d", event.Address, event.BlockHeight)
+	}
+	return fmt.Sprintf("%s received funds in block %d", event.Address, event.BlockHeight)
+}
+
+func watchConfirmBody(sub *WatchSubscription, confirmURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Someone asked for this address to be emailed when %s %s.\n\n", sub.Address, strings.Join(sub.Events, ", "))
+	fmt.Fprintf(&b, "To start the notifications, open:\n%s\n\n", confirmURL)
+	fmt.Fprintf(&b, "If this was not you, ignore this email; nothing more will be sent.\n")
+	return b.String()
+}
+
+func watchEmailBody(event *WatchEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Address:     %s\n", event.Address)
+	fmt.Fprintf(&b, "Event:       %s\n", event.Event)
+	fmt.Fprintf(&b, "Block:       %d\n", event.BlockHeight)
+	fmt.Fprintf(&b, "Transaction: %s\n", event.TxHash)
+	if event.TokenSymbol != "" {
+		fmt.Fprintf(&b, "Token:       %d %s\n", event.TokenAmount, event.TokenSymbol)
+	} else {
+		fmt.Fprintf(&b, "Amount:      %s SHADOW\n", formatShadowAmount(int64(event.Amount)))
+	}
+	if event.Counterparty != "" {
+		fmt.Fprintf(&b, "Counterparty: %s\n", event.Counterparty)
+	}
+	fmt.Fprintf(&b, "\nUnsubscribe with DELETE /api/v1/watch/%s\n", event.SubscriptionID)
+	return b.String()
+}
+
+// StoreWatchSubscription saves a subscription and indexes it by address
+// and by the client that created it
+func (d *Database) StoreWatchSubscription(sub *WatchSubscription) error {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+	return d.update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte(watchSubPrefix+sub.ID), data); err != nil {
+			return err
+		}
+		if sub.Owner != "" {
+			if err := txn.Set([]byte(watchOwnerPrefix+sub.Owner+":"+sub.ID), nil); err != nil {
+				return err
+			}
+		}
+		return txn.Set([]byte(watchAddrPrefix+sub.Address+":"+sub.ID), []byte(sub.ID))
+	})
+}
+
+// CountWatchSubscriptions counts the subscriptions a client created
+func (d *Database) CountWatchSubscriptions(owner string) (int, error) {
+	count := 0
+	err := d.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(watchOwnerPrefix + owner + ":")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// GetWatchSubscription finds a subscription by ID
+func (d *Database) GetWatchSubscription(id string) (*WatchSubscription, error) {
+	var sub WatchSubscription
+	err := d.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(watchSubPrefix + id))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &sub)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetWatchSubscriptions returns the subscriptions watching an address
+func (d *Database) GetWatchSubscriptions(address string) ([]WatchSubscription, error) {
+	subs := []WatchSubscription{}
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(watchAddrPrefix + address + ":")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			id, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			item, err := txn.Get([]byte(watchSubPrefix + string(id)))
+			if err != nil {
+				continue
+			}
+			var sub WatchSubscription
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &sub)
+			}); err != nil {
+				return err
+			}
+			subs = append(subs, sub)
+		}
+		return nil
+	})
+	return subs, err
+}
+
+// DeleteWatchSubscription removes a subscription. Its queued
+// notifications are dropped when they come due.
+func (d *Database) DeleteWatchSubscription(id string) error {
+	sub, err := d.GetWatchSubscription(id)
+	if err != nil {
+		return err
+	}
+	return d.update(func(txn *badger.Txn) error {
+		if err := txn.Delete([]byte(watchSubPrefix + id)); err != nil {
+			return err
+		}
+		if sub.Owner != "" {
+			if err := txn.Delete([]byte(watchOwnerPrefix + sub.Owner + ":" + id)); err != nil {
+				return err
+			}
+		}
+		return txn.Delete([]byte(watchAddrPrefix + sub.Address + ":" + id))
+	})
+}
+
+// QueueWatchNotification adds a notification to the delivery queue, or
+// replaces it when rescheduling
+func (d *Database) QueueWatchNotification(n *WatchNotification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(watchQueuePrefix+n.ID), data)
+	})
+}
+
+// DueWatchNotifications returns up to limit notifications due by now,
+// longest waiting first
+func (d *Database) DueWatchNotifications(now time.Time, limit int) ([]WatchNotification, error) {
+	var due []WatchNotification
+	err := d.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(watchQueuePrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var n WatchNotification
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &n)
+			}); err != nil {
+				return err
+			}
+			if !n.NextAttempt.After(now) {
+				due = append(due, n)
+			}
+		}
+		return nil
+	})
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttempt.Before(due[j].NextAttempt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, err
+}
+
+// DeleteWatchNotification removes a delivered or abandoned notification
+func (d *Database) DeleteWatchNotification(id string) error {
+	return d.update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(watchQueuePrefix + id))
+	})
+}
+
+// watchOwner identifies the client creating a subscription, by API key or
+// else IP address, hashed so the database keeps no client addresses
+func (es *ExplorerServer) watchOwner(r *http.Request) string {
+	client := ""
+	if secret := apiKeyFromRequest(r); secret != "" && es.rateLimiter != nil {
+		if key, err := es.rateLimiter.lookupKey(secret, time.Now()); err == nil && key != nil {
+			client = "key:" + key.ID
+		}
+	}
+	if client == "" {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		client = "ip:" + host
+	}
+	return hashAPIKey(client)[:32]
+}
+
+// withoutSecrets is a subscription as served: no confirmation token or
+// owner, and no webhook secret unless keepSecret
+func withoutSecrets(sub *WatchSubscription, keepSecret bool) *WatchSubscription {
+	served := *sub
+	served.ConfirmToken, served.Owner = "", ""
+	if !keepSecret {
+		served.Secret = ""
+	}
+	return &served
+}
+
+// Watchlist endpoint: subscribe to an address. The response carries the
+// subscription ID, needed to unsubscribe, and the webhook signing secret.
+// An email subscription is pending until the link mailed to it is opened,
+// and each client may hold maxPerClient subscriptions.
+func (es *ExplorerServer) handleCreateWatch(w http.ResponseWriter, r *http.Request) {
+	if es.watchlist == nil {
+		http.Error(w, "Watchlist notifications are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Address    string   `json:"address"`
+		WebhookURL string   `json:"webhook_url"`
+		Email      string   `json:"email"`
+		Events     []string `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Expected JSON with an address and a webhook_url or email", http.StatusBadRequest)
+		return
+	}
+
+	sub := &WatchSubscription{
+		ID:        randomHex(16),
+		Address:   strings.TrimSpace(req.Address),
+		Events:    req.Events,
+		CreatedAt: time.Now().UTC(),
+	}
+	if sub.Address == "" || len(sub.Address) > 128 || strings.ContainsAny(sub.Address, ": \t\n") {
+		http.Error(w, "Invalid address", http.StatusBadRequest)
+		return
+	}
+	if (req.WebhookURL == "") == (req.Email == "") {
+		http.Error(w, "Give either a webhook_url or an email", http.StatusBadRequest)
+		return
+	}
+	if req.WebhookURL != "" {
+		target, err := url.Parse(req.WebhookURL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			http.Error(w, "webhook_url must be an http or https URL", http.StatusBadRequest)
+			return
+		}
+		sub.WebhookURL = target.String()
+		sub.Secret = randomHex(24)
+	} else {
+		if es.watchlist.sendMail == nil {
+			http.Error(w, "Email notifications are not configured", http.StatusBadRequest)
+			return
+		}
+		address, err := mail.ParseAddress(req.Email)
+		if err != nil {
+			http.Error(w, "Invalid email", http.StatusBadRequest)
+			return
+		}
+		sub.Email = address.Address
+		sub.Pending, sub.ConfirmToken = true, randomHex(16)
+	}
+
+	if len(sub.Events) == 0 {
+		sub.Events = watchEventTypes
+	}
+	for _, event := range sub.Events {
+		if !containsString(watchEventTypes, event) {
+			http.Error(w, "events must be received, sent or mined", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !es.isAdminRequest(r) {
+		sub.Owner = es.watchOwner(r)
+		count, err := es.database.CountWatchSubscriptions(sub.Owner)
+		if err != nil {
+			http.Error(w, "Failed to check subscriptions", http.StatusInternalServerError)
+			return
+		}
+		if count >= es.watchlist.maxPerClient {
+			http.Error(w, fmt.Sprintf("At most %d subscriptions per client; delete one first", es.watchlist.maxPerClient),
+				http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	if err := es.database.StoreWatchSubscription(sub); err != nil {
+		http.Error(w, "Failed to store subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub.Pending {
+		confirmURL := fmt.Sprintf("%s/api/v1/watch/%s/confirm?token=%s", es.config.publicURL(r), sub.ID, sub.ConfirmToken)
+		if err := es.watchlist.sendMail(sub.Email, "Confirm notifications for "+sub.Address, watchConfirmBody(sub, confirmURL)); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to send watch confirmation", "err", err)
+			es.database.DeleteWatchSubscription(sub.ID)
+			http.Error(w, "Failed to send the confirmation email", http.StatusBadGateway)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(withoutSecrets(sub, true))
+}
+
+// Watchlist endpoint: confirm an email subscription with the token mailed
+// to it
+func (es *ExplorerServer) handleConfirmWatch(w http.ResponseWriter, r *http.Request) {
+	sub, err := es.database.GetWatchSubscription(mux.Vars(r)["id"])
+	if err == errNotFound {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load subscription", http.StatusInternalServerError)
+		return
+	}
+
+	if sub.Pending {
+		token := r.URL.Query().Get("token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(sub.ConfirmToken)) != 1 {
+			http.Error(w, "Invalid confirmation token", http.StatusForbidden)
+			return
+		}
+		sub.Pending, sub.ConfirmToken = false, ""
+		if err := es.database.StoreWatchSubscription(sub); err != nil {
+			http.Error(w, "Failed to confirm subscription", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(withoutSecrets(sub, false))
+}
+
+// Watchlist endpoint: a subscription, without its secrets
+func (es *ExplorerServer) handleGetWatch(w http.ResponseWriter, r *http.Request) {
+	sub, err := es.database.GetWatchSubscription(mux.Vars(r)["id"])
+	if err == errNotFound {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load subscription", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(withoutSecrets(sub, false))
+}
+
+// Watchlist endpoint: unsubscribe
+func (es *ExplorerServer) handleDeleteWatch(w http.ResponseWriter, r *http.Request) {
+	err := es.database.DeleteWatchSubscription(mux.Vars(r)["id"])
+	if err == errNotFound {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete subscription", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
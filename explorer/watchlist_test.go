@@ -0,0 +1,240 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func watchTestBlock(height uint64, timestamp time.Time) (*Block, []*WalletTransaction) {
+	block := &Block{Header: BlockHeader{Height: height, Timestamp: timestamp}}
+	stored := []*WalletTransaction{
+		{TxHash: "coinbase_1", BlockHash: "hash_1", Type: "mining_reward", Amount: 500, ToAddress: "farmer"},
+		{TxHash: "tx_1", BlockHash: "hash_1", Type: "received", Amount: 30, FromAddress: "alice", ToAddress: "bob"},
+		{TxHash: "tx_1", BlockHash: "hash_1", Type: "received", Amount: 12, FromAddress: "alice", ToAddress: "bob"},
+	}
+	return block, stored
+}
+
+func TestWatchEvents(t *testing.T) {
+	block, stored := watchTestBlock(7, time.Now())
+	events := watchEvents(block, stored)
+	if len(events) != 3 {
+		t.Fatalf("Expected mined, received and sent events, got %+v", events)
+	}
+	if events[0].Event != "mined" || events[0].Address != "farmer" || events[0].Amount != 500 {
+		t.Errorf("Unexpected mined event %+v", events[0])
+	}
+	if events[1].Event != "received" || events[1].Address != "bob" || events[1].Amount != 42 || events[1].Counterparty != "alice" {
+		t.Errorf("Expected bob's two outputs as one event, got %+v", events[1])
+	}
+	if events[2].Event != "sent" || events[2].Address != "alice" || events[2].BlockHeight != 7 {
+		t.Errorf("Unexpected sent event %+v", events[2])
+	}
+}
+
+func TestWatchlistDeliversWebhooksWithRetries(t *testing.T) {
+	database := newTestDatabase(t)
+	watchlist := NewWatchlist(database, nil, true)
+
+	failing := true
+	var received []*http.Request
+	var bodies [][]byte
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, body)
+	}))
+	defer hook.Close()
+
+	sub := &WatchSubscription{ID: "sub1", Address: "bob", WebhookURL: hook.URL, Events: []string{"received"}, Secret: "s3cret"}
+	if err := database.StoreWatchSubscription(sub); err != nil {
+		t.Fatalf("StoreWatchSubscription failed: %v", err)
+	}
+
+	// Catching up on old blocks notifies no one
+	block, stored := watchTestBlock(1, time.Now().Add(-2*watchNotifyWindow))
+	watchlist.Notify(block, stored)
+	if due, _ := database.DueWatchNotifications(time.Now(), 10); len(due) != 0 {
+		t.Fatalf("Expected no notifications for an old block, got %+v", due)
+	}
+
+	block, stored = watchTestBlock(2, time.Now())
+	watchlist.Notify(block, stored)
+	now := time.Now()
+	watchlist.DeliverDue(now)
+
+	due, _ := database.DueWatchNotifications(now.Add(watchRetryBase), 10)
+	if len(due) != 1 || due[0].Attempts != 1 || !strings.Contains(due[0].LastError, "502") {
+		t.Fatalf("Expected one rescheduled notification, got %+v", due)
+	}
+	if early, _ := database.DueWatchNotifications(now, 10); len(early) != 0 {
+		t.Errorf("A failed delivery must wait before retrying")
+	}
+
+	failing = false
+	watchlist.DeliverDue(now.Add(watchRetryBase))
+	if len(received) != 1 {
+		t.Fatalf("Expected one webhook call, got %d", len(received))
+	}
+	var event WatchEvent
+	json.Unmarshal(bodies[0], &event)
+	if event.Event != "received" || event.Amount != 42 || event.SubscriptionID != "sub1" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(bodies[0])
+	if got := received[0].Header.Get("X-Shadowy-Signature"); got != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("Unexpected signature %q", got)
+	}
+	if due, _ := database.DueWatchNotifications(now.Add(time.Hour), 10); len(due) != 0 {
+		t.Errorf("Expected the queue to be empty, got %+v", due)
+	}
+}
+
+func TestWatchlistRefusesPrivateWebhooks(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hook.Close()
+
+	watchlist := NewWatchlist(newTestDatabase(t), nil, false)
+	err := watchlist.deliver(&WatchSubscription{WebhookURL: hook.URL}, &WatchEvent{Event: "mined"})
+	if err == nil || !strings.Contains(err.Error(), errPrivateAddress.Error()) {
+		t.Errorf("Expected a loopback webhook to be refused, got %v", err)
+	}
+}
+
+func TestWatchSubscriptionEndpoints(t *testing.T) {
+	database := newTestDatabase(t)
+	var mailed, bodies []string
+	es := &ExplorerServer{database: database, watchlist: NewWatchlist(database, func(to, subject, body string) error {
+		mailed = append(mailed, to+": "+subject)
+		bodies = append(bodies, body)
+		return nil
+	}, true)}
+
+	create := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		es.handleCreateWatch(rec, httptest.NewRequest("POST", "/api/v1/watch", strings.NewReader(body)))
+		return rec
+	}
+	for _, body := range []string{
+		`{"address": "farmer"}`,
+		`{"address": "farmer", "webhook_url": "ftp://example.com", "email": ""}`,
+		`{"address": "farmer", "email": "not an email"}`,
+		`{"address": "farmer", "email": "ops@example.com", "events": ["burned"]}`,
+		`{"address": "", "email": "ops@example.com"}`,
+	} {
+		if rec := create(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+
+	rec := create(`{"address": "farmer", "email": "Ops <ops@example.com>", "events": ["mined"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var sub WatchSubscription
+	json.NewDecoder(rec.Body).Decode(&sub)
+	if sub.ID == "" || sub.Email != "ops@example.com" || !sub.Pending || sub.ConfirmToken != "" || sub.Owner != "" {
+		t.Fatalf("Unexpected subscription %+v", sub)
+	}
+	if len(mailed) != 1 || mailed[0] != "ops@example.com: Confirm notifications for farmer" {
+		t.Fatalf("Expected a confirmation mail, got %v", mailed)
+	}
+
+	// Nothing is sent until the subscription is confirmed
+	block, stored := watchTestBlock(3, time.Now())
+	es.watchlist.Notify(block, stored)
+	es.watchlist.DeliverDue(time.Now())
+	if len(mailed) != 1 {
+		t.Errorf("Expected no notification while pending, got %v", mailed)
+	}
+
+	vars := map[string]string{"id": sub.ID}
+	confirm := func(token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		es.handleConfirmWatch(rec, mux.SetURLVars(httptest.NewRequest("GET", "/?token="+token, nil), vars))
+		return rec
+	}
+	if rec := confirm("wrong"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a wrong token refused, got %d", rec.Code)
+	}
+	link := bodies[0][strings.Index(bodies[0], "http"):]
+	link = link[:strings.IndexByte(link, '\n')]
+	if !strings.Contains(link, "/api/v1/watch/"+sub.ID+"/confirm?token=") {
+		t.Fatalf("Unexpected confirmation link %q", link)
+	}
+	if rec := confirm(link[strings.Index(link, "token=")+len("token="):]); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the mailed token accepted, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// Subscriptions outlive a resync
+	if err := database.ResetDatabase(); err != nil {
+		t.Fatalf("ResetDatabase failed: %v", err)
+	}
+	es.watchlist.Notify(block, stored)
+	es.watchlist.DeliverDue(time.Now())
+	if len(mailed) != 2 || mailed[1] != "ops@example.com: farmer mined block 3" {
+		t.Errorf("Unexpected mail %v", mailed)
+	}
+
+	rec = httptest.NewRecorder()
+	es.handleDeleteWatch(rec, mux.SetURLVars(httptest.NewRequest("DELETE", "/", nil), vars))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	es.handleGetWatch(rec, mux.SetURLVars(httptest.NewRequest("GET", "/", nil), vars))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after unsubscribing, got %d", rec.Code)
+	}
+}
+
+func TestWatchSubscriptionsCappedPerClient(t *testing.T) {
+	database := newTestDatabase(t)
+	es := &ExplorerServer{database: database, watchlist: NewWatchlist(database, nil, true)}
+	es.watchlist.maxPerClient = 2
+
+	create := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/watch", strings.NewReader(`{"address": "bob", "webhook_url": "https://example.com/hook"}`))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		es.handleCreateWatch(rec, req)
+		return rec
+	}
+	var first WatchSubscription
+	for i := 0; i < 2; i++ {
+		rec := create("203.0.113.7:4000")
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+		}
+		json.NewDecoder(rec.Body).Decode(&first)
+	}
+	if rec := create("203.0.113.7:4001"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected a third subscription from one client refused, got %d", rec.Code)
+	}
+	if rec := create("203.0.113.8:4000"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected another client allowed, got %d", rec.Code)
+	}
+
+	// Unsubscribing frees a slot
+	if err := database.DeleteWatchSubscription(first.ID); err != nil {
+		t.Fatalf("DeleteWatchSubscription failed: %v", err)
+	}
+	if rec := create("203.0.113.7:4000"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected a freed slot to be usable, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const defaultAdminTokenFile = "admin.token"
+
+// adminTokenFromEnv reads the token admin requests must present from
+// TRACKER_ADMIN_TOKEN or the existing file in TRACKER_ADMIN_TOKEN_FILE.
+// Failing both, a token is generated once in TRACKER_DATA_DIR, readable by
+// the tracker's user only. With none of them set the admin API is disabled.
+func adminTokenFromEnv() string {
+	if token := os.Getenv("TRACKER_ADMIN_TOKEN"); token != "" {
+		return token
+	}
+
+	var token string
+	var err error
+	if path := os.Getenv("TRACKER_ADMIN_TOKEN_FILE"); path != "" {
+		token, err = readAdminToken(path)
+	} else if dir := os.Getenv("TRACKER_DATA_DIR"); dir != "" {
+		token, err = loadAdminToken(filepath.Join(dir, defaultAdminTokenFile))
+	} else {
+		log.Printf("⚠️ Admin API disabled: set TRACKER_ADMIN_TOKEN, TRACKER_ADMIN_TOKEN_FILE or TRACKER_DATA_DIR")
+		return ""
+	}
+	if err != nil {
+		log.Printf("⚠️ Admin API disabled: %v", err)
+		return ""
+	}
+	return token
+}
+
+// readAdminToken reads the token in path, which must already exist
+func readAdminToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read admin token: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("admin token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// loadAdminToken reads the token in path, generating and saving a new one
+// with mode 0600 if the file does not exist
+func loadAdminToken(path string) (string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return "", err
+		}
+		token := hex.EncodeToString(raw)
+		// O_EXCL so a file created meanwhile is never overwritten
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return "", fmt.Errorf("failed to save admin token: %w", err)
+		}
+		if _, err := file.WriteString(token + "\n"); err != nil {
+			file.Close()
+			return "", fmt.Errorf("failed to save admin token: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return "", fmt.Errorf("failed to save admin token: %w", err)
+		}
+		log.Printf("🔑 Generated admin token in %s", path)
+		return token, nil
+	}
+	return readAdminToken(path)
+}
+
+// requireAdmin lets through only requests bearing the admin token
+func (ts *TrackerService) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ts.adminToken == "" {
+			http.Error(w, "Admin API disabled", http.StatusServiceUnavailable)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		// Digests are compared so neither content nor length leaks in timing
+		presented, expected := sha256.Sum256([]byte(token)), sha256.Sum256([]byte(ts.adminToken))
+		if !ok || subtle.ConstantTimeCompare(presented[:], expected[:]) != 1 {
+			clientIP := ts.clientIP(r)
+			log.Printf("🔐 Rejected admin request %s %s from %s", r.Method, r.URL.Path, clientIP)
+			ts.events.Record(Event{Type: EventAdminDenied, IP: clientIP, Detail: r.Method + " " + r.URL.Path})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="shadowy-tracker"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleRemoveNode drops the node in the path from the registry at once.
+// With ?ban=true its node_id is banned as well, since an active node would
+// otherwise simply register again.
+func (ts *TrackerService) handleRemoveNode(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["nodeId"]
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	node, ok := ts.nodes[nodeID]
+	if !ok {
+		http.Error(w, "Node not found", http.StatusNotFound)
+		return
+	}
+
+	var ban *Ban
+	if r.URL.Query().Get("ban") == "true" {
+		reason := r.URL.Query().Get("reason")
+		if reason == "" {
+			reason = "removed by operator"
+		}
+		ban = &Ban{Kind: BanNode, Value: nodeID, Reason: reason, CreatedAt: time.Now().UTC()}
+		if err := ts.store.SaveBan(ban); err != nil {
+			log.Printf("⚠️ Failed to persist ban %s: %v", ban.key(), err)
+			http.Error(w, "Failed to save ban", http.StatusInternalServerError)
+			return
+		}
+		ts.bans.Add(ban)
+		ts.events.Record(Event{Type: EventBan, Detail: ban.key()})
+	}
+
+	log.Printf("🗑️ Removing node %s on operator request", nodeID)
+	ts.forgetNode(node, time.Now(), EventAdminRemoved, "removed by operator")
+	ts.peerChanges.Notify()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"node_id": nodeID,
+		"removed": true,
+		"ban":     ban,
+	})
+}
+
+// RegistryCompaction reports what compaction cleaned up
+type RegistryCompaction struct {
+	StaleEntries   int   `json:"stale_entries"` // In-memory index entries for departed nodes
+	StaleRecords   int   `json:"stale_records"` // Stored nodes no longer registered
+	BytesBefore    int64 `json:"bytes_before"`  // Store size on disk
+	BytesAfter     int64 `json:"bytes_after"`   // Store size on disk
+	DurationMillis int64 `json:"duration_ms"`
+}
+
+// compactRegistry drops registry entries and stored records for nodes that
+// are no longer registered, then reclaims the space the store holds for
+// deleted and overwritten records. The registry stays locked only while
+// stale entries are dropped, not while the store is rewritten.
+func (ts *TrackerService) compactRegistry() (RegistryCompaction, error) {
+	start := time.Now()
+	var result RegistryCompaction
+	err := func() error {
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		for nodeID := range ts.registry.nodes {
+			if _, ok := ts.nodes[nodeID]; !ok {
+				delete(ts.registry.nodes, nodeID)
+				result.StaleEntries++
+			}
+		}
+		var err error
+		result.StaleRecords, err = ts.store.PruneNodes(ts.nodes)
+		return err
+	}()
+	if err == nil {
+		result.BytesBefore, result.BytesAfter, err = ts.store.Compact()
+	}
+	result.DurationMillis = time.Since(start).Milliseconds()
+	return result, err
+}
+
+// handleCompact compacts the registry and its store
+func (ts *TrackerService) handleCompact(w http.ResponseWriter, r *http.Request) {
+	result, err := ts.compactRegistry()
+	if err != nil {
+		log.Printf("⚠️ Registry compaction failed: %v", err)
+		http.Error(w, "Compaction failed", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("🧹 Compacted registry: %d stale entries, %d stale records, %d -> %d bytes",
+		result.StaleEntries, result.StaleRecords, result.BytesBefore, result.BytesAfter)
+	ts.events.Record(Event{Type: EventCompaction,
+		Detail: fmt.Sprintf("%d stale records, %d -> %d bytes", result.StaleRecords, result.BytesBefore, result.BytesAfter)})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
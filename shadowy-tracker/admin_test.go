@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testAdminToken = "test-admin-token"
+
+func TestAdminRoutesRequireToken(t *testing.T) {
+	ts := NewTrackerService()
+	ts.events = newTestEventLog(t, defaultEventLogMaxBytes)
+	router := ts.internalRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/admin/status", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 with no admin token configured, got %d", rec.Code)
+	}
+
+	ts.adminToken = testAdminToken
+	for _, header := range []string{"", "Bearer wrong-token", testAdminToken, "Basic " + testAdminToken} {
+		req := httptest.NewRequest("GET", "/api/v1/admin/status", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for Authorization %q, got %d", header, rec.Code)
+		}
+	}
+	if events, _ := ts.events.Since(time.Time{}, 10); len(events) != 4 || events[0].Type != EventAdminDenied {
+		t.Errorf("Expected each refusal in the audit log, got %v", eventTypes(events))
+	}
+
+	if rec := tagRequest(t, ts, "GET", "/api/v1/admin/status", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the admin token, got %d", rec.Code)
+	}
+
+	// Metrics stay open to scrapers on the internal listener
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected metrics without a token, got %d", rec.Code)
+	}
+}
+
+func TestAdminTokenGeneratedOnFirstStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin.token")
+	token, err := loadAdminToken(path)
+	if err != nil || len(token) != 64 {
+		t.Fatalf("Expected a generated 32 byte hex token, got %q, %v", token, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the token saved with mode 0600, got %v, %v", info.Mode(), err)
+	}
+	if again, err := loadAdminToken(path); err != nil || again != token {
+		t.Errorf("Expected the saved token on the next start, got %q, %v", again, err)
+	}
+
+	os.WriteFile(path, []byte("  \n"), 0o600)
+	if _, err := loadAdminToken(path); err == nil {
+		t.Error("An empty token file should be refused")
+	}
+}
+
+func TestAdminTokenFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TRACKER_ADMIN_TOKEN", "")
+	t.Setenv("TRACKER_ADMIN_TOKEN_FILE", "")
+	t.Setenv("TRACKER_DATA_DIR", "")
+
+	if token := adminTokenFromEnv(); token != "" {
+		t.Errorf("Expected the admin API disabled with nothing configured, got %q", token)
+	}
+	if _, err := os.Stat(defaultAdminTokenFile); !os.IsNotExist(err) {
+		os.Remove(defaultAdminTokenFile)
+		t.Error("No token file should be written without a data directory")
+	}
+
+	// An explicit token file must already exist
+	t.Setenv("TRACKER_ADMIN_TOKEN_FILE", filepath.Join(dir, "missing.token"))
+	if token := adminTokenFromEnv(); token != "" {
+		t.Errorf("Expected a missing token file to disable the admin API, got %q", token)
+	}
+	os.WriteFile(filepath.Join(dir, "missing.token"), []byte("file-token\n"), 0o600)
+	if token := adminTokenFromEnv(); token != "file-token" {
+		t.Errorf("Expected the token from the file, got %q", token)
+	}
+
+	t.Setenv("TRACKER_ADMIN_TOKEN_FILE", "")
+	t.Setenv("TRACKER_DATA_DIR", filepath.Join(dir, "data"))
+	os.Mkdir(filepath.Join(dir, "data"), 0o700)
+	if token := adminTokenFromEnv(); len(token) != 64 {
+		t.Errorf("Expected a token generated in the data directory, got %q", token)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", "admin.token")); err != nil {
+		t.Errorf("Expected the generated token saved in the data directory: %v", err)
+	}
+
+	t.Setenv("TRACKER_ADMIN_TOKEN", "env-token")
+	if token := adminTokenFromEnv(); token != "env-token" {
+		t.Errorf("Expected TRACKER_ADMIN_TOKEN to win, got %q", token)
+	}
+}
+
+func TestAdminRemovesNode(t *testing.T) {
+	ts := NewTrackerService()
+	ts.store = openTestStore(t, t.TempDir())
+	defer ts.store.Close()
+	for _, id := range []string{"node-1", "node-2"} {
+		addTestNode(ts, id, 100, tib)
+		ts.registry.nodes[id] = ts.nodes[id]
+		ts.saveNode(ts.nodes[id])
+	}
+
+	if rec := tagRequest(t, ts, "DELETE", "/api/v1/admin/node/node-1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Removal failed: %d %s", rec.Code, rec.Body)
+	}
+	if _, ok := ts.nodes["node-1"]; ok {
+		t.Error("Removed node should leave the registry")
+	}
+	if stored, _ := ts.store.LoadNodes(); len(stored) != 1 || stored[0].NodeID != "node-2" {
+		t.Errorf("Removed node should leave the store, got %d stored", len(stored))
+	}
+
+	rec := tagRequest(t, ts, "DELETE", "/api/v1/admin/node/node-2?ban=true&reason=spam", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Removal with ban failed: %d %s", rec.Code, rec.Body)
+	}
+	if bans := ts.bans.List(); len(bans) != 1 || bans[0].Value != "node-2" || bans[0].Reason != "spam" {
+		t.Errorf("Expected node-2 to be banned for spam, got %+v", bans)
+	}
+
+	if rec := tagRequest(t, ts, "DELETE", "/api/v1/admin/node/node-9", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown node, got %d", rec.Code)
+	}
+}
+
+func TestAdminCompactsRegistry(t *testing.T) {
+	ts := NewTrackerService()
+	ts.store = openTestStore(t, t.TempDir())
+	defer ts.store.Close()
+	addTestNode(ts, "node-1", 100, tib)
+	ts.saveNode(ts.nodes["node-1"])
+	// Left behind by an older tracker
+	ts.store.SaveNode(&RegisteredNode{NodeID: "node-gone"})
+	ts.registry.nodes["node-gone"] = &RegisteredNode{NodeID: "node-gone"}
+
+	rec := tagRequest(t, ts, "POST", "/api/v1/admin/compact", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Compaction failed: %d %s", rec.Code, rec.Body)
+	}
+	var result RegistryCompaction
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode compaction: %v", err)
+	}
+	if result.StaleEntries != 1 || result.StaleRecords != 1 || result.BytesBefore == 0 {
+		t.Errorf("Expected one stale entry and record, got %+v", result)
+	}
+	if stored, _ := ts.store.LoadNodes(); len(stored) != 1 || stored[0].NodeID != "node-1" {
+		t.Errorf("Only registered nodes should remain stored, got %d", len(stored))
+	}
+}
+
+func TestAdminRemovalAlongsideRequests(t *testing.T) {
+	ts := NewTrackerService()
+	ts.store = openTestStore(t, t.TempDir())
+	defer ts.store.Close()
+	addPeerNodes(ts, 20)
+
+	// Run with -race: removals and compaction run while peers are queried
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			queryPeers(t, ts, "")
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		tagRequest(t, ts, "DELETE", fmt.Sprintf("/api/v1/admin/node/node-%02d", i), "")
+		tagRequest(t, ts, "POST", "/api/v1/admin/compact", "")
+	}
+	<-done
+
+	if ids, _ := queryPeers(t, ts, ""); len(ids) != 10 {
+		t.Errorf("Expected 10 peers left, got %d", len(ids))
+	}
+}
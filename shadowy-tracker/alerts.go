@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Alert rule types
+const (
+	AlertNodeOffline   = "node_offline"   // A node stopped heartbeating
+	AlertHeightStalled = "height_stalled" // Consensus height unchanged for For
+	AlertNetspaceDrop  = "netspace_drop"  // Netspace fell Percent below its peak over Window
+	AlertForkCount     = "fork_count"     // More than Threshold forks
+)
+
+// Alert destination types
+const (
+	DestinationWebhook = "webhook" // The Alert as JSON
+	DestinationDiscord = "discord"
+	DestinationSlack   = "slack"
+)
+
+const (
+	defaultAlertInterval      = time.Minute
+	defaultStallDuration      = 10 * time.Minute
+	defaultNetspaceDropWindow = time.Hour
+	alertDeliveryTimeout      = 10 * time.Second
+)
+
+// configDuration is a duration written as a string such as "10m"
+type configDuration time.Duration
+
+func (d *configDuration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("durations are strings such as \"10m\"")
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = configDuration(parsed)
+	return nil
+}
+
+func (d configDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// AlertRule is one condition the monitor watches. Chain limits it to one
+// chain; Tag limits node_offline to nodes carrying it.
+type AlertRule struct {
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Chain     string         `json:"chain,omitempty"`
+	Tag       string         `json:"tag,omitempty"`
+	For       configDuration `json:"for,omitempty"`
+	Window    configDuration `json:"window,omitempty"`
+	Percent   float64        `json:"percent,omitempty"`
+	Threshold int            `json:"threshold,omitempty"`
+}
+
+// AlertDestination is where alerts are posted
+type AlertDestination struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// AlertConfig is the layout of the TRACKER_ALERTS_FILE config
+type AlertConfig struct {
+	Interval     configDuration     `json:"interval,omitempty"`
+	Rules        []AlertRule        `json:"rules"`
+	Destinations []AlertDestination `json:"destinations"`
+}
+
+// Alert is a rule starting or stopping to match for a subject: a node_id
+// for node_offline, otherwise the chain ("" for the whole network)
+type Alert struct {
+	Rule    string    `json:"rule"`
+	Type    string    `json:"type"`
+	Subject string    `json:"subject,omitempty"`
+	Status  string    `json:"status"` // "firing" or "resolved"
+	Message string    `json:"message"`
+	Since   time.Time `json:"since"`
+	Time    time.Time `json:"time"`
+}
+
+// AlertMonitor evaluates the rules and remembers which alerts are firing
+type AlertMonitor struct {
+	cfg    AlertConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	firing   map[string]*Alert           // By rule|subject
+	stalls   map[string]heightMark       // By rule name
+	netspace map[string][]netspaceSample // By rule name
+}
+
+type heightMark struct {
+	height uint64
+	since  time.Time
+}
+
+type netspaceSample struct {
+	at       time.Time
+	netspace uint64
+}
+
+// LoadAlertConfig reads and checks the alerts file at path
+func LoadAlertConfig(path string, chains *ChainRegistry) (AlertConfig, error) {
+	var cfg AlertConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read alerts file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse alerts file: %w", err)
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = configDuration(defaultAlertInterval)
+	}
+
+	names := make(map[string]bool)
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if rule.Name == "" || names[rule.Name] {
+			return cfg, fmt.Errorf("rule %d needs a unique name", i)
+		}
+		names[rule.Name] = true
+		if rule.Chain != "" {
+			chain := chains.Resolve(rule.Chain)
+			if chain == nil {
+				return cfg, fmt.Errorf("rule %s: unknown chain %q", rule.Name, rule.Chain)
+			}
+			rule.Chain = chain.Name
+		}
+
+		switch rule.Type {
+		case AlertNodeOffline:
+		case AlertHeightStalled:
+			if rule.For <= 0 {
+				rule.For = configDuration(defaultStallDuration)
+			}
+		case AlertNetspaceDrop:
+			if rule.Percent <= 0 || rule.Percent >= 100 {
+				return cfg, fmt.Errorf("rule %s: percent must be between 0 and 100", rule.Name)
+			}
+			if rule.Window <= 0 {
+				rule.Window = configDuration(defaultNetspaceDropWindow)
+			}
+		case AlertForkCount:
+			if rule.Threshold < 1 {
+				return cfg, fmt.Errorf("rule %s: threshold must be at least 1", rule.Name)
+			}
+		default:
+			return cfg, fmt.Errorf("rule %s: unknown type %q", rule.Name, rule.Type)
+		}
+	}
+
+	for i, dest := range cfg.Destinations {
+		switch dest.Type {
+		case DestinationWebhook, DestinationDiscord, DestinationSlack:
+		default:
+			return cfg, fmt.Errorf("destination %d: unknown type %q", i, dest.Type)
+		}
+		if dest.URL == "" {
+			return cfg, fmt.Errorf("destination %d: url is required", i)
+		}
+	}
+	return cfg, nil
+}
+
+func NewAlertMonitor(cfg AlertConfig) *AlertMonitor {
+	return &AlertMonitor{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: alertDeliveryTimeout},
+		firing:   make(map[string]*Alert),
+		stalls:   make(map[string]heightMark),
+		netspace: make(map[string][]netspaceSample),
+	}
+}
+
+// alertMonitorFromEnv loads TRACKER_ALERTS_FILE; without it nothing is
+// monitored
+func alertMonitorFromEnv(chains *ChainRegistry) *AlertMonitor {
+	path := os.Getenv("TRACKER_ALERTS_FILE")
+	if path == "" {
+		return nil
+	}
+	cfg, err := LoadAlertConfig(path, chains)
+	if err != nil {
+		log.Printf("⚠️ Alerting disabled: %v", err)
+		return nil
+	}
+	log.Printf("🔔 Watching %d alert rules, delivering to %d destinations", len(cfg.Rules), len(cfg.Destinations))
+	return NewAlertMonitor(cfg)
+}
+
+// monitorAlerts evaluates the alert rules on the configured interval
+func (ts *TrackerService) monitorAlerts() {
+	ticker := time.NewTicker(time.Duration(ts.alerts.cfg.Interval))
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		ts.mu.RLock()
+		alerts := ts.evaluateAlerts(now)
+		ts.mu.RUnlock()
+		for _, alert := range alerts {
+			go ts.alerts.deliver(alert)
+		}
+	}
+}
+
+// evaluateAlerts checks every rule at now and returns the alerts that
+// started firing or resolved since the last evaluation
+func (ts *TrackerService) evaluateAlerts(now time.Time) []Alert {
+	m := ts.alerts
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Subjects matching each rule right now, with what to say about them
+	matching := make(map[string]Alert)
+	match := func(rule AlertRule, subject, message string) {
+		matching[rule.Name+"|"+subject] = Alert{Rule: rule.Name, Type: rule.Type, Subject: subject, Message: message}
+	}
+
+	inGrace := now.Before(ts.startedAt.Add(ts.cleanup.StartupGrace))
+	for _, rule := range m.cfg.Rules {
+		nodes := ts.nodesOnChain(rule.Chain)
+		switch rule.Type {
+		case AlertNodeOffline:
+			// Nodes restored at startup have not had a chance to heartbeat yet
+			if inGrace {
+				continue
+			}
+			for _, node := range nodes {
+				if (rule.Tag == "" || node.hasTag(rule.Tag)) && !isOnlineAt(node, now) {
+					match(rule, node.NodeID, fmt.Sprintf("Node %s is offline, last heartbeat %s", node.NodeID, node.LastHeartbeat.UTC().Format(time.RFC3339)))
+				}
+			}
+
+		case AlertHeightStalled:
+			height := ts.calculateChainStats(rule.Chain).ConsensusHeight
+			mark, ok := m.stalls[rule.Name]
+			if !ok || mark.height != height {
+				mark = heightMark{height: height, since: now}
+				m.stalls[rule.Name] = mark
+			}
+			if stalled := now.Sub(mark.since); len(nodes) > 0 && stalled >= time.Duration(rule.For) {
+				match(rule, rule.Chain, fmt.Sprintf("Consensus height %s stuck at %d for %s", chainLabel(rule.Chain), height, stalled.Round(time.Second)))
+			}
+
+		case AlertNetspaceDrop:
+			netspace := ts.calculateChainStats(rule.Chain).TotalNetspace
+			samples := append(m.netspace[rule.Name], netspaceSample{at: now, netspace: netspace})
+			for len(samples) > 0 && now.Sub(samples[0].at) > time.Duration(rule.Window) {
+				samples = samples[1:]
+			}
+			m.netspace[rule.Name] = samples
+
+			var peak uint64
+			for _, sample := range samples {
+				if sample.netspace > peak {
+					peak = sample.netspace
+				}
+			}
+			if peak > 0 && float64(netspace) < float64(peak)*(1-rule.Percent/100) {
+				match(rule, rule.Chain, fmt.Sprintf("Netspace %s dropped %.1f%% in %s, from %d to %d bytes",
+					chainLabel(rule.Chain), 100*(1-float64(netspace)/float64(peak)), time.Duration(rule.Window), peak, netspace))
+			}
+
+		case AlertForkCount:
+			if forks := ts.calculateChainStats(rule.Chain).ForkCount; forks > rule.Threshold {
+				match(rule, rule.Chain, fmt.Sprintf("%d forks %s, more than %d", forks, chainLabel(rule.Chain), rule.Threshold))
+			}
+		}
+	}
+
+	var changes []Alert
+	for key, alert := range matching {
+		if _, ok := m.firing[key]; ok {
+			continue
+		}
+		alert.Status, alert.Since, alert.Time = "firing", now, now
+		m.firing[key] = &alert
+		changes = append(changes, alert)
+	}
+	for key, alert := range m.firing {
+		if _, ok := matching[key]; ok {
+			continue
+		}
+		resolved := *alert
+		resolved.Status, resolved.Time = "resolved", now
+		resolved.Message = "Resolved: " + alert.Message
+		delete(m.firing, key)
+		changes = append(changes, resolved)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Rule != changes[j].Rule {
+			return changes[i].Rule < changes[j].Rule
+		}
+		return changes[i].Subject < changes[j].Subject
+	})
+	for _, alert := range changes {
+		log.Printf("🔔 Alert %s %s: %s", alert.Rule, alert.Status, alert.Message)
+		ts.events.Record(Event{Type: EventAlert, NodeID: nodeSubject(alert), Detail: alert.Status + ": " + alert.Message})
+	}
+	return changes
+}
+
+// nodeSubject is the node an alert is about, if any
+func nodeSubject(alert Alert) string {
+	if alert.Type == AlertNodeOffline {
+		return alert.Subject
+	}
+	return ""
+}
+
+// chainLabel names a chain in alert messages
+func chainLabel(chain string) string {
+	if chain == "" {
+		return "across all chains"
+	}
+	return "on " + chain
+}
+
+// deliver posts an alert to every destination, logging failures
+func (m *AlertMonitor) deliver(alert Alert) {
+	text := fmt.Sprintf("[%s] %s: %s", alert.Status, alert.Rule, alert.Message)
+	for _, dest := range m.cfg.Destinations {
+		var payload interface{} = alert
+		switch dest.Type {
+		case DestinationDiscord:
+			payload = map[string]string{"content": text}
+		case DestinationSlack:
+			payload = map[string]string{"text": text}
+		}
+		body, _ := json.Marshal(payload)
+
+		resp, err := m.client.Post(dest.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("⚠️ Failed to deliver alert %s to %s: %v", alert.Rule, dest.Type, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("⚠️ Alert %s rejected by %s: %s", alert.Rule, dest.Type, resp.Status)
+		}
+	}
+}
+
+// handleAdminAlerts lists the alert rules and the alerts firing now
+func (ts *TrackerService) handleAdminAlerts(w http.ResponseWriter, r *http.Request) {
+	rules := []AlertRule{}
+	firing := []Alert{}
+	if ts.alerts != nil {
+		ts.alerts.mu.Lock()
+		rules = ts.alerts.cfg.Rules
+		for _, alert := range ts.alerts.firing {
+			firing = append(firing, *alert)
+		}
+		ts.alerts.mu.Unlock()
+	}
+	sort.Slice(firing, func(i, j int) bool { return firing[i].Since.Before(firing[j].Since) })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled": ts.alerts != nil,
+		"rules":   rules,
+		"firing":  firing,
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeAlertConfig(t *testing.T, config string) string {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatalf("Failed to write alerts file: %v", err)
+	}
+	return path
+}
+
+func TestAlertRulesFireAndResolve(t *testing.T) {
+	cfg, err := LoadAlertConfig(writeAlertConfig(t, `{
+		"rules": [
+			{"name": "offline", "type": "node_offline", "tag": "core"},
+			{"name": "stall", "type": "height_stalled", "for": "10m", "chain": "testnet0"},
+			{"name": "netspace", "type": "netspace_drop", "percent": 25, "window": "1h"},
+			{"name": "forks", "type": "fork_count", "threshold": 1}
+		],
+		"destinations": []
+	}`), builtinChains())
+	if err != nil {
+		t.Fatalf("LoadAlertConfig failed: %v", err)
+	}
+
+	ts := NewTrackerService()
+	ts.alerts = NewAlertMonitor(cfg)
+	ts.cleanup.StartupGrace = 0
+	now := time.Now()
+	for _, id := range []string{"node-1", "node-2", "node-3"} {
+		addTestNode(ts, id, 100, 2*tib)
+		ts.nodes[id].ChainID = "testnet0"
+		ts.nodes[id].LastHeartbeat = now
+	}
+	ts.nodes["node-1"].Tags = []string{"core"}
+
+	if changes := ts.evaluateAlerts(now); len(changes) != 0 {
+		t.Fatalf("Expected a healthy network to raise nothing, got %+v", changes)
+	}
+
+	// node-1 and node-2 go quiet and take their netspace with them; only
+	// node-1 is watched. The height has been stuck since the first check.
+	later := now.Add(11 * time.Minute)
+	ts.nodes["node-3"].LastHeartbeat = later
+	ts.nodes["node-1"].TotalPlotSize, ts.nodes["node-2"].TotalPlotSize = 0, 0
+	changes := ts.evaluateAlerts(later)
+	var fired []string
+	for _, alert := range changes {
+		if alert.Status != "firing" {
+			t.Errorf("Expected only new alerts, got %+v", alert)
+		}
+		fired = append(fired, alert.Rule+":"+alert.Subject)
+	}
+	if strings.Join(fired, ",") != "netspace:,offline:node-1,stall:testnet0" {
+		t.Errorf("Unexpected alerts %v", fired)
+	}
+	if again := ts.evaluateAlerts(later.Add(time.Minute)); len(again) != 0 {
+		t.Errorf("Expected firing alerts not to repeat, got %+v", again)
+	}
+
+	// A fork, and the height moving again
+	ts.nodes["node-1"].LastHeartbeat = later.Add(2 * time.Minute)
+	ts.nodes["node-2"].ChainHeight, ts.nodes["node-3"].ChainHeight = 101, 101
+	ts.nodes["node-1"].ChainHeight, ts.nodes["node-2"].Status = 101, "online"
+	for id, hash := range map[string]string{"node-1": "aa", "node-2": "aa", "node-3": "aa", "node-4": "bb", "node-5": "cc"} {
+		if ts.nodes[id] == nil {
+			addTestNode(ts, id, 101, tib)
+		}
+		ts.nodes[id].ChainHash = hash
+	}
+	changes = ts.evaluateAlerts(later.Add(2 * time.Minute))
+	statuses := make(map[string]string)
+	for _, alert := range changes {
+		statuses[alert.Rule] = alert.Status
+	}
+	if statuses["offline"] != "resolved" || statuses["stall"] != "resolved" || statuses["forks"] != "firing" {
+		t.Errorf("Unexpected changes %+v", changes)
+	}
+}
+
+func TestAlertConfigValidation(t *testing.T) {
+	for _, config := range []string{
+		`{"rules": [{"name": "x", "type": "bogus"}]}`,
+		`{"rules": [{"name": "x", "type": "netspace_drop", "percent": 120}]}`,
+		`{"rules": [{"name": "x", "type": "fork_count"}]}`,
+		`{"rules": [{"name": "x", "type": "node_offline", "chain": "nope"}]}`,
+		`{"rules": [{"name": "x", "type": "height_stalled", "for": 600}]}`,
+		`{"destinations": [{"type": "pager", "url": "http://example.com"}]}`,
+	} {
+		if _, err := LoadAlertConfig(writeAlertConfig(t, config), builtinChains()); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+}
+
+func TestAlertDelivery(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = string(body)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	monitor := NewAlertMonitor(AlertConfig{Destinations: []AlertDestination{
+		{Type: DestinationWebhook, URL: server.URL + "/hook"},
+		{Type: DestinationDiscord, URL: server.URL + "/discord"},
+		{Type: DestinationSlack, URL: server.URL + "/slack"},
+	}})
+	monitor.deliver(Alert{Rule: "forks", Type: AlertForkCount, Status: "firing", Message: "3 forks"})
+
+	var hook Alert
+	json.Unmarshal([]byte(bodies["/hook"]), &hook)
+	if hook.Rule != "forks" || hook.Status != "firing" {
+		t.Errorf("Expected the alert as JSON on the webhook, got %s", bodies["/hook"])
+	}
+	if bodies["/discord"] != `{"content":"[firing] forks: 3 forks"}` {
+		t.Errorf("Unexpected Discord message %s", bodies["/discord"])
+	}
+	if bodies["/slack"] != `{"text":"[firing] forks: 3 forks"}` {
+		t.Errorf("Unexpected Slack message %s", bodies["/slack"])
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// Kinds of ban
+const (
+	BanNode   = "node"
+	BanIP     = "ip"
+	BanSubnet = "subnet"
+)
+
+const banKeyPrefix = "ban:"
+
+// Ban excludes the nodes it matches from peer discovery
+type Ban struct {
+	Kind      string    `json:"kind"`  // BanNode, BanIP or BanSubnet
+	Value     string    `json:"value"` // node_id, IP or CIDR
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (b *Ban) key() string {
+	return b.Kind + ":" + b.Value
+}
+
+// BanList holds the admin-managed bans
+type BanList struct {
+	mu      sync.RWMutex
+	bans    map[string]*Ban // By kind:value
+	subnets map[string]*net.IPNet
+}
+
+func NewBanList() *BanList {
+	return &BanList{bans: make(map[string]*Ban), subnets: make(map[string]*net.IPNet)}
+}
+
+// Add adds or replaces a ban; the ban must already be validated
+func (b *BanList) Add(ban *Ban) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bans[ban.key()] = ban
+	if ban.Kind == BanSubnet {
+		_, network, _ := net.ParseCIDR(ban.Value)
+		b.subnets[ban.Value] = network
+	}
+}
+
+// Remove lifts a ban, reporting whether it existed
+func (b *BanList) Remove(kind, value string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := kind + ":" + value
+	if _, ok := b.bans[key]; !ok {
+		return false
+	}
+	delete(b.bans, key)
+	if kind == BanSubnet {
+		delete(b.subnets, value)
+	}
+	return true
+}
+
+// List returns every ban, oldest first
+func (b *BanList) List() []*Ban {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	bans := make([]*Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		bans = append(bans, ban)
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if !bans[i].CreatedAt.Equal(bans[j].CreatedAt) {
+			return bans[i].CreatedAt.Before(bans[j].CreatedAt)
+		}
+		return bans[i].key() < bans[j].key()
+	})
+	return bans
+}
+
+// Match returns the ban covering a node by its node_id or either of its
+// IPs, or nil if it is not banned
+func (b *BanList) Match(node *RegisteredNode) *Ban {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if ban, ok := b.bans[BanNode+":"+node.NodeID]; ok {
+		return ban
+	}
+	for _, addr := range []string{node.ObservedIP, node.ExternalIP} {
+		ip := net.ParseIP(normalizeIP(addr))
+		if ip == nil {
+			continue
+		}
+		if ban, ok := b.bans[BanIP+":"+ip.String()]; ok {
+			return ban
+		}
+		for value, network := range b.subnets {
+			if network.Contains(ip) {
+				return b.bans[BanSubnet+":"+value]
+			}
+		}
+	}
+	return nil
+}
+
+// BanRequest is the body of the admin ban endpoint: exactly one of
+// node_id, ip or subnet
+type BanRequest struct {
+	NodeID string `json:"node_id"`
+	IP     string `json:"ip"`
+	Subnet string `json:"subnet"`
+	Reason string `json:"reason"`
+}
+
+// toBan validates a ban request, normalizing IPs and subnets so equal bans
+// share a key
+func (req *BanRequest) toBan() (*Ban, []FieldError) {
+	targets := 0
+	ban := &Ban{Reason: strings.TrimSpace(req.Reason), CreatedAt: time.Now().UTC()}
+
+	if nodeID := strings.TrimSpace(req.NodeID); nodeID != "" {
+		ban.Kind, ban.Value = BanNode, nodeID
+		targets++
+	}
+	if req.IP != "" {
+		ip := net.ParseIP(normalizeIP(req.IP))
+		if ip == nil {
+			return nil, []FieldError{{Field: "ip", Message: "must be an IP address"}}
+		}
+		ban.Kind, ban.Value = BanIP, ip.String()
+		targets++
+	}
+	if req.Subnet != "" {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(req.Subnet))
+		if err != nil {
+			return nil, []FieldError{{Field: "subnet", Message: "must be a CIDR such as 203.0.113.0/24"}}
+		}
+		ban.Kind, ban.Value = BanSubnet, network.String()
+		targets++
+	}
+
+	if targets != 1 {
+		return nil, []FieldError{{Field: "body", Message: "exactly one of node_id, ip or subnet is required"}}
+	}
+	return ban, nil
+}
+
+// SaveBan persists a ban
+func (s *NodeStore) SaveBan(ban *Ban) error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return putJSON(txn, []byte(banKeyPrefix+ban.key()), ban)
+	})
+}
+
+// DeleteBan forgets a ban
+func (s *NodeStore) DeleteBan(kind, value string) error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(banKeyPrefix + kind + ":" + value))
+	})
+}
+
+// LoadBans reads every stored ban
+func (s *NodeStore) LoadBans() ([]*Ban, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var bans []*Ban
+	err := s.db.View(func(txn *badger.Txn) error {
+		return eachJSON(txn, banKeyPrefix, func(ban *Ban) {
+			bans = append(bans, ban)
+		})
+	})
+	return bans, err
+}
+
+// loadBans restores the bans persisted before the last shutdown
+func (ts *TrackerService) loadBans() error {
+	bans, err := ts.store.LoadBans()
+	if err != nil {
+		return err
+	}
+	for _, ban := range bans {
+		ts.bans.Add(ban)
+	}
+	if len(bans) > 0 {
+		log.Printf("💾 Restored %d bans", len(bans))
+	}
+	return nil
+}
+
+// handleGetBans lists the active bans
+func (ts *TrackerService) handleGetBans(w http.ResponseWriter, r *http.Request) {
+	bans := ts.bans.List()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bans":  bans,
+		"count": len(bans),
+	})
+}
+
+// handleAddBan bans a node_id, IP or subnet from peer discovery
+func (ts *TrackerService) handleAddBan(w http.ResponseWriter, r *http.Request) {
+	var req BanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "Invalid request body", decodeErrorFields(err))
+		return
+	}
+	ban, fieldErrs := req.toBan()
+	if len(fieldErrs) > 0 {
+		writeValidationError(w, "Invalid ban", fieldErrs)
+		return
+	}
+
+	if err := ts.store.SaveBan(ban); err != nil {
+		log.Printf("⚠️ Failed to persist ban %s: %v", ban.key(), err)
+		http.Error(w, "Failed to save ban", http.StatusInternalServerError)
+		return
+	}
+	ts.bans.Add(ban)
+	ts.peerChanges.Notify()
+	log.Printf("🚫 Banned %s %s: %s", ban.Kind, ban.Value, ban.Reason)
+	ts.events.Record(Event{Type: EventBan, Detail: ban.key()})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ban)
+}
+
+// handleRemoveBan lifts the ban on the kind and value in the path
+func (ts *TrackerService) handleRemoveBan(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if !ts.bans.Remove(vars["kind"], vars["value"]) {
+		http.Error(w, "Ban not found", http.StatusNotFound)
+		return
+	}
+	if err := ts.store.DeleteBan(vars["kind"], vars["value"]); err != nil {
+		log.Printf("⚠️ Failed to forget ban %s:%s: %v", vars["kind"], vars["value"], err)
+	}
+	ts.peerChanges.Notify()
+	log.Printf("🚫 Lifted ban on %s %s", vars["kind"], vars["value"])
+	ts.events.Record(Event{Type: EventUnban, Detail: vars["kind"] + ":" + vars["value"]})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func peerIDs(t *testing.T, ts *TrackerService) []string {
+	rec := httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/peers", nil))
+	var resp struct {
+		Peers []struct {
+			NodeID string `json:"node_id"`
+		}
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode peers: %v", err)
+	}
+	var ids []string
+	for _, peer := range resp.Peers {
+		ids = append(ids, peer.NodeID)
+	}
+	return ids
+}
+
+func TestBansExcludePeers(t *testing.T) {
+	ts := NewTrackerService()
+	for i, id := range []string{"node-1", "node-2", "node-3", "node-4"} {
+		addTestNode(ts, id, 100, tib)
+		ts.nodes[id].Score = 50 + i
+	}
+	ts.nodes["node-2"].ObservedIP = "203.0.113.7"
+	ts.nodes["node-3"].ExternalIP = "198.51.100.20"
+
+	if got := peerIDs(t, ts); len(got) != 4 || got[0] != "node-4" || got[3] != "node-1" {
+		t.Fatalf("Expected every peer, best scored first, got %v", got)
+	}
+
+	for _, body := range []string{
+		`{"node_id":"node-1","reason":"spam"}`,
+		`{"ip":"203.0.113.7"}`,
+		`{"subnet":"198.51.100.0/24"}`,
+	} {
+		if rec := tagRequest(t, ts, "POST", "/api/v1/admin/bans", body); rec.Code != http.StatusCreated {
+			t.Fatalf("Ban %s failed: %d %s", body, rec.Code, rec.Body)
+		}
+	}
+	if got := peerIDs(t, ts); len(got) != 1 || got[0] != "node-4" {
+		t.Errorf("Expected banned nodes left out of peers, got %v", got)
+	}
+
+	if rec := tagRequest(t, ts, "DELETE", "/api/v1/admin/bans/subnet/198.51.100.0/24", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("Lifting the subnet ban failed: %d", rec.Code)
+	}
+	if got := peerIDs(t, ts); len(got) != 2 {
+		t.Errorf("Expected node-3 back after the subnet ban is lifted, got %v", got)
+	}
+	if rec := tagRequest(t, ts, "GET", "/api/v1/admin/bans", ""); !json.Valid(rec.Body.Bytes()) || len(ts.bans.List()) != 2 {
+		t.Errorf("Expected two bans listed, got %s", rec.Body)
+	}
+}
+
+func TestBanValidation(t *testing.T) {
+	ts := NewTrackerService()
+	for _, body := range []string{`{}`, `{"ip":"not-an-ip"}`, `{"subnet":"10.0.0.0/33"}`, `{"node_id":"a","ip":"10.0.0.1"}`} {
+		if rec := tagRequest(t, ts, "POST", "/api/v1/admin/bans", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestBansSurviveRestart(t *testing.T) {
+	path := t.TempDir()
+	ts := NewTrackerService()
+	ts.store = openTestStore(t, path)
+	tagRequest(t, ts, "POST", "/api/v1/admin/bans", `{"ip":"2001:db8::1"}`)
+	ts.store.Close()
+
+	restarted := NewTrackerService()
+	restarted.store = openTestStore(t, path)
+	defer restarted.store.Close()
+	if err := restarted.loadBans(); err != nil {
+		t.Fatalf("loadBans failed: %v", err)
+	}
+	if bans := restarted.bans.List(); len(bans) != 1 || bans[0].Kind != BanIP || bans[0].Value != "2001:db8::1" {
+		t.Errorf("Expected the IP ban restored, got %+v", bans)
+	}
+}
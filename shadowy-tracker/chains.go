@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Chain is a network the tracker accepts nodes for. Nodes identify it by
+// the hash of its genesis block; the tracker reports it by name.
+type Chain struct {
+	Name        string          `json:"name"`
+	GenesisHash string          `json:"genesis_hash"`
+	Genesis     json.RawMessage `json:"genesis,omitempty"` // Served to bootstrapping nodes
+}
+
+// chainsFile is the layout of the TRACKER_CHAINS_FILE config
+type chainsFile struct {
+	Default string   `json:"default"`
+	Chains  []*Chain `json:"chains"`
+}
+
+// ChainRegistry maps chain names and genesis hashes to chains. When loaded
+// from a file, admin changes are written back to it.
+type ChainRegistry struct {
+	mu           sync.RWMutex
+	chains       map[string]*Chain // By name
+	defaultChain string            // Served at /v1/sxe
+	path         string            // Empty keeps changes in memory only
+}
+
+// builtinChains is the registry used without a chains file: testnet0 only
+func builtinChains() *ChainRegistry {
+	return &ChainRegistry{
+		chains: map[string]*Chain{
+			"testnet0": {Name: "testnet0", GenesisHash: testnet0, Genesis: json.RawMessage(activeGenesis)},
+		},
+		defaultChain: "testnet0",
+	}
+}
+
+// LoadChainRegistry reads the chains file at path. A missing file starts
+// from the built-in chains and is created on the first change.
+func LoadChainRegistry(path string) (*ChainRegistry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		registry := builtinChains()
+		registry.path = path
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chains file: %w", err)
+	}
+
+	var file chainsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse chains file: %w", err)
+	}
+	registry := &ChainRegistry{chains: make(map[string]*Chain), defaultChain: file.Default, path: path}
+	for i, chain := range file.Chains {
+		if fieldErrs := validateChain(chain); len(fieldErrs) > 0 {
+			return nil, fmt.Errorf("chain %d: %s %s", i, fieldErrs[0].Field, fieldErrs[0].Message)
+		}
+		if other := registry.lookup(chain.GenesisHash); other != nil {
+			return nil, fmt.Errorf("chains %s and %s share a genesis hash", other.Name, chain.Name)
+		}
+		registry.chains[chain.Name] = chain
+	}
+	if _, ok := registry.chains[registry.defaultChain]; !ok && registry.defaultChain != "" {
+		return nil, fmt.Errorf("default chain %q is not defined", registry.defaultChain)
+	}
+	return registry, nil
+}
+
+// chainRegistryFromEnv loads TRACKER_CHAINS_FILE, falling back to the
+// built-in chains when it is unset or unreadable
+func chainRegistryFromEnv() *ChainRegistry {
+	path := os.Getenv("TRACKER_CHAINS_FILE")
+	if path == "" {
+		return builtinChains()
+	}
+	registry, err := LoadChainRegistry(path)
+	if err != nil {
+		log.Printf("⚠️ Using the built-in chains, %s not loaded: %v", path, err)
+		return builtinChains()
+	}
+	log.Printf("⛓️ Tracking %d chains from %s", len(registry.chains), path)
+	return registry
+}
+
+// validateChain checks a chain definition field by field
+func validateChain(chain *Chain) []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case chain.Name == "":
+		add("name", "is required")
+	case strings.ContainsAny(chain.Name, "/: \t\r\n"):
+		add("name", "must not contain slashes, colons or whitespace")
+	}
+
+	if hash, err := hex.DecodeString(chain.GenesisHash); err != nil || len(hash) != 32 {
+		add("genesis_hash", "must be 64 hex characters")
+	}
+
+	if len(chain.Genesis) > 0 && !json.Valid(chain.Genesis) {
+		add("genesis", "must be a JSON document")
+	}
+	return errs
+}
+
+// lookup finds a chain by name or genesis hash; the caller holds mu
+func (c *ChainRegistry) lookup(id string) *Chain {
+	if chain, ok := c.chains[id]; ok {
+		return chain
+	}
+	for _, chain := range c.chains {
+		if strings.EqualFold(chain.GenesisHash, id) {
+			return chain
+		}
+	}
+	return nil
+}
+
+// Resolve finds a chain by name or genesis hash, nil if it is unknown
+func (c *ChainRegistry) Resolve(id string) *Chain {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lookup(id)
+}
+
+// Default returns the chain served to nodes that do not name one
+func (c *ChainRegistry) Default() *Chain {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.chains[c.defaultChain]
+}
+
+// List returns every chain sorted by name
+func (c *ChainRegistry) List() []*Chain {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	chains := make([]*Chain, 0, len(c.chains))
+	for _, chain := range c.chains {
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].Name < chains[j].Name })
+	return chains
+}
+
+// Put adds or replaces a chain, making it the default if asked or if there
+// is none yet
+func (c *ChainRegistry) Put(chain *Chain, makeDefault bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if other := c.lookup(chain.GenesisHash); other != nil && other.Name != chain.Name {
+		return fmt.Errorf("genesis hash is already registered as %s", other.Name)
+	}
+
+	previous, existed := c.chains[chain.Name]
+	previousDefault := c.defaultChain
+	c.chains[chain.Name] = chain
+	if makeDefault || c.defaultChain == "" {
+		c.defaultChain = chain.Name
+	}
+	if err := c.save(); err != nil {
+		if existed {
+			c.chains[chain.Name] = previous
+		} else {
+			delete(c.chains, chain.Name)
+		}
+		c.defaultChain = previousDefault
+		return err
+	}
+	return nil
+}
+
+// Remove deletes a chain. Nodes already registered on it are kept.
+func (c *ChainRegistry) Remove(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	chain, ok := c.chains[name]
+	if !ok {
+		return nil
+	}
+	if name == c.defaultChain {
+		return fmt.Errorf("%s is the default chain", name)
+	}
+
+	delete(c.chains, name)
+	if err := c.save(); err != nil {
+		c.chains[name] = chain
+		return err
+	}
+	return nil
+}
+
+// save writes the registry to its file, if it has one; the caller holds mu
+func (c *ChainRegistry) save() error {
+	if c.path == "" {
+		return nil
+	}
+	file := chainsFile{Default: c.defaultChain}
+	for _, chain := range c.chains {
+		file.Chains = append(file.Chains, chain)
+	}
+	sort.Slice(file.Chains, func(i, j int) bool { return file.Chains[i].Name < file.Chains[j].Name })
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal chains: %w", err)
+	}
+	// Write beside the file and rename so a crash never leaves it half written
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save chains: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save chains: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save chains: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to save chains: %w", err)
+	}
+	return nil
+}
+
+// chainName returns the name nodes on the chain identified by id are
+// reported under: the registered name, or id itself if the chain is unknown
+func (ts *TrackerService) chainName(id string) string {
+	if chain := ts.chains.Resolve(id); chain != nil {
+		return chain.Name
+	}
+	return id
+}
+
+// requestedChain resolves ?chain= to a chain name. An empty name means
+// every chain; ok is false if the chain is unknown.
+func (ts *TrackerService) requestedChain(r *http.Request) (name string, ok bool) {
+	id := r.URL.Query().Get("chain")
+	if id == "" {
+		return "", true
+	}
+	chain := ts.chains.Resolve(id)
+	if chain == nil {
+		return "", false
+	}
+	return chain.Name, true
+}
+
+// nodesOnChain returns the nodes registered on the named chain, or every
+// node if name is empty
+func (ts *TrackerService) nodesOnChain(name string) map[string]*RegisteredNode {
+	if name == "" {
+		return ts.nodes
+	}
+	nodes := make(map[string]*RegisteredNode)
+	for id, node := range ts.nodes {
+		if node.ChainID == name {
+			nodes[id] = node
+		}
+	}
+	return nodes
+}
+
+// ChainSummary describes a chain in the chain listing
+type ChainSummary struct {
+	Name        string `json:"name"`
+	GenesisHash string `json:"genesis_hash"`
+	Default     bool   `json:"default"`
+	Nodes       int    `json:"nodes"`
+	OnlineNodes int    `json:"online_nodes"`
+}
+
+// handleGetChains lists the chains the tracker accepts nodes for
+func (ts *TrackerService) handleGetChains(w http.ResponseWriter, r *http.Request) {
+	var defaultName string
+	if chain := ts.chains.Default(); chain != nil {
+		defaultName = chain.Name
+	}
+
+	summaries := []ChainSummary{}
+	ts.mu.RLock()
+	for _, chain := range ts.chains.List() {
+		summary := ChainSummary{Name: chain.Name, GenesisHash: chain.GenesisHash, Default: chain.Name == defaultName}
+		for _, node := range ts.nodesOnChain(chain.Name) {
+			summary.Nodes++
+			if node.Status == "online" && time.Since(node.LastHeartbeat) < 5*time.Minute {
+				summary.OnlineNodes++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	ts.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"chains": summaries,
+		"count":  len(summaries),
+	})
+}
+
+// ChainRequest is the body of the admin chain endpoint
+type ChainRequest struct {
+	GenesisHash string          `json:"genesis_hash"`
+	Genesis     json.RawMessage `json:"genesis"`
+	Default     bool            `json:"default"`
+}
+
+// handlePutChain adds or replaces the chain named in the path
+func (ts *TrackerService) handlePutChain(w http.ResponseWriter, r *http.Request) {
+	var req ChainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "Invalid request body", decodeErrorFields(err))
+		return
+	}
+
+	chain := &Chain{Name: mux.Vars(r)["name"], GenesisHash: strings.ToLower(req.GenesisHash), Genesis: req.Genesis}
+	if fieldErrs := validateChain(chain); len(fieldErrs) > 0 {
+		writeValidationError(w, "Invalid chain", fieldErrs)
+		return
+	}
+	if err := ts.chains.Put(chain, req.Default); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Printf("⛓️ Chain %s set to genesis %s", chain.Name, chain.GenesisHash)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chain)
+}
+
+// handleDeleteChain stops accepting registrations for the chain in the path
+func (ts *TrackerService) handleDeleteChain(w http.ResponseWriter, r *http.Request) {
+	chain := ts.chains.Resolve(mux.Vars(r)["name"])
+	if chain == nil {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	if err := ts.chains.Remove(chain.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Printf("⛓️ Chain %s removed", chain.Name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// chainNav renders the dashboard links that scope it to each chain
+func (ts *TrackerService) chainNav(current string) string {
+	link := func(href, label string, active bool) string {
+		class := ""
+		if active {
+			class = ` class="active"`
+		}
+		return fmt.Sprintf(`<a href="%s"%s>%s</a>`, href, class, html.EscapeString(label))
+	}
+
+	links := []string{link("/dashboard", "All chains", current == "")}
+	for _, chain := range ts.chains.List() {
+		links = append(links, link("/dashboard?chain="+url.QueryEscape(chain.Name), chain.Name, chain.Name == current))
+	}
+	return `<div class="nav">` + strings.Join(links, "") + `</div>`
+}
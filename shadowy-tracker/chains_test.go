@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const devnetHash = "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+
+func TestRegisterOnAddedChain(t *testing.T) {
+	ts := NewTrackerService()
+
+	// Unknown until an operator adds it
+	payload := validRegistration()
+	payload["chain_id"] = devnetHash
+	signRegistration(payload)
+	if rec := postRegistration(t, ts, payload); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected an unknown chain rejected, got %d", rec.Code)
+	}
+
+	rec := tagRequest(t, ts, "PUT", "/api/v1/admin/chains/devnet", `{"genesis_hash":"`+devnetHash+`","genesis":{"network_id":"devnet"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Adding a chain failed: %d %s", rec.Code, rec.Body)
+	}
+	if rec := postRegistration(t, ts, payload); rec.Code != http.StatusOK {
+		t.Fatalf("Expected a registration on the new chain, got %d %s", rec.Code, rec.Body)
+	}
+	if got := ts.nodes["node-1"].ChainID; got != "devnet" {
+		t.Errorf("Expected the node reported under the chain name, got %q", got)
+	}
+
+	// Its genesis is served by name and by hash; /v1/sxe stays on the default
+	for path, want := range map[string]string{
+		"/v1/sxe/devnet":        `{"network_id":"devnet"}`,
+		"/v1/sxe/" + devnetHash: `{"network_id":"devnet"}`,
+		"/v1/sxe":               activeGenesis,
+	} {
+		rec := httptest.NewRecorder()
+		ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("Unexpected genesis at %s: %d %.40q", path, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestStatsAndPeersScopedByChain(t *testing.T) {
+	ts := NewTrackerService()
+	ts.chains.Put(&Chain{Name: "devnet", GenesisHash: devnetHash}, false)
+	addTestNode(ts, "testnode-1", 100, tib)
+	addTestNode(ts, "testnode-2", 100, tib)
+	addTestNode(ts, "devnode-1", 7, 3*tib)
+	ts.nodes["testnode-1"].ChainID = "testnet0"
+	ts.nodes["testnode-2"].ChainID = "testnet0"
+	ts.nodes["devnode-1"].ChainID = "devnet"
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	var stats NetworkStats
+	json.NewDecoder(get("/api/v1/stats?chain=" + devnetHash).Body).Decode(&stats)
+	if stats.Chain != "devnet" || stats.TotalNodes != 1 || stats.TotalNetspace != 3*tib || stats.HighestHeight != 7 {
+		t.Errorf("Expected devnet stats only, got %+v", stats)
+	}
+	stats = NetworkStats{}
+	json.NewDecoder(get("/api/v1/stats").Body).Decode(&stats)
+	if stats.Chain != "" || stats.TotalNodes != 3 {
+		t.Errorf("Expected unscoped stats to cover every chain, got %+v", stats)
+	}
+	if rec := get("/api/v1/stats?chain=nope"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown chain, got %d", rec.Code)
+	}
+
+	var peers struct{ Count int }
+	json.NewDecoder(get("/api/v1/peers?chain_id=" + testnet0).Body).Decode(&peers)
+	if peers.Count != 2 {
+		t.Errorf("Expected the testnet0 peers only, got %d", peers.Count)
+	}
+
+	body := get("/dashboard?chain=devnet").Body.String()
+	if !strings.Contains(body, "devnode-...") || strings.Contains(body, "testnode...") {
+		t.Error("Expected the devnet dashboard to list devnet nodes only")
+	}
+	if !strings.Contains(body, `<a href="/dashboard?chain=devnet" class="active">devnet</a>`) {
+		t.Error("Expected the dashboard to link each chain")
+	}
+
+	var listing struct{ Chains []ChainSummary }
+	json.NewDecoder(get("/api/v1/chains").Body).Decode(&listing)
+	if len(listing.Chains) != 2 || listing.Chains[0].Name != "devnet" || listing.Chains[0].Nodes != 1 ||
+		!listing.Chains[1].Default || listing.Chains[1].Nodes != 2 {
+		t.Errorf("Unexpected chain listing %+v", listing.Chains)
+	}
+}
+
+func TestChainsFilePersistsAdminChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chains.json")
+	ts := NewTrackerService()
+	registry, err := LoadChainRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadChainRegistry failed: %v", err)
+	}
+	ts.chains = registry
+
+	if rec := tagRequest(t, ts, "PUT", "/api/v1/admin/chains/devnet", `{"genesis_hash":"`+devnetHash+`","default":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("Adding a chain failed: %d %s", rec.Code, rec.Body)
+	}
+	if rec := tagRequest(t, ts, "DELETE", "/api/v1/admin/chains/testnet0", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("Removing a chain failed: %d %s", rec.Code, rec.Body)
+	}
+
+	reloaded, err := LoadChainRegistry(path)
+	if err != nil {
+		t.Fatalf("Reloading the chains file failed: %v", err)
+	}
+	chains := reloaded.List()
+	if len(chains) != 1 || chains[0].Name != "devnet" || reloaded.Default() != chains[0] {
+		t.Errorf("Expected only devnet, as the default, after a reload, got %+v", chains)
+	}
+
+	// The default chain cannot be removed, and hashes stay unique
+	if rec := tagRequest(t, ts, "DELETE", "/api/v1/admin/chains/devnet", ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 removing the default chain, got %d", rec.Code)
+	}
+	if rec := tagRequest(t, ts, "PUT", "/api/v1/admin/chains/other", `{"genesis_hash":"`+devnetHash+`"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a duplicate genesis hash, got %d", rec.Code)
+	}
+	if rec := tagRequest(t, ts, "PUT", "/api/v1/admin/chains/bad", `{"genesis_hash":"xyz"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed genesis hash, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	defaultCleanupInterval = 1 * time.Minute
+	defaultOfflineAfter    = 10 * time.Minute
+	defaultStartupGrace    = 10 * time.Minute
+)
+
+// CleanupConfig controls how offline nodes are purged. Nodes last heard
+// from before the tracker started are kept until StartupGrace has passed,
+// so state loaded at startup has time to re-heartbeat.
+type CleanupConfig struct {
+	Interval     time.Duration // How often cleanup runs
+	OfflineAfter time.Duration // Heartbeat age after which a node is removed
+	StartupGrace time.Duration // Protection window for nodes known before startup
+}
+
+func defaultCleanupConfig() CleanupConfig {
+	return CleanupConfig{
+		Interval:     defaultCleanupInterval,
+		OfflineAfter: defaultOfflineAfter,
+		StartupGrace: defaultStartupGrace,
+	}
+}
+
+// cleanupConfigFromEnv reads TRACKER_CLEANUP_INTERVAL, TRACKER_OFFLINE_AFTER
+// and TRACKER_STARTUP_GRACE as durations such as "30s" or "5m"
+func cleanupConfigFromEnv() CleanupConfig {
+	cfg := defaultCleanupConfig()
+	cfg.Interval = durationFromEnv("TRACKER_CLEANUP_INTERVAL", cfg.Interval, time.Second)
+	cfg.OfflineAfter = durationFromEnv("TRACKER_OFFLINE_AFTER", cfg.OfflineAfter, time.Second)
+	cfg.StartupGrace = durationFromEnv("TRACKER_STARTUP_GRACE", cfg.StartupGrace, 0)
+	return cfg
+}
+
+// durationFromEnv parses a duration variable, keeping fallback when it is
+// unset, malformed or below min
+func durationFromEnv(name string, fallback, min time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < min {
+		log.Printf("⚠️ Ignoring invalid %s %q, using %s", name, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// cleanupOfflineNodes removes nodes that haven't sent heartbeats
+func (ts *TrackerService) cleanupOfflineNodes() {
+	ticker := time.NewTicker(ts.cleanup.Interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		ts.mu.Lock()
+		ts.pruneOfflineNodes(now)
+		ts.mu.Unlock()
+		ts.limiter.Prune(now)
+		if removed, err := ts.store.PruneUptime(now.AddDate(0, 0, -uptimeRetentionDays)); err != nil {
+			log.Printf("⚠️ Failed to prune uptime history: %v", err)
+		} else if removed > 0 {
+			log.Printf("🧹 Pruned %d old uptime records", removed)
+		}
+		if removed, err := ts.store.PruneStatsHistory(now); err != nil {
+			log.Printf("⚠️ Failed to prune stats history: %v", err)
+		} else if removed > 0 {
+			log.Printf("🧹 Pruned %d old stats history records", removed)
+		}
+	}
+}
+
+// pruneOfflineNodes removes nodes whose last heartbeat is older than
+// OfflineAfter at now, sparing nodes known before startup while the
+// startup grace period lasts. It returns the removed node IDs.
+func (ts *TrackerService) pruneOfflineNodes(now time.Time) []string {
+	cutoff := now.Add(-ts.cleanup.OfflineAfter)
+	inGrace := now.Before(ts.startedAt.Add(ts.cleanup.StartupGrace))
+
+	var removed []string
+	for nodeID, node := range ts.nodes {
+		if !node.LastHeartbeat.Before(cutoff) {
+			continue
+		}
+		if inGrace && node.LastHeartbeat.Before(ts.startedAt) {
+			continue
+		}
+
+		log.Printf("🧹 Removing offline node %s", nodeID)
+		ts.forgetNode(node, now, EventCleanupRemoved, "last heartbeat "+node.LastHeartbeat.UTC().Format(time.RFC3339))
+		removed = append(removed, nodeID)
+	}
+	return removed
+}
+
+// forgetNode drops node from the registry and the store, recording why
+// under eventType
+func (ts *TrackerService) forgetNode(node *RegisteredNode, now time.Time, eventType, detail string) {
+	delete(ts.nodes, node.NodeID)
+	delete(ts.registry.nodes, node.NodeID)
+	if err := ts.store.DeleteNode(node.NodeID); err != nil {
+		log.Printf("⚠️ Failed to forget node %s: %v", node.NodeID, err)
+	}
+	ts.recordTransition(node.NodeID, now, node.Status, "removed")
+	ts.events.Record(Event{Time: now.UTC(), Type: eventType, NodeID: node.NodeID, Detail: detail})
+}
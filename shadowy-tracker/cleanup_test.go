@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartupGraceKeepsLoadedNodes(t *testing.T) {
+	ts := NewTrackerService()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ts.startedAt = start
+	ts.cleanup = CleanupConfig{Interval: time.Minute, OfflineAfter: 10 * time.Minute, StartupGrace: 5 * time.Minute}
+
+	// Loaded from earlier state, last heard from well before the restart
+	addTestNode(ts, "loaded", 100, tib)
+	ts.nodes["loaded"].LastHeartbeat = start.Add(-time.Hour)
+
+	if removed := ts.pruneOfflineNodes(start.Add(time.Minute)); len(removed) != 0 {
+		t.Fatalf("Nothing should be purged during the grace period, removed %v", removed)
+	}
+
+	// Re-heartbeating within the grace period keeps the node for good
+	addTestNode(ts, "returning", 100, tib)
+	ts.nodes["returning"].LastHeartbeat = start.Add(4 * time.Minute)
+
+	removed := ts.pruneOfflineNodes(start.Add(5 * time.Minute))
+	if len(removed) != 1 || removed[0] != "loaded" {
+		t.Fatalf("Expected only the stale loaded node removed after grace, got %v", removed)
+	}
+	if _, ok := ts.nodes["returning"]; !ok {
+		t.Error("Node that re-heartbeated should survive")
+	}
+}
+
+func TestCleanupRemovesStaleNodesAfterStartup(t *testing.T) {
+	ts := NewTrackerService()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ts.startedAt = start
+	ts.cleanup = CleanupConfig{Interval: time.Minute, OfflineAfter: 10 * time.Minute, StartupGrace: time.Hour}
+
+	// Registered after startup, then went quiet: the grace period does not apply
+	addTestNode(ts, "quiet", 100, tib)
+	ts.nodes["quiet"].LastHeartbeat = start.Add(time.Minute)
+	addTestNode(ts, "alive", 100, tib)
+	ts.nodes["alive"].LastHeartbeat = start.Add(15 * time.Minute)
+
+	removed := ts.pruneOfflineNodes(start.Add(20 * time.Minute))
+	if len(removed) != 1 || removed[0] != "quiet" {
+		t.Fatalf("Expected the quiet node removed, got %v", removed)
+	}
+	if _, ok := ts.nodes["alive"]; !ok {
+		t.Error("Node with a recent heartbeat should not be removed")
+	}
+}
+
+func TestCleanupConfigFromEnv(t *testing.T) {
+	t.Setenv("TRACKER_CLEANUP_INTERVAL", "30s")
+	t.Setenv("TRACKER_OFFLINE_AFTER", "bogus")
+	t.Setenv("TRACKER_STARTUP_GRACE", "0s")
+
+	cfg := cleanupConfigFromEnv()
+	if cfg.Interval != 30*time.Second {
+		t.Errorf("Expected 30s interval, got %s", cfg.Interval)
+	}
+	if cfg.OfflineAfter != defaultOfflineAfter {
+		t.Errorf("Invalid offline timeout should fall back to default, got %s", cfg.OfflineAfter)
+	}
+	if cfg.StartupGrace != 0 {
+		t.Errorf("Grace period should be disableable, got %s", cfg.StartupGrace)
+	}
+}
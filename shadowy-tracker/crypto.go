@@ -5,78 +5,155 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"strings"
-	
+	"time"
+
 	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
 	"golang.org/x/crypto/sha3"
 )
 
-// VerifyRegistrationSignature verifies that the registration is signed by the mining address
-func VerifyRegistrationSignature(req *RegistrationRequest) error {
-	// Create message to verify
-	message := fmt.Sprintf("%s|%s|%s|%d|%s|%s", 
-		req.NodeID, req.MiningAddr, req.ExternalIP, 
-		req.ChainHeight, req.Timestamp, req.SoftwareVersion)
-	
-	// Hash the message
-	hash := sha256.Sum256([]byte(message))
-	
-	// For now, we'll implement a simple verification
-	// In production, this would verify against the actual mining address signature
-	log.Printf("🔐 Verifying registration for %s", req.MiningAddr[:16]+"...")
-	
-	// Decode signature
-	signature, err := hex.DecodeString(req.Signature)
-	if err != nil {
-		return fmt.Errorf("invalid signature format: %w", err)
+// Shadowy addresses are "S" and the hex of a version byte, a 20 byte hash
+// of the public key and a 4 byte checksum, as derived by the node wallet
+const (
+	addressVersion     = 0x42
+	addressChecksumLen = 4
+)
+
+const defaultSignatureMaxSkew = 5 * time.Minute
+
+// SignaturePolicy controls how signed registrations and heartbeats are
+// checked. Timestamps must be within MaxSkew of the tracker's clock and
+// newer than the last request accepted from the node, so a captured
+// request cannot be replayed.
+type SignaturePolicy struct {
+	MaxSkew time.Duration
+
+	// AllowDevelopment also accepts the hash-based signatures of
+	// development builds, which prove nothing about the sender
+	AllowDevelopment bool
+}
+
+func defaultSignaturePolicy() SignaturePolicy {
+	return SignaturePolicy{MaxSkew: defaultSignatureMaxSkew}
+}
+
+// signaturePolicyFromEnv reads TRACKER_SIGNATURE_MAX_SKEW as a duration and
+// TRACKER_ALLOW_DEV_SIGNATURES as a boolean
+func signaturePolicyFromEnv() SignaturePolicy {
+	policy := defaultSignaturePolicy()
+	policy.MaxSkew = durationFromEnv("TRACKER_SIGNATURE_MAX_SKEW", policy.MaxSkew, time.Second)
+	if value := os.Getenv("TRACKER_ALLOW_DEV_SIGNATURES"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("⚠️ Ignoring invalid TRACKER_ALLOW_DEV_SIGNATURES %q", value)
+		}
+		policy.AllowDevelopment = allow
 	}
-	
-	// Decode public key
+	if policy.AllowDevelopment {
+		log.Println("⚠️ Development signatures are accepted; registrations are not authenticated")
+	}
+	return policy
+}
+
+// registrationMessage is what a node signs to register: every field the
+// tracker records, so none can be altered in transit
+func registrationMessage(req *RegistrationRequest) []byte {
+	return []byte(fmt.Sprintf("register|%s|%s|%s|%s|%d|%d|%d|%s|%s|%s|%s|%s|%s|%d|%d|%s",
+		req.NodeID, req.MiningAddr, req.PublicKey, req.ExternalIP, req.P2PPort, req.HTTPPort,
+		req.ChainHeight, req.ChainHash, req.ChainID, req.LastBlockTime,
+		req.SoftwareVersion, req.OSVersion, req.Architecture,
+		req.TotalPlotSize, req.PlotCount, req.Timestamp))
+}
+
+// heartbeatMessage is what a node signs to send a heartbeat
+func heartbeatMessage(req *HeartbeatRequest) []byte {
+	return []byte(fmt.Sprintf("heartbeat|%s|%d|%s|%s|%s|%d|%d|%s",
+		req.NodeID, req.ChainHeight, req.ChainHash, req.LastBlockTime, req.Status,
+		req.TotalPlotSize, req.PlotCount, req.Timestamp))
+}
+
+// VerifyRegistrationSignature checks the registration is signed with the
+// ML-DSA-87 key it carries, and that the key is the mining address's
+func VerifyRegistrationSignature(req *RegistrationRequest, policy SignaturePolicy) error {
+	if policy.AllowDevelopment && isDevelopmentSignature(req.Signature, fmt.Sprintf("%s|%s|%s|%d|%s|%s",
+		req.NodeID, req.MiningAddr, req.ExternalIP, req.ChainHeight, req.Timestamp, req.SoftwareVersion), 16) {
+		log.Printf("⚠️ Development signature accepted for %s", req.NodeID)
+		return nil
+	}
+
 	publicKey, err := hex.DecodeString(req.PublicKey)
 	if err != nil {
 		return fmt.Errorf("invalid public key format: %w", err)
 	}
-	
-	// Verify signature using ML-DSA-87
-	if len(publicKey) == mldsa87.PublicKeySize {
-		pk := new(mldsa87.PublicKey)
-		if err := pk.UnmarshalBinary(publicKey); err != nil {
-			return fmt.Errorf("invalid public key: %w", err)
-		}
-		valid := mldsa87.Verify(pk, hash[:], signature, nil)
-		if !valid {
-			return fmt.Errorf("signature verification failed")
-		}
-		log.Printf("✅ ML-DSA-87 signature verified for %s", req.MiningAddr[:16]+"...")
-		return nil
+	if address := DeriveShadowyAddress(publicKey); address != req.MiningAddr {
+		return fmt.Errorf("public key belongs to %s, not the mining address", address)
 	}
-	
-	// For development/testing, allow simple verification
-	expectedSig := fmt.Sprintf("%x", hash[:16])
-	if strings.HasPrefix(req.Signature, expectedSig) {
-		log.Printf("✅ Development signature accepted for %s", req.MiningAddr[:16]+"...")
+	return verifyMLDSA(publicKey, registrationMessage(req), req.Signature)
+}
+
+// VerifyHeartbeatSignature checks a heartbeat is signed with the key the
+// node registered
+func VerifyHeartbeatSignature(req *HeartbeatRequest, node *RegisteredNode, policy SignaturePolicy) error {
+	if policy.AllowDevelopment && isDevelopmentSignature(req.Signature, fmt.Sprintf("%s|%d|%s|%s",
+		req.NodeID, req.ChainHeight, req.ChainHash, req.Timestamp), 8) {
 		return nil
 	}
-	
-	return fmt.Errorf("signature verification failed")
+
+	publicKey, err := hex.DecodeString(node.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid registered public key: %w", err)
+	}
+	return verifyMLDSA(publicKey, heartbeatMessage(req), req.Signature)
 }
 
-// VerifyHeartbeatSignature verifies heartbeat signatures
-func VerifyHeartbeatSignature(req *HeartbeatRequest, node *RegisteredNode) error {
-	// Create message to verify
-	message := fmt.Sprintf("%s|%d|%s|%s", 
-		req.NodeID, req.ChainHeight, req.ChainHash, req.Timestamp)
-	
-	// Hash the message
+// verifyMLDSA checks a hex ML-DSA-87 signature of message
+func verifyMLDSA(publicKey, message []byte, signatureHex string) error {
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return fmt.Errorf("invalid signature format: %w", err)
+	}
+	if len(publicKey) != mldsa87.PublicKeySize {
+		return fmt.Errorf("public key must be %d bytes, got %d", mldsa87.PublicKeySize, len(publicKey))
+	}
+	if len(signature) != mldsa87.SignatureSize {
+		return fmt.Errorf("signature must be %d bytes, got %d", mldsa87.SignatureSize, len(signature))
+	}
+
+	pk := new(mldsa87.PublicKey)
+	if err := pk.UnmarshalBinary(publicKey); err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	if !mldsa87.Verify(pk, message, nil, signature) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// isDevelopmentSignature reports whether signature starts with the first
+// n bytes of the SHA-256 of message, as development builds sign
+func isDevelopmentSignature(signature, message string, n int) bool {
 	hash := sha256.Sum256([]byte(message))
-	
-	// For development, simple verification
-	expectedSig := fmt.Sprintf("%x", hash[:8])
-	if strings.HasPrefix(req.Signature, expectedSig) {
-		return nil
+	return signature != "" && strings.HasPrefix(signature, fmt.Sprintf("%x", hash[:n]))
+}
+
+// checkRequestTime rejects a signed request whose timestamp is too far
+// from now, or not after the last request accepted from the node.
+// Timestamps keep their fractional seconds, so a node may send several
+// requests within one second.
+func checkRequestTime(timestamp string, last, now time.Time, policy SignaturePolicy) (time.Time, error) {
+	signedAt, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp must be RFC3339")
+	}
+	if skew := now.Sub(signedAt); skew > policy.MaxSkew || skew < -policy.MaxSkew {
+		return time.Time{}, fmt.Errorf("timestamp is %s from the tracker's clock, more than %s", skew.Round(time.Second), policy.MaxSkew)
+	}
+	if !signedAt.After(last) {
+		return time.Time{}, fmt.Errorf("timestamp is not after the node's last request, possible replay")
 	}
-	
-	return fmt.Errorf("heartbeat signature verification failed")
+	return signedAt, nil
 }
 
 // GenerateNodeID creates a unique node identifier
@@ -86,9 +163,20 @@ func GenerateNodeID(miningAddr, ip string, port int) string {
 	return hex.EncodeToString(hash[:16])
 }
 
-// DeriveShadowyAddress derives a Shadowy address from public key
+// DeriveShadowyAddress derives the Shadowy address of a public key the way
+// the node wallet does: SHAKE-256 of the key, versioned and checksummed
+// with double Keccak-256
 func DeriveShadowyAddress(publicKey []byte) string {
-	// Shadowy address format: S4 + 50 hex chars
-	hash := sha3.Sum256(publicKey)
-	return fmt.Sprintf("S4%x", hash[:25])
-}
\ No newline at end of file
+	hash := make([]byte, 20)
+	shake := sha3.NewShake256()
+	shake.Write(publicKey)
+	shake.Read(hash)
+
+	payload := append([]byte{addressVersion}, hash...)
+	first := sha3.NewLegacyKeccak256()
+	first.Write(payload)
+	second := sha3.NewLegacyKeccak256()
+	second.Write(first.Sum(nil))
+
+	return "S" + hex.EncodeToString(append(payload, second.Sum(nil)[:addressChecksumLen]...))
+}
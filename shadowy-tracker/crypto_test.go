@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+// The test node key, derived from a fixed seed
+var testPublicKey, testPrivateKey = mldsa87.NewKeyFromSeed(&[mldsa87.SeedSize]byte{1, 2, 3})
+
+var (
+	testPublicKeyHex = func() string {
+		raw, _ := testPublicKey.MarshalBinary()
+		return hex.EncodeToString(raw)
+	}()
+	testAddress = func() string {
+		raw, _ := testPublicKey.MarshalBinary()
+		return DeriveShadowyAddress(raw)
+	}()
+)
+
+func testSign(message []byte) string {
+	signature := make([]byte, mldsa87.SignatureSize)
+	if err := mldsa87.SignTo(testPrivateKey, message, nil, false, signature); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(signature)
+}
+
+// signRegistration signs a registration payload with the test key
+func signRegistration(payload map[string]interface{}) {
+	delete(payload, "signature")
+	data, _ := json.Marshal(payload)
+	var req RegistrationRequest
+	json.Unmarshal(data, &req)
+	payload["signature"] = testSign(registrationMessage(&req))
+}
+
+// signedHeartbeat is a heartbeat from nodeID at height, signed with the
+// test key and timestamped at
+func signedHeartbeat(nodeID string, height uint64, at time.Time) string {
+	req := HeartbeatRequest{NodeID: nodeID, ChainHeight: height, ChainHash: "abc", Status: "online",
+		Timestamp: at.UTC().Format(time.RFC3339Nano)}
+	req.Signature = testSign(heartbeatMessage(&req))
+	body, _ := json.Marshal(req)
+	return string(body)
+}
+
+func postHeartbeat(ts *TrackerService, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	ts.handleHeartbeat(rec, httptest.NewRequest("POST", "/api/v1/heartbeat", strings.NewReader(body)))
+	return rec
+}
+
+func TestDeriveShadowyAddressFormat(t *testing.T) {
+	// "S", then version 0x42, 20 hash bytes and a 4 byte checksum in hex
+	if len(testAddress) != 51 || testAddress[:3] != "S42" {
+		t.Errorf("Expected an S42 address of 51 characters, got %s", testAddress)
+	}
+}
+
+func TestRegisterRejectsForgedSignatures(t *testing.T) {
+	cases := map[string]func(map[string]interface{}){
+		"tampered plot size": func(p map[string]interface{}) { p["total_plot_size_bytes"] = 1 << 40 },
+		"other mining address": func(p map[string]interface{}) {
+			p["mining_address"] = "S42618a7524a82df51c8a2406321e161de65073008806f042f0"
+			signRegistration(p)
+		},
+		"development signature": func(p map[string]interface{}) { p["signature"] = "abcdef0123456789" },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			payload := validRegistration()
+			mutate(payload)
+			if rec := postRegistration(t, NewTrackerService(), payload); rec.Code != http.StatusUnauthorized {
+				t.Errorf("Expected 401, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsReplays(t *testing.T) {
+	ts := NewTrackerService()
+	payload := validRegistration()
+	if rec := postRegistration(t, ts, payload); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := postRegistration(t, ts, payload); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a replayed registration rejected, got %d", rec.Code)
+	}
+
+	stale := validRegistration()
+	stale["node_id"] = "node-2"
+	stale["timestamp"] = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	signRegistration(stale)
+	if rec := postRegistration(t, ts, stale); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a stale registration rejected, got %d", rec.Code)
+	}
+
+	// Another key cannot take over a registered node_id
+	_, otherKey := mldsa87.NewKeyFromSeed(&[mldsa87.SeedSize]byte{9})
+	otherPublic, _ := otherKey.Public().(*mldsa87.PublicKey).MarshalBinary()
+	hijack := validRegistration()
+	hijack["public_key"] = hex.EncodeToString(otherPublic)
+	hijack["mining_address"] = DeriveShadowyAddress(otherPublic)
+	hijack["timestamp"] = time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	delete(hijack, "signature")
+	data, _ := json.Marshal(hijack)
+	var req RegistrationRequest
+	json.Unmarshal(data, &req)
+	signature := make([]byte, mldsa87.SignatureSize)
+	mldsa87.SignTo(otherKey, registrationMessage(&req), nil, false, signature)
+	hijack["signature"] = hex.EncodeToString(signature)
+	if rec := postRegistration(t, ts, hijack); rec.Code != http.StatusConflict {
+		t.Errorf("Expected a different key for node-1 rejected, got %d", rec.Code)
+	}
+}
+
+func TestHeartbeatSignatures(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-1", 10, tib)
+	ts.nodes["node-1"].PublicKey = testPublicKeyHex
+
+	now := time.Now()
+	body := signedHeartbeat("node-1", 11, now)
+	if rec := postHeartbeat(ts, body); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := postHeartbeat(ts, body); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a replayed heartbeat rejected, got %d", rec.Code)
+	}
+	tampered := strings.Replace(signedHeartbeat("node-1", 12, now.Add(time.Second)), `"chain_height":12`, `"chain_height":99`, 1)
+	if rec := postHeartbeat(ts, tampered); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a tampered heartbeat rejected, got %d", rec.Code)
+	}
+	if ts.nodes["node-1"].ChainHeight != 11 {
+		t.Errorf("Expected only the signed height applied, got %d", ts.nodes["node-1"].ChainHeight)
+	}
+}
+
+func TestHeartbeatsWithinOneSecond(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-1", 10, tib)
+	ts.nodes["node-1"].PublicKey = testPublicKeyHex
+
+	now := time.Now().Truncate(time.Second)
+	for i, at := range []time.Time{now.Add(100 * time.Millisecond), now.Add(600 * time.Millisecond)} {
+		if rec := postHeartbeat(ts, signedHeartbeat("node-1", uint64(11+i), at)); rec.Code != http.StatusOK {
+			t.Errorf("Expected heartbeat %d accepted, got %d: %s", i, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := postHeartbeat(ts, signedHeartbeat("node-1", 13, now.Add(300*time.Millisecond))); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an older heartbeat rejected, got %d", rec.Code)
+	}
+}
+
+func TestDevelopmentSignaturesAreOptIn(t *testing.T) {
+	req := &RegistrationRequest{NodeID: "node-1", MiningAddr: testAddress, ExternalIP: "203.0.113.10",
+		ChainHeight: 42, Timestamp: "2025-01-01T00:00:00Z", SoftwareVersion: "1.0.0"}
+	// What development builds send: the first 16 bytes of a SHA-256
+	hash := sha256.Sum256([]byte("node-1|" + testAddress + "|203.0.113.10|42|2025-01-01T00:00:00Z|1.0.0"))
+	req.Signature = hex.EncodeToString(hash[:16])
+
+	if err := VerifyRegistrationSignature(req, SignaturePolicy{}); err == nil {
+		t.Error("Expected a development signature rejected by default")
+	}
+	if err := VerifyRegistrationSignature(req, SignaturePolicy{AllowDevelopment: true}); err != nil {
+		t.Errorf("Expected a development signature accepted when allowed: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+)
+
+// DuplicatePolicy decides how netspace is counted when several node_ids
+// report the same mining address
+type DuplicatePolicy string
+
+const (
+	// DuplicatePolicyLargest counts only the largest node for each address
+	DuplicatePolicyLargest DuplicatePolicy = "largest"
+	// DuplicatePolicySum counts every node and flags the total as inflated
+	DuplicatePolicySum DuplicatePolicy = "sum"
+)
+
+// DuplicateMiner is a mining address registered under more than one node_id
+type DuplicateMiner struct {
+	MiningAddr      string   `json:"mining_address"`
+	NodeIDs         []string `json:"node_ids"`
+	TotalPlotSize   uint64   `json:"total_plot_size_bytes"` // Sum over all of its nodes
+	LargestNodeID   string   `json:"largest_node_id"`
+	LargestPlotSize uint64   `json:"largest_plot_size_bytes"`
+	CountedPlotSize uint64   `json:"counted_plot_size_bytes"` // What enters netspace totals under the policy
+}
+
+// duplicatePolicyFromEnv reads TRACKER_DUPLICATE_MINER_POLICY, defaulting
+// to counting only the largest node
+func duplicatePolicyFromEnv() DuplicatePolicy {
+	switch policy := DuplicatePolicy(os.Getenv("TRACKER_DUPLICATE_MINER_POLICY")); policy {
+	case "", DuplicatePolicyLargest:
+		return DuplicatePolicyLargest
+	case DuplicatePolicySum:
+		return DuplicatePolicySum
+	default:
+		log.Printf("⚠️ Unknown duplicate miner policy %q, counting the largest node", policy)
+		return DuplicatePolicyLargest
+	}
+}
+
+// findDuplicateMiners groups nodes by mining address and returns the
+// addresses claimed by more than one node_id, sorted by address
+func (ts *TrackerService) findDuplicateMiners(nodes map[string]*RegisteredNode) []DuplicateMiner {
+	byAddr := make(map[string][]*RegisteredNode)
+	for _, node := range nodes {
+		if node.MiningAddr == "" {
+			continue
+		}
+		byAddr[node.MiningAddr] = append(byAddr[node.MiningAddr], node)
+	}
+
+	duplicates := []DuplicateMiner{}
+	for addr, nodes := range byAddr {
+		if len(nodes) < 2 {
+			continue
+		}
+
+		// Largest first, ties broken by node_id so the pick is stable
+		sort.Slice(nodes, func(i, j int) bool {
+			if nodes[i].TotalPlotSize != nodes[j].TotalPlotSize {
+				return nodes[i].TotalPlotSize > nodes[j].TotalPlotSize
+			}
+			return nodes[i].NodeID < nodes[j].NodeID
+		})
+
+		dup := DuplicateMiner{
+			MiningAddr:      addr,
+			LargestNodeID:   nodes[0].NodeID,
+			LargestPlotSize: nodes[0].TotalPlotSize,
+		}
+		for _, node := range nodes {
+			dup.NodeIDs = append(dup.NodeIDs, node.NodeID)
+			dup.TotalPlotSize += node.TotalPlotSize
+		}
+		sort.Strings(dup.NodeIDs)
+
+		dup.CountedPlotSize = dup.LargestPlotSize
+		if ts.duplicatePolicy == DuplicatePolicySum {
+			dup.CountedPlotSize = dup.TotalPlotSize
+		}
+		duplicates = append(duplicates, dup)
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].MiningAddr < duplicates[j].MiningAddr
+	})
+	return duplicates
+}
+
+// collapsedNodes returns the node_ids whose plot size is left out of
+// netspace totals because another node with the same mining address is
+// counted instead. Empty under DuplicatePolicySum.
+func (ts *TrackerService) collapsedNodes(duplicates []DuplicateMiner) map[string]bool {
+	collapsed := make(map[string]bool)
+	if ts.duplicatePolicy == DuplicatePolicySum {
+		return collapsed
+	}
+	for _, dup := range duplicates {
+		for _, nodeID := range dup.NodeIDs {
+			if nodeID != dup.LargestNodeID {
+				collapsed[nodeID] = true
+			}
+		}
+	}
+	return collapsed
+}
+
+// handleDuplicateMiners lists mining addresses shared by several node_ids,
+// on one chain with ?chain=
+func (ts *TrackerService) handleDuplicateMiners(w http.ResponseWriter, r *http.Request) {
+	chain, ok := ts.requestedChain(r)
+	if !ok {
+		http.Error(w, "Unknown chain", http.StatusNotFound)
+		return
+	}
+	ts.mu.RLock()
+	duplicates := ts.findDuplicateMiners(ts.nodesOnChain(chain))
+	ts.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"policy":     ts.duplicatePolicy,
+		"duplicates": duplicates,
+		"count":      len(duplicates),
+	})
+}
+
+// duplicateNetspaceWarning describes double-counted netspace under the sum policy
+func duplicateNetspaceWarning(count int, extra uint64) string {
+	return fmt.Sprintf("%d mining addresses are registered under multiple node_ids; netspace includes %d duplicate bytes", count, extra)
+}
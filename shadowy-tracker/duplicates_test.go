@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func addTestMiner(ts *TrackerService, id, miningAddr string, height uint64, plotSize uint64) {
+	addTestNode(ts, id, height, plotSize)
+	ts.nodes[id].MiningAddr = miningAddr
+}
+
+func TestDuplicateMinersCollapsedToLargest(t *testing.T) {
+	ts := NewTrackerService()
+	addTestMiner(ts, "node-a", "S_shared", 100, 10*tib)
+	addTestMiner(ts, "node-b", "S_shared", 100, 4*tib)
+	addTestMiner(ts, "node-c", "S_solo", 100, 2*tib)
+
+	duplicates := ts.findDuplicateMiners(ts.nodes)
+	if len(duplicates) != 1 {
+		t.Fatalf("Expected one duplicate mining address, got %d", len(duplicates))
+	}
+	dup := duplicates[0]
+	if dup.MiningAddr != "S_shared" || len(dup.NodeIDs) != 2 || dup.NodeIDs[0] != "node-a" || dup.NodeIDs[1] != "node-b" {
+		t.Errorf("Unexpected duplicate group: %+v", dup)
+	}
+	if dup.LargestNodeID != "node-a" || dup.TotalPlotSize != 14*tib || dup.CountedPlotSize != 10*tib {
+		t.Errorf("Expected node-a counted at 10 TiB of 14, got %+v", dup)
+	}
+
+	stats := ts.calculateNetworkStats()
+	if stats.TotalNetspace != 12*tib {
+		t.Errorf("Expected 12 TiB netspace with the duplicate collapsed, got %d", stats.TotalNetspace)
+	}
+	if stats.NetspaceConsensusNetspace != 12*tib {
+		t.Errorf("Consensus netspace should also exclude the duplicate, got %d", stats.NetspaceConsensusNetspace)
+	}
+	if stats.DuplicateMiners != 1 || stats.DuplicateNetspace != 4*tib {
+		t.Errorf("Expected 1 duplicate miner with 4 TiB left out, got %d and %d", stats.DuplicateMiners, stats.DuplicateNetspace)
+	}
+	if stats.NetspaceWarning != "" {
+		t.Errorf("Largest policy should not warn, got %q", stats.NetspaceWarning)
+	}
+}
+
+func TestDuplicateMinersSummedWithWarning(t *testing.T) {
+	ts := NewTrackerService()
+	ts.duplicatePolicy = DuplicatePolicySum
+	addTestMiner(ts, "node-a", "S_shared", 100, 10*tib)
+	addTestMiner(ts, "node-b", "S_shared", 100, 4*tib)
+
+	stats := ts.calculateNetworkStats()
+	if stats.TotalNetspace != 14*tib {
+		t.Errorf("Sum policy should count both nodes, got %d", stats.TotalNetspace)
+	}
+	if stats.DuplicateNetspace != 4*tib || stats.NetspaceWarning == "" {
+		t.Errorf("Sum policy should warn about 4 TiB double counted, got %d and %q", stats.DuplicateNetspace, stats.NetspaceWarning)
+	}
+}
+
+func TestDuplicateMinersEndpoint(t *testing.T) {
+	ts := NewTrackerService()
+	addTestMiner(ts, "node-b", "S_shared", 100, 1*tib)
+	addTestMiner(ts, "node-a", "S_shared", 100, 1*tib)
+	addTestMiner(ts, "node-c", "", 100, 1*tib)
+	addTestMiner(ts, "node-d", "", 100, 1*tib)
+
+	rec := httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/anomalies/duplicate-miners", nil))
+
+	var body struct {
+		Policy     DuplicatePolicy  `json:"policy"`
+		Duplicates []DuplicateMiner `json:"duplicates"`
+		Count      int              `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Policy != DuplicatePolicyLargest || body.Count != 1 {
+		t.Fatalf("Expected one duplicate under the largest policy, got %+v", body)
+	}
+	// Equal sizes fall back to node_id order
+	if body.Duplicates[0].LargestNodeID != "node-a" {
+		t.Errorf("Expected node-a to win the tie, got %s", body.Duplicates[0].LargestNodeID)
+	}
+}
@@ -2,16 +2,6 @@ package main
 
 const testnet0 = "7e8b843f4620d7cd93232ccb4bbd16c9d8c7904a7dd039fbff30c0f7c455c288"
 
-func hash2chain(thisHash string) string {
-	switch thisHash {
-	case testnet0:
-		return "testnet0"
-	default:
-		return thisHash
-	}
-
-}
-
 // testnet0
 const activeGenesis = `
 {
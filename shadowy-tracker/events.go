@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event types recorded in the audit log
+const (
+	EventRegister          = "register"
+	EventRegisterRejected  = "register-rejected"
+	EventHeartbeatRejected = "heartbeat-rejected"
+	EventChainMismatch     = "chain-mismatch"
+	EventCleanupRemoved    = "cleanup-removed"
+	EventPenalty           = "penalty"
+	EventBan               = "ban"
+	EventUnban             = "unban"
+	EventThrottled         = "throttled"
+	EventAlert             = "alert"
+	EventUnreachable       = "unreachable"
+	EventReachable         = "reachable"
+	EventAdminRemoved      = "admin-removed"
+	EventAdminDenied       = "admin-denied"
+	EventCompaction        = "compaction"
+)
+
+const (
+	defaultEventLogPath     = "tracker-events.log"
+	defaultEventLogMaxBytes = 10 << 20
+	defaultEventLogKeep     = 3
+	maxEventsPerQuery       = 1000
+)
+
+// Event is one entry in the audit log
+type Event struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	NodeID string    `json:"node_id,omitempty"`
+	IP     string    `json:"ip,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// EventLog is an append-only JSON-lines file of node lifecycle events.
+// When the file passes maxBytes it is rotated to path.1, path.2, ...
+// keeping at most keep old files. A nil *EventLog records nothing.
+type EventLog struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	keep     int
+	file     *os.File
+	size     int64
+}
+
+// OpenEventLog opens or creates the log at path
+func OpenEventLog(path string, maxBytes int64, keep int) (*EventLog, error) {
+	el := &EventLog{path: path, maxBytes: maxBytes, keep: keep}
+	if err := el.open(); err != nil {
+		return nil, err
+	}
+	return el, nil
+}
+
+// eventLogFromEnv opens the log named by TRACKER_EVENTS_LOG ("off"
+// disables it), rotating at TRACKER_EVENTS_MAX_BYTES
+func eventLogFromEnv() *EventLog {
+	path := defaultEventLogPath
+	if value, ok := os.LookupEnv("TRACKER_EVENTS_LOG"); ok {
+		path = value
+	}
+	if path == "" || path == "off" {
+		return nil
+	}
+
+	maxBytes := int64(defaultEventLogMaxBytes)
+	if value := os.Getenv("TRACKER_EVENTS_MAX_BYTES"); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil && parsed > 0 {
+			maxBytes = parsed
+		} else {
+			log.Printf("⚠️ Ignoring invalid TRACKER_EVENTS_MAX_BYTES %q", value)
+		}
+	}
+
+	el, err := OpenEventLog(path, maxBytes, defaultEventLogKeep)
+	if err != nil {
+		log.Printf("⚠️ Events log disabled: %v", err)
+		return nil
+	}
+	return el
+}
+
+func (el *EventLog) open() error {
+	file, err := os.OpenFile(el.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open events log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat events log: %w", err)
+	}
+	el.file = file
+	el.size = info.Size()
+	return nil
+}
+
+// Record appends an event, stamping it with the current time if unset
+func (el *EventLog) Record(event Event) {
+	if el == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
+
+	if el.file == nil {
+		return
+	}
+	if el.size > 0 && el.size+int64(len(line)) > el.maxBytes {
+		if err := el.rotate(); err != nil {
+			log.Printf("⚠️ Failed to rotate events log: %v", err)
+		}
+	}
+
+	n, err := el.file.Write(line)
+	el.size += int64(n)
+	if err != nil {
+		log.Printf("⚠️ Failed to write event: %v", err)
+	}
+}
+
+// rotate shifts path.N-1 to path.N down to path -> path.1 and starts a new file
+func (el *EventLog) rotate() error {
+	el.file.Close()
+	el.file = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", el.path, el.keep))
+	for i := el.keep - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", el.path, i), fmt.Sprintf("%s.%d", el.path, i+1))
+	}
+	if el.keep > 0 {
+		if err := os.Rename(el.path, el.path+".1"); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(el.path)
+	}
+	return el.open()
+}
+
+// Since returns events strictly after since, oldest first, up to limit
+func (el *EventLog) Since(since time.Time, limit int) ([]Event, error) {
+	events := []Event{}
+	if el == nil {
+		return events, nil
+	}
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
+
+	// Oldest rotated file first
+	paths := []string{}
+	for i := el.keep; i >= 1; i-- {
+		paths = append(paths, fmt.Sprintf("%s.%d", el.path, i))
+	}
+	paths = append(paths, el.path)
+
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var event Event
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				continue
+			}
+			if !event.Time.After(since) {
+				continue
+			}
+			events = append(events, event)
+			if len(events) >= limit {
+				file.Close()
+				return events, nil
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// Close closes the underlying file
+func (el *EventLog) Close() error {
+	if el == nil {
+		return nil
+	}
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	if el.file == nil {
+		return nil
+	}
+	err := el.file.Close()
+	el.file = nil
+	return err
+}
+
+// handleAdminEvents returns audit events after ?since= (RFC3339 or unix
+// seconds), oldest first, up to ?limit= (default and maximum 1000)
+func (ts *TrackerService) handleAdminEvents(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := parseSince(value)
+		if err != nil {
+			http.Error(w, "since must be RFC3339 or unix seconds", http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+
+	limit := maxEventsPerQuery
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 && parsed < limit {
+			limit = parsed
+		}
+	}
+
+	events, err := ts.events.Since(since, limit)
+	if err != nil {
+		http.Error(w, "Failed to read events log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events":  events,
+		"count":   len(events),
+		"enabled": ts.events != nil,
+	})
+}
+
+func parseSince(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestEventLog(t *testing.T, maxBytes int64) *EventLog {
+	t.Helper()
+	el, err := OpenEventLog(filepath.Join(t.TempDir(), "events.log"), maxBytes, 2)
+	if err != nil {
+		t.Fatalf("Failed to open events log: %v", err)
+	}
+	t.Cleanup(func() { el.Close() })
+	return el
+}
+
+func eventTypes(events []Event) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestLifecycleEventsAreRecorded(t *testing.T) {
+	ts := NewTrackerService()
+	ts.events = newTestEventLog(t, defaultEventLogMaxBytes)
+
+	if rec := postRegistration(t, ts, validRegistration()); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	mismatch := validRegistration()
+	mismatch["chain_id"] = "unknown-chain"
+	signRegistration(mismatch)
+	if rec := postRegistration(t, ts, mismatch); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for unknown chain, got %d", rec.Code)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"node_id": "ghost"})
+	rec := httptest.NewRecorder()
+	ts.handleHeartbeat(rec, httptest.NewRequest("POST", "/api/v1/heartbeat", bytes.NewReader(body)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 for unregistered heartbeat, got %d", rec.Code)
+	}
+
+	ts.cleanup.StartupGrace = 0
+	ts.nodes["node-1"].LastHeartbeat = time.Now().Add(-time.Hour)
+	ts.pruneOfflineNodes(time.Now())
+
+	events, err := ts.events.Since(time.Time{}, maxEventsPerQuery)
+	if err != nil {
+		t.Fatalf("Since failed: %v", err)
+	}
+	want := []string{EventRegister, EventChainMismatch, EventHeartbeatRejected, EventCleanupRemoved}
+	got := eventTypes(events)
+	if len(got) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected events %v, got %v", want, got)
+		}
+	}
+	if events[0].NodeID != "node-1" || events[1].NodeID != "node-1" || events[2].NodeID != "ghost" {
+		t.Errorf("Events should carry node ids, got %+v", events)
+	}
+}
+
+func TestEventsQueryableBySince(t *testing.T) {
+	ts := NewTrackerService()
+	ts.events = newTestEventLog(t, defaultEventLogMaxBytes)
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, nodeID := range []string{"a", "b", "c"} {
+		ts.events.Record(Event{Time: base.Add(time.Duration(i) * time.Minute), Type: EventRegister, NodeID: nodeID})
+	}
+
+	rec := tagRequest(t, ts, "GET", "/api/v1/admin/events?since="+base.Format(time.RFC3339), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var resp struct {
+		Events []Event `json:"events"`
+		Count  int     `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Count != 2 || resp.Events[0].NodeID != "b" || resp.Events[1].NodeID != "c" {
+		t.Errorf("Expected events after since (b, c), got %+v", resp.Events)
+	}
+
+	rec = tagRequest(t, ts, "GET", "/api/v1/admin/events?since=soon", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad since, got %d", rec.Code)
+	}
+}
+
+func TestEventLogRotatesAndCaps(t *testing.T) {
+	el := newTestEventLog(t, 200)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		el.Record(Event{Time: base.Add(time.Duration(i) * time.Second), Type: EventRegister, NodeID: "node"})
+	}
+
+	for _, suffix := range []string{"", ".1", ".2"} {
+		info, err := os.Stat(el.path + suffix)
+		if err != nil {
+			t.Fatalf("Expected %s to exist: %v", el.path+suffix, err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("%s grew past the cap: %d bytes", el.path+suffix, info.Size())
+		}
+	}
+	if _, err := os.Stat(el.path + ".3"); !os.IsNotExist(err) {
+		t.Error("Only two rotated files should be kept")
+	}
+
+	// The newest events survive rotation, in order
+	events, err := el.Since(time.Time{}, maxEventsPerQuery)
+	if err != nil {
+		t.Fatalf("Since failed: %v", err)
+	}
+	if len(events) == 0 || len(events) >= 50 {
+		t.Fatalf("Expected a capped subset of events, got %d", len(events))
+	}
+	if last := events[len(events)-1]; !last.Time.Equal(base.Add(49 * time.Second)) {
+		t.Errorf("Newest event should be last, got %v", last.Time)
+	}
+	for i := 1; i < len(events); i++ {
+		if !events[i].Time.After(events[i-1].Time) {
+			t.Fatalf("Events out of order at %d", i)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+const (
+	defaultFederationInterval = time.Minute
+	defaultFederationKeyFile  = "federation.key"
+	federationTimeout         = 30 * time.Second
+	maxFederationBytes        = 64 << 20 // A registry of tens of thousands of nodes
+)
+
+// FederationPeer is another tracker this one exchanges registries with,
+// identified by the ML-DSA-87 key it signs them with
+type FederationPeer struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`        // Base URL of its public listener
+	PublicKey string `json:"public_key"` // Hex, as shown by its /api/v1/federation
+}
+
+// FederationConfig is the layout of TRACKER_FEDERATION_FILE
+type FederationConfig struct {
+	TrackerID string           `json:"tracker_id"`
+	KeyFile   string           `json:"key_file,omitempty"` // Created on first start
+	Interval  configDuration   `json:"interval,omitempty"`
+	Peers     []FederationPeer `json:"peers"`
+}
+
+// FederationSnapshot is one tracker's registry as sent to another
+type FederationSnapshot struct {
+	Tracker   string            `json:"tracker"`
+	Timestamp string            `json:"timestamp"`
+	Nodes     []*RegisteredNode `json:"nodes"`
+}
+
+// FederationEnvelope carries a snapshot and its signature over the exact
+// payload bytes, so the receiver need not re-encode to verify it
+type FederationEnvelope struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"`
+}
+
+// FederationPeerStatus is how exchanges with one peer have gone
+type FederationPeerStatus struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	LastSync  time.Time `json:"last_sync,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	Received  int       `json:"nodes_received"` // Records adopted in the last exchange
+
+	lastSignedAt time.Time // Newest snapshot accepted, against replays
+}
+
+// Federation signs this tracker's snapshots and checks its peers'
+type Federation struct {
+	cfg       FederationConfig
+	key       *mldsa87.PrivateKey
+	publicKey string
+	peerKeys  map[string][]byte
+	client    *http.Client
+
+	mu     sync.Mutex
+	status map[string]*FederationPeerStatus
+}
+
+// LoadFederationConfig reads and checks the federation file at path
+func LoadFederationConfig(path string) (FederationConfig, error) {
+	var cfg FederationConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read federation file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse federation file: %w", err)
+	}
+	if cfg.TrackerID == "" {
+		return cfg, fmt.Errorf("tracker_id is required")
+	}
+	if cfg.KeyFile == "" {
+		cfg.KeyFile = defaultFederationKeyFile
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = configDuration(defaultFederationInterval)
+	}
+
+	ids := map[string]bool{cfg.TrackerID: true}
+	for i := range cfg.Peers {
+		peer := &cfg.Peers[i]
+		if peer.ID == "" || ids[peer.ID] {
+			return cfg, fmt.Errorf("peer %d needs a unique id other than this tracker's", i)
+		}
+		ids[peer.ID] = true
+		if u, err := url.Parse(peer.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return cfg, fmt.Errorf("peer %s: url must be http or https", peer.ID)
+		}
+		peer.URL = strings.TrimSuffix(peer.URL, "/")
+		if key, err := hex.DecodeString(peer.PublicKey); err != nil || len(key) != mldsa87.PublicKeySize {
+			return cfg, fmt.Errorf("peer %s: public_key must be a %d byte hex ML-DSA-87 key", peer.ID, mldsa87.PublicKeySize)
+		}
+	}
+	return cfg, nil
+}
+
+// loadFederationKey reads the hex key seed at path, generating and saving
+// a new one if the file does not exist
+func loadFederationKey(path string) (*mldsa87.PrivateKey, error) {
+	var seed [mldsa87.SeedSize]byte
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		if _, err := rand.Read(seed[:]); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(seed[:])+"\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to save federation key: %w", err)
+		}
+		log.Printf("🔑 Generated federation key %s", path)
+	case err != nil:
+		return nil, fmt.Errorf("failed to read federation key: %w", err)
+	default:
+		decoded, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(decoded) != mldsa87.SeedSize {
+			return nil, fmt.Errorf("federation key must be a %d byte hex seed", mldsa87.SeedSize)
+		}
+		copy(seed[:], decoded)
+	}
+	_, key := mldsa87.NewKeyFromSeed(&seed)
+	return key, nil
+}
+
+func NewFederation(cfg FederationConfig, key *mldsa87.PrivateKey) *Federation {
+	f := &Federation{
+		cfg:       cfg,
+		key:       key,
+		publicKey: hex.EncodeToString(key.Public().(*mldsa87.PublicKey).Bytes()),
+		peerKeys:  make(map[string][]byte),
+		client:    &http.Client{Timeout: federationTimeout},
+		status:    make(map[string]*FederationPeerStatus),
+	}
+	for _, peer := range cfg.Peers {
+		f.peerKeys[peer.ID], _ = hex.DecodeString(peer.PublicKey)
+		f.status[peer.ID] = &FederationPeerStatus{ID: peer.ID, URL: peer.URL}
+	}
+	return f
+}
+
+// federationFromEnv reads TRACKER_FEDERATION_FILE. Without it the tracker
+// runs on its own.
+func federationFromEnv() *Federation {
+	path := os.Getenv("TRACKER_FEDERATION_FILE")
+	if path == "" {
+		return nil
+	}
+	cfg, err := LoadFederationConfig(path)
+	if err != nil {
+		log.Printf("⚠️ Federation disabled: %v", err)
+		return nil
+	}
+	key, err := loadFederationKey(cfg.KeyFile)
+	if err != nil {
+		log.Printf("⚠️ Federation disabled: %v", err)
+		return nil
+	}
+	f := NewFederation(cfg, key)
+	log.Printf("🤝 Federating as %s with %d trackers, public key %s...", cfg.TrackerID, len(cfg.Peers), f.publicKey[:16])
+	return f
+}
+
+// federationMessage is what a tracker signs: the snapshot bytes, domain
+// separated from node requests
+func federationMessage(payload []byte) []byte {
+	return append([]byte("federation|"), payload...)
+}
+
+// seal signs a snapshot
+func (f *Federation) seal(snapshot FederationSnapshot) (FederationEnvelope, error) {
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return FederationEnvelope{}, err
+	}
+	signature := make([]byte, mldsa87.SignatureSize)
+	if err := mldsa87.SignTo(f.key, federationMessage(payload), nil, false, signature); err != nil {
+		return FederationEnvelope{}, err
+	}
+	return FederationEnvelope{Payload: payload, Signature: hex.EncodeToString(signature)}, nil
+}
+
+// open checks an envelope comes from a configured peer, is signed with its
+// key and is newer than the last accepted from it
+func (f *Federation) open(envelope FederationEnvelope, now time.Time, policy SignaturePolicy) (FederationSnapshot, error) {
+	var snapshot FederationSnapshot
+	if err := json.Unmarshal(envelope.Payload, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	publicKey, ok := f.peerKeys[snapshot.Tracker]
+	if !ok {
+		return snapshot, fmt.Errorf("unknown tracker %q", snapshot.Tracker)
+	}
+	if err := verifyMLDSA(publicKey, federationMessage(envelope.Payload), envelope.Signature); err != nil {
+		return snapshot, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := f.status[snapshot.Tracker]
+	signedAt, err := checkRequestTime(snapshot.Timestamp, status.lastSignedAt, now, policy)
+	if err != nil {
+		return snapshot, err
+	}
+	status.lastSignedAt = signedAt
+	return snapshot, nil
+}
+
+// recordSync notes the outcome of an exchange with a peer
+func (f *Federation) recordSync(peerID string, received int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := f.status[peerID]
+	if err != nil {
+		status.LastError = err.Error()
+		return
+	}
+	status.LastSync, status.LastError, status.Received = time.Now(), "", received
+}
+
+// federationSnapshot is this tracker's registry for peer, leaving out the
+// records it learned from that peer
+func (ts *TrackerService) federationSnapshot(peer string, now time.Time) FederationSnapshot {
+	snapshot := FederationSnapshot{
+		Tracker:   ts.federation.cfg.TrackerID,
+		Timestamp: now.UTC().Format(time.RFC3339Nano),
+		Nodes:     []*RegisteredNode{},
+	}
+	for _, node := range ts.nodes {
+		if node.Via != peer {
+			snapshot.Nodes = append(snapshot.Nodes, node)
+		}
+	}
+	sort.Slice(snapshot.Nodes, func(i, j int) bool { return snapshot.Nodes[i].NodeID < snapshot.Nodes[j].NodeID })
+	return snapshot
+}
+
+// sealSnapshot signs this tracker's snapshot for peer, encoding the nodes
+// before any request can change them
+func (ts *TrackerService) sealSnapshot(peer string) (FederationEnvelope, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.federation.seal(ts.federationSnapshot(peer, time.Now()))
+}
+
+// mergeFederated adopts the records in a peer's snapshot that are newer
+// than this tracker's, deduplicating by node ID. Operator tags and the
+// node's score stay this tracker's own. A heartbeat later than the
+// snapshot was signed, or than now, is taken as then, so a peer's clock
+// cannot keep a record fresh past its expiry. It returns how many were
+// adopted.
+func (ts *TrackerService) mergeFederated(snapshot FederationSnapshot) int {
+	latest := time.Now()
+	if signedAt, err := time.Parse(time.RFC3339Nano, snapshot.Timestamp); err == nil && signedAt.Before(latest) {
+		latest = signedAt
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	merged := 0
+	for _, remote := range snapshot.Nodes {
+		if remote == nil || remote.NodeID == "" {
+			continue
+		}
+		chain := ts.chains.Resolve(remote.ChainID)
+		if chain == nil {
+			continue // A chain this tracker does not serve
+		}
+		heartbeat := remote.LastHeartbeat
+		if heartbeat.After(latest) {
+			heartbeat = latest
+		}
+		existing, known := ts.nodes[remote.NodeID]
+		if known && !heartbeat.After(existing.LastHeartbeat) {
+			continue
+		}
+		if ts.bans.Match(remote) != nil {
+			continue
+		}
+
+		node := *remote
+		node.ChainID = chain.Name
+		node.LastHeartbeat = heartbeat
+		node.Via = snapshot.Tracker
+		node.Tags, node.Score, node.Penalties = nil, maxNodeScore, nil
+		if known {
+			node.Tags, node.Score, node.Penalties = existing.Tags, existing.Score, existing.Penalties
+			if existing.LastSignedAt.After(node.LastSignedAt) {
+				node.LastSignedAt = existing.LastSignedAt
+			}
+		}
+		node.Geo, node.Reachability = nil, nil // This tracker's own observations
+		if known {
+			node.keepReachability(existing)
+		}
+		ts.locateNode(&node)
+		ts.applyVersionPolicy(&node)
+
+		ts.nodes[node.NodeID] = &node
+		ts.registry.nodes[node.NodeID] = &node
+		ts.saveNode(&node)
+		merged++
+	}
+	if merged > 0 {
+		ts.peerChanges.Notify()
+	}
+	return merged
+}
+
+// runFederation exchanges registries with every peer on the configured
+// interval
+func (ts *TrackerService) runFederation() {
+	ticker := time.NewTicker(time.Duration(ts.federation.cfg.Interval))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, peer := range ts.federation.cfg.Peers {
+			if err := ts.syncFederationPeer(peer); err != nil {
+				log.Printf("⚠️ Federation sync with %s failed: %v", peer.ID, err)
+			}
+		}
+	}
+}
+
+// syncFederationPeer sends this tracker's snapshot to peer and merges the
+// one it answers with
+func (ts *TrackerService) syncFederationPeer(peer FederationPeer) error {
+	f := ts.federation
+	received, err := func() (int, error) {
+		envelope, err := ts.sealSnapshot(peer.ID)
+		if err != nil {
+			return 0, err
+		}
+		body, _ := json.Marshal(envelope)
+		resp, err := f.client.Post(peer.URL+"/api/v1/federation/sync", "application/json", bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return 0, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(message)))
+		}
+
+		var reply FederationEnvelope
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxFederationBytes)).Decode(&reply); err != nil {
+			return 0, fmt.Errorf("invalid reply: %w", err)
+		}
+		snapshot, err := f.open(reply, time.Now(), ts.signatures)
+		if err != nil {
+			return 0, err
+		}
+		if snapshot.Tracker != peer.ID {
+			return 0, fmt.Errorf("reply signed by %s", snapshot.Tracker)
+		}
+		return ts.mergeFederated(snapshot), nil
+	}()
+
+	f.recordSync(peer.ID, received, err)
+	if err == nil && received > 0 {
+		log.Printf("🤝 Adopted %d node records from %s", received, peer.ID)
+	}
+	return err
+}
+
+// handleFederationSync merges a peer's signed snapshot and answers with
+// this tracker's own
+func (ts *TrackerService) handleFederationSync(w http.ResponseWriter, r *http.Request) {
+	if ts.federation == nil {
+		http.Error(w, "Federation is not enabled", http.StatusNotFound)
+		return
+	}
+	var envelope FederationEnvelope
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFederationBytes)).Decode(&envelope); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	snapshot, err := ts.federation.open(envelope, time.Now(), ts.signatures)
+	if err != nil {
+		log.Printf("⚠️ Rejected federation sync from %s: %v", ts.clientIP(r), err)
+		http.Error(w, fmt.Sprintf("Rejected: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	received := ts.mergeFederated(snapshot)
+	ts.federation.recordSync(snapshot.Tracker, received, nil)
+	if received > 0 {
+		log.Printf("🤝 Adopted %d node records from %s", received, snapshot.Tracker)
+	}
+
+	reply, err := ts.sealSnapshot(snapshot.Tracker)
+	if err != nil {
+		http.Error(w, "Failed to sign snapshot", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reply)
+}
+
+// handleGetFederation returns this tracker's federation identity, for
+// operators of other trackers to add it as a peer
+func (ts *TrackerService) handleGetFederation(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{"enabled": ts.federation != nil}
+	if ts.federation != nil {
+		response["tracker_id"] = ts.federation.cfg.TrackerID
+		response["public_key"] = ts.federation.publicKey
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleAdminFederation lists the peers and how exchanges with them went
+func (ts *TrackerService) handleAdminFederation(w http.ResponseWriter, r *http.Request) {
+	peers := []FederationPeerStatus{}
+	if ts.federation != nil {
+		ts.federation.mu.Lock()
+		for _, peer := range ts.federation.cfg.Peers {
+			peers = append(peers, *ts.federation.status[peer.ID])
+		}
+		ts.federation.mu.Unlock()
+	}
+	learned := 0
+	ts.mu.RLock()
+	for _, node := range ts.nodes {
+		if node.Via != "" {
+			learned++
+		}
+	}
+	ts.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":       ts.federation != nil,
+		"peers":         peers,
+		"count":         len(peers),
+		"learned_nodes": learned,
+	})
+}
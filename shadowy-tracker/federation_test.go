@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+// federatedTracker is a tracker with its own key, serving its public
+// routes on a test server
+type federatedTracker struct {
+	ts     *TrackerService
+	key    *mldsa87.PrivateKey
+	server *httptest.Server
+}
+
+func newFederatedTracker(t *testing.T, id string) *federatedTracker {
+	key, err := loadFederationKey(filepath.Join(t.TempDir(), id+".key"))
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	ft := &federatedTracker{ts: NewTrackerService(), key: key}
+	ft.ts.federation = NewFederation(FederationConfig{TrackerID: id}, key)
+	ft.server = httptest.NewServer(ft.ts.publicRouter())
+	t.Cleanup(ft.server.Close)
+	return ft
+}
+
+func (ft *federatedTracker) asPeer() FederationPeer {
+	return FederationPeer{ID: ft.ts.federation.cfg.TrackerID, URL: ft.server.URL, PublicKey: ft.ts.federation.publicKey}
+}
+
+// peerWith configures trackers a and b as each other's peers
+func peerWith(a, b *federatedTracker) {
+	for _, pair := range [][2]*federatedTracker{{a, b}, {b, a}} {
+		cfg := pair[0].ts.federation.cfg
+		cfg.Peers = append(cfg.Peers, pair[1].asPeer())
+		pair[0].ts.federation = NewFederation(cfg, pair[0].key)
+	}
+}
+
+func TestFederationExchangesRegistries(t *testing.T) {
+	eu, us := newFederatedTracker(t, "tracker-eu"), newFederatedTracker(t, "tracker-us")
+	peerWith(eu, us)
+
+	now := time.Now()
+	addTestNode(eu.ts, "node-eu1", 100, tib)
+	addTestNode(us.ts, "node-us1", 100, tib)
+	// Both know node-both; the US tracker heard from it last
+	addTestNode(eu.ts, "node-both", 100, tib)
+	addTestNode(us.ts, "node-both", 104, tib)
+	eu.ts.nodes["node-both"].LastHeartbeat = now.Add(-time.Minute)
+	eu.ts.nodes["node-both"].Tags = []string{"eu-dc"}
+	for _, ts := range []*TrackerService{eu.ts, us.ts} {
+		for _, node := range ts.nodes {
+			node.ChainID = testnet0
+		}
+	}
+
+	if err := eu.ts.syncFederationPeer(us.asPeer()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	for _, ts := range []*TrackerService{eu.ts, us.ts} {
+		if len(ts.nodes) != 3 {
+			t.Errorf("Expected both trackers to know all 3 nodes, got %d", len(ts.nodes))
+		}
+	}
+	if node := eu.ts.nodes["node-us1"]; node.Via != "tracker-us" || node.ChainID != "testnet0" || node.Score != maxNodeScore {
+		t.Errorf("Unexpected learned record %+v", node)
+	}
+	if node := eu.ts.nodes["node-both"]; node.ChainHeight != 104 || node.Via != "tracker-us" || len(node.Tags) != 1 {
+		t.Errorf("Expected the newer record with local tags kept, got %+v", node)
+	}
+	if node := us.ts.nodes["node-both"]; node.Via != "" {
+		t.Errorf("Expected the US tracker to keep its own newer record, got via %q", node.Via)
+	}
+
+	// The EU tracker does not echo back what it learned from the US one
+	snapshot := eu.ts.federationSnapshot("tracker-us", time.Now())
+	if len(snapshot.Nodes) != 1 || snapshot.Nodes[0].NodeID != "node-eu1" {
+		t.Errorf("Expected only the EU tracker's own node, got %d nodes", len(snapshot.Nodes))
+	}
+
+	rec := tagRequest(t, eu.ts, "GET", "/api/v1/admin/federation", "")
+	var status struct {
+		Peers        []FederationPeerStatus
+		LearnedNodes int `json:"learned_nodes"`
+	}
+	json.NewDecoder(rec.Body).Decode(&status)
+	if len(status.Peers) != 1 || status.Peers[0].LastSync.IsZero() || status.Peers[0].Received != 2 || status.LearnedNodes != 2 {
+		t.Errorf("Unexpected federation status %+v", status)
+	}
+}
+
+func TestFederationMergesAlongsideRequests(t *testing.T) {
+	eu, us := newFederatedTracker(t, "tracker-eu"), newFederatedTracker(t, "tracker-us")
+	peerWith(eu, us)
+	addTestNode(eu.ts, "node-eu1", 100, tib)
+	addTestNode(us.ts, "node-us1", 100, tib)
+	for _, ts := range []*TrackerService{eu.ts, us.ts} {
+		for _, node := range ts.nodes {
+			node.ChainID = testnet0
+		}
+	}
+
+	// Run with -race: syncs merge into both registries while the EU
+	// tracker serves registrations, tag edits and peer queries
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 20; i++ {
+			if err := us.ts.syncFederationPeer(eu.asPeer()); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	if rec := postRegistration(t, eu.ts, validRegistration()); rec.Code != http.StatusOK {
+		t.Errorf("Registration failed: %d %s", rec.Code, rec.Body)
+	}
+	for i := 0; i < 20; i++ {
+		queryPeers(t, eu.ts, "")
+		tagRequest(t, eu.ts, "PUT", "/api/v1/admin/node/node-eu1/tags", `{"tags": ["region:eu"]}`)
+		rec := httptest.NewRecorder()
+		eu.ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/stats", nil))
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if eu.ts.nodes["node-us1"] == nil || us.ts.nodes["node-eu1"] == nil {
+		t.Error("Expected both trackers to learn each other's nodes")
+	}
+}
+
+func TestFederationCapsFutureHeartbeats(t *testing.T) {
+	eu := newFederatedTracker(t, "tracker-eu")
+	addTestNode(eu.ts, "node-both", 100, tib)
+	eu.ts.nodes["node-both"].ChainID = testnet0
+	eu.ts.nodes["node-both"].LastHeartbeat = time.Now().Add(-2 * time.Minute)
+
+	signedAt := time.Now().Add(-time.Minute)
+	future := time.Now().Add(24 * time.Hour)
+	snapshot := FederationSnapshot{
+		Tracker:   "tracker-us",
+		Timestamp: signedAt.UTC().Format(time.RFC3339Nano),
+		Nodes: []*RegisteredNode{
+			{NodeID: "node-new", ChainID: testnet0, LastHeartbeat: future},
+			{NodeID: "node-both", ChainID: testnet0, ChainHeight: 104, LastHeartbeat: future},
+		},
+	}
+	if merged := eu.ts.mergeFederated(snapshot); merged != 2 {
+		t.Fatalf("Expected 2 records adopted, got %d", merged)
+	}
+	for _, id := range []string{"node-new", "node-both"} {
+		if heartbeat := eu.ts.nodes[id].LastHeartbeat; !heartbeat.Equal(signedAt) {
+			t.Errorf("Expected %s's heartbeat capped at the snapshot time %v, got %v", id, signedAt, heartbeat)
+		}
+	}
+
+	// A snapshot stamped in the future is capped at now instead
+	snapshot.Timestamp = future.UTC().Format(time.RFC3339Nano)
+	snapshot.Nodes = []*RegisteredNode{{NodeID: "node-later", ChainID: testnet0, LastHeartbeat: future}}
+	eu.ts.mergeFederated(snapshot)
+	if heartbeat := eu.ts.nodes["node-later"].LastHeartbeat; heartbeat.After(time.Now()) {
+		t.Errorf("Expected the heartbeat capped at now, got %v", heartbeat)
+	}
+}
+
+func TestFederationRejectsUntrustedSnapshots(t *testing.T) {
+	eu, us := newFederatedTracker(t, "tracker-eu"), newFederatedTracker(t, "tracker-us")
+	peerWith(eu, us)
+	rogue := newFederatedTracker(t, "tracker-us") // Claims the US tracker's ID
+	addTestNode(us.ts, "node-us1", 100, tib)
+	us.ts.nodes["node-us1"].ChainID = testnet0
+
+	post := func(envelope FederationEnvelope) int {
+		body, _ := json.Marshal(envelope)
+		resp, err := http.Post(eu.server.URL+"/api/v1/federation/sync", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	forged, _ := rogue.ts.federation.seal(rogue.ts.federationSnapshot("tracker-eu", time.Now()))
+	if code := post(forged); code != http.StatusUnauthorized {
+		t.Errorf("Expected a snapshot signed with the wrong key to be rejected, got %d", code)
+	}
+
+	genuine, _ := us.ts.federation.seal(us.ts.federationSnapshot("tracker-eu", time.Now()))
+	if code := post(genuine); code != http.StatusOK || eu.ts.nodes["node-us1"] == nil {
+		t.Fatalf("Expected the genuine snapshot to be merged, got %d", code)
+	}
+	if code := post(genuine); code != http.StatusUnauthorized {
+		t.Errorf("Expected a replayed snapshot to be rejected, got %d", code)
+	}
+
+	tampered, _ := us.ts.federation.seal(us.ts.federationSnapshot("tracker-eu", time.Now().Add(time.Second)))
+	tampered.Payload = bytes.Replace(tampered.Payload, []byte("node-us1"), []byte("node-us2"), 1)
+	if code := post(tampered); code != http.StatusUnauthorized {
+		t.Errorf("Expected a tampered snapshot to be rejected, got %d", code)
+	}
+}
+
+func TestLoadFederationConfig(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := loadFederationKey(filepath.Join(dir, "peer.key"))
+	peerKey := hex.EncodeToString(key.Public().(*mldsa87.PublicKey).Bytes())
+
+	write := func(config string) string {
+		path := filepath.Join(dir, "federation.json")
+		os.WriteFile(path, []byte(config), 0o644)
+		return path
+	}
+
+	cfg, err := LoadFederationConfig(write(`{"tracker_id": "tracker-eu", "peers": [
+		{"id": "tracker-us", "url": "https://us.example.com/", "public_key": "` + peerKey + `"}]}`))
+	if err != nil {
+		t.Fatalf("LoadFederationConfig failed: %v", err)
+	}
+	if cfg.KeyFile != defaultFederationKeyFile || time.Duration(cfg.Interval) != defaultFederationInterval || cfg.Peers[0].URL != "https://us.example.com" {
+		t.Errorf("Unexpected defaults %+v", cfg)
+	}
+
+	for _, config := range []string{
+		`{"peers": []}`,
+		`{"tracker_id": "a", "peers": [{"id": "a", "url": "https://x.example.com", "public_key": "` + peerKey + `"}]}`,
+		`{"tracker_id": "a", "peers": [{"id": "b", "url": "ftp://x.example.com", "public_key": "` + peerKey + `"}]}`,
+		`{"tracker_id": "a", "peers": [{"id": "b", "url": "https://x.example.com", "public_key": "abcd"}]}`,
+	} {
+		if _, err := LoadFederationConfig(write(config)); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+
+	// The key survives a restart
+	again, err := loadFederationKey(filepath.Join(dir, "peer.key"))
+	if err != nil || !again.Equal(key) {
+		t.Errorf("Expected the saved key to be reloaded, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+)
+
+// maxRecentBlocks is how many of its past tips a node's record keeps for
+// telling whether nodes at different heights share a lineage
+const maxRecentBlocks = 64
+
+// BlockRef is a block a node reported as its chain tip
+type BlockRef struct {
+	Height uint64 `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// recordTip remembers the node's current tip among its recent blocks
+func (node *RegisteredNode) recordTip() {
+	if node.ChainHash == "" {
+		return
+	}
+	if n := len(node.RecentBlocks); n > 0 && node.RecentBlocks[n-1] == (BlockRef{node.ChainHeight, node.ChainHash}) {
+		return
+	}
+	node.RecentBlocks = append(node.RecentBlocks, BlockRef{node.ChainHeight, node.ChainHash})
+	if len(node.RecentBlocks) > maxRecentBlocks {
+		node.RecentBlocks = append([]BlockRef(nil), node.RecentBlocks[len(node.RecentBlocks)-maxRecentBlocks:]...)
+	}
+}
+
+// ForkBranch is a group of nodes whose tips lie on one lineage. Tips are
+// on the same lineage unless some member of one branch has reported a
+// different block at a height the other has a block for.
+type ForkBranch struct {
+	TipHeight uint64     `json:"tip_height"`
+	TipHash   string     `json:"tip_hash"`
+	Tips      []BlockRef `json:"tips"` // Every tip reported by a member, highest first
+	Nodes     int        `json:"nodes"`
+	Netspace  uint64     `json:"netspace_bytes"`
+	NodeIDs   []string   `json:"node_ids"`
+	Main      bool       `json:"main"` // Backed by the most netspace
+
+	// Lowest height at which the branch is known to disagree with the
+	// main branch, 0 when the tips alone set them apart
+	DivergesAt uint64 `json:"diverges_at,omitempty"`
+
+	blocks map[uint64]string
+}
+
+// tipGroup is the online nodes sharing one tip and the blocks they have
+// reported over time
+type tipGroup struct {
+	tip      BlockRef
+	nodes    []*RegisteredNode
+	netspace uint64
+	blocks   map[uint64]string
+}
+
+// detectForks clusters online nodes that report a block hash into
+// branches, the main branch first and the rest by netspace
+func detectForks(nodes map[string]*RegisteredNode) []ForkBranch {
+	groups := make(map[BlockRef]*tipGroup)
+	for _, node := range nodes {
+		if node.ChainHash == "" || !isOnline(node) {
+			continue
+		}
+		tip := BlockRef{node.ChainHeight, node.ChainHash}
+		group, ok := groups[tip]
+		if !ok {
+			group = &tipGroup{tip: tip, blocks: map[uint64]string{tip.Height: tip.Hash}}
+			groups[tip] = group
+		}
+		group.nodes = append(group.nodes, node)
+		group.netspace += node.TotalPlotSize
+	}
+	for _, group := range groups {
+		sort.Slice(group.nodes, func(i, j int) bool { return group.nodes[i].NodeID < group.nodes[j].NodeID })
+		for _, node := range group.nodes {
+			for _, block := range node.RecentBlocks {
+				if _, known := group.blocks[block.Height]; !known {
+					group.blocks[block.Height] = block.Hash
+				}
+			}
+		}
+	}
+
+	// Highest tips first, so lower tips are placed against the ancestry
+	// reported by the nodes ahead of them
+	sorted := make([]*tipGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.tip.Height != b.tip.Height {
+			return a.tip.Height > b.tip.Height
+		}
+		if a.netspace != b.netspace {
+			return a.netspace > b.netspace
+		}
+		return a.tip.Hash < b.tip.Hash
+	})
+
+	var branches []*ForkBranch
+	for _, group := range sorted {
+		branch := placeTip(branches, group)
+		if branch == nil {
+			branch = &ForkBranch{TipHeight: group.tip.Height, TipHash: group.tip.Hash, blocks: make(map[uint64]string)}
+			branches = append(branches, branch)
+		}
+		branch.Tips = append(branch.Tips, group.tip)
+		branch.Nodes += len(group.nodes)
+		branch.Netspace += group.netspace
+		for _, node := range group.nodes {
+			branch.NodeIDs = append(branch.NodeIDs, node.NodeID)
+		}
+		for height, hash := range group.blocks {
+			if _, known := branch.blocks[height]; !known {
+				branch.blocks[height] = hash
+			}
+		}
+	}
+
+	result := make([]ForkBranch, 0, len(branches))
+	for _, branch := range branches {
+		sort.Strings(branch.NodeIDs)
+		result = append(result, *branch)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Netspace != result[j].Netspace {
+			return result[i].Netspace > result[j].Netspace
+		}
+		return result[i].Nodes > result[j].Nodes
+	})
+	if len(result) > 0 {
+		result[0].Main = true
+		for i := 1; i < len(result); i++ {
+			result[i].DivergesAt = divergence(result[0].blocks, result[i].blocks)
+		}
+	}
+	return result
+}
+
+// placeTip finds the branch a tip belongs to: one that already has it, or
+// failing that the one with the most netspace that contradicts none of the
+// blocks the tip's nodes have reported. It returns nil when there is none.
+func placeTip(branches []*ForkBranch, group *tipGroup) *ForkBranch {
+	var best *ForkBranch
+	for _, branch := range branches {
+		if hash, known := branch.blocks[group.tip.Height]; known && hash == group.tip.Hash {
+			return branch
+		}
+		if divergence(branch.blocks, group.blocks) == 0 && (best == nil || branch.Netspace > best.Netspace) {
+			best = branch
+		}
+	}
+	return best
+}
+
+// divergence returns the lowest height two branches report different
+// blocks at, or 0
+func divergence(a, b map[uint64]string) uint64 {
+	var lowest uint64
+	for height, hash := range a {
+		if other, ok := b[height]; ok && other != hash && (lowest == 0 || height < lowest) {
+			lowest = height
+		}
+	}
+	return lowest
+}
+
+// handleGetForks returns the competing branches, for one chain with ?chain=
+func (ts *TrackerService) handleGetForks(w http.ResponseWriter, r *http.Request) {
+	chain, ok := ts.requestedChain(r)
+	if !ok {
+		http.Error(w, "Unknown chain", http.StatusNotFound)
+		return
+	}
+	ts.mu.RLock()
+	branches := detectForks(ts.nodesOnChain(chain))
+	ts.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"chain":    chain,
+		"branches": branches,
+		"count":    len(branches),
+		"forks":    forkCount(branches),
+	})
+}
+
+// forkCount is the number of branches competing with the main one
+func forkCount(branches []ForkBranch) int {
+	if len(branches) == 0 {
+		return 0
+	}
+	return len(branches) - 1
+}
+
+// forkBranchesHTML is the dashboard section listing competing branches,
+// with those in conflict with the main branch highlighted
+func forkBranchesHTML(branches []ForkBranch) string {
+	if len(branches) < 2 {
+		return ""
+	}
+	section := `
+        <div class="nodes-table" style="margin-top: 20px;">
+            <h3 style="padding: 0 12px; color: #dc3545;">&#9888; Competing Branches</h3>
+            <table>
+                <thead>
+                    <tr>
+                        <th>Branch</th>
+                        <th>Tip Height</th>
+                        <th>Tip Hash</th>
+                        <th>Nodes</th>
+                        <th>Netspace</th>
+                        <th>Diverges At</th>
+                    </tr>
+                </thead>
+                <tbody>`
+	for _, branch := range branches {
+		label, style := "Main", "color: #28a745;"
+		if !branch.Main {
+			label, style = "Conflicting", "background: #3a1f1f; color: #ff6b6b;"
+		}
+		divergesAt := "-"
+		if branch.DivergesAt > 0 {
+			divergesAt = fmt.Sprint(branch.DivergesAt)
+		}
+		hash := branch.TipHash
+		if len(hash) > 16 {
+			hash = hash[:16] + "..."
+		}
+		section += fmt.Sprintf(`
+                    <tr style="%s">
+                        <td>%s</td>
+                        <td>%d</td>
+                        <td class="ip-column">%s</td>
+                        <td>%d</td>
+                        <td class="fork-netspace">%d</td>
+                        <td>%s</td>
+                    </tr>`,
+			style, label, branch.TipHeight, html.EscapeString(hash), branch.Nodes, branch.Netspace, divergesAt)
+	}
+	return section + `
+                </tbody>
+            </table>
+            <script>
+                document.querySelectorAll('.fork-netspace').forEach(function(cell) {
+                    cell.textContent = formatBytes(parseInt(cell.textContent));
+                });
+            </script>
+        </div>`
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// addForkNode adds an online node at tip height:hash that previously
+// reported the blocks in history
+func addForkNode(ts *TrackerService, id string, height uint64, hash string, plotSize uint64, history ...BlockRef) {
+	addTestNode(ts, id, height, plotSize)
+	node := ts.nodes[id]
+	node.ChainHash = hash
+	node.RecentBlocks = history
+	node.recordTip()
+}
+
+func TestDetectForksFollowsLineage(t *testing.T) {
+	ts := NewTrackerService()
+	// The main chain, with one node lagging a few blocks behind on it
+	addForkNode(ts, "node-main1", 110, "m110", 10*tib, BlockRef{100, "m100"}, BlockRef{105, "m105"})
+	addForkNode(ts, "node-main2", 110, "m110", 10*tib)
+	addForkNode(ts, "node-behind", 105, "m105", tib)
+	// A minority that split off at 105 and is now at 108
+	addForkNode(ts, "node-split", 108, "s108", 2*tib, BlockRef{100, "m100"}, BlockRef{105, "s105"})
+	// A node that followed the main chain to 105 but is at its height on
+	// a different block
+	addForkNode(ts, "node-rival", 110, "r110", tib, BlockRef{105, "m105"})
+	// Lagging at a height nobody else has reported: nothing says it is off
+	addForkNode(ts, "node-unknown", 103, "u103", tib)
+
+	branches := detectForks(ts.nodes)
+	if len(branches) != 3 {
+		t.Fatalf("Expected the main branch and two forks, got %+v", branches)
+	}
+	main := branches[0]
+	if !main.Main || main.TipHash != "m110" || strings.Join(main.NodeIDs, ",") != "node-behind,node-main1,node-main2,node-unknown" {
+		t.Errorf("Unexpected main branch %+v", main)
+	}
+	if len(main.Tips) != 3 || main.Tips[0] != (BlockRef{110, "m110"}) {
+		t.Errorf("Expected the main branch's tips highest first, got %v", main.Tips)
+	}
+	if split := branches[1]; split.TipHash != "s108" || split.Main || split.DivergesAt != 105 {
+		t.Errorf("Expected the split off branch to diverge at 105, got %+v", split)
+	}
+	if rival := branches[2]; rival.TipHash != "r110" || rival.DivergesAt != 110 {
+		t.Errorf("Expected the rival tip to diverge at 110, got %+v", rival)
+	}
+
+	stats := ts.calculateNetworkStats()
+	if stats.ForkCount != 2 {
+		t.Errorf("Expected 2 forks, got %d", stats.ForkCount)
+	}
+}
+
+func TestDetectForksIgnoresUnhashedAndOfflineNodes(t *testing.T) {
+	ts := NewTrackerService()
+	addForkNode(ts, "node-main1", 100, "aa", tib)
+	addTestNode(ts, "node-nohash", 100, tib)
+	addForkNode(ts, "node-offline", 100, "bb", tib)
+	ts.nodes["node-offline"].Status = "offline"
+
+	if branches := detectForks(ts.nodes); len(branches) != 1 || branches[0].Nodes != 1 {
+		t.Errorf("Expected a single branch of one node, got %+v", branches)
+	}
+}
+
+func TestRecordTipKeepsRecentBlocks(t *testing.T) {
+	node := &RegisteredNode{NodeID: "node-1"}
+	for height := uint64(1); height <= maxRecentBlocks+10; height++ {
+		node.ChainHeight, node.ChainHash = height, "h"
+		node.recordTip()
+		node.recordTip()
+	}
+	if len(node.RecentBlocks) != maxRecentBlocks || node.RecentBlocks[0].Height != 11 {
+		t.Errorf("Expected the last %d distinct tips, got %d starting at %d",
+			maxRecentBlocks, len(node.RecentBlocks), node.RecentBlocks[0].Height)
+	}
+}
+
+func TestForksEndpointAndDashboard(t *testing.T) {
+	ts := NewTrackerService()
+	addForkNode(ts, "node-main1", 100, "aa", 2*tib)
+	addForkNode(ts, "node-rival", 100, "bb", tib)
+
+	rec := httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/forks", nil))
+	var resp struct {
+		Branches []ForkBranch
+		Count    int
+		Forks    int
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode forks: %v", err)
+	}
+	if resp.Count != 2 || resp.Forks != 1 || !resp.Branches[0].Main || resp.Branches[1].NodeIDs[0] != "node-rival" {
+		t.Errorf("Unexpected forks response %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Competing Branches") || !strings.Contains(body, "Conflicting") {
+		t.Error("Expected the dashboard to highlight the competing branch")
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoInfo is where a node's observed IP is located, as far as the GeoIP
+// databases know
+type GeoInfo struct {
+	Country     string  `json:"country,omitempty"` // ISO 3166-1 alpha-2
+	CountryName string  `json:"country_name,omitempty"`
+	ASN         uint    `json:"asn,omitempty"`
+	ASOrg       string  `json:"as_org,omitempty"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+}
+
+// GeoLocator looks up IPs, returning nil for IPs it knows nothing about
+type GeoLocator interface {
+	Locate(ip net.IP) (*GeoInfo, error)
+	Close() error
+}
+
+// mmdbLocator reads MaxMind databases: a Country or City database for the
+// country and coordinates, and an ASN database. Either may be absent.
+type mmdbLocator struct {
+	location *maxminddb.Reader
+	asn      *maxminddb.Reader
+}
+
+// The parts of the GeoIP2/GeoLite2 records the tracker uses
+type mmdbLocationRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+type mmdbASNRecord struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// OpenGeoLocator opens the mmdb files at locationPath and asnPath; empty
+// paths are skipped
+func OpenGeoLocator(locationPath, asnPath string) (GeoLocator, error) {
+	locator := &mmdbLocator{}
+	var err error
+	if locationPath != "" {
+		if locator.location, err = maxminddb.Open(locationPath); err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+		}
+	}
+	if asnPath != "" {
+		if locator.asn, err = maxminddb.Open(asnPath); err != nil {
+			locator.Close()
+			return nil, fmt.Errorf("failed to open ASN database: %w", err)
+		}
+	}
+	return locator, nil
+}
+
+// geoLocatorFromEnv opens TRACKER_GEOIP_DB (a Country or City database)
+// and TRACKER_GEOIP_ASN_DB. With neither set nodes are not located.
+func geoLocatorFromEnv() GeoLocator {
+	locationPath, asnPath := os.Getenv("TRACKER_GEOIP_DB"), os.Getenv("TRACKER_GEOIP_ASN_DB")
+	if locationPath == "" && asnPath == "" {
+		return nil
+	}
+	locator, err := OpenGeoLocator(locationPath, asnPath)
+	if err != nil {
+		log.Printf("⚠️ GeoIP enrichment disabled: %v", err)
+		return nil
+	}
+	log.Println("🌍 GeoIP enrichment enabled")
+	return locator
+}
+
+func (l *mmdbLocator) Locate(ip net.IP) (*GeoInfo, error) {
+	info := &GeoInfo{}
+	found := false
+
+	if l.location != nil {
+		var record mmdbLocationRecord
+		if err := l.location.Lookup(ip, &record); err != nil {
+			return nil, err
+		}
+		if record.Country.ISOCode != "" {
+			found = true
+			info.Country = record.Country.ISOCode
+			info.CountryName = record.Country.Names["en"]
+			info.Latitude, info.Longitude = record.Location.Latitude, record.Location.Longitude
+		}
+	}
+	if l.asn != nil {
+		var record mmdbASNRecord
+		if err := l.asn.Lookup(ip, &record); err != nil {
+			return nil, err
+		}
+		if record.Number != 0 {
+			found = true
+			info.ASN, info.ASOrg = record.Number, record.Organization
+		}
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return info, nil
+}
+
+func (l *mmdbLocator) Close() error {
+	for _, reader := range []*maxminddb.Reader{l.location, l.asn} {
+		if reader != nil {
+			reader.Close()
+		}
+	}
+	return nil
+}
+
+// locateNode annotates a node with the location of its observed IP,
+// leaving it unset when no GeoIP database is configured or the IP is
+// private or unknown
+func (ts *TrackerService) locateNode(node *RegisteredNode) {
+	if ts.geo == nil {
+		return
+	}
+	ip := net.ParseIP(node.ObservedIP)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() {
+		node.Geo = nil
+		return
+	}
+	info, err := ts.geo.Locate(ip)
+	if err != nil {
+		log.Printf("⚠️ GeoIP lookup for %s failed: %v", node.NodeID, err)
+		return
+	}
+	node.Geo = info
+}
+
+// GeoCount is the nodes and netspace in one country or autonomous system
+type GeoCount struct {
+	Code     string `json:"code"` // Country code or AS number
+	Name     string `json:"name,omitempty"`
+	Nodes    int    `json:"nodes"`
+	Netspace uint64 `json:"netspace_bytes"`
+}
+
+// geographyOf groups located nodes by country and by AS, largest first
+func geographyOf(nodes map[string]*RegisteredNode) (countries, asns []GeoCount) {
+	byCountry := make(map[string]*GeoCount)
+	byASN := make(map[string]*GeoCount)
+	add := func(groups map[string]*GeoCount, code, name string, node *RegisteredNode) {
+		group, ok := groups[code]
+		if !ok {
+			group = &GeoCount{Code: code, Name: name}
+			groups[code] = group
+		}
+		group.Nodes++
+		group.Netspace += node.TotalPlotSize
+	}
+
+	for _, node := range nodes {
+		if node.Geo == nil {
+			continue
+		}
+		if node.Geo.Country != "" {
+			add(byCountry, node.Geo.Country, node.Geo.CountryName, node)
+		}
+		if node.Geo.ASN != 0 {
+			add(byASN, "AS"+strconv.FormatUint(uint64(node.Geo.ASN), 10), node.Geo.ASOrg, node)
+		}
+	}
+	return sortedGeoCounts(byCountry), sortedGeoCounts(byASN)
+}
+
+func sortedGeoCounts(groups map[string]*GeoCount) []GeoCount {
+	var counts []GeoCount
+	for _, group := range groups {
+		counts = append(counts, *group)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Nodes != counts[j].Nodes {
+			return counts[i].Nodes > counts[j].Nodes
+		}
+		return counts[i].Code < counts[j].Code
+	})
+	return counts
+}
+
+// MapPoint is the farming nodes around one location. Coordinates are
+// rounded to a degree so the map does not pinpoint individual farms.
+type MapPoint struct {
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Country     string  `json:"country,omitempty"`
+	Nodes       int     `json:"nodes"`
+	OnlineNodes int     `json:"online_nodes"`
+	Netspace    uint64  `json:"netspace_bytes"`
+}
+
+// mapPointsOf groups located farming nodes by rounded location
+func mapPointsOf(nodes map[string]*RegisteredNode) []MapPoint {
+	points := make(map[[2]float64]*MapPoint)
+	for _, node := range nodes {
+		if node.Geo == nil || node.TotalPlotSize == 0 || (node.Geo.Latitude == 0 && node.Geo.Longitude == 0) {
+			continue
+		}
+		key := [2]float64{math.Round(node.Geo.Latitude), math.Round(node.Geo.Longitude)}
+		point, ok := points[key]
+		if !ok {
+			point = &MapPoint{Latitude: key[0], Longitude: key[1], Country: node.Geo.Country}
+			points[key] = point
+		}
+		point.Nodes++
+		if isOnline(node) {
+			point.OnlineNodes++
+		}
+		point.Netspace += node.TotalPlotSize
+	}
+
+	result := []MapPoint{}
+	for _, point := range points {
+		result = append(result, *point)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Netspace != result[j].Netspace {
+			return result[i].Netspace > result[j].Netspace
+		}
+		if result[i].Latitude != result[j].Latitude {
+			return result[i].Latitude < result[j].Latitude
+		}
+		return result[i].Longitude < result[j].Longitude
+	})
+	return result
+}
+
+// handleGetMap returns farming nodes grouped by location for the
+// dashboard's world map, for one chain with ?chain=
+func (ts *TrackerService) handleGetMap(w http.ResponseWriter, r *http.Request) {
+	chain, ok := ts.requestedChain(r)
+	if !ok {
+		http.Error(w, "Unknown chain", http.StatusNotFound)
+		return
+	}
+	ts.mu.RLock()
+	points := mapPointsOf(ts.nodesOnChain(chain))
+	ts.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"points":  points,
+		"count":   len(points),
+		"enabled": ts.geo != nil,
+	})
+}
+
+// worldMapHTML is the dashboard section plotting /api/v1/map on a world
+// map, scoped to the same ?chain= as the dashboard
+const worldMapHTML = `
+        <div class="nodes-table" style="margin-top: 20px;">
+            <link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+            <script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+            <div id="world-map" style="height: 420px;"></div>
+            <script>
+                (function() {
+                    const map = L.map('world-map', { worldCopyJump: true }).setView([20, 0], 2);
+                    L.tileLayer('https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+                        attribution: '&copy; OpenStreetMap contributors'
+                    }).addTo(map);
+
+                    fetch('/api/v1/map' + location.search)
+                        .then(function(resp) { return resp.json(); })
+                        .then(function(data) {
+                            data.points.forEach(function(point) {
+                                L.circleMarker([point.latitude, point.longitude], {
+                                    radius: 4 + 2 * Math.log2(point.nodes + 1),
+                                    color: point.online_nodes > 0 ? '#28a745' : '#dc3545'
+                                }).bindPopup(point.country + ': ' + point.nodes + ' nodes, ' +
+                                    formatBytes(point.netspace_bytes)).addTo(map);
+                            });
+                        });
+                })();
+            </script>
+        </div>`
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeLocator locates IPs from a fixed table
+type fakeLocator map[string]*GeoInfo
+
+func (f fakeLocator) Locate(ip net.IP) (*GeoInfo, error) { return f[ip.String()], nil }
+func (f fakeLocator) Close() error                       { return nil }
+
+func TestRegistrationIsLocated(t *testing.T) {
+	ts := NewTrackerService()
+	ts.geo = fakeLocator{"192.0.2.1": {Country: "DE", CountryName: "Germany", ASN: 64500, ASOrg: "Example"}}
+
+	if rec := postRegistration(t, ts, validRegistration()); rec.Code != http.StatusOK {
+		t.Fatalf("Registration failed: %d %s", rec.Code, rec.Body)
+	}
+	if geo := ts.nodes["node-1"].Geo; geo == nil || geo.Country != "DE" || geo.ASN != 64500 {
+		t.Errorf("Expected the node located by its observed IP, got %+v", geo)
+	}
+}
+
+func TestGeographyInStatsAndMap(t *testing.T) {
+	ts := NewTrackerService()
+	ts.geo = fakeLocator{}
+	nodes := []struct {
+		id       string
+		geo      *GeoInfo
+		plotSize uint64
+	}{
+		{"node-de-1", &GeoInfo{Country: "DE", ASN: 64500, Latitude: 52.31, Longitude: 13.40}, tib},
+		{"node-de-2", &GeoInfo{Country: "DE", ASN: 64501, Latitude: 52.40, Longitude: 13.10}, 2 * tib},
+		{"node-us-1", &GeoInfo{Country: "US", ASN: 64500, Latitude: 37.75, Longitude: -97.82}, 0},
+		{"node-unknown", nil, tib},
+	}
+	for _, n := range nodes {
+		addTestNode(ts, n.id, 100, n.plotSize)
+		ts.nodes[n.id].Geo = n.geo
+	}
+
+	stats := ts.calculateNetworkStats()
+	if len(stats.Countries) != 2 || stats.Countries[0].Code != "DE" || stats.Countries[0].Nodes != 2 || stats.Countries[0].Netspace != 3*tib {
+		t.Errorf("Unexpected countries %+v", stats.Countries)
+	}
+	if len(stats.ASNs) != 2 || stats.ASNs[0].Code != "AS64500" || stats.ASNs[0].Nodes != 2 {
+		t.Errorf("Unexpected ASNs %+v", stats.ASNs)
+	}
+
+	rec := httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/map", nil))
+	var resp struct{ Points []MapPoint }
+	json.NewDecoder(rec.Body).Decode(&resp)
+
+	// Nearby farms share a rounded point; nodes without plots are left off
+	if len(resp.Points) != 1 {
+		t.Fatalf("Expected one map point, got %+v", resp.Points)
+	}
+	if point := resp.Points[0]; point.Latitude != 52 || point.Longitude != 13 || point.Nodes != 2 || point.Netspace != 3*tib {
+		t.Errorf("Unexpected map point %+v", point)
+	}
+
+	rec = httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard", nil))
+	if !strings.Contains(rec.Body.String(), `id="world-map"`) {
+		t.Error("Expected the dashboard to show the world map")
+	}
+}
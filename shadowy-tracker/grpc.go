@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"shadowy-tracker/trackerpb"
+)
+
+const (
+	// watchRecheckInterval is how often a peer watch looks for peers that
+	// went quiet, which no request announces
+	watchRecheckInterval = 30 * time.Second
+
+	defaultMaxPeerWatches   = 256
+	defaultPeerWatchIdleFor = 10 * time.Minute
+)
+
+// changeNotifier wakes every waiter when the registry changes
+type changeNotifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{ch: make(chan struct{})}
+}
+
+// Wait returns a channel closed at the next Notify
+func (n *changeNotifier) Wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.ch
+}
+
+// Notify wakes everyone waiting
+func (n *changeNotifier) Notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.ch)
+	n.ch = make(chan struct{})
+}
+
+// grpcTracker serves the tracker API over gRPC. The unary calls run the
+// REST routes in-process, so both APIs share validation, signature checks,
+// rate limits and metrics.
+type grpcTracker struct {
+	trackerpb.UnimplementedTrackerServer
+	ts     *TrackerService
+	routes http.Handler
+
+	watches   chan struct{} // One slot per open peer watch
+	watchIdle time.Duration // How long a watch may go without an event
+}
+
+// newGRPCServer creates a gRPC server for the tracker API, serving at most
+// maxWatches peer watches at once and ending those with nothing to send
+// for watchIdle
+func (ts *TrackerService) newGRPCServer(maxWatches int, watchIdle time.Duration, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	trackerpb.RegisterTrackerServer(server, &grpcTracker{
+		ts:        ts,
+		routes:    ts.publicRouter(),
+		watches:   make(chan struct{}, maxWatches),
+		watchIdle: watchIdle,
+	})
+	return server
+}
+
+// bufferedResponse collects a REST route's response for a gRPC call
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
+
+// call runs the REST route at method and target for a gRPC request from
+// ctx, decoding its JSON response into out
+func (g *grpcTracker) call(ctx context.Context, method, target string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	r, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(payload))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	// The caller's address, and what proxies in front of the tracker say
+	// about it, as for a REST request
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, header := range []string{"X-Forwarded-For", "X-Real-IP"} {
+			for _, value := range md.Get(header) {
+				r.Header.Add(header, value)
+			}
+		}
+	}
+
+	resp := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	g.routes.ServeHTTP(resp, r)
+	if resp.status != http.StatusOK {
+		return restError(resp)
+	}
+	if err := json.Unmarshal(resp.body.Bytes(), out); err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("invalid response: %v", err))
+	}
+	return nil
+}
+
+// restError converts a failed REST response into a gRPC status
+func restError(resp *bufferedResponse) error {
+	var validation ValidationErrorResponse
+	if json.Unmarshal(resp.body.Bytes(), &validation) == nil && validation.Error != "" {
+		return validationStatus(validation.Error, validation.Fields)
+	}
+
+	message := strings.TrimSpace(resp.body.String())
+
+	code := codes.Internal
+	switch resp.status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusRequestEntityTooLarge:
+		code = codes.ResourceExhausted
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+		message += "; retry after " + resp.header.Get("Retry-After") + "s"
+	}
+	return status.Error(code, message)
+}
+
+// validationStatus is an InvalidArgument status listing the invalid fields
+func validationStatus(message string, fields []FieldError) error {
+	for _, field := range fields {
+		message += fmt.Sprintf("; %s %s", field.Field, field.Message)
+	}
+	return status.Error(codes.InvalidArgument, message)
+}
+
+func (g *grpcTracker) Register(ctx context.Context, req *trackerpb.RegisterRequest) (*trackerpb.RegisterResponse, error) {
+	body := RegistrationRequest{
+		NodeID:          req.NodeId,
+		MiningAddr:      req.MiningAddress,
+		PublicKey:       req.PublicKey,
+		ExternalIP:      req.ExternalIp,
+		P2PPort:         int(req.P2PPort),
+		HTTPPort:        int(req.HttpPort),
+		ChainHeight:     req.ChainHeight,
+		ChainHash:       req.ChainHash,
+		ChainID:         req.ChainId,
+		LastBlockTime:   req.LastBlockTime,
+		SoftwareVersion: req.SoftwareVersion,
+		OSVersion:       req.OsVersion,
+		Architecture:    req.Architecture,
+		TotalPlotSize:   req.TotalPlotSizeBytes,
+		PlotCount:       int(req.PlotCount),
+		Timestamp:       req.Timestamp,
+		Signature:       req.Signature,
+	}
+	var resp struct {
+		NodeID     string `json:"node_id"`
+		Message    string `json:"message"`
+		Deprecated bool   `json:"deprecated"`
+		MinVersion string `json:"min_version"`
+	}
+	if err := g.call(ctx, "POST", "/api/v1/register", body, &resp); err != nil {
+		return nil, err
+	}
+	return &trackerpb.RegisterResponse{NodeId: resp.NodeID, Message: resp.Message,
+		Deprecated: resp.Deprecated, MinVersion: resp.MinVersion}, nil
+}
+
+func (g *grpcTracker) Heartbeat(ctx context.Context, req *trackerpb.HeartbeatRequest) (*trackerpb.HeartbeatResponse, error) {
+	body := HeartbeatRequest{
+		NodeID:        req.NodeId,
+		ChainHeight:   req.ChainHeight,
+		ChainHash:     req.ChainHash,
+		LastBlockTime: req.LastBlockTime,
+		Status:        req.Status,
+		TotalPlotSize: req.TotalPlotSizeBytes,
+		PlotCount:     int(req.PlotCount),
+		Timestamp:     req.Timestamp,
+		Signature:     req.Signature,
+	}
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if err := g.call(ctx, "POST", "/api/v1/heartbeat", body, &resp); err != nil {
+		return nil, err
+	}
+	return &trackerpb.HeartbeatResponse{Message: resp.Message}, nil
+}
+
+// peersValues is a PeersRequest as /api/v1/peers query parameters
+func peersValues(req *trackerpb.PeersRequest) url.Values {
+	values := url.Values{}
+	set := func(name, value string, present bool) {
+		if present {
+			values.Set(name, value)
+		}
+	}
+	set("chain_id", req.ChainId, req.ChainId != "")
+	set("min_height", strconv.FormatUint(req.MinHeight, 10), req.MinHeight > 0)
+	set("limit", strconv.Itoa(int(req.Limit)), req.Limit != 0)
+	set("offset", strconv.Itoa(int(req.Offset)), req.Offset != 0)
+	set("random", "true", req.Random)
+	set("version", req.Version, req.Version != "")
+	for _, id := range req.Exclude {
+		values.Add("exclude", id)
+	}
+	return values
+}
+
+func (g *grpcTracker) GetPeers(ctx context.Context, req *trackerpb.PeersRequest) (*trackerpb.PeersResponse, error) {
+	var resp struct {
+		Peers []struct {
+			NodeID      string    `json:"node_id"`
+			Address     string    `json:"address"`
+			ClientEth   string    `json:"client_eth"`
+			ChainHeight uint64    `json:"chain_height"`
+			ChainHash   string    `json:"chain_hash"`
+			ChainID     string    `json:"chain_id"`
+			LastSeen    time.Time `json:"last_seen"`
+			Score       int       `json:"score"`
+		} `json:"peers"`
+		Total int `json:"total"`
+	}
+	if err := g.call(ctx, "GET", "/api/v1/peers?"+peersValues(req).Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &trackerpb.PeersResponse{Total: int32(resp.Total)}
+	for _, p := range resp.Peers {
+		result.Peers = append(result.Peers, &trackerpb.Peer{
+			NodeId:      p.NodeID,
+			Address:     p.Address,
+			ClientEth:   p.ClientEth,
+			ChainHeight: p.ChainHeight,
+			ChainHash:   p.ChainHash,
+			ChainId:     p.ChainID,
+			LastSeen:    p.LastSeen.UTC().Format(time.RFC3339),
+			Score:       int32(p.Score),
+		})
+	}
+	return result, nil
+}
+
+func (g *grpcTracker) GetStats(ctx context.Context, req *trackerpb.StatsRequest) (*trackerpb.NetworkStats, error) {
+	var stats NetworkStats
+	if err := g.call(ctx, "GET", "/api/v1/stats?"+url.Values{"chain": {req.Chain}}.Encode(), nil, &stats); err != nil {
+		return nil, err
+	}
+	return &trackerpb.NetworkStats{
+		Chain:                   stats.Chain,
+		TotalNodes:              int32(stats.TotalNodes),
+		OnlineNodes:             int32(stats.OnlineNodes),
+		SyncingNodes:            int32(stats.SyncingNodes),
+		TotalNetspaceBytes:      stats.TotalNetspace,
+		HighestHeight:           stats.HighestHeight,
+		ConsensusHeight:         stats.ConsensusHeight,
+		NetspaceConsensusHeight: stats.NetspaceConsensusHeight,
+		ForkCount:               int32(stats.ForkCount),
+		DuplicateMiners:         int32(stats.DuplicateMiners),
+		DeprecatedNodes:         int32(stats.DeprecatedNodes),
+		LastUpdated:             stats.LastUpdated,
+	}, nil
+}
+
+// peerMessage is a node as a peer, with last_seen left out so heartbeats
+// alone do not count as changes
+func peerMessage(node *RegisteredNode) *trackerpb.Peer {
+	address, clientEth := peerAddresses(node)
+	return &trackerpb.Peer{
+		NodeId:      node.NodeID,
+		Address:     address,
+		ClientEth:   clientEth,
+		ChainHeight: node.ChainHeight,
+		ChainHash:   node.ChainHash,
+		ChainId:     node.ChainID,
+		Score:       int32(node.Score),
+	}
+}
+
+// WatchPeers streams the peer set and its changes until the client leaves
+// or nothing has changed for the idle timeout, after which the client is
+// expected to watch again
+func (g *grpcTracker) WatchPeers(req *trackerpb.PeersRequest, stream grpc.ServerStreamingServer[trackerpb.PeerEvent]) error {
+	query, fields := g.ts.parsePeerQuery(peersValues(req))
+	if len(fields) > 0 {
+		return validationStatus("Invalid peer query", fields)
+	}
+	if query.Random {
+		return status.Error(codes.InvalidArgument, "a random sample cannot be watched")
+	}
+
+	select {
+	case g.watches <- struct{}{}:
+		defer func() { <-g.watches }()
+	default:
+		return status.Error(codes.ResourceExhausted, "too many peer watches, try again later")
+	}
+	idle := time.NewTimer(g.watchIdle)
+	defer idle.Stop()
+
+	sent := make(map[string]*trackerpb.Peer)
+	for {
+		changed := g.ts.peerChanges.Wait()
+		// Copied under the lock so the stream is written without holding it
+		g.ts.mu.RLock()
+		nodes, _ := g.ts.selectPeers(query)
+		peers := make([]*trackerpb.Peer, len(nodes))
+		lastSeen := make([]string, len(nodes))
+		for i, node := range nodes {
+			peers[i] = peerMessage(node)
+			lastSeen[i] = node.LastHeartbeat.UTC().Format(time.RFC3339)
+		}
+		g.ts.mu.RUnlock()
+
+		current := make(map[string]bool, len(peers))
+		for i, peer := range peers {
+			current[peer.NodeId] = true
+			previous, known := sent[peer.NodeId]
+			if known && proto.Equal(previous, peer) {
+				continue
+			}
+			event := &trackerpb.PeerEvent{Type: trackerpb.PeerEvent_ADDED, Peer: proto.Clone(peer).(*trackerpb.Peer)}
+			if known {
+				event.Type = trackerpb.PeerEvent_UPDATED
+			}
+			event.Peer.LastSeen = lastSeen[i]
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+			sent[peer.NodeId] = peer
+			idle.Reset(g.watchIdle)
+		}
+		for id := range sent {
+			if !current[id] {
+				event := &trackerpb.PeerEvent{Type: trackerpb.PeerEvent_REMOVED, Peer: &trackerpb.Peer{NodeId: id}}
+				if err := stream.Send(event); err != nil {
+					return err
+				}
+				delete(sent, id)
+				idle.Reset(g.watchIdle)
+			}
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-idle.C:
+			return status.Errorf(codes.DeadlineExceeded, "no peer changes for %s", g.watchIdle)
+		case <-changed:
+		case <-time.After(watchRecheckInterval):
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"shadowy-tracker/trackerpb"
+)
+
+// dialGRPC serves ts over an in-memory gRPC connection
+func dialGRPC(t *testing.T, ts *TrackerService) trackerpb.TrackerClient {
+	return dialGRPCServer(t, ts.newGRPCServer(defaultMaxPeerWatches, defaultPeerWatchIdleFor))
+}
+
+// dialGRPCServer serves server over an in-memory gRPC connection
+func dialGRPCServer(t *testing.T, server *grpc.Server) trackerpb.TrackerClient {
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///tracker",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return trackerpb.NewTrackerClient(conn)
+}
+
+// registerRequest is a signed registration payload as a gRPC request
+func registerRequest(payload map[string]interface{}) *trackerpb.RegisterRequest {
+	data, _ := json.Marshal(payload)
+	var req RegistrationRequest
+	json.Unmarshal(data, &req)
+	return &trackerpb.RegisterRequest{
+		NodeId:             req.NodeID,
+		MiningAddress:      req.MiningAddr,
+		PublicKey:          req.PublicKey,
+		ExternalIp:         req.ExternalIP,
+		P2PPort:            int32(req.P2PPort),
+		HttpPort:           int32(req.HTTPPort),
+		ChainHeight:        req.ChainHeight,
+		ChainHash:          req.ChainHash,
+		ChainId:            req.ChainID,
+		LastBlockTime:      req.LastBlockTime,
+		SoftwareVersion:    req.SoftwareVersion,
+		TotalPlotSizeBytes: req.TotalPlotSize,
+		PlotCount:          int32(req.PlotCount),
+		Timestamp:          req.Timestamp,
+		Signature:          req.Signature,
+	}
+}
+
+// heartbeatRequest is a heartbeat from nodeID at height, signed with the
+// test key
+func heartbeatRequest(nodeID string, height uint64) *trackerpb.HeartbeatRequest {
+	req := HeartbeatRequest{NodeID: nodeID, ChainHeight: height, ChainHash: "abc", Status: "online",
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano)}
+	return &trackerpb.HeartbeatRequest{
+		NodeId:      req.NodeID,
+		ChainHeight: req.ChainHeight,
+		ChainHash:   req.ChainHash,
+		Status:      req.Status,
+		Timestamp:   req.Timestamp,
+		Signature:   testSign(heartbeatMessage(&req)),
+	}
+}
+
+func TestGRPCRegisterAndHeartbeat(t *testing.T) {
+	ts := NewTrackerService()
+	client := dialGRPC(t, ts)
+	ctx := context.Background()
+
+	resp, err := client.Register(ctx, registerRequest(validRegistration()))
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if resp.NodeId != "node-1" || ts.nodes["node-1"] == nil {
+		t.Fatalf("Expected node-1 to be registered, got %+v", resp)
+	}
+
+	if _, err := client.Heartbeat(ctx, heartbeatRequest("node-1", 43)); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	if ts.nodes["node-1"].ChainHeight != 43 {
+		t.Errorf("Heartbeat should update the height, got %d", ts.nodes["node-1"].ChainHeight)
+	}
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	ts := NewTrackerService()
+	client := dialGRPC(t, ts)
+	ctx := context.Background()
+
+	invalid := validRegistration()
+	invalid["p2p_port"] = 0
+	signRegistration(invalid)
+	forged := heartbeatRequest("node-1", 43)
+	forged.ChainHeight = 44
+
+	_, registerErr := client.Register(ctx, registerRequest(invalid))
+	_, unknownErr := client.Heartbeat(ctx, heartbeatRequest("node-9", 1))
+	client.Register(ctx, registerRequest(validRegistration()))
+	_, forgedErr := client.Heartbeat(ctx, forged)
+	_, randomErr := client.GetPeers(ctx, &trackerpb.PeersRequest{Random: true, Offset: 1})
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"invalid registration", registerErr, codes.InvalidArgument},
+		{"unknown node", unknownErr, codes.NotFound},
+		{"forged heartbeat", forgedErr, codes.Unauthenticated},
+		{"invalid peer query", randomErr, codes.InvalidArgument},
+	} {
+		if got := status.Code(tc.err); got != tc.code {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.code, tc.err)
+		}
+	}
+}
+
+func TestGRPCGetPeersAndStats(t *testing.T) {
+	ts := NewTrackerService()
+	addPeerNodes(ts, 5)
+	client := dialGRPC(t, ts)
+	ctx := context.Background()
+
+	peers, err := client.GetPeers(ctx, &trackerpb.PeersRequest{Limit: 2, Exclude: []string{"node-00"}})
+	if err != nil {
+		t.Fatalf("GetPeers failed: %v", err)
+	}
+	if peers.Total != 4 || len(peers.Peers) != 2 {
+		t.Fatalf("Expected 2 of 4 peers, got %d of %d", len(peers.Peers), peers.Total)
+	}
+	if peers.Peers[0].Address == "" || peers.Peers[0].LastSeen == "" {
+		t.Errorf("Peers should carry an address and last seen time, got %+v", peers.Peers[0])
+	}
+
+	stats, err := client.GetStats(ctx, &trackerpb.StatsRequest{})
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalNodes != 5 || stats.OnlineNodes != 5 {
+		t.Errorf("Expected 5 online nodes, got %+v", stats)
+	}
+}
+
+func TestGRPCWatchPeersStreamsChanges(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-0", 100, tib)
+	client := dialGRPC(t, ts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := client.WatchPeers(ctx, &trackerpb.PeersRequest{})
+	if err != nil {
+		t.Fatalf("WatchPeers failed: %v", err)
+	}
+	expect := func(eventType trackerpb.PeerEvent_Type, nodeID string) {
+		t.Helper()
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Expected %v for %s: %v", eventType, nodeID, err)
+		}
+		if event.Type != eventType || event.Peer.NodeId != nodeID {
+			t.Fatalf("Expected %v for %s, got %v for %s", eventType, nodeID, event.Type, event.Peer.NodeId)
+		}
+	}
+
+	expect(trackerpb.PeerEvent_ADDED, "node-0")
+
+	if _, err := client.Register(ctx, registerRequest(validRegistration())); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	expect(trackerpb.PeerEvent_ADDED, "node-1")
+
+	if _, err := client.Heartbeat(ctx, heartbeatRequest("node-1", 43)); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	expect(trackerpb.PeerEvent_UPDATED, "node-1")
+
+	tagRequest(t, ts, "POST", "/api/v1/admin/bans", `{"node_id":"node-0"}`)
+	expect(trackerpb.PeerEvent_REMOVED, "node-0")
+}
+
+func TestGRPCWatchPeersLimitsAndIdle(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-0", 100, tib)
+	client := dialGRPCServer(t, ts.newGRPCServer(1, 200*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	watch := func() (trackerpb.Tracker_WatchPeersClient, error) {
+		stream, err := client.WatchPeers(ctx, &trackerpb.PeersRequest{})
+		if err != nil {
+			t.Fatalf("WatchPeers failed: %v", err)
+		}
+		_, err = stream.Recv()
+		return stream, err
+	}
+
+	first, err := watch()
+	if err != nil {
+		t.Fatalf("Expected the first watch to be served: %v", err)
+	}
+	if _, err := watch(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected a second watch to be refused with ResourceExhausted, got %v", err)
+	}
+
+	// Nothing changes, so the first watch is ended and its slot freed
+	if _, err := first.Recv(); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected the idle watch to end with DeadlineExceeded, got %v", err)
+	}
+	if _, err := watch(); err != nil {
+		t.Errorf("Expected a new watch once the idle one ended: %v", err)
+	}
+}
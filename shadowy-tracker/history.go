@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Network statistics are sampled every minute and rolled up into hourly
+// and daily buckets, kept as history:<resolution>:<unix start>:<chain>
+// where the chain is empty for the whole network
+const (
+	historyKeyPrefix      = "history:"
+	historySampleInterval = time.Minute
+	defaultHistoryRange   = "30d"
+	defaultHistoryMetric  = "netspace"
+	maxHistoryPoints      = 1000
+	maxHistoryRangeDays   = 5 * 365
+)
+
+// historyResolution is one granularity of history and how long it is kept
+type historyResolution struct {
+	Name      string
+	Step      time.Duration
+	Retention time.Duration
+}
+
+var historyResolutions = []historyResolution{
+	{"minute", time.Minute, 48 * time.Hour},
+	{"hour", time.Hour, 90 * 24 * time.Hour},
+	{"day", 24 * time.Hour, maxHistoryRangeDays * 24 * time.Hour},
+}
+
+// historyMetrics are the statistics kept over time
+var historyMetrics = map[string]func(NetworkStats) uint64{
+	"netspace":           func(s NetworkStats) uint64 { return s.TotalNetspace },
+	"nodes":              func(s NetworkStats) uint64 { return uint64(s.TotalNodes) },
+	"online_nodes":       func(s NetworkStats) uint64 { return uint64(s.OnlineNodes) },
+	"syncing_nodes":      func(s NetworkStats) uint64 { return uint64(s.SyncingNodes) },
+	"highest_height":     func(s NetworkStats) uint64 { return s.HighestHeight },
+	"consensus_height":   func(s NetworkStats) uint64 { return s.ConsensusHeight },
+	"forks":              func(s NetworkStats) uint64 { return uint64(s.ForkCount) },
+	"duplicate_miners":   func(s NetworkStats) uint64 { return uint64(s.DuplicateMiners) },
+	"netspace_consensus": func(s NetworkStats) uint64 { return s.NetspaceConsensusHeight },
+}
+
+// statsRollup is what the store keeps for one bucket: the sum, minimum and
+// maximum of every metric over the samples taken in it
+type statsRollup struct {
+	Start   time.Time          `json:"start"`
+	Samples int                `json:"samples"`
+	Sum     map[string]float64 `json:"sum"`
+	Min     map[string]uint64  `json:"min"`
+	Max     map[string]uint64  `json:"max"`
+}
+
+func (r *statsRollup) add(values map[string]uint64) {
+	if r.Sum == nil {
+		r.Sum, r.Min, r.Max = make(map[string]float64), make(map[string]uint64), make(map[string]uint64)
+	}
+	for name, value := range values {
+		if min, ok := r.Min[name]; !ok || value < min {
+			r.Min[name] = value
+		}
+		if value > r.Max[name] {
+			r.Max[name] = value
+		}
+		r.Sum[name] += float64(value)
+	}
+	r.Samples++
+}
+
+// HistoryPoint is one metric over a minute, hour or day: its average and
+// range across the samples taken
+type HistoryPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+	Min   uint64    `json:"min"`
+	Max   uint64    `json:"max"`
+}
+
+func historyKey(resolution string, start time.Time, chain string) []byte {
+	return []byte(fmt.Sprintf("%s%s:%016d:%s", historyKeyPrefix, resolution, start.Unix(), chain))
+}
+
+// RecordStats adds a sample of the metrics for chain ("" for the whole
+// network) taken at at to every resolution's bucket
+func (s *NodeStore) RecordStats(chain string, at time.Time, values map[string]uint64) error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		for _, res := range historyResolutions {
+			start := at.UTC().Truncate(res.Step)
+			key := historyKey(res.Name, start, chain)
+
+			rollup := statsRollup{Start: start}
+			item, err := txn.Get(key)
+			if err == nil {
+				err = item.Value(func(val []byte) error { return json.Unmarshal(val, &rollup) })
+			}
+			if err != nil && err != badger.ErrKeyNotFound {
+				return err
+			}
+			rollup.add(values)
+			if err := putJSON(txn, key, rollup); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// statsHistory returns chain's buckets at resolution starting in [from, to)
+func (s *NodeStore) statsHistory(resolution, chain string, from, to time.Time) ([]statsRollup, error) {
+	rollups := []statsRollup{}
+	if s == nil {
+		return rollups, nil
+	}
+	prefix := historyKeyPrefix + resolution + ":"
+	suffix := ":" + chain
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(historyKey(resolution, from, "")); it.Valid(); it.Next() {
+			key := string(it.Item().Key())
+			if !strings.HasSuffix(key, suffix) || strings.Count(key, ":") != 3 {
+				continue
+			}
+			var rollup statsRollup
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &rollup)
+			}); err != nil {
+				continue
+			}
+			if !rollup.Start.Before(to) {
+				break
+			}
+			rollups = append(rollups, rollup)
+		}
+		return nil
+	})
+	return rollups, err
+}
+
+// PruneStatsHistory drops buckets older than their resolution's
+// retention, returning how many were removed
+func (s *NodeStore) PruneStatsHistory(now time.Time) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+	var stale [][]byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		for _, res := range historyResolutions {
+			before := fmt.Sprintf("%016d", now.Add(-res.Retention).Unix())
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = []byte(historyKeyPrefix + res.Name + ":")
+			opts.PrefetchValues = false
+			it := txn.NewIterator(opts)
+			for it.Rewind(); it.Valid(); it.Next() {
+				fields := strings.SplitN(string(it.Item().Key()), ":", 4)
+				if len(fields) == 4 && fields[2] < before {
+					stale = append(stale, it.Item().KeyCopy(nil))
+				}
+			}
+			it.Close()
+		}
+		return nil
+	})
+	if err != nil || len(stale) == 0 {
+		return 0, err
+	}
+
+	batch := s.db.NewWriteBatch()
+	defer batch.Cancel()
+	for _, key := range stale {
+		if err := batch.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), batch.Flush()
+}
+
+// recordStatsHistory samples the statistics of the whole network and of
+// every chain once a minute
+func (ts *TrackerService) recordStatsHistory() {
+	ticker := time.NewTicker(historySampleInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		ts.sampleStats(now)
+	}
+}
+
+// sampleStats records one sample of the network's and each chain's stats
+func (ts *TrackerService) sampleStats(now time.Time) {
+	chains := []string{""}
+	for _, chain := range ts.chains.List() {
+		chains = append(chains, chain.Name)
+	}
+	for _, chain := range chains {
+		ts.mu.RLock()
+		stats := ts.calculateChainStats(chain)
+		ts.mu.RUnlock()
+		values := make(map[string]uint64, len(historyMetrics))
+		for name, metric := range historyMetrics {
+			values[name] = metric(stats)
+		}
+		if err := ts.store.RecordStats(chain, now, values); err != nil {
+			log.Printf("⚠️ Failed to record stats history: %v", err)
+			return
+		}
+	}
+}
+
+// parseHistoryRange reads a range of hours (24h) or days (30d)
+func parseHistoryRange(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("range must be hours such as 24h or days up to %dd", maxHistoryRangeDays)
+	if len(value) < 2 {
+		return 0, invalid
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 1 {
+		return 0, invalid
+	}
+	switch value[len(value)-1] {
+	case 'h':
+		if n <= maxHistoryRangeDays*24 {
+			return time.Duration(n) * time.Hour, nil
+		}
+	case 'd':
+		if n <= maxHistoryRangeDays {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	return 0, invalid
+}
+
+// historyResolutionFor picks the finest resolution still kept for the
+// whole range that gives at most maxHistoryPoints points
+func historyResolutionFor(length time.Duration) historyResolution {
+	for _, res := range historyResolutions {
+		if length <= res.Retention && length/res.Step <= maxHistoryPoints {
+			return res
+		}
+	}
+	return historyResolutions[len(historyResolutions)-1]
+}
+
+// handleGetStatsHistory returns one metric over time, for one chain with
+// ?chain=. The resolution follows the range unless ?resolution= is given.
+func (ts *TrackerService) handleGetStatsHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	metric := query.Get("metric")
+	if metric == "" {
+		metric = defaultHistoryMetric
+	}
+	if _, ok := historyMetrics[metric]; !ok {
+		names := make([]string, 0, len(historyMetrics))
+		for name := range historyMetrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		http.Error(w, "metric must be one of "+strings.Join(names, ", "), http.StatusBadRequest)
+		return
+	}
+
+	rangeValue := query.Get("range")
+	if rangeValue == "" {
+		rangeValue = defaultHistoryRange
+	}
+	length, err := parseHistoryRange(rangeValue)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resolution := historyResolutionFor(length)
+	if name := query.Get("resolution"); name != "" {
+		found := false
+		for _, res := range historyResolutions {
+			if res.Name == name {
+				resolution, found = res, true
+			}
+		}
+		if !found {
+			http.Error(w, "resolution must be minute, hour or day", http.StatusBadRequest)
+			return
+		}
+	}
+
+	chain, ok := ts.requestedChain(r)
+	if !ok {
+		http.Error(w, "Unknown chain", http.StatusNotFound)
+		return
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-length).Truncate(resolution.Step)
+	rollups, err := ts.store.statsHistory(resolution.Name, chain, from, to)
+	if err != nil {
+		http.Error(w, "Failed to read stats history", http.StatusInternalServerError)
+		return
+	}
+
+	points := make([]HistoryPoint, 0, len(rollups))
+	for _, rollup := range rollups {
+		if rollup.Samples == 0 {
+			continue
+		}
+		points = append(points, HistoryPoint{
+			Time:  rollup.Start,
+			Value: rollup.Sum[metric] / float64(rollup.Samples),
+			Min:   rollup.Min[metric],
+			Max:   rollup.Max[metric],
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"metric":     metric,
+		"chain":      chain,
+		"range":      rangeValue,
+		"resolution": resolution.Name,
+		"persisted":  ts.store != nil,
+		"points":     points,
+	})
+}
+
+// historyChartsHTML is the dashboard section charting 30 days of netspace
+// and node counts, scoped to the same ?chain= as the dashboard
+const historyChartsHTML = `
+        <div class="stats" style="margin-top: 20px;">
+            <div class="stat-card">
+                <div class="stat-label">Netspace, 30 days</div>
+                <svg id="history-netspace" viewBox="0 0 600 150" style="width: 100%; height: 150px;"></svg>
+            </div>
+            <div class="stat-card">
+                <div class="stat-label">Online nodes, 30 days</div>
+                <svg id="history-online_nodes" viewBox="0 0 600 150" style="width: 100%; height: 150px;"></svg>
+            </div>
+        </div>
+        <script>
+            ['netspace', 'online_nodes'].forEach(function(metric) {
+                const params = new URLSearchParams(location.search);
+                params.set('metric', metric);
+                params.set('range', '30d');
+                fetch('/api/v1/stats/history?' + params)
+                    .then(function(resp) { return resp.json(); })
+                    .then(function(data) {
+                        const svg = document.getElementById('history-' + metric);
+                        if (data.points.length < 2) {
+                            svg.innerHTML = '<text x="10" y="80" fill="#aaa">Not enough history yet</text>';
+                            return;
+                        }
+                        const times = data.points.map(function(p) { return Date.parse(p.time); });
+                        const values = data.points.map(function(p) { return p.value; });
+                        const t0 = Math.min.apply(null, times), t1 = Math.max.apply(null, times);
+                        const max = Math.max.apply(null, values) || 1;
+                        const coords = data.points.map(function(p, i) {
+                            return (600 * (times[i] - t0) / (t1 - t0)).toFixed(1) + ',' + (145 - 135 * values[i] / max).toFixed(1);
+                        });
+                        const label = metric === 'netspace' ? formatBytes(max) : Math.round(max);
+                        svg.innerHTML = '<polyline fill="none" stroke="#4a9eff" stroke-width="2" points="' + coords.join(' ') + '"/>' +
+                            '<text x="5" y="15" fill="#aaa" font-size="12">' + label + '</text>';
+                    });
+            });
+        </script>`
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func getHistory(t *testing.T, ts *TrackerService, query string) (int, []HistoryPoint, string) {
+	rec := httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/stats/history?"+query, nil))
+	var resp struct {
+		Resolution string
+		Points     []HistoryPoint
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	return rec.Code, resp.Points, resp.Resolution
+}
+
+func TestStatsHistoryRollups(t *testing.T) {
+	ts := NewTrackerService()
+	ts.store = openTestStore(t, t.TempDir())
+	defer ts.store.Close()
+
+	// Two samples in one hour, a third in the next
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	addTestNode(ts, "node-1", 10, tib)
+	ts.nodes["node-1"].ChainID = "testnet0"
+	ts.sampleStats(hour.Add(10 * time.Minute))
+	ts.nodes["node-1"].TotalPlotSize = 3 * tib
+	ts.sampleStats(hour.Add(20 * time.Minute))
+	addTestNode(ts, "node-2", 10, tib)
+	ts.sampleStats(hour.Add(70 * time.Minute))
+
+	code, points, resolution := getHistory(t, ts, "metric=netspace&range=24h")
+	if code != http.StatusOK || resolution != "hour" || len(points) != 2 {
+		t.Fatalf("Expected two hourly points, got %d %s %+v", code, resolution, points)
+	}
+	if p := points[0]; !p.Time.Equal(hour) || p.Value != float64(2*tib) || p.Min != tib || p.Max != 3*tib {
+		t.Errorf("Unexpected first hour %+v", p)
+	}
+	if p := points[1]; p.Value != float64(4*tib) {
+		t.Errorf("Expected the second hour to count both nodes, got %+v", p)
+	}
+
+	// Finer ranges come from the minute samples
+	_, points, resolution = getHistory(t, ts, "metric=nodes&range=6h")
+	if resolution != "minute" || len(points) != 3 || points[2].Value != 2 {
+		t.Errorf("Expected three minute samples, got %s %+v", resolution, points)
+	}
+
+	// Chains are kept apart; node-2 is not on testnet0
+	_, points, _ = getHistory(t, ts, "metric=nodes&range=6h&chain=testnet0")
+	if len(points) != 3 || points[2].Value != 1 {
+		t.Errorf("Expected testnet0's own history, got %+v", points)
+	}
+
+	// Minute samples expire first
+	if removed, err := ts.store.PruneStatsHistory(hour.Add(50 * time.Hour)); err != nil || removed != 6 {
+		t.Errorf("Expected the six minute samples pruned, got %d (%v)", removed, err)
+	}
+}
+
+func TestStatsHistoryValidation(t *testing.T) {
+	ts := NewTrackerService()
+	for _, query := range []string{"metric=bogus", "range=10y", "range=0d", "resolution=week"} {
+		if code, _, _ := getHistory(t, ts, query); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, code)
+		}
+	}
+	if code, points, _ := getHistory(t, ts, ""); code != http.StatusOK || len(points) != 0 {
+		t.Errorf("Expected an empty history without a store, got %d %+v", code, points)
+	}
+}
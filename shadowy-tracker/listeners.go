@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	defaultPublicAddr   = ":8090"
+	defaultInternalAddr = "127.0.0.1:8091"
+	defaultACMECacheDir = "./acme-cache"
+	shutdownTimeout     = 10 * time.Second
+)
+
+// ListenConfig holds the bind addresses for the tracker's listeners. The
+// public listener serves node and dashboard routes; the internal listener
+// serves /metrics and /api/v1/admin/* and should not be exposed publicly.
+//
+// The public listener speaks TLS with either a certificate and key from
+// disk or certificates obtained from Let's Encrypt for ACMEDomains. The
+// internal listener is always plain HTTP; the gRPC listener uses the same
+// TLS as the public one.
+type ListenConfig struct {
+	PublicAddr   string
+	InternalAddr string // Empty disables the internal listener
+	GRPCAddr     string // Tracker API over gRPC; empty, the default, disables it
+	Hostname     string // Name the tracker is reached by, for logs and links
+
+	GRPCMaxWatches int           // Peer watches served at once
+	GRPCWatchIdle  time.Duration // A watch with no event for this long is ended
+
+	TLSCertFile  string
+	TLSKeyFile   string
+	ACMEDomains  []string
+	ACMEEmail    string
+	ACMECacheDir string
+	ACMEHTTPAddr string // Serves HTTP-01 challenges and redirects to HTTPS; empty relies on TLS-ALPN-01
+
+	TrustedProxies TrustedProxies
+}
+
+// listenConfigFromEnv reads TRACKER_LISTEN_ADDR, TRACKER_INTERNAL_ADDR and
+// TRACKER_GRPC_ADDR. Setting TRACKER_INTERNAL_ADDR to "off" disables
+// metrics and admin routes. The gRPC API is only served when
+// TRACKER_GRPC_ADDR is set, with TRACKER_GRPC_MAX_WATCHES and
+// TRACKER_GRPC_WATCH_IDLE bounding its peer watches.
+// TLS comes from TRACKER_TLS_CERT and TRACKER_TLS_KEY, or from ACME for the
+// comma separated TRACKER_ACME_DOMAINS with TRACKER_ACME_EMAIL,
+// TRACKER_ACME_CACHE and TRACKER_ACME_HTTP_ADDR. TRACKER_HOSTNAME defaults
+// to the first ACME domain or the machine's hostname, and
+// TRACKER_TRUSTED_PROXIES replaces the default loopback and private
+// networks.
+func listenConfigFromEnv() ListenConfig {
+	cfg := ListenConfig{
+		PublicAddr:     defaultPublicAddr,
+		InternalAddr:   defaultInternalAddr,
+		GRPCAddr:       os.Getenv("TRACKER_GRPC_ADDR"),
+		GRPCMaxWatches: intFromEnv("TRACKER_GRPC_MAX_WATCHES", defaultMaxPeerWatches),
+		GRPCWatchIdle:  durationFromEnv("TRACKER_GRPC_WATCH_IDLE", defaultPeerWatchIdleFor, time.Second),
+		TLSCertFile:    os.Getenv("TRACKER_TLS_CERT"),
+		TLSKeyFile:     os.Getenv("TRACKER_TLS_KEY"),
+		ACMEEmail:      os.Getenv("TRACKER_ACME_EMAIL"),
+		ACMECacheDir:   defaultACMECacheDir,
+		ACMEHTTPAddr:   os.Getenv("TRACKER_ACME_HTTP_ADDR"),
+		TrustedProxies: defaultTrustedProxies(),
+	}
+	if addr := os.Getenv("TRACKER_LISTEN_ADDR"); addr != "" {
+		cfg.PublicAddr = addr
+	}
+	if addr, ok := os.LookupEnv("TRACKER_INTERNAL_ADDR"); ok {
+		cfg.InternalAddr = addr
+		if addr == "off" {
+			cfg.InternalAddr = ""
+		}
+	}
+	if cfg.GRPCAddr == "off" {
+		cfg.GRPCAddr = ""
+	}
+	for _, domain := range strings.Split(os.Getenv("TRACKER_ACME_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			cfg.ACMEDomains = append(cfg.ACMEDomains, domain)
+		}
+	}
+	if dir := os.Getenv("TRACKER_ACME_CACHE"); dir != "" {
+		cfg.ACMECacheDir = dir
+	}
+
+	cfg.Hostname = os.Getenv("TRACKER_HOSTNAME")
+	if cfg.Hostname == "" && len(cfg.ACMEDomains) > 0 {
+		cfg.Hostname = cfg.ACMEDomains[0]
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+
+	if value := os.Getenv("TRACKER_TRUSTED_PROXIES"); value != "" {
+		proxies, err := parseTrustedProxies(value)
+		if err != nil {
+			log.Printf("⚠️ Ignoring TRACKER_TRUSTED_PROXIES: %v", err)
+		} else {
+			cfg.TrustedProxies = proxies
+		}
+	}
+	return cfg
+}
+
+// TLSEnabled reports whether the public listener serves HTTPS
+func (cfg ListenConfig) TLSEnabled() bool {
+	return cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" || len(cfg.ACMEDomains) > 0
+}
+
+// PublicURL is where nodes and visitors reach the public listener
+func (cfg ListenConfig) PublicURL() string {
+	scheme, defaultPort := "http", "80"
+	if cfg.TLSEnabled() {
+		scheme, defaultPort = "https", "443"
+	}
+	host := cfg.Hostname
+	if host == "" {
+		host = "localhost"
+	}
+	if _, port, err := net.SplitHostPort(cfg.PublicAddr); err == nil && port != defaultPort {
+		host = net.JoinHostPort(host, port)
+	}
+	return scheme + "://" + host
+}
+
+// tlsConfig builds the public listener's TLS configuration, and for ACME
+// the handler answering HTTP-01 challenges. It returns nil for plain HTTP.
+func (cfg ListenConfig) tlsConfig() (*tls.Config, http.Handler, error) {
+	switch {
+	case cfg.TLSCertFile != "" || cfg.TLSKeyFile != "":
+		if len(cfg.ACMEDomains) > 0 {
+			return nil, nil, fmt.Errorf("TLS certificate files and ACME domains are mutually exclusive")
+		}
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			return nil, nil, fmt.Errorf("TLS needs both a certificate and a key")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil, nil
+
+	case len(cfg.ACMEDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+			Cache:      autocert.DirCache(cfg.ACMECacheDir),
+			Email:      cfg.ACMEEmail,
+		}
+		config := manager.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+		return config, manager.HTTPHandler(nil), nil
+	}
+	return nil, nil, nil
+}
+
+// publicRouter builds the routes served to nodes and dashboard visitors
+func (ts *TrackerService) publicRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	// API routes
+	api := r.PathPrefix("/api/v1").Subrouter()
+	limits := ts.limiter.cfg
+	api.HandleFunc("/register", countResults(&ts.counters.registrations,
+		ts.rateLimited("register", limits.RegistrationsPerIP, limits.RegistrationsPerNode, ts.handleRegister))).Methods("POST")
+	api.HandleFunc("/heartbeat", countResults(&ts.counters.heartbeats,
+		ts.rateLimited("heartbeat", limits.HeartbeatsPerIP, limits.HeartbeatsPerNode, ts.handleHeartbeat))).Methods("POST")
+	api.HandleFunc("/peers", ts.handleGetPeers).Methods("GET")
+	api.HandleFunc("/stats", ts.handleGetStats).Methods("GET")
+	api.HandleFunc("/stats/history", ts.handleGetStatsHistory).Methods("GET")
+	api.HandleFunc("/nodes", ts.handleGetNodes).Methods("GET")
+	api.HandleFunc("/node/{nodeId}", ts.handleGetNode).Methods("GET")
+	api.HandleFunc("/node/{nodeId}/uptime", ts.handleGetNodeUptime).Methods("GET")
+	api.HandleFunc("/forks", ts.handleGetForks).Methods("GET")
+	api.HandleFunc("/anomalies/duplicate-miners", ts.handleDuplicateMiners).Methods("GET")
+	api.HandleFunc("/chains", ts.handleGetChains).Methods("GET")
+	api.HandleFunc("/map", ts.handleGetMap).Methods("GET")
+	api.HandleFunc("/federation", ts.handleGetFederation).Methods("GET")
+	api.HandleFunc("/federation/sync", ts.handleFederationSync).Methods("POST")
+
+	// Genesis endpoints for node bootstrapping
+	r.HandleFunc("/v1/sxe", ts.handleGetGenesis).Methods("GET")
+	r.HandleFunc("/v1/sxe/{chain}", ts.handleGetGenesis).Methods("GET")
+
+	// Web dashboard routes
+	r.HandleFunc("/", ts.handleDashboard).Methods("GET")
+	r.HandleFunc("/dashboard", ts.handleDashboard).Methods("GET")
+	r.HandleFunc("/tokens", ts.handleTokensPage).Methods("GET")
+	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
+
+	return r
+}
+
+// internalRouter builds the operator-only metrics and admin routes; admin
+// routes also require the admin token
+func (ts *TrackerService) internalRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/metrics", ts.handleMetrics).Methods("GET")
+
+	admin := r.PathPrefix("/api/v1/admin").Subrouter()
+	admin.Use(ts.requireAdmin)
+	admin.HandleFunc("/status", ts.handleAdminStatus).Methods("GET")
+	admin.HandleFunc("/events", ts.handleAdminEvents).Methods("GET")
+	admin.HandleFunc("/node/{nodeId}/tags", ts.handleSetNodeTags).Methods("PUT", "POST")
+	admin.HandleFunc("/node/{nodeId}/tags/{tag}", ts.handleRemoveNodeTag).Methods("DELETE")
+	admin.HandleFunc("/node/{nodeId}", ts.handleRemoveNode).Methods("DELETE")
+	admin.HandleFunc("/alerts", ts.handleAdminAlerts).Methods("GET")
+	admin.HandleFunc("/federation", ts.handleAdminFederation).Methods("GET")
+	admin.HandleFunc("/bans", ts.handleGetBans).Methods("GET")
+	admin.HandleFunc("/bans", ts.handleAddBan).Methods("POST")
+	admin.HandleFunc("/bans/{kind}/{value:.+}", ts.handleRemoveBan).Methods("DELETE")
+	admin.HandleFunc("/chains", ts.handleGetChains).Methods("GET")
+	admin.HandleFunc("/chains/{name}", ts.handlePutChain).Methods("PUT")
+	admin.HandleFunc("/chains/{name}", ts.handleDeleteChain).Methods("DELETE")
+	admin.HandleFunc("/compact", ts.handleCompact).Methods("POST")
+
+	return r
+}
+
+// handleAdminStatus reports the tracker's own process state
+func (ts *TrackerService) handleAdminStatus(w http.ResponseWriter, r *http.Request) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"started_at":     ts.startedAt,
+		"uptime_seconds": int64(time.Since(ts.startedAt).Seconds()),
+		"node_count":     len(ts.nodes),
+	})
+}
+
+// newServers creates the public server and, if configured, the internal
+// one, the gRPC server and the ACME challenge server
+func (ts *TrackerService) newServers(cfg ListenConfig) error {
+	tlsConfig, challenges, err := cfg.tlsConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.TrustedProxies != nil {
+		ts.proxies = cfg.TrustedProxies
+	}
+
+	ts.server = &http.Server{
+		Addr:         cfg.PublicAddr,
+		Handler:      ts.publicRouter(),
+		TLSConfig:    tlsConfig,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+
+	ts.internalServer = nil
+	if cfg.InternalAddr != "" {
+		ts.internalServer = &http.Server{
+			Addr:         cfg.InternalAddr,
+			Handler:      ts.internalRouter(),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+		}
+	}
+
+	ts.grpcServer, ts.grpcAddr = nil, cfg.GRPCAddr
+	if cfg.GRPCAddr != "" {
+		var opts []grpc.ServerOption
+		if tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		maxWatches, watchIdle := cfg.GRPCMaxWatches, cfg.GRPCWatchIdle
+		if maxWatches <= 0 {
+			maxWatches = defaultMaxPeerWatches
+		}
+		if watchIdle <= 0 {
+			watchIdle = defaultPeerWatchIdleFor
+		}
+		ts.grpcServer = ts.newGRPCServer(maxWatches, watchIdle, opts...)
+	}
+
+	ts.challengeServer = nil
+	if challenges != nil && cfg.ACMEHTTPAddr != "" {
+		ts.challengeServer = &http.Server{
+			Addr:         cfg.ACMEHTTPAddr,
+			Handler:      challenges,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+		}
+	}
+	return nil
+}
+
+// serve runs every configured listener until ctx is cancelled or one of them
+// fails, then shuts all of them down gracefully
+func (ts *TrackerService) serve(ctx context.Context) error {
+	servers := []*http.Server{ts.server}
+	if ts.internalServer != nil {
+		servers = append(servers, ts.internalServer)
+	}
+	if ts.challengeServer != nil {
+		servers = append(servers, ts.challengeServer)
+	}
+
+	errCh := make(chan error, len(servers)+1)
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			var err error
+			if srv.TLSConfig != nil {
+				err = srv.ListenAndServeTLS("", "")
+			} else {
+				err = srv.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				errCh <- fmt.Errorf("listener %s: %w", srv.Addr, err)
+			}
+		}(srv)
+	}
+
+	if ts.grpcServer != nil {
+		listener, err := net.Listen("tcp", ts.grpcAddr)
+		if err != nil {
+			errCh <- fmt.Errorf("listener %s: %w", ts.grpcAddr, err)
+		} else {
+			go func() {
+				if err := ts.grpcServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+					errCh <- fmt.Errorf("listener %s: %w", ts.grpcAddr, err)
+				}
+			}()
+		}
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-errCh:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("⚠️ Failed to shut down listener %s: %v", srv.Addr, err)
+		}
+	}
+	if ts.grpcServer != nil {
+		// Peer watches never finish on their own, so they are cut off
+		// once the timeout is up
+		stopped := make(chan struct{})
+		go func() {
+			ts.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			ts.grpcServer.Stop()
+		}
+	}
+
+	return serveErr
+}
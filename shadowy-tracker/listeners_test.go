@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminAndMetricsOnlyOnInternalListener(t *testing.T) {
+	ts := NewTrackerService()
+	ts.adminToken = testAdminToken
+	addTestNode(ts, "node-1", 100, tib)
+
+	public := httptest.NewServer(ts.publicRouter())
+	defer public.Close()
+	internal := httptest.NewServer(ts.internalRouter())
+	defer internal.Close()
+
+	for _, path := range []string{"/metrics", "/api/v1/admin/status"} {
+		resp, err := http.Get(public.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s on public listener failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected %s to be unavailable on the public listener, got %d", path, resp.StatusCode)
+		}
+
+		req, _ := http.NewRequest("GET", internal.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s on internal listener failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected %s on the internal listener, got %d", path, resp.StatusCode)
+		}
+	}
+
+	// Public routes stay on the public listener
+	resp, err := http.Get(internal.URL + "/api/v1/peers")
+	if err != nil {
+		t.Fatalf("GET /api/v1/peers on internal listener failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected public routes to be absent from the internal listener, got %d", resp.StatusCode)
+	}
+}
+
+func TestMetricsReportNodeCounts(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-1", 100, tib)
+	addTestNode(ts, "node-2", 100, tib)
+
+	rec := httptest.NewRecorder()
+	ts.internalRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{"shadowy_tracker_nodes_total 2", "shadowy_tracker_netspace_bytes 2199023255552"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestListenConfigFromEnv(t *testing.T) {
+	t.Setenv("TRACKER_LISTEN_ADDR", ":9000")
+	t.Setenv("TRACKER_INTERNAL_ADDR", "off")
+	t.Setenv("TRACKER_GRPC_ADDR", "")
+
+	cfg := listenConfigFromEnv()
+	if cfg.PublicAddr != ":9000" || cfg.InternalAddr != "" || cfg.GRPCAddr != "" {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+
+	ts := NewTrackerService()
+	if err := ts.newServers(cfg); err != nil {
+		t.Fatalf("newServers failed: %v", err)
+	}
+	if ts.internalServer != nil {
+		t.Error("Internal listener should be disabled")
+	}
+	if ts.grpcServer != nil {
+		t.Error("gRPC listener should be off unless configured")
+	}
+
+	t.Setenv("TRACKER_GRPC_ADDR", ":9002")
+	t.Setenv("TRACKER_GRPC_MAX_WATCHES", "8")
+	t.Setenv("TRACKER_GRPC_WATCH_IDLE", "1m")
+	cfg = listenConfigFromEnv()
+	if cfg.GRPCAddr != ":9002" || cfg.GRPCMaxWatches != 8 || cfg.GRPCWatchIdle != time.Minute {
+		t.Errorf("Unexpected gRPC config: %+v", cfg)
+	}
+	if err := ts.newServers(cfg); err != nil {
+		t.Fatalf("newServers failed: %v", err)
+	}
+	if ts.grpcServer == nil {
+		t.Error("gRPC listener should be enabled by TRACKER_GRPC_ADDR")
+	}
+}
+
+func TestServeShutsDownBothListeners(t *testing.T) {
+	ts := NewTrackerService()
+	if err := ts.newServers(ListenConfig{PublicAddr: "127.0.0.1:0", InternalAddr: "127.0.0.1:0"}); err != nil {
+		t.Fatalf("newServers failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ts.serve(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listeners did not shut down")
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and
+// its key, returning their paths
+func writeTestCertificate(t *testing.T) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tracker.test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestPublicListenerServesTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	ts := NewTrackerService()
+	if err := ts.newServers(ListenConfig{PublicAddr: "127.0.0.1:0", TLSCertFile: certFile, TLSKeyFile: keyFile}); err != nil {
+		t.Fatalf("newServers failed: %v", err)
+	}
+	if ts.server.TLSConfig == nil || len(ts.server.TLSConfig.Certificates) != 1 {
+		t.Fatal("Expected the public listener to carry the certificate")
+	}
+
+	server := httptest.NewUnstartedServer(ts.server.Handler)
+	server.TLS = ts.server.TLSConfig
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get(server.URL + "/api/v1/stats")
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Errorf("Expected stats over TLS, got %d", resp.StatusCode)
+	}
+}
+
+func TestTLSConfigRejectsConflictingSettings(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	for _, cfg := range []ListenConfig{
+		{TLSCertFile: certFile},
+		{TLSKeyFile: keyFile},
+		{TLSCertFile: certFile, TLSKeyFile: keyFile, ACMEDomains: []string{"tracker.example.com"}},
+		{TLSCertFile: keyFile, TLSKeyFile: certFile},
+	} {
+		if err := NewTrackerService().newServers(cfg); err == nil {
+			t.Errorf("Expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+func TestACMEListenerConfig(t *testing.T) {
+	ts := NewTrackerService()
+	cfg := ListenConfig{
+		PublicAddr:   ":443",
+		ACMEDomains:  []string{"tracker.example.com"},
+		ACMECacheDir: t.TempDir(),
+		ACMEHTTPAddr: ":80",
+	}
+	if err := ts.newServers(cfg); err != nil {
+		t.Fatalf("newServers failed: %v", err)
+	}
+	if ts.server.TLSConfig == nil || ts.server.TLSConfig.GetCertificate == nil {
+		t.Error("Expected certificates to come from ACME")
+	}
+	if ts.challengeServer == nil || ts.challengeServer.Addr != ":80" {
+		t.Error("Expected an HTTP-01 challenge listener")
+	}
+	if url := cfg.PublicURL(); url != "https://localhost" {
+		t.Errorf("Unexpected public URL %s", url)
+	}
+}
+
+func TestListenConfigTLSFromEnv(t *testing.T) {
+	t.Setenv("TRACKER_LISTEN_ADDR", ":8443")
+	t.Setenv("TRACKER_ACME_DOMAINS", "tracker.example.com, seed.example.com")
+	t.Setenv("TRACKER_TRUSTED_PROXIES", "203.0.113.10, 2001:db8::/32")
+
+	cfg := listenConfigFromEnv()
+	if len(cfg.ACMEDomains) != 2 || cfg.Hostname != "tracker.example.com" {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+	if url := cfg.PublicURL(); url != "https://tracker.example.com:8443" {
+		t.Errorf("Unexpected public URL %s", url)
+	}
+
+	ts := NewTrackerService()
+	if err := ts.newServers(cfg); err != nil {
+		t.Fatalf("newServers failed: %v", err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.10:4000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := ts.clientIP(r); got != "198.51.100.1" {
+		t.Errorf("Expected the configured proxy to be trusted, got %s", got)
+	}
+	r.RemoteAddr = "10.0.0.1:4000"
+	if got := ts.clientIP(r); got != "10.0.0.1" {
+		t.Errorf("Expected private networks to lose trust once proxies are configured, got %s", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := parseTrustedProxies("none")
+	if err != nil || len(proxies) != 0 {
+		t.Errorf("Expected none to trust nothing, got %v %v", proxies, err)
+	}
+	for _, value := range []string{"10.0.0.0/33", "proxy.local"} {
+		if _, err := parseTrustedProxies(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
@@ -1,22 +1,55 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
 )
 
 // TrackerService manages network peer discovery and statistics
 type TrackerService struct {
+	mu       sync.RWMutex // Guards nodes, registry and the nodes in them
 	nodes    map[string]*RegisteredNode
 	registry *NodeRegistry
 	server   *http.Server
+
+	internalServer  *http.Server // Metrics and admin, see ListenConfig
+	challengeServer *http.Server // ACME HTTP-01 challenges, nil when unused
+	startedAt       time.Time
+
+	duplicatePolicy DuplicatePolicy // Netspace counting for shared mining addresses
+	cleanup         CleanupConfig   // Offline node purging
+	signatures      SignaturePolicy // Registration and heartbeat authentication
+	missedAfter     time.Duration   // Heartbeat silence that counts against uptime
+	events          *EventLog       // Audit trail, nil when disabled
+	store           *NodeStore      // Persistent registry, nil when disabled
+	chains          *ChainRegistry  // Chains nodes may register for
+	bans            *BanList        // Nodes excluded from peer discovery
+	geo             GeoLocator      // Node geography, nil when disabled
+	counters        requestCounters // Node requests served, for /metrics
+	limiter         *RateLimiter    // Per-IP and per-node request limits
+	alerts          *AlertMonitor   // Alert rules, nil when not configured
+	proxies         TrustedProxies  // Whose X-Forwarded-For to believe
+	versions        VersionPolicy   // Oldest node software still current
+	federation      *Federation     // Registry exchange with other trackers, nil when alone
+	probes          ProbeConfig     // Reachability checks of node ports
+	adminToken      string          // Bearer token for the admin API, empty disables it
+	peerChanges     *changeNotifier // Wakes gRPC peer watches
+	grpcServer      *grpc.Server    // Tracker API over gRPC, nil when disabled
+	grpcAddr        string
 }
 
 // RegisteredNode represents a registered blockchain node
@@ -33,15 +66,17 @@ type RegisteredNode struct {
 	HTTPPort   int    `json:"http_port"`
 
 	// Chain state
-	ChainHeight   uint64    `json:"chain_height"`
-	ChainHash     string    `json:"chain_hash"`
-	ChainID       string    `json:"chain_id"`
-	LastBlockTime time.Time `json:"last_block_time"`
+	ChainHeight   uint64     `json:"chain_height"`
+	ChainHash     string     `json:"chain_hash"`
+	ChainID       string     `json:"chain_id"`
+	LastBlockTime time.Time  `json:"last_block_time"`
+	RecentBlocks  []BlockRef `json:"recent_blocks,omitempty"` // Past tips, for fork detection
 
 	// System info
 	SoftwareVersion string `json:"software_version"`
 	OSVersion       string `json:"os_version"`
 	Architecture    string `json:"architecture"`
+	Deprecated      bool   `json:"deprecated,omitempty"` // Older than the version policy allows
 
 	// Farming info
 	TotalPlotSize uint64 `json:"total_plot_size_bytes"`
@@ -52,6 +87,28 @@ type RegisteredNode struct {
 	RegisteredAt  time.Time `json:"registered_at"`
 	LastHeartbeat time.Time `json:"last_heartbeat"`
 	Status        string    `json:"status"` // "online", "offline", "syncing"
+
+	// Timestamp of the last signed request accepted, for replay protection
+	LastSignedAt time.Time `json:"last_signed_at"`
+
+	// Operator annotations such as datacenter, region or role
+	Tags []string `json:"tags,omitempty"`
+
+	// Reputation, lowered by penalties and recovered by clean heartbeats;
+	// see scoring.go
+	Score     int            `json:"score"`
+	Penalties map[string]int `json:"penalties,omitempty"` // Count by reason
+
+	// Location of the observed IP, when a GeoIP database is configured
+	Geo *GeoInfo `json:"geo,omitempty"`
+
+	// Federated tracker the record was learned from, empty when the node
+	// talks to this tracker directly
+	Via string `json:"via,omitempty"`
+
+	// Whether the tracker could dial the node's ports, nil until probed;
+	// see probes.go
+	Reachability *Reachability `json:"reachability,omitempty"`
 }
 
 // RegistrationRequest represents a node registration request
@@ -90,14 +147,43 @@ type HeartbeatRequest struct {
 
 // NetworkStats represents overall network statistics
 type NetworkStats struct {
+	Chain           string `json:"chain,omitempty"` // Empty when covering every chain
 	TotalNodes      int    `json:"total_nodes"`
 	OnlineNodes     int    `json:"online_nodes"`
 	SyncingNodes    int    `json:"syncing_nodes"`
 	TotalNetspace   uint64 `json:"total_netspace_bytes"`
 	HighestHeight   uint64 `json:"highest_height"`
-	ConsensusHeight uint64 `json:"consensus_height"`
-	ForkCount       int    `json:"fork_count"`
+	ConsensusHeight uint64 `json:"consensus_height"` // Height backed by the most nodes
+	ForkCount       int    `json:"fork_count"`       // Branches competing with the main one, see detectForks
 	LastUpdated     string `json:"last_updated"`
+
+	// Netspace-weighted consensus: the height backed by the most plot storage,
+	// which better tracks the heaviest chain in proof-of-storage
+	NetspaceConsensusHeight   uint64 `json:"netspace_consensus_height"`
+	NetspaceConsensusNetspace uint64 `json:"netspace_consensus_netspace_bytes"`
+
+	// Mining addresses registered under several node_ids. Under the largest
+	// policy DuplicateNetspace is what was left out of the totals; under the
+	// sum policy it is what was double counted.
+	DuplicateMiners   int    `json:"duplicate_miners"`
+	DuplicateNetspace uint64 `json:"duplicate_netspace_bytes"`
+	NetspaceWarning   string `json:"netspace_warning,omitempty"`
+
+	// Nodes and netspace by country and by autonomous system, largest
+	// first; empty unless GeoIP enrichment is enabled
+	Countries []GeoCount `json:"countries,omitempty"`
+	ASNs      []GeoCount `json:"asns,omitempty"`
+
+	// Nodes by software version, newest first, and those running software
+	// older than MinVersion
+	Versions        []VersionCount `json:"versions"`
+	MinVersion      string         `json:"min_version,omitempty"`
+	DeprecatedNodes int            `json:"deprecated_nodes"`
+
+	// Nodes whose P2P port the tracker could not dial, and nodes reporting
+	// an IP other than the one they connect from
+	UnreachableNodes int `json:"unreachable_nodes"`
+	NATNodes         int `json:"nat_nodes"`
 }
 
 // NodeRegistry manages the collection of registered nodes
@@ -107,8 +193,20 @@ type NodeRegistry struct {
 
 func NewTrackerService() *TrackerService {
 	return &TrackerService{
-		nodes:    make(map[string]*RegisteredNode),
-		registry: &NodeRegistry{nodes: make(map[string]*RegisteredNode)},
+		nodes:     make(map[string]*RegisteredNode),
+		registry:  &NodeRegistry{nodes: make(map[string]*RegisteredNode)},
+		startedAt: time.Now(),
+
+		duplicatePolicy: DuplicatePolicyLargest,
+		cleanup:         defaultCleanupConfig(),
+		signatures:      defaultSignaturePolicy(),
+		missedAfter:     defaultMissedHeartbeatAfter,
+		chains:          builtinChains(),
+		bans:            NewBanList(),
+		limiter:         NewRateLimiter(defaultRateLimitConfig()),
+		proxies:         defaultTrustedProxies(),
+		probes:          defaultProbeConfig(),
+		peerChanges:     newChangeNotifier(),
 	}
 }
 
@@ -116,87 +214,156 @@ func main() {
 	log.Println("🚀 Starting Shadowy Network Tracker Service")
 
 	tracker := NewTrackerService()
+	tracker.duplicatePolicy = duplicatePolicyFromEnv()
+	tracker.cleanup = cleanupConfigFromEnv()
+	tracker.signatures = signaturePolicyFromEnv()
+	tracker.missedAfter = missedAfterFromEnv()
+	tracker.versions = versionPolicyFromEnv()
+	tracker.probes = probeConfigFromEnv()
+	tracker.adminToken = adminTokenFromEnv()
+	tracker.chains = chainRegistryFromEnv()
+	tracker.limiter = NewRateLimiter(rateLimitConfigFromEnv())
+	tracker.alerts = alertMonitorFromEnv(tracker.chains)
+	tracker.events = eventLogFromEnv()
+	defer tracker.events.Close()
+	tracker.geo = geoLocatorFromEnv()
+	if tracker.geo != nil {
+		defer tracker.geo.Close()
+	}
+	tracker.store = nodeStoreFromEnv()
+	defer tracker.store.Close()
+	if err := tracker.loadNodes(); err != nil {
+		log.Printf("⚠️ Failed to restore registered nodes: %v", err)
+	}
+	if err := tracker.loadBans(); err != nil {
+		log.Printf("⚠️ Failed to restore bans: %v", err)
+	}
+	tracker.federation = federationFromEnv()
 
-	// Set up HTTP routes
-	r := mux.NewRouter()
-
-	// API routes
-	api := r.PathPrefix("/api/v1").Subrouter()
-	api.HandleFunc("/register", tracker.handleRegister).Methods("POST")
-	api.HandleFunc("/heartbeat", tracker.handleHeartbeat).Methods("POST")
-	api.HandleFunc("/peers", tracker.handleGetPeers).Methods("GET")
-	api.HandleFunc("/stats", tracker.handleGetStats).Methods("GET")
-	api.HandleFunc("/nodes", tracker.handleGetNodes).Methods("GET")
-	api.HandleFunc("/node/{nodeId}", tracker.handleGetNode).Methods("GET")
-
-	// Genesis endpoint for node bootstrapping
-	r.HandleFunc("/v1/sxe", tracker.handleGetGenesis).Methods("GET")
-
-	// Web dashboard routes
-	r.HandleFunc("/", tracker.handleDashboard).Methods("GET")
-	r.HandleFunc("/dashboard", tracker.handleDashboard).Methods("GET")
-	r.HandleFunc("/tokens", tracker.handleTokensPage).Methods("GET")
-	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
-
-	// Configure server
-	tracker.server = &http.Server{
-		Addr:         ":8090",
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+	// Public and internal listeners
+	cfg := listenConfigFromEnv()
+	if err := tracker.newServers(cfg); err != nil {
+		log.Fatalf("❌ Invalid listener configuration: %v", err)
 	}
 
 	// Start cleanup routine
 	go tracker.cleanupOfflineNodes()
+	if tracker.store != nil {
+		go tracker.recordStatsHistory()
+	}
+	if tracker.alerts != nil {
+		go tracker.monitorAlerts()
+	}
+	if tracker.federation != nil {
+		go tracker.runFederation()
+	}
+	if tracker.probes.Interval > 0 {
+		go tracker.probeNodes()
+	}
 
-	log.Println("📡 Tracker service listening on :8090")
+	log.Printf("📡 Tracker service listening on %s (%s)", cfg.PublicAddr, cfg.PublicURL())
+	if len(cfg.ACMEDomains) > 0 {
+		log.Printf("🔐 Obtaining certificates for %s", strings.Join(cfg.ACMEDomains, ", "))
+	}
+	if tracker.challengeServer != nil {
+		log.Printf("🔐 ACME challenges served on %s", cfg.ACMEHTTPAddr)
+	}
+	if cfg.InternalAddr != "" {
+		log.Printf("🔒 Metrics and admin listening on %s", cfg.InternalAddr)
+	}
+	if cfg.GRPCAddr != "" {
+		log.Printf("📡 gRPC API listening on %s", cfg.GRPCAddr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err := tracker.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := tracker.serve(ctx); err != nil {
 		log.Fatalf("❌ Failed to start server: %v", err)
 	}
+	log.Println("👋 Tracker service stopped")
 }
 
 // handleRegister processes node registration requests
 func (ts *TrackerService) handleRegister(w http.ResponseWriter, r *http.Request) {
 	var req RegistrationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeValidationError(w, "Invalid request body", decodeErrorFields(err))
+		return
+	}
+
+	// Report every malformed field at once instead of a generic 400
+	if fieldErrs := ValidateRegistration(&req); len(fieldErrs) > 0 {
+		log.Printf("Rejected registration for %q: %d invalid fields", req.NodeID, len(fieldErrs))
+		ts.events.Record(Event{Type: EventRegisterRejected, NodeID: req.NodeID, IP: ts.clientIP(r),
+			Detail: fmt.Sprintf("%d invalid fields", len(fieldErrs))})
+		writeValidationError(w, "Invalid registration", fieldErrs)
 		return
 	}
 
 	// Verify signature against mining address
-	if err := VerifyRegistrationSignature(&req); err != nil {
+	if err := VerifyRegistrationSignature(&req, ts.signatures); err != nil {
 		log.Printf("Registration signature verification failed for %s: %v", req.NodeID, err)
+		ts.events.Record(Event{Type: EventRegisterRejected, NodeID: req.NodeID, IP: ts.clientIP(r),
+			Detail: "invalid signature"})
 		http.Error(w, "Invalid signature", http.StatusUnauthorized)
 		return
 	}
-	
-	if req.ChainID != testnet0 {
+
+	chain := ts.chains.Resolve(req.ChainID)
+	if chain == nil {
 		log.Printf("client connecting with unknown chain for this tracker: %s", req.ChainID)
+		ts.events.Record(Event{Type: EventChainMismatch, NodeID: req.NodeID, IP: ts.clientIP(r),
+			Detail: "chain " + req.ChainID})
 		http.Error(w, "your genesis block does not match any known active chains", http.StatusBadRequest)
 		return
 	}
 
+	// Extract client's actual IP address
+	clientIP := ts.clientIP(r)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	// A registered node_id stays with its key, and each request is accepted once
+	existing, registered := ts.nodes[req.NodeID]
+	if registered && existing.PublicKey != req.PublicKey {
+		log.Printf("Registration for %s with a different key rejected", req.NodeID)
+		ts.events.Record(Event{Type: EventRegisterRejected, NodeID: req.NodeID, IP: clientIP,
+			Detail: "node_id registered to another key"})
+		http.Error(w, "node_id is registered to another key", http.StatusConflict)
+		return
+	}
+	var lastSignedAt time.Time
+	if registered {
+		lastSignedAt = existing.LastSignedAt
+	}
+	signedAt, err := checkRequestTime(req.Timestamp, lastSignedAt, time.Now(), ts.signatures)
+	if err != nil {
+		log.Printf("Registration for %s rejected: %v", req.NodeID, err)
+		ts.events.Record(Event{Type: EventRegisterRejected, NodeID: req.NodeID, IP: clientIP,
+			Detail: err.Error()})
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
 	// Parse timestamps
 	lastBlockTime, _ := time.Parse(time.RFC3339, req.LastBlockTime)
 
-	// Extract client's actual IP address
-	clientIP := extractClientIP(r)
-
-	log.Printf("Incoming client for chain %s from %s", req.ChainID, clientIP)
+	log.Printf("Incoming client for chain %s from %s", chain.Name, clientIP)
 
 	// Create registered node
 	node := &RegisteredNode{
 		NodeID:          req.NodeID,
 		MiningAddr:      req.MiningAddr,
 		PublicKey:       req.PublicKey,
-		ExternalIP:      req.ExternalIP,
+		ExternalIP:      normalizeIP(req.ExternalIP),
 		ObservedIP:      clientIP,
 		P2PPort:         req.P2PPort,
 		HTTPPort:        req.HTTPPort,
 		ChainHeight:     req.ChainHeight,
 		ChainHash:       req.ChainHash,
-		ChainID:         hash2chain(req.ChainID),
+		ChainID:         chain.Name,
 		LastBlockTime:   lastBlockTime,
 		SoftwareVersion: req.SoftwareVersion,
 		OSVersion:       req.OSVersion,
@@ -207,21 +374,51 @@ func (ts *TrackerService) handleRegister(w http.ResponseWriter, r *http.Request)
 		RegisteredAt:    time.Now(),
 		LastHeartbeat:   time.Now(),
 		Status:          "online",
+		LastSignedAt:    signedAt,
+		Score:           maxNodeScore,
+	}
+
+	// Operator tags and reputation survive re-registration
+	var previousHeartbeat time.Time
+	var previousStatus string
+	if registered {
+		node.Tags = existing.Tags
+		node.Score, node.Penalties = existing.Score, existing.Penalties
+		node.RecentBlocks = existing.RecentBlocks
+		node.keepReachability(existing)
+		previousHeartbeat, previousStatus = existing.LastHeartbeat, existing.Status
+	}
+
+	node.recordTip()
+	ts.locateNode(node)
+	ts.applyVersionPolicy(node)
+	if node.Deprecated {
+		log.Printf("⚠️ Node %s runs deprecated version %q (minimum %s)", req.NodeID, req.SoftwareVersion, ts.versions.MinVersion)
 	}
 
 	// Store node
 	ts.nodes[req.NodeID] = node
 	ts.registry.nodes[req.NodeID] = node
+	ts.saveNode(node)
+	ts.recordUptime(node, previousHeartbeat, previousStatus)
+	ts.peerChanges.Notify()
+	ts.events.Record(Event{Type: EventRegister, NodeID: req.NodeID, IP: clientIP,
+		Detail: fmt.Sprintf("height %d, %d plots", req.ChainHeight, req.PlotCount)})
 
 	log.Printf("✅ Registered node %s (mining: %s, height: %d, plots: %d)",
 		req.NodeID, req.MiningAddr[:16]+"...", req.ChainHeight, req.PlotCount)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	response := map[string]interface{}{
 		"success": true,
 		"message": "Node registered successfully",
 		"node_id": req.NodeID,
-	})
+	}
+	if node.Deprecated {
+		response["deprecated"] = true
+		response["min_version"] = ts.versions.MinVersion
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
 // handleHeartbeat processes node heartbeat updates
@@ -232,26 +429,57 @@ func (ts *TrackerService) handleHeartbeat(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
 	// Find existing node
 	node, exists := ts.nodes[req.NodeID]
 	if !exists {
+		ts.events.Record(Event{Type: EventHeartbeatRejected, NodeID: req.NodeID, IP: ts.clientIP(r),
+			Detail: "node not registered"})
 		http.Error(w, "Node not registered", http.StatusNotFound)
 		return
 	}
 
+	// Only the key the node registered may send its heartbeats, each once
+	if err := VerifyHeartbeatSignature(&req, node, ts.signatures); err != nil {
+		log.Printf("Heartbeat signature verification failed for %s: %v", req.NodeID, err)
+		ts.events.Record(Event{Type: EventHeartbeatRejected, NodeID: req.NodeID, IP: ts.clientIP(r),
+			Detail: "invalid signature"})
+		ts.penalize(node, PenaltyInvalidSignature, err.Error())
+		ts.saveNode(node)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	signedAt, err := checkRequestTime(req.Timestamp, node.LastSignedAt, time.Now(), ts.signatures)
+	if err != nil {
+		ts.events.Record(Event{Type: EventHeartbeatRejected, NodeID: req.NodeID, IP: ts.clientIP(r),
+			Detail: err.Error()})
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
 	// Update node state
+	previousHeartbeat, previousStatus := node.LastHeartbeat, node.Status
 	lastBlockTime, _ := time.Parse(time.RFC3339, req.LastBlockTime)
 	node.ChainHeight = req.ChainHeight
 	node.ChainHash = req.ChainHash
 	node.LastBlockTime = lastBlockTime
 	node.Status = req.Status
 	node.LastHeartbeat = time.Now()
+	node.LastSignedAt = signedAt
+	node.Via = ""
+	node.recordTip()
 
 	// Update plot information if provided
 	if req.TotalPlotSize > 0 {
 		node.TotalPlotSize = req.TotalPlotSize
 		node.PlotCount = req.PlotCount
 	}
+	ts.scoreHeartbeat(node, previousHeartbeat)
+	ts.saveNode(node)
+	ts.recordUptime(node, previousHeartbeat, previousStatus)
+	ts.peerChanges.Notify()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -260,62 +488,40 @@ func (ts *TrackerService) handleHeartbeat(w http.ResponseWriter, r *http.Request
 	})
 }
 
-// handleGetPeers returns list of peers for node discovery
-func (ts *TrackerService) handleGetPeers(w http.ResponseWriter, r *http.Request) {
-	var activePeers []map[string]interface{}
-
-	// Get requested chain ID from query parameter
-	requestedChainIDRaw := r.URL.Query().Get("chain_id")
-	requestedChainID := hash2chain(requestedChainIDRaw)
-	log.Printf("client wants %s (nee %s)", requestedChainID, requestedChainIDRaw)
-	for _, node := range ts.nodes {
-		if node.Status == "online" && time.Since(node.LastHeartbeat) < 5*time.Minute {
-			// Filter by chain ID if specified
-			if requestedChainID != "" && node.ChainID != requestedChainID {
-				log.Printf("Ignoring node %s with different chain ID %s", node.NodeID, node.ChainID)
-				continue // Skip nodes with different chain IDs
-			}
-
-			// Use observed IP instead of self-reported IP for peer discovery
-			ip := node.ObservedIP
-			if ip == "" || ip == "unknown" {
-				ip = node.ExternalIP // Fallback to self-reported IP
-			}
-
-			peer := map[string]interface{}{
-				"node_id":      node.NodeID,
-				"address":      fmt.Sprintf("%s:%d", ip, node.P2PPort),
-				"client_eth":   fmt.Sprintf("%s:%d", node.ExternalIP, node.HTTPPort),
-				"chain_height": node.ChainHeight,
-				"chain_hash":   node.ChainHash,
-				"chain_id":     node.ChainID,
-				"last_seen":    node.LastHeartbeat,
-			}
-			activePeers = append(activePeers, peer)
-		}
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"peers": activePeers,
-		"count": len(activePeers),
-	})
-}
-
-// handleGetStats returns network statistics
+// handleGetStats returns network statistics, for one chain with ?chain=
 func (ts *TrackerService) handleGetStats(w http.ResponseWriter, r *http.Request) {
-	stats := ts.calculateNetworkStats()
+	chain, ok := ts.requestedChain(r)
+	if !ok {
+		http.Error(w, "Unknown chain", http.StatusNotFound)
+		return
+	}
+	ts.mu.RLock()
+	stats := ts.calculateChainStats(chain)
+	ts.mu.RUnlock()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
 
-// handleGetNodes returns all registered nodes
+// handleGetNodes returns all registered nodes, optionally only those with ?tag=
 func (ts *TrackerService) handleGetNodes(w http.ResponseWriter, r *http.Request) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	nodes := ts.nodes
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		nodes = make(map[string]*RegisteredNode)
+		for id, node := range ts.nodes {
+			if node.hasTag(tag) {
+				nodes[id] = node
+			}
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"nodes": ts.nodes,
-		"count": len(ts.nodes),
+		"nodes": nodes,
+		"count": len(nodes),
 	})
 }
 
@@ -324,6 +530,8 @@ func (ts *TrackerService) handleGetNode(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	nodeID := vars["nodeId"]
 
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
 	node, exists := ts.nodes[nodeID]
 	if !exists {
 		http.Error(w, "Node not found", http.StatusNotFound)
@@ -334,22 +542,44 @@ func (ts *TrackerService) handleGetNode(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(node)
 }
 
-// handleGetGenesis returns the active genesis block for node bootstrapping
+// handleGetGenesis returns a genesis block for node bootstrapping: the
+// chain named or hashed in the path, or the default chain
 func (ts *TrackerService) handleGetGenesis(w http.ResponseWriter, r *http.Request) {
+	chain := ts.chains.Default()
+	if id, ok := mux.Vars(r)["chain"]; ok {
+		chain = ts.chains.Resolve(id)
+	}
+	if chain == nil || len(chain.Genesis) == 0 {
+		http.Error(w, "Genesis not found", http.StatusNotFound)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(activeGenesis))
+	w.Write(chain.Genesis)
 }
 
-// calculateNetworkStats computes overall network statistics
+// calculateNetworkStats computes overall network statistics across chains
 func (ts *TrackerService) calculateNetworkStats() NetworkStats {
+	return ts.calculateChainStats("")
+}
+
+// calculateChainStats computes statistics for the nodes on one chain, or
+// on every chain if chain is empty
+func (ts *TrackerService) calculateChainStats(chain string) NetworkStats {
 	var stats NetworkStats
 	var totalNetspace uint64
 	var maxHeight uint64
 	heightCounts := make(map[uint64]int)
+	heightNetspace := make(map[uint64]uint64)
+
+	nodes := ts.nodesOnChain(chain)
+	duplicates := ts.findDuplicateMiners(nodes)
+	collapsed := ts.collapsedNodes(duplicates)
 
-	stats.TotalNodes = len(ts.nodes)
+	stats.Chain = chain
+	stats.TotalNodes = len(nodes)
 
-	for _, node := range ts.nodes {
+	for _, node := range nodes {
 		// Count online nodes
 		if node.Status == "online" && time.Since(node.LastHeartbeat) < 5*time.Minute {
 			stats.OnlineNodes++
@@ -357,36 +587,60 @@ func (ts *TrackerService) calculateNetworkStats() NetworkStats {
 			stats.SyncingNodes++
 		}
 
-		// Sum total netspace
-		totalNetspace += node.TotalPlotSize
+		// Sum total netspace, leaving out collapsed duplicate miners
+		plotSize := node.TotalPlotSize
+		if collapsed[node.NodeID] {
+			plotSize = 0
+		}
+		totalNetspace += plotSize
 
 		// Track highest height
 		if node.ChainHeight > maxHeight {
 			maxHeight = node.ChainHeight
 		}
 
-		// Count nodes at each height (for consensus calculation)
+		// Count nodes and netspace at each height (for consensus calculation)
 		heightCounts[node.ChainHeight]++
+		heightNetspace[node.ChainHeight] += plotSize
 	}
 
 	stats.TotalNetspace = totalNetspace
 	stats.HighestHeight = maxHeight
 
+	stats.DuplicateMiners = len(duplicates)
+	for _, dup := range duplicates {
+		stats.DuplicateNetspace += dup.TotalPlotSize - dup.LargestPlotSize
+	}
+	if len(duplicates) > 0 && ts.duplicatePolicy == DuplicatePolicySum {
+		stats.NetspaceWarning = duplicateNetspaceWarning(len(duplicates), stats.DuplicateNetspace)
+	}
+
 	// Find consensus height (height with most nodes)
-	var consensusHeight uint64
-	var maxCount int
+	nodeWeights := make(map[uint64]uint64, len(heightCounts))
 	for height, count := range heightCounts {
-		if count > maxCount {
-			maxCount = count
-			consensusHeight = height
-		}
+		nodeWeights[height] = uint64(count)
 	}
-	stats.ConsensusHeight = consensusHeight
+	stats.ConsensusHeight, _ = heaviestHeight(nodeWeights)
 
-	// Count forks (heights with multiple nodes)
-	for _, count := range heightCounts {
-		if count > 1 {
-			stats.ForkCount++
+	// Find netspace-weighted consensus height (height with most plot storage)
+	stats.NetspaceConsensusHeight, stats.NetspaceConsensusNetspace = heaviestHeight(heightNetspace)
+
+	// Count branches competing with the main chain
+	stats.ForkCount = forkCount(detectForks(nodes))
+
+	stats.Countries, stats.ASNs = geographyOf(nodes)
+
+	stats.Versions = versionsOf(nodes)
+	stats.MinVersion = ts.versions.MinVersion
+	for _, node := range nodes {
+		if node.Deprecated {
+			stats.DeprecatedNodes++
+		}
+		if node.Reachability != nil && node.Reachability.Unreachable {
+			stats.UnreachableNodes++
+		}
+		if node.Reachability != nil && node.Reachability.NAT {
+			stats.NATNodes++
 		}
 	}
 
@@ -395,57 +649,147 @@ func (ts *TrackerService) calculateNetworkStats() NetworkStats {
 	return stats
 }
 
-// extractClientIP extracts the client's IP address from the HTTP request
-func extractClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (for proxy/load balancer scenarios)
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		if idx := strings.Index(xff, ","); idx != -1 {
-			return strings.TrimSpace(xff[:idx])
+// heaviestHeight returns the height with the largest weight and that weight.
+// Ties go to the higher height so the result does not depend on map order.
+func heaviestHeight(weights map[uint64]uint64) (uint64, uint64) {
+	var bestHeight, bestWeight uint64
+	for height, weight := range weights {
+		if weight > bestWeight || (weight == bestWeight && height > bestHeight) {
+			bestHeight = height
+			bestWeight = weight
 		}
-		return strings.TrimSpace(xff)
 	}
+	return bestHeight, bestWeight
+}
 
-	// Check X-Real-IP header (common nginx proxy header)
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return strings.TrimSpace(xri)
-	}
+// TrustedProxies are the networks whose forwarding headers are believed.
+// Requests from anywhere else are attributed to their connection's address
+// however they are labelled.
+type TrustedProxies []*net.IPNet
 
-	// Fall back to RemoteAddr from the connection
-	if addr := r.RemoteAddr; addr != "" {
-		// RemoteAddr is in format "IP:port", extract just the IP
-		if host, _, err := net.SplitHostPort(addr); err == nil {
-			return host
+// defaultTrustedProxies trusts loopback and private networks, where a
+// reverse proxy in front of the tracker usually sits
+func defaultTrustedProxies() TrustedProxies {
+	proxies, _ := parseTrustedProxies("127.0.0.0/8,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7")
+	return proxies
+}
+
+// parseTrustedProxies parses a comma separated list of CIDRs and bare IPs;
+// "none" trusts no proxy
+func parseTrustedProxies(value string) (TrustedProxies, error) {
+	proxies := TrustedProxies{}
+	if strings.TrimSpace(value) == "none" {
+		return proxies, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy network %q", entry)
 		}
-		// If splitting fails, return the full address
-		return addr
+		proxies = append(proxies, network)
 	}
-
-	// Default fallback
-	return "unknown"
+	return proxies, nil
 }
 
-// cleanupOfflineNodes removes nodes that haven't sent heartbeats
-func (ts *TrackerService) cleanupOfflineNodes() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+// trusts reports whether addr is inside a trusted proxy network
+func (p TrustedProxies) trusts(addr string) bool {
+	ip := net.ParseIP(normalizeIP(addr))
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
 
-	for range ticker.C {
-		cutoff := time.Now().Add(-10 * time.Minute)
+// ClientIP extracts the client's IP address from the HTTP request. When the
+// connection comes from a trusted proxy, X-Forwarded-For is walked from the
+// nearest hop back to the first address that is not itself a trusted
+// proxy, falling back to X-Real-IP.
+func (p TrustedProxies) ClientIP(r *http.Request) string {
+	remote := normalizeIP(r.RemoteAddr)
+	if remote == "" {
+		return "unknown"
+	}
+	if !p.trusts(remote) {
+		return remote
+	}
 
-		for nodeID, node := range ts.nodes {
-			if node.LastHeartbeat.Before(cutoff) {
-				log.Printf("🧹 Removing offline node %s", nodeID)
-				delete(ts.nodes, nodeID)
-				delete(ts.registry.nodes, nodeID)
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := normalizeIP(hops[i])
+			if hop != "" && (!p.trusts(hop) || i == 0) {
+				return hop
 			}
 		}
 	}
+
+	// Check X-Real-IP header (common nginx proxy header)
+	if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		return normalizeIP(xri)
+	}
+	return remote
 }
 
-// handleDashboard serves the web dashboard
+// clientIP is the address a request came from, seen through the
+// tracker's trusted proxies
+func (ts *TrackerService) clientIP(r *http.Request) string {
+	return ts.proxies.ClientIP(r)
+}
+
+// normalizeIP reduces an address as reported by a node or proxy to a bare
+// IP: ports, IPv6 brackets and zones are dropped and IPv4-mapped IPv6
+// addresses become plain IPv4. Values that are not IPs are returned trimmed.
+func normalizeIP(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	if idx := strings.Index(addr, "%"); idx != -1 {
+		addr = addr[:idx]
+	}
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip.String()
+	}
+	return addr
+}
+
+// peerAddress formats ip and port as a dialable host:port, bracketing IPv6
+func peerAddress(ip string, port int) string {
+	return net.JoinHostPort(normalizeIP(ip), strconv.Itoa(port))
+}
+
+// handleDashboard serves the web dashboard, for one chain with ?chain=
 func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request) {
-	stats := ts.calculateNetworkStats()
+	chain, ok := ts.requestedChain(r)
+	if !ok {
+		http.Error(w, "Unknown chain", http.StatusNotFound)
+		return
+	}
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	stats := ts.calculateChainStats(chain)
 
 	html := fmt.Sprintf(`
 <!DOCTYPE html>
@@ -472,6 +816,10 @@ func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request
         .refresh { margin-bottom: 20px; }
         .refresh button { padding: 10px 20px; background: #4a9eff; color: white; border: none; border-radius: 4px; cursor: pointer; }
         .refresh button:hover { background: #357abd; }
+        .nav { margin-bottom: 20px; }
+        .nav a { color: #4a9eff; text-decoration: none; margin-right: 20px; padding: 8px 12px; border-radius: 4px; }
+        .nav a:hover { background: #2d2d2d; }
+        .nav a.active { background: #4a9eff; color: white; }
     </style>
     <script>
         function formatBytes(bytes) {
@@ -497,6 +845,8 @@ func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request
             <p>Real-time blockchain network monitoring and peer discovery</p>
         </div>
 
+        %s
+
         <div class="refresh">
             <button onclick="refreshData()">&#8634; Refresh</button>
             <span style="margin-left: 10px;">Auto-refresh: 30s</span>
@@ -527,6 +877,10 @@ func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request
                 <div class="stat-value">%d</div>
                 <div class="stat-label">Consensus Height</div>
             </div>
+            <div class="stat-card">
+                <div class="stat-value">%d</div>
+                <div class="stat-label">Netspace Consensus Height</div>
+            </div>
         </div>
 
         <div class="nodes-table">
@@ -545,11 +899,20 @@ func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request
                     </tr>
                 </thead>
                 <tbody>`,
+		ts.chainNav(chain),
 		stats.TotalNodes, stats.OnlineNodes, stats.SyncingNodes,
-		stats.TotalNetspace, stats.HighestHeight, stats.ConsensusHeight)
-
-	// Add node rows
-	for _, node := range ts.nodes {
+		stats.TotalNetspace, stats.HighestHeight, stats.ConsensusHeight,
+		stats.NetspaceConsensusHeight)
+
+	// Add node rows, flagging nodes off the main branch
+	branches := detectForks(ts.nodesOnChain(chain))
+	offMain := make(map[string]bool)
+	for _, branch := range branches {
+		for _, id := range branch.NodeIDs {
+			offMain[id] = !branch.Main
+		}
+	}
+	for _, node := range ts.nodesOnChain(chain) {
 		statusClass := "status-offline"
 		if node.Status == "online" && time.Since(node.LastHeartbeat) < 5*time.Minute {
 			statusClass = "status-online"
@@ -562,6 +925,7 @@ func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request
 		if observedIP == "" {
 			observedIP = "unknown"
 		}
+		observedIP += reachabilityLabel(node)
 		
 		internalIP := node.ExternalIP
 		if internalIP == "" {
@@ -571,8 +935,13 @@ func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request
 		// Format chain ID (show first 8 characters)
 		chainID := node.ChainID
 
+		rowStyle := ""
+		if offMain[node.NodeID] {
+			rowStyle = ` style="background: #3a1f1f;"`
+		}
+
 		html += fmt.Sprintf(`
-                    <tr>
+                    <tr%s>
                         <td>%s</td>
                         <td class="%s">%s</td>
                         <td>%d</td>
@@ -583,7 +952,7 @@ func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request
                         <td>%s</td>
                         <td>%s</td>
                     </tr>`,
-			node.NodeID[:8]+"...", statusClass, node.Status,
+			rowStyle, node.NodeID[:8]+"...", statusClass, node.Status,
 			node.ChainHeight, node.NodeID, node.TotalPlotSize,
 			observedIP, internalIP, chainID,
 			node.SoftwareVersion, node.LastHeartbeat.Format("15:04:05"))
@@ -592,7 +961,20 @@ func (ts *TrackerService) handleDashboard(w http.ResponseWriter, r *http.Request
 	html += `
                 </tbody>
             </table>
-        </div>
+        </div>`
+	html += forkBranchesHTML(branches)
+
+	// Growth charts, when history is being recorded
+	if ts.store != nil {
+		html += historyChartsHTML
+	}
+
+	// World map of farming nodes, when they can be located
+	if ts.geo != nil {
+		html += worldMapHTML
+	}
+
+	html += `
     </div>
 
     <script>
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const tib = uint64(1) << 40
+
+func addTestNode(ts *TrackerService, id string, height uint64, plotSize uint64) {
+	ts.nodes[id] = &RegisteredNode{
+		NodeID:        id,
+		ChainHeight:   height,
+		TotalPlotSize: plotSize,
+		Status:        "online",
+		LastHeartbeat: time.Now(),
+	}
+}
+
+func TestNetspaceOutweighsNodeCount(t *testing.T) {
+	ts := NewTrackerService()
+
+	// Many small farmers on one fork
+	for _, id := range []string{"small-1", "small-2", "small-3", "small-4", "small-5"} {
+		addTestNode(ts, id, 100, 1*tib)
+	}
+	// A few large farmers on another
+	addTestNode(ts, "large-1", 102, 50*tib)
+	addTestNode(ts, "large-2", 102, 40*tib)
+
+	stats := ts.calculateNetworkStats()
+
+	if stats.ConsensusHeight != 100 {
+		t.Errorf("Node-count consensus should follow the 5 small nodes, got %d", stats.ConsensusHeight)
+	}
+	if stats.NetspaceConsensusHeight != 102 {
+		t.Errorf("Netspace consensus should follow the large nodes, got %d", stats.NetspaceConsensusHeight)
+	}
+	if stats.NetspaceConsensusNetspace != 90*tib {
+		t.Errorf("Expected 90 TiB behind netspace consensus, got %d", stats.NetspaceConsensusNetspace)
+	}
+	if stats.TotalNetspace != 95*tib {
+		t.Errorf("Expected 95 TiB total netspace, got %d", stats.TotalNetspace)
+	}
+}
+
+func TestConsensusAgreesWhenNetspaceIsEven(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "a", 200, 2*tib)
+	addTestNode(ts, "b", 200, 2*tib)
+	addTestNode(ts, "c", 199, 2*tib)
+
+	stats := ts.calculateNetworkStats()
+	if stats.ConsensusHeight != 200 || stats.NetspaceConsensusHeight != 200 {
+		t.Errorf("Expected both consensus heights at 200, got %d and %d",
+			stats.ConsensusHeight, stats.NetspaceConsensusHeight)
+	}
+}
+
+func TestHeaviestHeightTieBreak(t *testing.T) {
+	height, weight := heaviestHeight(map[uint64]uint64{10: 5, 12: 5, 11: 3})
+	if height != 12 || weight != 5 {
+		t.Errorf("Ties should resolve to the higher height, got %d (weight %d)", height, weight)
+	}
+
+	if height, weight := heaviestHeight(nil); height != 0 || weight != 0 {
+		t.Errorf("Empty weights should give zero, got %d (weight %d)", height, weight)
+	}
+}
+
+func TestClientIPHandlesIPv6(t *testing.T) {
+	cases := []struct {
+		remoteAddr string
+		header     string
+		value      string
+		want       string
+	}{
+		{"203.0.113.7:51234", "", "", "203.0.113.7"},
+		{"[2001:db8::7]:51234", "", "", "2001:db8::7"},
+		{"[fe80::1%eth0]:51234", "", "", "fe80::1"},
+		{"[::ffff:198.51.100.4]:51234", "", "", "198.51.100.4"},
+		{"10.0.0.1:80", "X-Forwarded-For", "2001:db8::9, 10.0.0.2", "2001:db8::9"},
+		{"10.0.0.1:80", "X-Real-IP", "[2001:db8::a]:443", "2001:db8::a"},
+		{"10.0.0.1:80", "X-Forwarded-For", "198.51.100.1, 2001:db8::9, 10.0.0.2", "2001:db8::9"},
+		{"203.0.113.7:51234", "X-Forwarded-For", "198.51.100.1", "203.0.113.7"},
+		{"203.0.113.7:51234", "X-Real-IP", "198.51.100.1", "203.0.113.7"},
+	}
+	proxies := defaultTrustedProxies()
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remoteAddr
+		if c.header != "" {
+			r.Header.Set(c.header, c.value)
+		}
+		if got := proxies.ClientIP(r); got != c.want {
+			t.Errorf("ClientIP(%s %s=%q) = %q, want %q", c.remoteAddr, c.header, c.value, got, c.want)
+		}
+	}
+}
+
+func TestPeersResponseFormatsAddresses(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "v4", 100, tib)
+	ts.nodes["v4"].ObservedIP = "203.0.113.7"
+	ts.nodes["v4"].ExternalIP = "203.0.113.7"
+	ts.nodes["v4"].P2PPort = 8888
+	ts.nodes["v4"].HTTPPort = 8080
+	addTestNode(ts, "v6", 100, tib)
+	ts.nodes["v6"].ObservedIP = "2001:db8::7"
+	ts.nodes["v6"].ExternalIP = "2001:db8::8"
+	ts.nodes["v6"].P2PPort = 8888
+	ts.nodes["v6"].HTTPPort = 8080
+
+	rec := httptest.NewRecorder()
+	ts.handleGetPeers(rec, httptest.NewRequest("GET", "/api/v1/peers", nil))
+
+	var body struct {
+		Peers []struct {
+			NodeID    string `json:"node_id"`
+			Address   string `json:"address"`
+			ClientEth string `json:"client_eth"`
+		} `json:"peers"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode peers: %v", err)
+	}
+
+	want := map[string][2]string{
+		"v4": {"203.0.113.7:8888", "203.0.113.7:8080"},
+		"v6": {"[2001:db8::7]:8888", "[2001:db8::8]:8080"},
+	}
+	if len(body.Peers) != len(want) {
+		t.Fatalf("Expected %d peers, got %d", len(want), len(body.Peers))
+	}
+	for _, peer := range body.Peers {
+		if got := [2]string{peer.Address, peer.ClientEth}; got != want[peer.NodeID] {
+			t.Errorf("Peer %s formatted as %v, want %v", peer.NodeID, got, want[peer.NodeID])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// resultCounter counts requests by whether they were accepted
+type resultCounter struct {
+	accepted atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// requestCounters counts node requests since the tracker started. They are
+// exported as counters so operators can take rates with rate().
+type requestCounters struct {
+	registrations resultCounter
+	heartbeats    resultCounter
+}
+
+// statusRecorder remembers the status code a handler responded with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// countResults wraps a handler to count its 2xx responses as accepted and
+// everything else as rejected
+func countResults(counter *resultCounter, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		if rec.status < 300 {
+			counter.accepted.Add(1)
+		} else {
+			counter.rejected.Add(1)
+		}
+	}
+}
+
+// handleMetrics exposes network statistics in the Prometheus text format
+func (ts *TrackerService) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	stats := ts.calculateNetworkStats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	gauges := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"shadowy_tracker_nodes_total", "Registered nodes", uint64(stats.TotalNodes)},
+		{"shadowy_tracker_nodes_online", "Nodes reporting online", uint64(stats.OnlineNodes)},
+		{"shadowy_tracker_nodes_syncing", "Nodes reporting syncing", uint64(stats.SyncingNodes)},
+		{"shadowy_tracker_netspace_bytes", "Total reported plot size", stats.TotalNetspace},
+		{"shadowy_tracker_highest_height", "Highest reported chain height", stats.HighestHeight},
+		{"shadowy_tracker_consensus_height", "Chain height backed by the most nodes", stats.ConsensusHeight},
+		{"shadowy_tracker_netspace_consensus_height", "Chain height backed by the most netspace", stats.NetspaceConsensusHeight},
+		{"shadowy_tracker_fork_count", "Branches competing with the main chain", uint64(stats.ForkCount)},
+		{"shadowy_tracker_duplicate_miners", "Mining addresses registered under several node_ids", uint64(stats.DuplicateMiners)},
+		{"shadowy_tracker_deprecated_nodes", "Nodes running software older than the minimum version", uint64(stats.DeprecatedNodes)},
+		{"shadowy_tracker_unreachable_nodes", "Nodes whose P2P port the tracker cannot dial", uint64(stats.UnreachableNodes)},
+		{"shadowy_tracker_nat_nodes", "Nodes reporting an IP other than the one they connect from", uint64(stats.NATNodes)},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", g.name, g.help, g.name, g.name, g.value)
+	}
+
+	// Node counts by software version, so upgrades can be followed
+	versions := make(map[string]int)
+	for _, node := range ts.nodes {
+		versions[node.SoftwareVersion]++
+	}
+	names := make([]string, 0, len(versions))
+	for version := range versions {
+		names = append(names, version)
+	}
+	sort.Strings(names)
+	fmt.Fprint(w, "# HELP shadowy_tracker_nodes_by_version Registered nodes by software version\n# TYPE shadowy_tracker_nodes_by_version gauge\n")
+	for _, version := range names {
+		fmt.Fprintf(w, "shadowy_tracker_nodes_by_version{version=\"%s\"} %d\n", escapeLabelValue(version), versions[version])
+	}
+
+	counters := []struct {
+		name    string
+		help    string
+		counter *resultCounter
+	}{
+		{"shadowy_tracker_registrations_total", "Registration requests by result", &ts.counters.registrations},
+		{"shadowy_tracker_heartbeats_total", "Heartbeat requests by result", &ts.counters.heartbeats},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		fmt.Fprintf(w, "%s{result=\"accepted\"} %d\n", c.name, c.counter.accepted.Load())
+		fmt.Fprintf(w, "%s{result=\"rejected\"} %d\n", c.name, c.counter.rejected.Load())
+	}
+}
+
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsVersionsAndRequestCounts(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-a", 100, tib)
+	addTestNode(ts, "node-b", 100, tib)
+	ts.nodes["node-a"].SoftwareVersion = "0.9.0"
+	ts.nodes["node-b"].SoftwareVersion = `1.0.0"rc`
+	ts.nodes["node-a"].ChainHash, ts.nodes["node-b"].ChainHash = "aa", "bb"
+
+	public := ts.publicRouter()
+	body, _ := json.Marshal(validRegistration())
+	for _, payload := range [][]byte{body, []byte(`{"node_id":`)} {
+		public.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/register", bytes.NewReader(payload)))
+	}
+	public.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/heartbeat", strings.NewReader(`{"node_id":"nobody"}`)))
+
+	rec := httptest.NewRecorder()
+	ts.internalRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	metrics := rec.Body.String()
+	for _, want := range []string{
+		"shadowy_tracker_fork_count 1",
+		`shadowy_tracker_nodes_by_version{version="0.9.0"} 1`,
+		`shadowy_tracker_nodes_by_version{version="1.0.0"} 1`,
+		`shadowy_tracker_nodes_by_version{version="1.0.0\"rc"} 1`,
+		"# TYPE shadowy_tracker_registrations_total counter",
+		`shadowy_tracker_registrations_total{result="accepted"} 1`,
+		`shadowy_tracker_registrations_total{result="rejected"} 1`,
+		`shadowy_tracker_heartbeats_total{result="accepted"} 0`,
+		`shadowy_tracker_heartbeats_total{result="rejected"} 1`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, metrics)
+		}
+	}
+}
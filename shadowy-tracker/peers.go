@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxPeerLimit      = 1000
+	defaultPeerSample = 16 // Peers handed out by ?random=true without a limit
+)
+
+// peerQuery narrows /api/v1/peers. Peers are listed best scored first and
+// paged with Limit and Offset, or with Random a fresh sample of Limit peers
+// is drawn on every request so bootstrapping nodes spread out over the
+// network instead of all dialling the top of the list.
+type peerQuery struct {
+	Chain     string
+	MinHeight uint64
+	Exclude   map[string]bool
+	Limit     int // 0 is no limit
+	Offset    int
+	Random    bool
+
+	// Deprecated nodes are left out for clients that are themselves
+	// running a current version
+	SkipDeprecated bool
+}
+
+// parsePeerQuery reads chain_id (or chain), min_height, exclude (repeated
+// or comma separated node IDs), limit, offset, random and the asking
+// client's version
+func (ts *TrackerService) parsePeerQuery(values url.Values) (peerQuery, []FieldError) {
+	query := peerQuery{Exclude: make(map[string]bool)}
+	var fields []FieldError
+
+	// Chain by genesis hash or name
+	requested := values.Get("chain_id")
+	if requested == "" {
+		requested = values.Get("chain")
+	}
+	query.Chain = ts.chainName(requested)
+
+	if value := values.Get("min_height"); value != "" {
+		height, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "min_height", Message: "must be a block height"})
+		}
+		query.MinHeight = height
+	}
+	for _, value := range values["exclude"] {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				query.Exclude[id] = true
+			}
+		}
+	}
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPeerLimit {
+			fields = append(fields, FieldError{Field: "limit", Message: "must be between 1 and " + strconv.Itoa(maxPeerLimit)})
+		}
+		query.Limit = limit
+	}
+	if value := values.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			fields = append(fields, FieldError{Field: "offset", Message: "must be zero or more"})
+		}
+		query.Offset = offset
+	}
+	if value := values.Get("random"); value != "" {
+		random, err := strconv.ParseBool(value)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "random", Message: "must be true or false"})
+		}
+		query.Random = random
+	}
+	if version := values.Get("version"); version != "" {
+		query.SkipDeprecated = !ts.versions.Deprecated(version)
+	}
+	if query.Random && query.Offset > 0 {
+		fields = append(fields, FieldError{Field: "offset", Message: "cannot page a random sample"})
+	}
+	if query.Random && query.Limit == 0 {
+		query.Limit = defaultPeerSample
+	}
+	return query, fields
+}
+
+// selectPeers returns the online, unbanned nodes matching query in the
+// order they are handed out, and how many matched before paging
+func (ts *TrackerService) selectPeers(query peerQuery) ([]*RegisteredNode, int) {
+	var candidates []*RegisteredNode
+	for _, node := range ts.nodes {
+		if node.Status != "online" || time.Since(node.LastHeartbeat) >= 5*time.Minute {
+			continue
+		}
+		// Filter by chain ID if specified
+		if query.Chain != "" && node.ChainID != query.Chain {
+			continue
+		}
+		if node.ChainHeight < query.MinHeight || query.Exclude[node.NodeID] || (query.SkipDeprecated && node.Deprecated) {
+			continue
+		}
+		if ban := ts.bans.Match(node); ban != nil {
+			continue // Banned by an operator
+		}
+		if node.Reachability != nil && node.Reachability.Unreachable {
+			continue // Peers could not connect to it either
+		}
+		candidates = append(candidates, node)
+	}
+	total := len(candidates)
+
+	if query.Random {
+		rand.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+	} else {
+		// Best scored peers first
+		sort.Slice(candidates, func(i, j int) bool {
+			if candidates[i].Score != candidates[j].Score {
+				return candidates[i].Score > candidates[j].Score
+			}
+			return candidates[i].NodeID < candidates[j].NodeID
+		})
+	}
+
+	if query.Offset >= len(candidates) {
+		return nil, total
+	}
+	candidates = candidates[query.Offset:]
+	if query.Limit > 0 && query.Limit < len(candidates) {
+		candidates = candidates[:query.Limit]
+	}
+	return candidates, total
+}
+
+// handleGetPeers returns list of peers for node discovery
+func (ts *TrackerService) handleGetPeers(w http.ResponseWriter, r *http.Request) {
+	query, fields := ts.parsePeerQuery(r.URL.Query())
+	if len(fields) > 0 {
+		writeValidationError(w, "Invalid peer query", fields)
+		return
+	}
+	log.Printf("client wants %s (nee %s)", query.Chain, r.URL.Query().Get("chain_id"))
+
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	nodes, total := ts.selectPeers(query)
+	var activePeers []map[string]interface{}
+	for _, node := range nodes {
+		address, clientEth := peerAddresses(node)
+		peer := map[string]interface{}{
+			"node_id":      node.NodeID,
+			"address":      address,
+			"client_eth":   clientEth,
+			"chain_height": node.ChainHeight,
+			"chain_hash":   node.ChainHash,
+			"chain_id":     node.ChainID,
+			"last_seen":    node.LastHeartbeat,
+			"score":        node.Score,
+		}
+		activePeers = append(activePeers, peer)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"peers": activePeers,
+		"count": len(activePeers),
+		"total": total,
+	})
+}
+
+// peerAddresses returns where peers reach a node: its P2P address at the
+// IP the tracker observed, and its HTTP API at the IP it reported
+func peerAddresses(node *RegisteredNode) (address, clientEth string) {
+	// Use observed IP instead of self-reported IP for peer discovery
+	ip := node.ObservedIP
+	if ip == "" || ip == "unknown" {
+		ip = node.ExternalIP // Fallback to self-reported IP
+	}
+	return peerAddress(ip, node.P2PPort), peerAddress(node.ExternalIP, node.HTTPPort)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// queryPeers fetches /api/v1/peers?query, returning the peer IDs in order
+// and the total matching before paging
+func queryPeers(t *testing.T, ts *TrackerService, query string) ([]string, int) {
+	rec := httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/peers?"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET peers?%s failed: %d %s", query, rec.Code, rec.Body)
+	}
+	var resp struct {
+		Peers []struct {
+			NodeID string `json:"node_id"`
+		}
+		Count int
+		Total int
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode peers: %v", err)
+	}
+	var ids []string
+	for _, peer := range resp.Peers {
+		ids = append(ids, peer.NodeID)
+	}
+	if resp.Count != len(ids) {
+		t.Errorf("Count %d does not match %d peers", resp.Count, len(ids))
+	}
+	return ids, resp.Total
+}
+
+func addPeerNodes(ts *TrackerService, n int) {
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("node-%02d", i)
+		addTestNode(ts, id, uint64(100+i), tib)
+		ts.nodes[id].Score = maxNodeScore - i
+	}
+}
+
+func TestPeersFilterAndPage(t *testing.T) {
+	ts := NewTrackerService()
+	addPeerNodes(ts, 10)
+
+	ids, total := queryPeers(t, ts, "limit=3")
+	if strings.Join(ids, ",") != "node-00,node-01,node-02" || total != 10 {
+		t.Errorf("Expected the three best peers of 10, got %v of %d", ids, total)
+	}
+	ids, _ = queryPeers(t, ts, "limit=3&offset=3")
+	if strings.Join(ids, ",") != "node-03,node-04,node-05" {
+		t.Errorf("Expected the second page, got %v", ids)
+	}
+	if ids, _ = queryPeers(t, ts, "offset=20"); len(ids) != 0 {
+		t.Errorf("Expected nothing past the end, got %v", ids)
+	}
+
+	ids, total = queryPeers(t, ts, "min_height=107&exclude=node-08&exclude=node-00,node-09")
+	if strings.Join(ids, ",") != "node-07" || total != 1 {
+		t.Errorf("Expected only node-07 at height 107 or more, got %v", ids)
+	}
+}
+
+func TestPeersRandomSample(t *testing.T) {
+	ts := NewTrackerService()
+	addPeerNodes(ts, 40)
+
+	firsts := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		ids, total := queryPeers(t, ts, "random=true&limit=5&exclude=node-00")
+		if len(ids) != 5 || total != 39 {
+			t.Fatalf("Expected 5 of 39 peers, got %v of %d", ids, total)
+		}
+		seen := make(map[string]bool)
+		for _, id := range ids {
+			if seen[id] || id == "node-00" {
+				t.Fatalf("Unexpected sample %v", ids)
+			}
+			seen[id] = true
+		}
+		firsts[ids[0]] = true
+	}
+	if len(firsts) < 2 {
+		t.Errorf("Expected samples to vary between requests, always got %v", firsts)
+	}
+
+	if ids, _ := queryPeers(t, ts, "random=true"); len(ids) != defaultPeerSample {
+		t.Errorf("Expected a default sample of %d, got %d", defaultPeerSample, len(ids))
+	}
+}
+
+func TestPeersRejectInvalidQuery(t *testing.T) {
+	ts := NewTrackerService()
+	for _, query := range []string{"limit=0", "limit=5000", "offset=-1", "min_height=tall", "random=maybe", "random=true&offset=5"} {
+		rec := httptest.NewRecorder()
+		ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/peers?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProbeInterval = 5 * time.Minute
+	defaultProbeTimeout  = 5 * time.Second
+	defaultProbeWorkers  = 16
+	defaultProbeFailures = 3 // Failed rounds in a row before a node counts as unreachable
+)
+
+// ProbeConfig controls how the tracker checks that registered nodes can be
+// dialled. An Interval of zero disables probing.
+type ProbeConfig struct {
+	Interval time.Duration // How often every node is probed
+	Timeout  time.Duration // How long one dial may take
+	Workers  int           // Dials in flight at once
+	Failures int           // Consecutive P2P failures that make a node unreachable
+}
+
+func defaultProbeConfig() ProbeConfig {
+	return ProbeConfig{
+		Interval: defaultProbeInterval,
+		Timeout:  defaultProbeTimeout,
+		Workers:  defaultProbeWorkers,
+		Failures: defaultProbeFailures,
+	}
+}
+
+// probeConfigFromEnv reads TRACKER_PROBE_INTERVAL ("0s" disables probing),
+// TRACKER_PROBE_TIMEOUT, TRACKER_PROBE_WORKERS and TRACKER_PROBE_FAILURES
+func probeConfigFromEnv() ProbeConfig {
+	cfg := defaultProbeConfig()
+	cfg.Interval = durationFromEnv("TRACKER_PROBE_INTERVAL", cfg.Interval, 0)
+	cfg.Timeout = durationFromEnv("TRACKER_PROBE_TIMEOUT", cfg.Timeout, 100*time.Millisecond)
+	cfg.Workers = intFromEnv("TRACKER_PROBE_WORKERS", cfg.Workers)
+	cfg.Failures = intFromEnv("TRACKER_PROBE_FAILURES", cfg.Failures)
+	return cfg
+}
+
+// PortProbe is the outcome of dialling one of a node's ports
+type PortProbe struct {
+	Address   string  `json:"address"`
+	Reachable bool    `json:"reachable"`
+	RTTMillis float64 `json:"rtt_ms,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Reachability is what the tracker last found when dialling a node. Only
+// the P2P port decides whether a node is handed out as a peer; the HTTP
+// port is reported for operators.
+type Reachability struct {
+	CheckedAt   time.Time `json:"checked_at"`
+	P2P         PortProbe `json:"p2p"`
+	HTTP        PortProbe `json:"http"`
+	Failures    int       `json:"consecutive_failures"`
+	Unreachable bool      `json:"unreachable"`
+	NAT         bool      `json:"nat"` // Reports an IP other than the one it connects from
+}
+
+// probePort dials address over TCP, timing the handshake
+func probePort(ctx context.Context, address string, timeout time.Duration) PortProbe {
+	probe := PortProbe{Address: address}
+	dialer := net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	conn.Close()
+	probe.Reachable = true
+	probe.RTTMillis = float64(time.Since(start).Microseconds()) / 1000
+	return probe
+}
+
+// behindNAT reports whether a node's self-reported IP differs from the
+// address its requests arrive from
+func behindNAT(node *RegisteredNode) bool {
+	if node.ObservedIP == "" || node.ObservedIP == "unknown" {
+		return false
+	}
+	return normalizeIP(node.ExternalIP) != normalizeIP(node.ObservedIP)
+}
+
+// probeTarget is a node's ports as they stood when a round began
+type probeTarget struct {
+	nodeID    string
+	p2p, http string
+	p2pProbe  PortProbe
+	httpProbe PortProbe
+}
+
+// probeNodes probes every node each Interval until the tracker stops
+func (ts *TrackerService) probeNodes() {
+	ticker := time.NewTicker(ts.probes.Interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		ts.probeRound(context.Background(), now)
+	}
+}
+
+// probeRound dials the P2P and HTTP ports of every online node, at most
+// Workers at a time, then records the results. The registry is only locked
+// to list the targets and to record results, never while dialling.
+func (ts *TrackerService) probeRound(ctx context.Context, now time.Time) {
+	var targets []*probeTarget
+	ts.mu.RLock()
+	for _, node := range ts.nodes {
+		if node.Status != "online" || now.Sub(node.LastHeartbeat) >= 5*time.Minute {
+			continue
+		}
+		p2p, http := peerAddresses(node)
+		targets = append(targets, &probeTarget{nodeID: node.NodeID, p2p: p2p, http: http})
+	}
+	ts.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	work := make(chan *probeTarget)
+	for i := 0; i < ts.probes.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range work {
+				target.p2pProbe = probePort(ctx, target.p2p, ts.probes.Timeout)
+				target.httpProbe = probePort(ctx, target.http, ts.probes.Timeout)
+			}
+		}()
+	}
+	for _, target := range targets {
+		work <- target
+	}
+	close(work)
+	wg.Wait()
+
+	changed := false
+	ts.mu.Lock()
+	for _, target := range targets {
+		node, ok := ts.nodes[target.nodeID]
+		if !ok {
+			continue // Removed while being probed
+		}
+		if p2p, http := peerAddresses(node); p2p != target.p2p || http != target.http {
+			continue // Re-registered elsewhere; the next round will tell
+		}
+		if ts.recordProbe(node, target.p2pProbe, target.httpProbe, now) {
+			changed = true
+		}
+	}
+	ts.mu.Unlock()
+	if changed {
+		ts.peerChanges.Notify()
+	}
+}
+
+// recordProbe stores one round's results on node, reporting whether it
+// became reachable or unreachable
+func (ts *TrackerService) recordProbe(node *RegisteredNode, p2p, http PortProbe, now time.Time) bool {
+	previous := node.Reachability
+	reach := &Reachability{CheckedAt: now, P2P: p2p, HTTP: http, NAT: behindNAT(node)}
+	if !p2p.Reachable {
+		reach.Failures = 1
+		if previous != nil {
+			reach.Failures = previous.Failures + 1
+		}
+	}
+	reach.Unreachable = reach.Failures >= ts.probes.Failures
+	node.Reachability = reach
+
+	wasUnreachable := previous != nil && previous.Unreachable
+	if reach.Unreachable == wasUnreachable {
+		return false
+	}
+	if reach.Unreachable {
+		detail := "p2p " + p2p.Address + ": " + p2p.Error
+		log.Printf("🔌 Node %s unreachable after %d probes (%s)", node.NodeID, reach.Failures, detail)
+		ts.events.Record(Event{Time: now.UTC(), Type: EventUnreachable, NodeID: node.NodeID, Detail: detail})
+	} else {
+		log.Printf("🔌 Node %s reachable again at %s", node.NodeID, p2p.Address)
+		ts.events.Record(Event{Time: now.UTC(), Type: EventReachable, NodeID: node.NodeID, Detail: "p2p " + p2p.Address})
+	}
+	ts.saveNode(node)
+	return true
+}
+
+// keepReachability carries previous's probe results over to node while it
+// is still at the same P2P address
+func (node *RegisteredNode) keepReachability(previous *RegisteredNode) {
+	if previous.Reachability == nil {
+		return
+	}
+	if p2p, _ := peerAddresses(node); p2p == previous.Reachability.P2P.Address {
+		node.Reachability = previous.Reachability
+	}
+}
+
+// reachabilityLabel is how the dashboard marks a node's address
+func reachabilityLabel(node *RegisteredNode) string {
+	var labels []string
+	if node.Reachability != nil && node.Reachability.Unreachable {
+		labels = append(labels, "unreachable")
+	}
+	if node.Reachability != nil && node.Reachability.NAT {
+		labels = append(labels, "NAT")
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(labels, ", ") + ")"
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenLocal opens a TCP port on the loopback address, returning it
+func listenLocal(t *testing.T) (net.Listener, int) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	return listener, listener.Addr().(*net.TCPAddr).Port
+}
+
+// addProbedNode adds a node whose P2P and HTTP ports are on the loopback
+// address
+func addProbedNode(ts *TrackerService, id string, p2pPort, httpPort int) *RegisteredNode {
+	addTestNode(ts, id, 100, tib)
+	node := ts.nodes[id]
+	node.ObservedIP, node.ExternalIP = "127.0.0.1", "127.0.0.1"
+	node.P2PPort, node.HTTPPort = p2pPort, httpPort
+	return node
+}
+
+func TestProbeMarksNodesUnreachableAfterRepeatedFailures(t *testing.T) {
+	ts := NewTrackerService()
+	ts.events = newTestEventLog(t, defaultEventLogMaxBytes)
+	ts.probes.Failures, ts.probes.Timeout = 2, time.Second
+	_, open := listenLocal(t)
+	closedListener, closed := listenLocal(t)
+	closedListener.Close()
+	addProbedNode(ts, "node-open", open, open)
+	node := addProbedNode(ts, "node-closed", closed, open)
+
+	ts.probeRound(context.Background(), time.Now())
+	if node.Reachability == nil || node.Reachability.Failures != 1 || node.Reachability.Unreachable {
+		t.Fatalf("One failed probe should not make a node unreachable, got %+v", node.Reachability)
+	}
+	if !node.Reachability.HTTP.Reachable || node.Reachability.P2P.Error == "" {
+		t.Errorf("Expected a reachable HTTP port and a P2P error, got %+v", node.Reachability)
+	}
+	if ids, _ := queryPeers(t, ts, ""); len(ids) != 2 {
+		t.Errorf("Both nodes should still be peers, got %v", ids)
+	}
+
+	ts.probeRound(context.Background(), time.Now())
+	if !node.Reachability.Unreachable {
+		t.Fatalf("Two failed probes should make the node unreachable, got %+v", node.Reachability)
+	}
+	if ids, _ := queryPeers(t, ts, ""); len(ids) != 1 || ids[0] != "node-open" {
+		t.Errorf("Unreachable nodes should not be handed out as peers, got %v", ids)
+	}
+	if stats := ts.calculateNetworkStats(); stats.UnreachableNodes != 1 {
+		t.Errorf("Expected 1 unreachable node in stats, got %d", stats.UnreachableNodes)
+	}
+	if events, _ := ts.events.Since(time.Time{}, 10); len(events) != 1 || events[0].Type != EventUnreachable {
+		t.Errorf("Expected one unreachable event, got %v", eventTypes(events))
+	}
+}
+
+func TestProbeRecoversReachableNodes(t *testing.T) {
+	ts := NewTrackerService()
+	ts.probes.Failures = 1
+	listener, port := listenLocal(t)
+	node := addProbedNode(ts, "node-1", port, port)
+	node.Reachability = &Reachability{P2P: PortProbe{Address: listener.Addr().String()}, Failures: 3, Unreachable: true}
+
+	ts.probeRound(context.Background(), time.Now())
+	reach := node.Reachability
+	if reach.Unreachable || reach.Failures != 0 || !reach.P2P.Reachable || reach.P2P.RTTMillis <= 0 {
+		t.Fatalf("Expected a reachable node with a round trip time, got %+v", reach)
+	}
+	if ids, _ := queryPeers(t, ts, ""); len(ids) != 1 {
+		t.Errorf("Reachable node should be a peer again, got %v", ids)
+	}
+}
+
+func TestProbeSkipsOfflineNodes(t *testing.T) {
+	ts := NewTrackerService()
+	node := addProbedNode(ts, "node-1", 1, 1)
+	node.LastHeartbeat = time.Now().Add(-time.Hour)
+
+	ts.probeRound(context.Background(), time.Now())
+	if node.Reachability != nil {
+		t.Errorf("Offline nodes should not be probed, got %+v", node.Reachability)
+	}
+}
+
+func TestProbeRoundAlongsideRequests(t *testing.T) {
+	ts := NewTrackerService()
+	ts.probes.Failures = 1
+	_, open := listenLocal(t)
+	for i := 0; i < 5; i++ {
+		addProbedNode(ts, fmt.Sprintf("node-%02d", i), open, open)
+	}
+	addProbedNode(ts, "node-1", open, open).PublicKey = testPublicKeyHex
+
+	// Run with -race: rounds record results while heartbeats, tag edits and
+	// peer queries are served
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			ts.probeRound(context.Background(), time.Now())
+		}
+	}()
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		body := signedHeartbeat("node-1", uint64(101+i), start.Add(time.Duration(i+1)*time.Second))
+		if rec := postHeartbeat(ts, body); rec.Code != http.StatusOK {
+			t.Errorf("Heartbeat failed: %d %s", rec.Code, rec.Body)
+		}
+		tagRequest(t, ts, "PUT", "/api/v1/admin/node/node-00/tags", `{"tags": ["role:seed"]}`)
+		queryPeers(t, ts, "")
+	}
+	<-done
+
+	if reach := ts.nodes["node-1"].Reachability; reach == nil || !reach.P2P.Reachable {
+		t.Errorf("Expected node-1 to be probed, got %+v", reach)
+	}
+}
+
+func TestReachabilityNotesNAT(t *testing.T) {
+	ts := NewTrackerService()
+	node := addProbedNode(ts, "node-1", 26656, 8080)
+	node.ExternalIP = "192.168.1.20"
+
+	ts.recordProbe(node, PortProbe{Reachable: true}, PortProbe{Reachable: true}, time.Now())
+	if !node.Reachability.NAT || node.Reachability.Unreachable {
+		t.Errorf("A node reporting a private IP should be marked as behind NAT, got %+v", node.Reachability)
+	}
+	if label := reachabilityLabel(node); !strings.Contains(label, "NAT") {
+		t.Errorf("Dashboard label should mention NAT, got %q", label)
+	}
+	if stats := ts.calculateNetworkStats(); stats.NATNodes != 1 {
+		t.Errorf("Expected 1 NAT node in stats, got %d", stats.NATNodes)
+	}
+}
+
+func TestReachabilitySurvivesReRegistrationAtTheSameAddress(t *testing.T) {
+	previous := &RegisteredNode{ObservedIP: "203.0.113.10", P2PPort: 26656,
+		Reachability: &Reachability{P2P: PortProbe{Address: "203.0.113.10:26656"}, Unreachable: true}}
+
+	same := &RegisteredNode{ObservedIP: "203.0.113.10", P2PPort: 26656}
+	same.keepReachability(previous)
+	if same.Reachability == nil || !same.Reachability.Unreachable {
+		t.Error("Probe results should carry over at the same address")
+	}
+
+	moved := &RegisteredNode{ObservedIP: "203.0.113.10", P2PPort: 26657}
+	moved.keepReachability(previous)
+	if moved.Reachability != nil {
+		t.Error("Probe results should be dropped when the P2P port changes")
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const maxNodeRequestBytes = 64 << 10 // Registrations carry a key and signature of ~15 KiB
+
+// RateLimitConfig caps registrations and heartbeats per client IP and per
+// node_id within each Window. A key that goes over its cap is blocked for
+// BanBase, doubling with every repeat offence up to BanMax; its offences
+// are forgotten after BanMax without one.
+type RateLimitConfig struct {
+	Window               time.Duration
+	RegistrationsPerIP   int
+	RegistrationsPerNode int
+	HeartbeatsPerIP      int // Generous, as many nodes may share a NAT
+	HeartbeatsPerNode    int // Nodes heartbeat every 30 seconds
+	BanBase              time.Duration
+	BanMax               time.Duration
+}
+
+func defaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Window:               time.Minute,
+		RegistrationsPerIP:   20,
+		RegistrationsPerNode: 5,
+		HeartbeatsPerIP:      240,
+		HeartbeatsPerNode:    10,
+		BanBase:              time.Minute,
+		BanMax:               24 * time.Hour,
+	}
+}
+
+// rateLimitConfigFromEnv reads TRACKER_RATE_WINDOW,
+// TRACKER_REGISTER_PER_IP, TRACKER_REGISTER_PER_NODE,
+// TRACKER_HEARTBEAT_PER_IP, TRACKER_HEARTBEAT_PER_NODE,
+// TRACKER_THROTTLE_BAN_BASE and TRACKER_THROTTLE_BAN_MAX
+func rateLimitConfigFromEnv() RateLimitConfig {
+	cfg := defaultRateLimitConfig()
+	cfg.Window = durationFromEnv("TRACKER_RATE_WINDOW", cfg.Window, time.Second)
+	cfg.RegistrationsPerIP = intFromEnv("TRACKER_REGISTER_PER_IP", cfg.RegistrationsPerIP)
+	cfg.RegistrationsPerNode = intFromEnv("TRACKER_REGISTER_PER_NODE", cfg.RegistrationsPerNode)
+	cfg.HeartbeatsPerIP = intFromEnv("TRACKER_HEARTBEAT_PER_IP", cfg.HeartbeatsPerIP)
+	cfg.HeartbeatsPerNode = intFromEnv("TRACKER_HEARTBEAT_PER_NODE", cfg.HeartbeatsPerNode)
+	cfg.BanBase = durationFromEnv("TRACKER_THROTTLE_BAN_BASE", cfg.BanBase, time.Second)
+	cfg.BanMax = durationFromEnv("TRACKER_THROTTLE_BAN_MAX", cfg.BanMax, cfg.BanBase)
+	return cfg
+}
+
+// intFromEnv parses a positive integer variable, keeping fallback when it
+// is unset or invalid
+func intFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Printf("⚠️ Ignoring invalid %s %q, using %d", name, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// limitState is one key's requests in the current window and its record
+// of offences
+type limitState struct {
+	windowStart time.Time
+	count       int
+	strikes     int
+	lastStrike  time.Time
+	blockedTill time.Time
+}
+
+// RateLimiter counts requests per key in fixed windows
+type RateLimiter struct {
+	mu     sync.Mutex
+	cfg    RateLimitConfig
+	states map[string]*limitState
+}
+
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	return &RateLimiter{cfg: cfg, states: make(map[string]*limitState)}
+}
+
+// Allow counts a request for key against limit. When the key is blocked it
+// returns false and how long until it may try again; going over the limit
+// blocks it, reporting the offence with strike true.
+func (l *RateLimiter) Allow(key string, limit int, now time.Time) (ok bool, retryAfter time.Duration, strike bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, exists := l.states[key]
+	if !exists {
+		state = &limitState{}
+		l.states[key] = state
+	}
+	if now.Before(state.blockedTill) {
+		return false, state.blockedTill.Sub(now), false
+	}
+	if state.strikes > 0 && now.Sub(state.lastStrike) > l.cfg.BanMax {
+		state.strikes = 0
+	}
+	if now.Sub(state.windowStart) >= l.cfg.Window {
+		state.windowStart, state.count = now, 0
+	}
+
+	state.count++
+	if state.count <= limit {
+		return true, 0, false
+	}
+
+	// Over the limit: block for BanBase, doubled for each earlier offence
+	ban := l.cfg.BanBase
+	for i := 0; i < state.strikes && ban < l.cfg.BanMax; i++ {
+		ban *= 2
+	}
+	if ban > l.cfg.BanMax {
+		ban = l.cfg.BanMax
+	}
+	state.strikes++
+	state.lastStrike = now
+	state.blockedTill = now.Add(ban)
+	return false, ban, true
+}
+
+// Prune forgets keys that are neither blocked, counting nor remembered
+// for an offence at now
+func (l *RateLimiter) Prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, state := range l.states {
+		if now.After(state.blockedTill) && now.Sub(state.windowStart) >= l.cfg.Window &&
+			(state.strikes == 0 || now.Sub(state.lastStrike) > l.cfg.BanMax) {
+			delete(l.states, key)
+		}
+	}
+}
+
+// rateLimited wraps a node request handler with per-IP and per-node_id
+// limits. kind names the request in keys and logs.
+func (ts *TrackerService) rateLimited(kind string, perIP, perNode int, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		clientIP := ts.clientIP(r)
+		if !ts.allowRequest(w, kind, "ip", clientIP, perIP, now, nil) {
+			return
+		}
+
+		// Peek at the node_id, then hand the body on untouched
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxNodeRequestBytes))
+		if err != nil {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		var peek struct {
+			NodeID string `json:"node_id"`
+		}
+		if json.Unmarshal(body, &peek) == nil && peek.NodeID != "" {
+			ts.mu.Lock()
+			allowed := ts.allowRequest(w, kind, "node", peek.NodeID, perNode, now, ts.nodes[peek.NodeID])
+			ts.mu.Unlock()
+			if !allowed {
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// allowRequest applies one limit, answering 429 when it is exceeded. A
+// registered node that trips its own limit is penalized.
+func (ts *TrackerService) allowRequest(w http.ResponseWriter, kind, scope, id string, limit int, now time.Time, node *RegisteredNode) bool {
+	ok, retryAfter, strike := ts.limiter.Allow(kind+"|"+scope+"|"+id, limit, now)
+	if ok {
+		return true
+	}
+	if strike {
+		log.Printf("🚦 Throttling %s from %s %s for %s", kind, scope, id, retryAfter)
+		event := Event{Type: EventThrottled, Detail: fmt.Sprintf("%s over %d per %s, blocked %s", kind, limit, ts.limiter.cfg.Window, retryAfter)}
+		if scope == "ip" {
+			event.IP = id
+		} else {
+			event.NodeID = id
+		}
+		ts.events.Record(event)
+		if node != nil {
+			ts.penalize(node, PenaltyRateLimited, fmt.Sprintf("%s flood", kind))
+			ts.saveNode(node)
+		}
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	http.Error(w, "Too many requests", http.StatusTooManyRequests)
+	return false
+}
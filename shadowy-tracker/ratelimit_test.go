@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBansGrowExponentially(t *testing.T) {
+	cfg := RateLimitConfig{Window: time.Minute, BanBase: time.Minute, BanMax: 10 * time.Minute}
+	limiter := NewRateLimiter(cfg)
+	now := time.Unix(1700000000, 0)
+
+	var bans []time.Duration
+	for offence := 0; offence < 5; offence++ {
+		if ok, _, _ := limiter.Allow("k", 1, now); !ok {
+			t.Fatalf("Expected the first request of offence %d allowed", offence)
+		}
+		ok, ban, strike := limiter.Allow("k", 1, now)
+		if ok || !strike {
+			t.Fatalf("Expected the second request in a window to strike")
+		}
+		if ok, retry, strike := limiter.Allow("k", 1, now.Add(time.Second)); ok || strike || retry != ban-time.Second {
+			t.Errorf("Expected requests during the ban refused without a new strike, got %v %s %v", ok, retry, strike)
+		}
+		bans = append(bans, ban)
+		now = now.Add(ban)
+	}
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute}
+	for i := range want {
+		if bans[i] != want[i] {
+			t.Errorf("Expected ban %d to last %s, got %s", i, want[i], bans[i])
+		}
+	}
+
+	// Offences are forgotten after BanMax of good behaviour
+	now = now.Add(cfg.BanMax + time.Minute)
+	limiter.Allow("k", 1, now)
+	if _, ban, _ := limiter.Allow("k", 1, now); ban != time.Minute {
+		t.Errorf("Expected a first offence again after a quiet spell, got %s", ban)
+	}
+	limiter.Prune(now.Add(cfg.BanMax + time.Hour))
+	if len(limiter.states) != 0 {
+		t.Errorf("Expected stale keys pruned, %d left", len(limiter.states))
+	}
+}
+
+func TestHeartbeatFloodIsThrottled(t *testing.T) {
+	ts := NewTrackerService()
+	ts.limiter = NewRateLimiter(RateLimitConfig{Window: time.Minute, HeartbeatsPerIP: 100, HeartbeatsPerNode: 2,
+		RegistrationsPerIP: 100, RegistrationsPerNode: 100, BanBase: time.Minute, BanMax: time.Hour})
+	addTestNode(ts, "node-1", 10, tib)
+	ts.nodes["node-1"].PublicKey = testPublicKeyHex
+	ts.nodes["node-1"].Score = maxNodeScore
+	router := ts.publicRouter()
+
+	send := func(nodeID string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/heartbeat",
+			strings.NewReader(signedHeartbeat(nodeID, 11, time.Now()))))
+		return rec
+	}
+
+	codes := []int{send("node-1").Code, send("node-1").Code, send("node-1").Code}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("Expected the third heartbeat in a minute throttled, got %v", codes)
+	}
+	rec := send("node-1")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected the node blocked with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if node := ts.nodes["node-1"]; node.Penalties[PenaltyRateLimited] != 1 {
+		t.Errorf("Expected one rate limit penalty for the flood, got %v", node.Penalties)
+	}
+
+	// Other nodes behind the same IP are unaffected
+	if rec := send("node-2"); rec.Code == http.StatusTooManyRequests {
+		t.Error("Expected another node_id from the same IP to get through")
+	}
+}
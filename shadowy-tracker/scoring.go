@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Reasons a node's score is lowered
+const (
+	PenaltyStaleHeight      = "stale-height"
+	PenaltyFlapping         = "flapping"
+	PenaltyInvalidSignature = "invalid-signature"
+	PenaltyChainMismatch    = "chain-mismatch"
+	PenaltyRateLimited      = "rate-limited"
+)
+
+// penaltyCost is how many points each penalty takes off a node's score
+var penaltyCost = map[string]int{
+	PenaltyStaleHeight:      5,
+	PenaltyFlapping:         10,
+	PenaltyInvalidSignature: 25,
+	PenaltyChainMismatch:    20,
+	PenaltyRateLimited:      15,
+}
+
+// Nodes start at maxNodeScore and earn back a point with every heartbeat
+// that draws no penalty
+const (
+	maxNodeScore   = 100
+	scoreRecovery  = 1
+	staleHeightLag = 10 // Blocks behind the chain's highest node
+)
+
+// penalize lowers a node's score for reason and counts it against the node
+func (ts *TrackerService) penalize(node *RegisteredNode, reason, detail string) {
+	node.Score -= penaltyCost[reason]
+	if node.Score < 0 {
+		node.Score = 0
+	}
+	if node.Penalties == nil {
+		node.Penalties = make(map[string]int)
+	}
+	node.Penalties[reason]++
+
+	log.Printf("⚠️ Node %s penalized for %s: %s (score %d)", node.NodeID, reason, detail, node.Score)
+	ts.events.Record(Event{Type: EventPenalty, NodeID: node.NodeID, IP: node.ObservedIP,
+		Detail: fmt.Sprintf("%s: %s", reason, detail)})
+}
+
+// scoreHeartbeat penalizes a node whose heartbeat came after a silence,
+// trails the chain's highest node while claiming to be synced, or reports
+// a block hash its peers at the same height disagree with. A clean
+// heartbeat recovers a point.
+func (ts *TrackerService) scoreHeartbeat(node *RegisteredNode, previous time.Time) {
+	penalized := false
+
+	if !previous.IsZero() && node.LastHeartbeat.Sub(previous) > ts.missedAfter {
+		ts.penalize(node, PenaltyFlapping, fmt.Sprintf("silent for %s", node.LastHeartbeat.Sub(previous).Round(time.Second)))
+		penalized = true
+	}
+
+	if node.Status != "syncing" {
+		if highest := ts.peerHighestHeight(node); highest > node.ChainHeight+staleHeightLag {
+			ts.penalize(node, PenaltyStaleHeight, fmt.Sprintf("height %d, chain at %d", node.ChainHeight, highest))
+			penalized = true
+		}
+	}
+
+	if hash := ts.peerConsensusHash(node); hash != "" && hash != node.ChainHash {
+		ts.penalize(node, PenaltyChainMismatch, fmt.Sprintf("hash %s at height %d, peers report %s", node.ChainHash, node.ChainHeight, hash))
+		penalized = true
+	}
+
+	if !penalized && node.Score < maxNodeScore {
+		node.Score += scoreRecovery
+	}
+}
+
+// peerHighestHeight returns the highest height reported by the other
+// online nodes on node's chain
+func (ts *TrackerService) peerHighestHeight(node *RegisteredNode) uint64 {
+	var highest uint64
+	for _, peer := range ts.nodesOnChain(node.ChainID) {
+		if peer.NodeID != node.NodeID && isOnline(peer) && peer.ChainHeight > highest {
+			highest = peer.ChainHeight
+		}
+	}
+	return highest
+}
+
+// peerConsensusHash returns the block hash most other online nodes on
+// node's chain report at node's height, provided at least two agree on it
+// and they outnumber those agreeing with node. Otherwise it returns "".
+func (ts *TrackerService) peerConsensusHash(node *RegisteredNode) string {
+	if node.ChainHash == "" {
+		return ""
+	}
+	counts := make(map[string]int)
+	for _, peer := range ts.nodesOnChain(node.ChainID) {
+		if peer.NodeID != node.NodeID && isOnline(peer) && peer.ChainHeight == node.ChainHeight && peer.ChainHash != "" {
+			counts[peer.ChainHash]++
+		}
+	}
+
+	var best string
+	for hash, count := range counts {
+		if count > counts[best] || (count == counts[best] && hash < best) {
+			best = hash
+		}
+	}
+	if counts[best] < 2 || counts[best] <= counts[node.ChainHash] {
+		return ""
+	}
+	return best
+}
+
+// isOnline reports whether a node is online and heard from recently
+func isOnline(node *RegisteredNode) bool {
+	return isOnlineAt(node, time.Now())
+}
+
+// isOnlineAt reports whether a node is online and heard from recently at now
+func isOnlineAt(node *RegisteredNode, now time.Time) bool {
+	return node.Status == "online" && now.Sub(node.LastHeartbeat) < 5*time.Minute
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScoreHeartbeatPenalties(t *testing.T) {
+	ts := NewTrackerService()
+	for _, id := range []string{"peer-1", "peer-2", "node-1"} {
+		addTestNode(ts, id, 100, tib)
+		ts.nodes[id].ChainHash = "good"
+		ts.nodes[id].Score = maxNodeScore
+	}
+	node := ts.nodes["node-1"]
+
+	// In step with its peers: nothing to penalize
+	ts.scoreHeartbeat(node, node.LastHeartbeat.Add(-30*time.Second))
+	if node.Score != maxNodeScore || len(node.Penalties) != 0 {
+		t.Fatalf("Expected a clean heartbeat to keep the score, got %d %v", node.Score, node.Penalties)
+	}
+
+	// Back after a silence, on a hash its peers disagree with
+	node.ChainHash = "forked"
+	ts.scoreHeartbeat(node, node.LastHeartbeat.Add(-time.Hour))
+	want := maxNodeScore - penaltyCost[PenaltyFlapping] - penaltyCost[PenaltyChainMismatch]
+	if node.Score != want || node.Penalties[PenaltyFlapping] != 1 || node.Penalties[PenaltyChainMismatch] != 1 {
+		t.Errorf("Expected flapping and chain mismatch penalties, got %d %v", node.Score, node.Penalties)
+	}
+
+	// Far behind while claiming to be synced, but not while syncing
+	node.ChainHash = ""
+	node.ChainHeight = 50
+	node.Status = "syncing"
+	ts.scoreHeartbeat(node, node.LastHeartbeat)
+	if node.Penalties[PenaltyStaleHeight] != 0 || node.Score != want+scoreRecovery {
+		t.Errorf("Expected a syncing node to recover rather than be penalized, got %d %v", node.Score, node.Penalties)
+	}
+	node.Status = "online"
+	ts.scoreHeartbeat(node, node.LastHeartbeat)
+	if node.Penalties[PenaltyStaleHeight] != 1 {
+		t.Errorf("Expected a stale height penalty, got %v", node.Penalties)
+	}
+}
+
+func TestInvalidHeartbeatSignatureLowersScore(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-1", 10, tib)
+	ts.nodes["node-1"].PublicKey = testPublicKeyHex
+	ts.nodes["node-1"].Score = maxNodeScore
+
+	body := strings.Replace(signedHeartbeat("node-1", 11, time.Now()), `"chain_height":11`, `"chain_height":12`, 1)
+	if rec := postHeartbeat(ts, body); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected a tampered heartbeat rejected, got %d", rec.Code)
+	}
+	if score := ts.nodes["node-1"].Score; score != maxNodeScore-penaltyCost[PenaltyInvalidSignature] {
+		t.Errorf("Expected an invalid signature penalty, got score %d", score)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Registrations are kept as node:<id>; uptime history is kept beside them,
+// see uptime.go
+const (
+	nodeKeyPrefix = "node:"
+
+	defaultStorePath = "tracker-data"
+)
+
+// NodeStore persists registered nodes and their uptime history so the
+// network view survives restarts. A nil *NodeStore stores nothing.
+type NodeStore struct {
+	db *badger.DB
+}
+
+// OpenNodeStore opens or creates the store in the directory path
+func OpenNodeStore(path string) (*NodeStore, error) {
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil // Badger's own logging is noise here
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open node store: %w", err)
+	}
+	store := &NodeStore{db: db}
+	migrated, err := store.migrateDailyUptime()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate daily uptime: %w", err)
+	}
+	if migrated > 0 {
+		log.Printf("📦 Migrated %d daily uptime records to hourly buckets", migrated)
+	}
+	return store, nil
+}
+
+// nodeStoreFromEnv opens the store in TRACKER_DB_PATH ("off" keeps nodes
+// in memory only)
+func nodeStoreFromEnv() *NodeStore {
+	path := defaultStorePath
+	if value, ok := os.LookupEnv("TRACKER_DB_PATH"); ok {
+		path = value
+	}
+	if path == "" || path == "off" {
+		return nil
+	}
+
+	store, err := OpenNodeStore(path)
+	if err != nil {
+		log.Printf("⚠️ Node persistence disabled: %v", err)
+		return nil
+	}
+	return store
+}
+
+// Close closes the underlying database
+func (s *NodeStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// SaveNode writes a node's current registration and state
+func (s *NodeStore) SaveNode(node *RegisteredNode) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return fmt.Errorf("failed to marshal node: %w", err)
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(nodeKeyPrefix+node.NodeID), data)
+	})
+}
+
+// DeleteNode forgets a node's registration, keeping its uptime history
+func (s *NodeStore) DeleteNode(nodeID string) error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(nodeKeyPrefix + nodeID))
+	})
+}
+
+// LoadNodes reads every stored node
+func (s *NodeStore) LoadNodes() ([]*RegisteredNode, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var nodes []*RegisteredNode
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(nodeKeyPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var node RegisteredNode
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &node)
+			}); err != nil {
+				log.Printf("⚠️ Skipping unreadable node %s: %v", it.Item().Key(), err)
+				continue
+			}
+			nodes = append(nodes, &node)
+		}
+		return nil
+	})
+	return nodes, err
+}
+
+// PruneNodes deletes stored nodes missing from keep, returning how many
+// were deleted
+func (s *NodeStore) PruneNodes(keep map[string]*RegisteredNode) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+	var staleKeys [][]byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(nodeKeyPrefix)
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			if _, ok := keep[strings.TrimPrefix(string(key), nodeKeyPrefix)]; !ok {
+				staleKeys = append(staleKeys, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	batch := s.db.NewWriteBatch()
+	defer batch.Cancel()
+	for _, key := range staleKeys {
+		if err := batch.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	if err := batch.Flush(); err != nil {
+		return 0, err
+	}
+	return len(staleKeys), nil
+}
+
+// Compact rewrites the database to reclaim the space taken by deleted and
+// overwritten records, returning its size on disk before and after
+func (s *NodeStore) Compact() (before, after int64, err error) {
+	if s == nil {
+		return 0, 0, nil
+	}
+	if before, err = s.diskUsage(); err != nil {
+		return 0, 0, err
+	}
+	if err := s.db.Flatten(1); err != nil {
+		return before, 0, fmt.Errorf("failed to compact store: %w", err)
+	}
+	// Each pass rewrites at most one value log file
+	for s.db.RunValueLogGC(0.5) == nil {
+	}
+	after, err = s.diskUsage()
+	return before, after, err
+}
+
+// diskUsage is the size of the store's files
+func (s *NodeStore) diskUsage() (int64, error) {
+	var total int64
+	dirs := []string{s.db.Opts().Dir}
+	if valueDir := s.db.Opts().ValueDir; valueDir != dirs[0] {
+		dirs = append(dirs, valueDir)
+	}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// loadNodes restores the nodes persisted before the last shutdown. They
+// keep their old heartbeats, so the cleanup startup grace decides whether
+// they come back online in time.
+func (ts *TrackerService) loadNodes() error {
+	nodes, err := ts.store.LoadNodes()
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		// Nodes stored before scoring start with a clean record
+		if node.Score == 0 && node.Penalties == nil {
+			node.Score = maxNodeScore
+		}
+		if node.Geo == nil {
+			ts.locateNode(node)
+		}
+		ts.applyVersionPolicy(node)
+		ts.nodes[node.NodeID] = node
+		ts.registry.nodes[node.NodeID] = node
+	}
+	if len(nodes) > 0 {
+		log.Printf("💾 Restored %d registered nodes", len(nodes))
+	}
+	return nil
+}
+
+// saveNode persists a node, logging rather than failing the request when
+// the store cannot be written
+func (ts *TrackerService) saveNode(node *RegisteredNode) {
+	if err := ts.store.SaveNode(node); err != nil {
+		log.Printf("⚠️ Failed to persist node %s: %v", node.NodeID, err)
+	}
+}
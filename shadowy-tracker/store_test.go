@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func openTestStore(t *testing.T, path string) *NodeStore {
+	store, err := OpenNodeStore(path)
+	if err != nil {
+		t.Fatalf("OpenNodeStore failed: %v", err)
+	}
+	return store
+}
+
+func TestNodesSurviveRestart(t *testing.T) {
+	path := t.TempDir()
+	ts := NewTrackerService()
+	ts.store = openTestStore(t, path)
+	addTestNode(ts, "node-1", 10, tib)
+	ts.nodes["node-1"].PublicKey = testPublicKeyHex
+	ts.saveNode(ts.nodes["node-1"])
+
+	signedAt := time.Now().Truncate(time.Second)
+	if rec := postHeartbeat(ts, signedHeartbeat("node-1", 42, signedAt)); rec.Code != http.StatusOK {
+		t.Fatalf("Heartbeat failed: %d %s", rec.Code, rec.Body)
+	}
+	ts.store.Close()
+
+	restarted := NewTrackerService()
+	restarted.store = openTestStore(t, path)
+	defer restarted.store.Close()
+	if err := restarted.loadNodes(); err != nil {
+		t.Fatalf("loadNodes failed: %v", err)
+	}
+	node, ok := restarted.nodes["node-1"]
+	if !ok || node.ChainHeight != 42 || node.TotalPlotSize != tib || restarted.registry.nodes["node-1"] != node {
+		t.Fatalf("Expected node-1 at height 42 after a restart, got %+v", node)
+	}
+	if !node.LastSignedAt.Equal(signedAt) {
+		t.Errorf("Expected the last signed request kept for replay protection, got %s", node.LastSignedAt)
+	}
+
+	// Nodes purged as offline stay gone
+	restarted.cleanup.StartupGrace = 0
+	restarted.pruneOfflineNodes(time.Now().Add(time.Hour))
+	if nodes, _ := restarted.store.LoadNodes(); len(nodes) != 0 {
+		t.Errorf("Expected the purged node forgotten, got %d stored", len(nodes))
+	}
+}
+
+func TestRecordHeartbeatUptime(t *testing.T) {
+	store := openTestStore(t, t.TempDir())
+	defer store.Close()
+
+	midnight := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	beats := []struct{ previous, now time.Time }{
+		{time.Time{}, midnight.Add(-2 * time.Minute)},                          // First heartbeat counts no time
+		{midnight.Add(-2 * time.Minute), midnight.Add(3 * time.Minute)},        // Only 3 minutes fall on the 2nd
+		{midnight.Add(3 * time.Minute), midnight.Add(5 * time.Minute)},         // 2 more
+		{midnight.Add(5 * time.Minute), midnight.Add(5 * time.Hour)},           // Too long a gap: missed
+		{midnight.Add(5 * time.Hour), midnight.Add(5*time.Hour + time.Minute)}, // 1 more
+	}
+	for _, beat := range beats {
+		if _, err := store.RecordHeartbeat("node-1", beat.previous, beat.now, 10*time.Minute); err != nil {
+			t.Fatalf("RecordHeartbeat failed: %v", err)
+		}
+	}
+
+	to := midnight.Add(6 * time.Hour)
+	buckets, _, _, err := store.uptimeHistory("node-1", to.Add(-7*24*time.Hour), to)
+	if err != nil {
+		t.Fatalf("uptimeHistory failed: %v", err)
+	}
+	days := buildUptimeReport(buckets, to.Add(-7*24*time.Hour), to, true).Buckets
+	if len(days) != 2 || !days[0].Start.Equal(midnight.Add(-24*time.Hour)) || days[0].Heartbeats != 1 || days[0].OnlineSeconds != 2*60 ||
+		days[1].Heartbeats != 4 || days[1].OnlineSeconds != 6*60 {
+		t.Errorf("Unexpected daily uptime %+v", days)
+	}
+
+	if removed, err := store.PruneUptime(midnight); err != nil || removed != 1 {
+		t.Errorf("Expected the 1st pruned, got %d (%v)", removed, err)
+	}
+}
+
+func TestDailyUptimeMigratedOnOpen(t *testing.T) {
+	path := t.TempDir()
+	store := openTestStore(t, path)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := store.RecordHeartbeat("node-1", time.Time{}, day.Add(30*time.Second), time.Minute); err != nil {
+		t.Fatalf("RecordHeartbeat failed: %v", err)
+	}
+	// Daily records as the store kept them before hourly buckets
+	err := store.db.Update(func(txn *badger.Txn) error {
+		for key, record := range map[string]legacyUptimeDay{
+			"uptime:node-1:2026-03-01": {Heartbeats: 100, OnlineSeconds: 3000},
+			"uptime:node-1:2026-03-02": {Heartbeats: 200, OnlineSeconds: 6000},
+		} {
+			data, _ := json.Marshal(record)
+			if err := txn.Set([]byte(key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to write daily records: %v", err)
+	}
+	store.Close()
+
+	store = openTestStore(t, path)
+	defer store.Close()
+	buckets, _, _, err := store.uptimeHistory("node-1", day, day.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("uptimeHistory failed: %v", err)
+	}
+	if len(buckets) != 2 || buckets[0].Heartbeats != 101 || buckets[0].OnlineSeconds != 3000 || !buckets[0].FirstHeartbeat.Equal(day) ||
+		!buckets[1].Start.Equal(day.Add(24*time.Hour)) || buckets[1].Heartbeats != 200 {
+		t.Errorf("Unexpected migrated buckets %+v", buckets)
+	}
+	if migrated, err := store.migrateDailyUptime(); err != nil || migrated != 0 {
+		t.Errorf("Expected nothing left to migrate, got %d (%v)", migrated, err)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Limits on operator annotations per node
+const (
+	maxNodeTags  = 16
+	maxTagLength = 64
+)
+
+// TagRequest is the body of the admin tag endpoints
+type TagRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// validateTags trims and de-duplicates tags, reporting any that are empty,
+// too long or contain whitespace
+func validateTags(tags []string) ([]string, []FieldError) {
+	var errs []FieldError
+	var cleaned []string
+	seen := make(map[string]bool)
+
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		field := fmt.Sprintf("tags[%d]", i)
+		switch {
+		case tag == "":
+			errs = append(errs, FieldError{Field: field, Message: "must not be empty"})
+		case len(tag) > maxTagLength:
+			errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf("must be at most %d characters, got %d", maxTagLength, len(tag))})
+		case strings.ContainsAny(tag, " \t\r\n"):
+			errs = append(errs, FieldError{Field: field, Message: "must not contain whitespace"})
+		case !seen[tag]:
+			seen[tag] = true
+			cleaned = append(cleaned, tag)
+		}
+	}
+
+	if len(cleaned) > maxNodeTags {
+		errs = append(errs, FieldError{Field: "tags", Message: fmt.Sprintf("at most %d tags per node, got %d", maxNodeTags, len(cleaned))})
+	}
+	return cleaned, errs
+}
+
+// hasTag reports whether the node carries tag
+func (n *RegisteredNode) hasTag(tag string) bool {
+	for _, t := range n.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSetNodeTags replaces (PUT) or extends (POST) a node's tags
+func (ts *TrackerService) handleSetNodeTags(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["nodeId"]
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	node, exists := ts.nodes[nodeID]
+	if !exists {
+		http.Error(w, "Node not found", http.StatusNotFound)
+		return
+	}
+
+	var req TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "Invalid request body", decodeErrorFields(err))
+		return
+	}
+
+	tags := req.Tags
+	if r.Method == http.MethodPost {
+		tags = append(append([]string{}, node.Tags...), req.Tags...)
+	}
+
+	cleaned, fieldErrs := validateTags(tags)
+	if len(fieldErrs) > 0 {
+		writeValidationError(w, "Invalid tags", fieldErrs)
+		return
+	}
+
+	node.Tags = cleaned
+	ts.saveNode(node)
+	log.Printf("🏷️ Node %s tagged %v", nodeID, node.Tags)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(node)
+}
+
+// handleRemoveNodeTag removes a single tag from a node
+func (ts *TrackerService) handleRemoveNodeTag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	node, exists := ts.nodes[vars["nodeId"]]
+	if !exists {
+		http.Error(w, "Node not found", http.StatusNotFound)
+		return
+	}
+	if !node.hasTag(vars["tag"]) {
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return
+	}
+
+	var remaining []string
+	for _, t := range node.Tags {
+		if t != vars["tag"] {
+			remaining = append(remaining, t)
+		}
+	}
+	node.Tags = remaining
+	ts.saveNode(node)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(node)
+}
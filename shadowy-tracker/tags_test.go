@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tagRequest sends an admin request to the internal router, setting up
+// and presenting the test admin token
+func tagRequest(t *testing.T, ts *TrackerService, method, path, body string) *httptest.ResponseRecorder {
+	if ts.adminToken == "" {
+		ts.adminToken = testAdminToken
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+ts.adminToken)
+	rec := httptest.NewRecorder()
+	ts.internalRouter().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTagNodeAndFilterByTag(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-1", 100, tib)
+	addTestNode(ts, "node-2", 100, tib)
+
+	rec := tagRequest(t, ts, "PUT", "/api/v1/admin/node/node-1/tags", `{"tags": ["region:eu", "role:seed", "region:eu"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Tagging failed with %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := ts.nodes["node-1"].Tags; len(got) != 2 || got[0] != "region:eu" || got[1] != "role:seed" {
+		t.Errorf("Expected de-duplicated tags, got %v", got)
+	}
+
+	rec = tagRequest(t, ts, "POST", "/api/v1/admin/node/node-2/tags", `{"tags": ["region:us"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Adding tag failed with %d", rec.Code)
+	}
+
+	// Tags show up on the public node endpoints and filter the list
+	public := ts.publicRouter()
+	rec = httptest.NewRecorder()
+	public.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/nodes?tag=region:eu", nil))
+
+	var body struct {
+		Nodes map[string]*RegisteredNode `json:"nodes"`
+		Count int                        `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode nodes: %v", err)
+	}
+	if body.Count != 1 || body.Nodes["node-1"] == nil {
+		t.Errorf("Expected only node-1 for region:eu, got %v", body.Nodes)
+	}
+
+	rec = httptest.NewRecorder()
+	public.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/node/node-1", nil))
+	var node RegisteredNode
+	json.NewDecoder(rec.Body).Decode(&node)
+	if len(node.Tags) != 2 {
+		t.Errorf("Expected tags on node detail, got %v", node.Tags)
+	}
+}
+
+func TestRemoveNodeTag(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-1", 100, tib)
+	ts.nodes["node-1"].Tags = []string{"dc:ams", "role:seed"}
+
+	rec := tagRequest(t, ts, "DELETE", "/api/v1/admin/node/node-1/tags/dc:ams", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Removing tag failed with %d", rec.Code)
+	}
+	if got := ts.nodes["node-1"].Tags; len(got) != 1 || got[0] != "role:seed" {
+		t.Errorf("Expected only role:seed left, got %v", got)
+	}
+
+	rec = tagRequest(t, ts, "DELETE", "/api/v1/admin/node/node-1/tags/dc:ams", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 removing a missing tag, got %d", rec.Code)
+	}
+}
+
+func TestTagValidation(t *testing.T) {
+	ts := NewTrackerService()
+	addTestNode(ts, "node-1", 100, tib)
+
+	tooMany := make([]string, maxNodeTags+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("t", i+1)
+	}
+	tooManyJSON, _ := json.Marshal(TagRequest{Tags: tooMany})
+
+	cases := map[string]string{
+		"empty":      `{"tags": [" "]}`,
+		"too long":   `{"tags": ["` + strings.Repeat("x", maxTagLength+1) + `"]}`,
+		"whitespace": `{"tags": ["data center"]}`,
+		"too many":   string(tooManyJSON),
+	}
+	for name, body := range cases {
+		rec := tagRequest(t, ts, "PUT", "/api/v1/admin/node/node-1/tags", body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+	if len(ts.nodes["node-1"].Tags) != 0 {
+		t.Errorf("Rejected requests must not change tags, got %v", ts.nodes["node-1"].Tags)
+	}
+
+	rec := tagRequest(t, ts, "PUT", "/api/v1/admin/node/missing/tags", `{"tags": ["a"]}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown node, got %d", rec.Code)
+	}
+
+	// Tag routes are admin-only
+	rec = httptest.NewRecorder()
+	ts.publicRouter().ServeHTTP(rec, httptest.NewRequest("PUT", "/api/v1/admin/node/node-1/tags", strings.NewReader(`{"tags": ["a"]}`)))
+	if rec.Code == http.StatusOK {
+		t.Error("Tag endpoint must not be served on the public listener")
+	}
+}
@@ -0,0 +1,1127 @@
+// The tracker's node API over gRPC. Requests and responses carry the same
+// fields as the REST API under /api/v1, and registrations and heartbeats
+// are signed exactly as they are there.
+//
+// Regenerate with:
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative trackerpb/tracker.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: trackerpb/tracker.proto
+
+package trackerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PeerEvent_Type int32
+
+const (
+	PeerEvent_TYPE_UNSPECIFIED PeerEvent_Type = 0
+	PeerEvent_ADDED            PeerEvent_Type = 1
+	PeerEvent_UPDATED          PeerEvent_Type = 2
+	PeerEvent_REMOVED          PeerEvent_Type = 3
+)
+
+// Enum value maps for PeerEvent_Type.
+var (
+	PeerEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "ADDED",
+		2: "UPDATED",
+		3: "REMOVED",
+	}
+	PeerEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"ADDED":            1,
+		"UPDATED":          2,
+		"REMOVED":          3,
+	}
+)
+
+func (x PeerEvent_Type) Enum() *PeerEvent_Type {
+	p := new(PeerEvent_Type)
+	*p = x
+	return p
+}
+
+func (x PeerEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PeerEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_trackerpb_tracker_proto_enumTypes[0].Descriptor()
+}
+
+func (PeerEvent_Type) Type() protoreflect.EnumType {
+	return &file_trackerpb_tracker_proto_enumTypes[0]
+}
+
+func (x PeerEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PeerEvent_Type.Descriptor instead.
+func (PeerEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{9, 0}
+}
+
+type RegisterRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	NodeId             string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	MiningAddress      string                 `protobuf:"bytes,2,opt,name=mining_address,json=miningAddress,proto3" json:"mining_address,omitempty"`
+	PublicKey          string                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	ExternalIp         string                 `protobuf:"bytes,4,opt,name=external_ip,json=externalIp,proto3" json:"external_ip,omitempty"`
+	P2PPort            int32                  `protobuf:"varint,5,opt,name=p2p_port,json=p2pPort,proto3" json:"p2p_port,omitempty"`
+	HttpPort           int32                  `protobuf:"varint,6,opt,name=http_port,json=httpPort,proto3" json:"http_port,omitempty"`
+	ChainHeight        uint64                 `protobuf:"varint,7,opt,name=chain_height,json=chainHeight,proto3" json:"chain_height,omitempty"`
+	ChainHash          string                 `protobuf:"bytes,8,opt,name=chain_hash,json=chainHash,proto3" json:"chain_hash,omitempty"`
+	ChainId            string                 `protobuf:"bytes,9,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	LastBlockTime      string                 `protobuf:"bytes,10,opt,name=last_block_time,json=lastBlockTime,proto3" json:"last_block_time,omitempty"`
+	SoftwareVersion    string                 `protobuf:"bytes,11,opt,name=software_version,json=softwareVersion,proto3" json:"software_version,omitempty"`
+	OsVersion          string                 `protobuf:"bytes,12,opt,name=os_version,json=osVersion,proto3" json:"os_version,omitempty"`
+	Architecture       string                 `protobuf:"bytes,13,opt,name=architecture,proto3" json:"architecture,omitempty"`
+	TotalPlotSizeBytes uint64                 `protobuf:"varint,14,opt,name=total_plot_size_bytes,json=totalPlotSizeBytes,proto3" json:"total_plot_size_bytes,omitempty"`
+	PlotCount          int32                  `protobuf:"varint,15,opt,name=plot_count,json=plotCount,proto3" json:"plot_count,omitempty"`
+	Timestamp          string                 `protobuf:"bytes,16,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Signature          string                 `protobuf:"bytes,17,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RegisterRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetMiningAddress() string {
+	if x != nil {
+		return x.MiningAddress
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetExternalIp() string {
+	if x != nil {
+		return x.ExternalIp
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetP2PPort() int32 {
+	if x != nil {
+		return x.P2PPort
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetHttpPort() int32 {
+	if x != nil {
+		return x.HttpPort
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetChainHeight() uint64 {
+	if x != nil {
+		return x.ChainHeight
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetChainHash() string {
+	if x != nil {
+		return x.ChainHash
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetLastBlockTime() string {
+	if x != nil {
+		return x.LastBlockTime
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetSoftwareVersion() string {
+	if x != nil {
+		return x.SoftwareVersion
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetOsVersion() string {
+	if x != nil {
+		return x.OsVersion
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetArchitecture() string {
+	if x != nil {
+		return x.Architecture
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetTotalPlotSizeBytes() uint64 {
+	if x != nil {
+		return x.TotalPlotSizeBytes
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetPlotCount() int32 {
+	if x != nil {
+		return x.PlotCount
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type RegisterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Deprecated    bool                   `protobuf:"varint,3,opt,name=deprecated,proto3" json:"deprecated,omitempty"`
+	MinVersion    string                 `protobuf:"bytes,4,opt,name=min_version,json=minVersion,proto3" json:"min_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RegisterResponse) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *RegisterResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RegisterResponse) GetDeprecated() bool {
+	if x != nil {
+		return x.Deprecated
+	}
+	return false
+}
+
+func (x *RegisterResponse) GetMinVersion() string {
+	if x != nil {
+		return x.MinVersion
+	}
+	return ""
+}
+
+type HeartbeatRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	NodeId             string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	ChainHeight        uint64                 `protobuf:"varint,2,opt,name=chain_height,json=chainHeight,proto3" json:"chain_height,omitempty"`
+	ChainHash          string                 `protobuf:"bytes,3,opt,name=chain_hash,json=chainHash,proto3" json:"chain_hash,omitempty"`
+	LastBlockTime      string                 `protobuf:"bytes,4,opt,name=last_block_time,json=lastBlockTime,proto3" json:"last_block_time,omitempty"`
+	Status             string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	TotalPlotSizeBytes uint64                 `protobuf:"varint,6,opt,name=total_plot_size_bytes,json=totalPlotSizeBytes,proto3" json:"total_plot_size_bytes,omitempty"`
+	PlotCount          int32                  `protobuf:"varint,7,opt,name=plot_count,json=plotCount,proto3" json:"plot_count,omitempty"`
+	Timestamp          string                 `protobuf:"bytes,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Signature          string                 `protobuf:"bytes,9,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HeartbeatRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetChainHeight() uint64 {
+	if x != nil {
+		return x.ChainHeight
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetChainHash() string {
+	if x != nil {
+		return x.ChainHash
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetLastBlockTime() string {
+	if x != nil {
+		return x.LastBlockTime
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetTotalPlotSizeBytes() uint64 {
+	if x != nil {
+		return x.TotalPlotSizeBytes
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetPlotCount() int32 {
+	if x != nil {
+		return x.PlotCount
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type HeartbeatResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatResponse) Reset() {
+	*x = HeartbeatResponse{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResponse) ProtoMessage() {}
+
+func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HeartbeatResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type PeersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChainId       string                 `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	MinHeight     uint64                 `protobuf:"varint,2,opt,name=min_height,json=minHeight,proto3" json:"min_height,omitempty"`
+	Exclude       []string               `protobuf:"bytes,3,rep,name=exclude,proto3" json:"exclude,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	Random        bool                   `protobuf:"varint,6,opt,name=random,proto3" json:"random,omitempty"`
+	Version       string                 `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"` // The asking node's software version
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeersRequest) Reset() {
+	*x = PeersRequest{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeersRequest) ProtoMessage() {}
+
+func (x *PeersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeersRequest.ProtoReflect.Descriptor instead.
+func (*PeersRequest) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PeersRequest) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *PeersRequest) GetMinHeight() uint64 {
+	if x != nil {
+		return x.MinHeight
+	}
+	return 0
+}
+
+func (x *PeersRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *PeersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *PeersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *PeersRequest) GetRandom() bool {
+	if x != nil {
+		return x.Random
+	}
+	return false
+}
+
+func (x *PeersRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type Peer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	ClientEth     string                 `protobuf:"bytes,3,opt,name=client_eth,json=clientEth,proto3" json:"client_eth,omitempty"`
+	ChainHeight   uint64                 `protobuf:"varint,4,opt,name=chain_height,json=chainHeight,proto3" json:"chain_height,omitempty"`
+	ChainHash     string                 `protobuf:"bytes,5,opt,name=chain_hash,json=chainHash,proto3" json:"chain_hash,omitempty"`
+	ChainId       string                 `protobuf:"bytes,6,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	LastSeen      string                 `protobuf:"bytes,7,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"` // RFC 3339
+	Score         int32                  `protobuf:"varint,8,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Peer) Reset() {
+	*x = Peer{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peer) ProtoMessage() {}
+
+func (x *Peer) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peer.ProtoReflect.Descriptor instead.
+func (*Peer) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Peer) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *Peer) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Peer) GetClientEth() string {
+	if x != nil {
+		return x.ClientEth
+	}
+	return ""
+}
+
+func (x *Peer) GetChainHeight() uint64 {
+	if x != nil {
+		return x.ChainHeight
+	}
+	return 0
+}
+
+func (x *Peer) GetChainHash() string {
+	if x != nil {
+		return x.ChainHash
+	}
+	return ""
+}
+
+func (x *Peer) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *Peer) GetLastSeen() string {
+	if x != nil {
+		return x.LastSeen
+	}
+	return ""
+}
+
+func (x *Peer) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type PeersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peers         []*Peer                `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeersResponse) Reset() {
+	*x = PeersResponse{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeersResponse) ProtoMessage() {}
+
+func (x *PeersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeersResponse.ProtoReflect.Descriptor instead.
+func (*PeersResponse) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PeersResponse) GetPeers() []*Peer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *PeersResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Chain         string                 `protobuf:"bytes,1,opt,name=chain,proto3" json:"chain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StatsRequest) GetChain() string {
+	if x != nil {
+		return x.Chain
+	}
+	return ""
+}
+
+type NetworkStats struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Chain                   string                 `protobuf:"bytes,1,opt,name=chain,proto3" json:"chain,omitempty"`
+	TotalNodes              int32                  `protobuf:"varint,2,opt,name=total_nodes,json=totalNodes,proto3" json:"total_nodes,omitempty"`
+	OnlineNodes             int32                  `protobuf:"varint,3,opt,name=online_nodes,json=onlineNodes,proto3" json:"online_nodes,omitempty"`
+	SyncingNodes            int32                  `protobuf:"varint,4,opt,name=syncing_nodes,json=syncingNodes,proto3" json:"syncing_nodes,omitempty"`
+	TotalNetspaceBytes      uint64                 `protobuf:"varint,5,opt,name=total_netspace_bytes,json=totalNetspaceBytes,proto3" json:"total_netspace_bytes,omitempty"`
+	HighestHeight           uint64                 `protobuf:"varint,6,opt,name=highest_height,json=highestHeight,proto3" json:"highest_height,omitempty"`
+	ConsensusHeight         uint64                 `protobuf:"varint,7,opt,name=consensus_height,json=consensusHeight,proto3" json:"consensus_height,omitempty"`
+	NetspaceConsensusHeight uint64                 `protobuf:"varint,8,opt,name=netspace_consensus_height,json=netspaceConsensusHeight,proto3" json:"netspace_consensus_height,omitempty"`
+	ForkCount               int32                  `protobuf:"varint,9,opt,name=fork_count,json=forkCount,proto3" json:"fork_count,omitempty"`
+	DuplicateMiners         int32                  `protobuf:"varint,10,opt,name=duplicate_miners,json=duplicateMiners,proto3" json:"duplicate_miners,omitempty"`
+	DeprecatedNodes         int32                  `protobuf:"varint,11,opt,name=deprecated_nodes,json=deprecatedNodes,proto3" json:"deprecated_nodes,omitempty"`
+	LastUpdated             string                 `protobuf:"bytes,12,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *NetworkStats) Reset() {
+	*x = NetworkStats{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkStats) ProtoMessage() {}
+
+func (x *NetworkStats) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkStats.ProtoReflect.Descriptor instead.
+func (*NetworkStats) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *NetworkStats) GetChain() string {
+	if x != nil {
+		return x.Chain
+	}
+	return ""
+}
+
+func (x *NetworkStats) GetTotalNodes() int32 {
+	if x != nil {
+		return x.TotalNodes
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetOnlineNodes() int32 {
+	if x != nil {
+		return x.OnlineNodes
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetSyncingNodes() int32 {
+	if x != nil {
+		return x.SyncingNodes
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetTotalNetspaceBytes() uint64 {
+	if x != nil {
+		return x.TotalNetspaceBytes
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetHighestHeight() uint64 {
+	if x != nil {
+		return x.HighestHeight
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetConsensusHeight() uint64 {
+	if x != nil {
+		return x.ConsensusHeight
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetNetspaceConsensusHeight() uint64 {
+	if x != nil {
+		return x.NetspaceConsensusHeight
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetForkCount() int32 {
+	if x != nil {
+		return x.ForkCount
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetDuplicateMiners() int32 {
+	if x != nil {
+		return x.DuplicateMiners
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetDeprecatedNodes() int32 {
+	if x != nil {
+		return x.DeprecatedNodes
+	}
+	return 0
+}
+
+func (x *NetworkStats) GetLastUpdated() string {
+	if x != nil {
+		return x.LastUpdated
+	}
+	return ""
+}
+
+type PeerEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          PeerEvent_Type         `protobuf:"varint,1,opt,name=type,proto3,enum=shadowy.tracker.v1.PeerEvent_Type" json:"type,omitempty"`
+	Peer          *Peer                  `protobuf:"bytes,2,opt,name=peer,proto3" json:"peer,omitempty"` // Only node_id is set for REMOVED
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerEvent) Reset() {
+	*x = PeerEvent{}
+	mi := &file_trackerpb_tracker_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerEvent) ProtoMessage() {}
+
+func (x *PeerEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_trackerpb_tracker_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerEvent.ProtoReflect.Descriptor instead.
+func (*PeerEvent) Descriptor() ([]byte, []int) {
+	return file_trackerpb_tracker_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PeerEvent) GetType() PeerEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return PeerEvent_TYPE_UNSPECIFIED
+}
+
+func (x *PeerEvent) GetPeer() *Peer {
+	if x != nil {
+		return x.Peer
+	}
+	return nil
+}
+
+var File_trackerpb_tracker_proto protoreflect.FileDescriptor
+
+const file_trackerpb_tracker_proto_rawDesc = "" +
+	"\n" +
+	"\x17trackerpb/tracker.proto\x12\x12shadowy.tracker.v1\"\xca\x04\n" +
+	"\x0fRegisterRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12%\n" +
+	"\x0emining_address\x18\x02 \x01(\tR\rminingAddress\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\tR\tpublicKey\x12\x1f\n" +
+	"\vexternal_ip\x18\x04 \x01(\tR\n" +
+	"externalIp\x12\x19\n" +
+	"\bp2p_port\x18\x05 \x01(\x05R\ap2pPort\x12\x1b\n" +
+	"\thttp_port\x18\x06 \x01(\x05R\bhttpPort\x12!\n" +
+	"\fchain_height\x18\a \x01(\x04R\vchainHeight\x12\x1d\n" +
+	"\n" +
+	"chain_hash\x18\b \x01(\tR\tchainHash\x12\x19\n" +
+	"\bchain_id\x18\t \x01(\tR\achainId\x12&\n" +
+	"\x0flast_block_time\x18\n" +
+	" \x01(\tR\rlastBlockTime\x12)\n" +
+	"\x10software_version\x18\v \x01(\tR\x0fsoftwareVersion\x12\x1d\n" +
+	"\n" +
+	"os_version\x18\f \x01(\tR\tosVersion\x12\"\n" +
+	"\farchitecture\x18\r \x01(\tR\farchitecture\x121\n" +
+	"\x15total_plot_size_bytes\x18\x0e \x01(\x04R\x12totalPlotSizeBytes\x12\x1d\n" +
+	"\n" +
+	"plot_count\x18\x0f \x01(\x05R\tplotCount\x12\x1c\n" +
+	"\ttimestamp\x18\x10 \x01(\tR\ttimestamp\x12\x1c\n" +
+	"\tsignature\x18\x11 \x01(\tR\tsignature\"\x86\x01\n" +
+	"\x10RegisterResponse\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"deprecated\x18\x03 \x01(\bR\n" +
+	"deprecated\x12\x1f\n" +
+	"\vmin_version\x18\x04 \x01(\tR\n" +
+	"minVersion\"\xbb\x02\n" +
+	"\x10HeartbeatRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12!\n" +
+	"\fchain_height\x18\x02 \x01(\x04R\vchainHeight\x12\x1d\n" +
+	"\n" +
+	"chain_hash\x18\x03 \x01(\tR\tchainHash\x12&\n" +
+	"\x0flast_block_time\x18\x04 \x01(\tR\rlastBlockTime\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x121\n" +
+	"\x15total_plot_size_bytes\x18\x06 \x01(\x04R\x12totalPlotSizeBytes\x12\x1d\n" +
+	"\n" +
+	"plot_count\x18\a \x01(\x05R\tplotCount\x12\x1c\n" +
+	"\ttimestamp\x18\b \x01(\tR\ttimestamp\x12\x1c\n" +
+	"\tsignature\x18\t \x01(\tR\tsignature\"-\n" +
+	"\x11HeartbeatResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xc2\x01\n" +
+	"\fPeersRequest\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\tR\achainId\x12\x1d\n" +
+	"\n" +
+	"min_height\x18\x02 \x01(\x04R\tminHeight\x12\x18\n" +
+	"\aexclude\x18\x03 \x03(\tR\aexclude\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06random\x18\x06 \x01(\bR\x06random\x12\x18\n" +
+	"\aversion\x18\a \x01(\tR\aversion\"\xe8\x01\n" +
+	"\x04Peer\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x1d\n" +
+	"\n" +
+	"client_eth\x18\x03 \x01(\tR\tclientEth\x12!\n" +
+	"\fchain_height\x18\x04 \x01(\x04R\vchainHeight\x12\x1d\n" +
+	"\n" +
+	"chain_hash\x18\x05 \x01(\tR\tchainHash\x12\x19\n" +
+	"\bchain_id\x18\x06 \x01(\tR\achainId\x12\x1b\n" +
+	"\tlast_seen\x18\a \x01(\tR\blastSeen\x12\x14\n" +
+	"\x05score\x18\b \x01(\x05R\x05score\"U\n" +
+	"\rPeersResponse\x12.\n" +
+	"\x05peers\x18\x01 \x03(\v2\x18.shadowy.tracker.v1.PeerR\x05peers\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"$\n" +
+	"\fStatsRequest\x12\x14\n" +
+	"\x05chain\x18\x01 \x01(\tR\x05chain\"\xe5\x03\n" +
+	"\fNetworkStats\x12\x14\n" +
+	"\x05chain\x18\x01 \x01(\tR\x05chain\x12\x1f\n" +
+	"\vtotal_nodes\x18\x02 \x01(\x05R\n" +
+	"totalNodes\x12!\n" +
+	"\fonline_nodes\x18\x03 \x01(\x05R\vonlineNodes\x12#\n" +
+	"\rsyncing_nodes\x18\x04 \x01(\x05R\fsyncingNodes\x120\n" +
+	"\x14total_netspace_bytes\x18\x05 \x01(\x04R\x12totalNetspaceBytes\x12%\n" +
+	"\x0ehighest_height\x18\x06 \x01(\x04R\rhighestHeight\x12)\n" +
+	"\x10consensus_height\x18\a \x01(\x04R\x0fconsensusHeight\x12:\n" +
+	"\x19netspace_consensus_height\x18\b \x01(\x04R\x17netspaceConsensusHeight\x12\x1d\n" +
+	"\n" +
+	"fork_count\x18\t \x01(\x05R\tforkCount\x12)\n" +
+	"\x10duplicate_miners\x18\n" +
+	" \x01(\x05R\x0fduplicateMiners\x12)\n" +
+	"\x10deprecated_nodes\x18\v \x01(\x05R\x0fdeprecatedNodes\x12!\n" +
+	"\flast_updated\x18\f \x01(\tR\vlastUpdated\"\xb4\x01\n" +
+	"\tPeerEvent\x126\n" +
+	"\x04type\x18\x01 \x01(\x0e2\".shadowy.tracker.v1.PeerEvent.TypeR\x04type\x12,\n" +
+	"\x04peer\x18\x02 \x01(\v2\x18.shadowy.tracker.v1.PeerR\x04peer\"A\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\t\n" +
+	"\x05ADDED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\v\n" +
+	"\aREMOVED\x10\x032\xac\x03\n" +
+	"\aTracker\x12U\n" +
+	"\bRegister\x12#.shadowy.tracker.v1.RegisterRequest\x1a$.shadowy.tracker.v1.RegisterResponse\x12X\n" +
+	"\tHeartbeat\x12$.shadowy.tracker.v1.HeartbeatRequest\x1a%.shadowy.tracker.v1.HeartbeatResponse\x12O\n" +
+	"\bGetPeers\x12 .shadowy.tracker.v1.PeersRequest\x1a!.shadowy.tracker.v1.PeersResponse\x12N\n" +
+	"\bGetStats\x12 .shadowy.tracker.v1.StatsRequest\x1a .shadowy.tracker.v1.NetworkStats\x12O\n" +
+	"\n" +
+	"WatchPeers\x12 .shadowy.tracker.v1.PeersRequest\x1a\x1d.shadowy.tracker.v1.PeerEvent0\x01B\x1bZ\x19shadowy-tracker/trackerpbb\x06proto3"
+
+var (
+	file_trackerpb_tracker_proto_rawDescOnce sync.Once
+	file_trackerpb_tracker_proto_rawDescData []byte
+)
+
+func file_trackerpb_tracker_proto_rawDescGZIP() []byte {
+	file_trackerpb_tracker_proto_rawDescOnce.Do(func() {
+		file_trackerpb_tracker_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_trackerpb_tracker_proto_rawDesc), len(file_trackerpb_tracker_proto_rawDesc)))
+	})
+	return file_trackerpb_tracker_proto_rawDescData
+}
+
+var file_trackerpb_tracker_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_trackerpb_tracker_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_trackerpb_tracker_proto_goTypes = []any{
+	(PeerEvent_Type)(0),       // 0: shadowy.tracker.v1.PeerEvent.Type
+	(*RegisterRequest)(nil),   // 1: shadowy.tracker.v1.RegisterRequest
+	(*RegisterResponse)(nil),  // 2: shadowy.tracker.v1.RegisterResponse
+	(*HeartbeatRequest)(nil),  // 3: shadowy.tracker.v1.HeartbeatRequest
+	(*HeartbeatResponse)(nil), // 4: shadowy.tracker.v1.HeartbeatResponse
+	(*PeersRequest)(nil),      // 5: shadowy.tracker.v1.PeersRequest
+	(*Peer)(nil),              // 6: shadowy.tracker.v1.Peer
+	(*PeersResponse)(nil),     // 7: shadowy.tracker.v1.PeersResponse
+	(*StatsRequest)(nil),      // 8: shadowy.tracker.v1.StatsRequest
+	(*NetworkStats)(nil),      // 9: shadowy.tracker.v1.NetworkStats
+	(*PeerEvent)(nil),         // 10: shadowy.tracker.v1.PeerEvent
+}
+var file_trackerpb_tracker_proto_depIdxs = []int32{
+	6,  // 0: shadowy.tracker.v1.PeersResponse.peers:type_name -> shadowy.tracker.v1.Peer
+	0,  // 1: shadowy.tracker.v1.PeerEvent.type:type_name -> shadowy.tracker.v1.PeerEvent.Type
+	6,  // 2: shadowy.tracker.v1.PeerEvent.peer:type_name -> shadowy.tracker.v1.Peer
+	1,  // 3: shadowy.tracker.v1.Tracker.Register:input_type -> shadowy.tracker.v1.RegisterRequest
+	3,  // 4: shadowy.tracker.v1.Tracker.Heartbeat:input_type -> shadowy.tracker.v1.HeartbeatRequest
+	5,  // 5: shadowy.tracker.v1.Tracker.GetPeers:input_type -> shadowy.tracker.v1.PeersRequest
+	8,  // 6: shadowy.tracker.v1.Tracker.GetStats:input_type -> shadowy.tracker.v1.StatsRequest
+	5,  // 7: shadowy.tracker.v1.Tracker.WatchPeers:input_type -> shadowy.tracker.v1.PeersRequest
+	2,  // 8: shadowy.tracker.v1.Tracker.Register:output_type -> shadowy.tracker.v1.RegisterResponse
+	4,  // 9: shadowy.tracker.v1.Tracker.Heartbeat:output_type -> shadowy.tracker.v1.HeartbeatResponse
+	7,  // 10: shadowy.tracker.v1.Tracker.GetPeers:output_type -> shadowy.tracker.v1.PeersResponse
+	9,  // 11: shadowy.tracker.v1.Tracker.GetStats:output_type -> shadowy.tracker.v1.NetworkStats
+	10, // 12: shadowy.tracker.v1.Tracker.WatchPeers:output_type -> shadowy.tracker.v1.PeerEvent
+	8,  // [8:13] is the sub-list for method output_type
+	3,  // [3:8] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_trackerpb_tracker_proto_init() }
+func file_trackerpb_tracker_proto_init() {
+	if File_trackerpb_tracker_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_trackerpb_tracker_proto_rawDesc), len(file_trackerpb_tracker_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_trackerpb_tracker_proto_goTypes,
+		DependencyIndexes: file_trackerpb_tracker_proto_depIdxs,
+		EnumInfos:         file_trackerpb_tracker_proto_enumTypes,
+		MessageInfos:      file_trackerpb_tracker_proto_msgTypes,
+	}.Build()
+	File_trackerpb_tracker_proto = out.File
+	file_trackerpb_tracker_proto_goTypes = nil
+	file_trackerpb_tracker_proto_depIdxs = nil
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gorilla/mux"
+)
+
+// Uptime history is kept per node as hourly heartbeat buckets
+// (uptime:<id>:<2006-01-02T15>), windows without heartbeats
+// (missed:<id>:<unix start>) and status changes (status:<id>:<unix nanos>).
+// Stores written before hourly buckets hold one record per UTC day
+// (uptime:<id>:<2006-01-02>); migrateDailyUptime folds those into the
+// first hour of their day.
+const (
+	uptimeKeyPrefix     = "uptime:"
+	missedKeyPrefix     = "missed:"
+	transitionKeyPrefix = "status:"
+	uptimeBucketLayout  = "2006-01-02T15"
+	legacyUptimeLayout  = "2006-01-02"
+
+	defaultMissedHeartbeatAfter = 2 * time.Minute // Nodes heartbeat every 30 seconds
+	defaultUptimeRange          = "7d"
+	uptimeRetentionDays         = 90
+)
+
+// uptimeBucket is what the store keeps for one node and hour
+type uptimeBucket struct {
+	Start          time.Time `json:"start"`
+	Heartbeats     int       `json:"heartbeats"`
+	OnlineSeconds  int64     `json:"online_seconds"`
+	FirstHeartbeat time.Time `json:"first_heartbeat"`
+}
+
+// UptimeBucket is a node's heartbeats and time online in one hour or day
+type UptimeBucket struct {
+	Start         time.Time `json:"start"`
+	Heartbeats    int       `json:"heartbeats"`
+	OnlineSeconds int64     `json:"online_seconds"`
+	UptimePercent float64   `json:"uptime_percent"`
+}
+
+// MissedWindow is a stretch in which a node sent no heartbeats for longer
+// than the missed heartbeat threshold
+type MissedWindow struct {
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	DurationSeconds int64     `json:"duration_seconds"`
+	Ongoing         bool      `json:"ongoing,omitempty"` // Still no heartbeat
+}
+
+// StatusTransition is a change in a node's reported status. From is empty
+// when the node was first seen; To is "offline" once heartbeats were
+// missed and "removed" when cleanup purged the node.
+type StatusTransition struct {
+	Time time.Time `json:"time"`
+	From string    `json:"from,omitempty"`
+	To   string    `json:"to"`
+}
+
+// UptimeReport is a node's reliability over a range
+type UptimeReport struct {
+	NodeID          string             `json:"node_id"`
+	Range           string             `json:"range"`
+	From            time.Time          `json:"from"`
+	To              time.Time          `json:"to"`
+	UptimePercent   float64            `json:"uptime_percent"` // Of the time observed in the range
+	OnlineSeconds   int64              `json:"online_seconds"`
+	ObservedSeconds int64              `json:"observed_seconds"` // From the first heartbeat in the range
+	Heartbeats      int                `json:"heartbeats"`
+	Buckets         []UptimeBucket     `json:"buckets"` // Hourly for ranges in hours, daily for days
+	Missed          []MissedWindow     `json:"missed_heartbeats"`
+	Transitions     []StatusTransition `json:"transitions"`
+	Persisted       bool               `json:"persisted"`
+}
+
+// missedAfterFromEnv reads TRACKER_MISSED_HEARTBEAT_AFTER, the silence
+// after which a node counts as having missed heartbeats
+func missedAfterFromEnv() time.Duration {
+	return durationFromEnv("TRACKER_MISSED_HEARTBEAT_AFTER", defaultMissedHeartbeatAfter, time.Second)
+}
+
+func uptimeKey(nodeID string, at time.Time) []byte {
+	return []byte(uptimeKeyPrefix + nodeID + ":" + at.UTC().Format(uptimeBucketLayout))
+}
+
+func missedKey(nodeID string, from time.Time) []byte {
+	return []byte(fmt.Sprintf("%s%s:%016d", missedKeyPrefix, nodeID, from.Unix()))
+}
+
+func transitionKey(nodeID string, at time.Time) []byte {
+	return []byte(fmt.Sprintf("%s%s:%019d", transitionKeyPrefix, nodeID, at.UnixNano()))
+}
+
+// RecordHeartbeat counts a heartbeat at now into its hour. The time since
+// the previous heartbeat counts as online, spread over the hours it spans,
+// unless it is longer than missedAfter; then it is recorded as a missed
+// window and reported as missed.
+func (s *NodeStore) RecordHeartbeat(nodeID string, previous, now time.Time, missedAfter time.Duration) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	gap := now.Sub(previous)
+	missed := !previous.IsZero() && gap > missedAfter
+
+	err := s.db.Update(func(txn *badger.Txn) error {
+		bucket, err := getUptimeBucket(txn, nodeID, now)
+		if err != nil {
+			return err
+		}
+		bucket.Heartbeats++
+		if bucket.FirstHeartbeat.IsZero() {
+			bucket.FirstHeartbeat = now.UTC()
+		}
+		if err := putJSON(txn, uptimeKey(nodeID, now), bucket); err != nil {
+			return err
+		}
+
+		switch {
+		case missed:
+			return putJSON(txn, missedKey(nodeID, previous), MissedWindow{
+				From: previous.UTC(), To: now.UTC(), DurationSeconds: int64(gap / time.Second),
+			})
+		case !previous.IsZero() && gap > 0:
+			for from := previous; from.Before(now); {
+				to := from.UTC().Truncate(time.Hour).Add(time.Hour)
+				if to.After(now) {
+					to = now
+				}
+				bucket, err := getUptimeBucket(txn, nodeID, from)
+				if err != nil {
+					return err
+				}
+				bucket.OnlineSeconds += int64(to.Sub(from) / time.Second)
+				if err := putJSON(txn, uptimeKey(nodeID, from), bucket); err != nil {
+					return err
+				}
+				from = to
+			}
+		}
+		return nil
+	})
+	return missed, err
+}
+
+// getUptimeBucket reads the bucket of the hour holding at, empty if none
+func getUptimeBucket(txn *badger.Txn, nodeID string, at time.Time) (*uptimeBucket, error) {
+	bucket := &uptimeBucket{Start: at.UTC().Truncate(time.Hour)}
+	item, err := txn.Get(uptimeKey(nodeID, at))
+	if err == badger.ErrKeyNotFound {
+		return bucket, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bucket, item.Value(func(val []byte) error {
+		return json.Unmarshal(val, bucket)
+	})
+}
+
+func putJSON(txn *badger.Txn, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return txn.Set(key, data)
+}
+
+// legacyUptimeDay is a daily uptime record from before hourly buckets
+type legacyUptimeDay struct {
+	Heartbeats    int   `json:"heartbeats"`
+	OnlineSeconds int64 `json:"online_seconds"`
+}
+
+// migrateDailyUptime rewrites daily uptime records as the bucket of the
+// first hour of their day, merged with any bucket already there, returning
+// how many were migrated. Daily totals and reports stay the same; the day's
+// time online shows in its first hour of an hourly report.
+func (s *NodeStore) migrateDailyUptime() (int, error) {
+	type legacy struct {
+		key    []byte
+		nodeID string
+		day    time.Time
+		record legacyUptimeDay
+	}
+	var records []legacy
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(uptimeKeyPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := string(it.Item().Key())
+			i := strings.LastIndex(key, ":")
+			day, err := time.Parse(legacyUptimeLayout, key[i+1:])
+			if err != nil {
+				continue // Already an hourly bucket
+			}
+			record := legacy{key: it.Item().KeyCopy(nil), nodeID: key[len(uptimeKeyPrefix):i], day: day}
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &record.record)
+			}); err != nil {
+				log.Printf("⚠️ Dropping unreadable uptime record %s: %v", key, err)
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// One transaction per record, so a large history cannot outgrow one
+	for _, record := range records {
+		err := s.db.Update(func(txn *badger.Txn) error {
+			bucket, err := getUptimeBucket(txn, record.nodeID, record.day)
+			if err != nil {
+				return err
+			}
+			bucket.Heartbeats += record.record.Heartbeats
+			bucket.OnlineSeconds += record.record.OnlineSeconds
+			if bucket.FirstHeartbeat.IsZero() || record.day.Before(bucket.FirstHeartbeat) {
+				bucket.FirstHeartbeat = record.day
+			}
+			if err := putJSON(txn, uptimeKey(record.nodeID, record.day), bucket); err != nil {
+				return err
+			}
+			return txn.Delete(record.key)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(records), nil
+}
+
+// RecordTransition stores a change in a node's status
+func (s *NodeStore) RecordTransition(nodeID string, transition StatusTransition) error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return putJSON(txn, transitionKey(nodeID, transition.Time), transition)
+	})
+}
+
+// uptimeHistory reads a node's hourly buckets, missed windows and status
+// changes between from and to, oldest first
+func (s *NodeStore) uptimeHistory(nodeID string, from, to time.Time) ([]uptimeBucket, []MissedWindow, []StatusTransition, error) {
+	buckets, missed, transitions := []uptimeBucket{}, []MissedWindow{}, []StatusTransition{}
+	if s == nil {
+		return buckets, missed, transitions, nil
+	}
+	err := s.db.View(func(txn *badger.Txn) error {
+		err := eachJSON(txn, uptimeKeyPrefix+nodeID+":", func(bucket *uptimeBucket) {
+			if !bucket.Start.Before(from.Truncate(time.Hour)) && bucket.Start.Before(to) {
+				buckets = append(buckets, *bucket)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		// A window that began before the range still counts where it overlaps
+		err = eachJSON(txn, missedKeyPrefix+nodeID+":", func(window *MissedWindow) {
+			if window.To.After(from) && window.From.Before(to) {
+				missed = append(missed, *window)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return eachJSON(txn, transitionKeyPrefix+nodeID+":", func(transition *StatusTransition) {
+			if !transition.Time.Before(from) && transition.Time.Before(to) {
+				transitions = append(transitions, *transition)
+			}
+		})
+	})
+	return buckets, missed, transitions, err
+}
+
+// eachJSON decodes every value under prefix in key order, skipping values
+// that do not decode
+func eachJSON[T any](txn *badger.Txn, prefix string, fn func(*T)) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefix)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		var value T
+		if err := it.Item().Value(func(val []byte) error {
+			return json.Unmarshal(val, &value)
+		}); err != nil {
+			continue
+		}
+		fn(&value)
+	}
+	return nil
+}
+
+// PruneUptime drops uptime history from before cutoff, returning how many
+// records were removed
+func (s *NodeStore) PruneUptime(cutoff time.Time) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+	// Each family's key suffix sorts in time order
+	oldest := map[string]string{
+		uptimeKeyPrefix:     cutoff.UTC().Format(uptimeBucketLayout),
+		missedKeyPrefix:     fmt.Sprintf("%016d", cutoff.Unix()),
+		transitionKeyPrefix: fmt.Sprintf("%019d", cutoff.UnixNano()),
+	}
+	var stale [][]byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		for prefix, before := range oldest {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = []byte(prefix)
+			opts.PrefetchValues = false
+			it := txn.NewIterator(opts)
+			for it.Rewind(); it.Valid(); it.Next() {
+				key := string(it.Item().Key())
+				if key[strings.LastIndex(key, ":")+1:] < before {
+					stale = append(stale, it.Item().KeyCopy(nil))
+				}
+			}
+			it.Close()
+		}
+		return nil
+	})
+	if err != nil || len(stale) == 0 {
+		return 0, err
+	}
+
+	batch := s.db.NewWriteBatch()
+	defer batch.Cancel()
+	for _, key := range stale {
+		if err := batch.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), batch.Flush()
+}
+
+// recordUptime counts a node's latest heartbeat toward its uptime and
+// records how its status changed since the previous one, going through
+// offline when heartbeats were missed in between
+func (ts *TrackerService) recordUptime(node *RegisteredNode, previous time.Time, previousStatus string) {
+	missed, err := ts.store.RecordHeartbeat(node.NodeID, previous, node.LastHeartbeat, ts.missedAfter)
+	if err != nil {
+		log.Printf("⚠️ Failed to record uptime of %s: %v", node.NodeID, err)
+		return
+	}
+	if missed {
+		ts.recordTransition(node.NodeID, previous.Add(ts.missedAfter), previousStatus, "offline")
+		previousStatus = "offline"
+	}
+	if node.Status != previousStatus {
+		ts.recordTransition(node.NodeID, node.LastHeartbeat, previousStatus, node.Status)
+	}
+}
+
+func (ts *TrackerService) recordTransition(nodeID string, at time.Time, from, to string) {
+	transition := StatusTransition{Time: at.UTC(), From: from, To: to}
+	if err := ts.store.RecordTransition(nodeID, transition); err != nil {
+		log.Printf("⚠️ Failed to record status of %s: %v", nodeID, err)
+	}
+}
+
+// parseUptimeRange reads a range of 1h-168h or 1d-90d, returning its
+// length and whether buckets are reported daily
+func parseUptimeRange(value string) (time.Duration, bool, error) {
+	invalid := fmt.Errorf("range must be 1h-168h or 1d-%dd", uptimeRetentionDays)
+	if len(value) < 2 {
+		return 0, false, invalid
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 1 {
+		return 0, false, invalid
+	}
+	switch value[len(value)-1] {
+	case 'h':
+		if n <= 168 {
+			return time.Duration(n) * time.Hour, false, nil
+		}
+	case 'd':
+		if n <= uptimeRetentionDays {
+			return time.Duration(n) * 24 * time.Hour, true, nil
+		}
+	}
+	return 0, false, invalid
+}
+
+// buildUptimeReport sums a node's history over [from, to]. Buckets are
+// rolled up by UTC day when daily; time before the node's first heartbeat
+// in the range is not counted against it.
+func buildUptimeReport(buckets []uptimeBucket, from, to time.Time, daily bool) UptimeReport {
+	report := UptimeReport{From: from, To: to, Buckets: []UptimeBucket{}}
+	observedFrom := to
+	for _, bucket := range buckets {
+		report.Heartbeats += bucket.Heartbeats
+		report.OnlineSeconds += bucket.OnlineSeconds
+		if !bucket.FirstHeartbeat.IsZero() && bucket.FirstHeartbeat.Before(observedFrom) {
+			observedFrom = bucket.FirstHeartbeat
+		}
+
+		start := bucket.Start
+		if daily {
+			start = start.Truncate(24 * time.Hour)
+		}
+		if n := len(report.Buckets); n > 0 && report.Buckets[n-1].Start.Equal(start) {
+			report.Buckets[n-1].Heartbeats += bucket.Heartbeats
+			report.Buckets[n-1].OnlineSeconds += bucket.OnlineSeconds
+			continue
+		}
+		report.Buckets = append(report.Buckets, UptimeBucket{Start: start, Heartbeats: bucket.Heartbeats, OnlineSeconds: bucket.OnlineSeconds})
+	}
+
+	span := time.Hour
+	if daily {
+		span = 24 * time.Hour
+	}
+	for i := range report.Buckets {
+		report.Buckets[i].UptimePercent = percentOf(report.Buckets[i].OnlineSeconds, bucketSeconds(report.Buckets[i].Start, span, to))
+	}
+
+	if observedFrom.Before(from) {
+		observedFrom = from
+	}
+	report.ObservedSeconds = int64(to.Sub(observedFrom) / time.Second)
+	report.UptimePercent = percentOf(report.OnlineSeconds, report.ObservedSeconds)
+	return report
+}
+
+// bucketSeconds is how much of a bucket has passed by now
+func bucketSeconds(start time.Time, span time.Duration, now time.Time) int64 {
+	if end := start.Add(span); end.Before(now) {
+		return int64(span / time.Second)
+	}
+	return int64(now.Sub(start) / time.Second)
+}
+
+func percentOf(part, whole int64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return min(float64(part)*100/float64(whole), 100)
+}
+
+// handleGetNodeUptime reports a node's reliability over ?range= (default
+// 7d): its uptime, hourly or daily buckets, the windows in which it missed
+// heartbeats and its status changes. ?days=N, from before ranges, is read
+// as ?range=Nd.
+func (ts *TrackerService) handleGetNodeUptime(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["nodeId"]
+
+	rangeValue := r.URL.Query().Get("range")
+	if days := r.URL.Query().Get("days"); rangeValue == "" && days != "" {
+		rangeValue = days + "d"
+	}
+	if rangeValue == "" {
+		rangeValue = defaultUptimeRange
+	}
+	length, daily, err := parseUptimeRange(rangeValue)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-length)
+	buckets, missed, transitions, err := ts.store.uptimeHistory(nodeID, from, to)
+	if err != nil {
+		http.Error(w, "Failed to read uptime", http.StatusInternalServerError)
+		return
+	}
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	node, exists := ts.nodes[nodeID]
+	if !exists && len(buckets) == 0 {
+		http.Error(w, "Node not found", http.StatusNotFound)
+		return
+	}
+
+	report := buildUptimeReport(buckets, from, to, daily)
+	report.NodeID, report.Range, report.Persisted = nodeID, rangeValue, ts.store != nil
+	report.Missed, report.Transitions = missed, transitions
+
+	// A node that has gone quiet is missing heartbeats right now
+	if exists && !node.LastHeartbeat.IsZero() && to.Sub(node.LastHeartbeat) > ts.missedAfter {
+		report.Missed = append(report.Missed, MissedWindow{From: node.LastHeartbeat.UTC(), To: to,
+			DurationSeconds: int64(to.Sub(node.LastHeartbeat) / time.Second), Ongoing: true})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
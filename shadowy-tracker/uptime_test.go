@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRecordHeartbeatBuckets(t *testing.T) {
+	store := openTestStore(t, t.TempDir())
+	defer store.Close()
+
+	hour := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	beats := []struct {
+		previous, now time.Time
+		missed        bool
+	}{
+		{time.Time{}, hour.Add(-time.Minute), false},                  // First heartbeat counts no time
+		{hour.Add(-time.Minute), hour.Add(time.Minute), false},        // A minute in each hour
+		{hour.Add(time.Minute), hour.Add(2 * time.Minute), false},     // One more
+		{hour.Add(2 * time.Minute), hour.Add(20 * time.Minute), true}, // Silent too long
+		{hour.Add(20 * time.Minute), hour.Add(21 * time.Minute), false},
+	}
+	for _, beat := range beats {
+		missed, err := store.RecordHeartbeat("node-1", beat.previous, beat.now, 2*time.Minute)
+		if err != nil {
+			t.Fatalf("RecordHeartbeat failed: %v", err)
+		}
+		if missed != beat.missed {
+			t.Errorf("Heartbeat at %s: expected missed %v", beat.now, beat.missed)
+		}
+	}
+
+	buckets, missed, _, err := store.uptimeHistory("node-1", hour.Add(-24*time.Hour), hour.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("uptimeHistory failed: %v", err)
+	}
+	if len(buckets) != 2 || buckets[0].Heartbeats != 1 || buckets[0].OnlineSeconds != 60 ||
+		buckets[1].Heartbeats != 4 || buckets[1].OnlineSeconds != 3*60 {
+		t.Errorf("Unexpected buckets %+v", buckets)
+	}
+	if len(missed) != 1 || !missed[0].From.Equal(hour.Add(2*time.Minute)) || missed[0].DurationSeconds != 18*60 {
+		t.Errorf("Expected one 18 minute window, got %+v", missed)
+	}
+
+	if removed, err := store.PruneUptime(hour.Add(time.Minute)); err != nil || removed != 1 {
+		t.Errorf("Expected the earlier hour pruned, got %d (%v)", removed, err)
+	}
+}
+
+func TestBuildUptimeReport(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	buckets := []uptimeBucket{
+		{Start: day.Add(12 * time.Hour), Heartbeats: 100, OnlineSeconds: 1800, FirstHeartbeat: day.Add(12 * time.Hour)},
+		{Start: day.Add(13 * time.Hour), Heartbeats: 120, OnlineSeconds: 3600, FirstHeartbeat: day.Add(13 * time.Hour)},
+		{Start: day.Add(24 * time.Hour), Heartbeats: 120, OnlineSeconds: 3600, FirstHeartbeat: day.Add(24 * time.Hour)},
+	}
+	to := day.Add(25 * time.Hour)
+
+	report := buildUptimeReport(buckets, to.Add(-7*24*time.Hour), to, true)
+	if len(report.Buckets) != 2 || report.Buckets[0].OnlineSeconds != 5400 || report.Heartbeats != 340 {
+		t.Errorf("Expected two daily buckets, got %+v", report.Buckets)
+	}
+	// Observed from the first heartbeat, 13 hours before the end
+	if report.ObservedSeconds != 13*3600 || report.UptimePercent != float64(9000)*100/float64(13*3600) {
+		t.Errorf("Unexpected observed time %d and uptime %v", report.ObservedSeconds, report.UptimePercent)
+	}
+
+	hourly := buildUptimeReport(buckets, to.Add(-24*time.Hour), to, false)
+	if len(hourly.Buckets) != 3 || hourly.Buckets[0].UptimePercent != 50 || hourly.Buckets[2].UptimePercent != 100 {
+		t.Errorf("Unexpected hourly buckets %+v", hourly.Buckets)
+	}
+}
+
+func TestParseUptimeRange(t *testing.T) {
+	if length, daily, err := parseUptimeRange("7d"); err != nil || length != 7*24*time.Hour || !daily {
+		t.Errorf("Unexpected 7d: %s %v %v", length, daily, err)
+	}
+	if length, daily, err := parseUptimeRange("24h"); err != nil || length != 24*time.Hour || daily {
+		t.Errorf("Unexpected 24h: %s %v %v", length, daily, err)
+	}
+	for _, value := range []string{"", "d", "0d", "91d", "169h", "7w", "-1h"} {
+		if _, _, err := parseUptimeRange(value); err == nil {
+			t.Errorf("Expected range %q rejected", value)
+		}
+	}
+}
+
+func TestNodeUptimeAPI(t *testing.T) {
+	ts := NewTrackerService()
+	ts.store = openTestStore(t, t.TempDir())
+	defer ts.store.Close()
+	addTestNode(ts, "node-1", 10, tib)
+	node := ts.nodes["node-1"]
+
+	// Registered, then silent for 5 minutes, then back syncing
+	now := time.Now().Truncate(time.Second)
+	node.LastHeartbeat, node.Status = now.Add(-6*time.Minute), "online"
+	ts.recordUptime(node, time.Time{}, "")
+	node.Status = "syncing"
+	previous := node.LastHeartbeat
+	node.LastHeartbeat = now.Add(-time.Minute)
+	ts.recordUptime(node, previous, "online")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/node/{nodeId}/uptime", ts.handleGetNodeUptime)
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		return rec
+	}
+
+	var report UptimeReport
+	json.NewDecoder(get("/api/v1/node/node-1/uptime?range=24h").Body).Decode(&report)
+	if report.Range != "24h" || report.Heartbeats != 2 || !report.Persisted {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if len(report.Missed) != 1 || report.Missed[0].DurationSeconds != 5*60 || report.Missed[0].Ongoing {
+		t.Errorf("Expected one 5 minute window, got %+v", report.Missed)
+	}
+	want := []StatusTransition{{To: "online"}, {From: "online", To: "offline"}, {From: "offline", To: "syncing"}}
+	if len(report.Transitions) != len(want) {
+		t.Fatalf("Expected transitions %+v, got %+v", want, report.Transitions)
+	}
+	for i := range want {
+		if report.Transitions[i].From != want[i].From || report.Transitions[i].To != want[i].To {
+			t.Errorf("Expected transition %d %+v, got %+v", i, want[i], report.Transitions[i])
+		}
+	}
+
+	// Going quiet shows as an ongoing window
+	node.LastHeartbeat = now.Add(-10 * time.Minute)
+	json.NewDecoder(get("/api/v1/node/node-1/uptime").Body).Decode(&report)
+	if report.Range != "7d" || len(report.Missed) != 2 || !report.Missed[1].Ongoing {
+		t.Errorf("Expected an ongoing window, got %+v", report.Missed)
+	}
+
+	// ?days= still selects a range in days
+	json.NewDecoder(get("/api/v1/node/node-1/uptime?days=30").Body).Decode(&report)
+	if report.Range != "30d" || report.Heartbeats != 2 {
+		t.Errorf("Expected ?days=30 read as 30d, got %+v", report)
+	}
+
+	if rec := get("/api/v1/node/node-1/uptime?range=91d"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected too long a range rejected, got %d", rec.Code)
+	}
+	if rec := get("/api/v1/node/node-1/uptime?days=366"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected too many days rejected, got %d", rec.Code)
+	}
+	if rec := get("/api/v1/node/ghost/uptime"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown node to 404, got %d", rec.Code)
+	}
+}
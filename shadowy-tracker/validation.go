@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FieldError describes one invalid field in a request payload
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse is returned with a 400 when a payload fails validation
+type ValidationErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
+
+// ValidateRegistration checks a registration payload field by field and
+// returns every problem found, or nil if the payload is well formed
+func ValidateRegistration(req *RegistrationRequest) []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(req.NodeID) == "" {
+		add("node_id", "is required")
+	}
+
+	if req.MiningAddr == "" {
+		add("mining_address", "is required")
+	} else if len(req.MiningAddr) < 16 {
+		add("mining_address", "must be at least 16 characters, got %d", len(req.MiningAddr))
+	}
+
+	if req.PublicKey == "" {
+		add("public_key", "is required")
+	} else if _, err := hex.DecodeString(req.PublicKey); err != nil {
+		add("public_key", "must be hex encoded")
+	}
+
+	if req.Signature == "" {
+		add("signature", "is required")
+	} else if _, err := hex.DecodeString(req.Signature); err != nil {
+		add("signature", "must be hex encoded")
+	}
+
+	if req.ChainID == "" {
+		add("chain_id", "is required")
+	}
+
+	if req.P2PPort < 1 || req.P2PPort > 65535 {
+		add("p2p_port", "must be between 1 and 65535, got %d", req.P2PPort)
+	}
+	if req.HTTPPort < 1 || req.HTTPPort > 65535 {
+		add("http_port", "must be between 1 and 65535, got %d", req.HTTPPort)
+	}
+
+	if req.Timestamp == "" {
+		add("timestamp", "is required")
+	} else if _, err := time.Parse(time.RFC3339, req.Timestamp); err != nil {
+		add("timestamp", "must be an RFC3339 timestamp")
+	}
+
+	if req.LastBlockTime != "" {
+		if _, err := time.Parse(time.RFC3339, req.LastBlockTime); err != nil {
+			add("last_block_time", "must be an RFC3339 timestamp")
+		}
+	}
+
+	if req.PlotCount < 0 {
+		add("plot_count", "must not be negative, got %d", req.PlotCount)
+	}
+
+	return errs
+}
+
+// decodeErrorFields turns a JSON decode error into field errors, naming the
+// offending field when the decoder knows it
+func decodeErrorFields(err error) []FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be of type %s, got %s", typeErr.Type, typeErr.Value),
+		}}
+	}
+	return []FieldError{{Field: "body", Message: fmt.Sprintf("invalid JSON: %v", err)}}
+}
+
+// writeValidationError responds with a 400 listing the invalid fields
+func writeValidationError(w http.ResponseWriter, message string, fields []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(ValidationErrorResponse{
+		Error:  message,
+		Fields: fields,
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// validRegistration returns a payload that passes validation, signed with
+// the test key
+func validRegistration() map[string]interface{} {
+	req := map[string]interface{}{
+		"node_id":               "node-1",
+		"mining_address":        testAddress,
+		"public_key":            testPublicKeyHex,
+		"external_ip":           "203.0.113.10",
+		"p2p_port":              26656,
+		"http_port":             8080,
+		"chain_height":          42,
+		"chain_hash":            "deadbeef",
+		"chain_id":              testnet0,
+		"last_block_time":       "2025-01-01T00:00:00Z",
+		"software_version":      "1.0.0",
+		"total_plot_size_bytes": 1 << 30,
+		"plot_count":            1,
+		"timestamp":             time.Now().UTC().Format(time.RFC3339),
+	}
+	signRegistration(req)
+	return req
+}
+
+func postRegistration(t *testing.T, ts *TrackerService, payload interface{}) *httptest.ResponseRecorder {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Failed to marshal payload: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	ts.handleRegister(rec, httptest.NewRequest("POST", "/api/v1/register", bytes.NewReader(body)))
+	return rec
+}
+
+func TestRegisterAcceptsValidPayload(t *testing.T) {
+	ts := NewTrackerService()
+	rec := postRegistration(t, ts, validRegistration())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := ts.nodes["node-1"]; !ok {
+		t.Error("Valid registration should be stored")
+	}
+}
+
+func TestRegisterReportsOffendingField(t *testing.T) {
+	cases := []struct {
+		name   string
+		mutate func(map[string]interface{})
+		field  string
+	}{
+		{"missing node id", func(p map[string]interface{}) { delete(p, "node_id") }, "node_id"},
+		{"empty public key", func(p map[string]interface{}) { p["public_key"] = "" }, "public_key"},
+		{"non-hex public key", func(p map[string]interface{}) { p["public_key"] = "not-hex" }, "public_key"},
+		{"p2p port out of range", func(p map[string]interface{}) { p["p2p_port"] = 70000 }, "p2p_port"},
+		{"zero http port", func(p map[string]interface{}) { p["http_port"] = 0 }, "http_port"},
+		{"bad timestamp", func(p map[string]interface{}) { p["timestamp"] = "yesterday" }, "timestamp"},
+		{"bad last block time", func(p map[string]interface{}) { p["last_block_time"] = "2025-13-45" }, "last_block_time"},
+		{"short mining address", func(p map[string]interface{}) { p["mining_address"] = "S123" }, "mining_address"},
+		{"wrong type", func(p map[string]interface{}) { p["chain_height"] = "tall" }, "chain_height"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := validRegistration()
+			tc.mutate(payload)
+
+			rec := postRegistration(t, NewTrackerService(), payload)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("Expected 400, got %d", rec.Code)
+			}
+
+			var resp ValidationErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Expected structured error, got %v", err)
+			}
+			if len(resp.Fields) != 1 || resp.Fields[0].Field != tc.field {
+				t.Errorf("Expected only %s to be reported, got %+v", tc.field, resp.Fields)
+			}
+		})
+	}
+}
+
+func TestRegisterReportsAllInvalidFields(t *testing.T) {
+	payload := validRegistration()
+	payload["p2p_port"] = -1
+	payload["signature"] = ""
+	payload["chain_id"] = ""
+
+	rec := postRegistration(t, NewTrackerService(), payload)
+
+	var resp ValidationErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	got := map[string]bool{}
+	for _, f := range resp.Fields {
+		got[f.Field] = true
+	}
+	for _, field := range []string{"p2p_port", "signature", "chain_id"} {
+		if !got[field] {
+			t.Errorf("Expected %s in %+v", field, resp.Fields)
+		}
+	}
+}
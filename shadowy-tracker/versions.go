@@ -0,0 +1,152 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VersionPolicy sets the oldest node software still considered current.
+// Nodes running anything older, or a version that cannot be parsed, are
+// deprecated: they may still register and heartbeat, but are left out of
+// the peers handed to up-to-date clients so upgraded nodes find each other.
+type VersionPolicy struct {
+	MinVersion string // Empty deprecates nothing
+}
+
+// versionPolicyFromEnv reads TRACKER_MIN_VERSION, e.g. "1.4.0"
+func versionPolicyFromEnv() VersionPolicy {
+	policy := VersionPolicy{MinVersion: os.Getenv("TRACKER_MIN_VERSION")}
+	if policy.MinVersion != "" {
+		if _, ok := parseVersion(policy.MinVersion); !ok {
+			log.Printf("⚠️ Ignoring invalid TRACKER_MIN_VERSION %q", policy.MinVersion)
+			return VersionPolicy{}
+		}
+		log.Printf("📦 Nodes older than %s are deprecated", policy.MinVersion)
+	}
+	return policy
+}
+
+// Deprecated reports whether version is older than the policy allows
+func (p VersionPolicy) Deprecated(version string) bool {
+	if p.MinVersion == "" {
+		return false
+	}
+	if _, ok := parseVersion(version); !ok {
+		return true
+	}
+	return compareVersions(version, p.MinVersion) < 0
+}
+
+// softwareVersion is a parsed "v1.2.3-beta.1+build" style version
+type softwareVersion struct {
+	numbers    []int
+	prerelease string
+}
+
+// parseVersion reads a dotted numeric version with an optional leading
+// "v", "-prerelease" and "+build", which is ignored
+func parseVersion(version string) (softwareVersion, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.Index(version, "+"); idx != -1 {
+		version = version[:idx]
+	}
+	var parsed softwareVersion
+	if idx := strings.Index(version, "-"); idx != -1 {
+		version, parsed.prerelease = version[:idx], version[idx+1:]
+	}
+	if version == "" {
+		return softwareVersion{}, false
+	}
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return softwareVersion{}, false
+		}
+		parsed.numbers = append(parsed.numbers, n)
+	}
+	return parsed, true
+}
+
+// compareVersions orders two versions, returning -1, 0 or 1. Missing
+// components count as zero and a prerelease comes before its release.
+// Versions that cannot be parsed sort before every version that can.
+func compareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+
+	for i := 0; i < len(va.numbers) || i < len(vb.numbers); i++ {
+		var na, nb int
+		if i < len(va.numbers) {
+			na = va.numbers[i]
+		}
+		if i < len(vb.numbers) {
+			nb = vb.numbers[i]
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case va.prerelease == vb.prerelease:
+		return 0
+	case va.prerelease == "":
+		return 1
+	case vb.prerelease == "":
+		return -1
+	}
+	return strings.Compare(va.prerelease, vb.prerelease)
+}
+
+// applyVersionPolicy marks a node deprecated when its software is too old
+func (ts *TrackerService) applyVersionPolicy(node *RegisteredNode) {
+	node.Deprecated = ts.versions.Deprecated(node.SoftwareVersion)
+}
+
+// VersionCount is the nodes running one software version
+type VersionCount struct {
+	Version     string `json:"version"`
+	Nodes       int    `json:"nodes"`
+	OnlineNodes int    `json:"online_nodes"`
+	Netspace    uint64 `json:"netspace_bytes"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+// versionsOf groups nodes by software version, newest first
+func versionsOf(nodes map[string]*RegisteredNode) []VersionCount {
+	byVersion := make(map[string]*VersionCount)
+	for _, node := range nodes {
+		count, ok := byVersion[node.SoftwareVersion]
+		if !ok {
+			count = &VersionCount{Version: node.SoftwareVersion, Deprecated: node.Deprecated}
+			byVersion[node.SoftwareVersion] = count
+		}
+		count.Nodes++
+		if isOnline(node) {
+			count.OnlineNodes++
+		}
+		count.Netspace += node.TotalPlotSize
+	}
+
+	counts := make([]VersionCount, 0, len(byVersion))
+	for _, count := range byVersion {
+		counts = append(counts, *count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return compareVersions(counts[i].Version, counts[j].Version) > 0
+	})
+	return counts
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3+build.7", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.9", 1},
+		{"1.2.0-beta", "1.2.0", -1},
+		{"1.2.0-beta", "1.2.0-alpha", 1},
+		{"dev", "0.0.1", -1},
+	}
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+
+	policy := VersionPolicy{MinVersion: "1.4.0"}
+	for version, want := range map[string]bool{"1.3.9": true, "1.4.0-rc1": true, "dev": true, "1.4.0": false, "2.0": false} {
+		if got := policy.Deprecated(version); got != want {
+			t.Errorf("Deprecated(%q) = %v, want %v", version, got, want)
+		}
+	}
+	if (VersionPolicy{}).Deprecated("dev") {
+		t.Error("Expected no minimum to deprecate nothing")
+	}
+}
+
+func TestRegisterMarksDeprecatedVersions(t *testing.T) {
+	ts := NewTrackerService()
+	ts.versions = VersionPolicy{MinVersion: "1.4.0"}
+
+	rec := postRegistration(t, ts, validRegistration())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected outdated nodes to still register, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Deprecated bool   `json:"deprecated"`
+		MinVersion string `json:"min_version"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if !resp.Deprecated || resp.MinVersion != "1.4.0" || !ts.nodes["node-1"].Deprecated {
+		t.Errorf("Expected node-1 on 1.0.0 to be told it is deprecated, got %+v", resp)
+	}
+}
+
+func TestPeersHideDeprecatedFromCurrentClients(t *testing.T) {
+	ts := NewTrackerService()
+	ts.versions = VersionPolicy{MinVersion: "1.4.0"}
+	for id, version := range map[string]string{"node-old": "1.3.0", "node-new": "1.4.2", "node-rc1": "1.5.0-rc1"} {
+		addTestNode(ts, id, 100, tib)
+		ts.nodes[id].SoftwareVersion = version
+		ts.applyVersionPolicy(ts.nodes[id])
+	}
+
+	if ids, _ := queryPeers(t, ts, "version=1.4.2"); strings.Join(ids, ",") != "node-new,node-rc1" {
+		t.Errorf("Expected a current client to get current peers only, got %v", ids)
+	}
+	for _, query := range []string{"version=1.3.0", ""} {
+		if ids, _ := queryPeers(t, ts, query); len(ids) != 3 {
+			t.Errorf("Expected %q to get every peer, got %v", query, ids)
+		}
+	}
+
+	stats := ts.calculateNetworkStats()
+	if stats.DeprecatedNodes != 1 || stats.MinVersion != "1.4.0" || len(stats.Versions) != 3 {
+		t.Fatalf("Unexpected version stats %+v", stats)
+	}
+	if stats.Versions[0].Version != "1.5.0-rc1" || !stats.Versions[2].Deprecated || stats.Versions[2].Nodes != 1 {
+		t.Errorf("Expected versions newest first with 1.3.0 deprecated, got %+v", stats.Versions)
+	}
+}
@@ -6,8 +6,14 @@ echo "🔨 Building Shadowy WASM Library..."
 export GOOS=js
 export GOARCH=wasm
 
+# Production builds log warnings and errors only; --debug logs everything
+LDFLAGS=""
+if [ "$1" = "--debug" ]; then
+    LDFLAGS="-X main.defaultLogLevel=debug"
+fi
+
 # Build the WASM module
-go build -o shadowy.wasm main.go
+go build -ldflags "$LDFLAGS" -o shadowy.wasm .
 
 if [ $? -eq 0 ]; then
     echo "✅ WASM build successful: shadowy.wasm"
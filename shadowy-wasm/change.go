@@ -0,0 +1,61 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// DefaultDustThreshold is the smallest change output worth creating, in
+// satoshis. Smaller change costs more to spend than it is worth.
+const DefaultDustThreshold uint64 = 1000
+
+// dustThreshold is the change amount below which change is paid as fee
+var dustThreshold = DefaultDustThreshold
+
+// buildPaymentOutputs creates the payment output and, unless the change
+// would be dust, a change output. It returns the outputs and the fee
+// actually paid, which includes any change folded into it.
+func buildPaymentOutputs(destination, changeAddress string, amount, fee, totalSelected uint64) ([]TransactionOutput, uint64) {
+	outputs := []TransactionOutput{{
+		Value:        amount,
+		ScriptPubkey: fmt.Sprintf("OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG", destination[1:41]),
+		Address:      destination,
+	}}
+
+	if totalSelected <= amount+fee {
+		return outputs, fee
+	}
+
+	change := totalSelected - amount - fee
+	if change < dustThreshold {
+		logDebugf("🧹 Folding %d satoshis of dust change into the fee", change)
+		return outputs, fee + change
+	}
+
+	outputs = append(outputs, TransactionOutput{
+		Value:        change,
+		ScriptPubkey: fmt.Sprintf("OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG", changeAddress[1:41]),
+		Address:      changeAddress,
+	})
+	return outputs, fee
+}
+
+// Set the change amount below which change is added to the fee instead
+func setDustThreshold(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeNumber || args[0].Float() < 0 {
+		return map[string]interface{}{
+			"success": false,
+			"error":   "Dust threshold in satoshis required",
+		}
+	}
+
+	dustThreshold = uint64(args[0].Float())
+
+	return map[string]interface{}{
+		"success":        true,
+		"dust_threshold": dustThreshold,
+	}
+}
@@ -0,0 +1,55 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import "testing"
+
+func withDustThreshold(t *testing.T, threshold uint64) {
+	previous := dustThreshold
+	dustThreshold = threshold
+	t.Cleanup(func() { dustThreshold = previous })
+}
+
+func TestDustChangeFoldedIntoFee(t *testing.T) {
+	withDustThreshold(t, 1000)
+
+	// 999 satoshis of change is below the threshold
+	outputs, fee := buildPaymentOutputs(testRecipient, testSender, 5000000, 100000, 5100999)
+	if len(outputs) != 1 || outputs[0].Address != testRecipient || outputs[0].Value != 5000000 {
+		t.Fatalf("Expected only the payment output, got %+v", outputs)
+	}
+	if fee != 100999 {
+		t.Errorf("Expected dust to be added to the fee, got fee %d", fee)
+	}
+}
+
+func TestChangeAboveThresholdKept(t *testing.T) {
+	withDustThreshold(t, 1000)
+
+	outputs, fee := buildPaymentOutputs(testRecipient, testSender, 5000000, 100000, 5101000)
+	if len(outputs) != 2 {
+		t.Fatalf("Expected payment and change outputs, got %+v", outputs)
+	}
+	if outputs[1].Address != testSender || outputs[1].Value != 1000 {
+		t.Errorf("Unexpected change output: %+v", outputs[1])
+	}
+	if fee != 100000 {
+		t.Errorf("Fee should be unchanged, got %d", fee)
+	}
+
+	// Exact spends have no change either way
+	outputs, fee = buildPaymentOutputs(testRecipient, testSender, 5000000, 100000, 5100000)
+	if len(outputs) != 1 || fee != 100000 {
+		t.Errorf("Exact spend should produce one output and the base fee, got %+v fee %d", outputs, fee)
+	}
+}
+
+func TestZeroDustThresholdAlwaysMakesChange(t *testing.T) {
+	withDustThreshold(t, 0)
+
+	outputs, fee := buildPaymentOutputs(testRecipient, testSender, 5000000, 100000, 5100001)
+	if len(outputs) != 2 || outputs[1].Value != 1 || fee != 100000 {
+		t.Errorf("Expected a 1 satoshi change output with threshold 0, got %+v fee %d", outputs, fee)
+	}
+}
@@ -0,0 +1,280 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall/js"
+)
+
+// signedEnvelope covers both a shadowy_sign_transaction result and the
+// node's signed transaction format
+type signedEnvelope struct {
+	Transaction       json.RawMessage `json:"transaction"`
+	TxHash            string          `json:"tx_hash"`
+	SignerKey         string          `json:"signer_key"`
+	Algorithm         string          `json:"algorithm"`
+	SignedTransaction *signedEnvelope `json:"signed_transaction"`
+	Fee               *uint64         `json:"fee"`
+}
+
+// parseSignedEnvelope returns the transaction body exactly as it was signed,
+// along with the envelope it came from
+func parseSignedEnvelope(data []byte) ([]byte, *signedEnvelope, error) {
+	var envelope signedEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, nil, fmt.Errorf("invalid signed transaction: %v", err)
+	}
+
+	env := &envelope
+	if envelope.SignedTransaction != nil {
+		env = envelope.SignedTransaction
+		if env.Fee == nil {
+			env.Fee = envelope.Fee
+		}
+	}
+	raw := []byte(env.Transaction)
+	if len(raw) == 0 {
+		return nil, nil, fmt.Errorf("signed transaction has no transaction body")
+	}
+
+	// The node format carries the transaction as a JSON string
+	var txJSON string
+	if err := json.Unmarshal(raw, &txJSON); err == nil {
+		raw = []byte(txJSON)
+	}
+
+	return raw, env, nil
+}
+
+// Token operation names, indexed by the node's TokenOpType
+var tokenOpNames = []string{
+	"CREATE", "TRANSFER", "MELT", "TRADE_OFFER", "TRADE_EXECUTE",
+	"SYNDICATE_JOIN", "POOL_CREATE", "POOL_SWAP",
+}
+
+// DecodedInput is an input as shown by shadowy_decode_transaction
+type DecodedInput struct {
+	TxID        string `json:"txid"`
+	Vout        uint32 `json:"vout"`
+	Sequence    uint32 `json:"sequence"`
+	Replaceable bool   `json:"replaceable"`
+}
+
+// DecodedOutput is an output with its address classified
+type DecodedOutput struct {
+	Index        int     `json:"index"`
+	Value        uint64  `json:"value"`
+	Amount       float64 `json:"amount"` // SHADOW
+	Address      string  `json:"address"`
+	AddressType  string  `json:"address_type"` // "standard" (S), "liquidity_pool" (L) or "unknown"
+	ValidAddress bool    `json:"valid_address"`
+	ScriptPubkey string  `json:"script_pubkey,omitempty"`
+}
+
+// DecodedTokenOp is a token operation with its type spelled out
+type DecodedTokenOp struct {
+	Type     int             `json:"type"`
+	TypeName string          `json:"type_name"`
+	TokenID  string          `json:"token_id"`
+	Amount   uint64          `json:"amount"`
+	From     string          `json:"from,omitempty"`
+	To       string          `json:"to,omitempty"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// DecodedTransaction is the structured view returned to UIs
+type DecodedTransaction struct {
+	Version     int              `json:"version"`
+	Timestamp   string           `json:"timestamp"`
+	Locktime    uint32           `json:"locktime"`
+	Inputs      []DecodedInput   `json:"inputs"`
+	Outputs     []DecodedOutput  `json:"outputs"`
+	TokenOps    []DecodedTokenOp `json:"token_ops"`
+	TotalOutput uint64           `json:"total_output"`
+	Fee         uint64           `json:"fee"`
+	FeeKnown    bool             `json:"fee_known"` // Input values are not part of the transaction
+	Size        int              `json:"size"`      // Bytes of the signed transaction body
+
+	Signed     bool   `json:"signed"`
+	TxHash     string `json:"tx_hash,omitempty"`
+	HashValid  bool   `json:"hash_valid"`
+	SignedSize int    `json:"signed_size,omitempty"` // Bytes of the complete signed transaction
+	Algorithm  string `json:"algorithm,omitempty"`
+	SignerKey  string `json:"signer_key,omitempty"`
+}
+
+// classifyAddress reports whether addr is a standard S-address, a liquidity
+// pool L-address or neither, and whether it is well formed
+func classifyAddress(addr string) (string, bool) {
+	switch {
+	case strings.HasPrefix(addr, "S"):
+		decoded, err := hex.DecodeString(addr[1:])
+		if err != nil || len(decoded) != 25 || decoded[0] != 0x42 {
+			return "standard", false
+		}
+		return "standard", bytes.Equal(decoded[21:], calculateChecksum(decoded[:21]))
+	case strings.HasPrefix(addr, "L"):
+		_, err := hex.DecodeString(addr[1:])
+		return "liquidity_pool", err == nil && len(addr) == 41
+	default:
+		return "unknown", false
+	}
+}
+
+// decodeTransaction accepts a raw transaction (hex or JSON), a node signed
+// transaction or a shadowy_sign_transaction result
+func decodeTransaction(data []byte) (*DecodedTransaction, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty transaction")
+	}
+
+	decoded := &DecodedTransaction{}
+	body := data
+
+	if data[0] != '{' {
+		// raw_tx from a signed result is the hex-encoded body
+		raw, err := hex.DecodeString(strings.Trim(string(data), `"`))
+		if err != nil {
+			return nil, fmt.Errorf("transaction is neither JSON nor hex: %v", err)
+		}
+		body = raw
+	} else {
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return nil, fmt.Errorf("invalid transaction JSON: %v", err)
+		}
+		_, hasTx := probe["transaction"]
+		_, hasSigned := probe["signed_transaction"]
+		if hasTx || hasSigned {
+			raw, env, err := parseSignedEnvelope(data)
+			if err != nil {
+				return nil, err
+			}
+			body = raw
+			decoded.Signed = true
+			decoded.TxHash = env.TxHash
+			decoded.Algorithm = env.Algorithm
+			decoded.SignerKey = env.SignerKey
+			if env.Fee != nil {
+				decoded.Fee = *env.Fee
+				decoded.FeeKnown = true
+			}
+			signedTx := data
+			if hasSigned {
+				signedTx = probe["signed_transaction"]
+			}
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, signedTx); err == nil {
+				decoded.SignedSize = compact.Len()
+			}
+		}
+	}
+
+	var tx struct {
+		Transaction
+		TokenOps []struct {
+			Type     int             `json:"type"`
+			TokenID  string          `json:"token_id"`
+			Amount   uint64          `json:"amount"`
+			From     string          `json:"from"`
+			To       string          `json:"to"`
+			Metadata json.RawMessage `json:"metadata"`
+		} `json:"token_ops"`
+	}
+	if err := json.Unmarshal(body, &tx); err != nil {
+		return nil, fmt.Errorf("invalid transaction body: %v", err)
+	}
+
+	decoded.Version = tx.Version
+	decoded.Timestamp = tx.Timestamp
+	decoded.Locktime = tx.Locktime
+	decoded.Size = len(body)
+
+	if decoded.TxHash != "" {
+		sum := sha256.Sum256(body)
+		decoded.HashValid = hex.EncodeToString(sum[:]) == decoded.TxHash
+	}
+
+	decoded.Inputs = make([]DecodedInput, 0, len(tx.Inputs))
+	for _, input := range tx.Inputs {
+		decoded.Inputs = append(decoded.Inputs, DecodedInput{
+			TxID:        input.TxID,
+			Vout:        input.Vout,
+			Sequence:    input.Sequence,
+			Replaceable: input.Sequence < 0xfffffffe,
+		})
+	}
+
+	decoded.Outputs = make([]DecodedOutput, 0, len(tx.Outputs))
+	for i, output := range tx.Outputs {
+		addressType, valid := classifyAddress(output.Address)
+		decoded.Outputs = append(decoded.Outputs, DecodedOutput{
+			Index:        i,
+			Value:        output.Value,
+			Amount:       float64(output.Value) / 100000000.0,
+			Address:      output.Address,
+			AddressType:  addressType,
+			ValidAddress: valid,
+			ScriptPubkey: output.ScriptPubkey,
+		})
+		decoded.TotalOutput += output.Value
+	}
+
+	decoded.TokenOps = make([]DecodedTokenOp, 0, len(tx.TokenOps))
+	for _, op := range tx.TokenOps {
+		name := "UNKNOWN"
+		if op.Type >= 0 && op.Type < len(tokenOpNames) {
+			name = tokenOpNames[op.Type]
+		}
+		decoded.TokenOps = append(decoded.TokenOps, DecodedTokenOp{
+			Type:     op.Type,
+			TypeName: name,
+			TokenID:  op.TokenID,
+			Amount:   op.Amount,
+			From:     op.From,
+			To:       op.To,
+			Metadata: op.Metadata,
+		})
+	}
+
+	return decoded, nil
+}
+
+// Decode a raw or signed transaction for display
+func decodeTransactionJS(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return map[string]interface{}{
+			"error": "Transaction required",
+		}
+	}
+
+	// Accept the transaction as a string (JSON or hex) or an object
+	input := args[0].String()
+	if args[0].Type() == js.TypeObject {
+		input = js.Global().Get("JSON").Call("stringify", args[0]).String()
+	}
+
+	decoded, err := decodeTransaction([]byte(input))
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	decodedJSON, err := json.Marshal(decoded)
+	if err != nil {
+		return map[string]interface{}{
+			"error": "Failed to serialize decoded transaction",
+		}
+	}
+
+	return js.Global().Get("JSON").Call("parse", string(decodedJSON))
+}
@@ -0,0 +1,166 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+const testPoolAddress = "L0123456789abcdef0123456789abcdef01234567"
+
+func signTestTransaction(t *testing.T) (map[string]interface{}, string) {
+	publicKey, _, err := mldsa87.GenerateKey(bytes.NewReader(testSeed()))
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	sender, err := generateShadowyAddress(publicKey.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to derive address: %v", err)
+	}
+
+	setCurrentWallet(&WalletV3{
+		Address:   sender,
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey.Bytes()),
+		seed:      testSeed(),
+	})
+	t.Cleanup(func() { setCurrentWallet(nil) })
+
+	tx := Transaction{
+		Version: 1,
+		Inputs: []TransactionInput{
+			{TxID: "aaaa", Vout: 1, Sequence: 0xffffffff},
+			{TxID: "bbbb", Vout: 0, Sequence: rbfSequence},
+		},
+		Outputs: []TransactionOutput{
+			{Value: 250000000, Address: testPoolAddress},
+			{Value: 40000000, Address: sender},
+			{Value: 1, Address: testRecipient},
+		},
+		Timestamp: "2025-01-01T00:00:00Z",
+	}
+	result, err := signAndPackage(tx)
+	if err != nil {
+		t.Fatalf("Signing failed: %v", err)
+	}
+	result["fee"] = uint64(100000)
+	return result, sender
+}
+
+func TestDecodeSignedTransaction(t *testing.T) {
+	result, sender := signTestTransaction(t)
+	resultJSON, _ := json.Marshal(result)
+
+	decoded, err := decodeTransaction(resultJSON)
+	if err != nil {
+		t.Fatalf("decodeTransaction failed: %v", err)
+	}
+
+	if !decoded.Signed || decoded.TxHash != result["txid"] || !decoded.HashValid {
+		t.Errorf("Unexpected signature fields: signed=%v hash=%s valid=%v", decoded.Signed, decoded.TxHash, decoded.HashValid)
+	}
+	if decoded.Algorithm != "ML-DSA-87" || decoded.SignerKey != result["signer_key"] {
+		t.Errorf("Unexpected signer: %s %s", decoded.Algorithm, decoded.SignerKey)
+	}
+	if !decoded.FeeKnown || decoded.Fee != 100000 {
+		t.Errorf("Expected fee 100000, got %d (known=%v)", decoded.Fee, decoded.FeeKnown)
+	}
+
+	if len(decoded.Inputs) != 2 || decoded.Inputs[0].TxID != "aaaa" || decoded.Inputs[0].Vout != 1 {
+		t.Fatalf("Unexpected inputs: %+v", decoded.Inputs)
+	}
+	if decoded.Inputs[0].Replaceable || !decoded.Inputs[1].Replaceable {
+		t.Errorf("Replaceability should follow the sequence numbers: %+v", decoded.Inputs)
+	}
+
+	want := []struct {
+		address     string
+		value       uint64
+		addressType string
+		valid       bool
+	}{
+		{testPoolAddress, 250000000, "liquidity_pool", true},
+		{sender, 40000000, "standard", true},
+		{testRecipient, 1, "standard", false}, // Bad checksum
+	}
+	if len(decoded.Outputs) != len(want) {
+		t.Fatalf("Expected %d outputs, got %d", len(want), len(decoded.Outputs))
+	}
+	for i, w := range want {
+		out := decoded.Outputs[i]
+		if out.Index != i || out.Address != w.address || out.Value != w.value || out.AddressType != w.addressType || out.ValidAddress != w.valid {
+			t.Errorf("Output %d = %+v, want %+v", i, out, w)
+		}
+	}
+	if decoded.Outputs[0].Amount != 2.5 {
+		t.Errorf("Expected 2.5 SHADOW, got %f", decoded.Outputs[0].Amount)
+	}
+	if decoded.TotalOutput != 290000001 {
+		t.Errorf("Expected total output 290000001, got %d", decoded.TotalOutput)
+	}
+
+	signed := result["signed_transaction"].(map[string]interface{})
+	if decoded.Size != len(signed["transaction"].(string)) {
+		t.Errorf("Expected size %d, got %d", len(signed["transaction"].(string)), decoded.Size)
+	}
+	signedJSON, _ := json.Marshal(signed)
+	if decoded.SignedSize != len(signedJSON) {
+		t.Errorf("Expected signed size %d, got %d", len(signedJSON), decoded.SignedSize)
+	}
+}
+
+func TestDecodeNodeFormatAndRawHex(t *testing.T) {
+	result, _ := signTestTransaction(t)
+
+	// The bare signed transaction the node receives
+	signedJSON, _ := json.Marshal(result["signed_transaction"])
+	fromSigned, err := decodeTransaction(signedJSON)
+	if err != nil {
+		t.Fatalf("Decoding node format failed: %v", err)
+	}
+	if !fromSigned.Signed || !fromSigned.HashValid || fromSigned.FeeKnown {
+		t.Errorf("Unexpected node format decode: %+v", fromSigned)
+	}
+
+	// raw_tx is the unsigned body in hex
+	fromRaw, err := decodeTransaction([]byte(result["raw_tx"].(string)))
+	if err != nil {
+		t.Fatalf("Decoding raw_tx failed: %v", err)
+	}
+	if fromRaw.Signed || len(fromRaw.Outputs) != 3 || fromRaw.Size != fromSigned.Size {
+		t.Errorf("Unexpected raw decode: %+v", fromRaw)
+	}
+}
+
+func TestDecodeTokenOps(t *testing.T) {
+	raw := `{"version":1,"inputs":[],"outputs":[],"token_ops":[
+		{"type":1,"token_id":"tok","amount":5,"from":"Sa","to":"Sb"},
+		{"type":6,"token_id":"pool","amount":1,"metadata":{"name":"Pool"}},
+		{"type":42,"token_id":"odd","amount":0}]}`
+
+	decoded, err := decodeTransaction([]byte(raw))
+	if err != nil {
+		t.Fatalf("decodeTransaction failed: %v", err)
+	}
+	if len(decoded.TokenOps) != 3 {
+		t.Fatalf("Expected 3 token ops, got %d", len(decoded.TokenOps))
+	}
+	if op := decoded.TokenOps[0]; op.TypeName != "TRANSFER" || op.From != "Sa" || op.To != "Sb" || op.Amount != 5 {
+		t.Errorf("Unexpected transfer op: %+v", op)
+	}
+	if op := decoded.TokenOps[1]; op.TypeName != "POOL_CREATE" || len(op.Metadata) == 0 {
+		t.Errorf("Unexpected pool op: %+v", op)
+	}
+	if decoded.TokenOps[2].TypeName != "UNKNOWN" {
+		t.Errorf("Unrecognized op type should be UNKNOWN, got %s", decoded.TokenOps[2].TypeName)
+	}
+
+	if _, err := decodeTransaction([]byte("not a transaction")); err == nil {
+		t.Error("Expected error for garbage input")
+	}
+}
@@ -0,0 +1,200 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"syscall/js"
+	"time"
+)
+
+// rbfSequence marks inputs as replaceable (BIP125: any sequence below
+// 0xfffffffe signals opt-in replace-by-fee)
+const rbfSequence uint32 = 0xfffffffd
+
+// bumpFee rebuilds tx to pay newFee instead of oldFee. It keeps the same
+// inputs and takes the difference out of the change output, dropping that
+// output if it is spent entirely. The change output is the one at
+// changeIndex, which must pay changeAddress, or with changeIndex -1 the
+// only output paying changeAddress; a payment to ourselves is not taken
+// for change, so more than one such output needs changeIndex.
+func bumpFee(tx Transaction, changeAddress string, changeIndex int, oldFee, newFee uint64) (Transaction, error) {
+	if newFee == 0 {
+		return Transaction{}, fmt.Errorf("new fee must be positive")
+	}
+	if newFee <= oldFee {
+		return Transaction{}, fmt.Errorf("new fee %d must be higher than current fee %d", newFee, oldFee)
+	}
+	increase := newFee - oldFee
+
+	if changeIndex < 0 {
+		for i, output := range tx.Outputs {
+			if output.Address != changeAddress {
+				continue
+			}
+			if changeIndex >= 0 {
+				return Transaction{}, fmt.Errorf("outputs %d and %d both pay %s; name the change output", changeIndex, i, changeAddress)
+			}
+			changeIndex = i
+		}
+		if changeIndex < 0 {
+			return Transaction{}, fmt.Errorf("transaction has no change output to fund the fee increase")
+		}
+	} else if changeIndex >= len(tx.Outputs) {
+		return Transaction{}, fmt.Errorf("change output %d does not exist; the transaction has %d outputs", changeIndex, len(tx.Outputs))
+	} else if tx.Outputs[changeIndex].Address != changeAddress {
+		return Transaction{}, fmt.Errorf("output %d pays %s, not this wallet", changeIndex, tx.Outputs[changeIndex].Address)
+	}
+	if tx.Outputs[changeIndex].Value < increase {
+		return Transaction{}, fmt.Errorf("change of %d cannot cover fee increase of %d", tx.Outputs[changeIndex].Value, increase)
+	}
+
+	bumped := Transaction{
+		Version:   tx.Version,
+		Inputs:    make([]TransactionInput, len(tx.Inputs)),
+		Locktime:  tx.Locktime,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	for i, input := range tx.Inputs {
+		bumped.Inputs[i] = TransactionInput{
+			TxID:      input.TxID,
+			Vout:      input.Vout,
+			ScriptSig: "", // Will be filled during signing
+			Sequence:  rbfSequence,
+		}
+	}
+
+	for i, output := range tx.Outputs {
+		if i == changeIndex {
+			output.Value -= increase
+			if output.Value == 0 {
+				continue
+			}
+		}
+		bumped.Outputs = append(bumped.Outputs, output)
+	}
+
+	return bumped, nil
+}
+
+// parseFee accepts a whole, positive JavaScript number of satoshis
+func parseFee(value js.Value, field string) (uint64, error) {
+	if value.Type() != js.TypeNumber {
+		return 0, fmt.Errorf("%s must be a number", field)
+	}
+	n := value.Float()
+	if n != math.Trunc(n) || n <= 0 || n > 1<<53 {
+		return 0, fmt.Errorf("%s must be a whole number of satoshis above 0", field)
+	}
+	return uint64(n), nil
+}
+
+// parseSignedForBump extracts the unsigned transaction and its fee from
+// either a shadowy_sign_transaction result or its signed_transaction field
+func parseSignedForBump(data []byte) (Transaction, uint64, bool, error) {
+	raw, env, err := parseSignedEnvelope(data)
+	if err != nil {
+		return Transaction{}, 0, false, err
+	}
+
+	var tx Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return Transaction{}, 0, false, fmt.Errorf("invalid transaction body: %v", err)
+	}
+
+	if env.Fee == nil {
+		return tx, 0, false, nil
+	}
+	return tx, *env.Fee, true, nil
+}
+
+// Bump the fee of a previously signed transaction
+func bumpFeeJS(this js.Value, args []js.Value) interface{} {
+	if currentWallet == nil {
+		return createResolvedPromise(map[string]interface{}{
+			"error": "No wallet loaded",
+		})
+	}
+
+	if len(args) < 2 {
+		return createResolvedPromise(map[string]interface{}{
+			"error": "Signed transaction and new fee required",
+		})
+	}
+
+	// Accept the signed transaction as a JSON string or an object
+	signedJSON := args[0].String()
+	if args[0].Type() == js.TypeObject {
+		signedJSON = js.Global().Get("JSON").Call("stringify", args[0]).String()
+	}
+	newFee, err := parseFee(args[1], "New fee")
+	if err != nil {
+		return createResolvedPromise(map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	oldFeeArg := js.Undefined()
+	if len(args) >= 3 {
+		oldFeeArg = args[2]
+	}
+
+	// Without a change output index, the only output paying the wallet is it
+	changeIndex := -1
+	if len(args) >= 4 && !args[3].IsUndefined() && !args[3].IsNull() {
+		index, err := parseUint32(args[3], "Change output index")
+		if err != nil {
+			return createResolvedPromise(map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		changeIndex = int(index)
+	}
+
+	return createResolvedPromise(nil).Call("then", js.FuncOf(func(this js.Value, promiseArgs []js.Value) interface{} {
+		tx, oldFee, hasFee, err := parseSignedForBump([]byte(signedJSON))
+		if err != nil {
+			return map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+
+		// Older results do not record the fee, so let the caller supply it
+		if !oldFeeArg.IsUndefined() && !oldFeeArg.IsNull() {
+			if oldFee, err = parseFee(oldFeeArg, "Original fee"); err != nil {
+				return map[string]interface{}{
+					"error": err.Error(),
+				}
+			}
+			hasFee = true
+		}
+		if !hasFee {
+			return map[string]interface{}{
+				"error": "Original fee unknown; pass it as the third argument",
+			}
+		}
+
+		bumped, err := bumpFee(tx, currentWallet.Address, changeIndex, oldFee, newFee)
+		if err != nil {
+			return map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+
+		logDebugf("⛽ Bumping fee from %d to %d satoshis", oldFee, newFee)
+
+		result, err := signAndPackage(bumped)
+		if err != nil {
+			return map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+		result["fee"] = newFee
+		result["replaceable"] = true
+
+		return result
+	}))
+}
@@ -0,0 +1,173 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+const (
+	testSender    = "S1111111111111111111111111111111111111111111111111a"
+	testRecipient = "S2222222222222222222222222222222222222222222222222b"
+)
+
+func testStuckTransaction() Transaction {
+	return Transaction{
+		Version: 1,
+		Inputs: []TransactionInput{
+			{TxID: "aaaa", Vout: 0, Sequence: 0xffffffff},
+			{TxID: "bbbb", Vout: 2, Sequence: 0xffffffff},
+		},
+		Outputs: []TransactionOutput{
+			{Value: 5000000, Address: testRecipient},
+			{Value: 900000, Address: testSender},
+		},
+		Timestamp: "2025-01-01T00:00:00Z",
+	}
+}
+
+func sumOutputs(tx Transaction) uint64 {
+	var total uint64
+	for _, output := range tx.Outputs {
+		total += output.Value
+	}
+	return total
+}
+
+func TestBumpFeeSpendsSameInputsAndPaysMore(t *testing.T) {
+	original := testStuckTransaction()
+	const oldFee, newFee = 100000, 350000
+
+	bumped, err := bumpFee(original, testSender, -1, oldFee, newFee)
+	if err != nil {
+		t.Fatalf("bumpFee failed: %v", err)
+	}
+
+	if len(bumped.Inputs) != len(original.Inputs) {
+		t.Fatalf("Expected %d inputs, got %d", len(original.Inputs), len(bumped.Inputs))
+	}
+	for i, input := range bumped.Inputs {
+		if input.TxID != original.Inputs[i].TxID || input.Vout != original.Inputs[i].Vout {
+			t.Errorf("Input %d changed: %+v", i, input)
+		}
+		if input.Sequence != rbfSequence {
+			t.Errorf("Input %d not marked replaceable: sequence %#x", i, input.Sequence)
+		}
+	}
+
+	// Same inputs, so the fee grows by exactly what the outputs shrink
+	if paid := sumOutputs(original) - sumOutputs(bumped); paid != newFee-oldFee {
+		t.Errorf("Expected outputs to shrink by %d, shrank by %d", newFee-oldFee, paid)
+	}
+	if bumped.Outputs[0] != original.Outputs[0] {
+		t.Errorf("Payment output must not change: %+v", bumped.Outputs[0])
+	}
+	if bumped.Outputs[1].Value != 900000-250000 {
+		t.Errorf("Expected reduced change of 650000, got %d", bumped.Outputs[1].Value)
+	}
+}
+
+func TestBumpFeeDropsFullySpentChange(t *testing.T) {
+	bumped, err := bumpFee(testStuckTransaction(), testSender, -1, 100000, 1000000)
+	if err != nil {
+		t.Fatalf("bumpFee failed: %v", err)
+	}
+	if len(bumped.Outputs) != 1 || bumped.Outputs[0].Address != testRecipient {
+		t.Errorf("Expected only the payment output, got %+v", bumped.Outputs)
+	}
+}
+
+func TestBumpFeeErrors(t *testing.T) {
+	tx := testStuckTransaction()
+
+	if _, err := bumpFee(tx, testSender, -1, 100000, 100000); err == nil {
+		t.Error("Expected error when fee does not increase")
+	}
+	if _, err := bumpFee(tx, testSender, -1, 100000, 50000); err == nil {
+		t.Error("Expected error when fee decreases")
+	}
+	if _, err := bumpFee(tx, testSender, -1, 0, 0); err == nil {
+		t.Error("Expected error for a zero fee")
+	}
+	if _, err := bumpFee(tx, testSender, -1, 100000, 1000001); err == nil {
+		t.Error("Expected error when change cannot cover the increase")
+	}
+	if _, err := bumpFee(tx, "Snot-our-address", -1, 100000, 200000); err == nil {
+		t.Error("Expected error when there is no change output")
+	}
+	if _, err := bumpFee(tx, testSender, 0, 100000, 200000); err == nil {
+		t.Error("Expected error when the named output pays someone else")
+	}
+	if _, err := bumpFee(tx, testSender, 2, 100000, 200000); err == nil {
+		t.Error("Expected error when the named output does not exist")
+	}
+}
+
+func TestBumpFeeNeedsChangeIndexForSelfPayments(t *testing.T) {
+	// Paying ourselves as well as getting change back
+	tx := testStuckTransaction()
+	tx.Outputs = append(tx.Outputs, TransactionOutput{Value: 2000000, Address: testSender})
+
+	if _, err := bumpFee(tx, testSender, -1, 100000, 200000); err == nil {
+		t.Fatal("Expected error when two outputs pay the sender")
+	}
+	bumped, err := bumpFee(tx, testSender, 1, 100000, 200000)
+	if err != nil {
+		t.Fatalf("bumpFee failed: %v", err)
+	}
+	if bumped.Outputs[1].Value != 800000 || bumped.Outputs[2].Value != 2000000 {
+		t.Errorf("Expected only the named change output reduced, got %+v", bumped.Outputs)
+	}
+}
+
+func TestBumpFeeFromSignedResult(t *testing.T) {
+	publicKey, _, err := mldsa87.GenerateKey(bytes.NewReader(testSeed()))
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	defer setCurrentWallet(nil)
+	setCurrentWallet(&WalletV3{
+		Address:   testSender,
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey.Bytes()),
+		seed:      testSeed(),
+	})
+
+	original, err := signAndPackage(testStuckTransaction())
+	if err != nil {
+		t.Fatalf("Signing original failed: %v", err)
+	}
+	original["fee"] = uint64(100000)
+	originalJSON, _ := json.Marshal(original)
+
+	tx, fee, hasFee, err := parseSignedForBump(originalJSON)
+	if err != nil || !hasFee || fee != 100000 {
+		t.Fatalf("Failed to parse signed result: fee=%d hasFee=%v err=%v", fee, hasFee, err)
+	}
+
+	bumped, err := bumpFee(tx, currentWallet.Address, -1, fee, 200000)
+	if err != nil {
+		t.Fatalf("bumpFee failed: %v", err)
+	}
+	result, err := signAndPackage(bumped)
+	if err != nil {
+		t.Fatalf("Signing bumped transaction failed: %v", err)
+	}
+
+	signed := result["signed_transaction"].(map[string]interface{})
+	signature, _ := base64.StdEncoding.DecodeString(signed["signature"].(string))
+	if !mldsa87.Verify(publicKey, []byte(signed["transaction"].(string)), nil, signature) {
+		t.Error("Bumped transaction signature should verify")
+	}
+	if result["txid"] == original["txid"] {
+		t.Error("Bumped transaction should have a new txid")
+	}
+	if isZero(currentWallet.seed) {
+		t.Error("Signing must not wipe the loaded wallet's seed")
+	}
+}
@@ -0,0 +1,61 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"syscall/js"
+)
+
+// fingerprintDomain separates fingerprint hashes from every other use of
+// SHA-256 over the public key
+const fingerprintDomain = "shadowy-wallet-fingerprint-v1:"
+
+// walletFingerprint derives a short, human-comparable identifier such as
+// "3F2A-9C01-B7D4-E65A" from a base64 public key. It only ever sees the
+// public key, so it reveals nothing the address does not already.
+func walletFingerprint(publicKey string) (string, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return "", errors.New("Invalid wallet public key")
+	}
+	if len(keyBytes) == 0 {
+		return "", errors.New("Wallet has no public key")
+	}
+
+	hash := sha256.Sum256(append([]byte(fingerprintDomain), keyBytes...))
+	digits := strings.ToUpper(hex.EncodeToString(hash[:8]))
+
+	groups := make([]string, 0, 4)
+	for i := 0; i < len(digits); i += 4 {
+		groups = append(groups, digits[i:i+4])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// Get the loaded wallet's fingerprint for visual verification
+func walletFingerprintJS(this js.Value, args []js.Value) interface{} {
+	if currentWallet == nil {
+		return map[string]interface{}{
+			"error": "No wallet loaded",
+		}
+	}
+
+	fingerprint, err := walletFingerprint(currentWallet.PublicKey)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"name":        currentWallet.Name,
+		"address":     currentWallet.Address,
+		"fingerprint": fingerprint,
+	}
+}
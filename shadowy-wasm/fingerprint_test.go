@@ -0,0 +1,93 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+func testWallet(t *testing.T, seed []byte) WalletV3 {
+	t.Helper()
+	publicKey, _, err := mldsa87.GenerateKey(bytes.NewReader(seed))
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	return WalletV3{
+		Version:   3,
+		Name:      "test",
+		Seed:      base64.StdEncoding.EncodeToString(seed),
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey.Bytes()),
+	}
+}
+
+func TestWalletFingerprintDiffersBetweenWallets(t *testing.T) {
+	otherSeed := testSeed()
+	otherSeed[0] ^= 0xff
+
+	first, err := walletFingerprint(testWallet(t, testSeed()).PublicKey)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	second, err := walletFingerprint(testWallet(t, otherSeed).PublicKey)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+
+	if first == second {
+		t.Errorf("Different wallets share fingerprint %s", first)
+	}
+	if !regexp.MustCompile(`^[0-9A-F]{4}(-[0-9A-F]{4}){3}$`).MatchString(first) {
+		t.Errorf("Unexpected fingerprint format %q", first)
+	}
+}
+
+func TestWalletFingerprintStableAcrossLoads(t *testing.T) {
+	wallet := testWallet(t, testSeed())
+	before, err := walletFingerprint(wallet.PublicKey)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+
+	// Round-trip through the wallet file format as a reload would
+	data, err := json.Marshal(wallet)
+	if err != nil {
+		t.Fatalf("Failed to marshal wallet: %v", err)
+	}
+	var reloaded WalletV3
+	if err := json.Unmarshal(data, &reloaded); err != nil {
+		t.Fatalf("Failed to unmarshal wallet: %v", err)
+	}
+	after, err := walletFingerprint(reloaded.PublicKey)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	if before != after {
+		t.Errorf("Fingerprint changed across loads: %s != %s", before, after)
+	}
+}
+
+func TestWalletFingerprintIgnoresSeed(t *testing.T) {
+	wallet := testWallet(t, testSeed())
+	fingerprint, _ := walletFingerprint(wallet.PublicKey)
+
+	// Only the public key feeds the fingerprint, never the seed
+	wallet.Seed = ""
+	wallet.seed = nil
+	if again, _ := walletFingerprint(wallet.PublicKey); again != fingerprint {
+		t.Errorf("Fingerprint should not depend on the seed: %s != %s", again, fingerprint)
+	}
+
+	if _, err := walletFingerprint(""); err == nil {
+		t.Error("Expected an error for a wallet without a public key")
+	}
+	if _, err := walletFingerprint("not base64!"); err == nil {
+		t.Error("Expected an error for a malformed public key")
+	}
+}
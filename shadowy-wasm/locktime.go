@@ -0,0 +1,96 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+)
+
+// defaultSequence marks an input final: no relative timelock, no RBF
+const defaultSequence uint32 = 0xffffffff
+
+// txOverrides holds the optional locktime and sequence numbers a caller
+// can pass to shadowy_sign_transaction
+type txOverrides struct {
+	Locktime  uint32
+	Sequence  uint32   // Applied to every input without its own entry
+	Sequences []uint32 // Per-input, in the order inputs are selected
+}
+
+func defaultTxOverrides() txOverrides {
+	return txOverrides{Sequence: defaultSequence}
+}
+
+// parseTxOverrides reads locktime, sequence and sequences from the
+// transaction data, keeping the defaults for any that are absent
+func parseTxOverrides(txData js.Value) (txOverrides, error) {
+	overrides := defaultTxOverrides()
+
+	if value := txData.Get("locktime"); !value.IsUndefined() && !value.IsNull() {
+		locktime, err := parseUint32(value, "locktime")
+		if err != nil {
+			return overrides, err
+		}
+		overrides.Locktime = locktime
+	}
+
+	if value := txData.Get("sequence"); !value.IsUndefined() && !value.IsNull() {
+		sequence, err := parseUint32(value, "sequence")
+		if err != nil {
+			return overrides, err
+		}
+		overrides.Sequence = sequence
+	}
+
+	if value := txData.Get("sequences"); !value.IsUndefined() && !value.IsNull() {
+		if !js.Global().Get("Array").Call("isArray", value).Bool() {
+			return overrides, fmt.Errorf("sequences must be an array")
+		}
+		for i := 0; i < value.Length(); i++ {
+			sequence, err := parseUint32(value.Index(i), fmt.Sprintf("sequences[%d]", i))
+			if err != nil {
+				return overrides, err
+			}
+			overrides.Sequences = append(overrides.Sequences, sequence)
+		}
+	}
+
+	return overrides, nil
+}
+
+// parseUint32 accepts a whole JavaScript number between 0 and 0xffffffff
+func parseUint32(value js.Value, field string) (uint32, error) {
+	if value.Type() != js.TypeNumber {
+		return 0, fmt.Errorf("%s must be a number", field)
+	}
+	n := value.Float()
+	if n != math.Trunc(n) || n < 0 || n > math.MaxUint32 {
+		return 0, fmt.Errorf("%s must be a whole number between 0 and %d", field, uint32(math.MaxUint32))
+	}
+	return uint32(n), nil
+}
+
+// sequenceFor returns the sequence number for the input at index
+func (o txOverrides) sequenceFor(index int) uint32 {
+	if index < len(o.Sequences) {
+		return o.Sequences[index]
+	}
+	return o.Sequence
+}
+
+// buildInputs turns the selected UTXOs into unsigned inputs
+func buildInputs(utxos []UTXO, overrides txOverrides) []TransactionInput {
+	var inputs []TransactionInput
+	for i, utxo := range utxos {
+		inputs = append(inputs, TransactionInput{
+			TxID:      utxo.TxID,
+			Vout:      utxo.Vout,
+			ScriptSig: "", // Will be filled during signing
+			Sequence:  overrides.sequenceFor(i),
+		})
+	}
+	return inputs
+}
@@ -0,0 +1,104 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"syscall/js"
+	"testing"
+)
+
+func TestParseTxOverridesDefaults(t *testing.T) {
+	overrides, err := parseTxOverrides(js.ValueOf(map[string]interface{}{}))
+	if err != nil {
+		t.Fatalf("parseTxOverrides failed: %v", err)
+	}
+	if overrides.Locktime != 0 || overrides.sequenceFor(0) != defaultSequence {
+		t.Errorf("Expected locktime 0 and final sequence by default, got %+v", overrides)
+	}
+}
+
+func TestParseTxOverridesValidatesRanges(t *testing.T) {
+	invalid := []map[string]interface{}{
+		{"locktime": -1},
+		{"locktime": 4294967296.0},
+		{"locktime": 1.5},
+		{"locktime": "500000"},
+		{"sequence": 4294967296.0},
+		{"sequences": []interface{}{1, -2}},
+		{"sequences": 7},
+		{"sequences": map[string]interface{}{"0": 1}},
+	}
+	for _, data := range invalid {
+		if _, err := parseTxOverrides(js.ValueOf(data)); err == nil {
+			t.Errorf("Expected %v to be rejected", data)
+		}
+	}
+
+	overrides, err := parseTxOverrides(js.ValueOf(map[string]interface{}{
+		"locktime":  4294967295.0,
+		"sequence":  0,
+		"sequences": []interface{}{rbfSequence},
+	}))
+	if err != nil {
+		t.Fatalf("Expected boundary values to be accepted: %v", err)
+	}
+	if overrides.Locktime != 0xffffffff || overrides.sequenceFor(0) != rbfSequence || overrides.sequenceFor(1) != 0 {
+		t.Errorf("Unexpected overrides: %+v", overrides)
+	}
+}
+
+func TestCustomLocktimeAndSequenceAreSigned(t *testing.T) {
+	signTestTransaction(t) // loads a wallet to sign with
+
+	overrides, err := parseTxOverrides(js.ValueOf(map[string]interface{}{
+		"locktime":  850000,
+		"sequences": []interface{}{rbfSequence},
+		"sequence":  0xfffffffe,
+	}))
+	if err != nil {
+		t.Fatalf("parseTxOverrides failed: %v", err)
+	}
+
+	sign := func(overrides txOverrides) map[string]interface{} {
+		tx := Transaction{
+			Version:   1,
+			Inputs:    buildInputs([]UTXO{{TxID: "aaaa", Vout: 1}, {TxID: "bbbb", Vout: 0}}, overrides),
+			Outputs:   []TransactionOutput{{Value: 1, Address: testRecipient}},
+			Locktime:  overrides.Locktime,
+			Timestamp: "2025-01-01T00:00:00Z",
+		}
+		result, err := signAndPackage(tx)
+		if err != nil {
+			t.Fatalf("Signing failed: %v", err)
+		}
+		return result
+	}
+	result := sign(overrides)
+	defaults := sign(defaultTxOverrides())
+
+	signed := result["signed_transaction"].(map[string]interface{})
+	body := signed["transaction"].(string)
+
+	var parsed Transaction
+	if err := json.Unmarshal([]byte(body), &parsed); err != nil {
+		t.Fatalf("Failed to parse signed transaction: %v", err)
+	}
+	if parsed.Locktime != 850000 {
+		t.Errorf("Expected locktime 850000, got %d", parsed.Locktime)
+	}
+	if parsed.Inputs[0].Sequence != rbfSequence || parsed.Inputs[1].Sequence != 0xfffffffe {
+		t.Errorf("Unexpected sequences: %#x %#x", parsed.Inputs[0].Sequence, parsed.Inputs[1].Sequence)
+	}
+
+	sum := sha256.Sum256([]byte(body))
+	if result["txid"] != hex.EncodeToString(sum[:]) {
+		t.Errorf("Hash does not cover the signed body: %v", result["txid"])
+	}
+	if result["txid"] == defaults["txid"] {
+		t.Error("Custom locktime and sequences should change the hash")
+	}
+}
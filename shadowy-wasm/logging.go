@@ -0,0 +1,102 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"log"
+	"strings"
+	"syscall/js"
+)
+
+// LogLevel gates console output from the library
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+	LogSilent
+)
+
+var logLevelNames = map[string]LogLevel{
+	"debug":  LogDebug,
+	"info":   LogInfo,
+	"warn":   LogWarn,
+	"error":  LogError,
+	"silent": LogSilent,
+}
+
+// defaultLogLevel keeps production builds quiet; development builds set it
+// with -ldflags "-X main.defaultLogLevel=debug" (see build.sh --debug)
+var defaultLogLevel = "warn"
+
+// logLevel is the current threshold; messages below it are dropped
+var logLevel = initialLogLevel()
+
+func initialLogLevel() LogLevel {
+	if level, ok := parseLogLevel(defaultLogLevel); ok {
+		return level
+	}
+	return LogWarn
+}
+
+// parseLogLevel maps a level name to a LogLevel, ignoring case
+func parseLogLevel(name string) (LogLevel, bool) {
+	level, ok := logLevelNames[strings.ToLower(strings.TrimSpace(name))]
+	return level, ok
+}
+
+// String returns the level's name
+func (l LogLevel) String() string {
+	for name, level := range logLevelNames {
+		if level == l {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+func logf(level LogLevel, format string, args ...interface{}) {
+	if level < logLevel {
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// logDebugf is for request and response bodies and other detail that may
+// include balances or addresses
+func logDebugf(format string, args ...interface{}) { logf(LogDebug, format, args...) }
+
+// logInfof is for lifecycle events without sensitive payloads
+func logInfof(format string, args ...interface{}) { logf(LogInfo, format, args...) }
+
+func logWarnf(format string, args ...interface{}) { logf(LogWarn, format, args...) }
+
+func logErrorf(format string, args ...interface{}) { logf(LogError, format, args...) }
+
+// setLogLevel changes the console log threshold. Accepts "debug", "info",
+// "warn", "error" or "silent"; with no argument it reports the current level.
+func setLogLevel(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].IsUndefined() || args[0].IsNull() {
+		return map[string]interface{}{
+			"success": true,
+			"level":   logLevel.String(),
+		}
+	}
+
+	level, ok := parseLogLevel(args[0].String())
+	if !ok {
+		return map[string]interface{}{
+			"success": false,
+			"error":   "Log level must be one of debug, info, warn, error, silent",
+		}
+	}
+
+	logLevel = level
+	return map[string]interface{}{
+		"success": true,
+		"level":   logLevel.String(),
+	}
+}
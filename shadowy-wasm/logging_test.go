@@ -0,0 +1,70 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func captureLogs(t *testing.T, level LogLevel) *bytes.Buffer {
+	previousLevel := logLevel
+	previousOutput := log.Writer()
+
+	var buf bytes.Buffer
+	logLevel = level
+	log.SetOutput(&buf)
+	t.Cleanup(func() {
+		logLevel = previousLevel
+		log.SetOutput(previousOutput)
+	})
+	return &buf
+}
+
+const testBalanceBody = `{"address":"S_test","balance":12.5,"balance_satoshis":1250000000}`
+
+func TestBalanceFetchSilentBelowThreshold(t *testing.T) {
+	buf := captureLogs(t, LogWarn)
+
+	result := parseBalanceResponse("/api/v1/address/S_test/balance", 200, testBalanceBody)
+	if result["balance_satoshis"] != uint64(1250000000) {
+		t.Fatalf("Unexpected balance result: %v", result)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no log output at warn level, got: %s", buf.String())
+	}
+}
+
+func TestBalanceBodyOnlyLoggedAtDebug(t *testing.T) {
+	buf := captureLogs(t, LogInfo)
+	parseBalanceResponse("/api/v1/address/S_test/balance", 200, testBalanceBody)
+	if strings.Contains(buf.String(), "1250000000") {
+		t.Errorf("Balance leaked at info level: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "HTTP 200") {
+		t.Errorf("Expected the status line at info level, got: %s", buf.String())
+	}
+
+	buf = captureLogs(t, LogDebug)
+	parseBalanceResponse("/api/v1/address/S_test/balance", 200, testBalanceBody)
+	if !strings.Contains(buf.String(), "1250000000") {
+		t.Errorf("Expected the body at debug level, got: %s", buf.String())
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for name, want := range map[string]LogLevel{"debug": LogDebug, "INFO": LogInfo, " warn ": LogWarn, "silent": LogSilent} {
+		if got, ok := parseLogLevel(name); !ok || got != want {
+			t.Errorf("parseLogLevel(%q) = %v, %v; want %v", name, got, ok, want)
+		}
+	}
+	if _, ok := parseLogLevel("verbose"); ok {
+		t.Error("Unknown level should be rejected")
+	}
+	if initialLogLevel() != LogWarn {
+		t.Errorf("Default build should start at warn, got %v", initialLogLevel())
+	}
+}
@@ -10,9 +10,8 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"strings"
 	"syscall/js"
 	"time"
 
@@ -34,6 +33,8 @@ type WalletV3 struct {
 	CreatedAt string `json:"created_at"`
 	Seed      string `json:"seed"`       // base64 encoded 64-byte seed
 	PublicKey string `json:"public_key"` // base64 encoded public key
+
+	seed []byte // decoded seed held in memory while loaded; wiped on unload
 }
 
 // Legacy wallet format (V2)
@@ -140,8 +141,8 @@ func createPromise(executor js.Func) js.Value {
 func main() {
 	c := make(chan struct{}, 0)
 
-	log.Println("🌟 Shadowy WASM library initializing...")
-	log.Printf("Version: %s, Crypto: %s", WasmVersion, CryptoAlgorithm)
+	logInfof("🌟 Shadowy WASM library initializing...")
+	logInfof("Version: %s, Crypto: %s", WasmVersion, CryptoAlgorithm)
 
 	// Export functions to JavaScript
 	js.Global().Set("shadowy_create_client", js.FuncOf(createClient))
@@ -150,14 +151,22 @@ func main() {
 	js.Global().Set("shadowy_get_health", js.FuncOf(getHealth))
 	js.Global().Set("shadowy_get_balance", js.FuncOf(getBalance))
 	js.Global().Set("shadowy_get_node_info", js.FuncOf(getNodeInfo))
+	js.Global().Set("shadowy_get_api_version", js.FuncOf(getAPIVersion))
 	js.Global().Set("shadowy_create_wallet", js.FuncOf(createWallet))
 	js.Global().Set("shadowy_load_wallet", js.FuncOf(loadWallet))
 	js.Global().Set("shadowy_get_wallet_address", js.FuncOf(getWalletAddress))
+	js.Global().Set("shadowy_wallet_fingerprint", js.FuncOf(walletFingerprintJS))
+	js.Global().Set("shadowy_unload_wallet", js.FuncOf(unloadWallet))
+	js.Global().Set("shadowy_build_transaction", js.FuncOf(buildTransaction))
 	js.Global().Set("shadowy_sign_transaction", js.FuncOf(signTransaction))
+	js.Global().Set("shadowy_bump_fee", js.FuncOf(bumpFeeJS))
+	js.Global().Set("shadowy_set_dust_threshold", js.FuncOf(setDustThreshold))
+	js.Global().Set("shadowy_decode_transaction", js.FuncOf(decodeTransactionJS))
 	js.Global().Set("shadowy_broadcast_transaction", js.FuncOf(broadcastTransaction))
 	js.Global().Set("shadowy_get_utxos", js.FuncOf(getUTXOs))
+	js.Global().Set("shadowy_set_log_level", js.FuncOf(setLogLevel))
 
-	log.Println("✅ WASM library ready")
+	logInfof("✅ WASM library ready")
 
 	<-c
 }
@@ -182,10 +191,16 @@ func createClient(this js.Value, args []js.Value) interface{} {
 		},
 	})
 
-	log.Printf("🌐 HTTP client created for: %s", nodeURL)
+	logInfof("🌐 HTTP client created for: %s", nodeURL)
+
+	// Check the node speaks an API version we understand; hosts can await
+	// version_check to prompt for an upgrade
+	nodeAPIVersion = unknownAPIVersion("", "")
+	versionCheck := negotiateAPIVersion()
 
 	return map[string]interface{}{
-		"success": true,
+		"success":       true,
+		"version_check": versionCheck,
 	}
 }
 
@@ -261,7 +276,7 @@ func getHealth(this js.Value, args []js.Value) interface{} {
 		resolve := args[0]
 		reject := args[1]
 
-		log.Printf("🏥 Getting node health status...")
+		logInfof("🏥 Getting node health status...")
 
 		// Make HTTP request asynchronously
 		httpResult := makeHTTPRequest("GET", "/api/v1/health", "")
@@ -284,8 +299,8 @@ func getHealth(this js.Value, args []js.Value) interface{} {
 				statusCode := result.Get("status_code").Int()
 				body := result.Get("body").String()
 
-				log.Printf("🏥 Health API response: HTTP %d", statusCode)
-				log.Printf("🏥 Health API body: %s", body)
+				logInfof("🏥 Health API response: HTTP %d", statusCode)
+				logDebugf("🏥 Health API body: %s", body)
 
 				// Handle both 200 OK and 503 Service Unavailable with valid JSON
 				if statusCode == 200 || statusCode == 503 {
@@ -294,7 +309,7 @@ func getHealth(this js.Value, args []js.Value) interface{} {
 					if err == nil {
 						// Add HTTP status to the response
 						healthData["http_status"] = statusCode
-						log.Printf("✅ Parsed health data: %+v", healthData)
+						logDebugf("✅ Parsed health data: %+v", healthData)
 						resolve.Invoke(healthData)
 						return nil
 					}
@@ -310,7 +325,7 @@ func getHealth(this js.Value, args []js.Value) interface{} {
 				})
 				return nil
 			})).Call("catch", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-				log.Printf("❌ Health check request failed")
+				logErrorf("❌ Health check request failed")
 				reject.Invoke(map[string]interface{}{
 					"error": "HTTP request failed",
 				})
@@ -340,47 +355,50 @@ func getBalance(this js.Value, args []js.Value) interface{} {
 		statusCode := result.Get("status_code").Int()
 		body := result.Get("body").String()
 
-		log.Printf("💰 Balance request to: %s", endpoint)
-		log.Printf("💰 Balance response: HTTP %d", statusCode)
-		log.Printf("💰 Balance body: %s", body)
-
-		if statusCode == 200 {
-			body := result.Get("body").String()
-			log.Printf("💰 Balance API response: %s", body)
-
-			var balance BalanceResponse
-			err := json.Unmarshal([]byte(body), &balance)
-			if err != nil {
-				log.Printf("❌ Failed to parse balance JSON: %s", err.Error())
-				return map[string]interface{}{
-					"error": "Failed to parse balance response",
-				}
-			}
+		return parseBalanceResponse(endpoint, statusCode, body)
+	}))
+}
 
-			log.Printf("✅ Parsed balance: %+v", balance)
+// parseBalanceResponse converts a balance API response for JavaScript. The
+// body carries the wallet balance, so it is only ever logged at debug level.
+func parseBalanceResponse(endpoint string, statusCode int, body string) map[string]interface{} {
+	logDebugf("💰 Balance request to: %s", endpoint)
+	logInfof("💰 Balance response: HTTP %d", statusCode)
+	logDebugf("💰 Balance body: %s", body)
 
-			// Convert to map for JavaScript compatibility
+	if statusCode == 200 {
+		var balance BalanceResponse
+		err := json.Unmarshal([]byte(body), &balance)
+		if err != nil {
+			logErrorf("❌ Failed to parse balance JSON: %s", err.Error())
 			return map[string]interface{}{
-				"address":                 balance.Address,
-				"balance":                 balance.Balance,
-				"balance_satoshis":        balance.BalanceSatoshis,
-				"confirmed":               balance.Confirmed,
-				"confirmed_satoshis":      balance.ConfirmedSatoshis,
-				"unconfirmed":             balance.Unconfirmed,
-				"unconfirmed_satoshis":    balance.UnconfirmedSatoshis,
-				"total_received":          balance.TotalReceived,
-				"total_received_satoshis": balance.TotalReceivedSatoshis,
-				"total_sent":              balance.TotalSent,
-				"total_sent_satoshis":     balance.TotalSentSatoshis,
-				"transaction_count":       balance.TransactionCount,
-				"last_activity":           balance.LastActivity,
+				"error": "Failed to parse balance response",
 			}
 		}
 
+		logDebugf("✅ Parsed balance: %+v", balance)
+
+		// Convert to map for JavaScript compatibility
 		return map[string]interface{}{
-			"error": fmt.Sprintf("Balance lookup failed: HTTP %d", statusCode),
+			"address":                 balance.Address,
+			"balance":                 balance.Balance,
+			"balance_satoshis":        balance.BalanceSatoshis,
+			"confirmed":               balance.Confirmed,
+			"confirmed_satoshis":      balance.ConfirmedSatoshis,
+			"unconfirmed":             balance.Unconfirmed,
+			"unconfirmed_satoshis":    balance.UnconfirmedSatoshis,
+			"total_received":          balance.TotalReceived,
+			"total_received_satoshis": balance.TotalReceivedSatoshis,
+			"total_sent":              balance.TotalSent,
+			"total_sent_satoshis":     balance.TotalSentSatoshis,
+			"transaction_count":       balance.TransactionCount,
+			"last_activity":           balance.LastActivity,
 		}
-	}))
+	}
+
+	return map[string]interface{}{
+		"error": fmt.Sprintf("Balance lookup failed: HTTP %d", statusCode),
+	}
 }
 
 // Get node information
@@ -430,16 +448,19 @@ func createWallet(this js.Value, args []js.Value) interface{} {
 		}
 
 		// Generate ML-DSA-87 key pair from seed
-		publicKey, _, err := mldsa87.GenerateKey(bytes.NewReader(seed))
+		publicKey, privateKey, err := mldsa87.GenerateKey(bytes.NewReader(seed))
 		if err != nil {
+			zero(seed)
 			return map[string]interface{}{
 				"error": "Failed to generate key pair",
 			}
 		}
+		zeroPrivateKey(privateKey)
 
 		// Generate Shadowy address from public key
 		address, err := generateShadowyAddress(publicKey.Bytes())
 		if err != nil {
+			zero(seed)
 			return map[string]interface{}{
 				"error": "Failed to generate address",
 			}
@@ -458,6 +479,7 @@ func createWallet(this js.Value, args []js.Value) interface{} {
 		// Add private key for internal use (not saved to file)
 		walletJSON, err := json.MarshalIndent(wallet, "", "  ")
 		if err != nil {
+			zero(seed)
 			return map[string]interface{}{
 				"error": "Failed to serialize wallet",
 			}
@@ -467,17 +489,23 @@ func createWallet(this js.Value, args []js.Value) interface{} {
 		cryptoBridge := js.Global().Get("shadowy_crypto_bridge")
 		filename := fmt.Sprintf("shadowy-wallet-%s.json", walletName)
 		success := cryptoBridge.Call("writeWalletFile", filename, string(walletJSON))
+		zero(walletJSON)
 
 		if !success.Bool() {
+			zero(seed)
 			return map[string]interface{}{
 				"error": "Failed to save wallet file",
 			}
 		}
 
+		// Keep only the raw seed in memory so it can be wiped later
+		wallet.seed = seed
+		wallet.Seed = ""
+
 		// Set as current wallet
-		currentWallet = wallet
+		setCurrentWallet(wallet)
 
-		log.Printf("✅ Created wallet: %s (%s)", walletName, address)
+		logInfof("✅ Created wallet: %s (%s)", walletName, address)
 
 		return map[string]interface{}{
 			"name":    wallet.Name,
@@ -490,9 +518,9 @@ func createWallet(this js.Value, args []js.Value) interface{} {
 // Load wallet
 func loadWallet(this js.Value, args []js.Value) interface{} {
 	// Capture the original arguments before entering the promise callback
-	log.Printf("🔧 LoadWallet outer called with %d args", len(args))
+	logDebugf("🔧 LoadWallet outer called with %d args", len(args))
 	for i, arg := range args {
-		log.Printf("🔧 Outer Arg[%d]: Type=%s, String=%s", i, arg.Type(), arg.String())
+		logDebugf("🔧 Outer Arg[%d]: Type=%s, String=%s", i, arg.Type(), arg.String())
 	}
 
 	if len(args) < 1 {
@@ -502,17 +530,17 @@ func loadWallet(this js.Value, args []js.Value) interface{} {
 	}
 
 	walletName := args[0].String()
-	log.Printf("🔧 Using wallet name: '%s'", walletName)
+	logDebugf("🔧 Using wallet name: '%s'", walletName)
 
 	return createResolvedPromise(nil).Call("then", js.FuncOf(func(this js.Value, promiseArgs []js.Value) interface{} {
-		log.Printf("🔧 LoadWallet called with name: '%s'", walletName)
+		logDebugf("🔧 LoadWallet called with name: '%s'", walletName)
 
 		// Load wallet from file via crypto bridge
 		cryptoBridge := js.Global().Get("shadowy_crypto_bridge")
 		filename := fmt.Sprintf("shadowy-wallet-%s.json", walletName)
-		log.Printf("🔧 Looking for wallet file: '%s'", filename)
+		logDebugf("🔧 Looking for wallet file: '%s'", filename)
 		walletData := cryptoBridge.Call("readWalletFile", filename)
-		log.Printf("🔧 Wallet data loaded: %v", !walletData.IsNull())
+		logDebugf("🔧 Wallet data loaded: %v", !walletData.IsNull())
 
 		if walletData.IsNull() {
 			return map[string]interface{}{
@@ -521,7 +549,8 @@ func loadWallet(this js.Value, args []js.Value) interface{} {
 		}
 
 		// Parse wallet and detect format based on available fields
-		walletStr := walletData.String()
+		walletBytes := []byte(walletData.String())
+		defer zero(walletBytes)
 
 		// Check if it has V2-style fields (private_key)
 		var formatCheck struct {
@@ -529,7 +558,7 @@ func loadWallet(this js.Value, args []js.Value) interface{} {
 			PrivateKey string `json:"private_key"`
 			Seed       string `json:"seed"`
 		}
-		err := json.Unmarshal([]byte(walletStr), &formatCheck)
+		err := json.Unmarshal(walletBytes, &formatCheck)
 		if err != nil {
 			return map[string]interface{}{
 				"error": "Failed to parse wallet format",
@@ -542,7 +571,7 @@ func loadWallet(this js.Value, args []js.Value) interface{} {
 		if formatCheck.PrivateKey != "" {
 			// Handle V2 wallet format
 			var walletV2 WalletV2
-			err = json.Unmarshal([]byte(walletStr), &walletV2)
+			err = json.Unmarshal(walletBytes, &walletV2)
 			if err != nil {
 				return map[string]interface{}{
 					"error": "Failed to parse V2 wallet file",
@@ -560,10 +589,10 @@ func loadWallet(this js.Value, args []js.Value) interface{} {
 				Seed:      base64.StdEncoding.EncodeToString([]byte(walletV2.PrivateKey)),
 				PublicKey: "", // Would need to derive this from private key
 			}
-			log.Printf("✅ Converted V2 wallet to V3 format")
+			logInfof("✅ Converted V2 wallet to V3 format")
 		} else {
 			// Handle V3 wallet format
-			err = json.Unmarshal([]byte(walletStr), &wallet)
+			err = json.Unmarshal(walletBytes, &wallet)
 			if err != nil {
 				return map[string]interface{}{
 					"error": "Failed to parse V3 wallet file",
@@ -571,9 +600,19 @@ func loadWallet(this js.Value, args []js.Value) interface{} {
 			}
 		}
 
-		currentWallet = &wallet
+		// Keep only the raw seed in memory so it can be wiped later
+		seed, err := base64.StdEncoding.DecodeString(wallet.Seed)
+		if err != nil {
+			return map[string]interface{}{
+				"error": "Failed to decode wallet seed",
+			}
+		}
+		wallet.seed = seed
+		wallet.Seed = ""
+
+		setCurrentWallet(&wallet)
 
-		log.Printf("✅ Loaded wallet: %s (%s)", wallet.Name, wallet.Address)
+		logInfof("✅ Loaded wallet: %s (%s)", wallet.Name, wallet.Address)
 
 		return map[string]interface{}{
 			"name":    wallet.Name,
@@ -583,6 +622,25 @@ func loadWallet(this js.Value, args []js.Value) interface{} {
 	}))
 }
 
+// Unload the current wallet and wipe its key material
+func unloadWallet(this js.Value, args []js.Value) interface{} {
+	if currentWallet == nil {
+		return map[string]interface{}{
+			"error": "No wallet loaded",
+		}
+	}
+
+	name := currentWallet.Name
+	setCurrentWallet(nil)
+
+	logInfof("🔒 Unloaded wallet: %s", name)
+
+	return map[string]interface{}{
+		"success": true,
+		"name":    name,
+	}
+}
+
 // Get current wallet address
 func getWalletAddress(this js.Value, args []js.Value) interface{} {
 	if currentWallet == nil {
@@ -600,6 +658,13 @@ func getWalletAddress(this js.Value, args []js.Value) interface{} {
 
 // Get UTXOs for current wallet
 func getUTXOs(this js.Value, args []js.Value) interface{} {
+	return fetchUTXOs(true)
+}
+
+// fetchUTXOs resolves with the current wallet's UTXOs from the API. With
+// useMocks, mock UTXOs stand in when there are none or the API fails;
+// without, an API failure rejects and no UTXOs resolve as an empty list.
+func fetchUTXOs(useMocks bool) interface{} {
 	if currentWallet == nil {
 		return map[string]interface{}{
 			"error": "No wallet loaded",
@@ -612,7 +677,37 @@ func getUTXOs(this js.Value, args []js.Value) interface{} {
 		resolve := args[0]
 		reject := args[1]
 
-		log.Printf("🔍 Getting UTXOs for address: %s", currentWallet.Address)
+		// Convert to JSON and back to ensure JavaScript compatibility
+		resolveUTXOs := func(utxos []UTXO) {
+			logDebugf("✅ Returning %d UTXOs to JavaScript", len(utxos))
+			utxosJSON, err := json.Marshal(convertUTXOsToJS(utxos))
+			if err != nil {
+				logErrorf("❌ Failed to serialize UTXOs: %s", err.Error())
+				resolve.Invoke([]interface{}{})
+				return
+			}
+			var utxosForJS interface{}
+			if err := json.Unmarshal(utxosJSON, &utxosForJS); err != nil {
+				logErrorf("❌ Failed to deserialize UTXOs: %s", err.Error())
+				resolve.Invoke([]interface{}{})
+				return
+			}
+			resolve.Invoke(utxosForJS)
+		}
+		// The API could not be used
+		fail := func(message string) {
+			if useMocks {
+				logWarnf("⚠️ %s, using mock UTXOs", message)
+				resolveUTXOs(createMockUTXOs(currentWallet.Address))
+				return
+			}
+			logErrorf("❌ %s", message)
+			reject.Invoke(map[string]interface{}{
+				"error": message,
+			})
+		}
+
+		logDebugf("🔍 Getting UTXOs for address: %s", currentWallet.Address)
 
 		// Make HTTP request
 		httpResult := makeHTTPRequest("GET", endpoint, "")
@@ -625,92 +720,49 @@ func getUTXOs(this js.Value, args []js.Value) interface{} {
 
 		// Convert to js.Value and handle as Promise
 		httpPromise := js.ValueOf(httpResult)
-		if httpPromise.Type() == js.TypeObject && !httpPromise.IsUndefined() {
-			// Handle the HTTP response
-			httpPromise.Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-				response := args[0]
-				result := response.Get("result")
-				statusCode := result.Get("status_code").Int()
-				body := result.Get("body").String()
-
-				log.Printf("🔍 UTXO API request to: %s", endpoint)
-				log.Printf("🔍 UTXO API response: HTTP %d", statusCode)
-				log.Printf("🔍 UTXO API body: %s", body)
-
-				var utxos []map[string]interface{}
+		if httpPromise.Type() != js.TypeObject || httpPromise.IsUndefined() {
+			fail("Direct HTTP result")
+			return nil
+		}
 
-				if statusCode == 200 {
-					// Handle null response (no UTXOs found)
-					if body == "null" || body == "" {
-						log.Printf("✅ No UTXOs found for address, using mock UTXOs")
-						utxos = convertUTXOsToJS(createMockUTXOs(currentWallet.Address))
-					} else {
-						// Try to parse real UTXOs from API
-						var realUTXOs []UTXO
-						err := json.Unmarshal([]byte(body), &realUTXOs)
-						if err != nil {
-							log.Printf("❌ Failed to parse UTXO API response: %s", err.Error())
-							log.Printf("⚠️ Using mock UTXOs due to parsing error")
-							utxos = convertUTXOsToJS(createMockUTXOs(currentWallet.Address))
-						} else if len(realUTXOs) == 0 {
-							log.Printf("✅ Empty UTXO array from API, using mock UTXOs")
-							utxos = convertUTXOsToJS(createMockUTXOs(currentWallet.Address))
-						} else {
-							log.Printf("✅ Successfully parsed %d UTXOs from API", len(realUTXOs))
-							utxos = convertUTXOsToJS(realUTXOs)
-						}
-					}
-				} else {
-					// If API doesn't exist yet, return mock UTXOs for testing
-					log.Printf("⚠️ UTXO API not available (HTTP %d), using mock data", statusCode)
-					utxos = convertUTXOsToJS(createMockUTXOs(currentWallet.Address))
-				}
+		// Handle the HTTP response
+		httpPromise.Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			response := args[0]
+			result := response.Get("result")
+			statusCode := result.Get("status_code").Int()
+			body := result.Get("body").String()
 
-				// Successfully resolve with UTXOs
-				log.Printf("✅ Returning %d UTXOs to JavaScript", len(utxos))
+			logDebugf("🔍 UTXO API request to: %s", endpoint)
+			logInfof("🔍 UTXO API response: HTTP %d", statusCode)
+			logDebugf("🔍 UTXO API body: %s", body)
 
-				// Convert to JSON and back to ensure JavaScript compatibility
-				utxosJSON, err := json.Marshal(utxos)
-				if err != nil {
-					log.Printf("❌ Failed to serialize UTXOs: %s", err.Error())
-					resolve.Invoke([]map[string]interface{}{})
-					return nil
-				}
+			if statusCode != 200 {
+				// The API may not exist yet
+				fail(fmt.Sprintf("UTXO API not available (HTTP %d)", statusCode))
+				return nil
+			}
 
-				var utxosForJS interface{}
-				err = json.Unmarshal(utxosJSON, &utxosForJS)
-				if err != nil {
-					log.Printf("❌ Failed to deserialize UTXOs: %s", err.Error())
-					resolve.Invoke([]map[string]interface{}{})
+			// A null body means no UTXOs were found
+			var realUTXOs []UTXO
+			if body != "null" && body != "" {
+				if err := json.Unmarshal([]byte(body), &realUTXOs); err != nil {
+					fail(fmt.Sprintf("Failed to parse UTXO API response: %s", err.Error()))
 					return nil
 				}
+			}
+			if len(realUTXOs) == 0 && useMocks {
+				logInfof("✅ No UTXOs found for address, using mock UTXOs")
+				realUTXOs = createMockUTXOs(currentWallet.Address)
+			} else {
+				logInfof("✅ Successfully parsed %d UTXOs from API", len(realUTXOs))
+			}
+			resolveUTXOs(realUTXOs)
+			return nil
 
-				resolve.Invoke(utxosForJS)
-				return nil
-
-			})).Call("catch", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-				log.Printf("❌ UTXO HTTP request failed")
-				// Even on HTTP error, return mock UTXOs for testing
-				utxos := convertUTXOsToJS(createMockUTXOs(currentWallet.Address))
-
-				// JSON serialize for JavaScript compatibility
-				utxosJSON, _ := json.Marshal(utxos)
-				var utxosForJS interface{}
-				json.Unmarshal(utxosJSON, &utxosForJS)
-				resolve.Invoke(utxosForJS)
-				return nil
-			}))
-		} else {
-			// Direct result, not a promise
-			log.Printf("⚠️ Direct HTTP result, using mock UTXOs")
-			utxos := convertUTXOsToJS(createMockUTXOs(currentWallet.Address))
-
-			// JSON serialize for JavaScript compatibility
-			utxosJSON, _ := json.Marshal(utxos)
-			var utxosForJS interface{}
-			json.Unmarshal(utxosJSON, &utxosForJS)
-			resolve.Invoke(utxosForJS)
-		}
+		})).Call("catch", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			fail("UTXO HTTP request failed")
+			return nil
+		}))
 
 		return nil
 	}))
@@ -779,215 +831,123 @@ func signTransaction(this js.Value, args []js.Value) interface{} {
 	// Parse transaction data from JavaScript (outside of Promise wrapper)
 	txData := args[0]
 
-	// Handle different transaction formats
-	var destination, fromAddress string
-	var amount, fee uint64
-
-	// Check if this is the new transaction format with inputs/outputs
-	if !txData.Get("inputs").IsUndefined() {
-		log.Printf("🔐 Received transaction with inputs/outputs format")
-
-		// Extract destination from first output
-		outputs := txData.Get("outputs")
-		if outputs.Length() > 0 {
-			firstOutput := outputs.Index(0)
-			destination = firstOutput.Get("address").String()
-			amount = uint64(firstOutput.Get("value").Float())
-		}
-
-		// Extract source from transaction or use current wallet
-		if currentWallet != nil {
-			fromAddress = currentWallet.Address
-		}
-
-		// Use default fee
-		fee = 100000 // 0.001 SHADOW
-
-	} else {
-		// Legacy format
-		destination = txData.Get("destination").String()
-		if !txData.Get("amount").IsUndefined() {
-			amount = uint64(txData.Get("amount").Float())
+	// A shadowy_build_transaction result is signed exactly as built
+	if txData.Get("unsigned_transaction").Type() == js.TypeString {
+		tx, fee, err := parsePrebuilt(txData)
+		if err != nil {
+			return createResolvedPromise(map[string]interface{}{
+				"error": err.Error(),
+			})
 		}
-		if !txData.Get("fee").IsUndefined() {
-			fee = uint64(txData.Get("fee").Float())
+		result, err := signAndPackage(tx)
+		if err != nil {
+			return createResolvedPromise(map[string]interface{}{
+				"error": err.Error(),
+			})
 		}
-		fromAddress = txData.Get("from_address").String()
+		result["fee"] = fee
+		return createResolvedPromise(result)
 	}
 
-	// Validate addresses
-	if len(destination) != 51 || !strings.HasPrefix(destination, "S") {
+	params, err := parsePaymentParams(txData)
+	if err != nil {
 		return createResolvedPromise(map[string]interface{}{
-			"error": fmt.Sprintf("Invalid destination address format: %s (expected 51 chars starting with S)", destination),
+			"error": err.Error(),
 		})
 	}
 
-	if len(fromAddress) != 51 || !strings.HasPrefix(fromAddress, "S") {
-		return createResolvedPromise(map[string]interface{}{
-			"error": fmt.Sprintf("Invalid from address format: %s (expected 51 chars starting with S)", fromAddress),
-		})
-	}
-
-	log.Printf("🔐 Signing transaction: %d satoshis to %s (fee: %d)", amount, destination, fee)
+	logDebugf("🔐 Signing transaction: %d satoshis to %s (fee: %d)", params.Amount, params.Destination, params.Fee)
 
 	// Get real UTXOs from the API first, then sign the transaction
 	return createResolvedPromise(getUTXOs(js.Null(), nil)).Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		utxosValue := args[0]
-		log.Printf("🔐 Signing transaction: %d SHADOW to %s (fee: %d)", amount, destination, fee)
-
 		// Convert JavaScript UTXOs to Go slice
-		var utxos []UTXO
-		if utxosValue.Type() == js.TypeObject && !utxosValue.IsNull() {
-			if utxosValue.Length != nil {
-				// It's an array
-				length := utxosValue.Length()
-				for i := 0; i < length; i++ {
-					utxoJS := utxosValue.Index(i)
-					utxo := UTXO{
-						TxID:          utxoJS.Get("txid").String(),
-						Vout:          uint32(utxoJS.Get("vout").Int()),
-						Value:         uint64(utxoJS.Get("value").Float()),
-						ScriptPubkey:  utxoJS.Get("script_pubkey").String(),
-						Address:       utxoJS.Get("address").String(),
-						Confirmations: utxoJS.Get("confirmations").Int(),
-					}
-					utxos = append(utxos, utxo)
-				}
-			}
-		}
+		utxos := utxosFromJS(args[0])
 
-		log.Printf("💰 Got %d UTXOs from API for transaction", len(utxos))
+		logDebugf("💰 Got %d UTXOs from API for transaction", len(utxos))
 
 		// If no UTXOs from API, use mock ones
 		if len(utxos) == 0 {
-			log.Printf("⚠️ No UTXOs from API, using mock UTXOs")
+			logWarnf("⚠️ No UTXOs from API, using mock UTXOs")
 			utxos = createMockUTXOs(currentWallet.Address)
 		}
 
-		// Select UTXOs using greedy algorithm
-		totalNeeded := amount + fee
-		selectedUTXOs, totalSelected, err := selectUTXOs(utxos, totalNeeded)
+		built, err := buildPaymentTransaction(params, utxos, time.Now())
 		if err != nil {
 			return map[string]interface{}{
 				"error": err.Error(),
 			}
 		}
 
-		log.Printf("💰 Selected %d UTXOs totaling %d satoshis", len(selectedUTXOs), totalSelected)
-
-		// Create transaction inputs
-		var inputs []TransactionInput
-		for _, utxo := range selectedUTXOs {
-			inputs = append(inputs, TransactionInput{
-				TxID:      utxo.TxID,
-				Vout:      utxo.Vout,
-				ScriptSig: "", // Will be filled during signing
-				Sequence:  0xffffffff,
-			})
-		}
-
-		// Create transaction outputs
-		var outputs []TransactionOutput
-
-		// Main output to destination
-		outputs = append(outputs, TransactionOutput{
-			Value:        amount,
-			ScriptPubkey: fmt.Sprintf("OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG", destination[1:41]),
-			Address:      destination,
-		})
-
-		// Change output (if any)
-		if totalSelected > totalNeeded {
-			change := totalSelected - totalNeeded
-			outputs = append(outputs, TransactionOutput{
-				Value:        change,
-				ScriptPubkey: fmt.Sprintf("OP_DUP OP_HASH160 %s OP_EQUALVERIFY OP_CHECKSIG", fromAddress[1:41]),
-				Address:      fromAddress,
-			})
-		}
-
-		// Create transaction
-		tx := Transaction{
-			Version:   1,
-			Inputs:    inputs,
-			Outputs:   outputs,
-			Locktime:  0,
-			Timestamp: time.Now().UTC().Format(time.RFC3339),
-		}
-
-		// Serialize transaction for signing
-		txBytes, err := json.Marshal(tx)
+		result, err := signAndPackage(built.Tx)
 		if err != nil {
 			return map[string]interface{}{
-				"error": "Failed to serialize transaction",
+				"error": err.Error(),
 			}
 		}
+		result["fee"] = built.Fee
+		if built.Fee > built.RequestedFee {
+			result["dust_absorbed"] = built.Fee - built.RequestedFee
+		}
 
-		// Create transaction hash
-		hasher := sha256.New()
-		hasher.Write(txBytes)
-		txHash := hex.EncodeToString(hasher.Sum(nil))
+		return result
+	}))
+}
 
-		// Sign with ML-DSA-87
-		seed, err := base64.StdEncoding.DecodeString(currentWallet.Seed)
-		if err != nil {
-			return map[string]interface{}{
-				"error": "Failed to decode wallet seed",
-			}
-		}
+// signAndPackage signs tx with the current wallet and returns the signed
+// transaction in the format expected by the node
+func signAndPackage(tx Transaction) (map[string]interface{}, error) {
+	// Serialize transaction for signing
+	txBytes, err := json.Marshal(tx)
+	if err != nil {
+		return nil, errors.New("Failed to serialize transaction")
+	}
 
-		_, privateKey, err := mldsa87.GenerateKey(bytes.NewReader(seed))
-		if err != nil {
-			return map[string]interface{}{
-				"error": "Failed to regenerate private key",
-			}
-		}
+	// Create transaction hash
+	hasher := sha256.New()
+	hasher.Write(txBytes)
+	txHash := hex.EncodeToString(hasher.Sum(nil))
 
-		signature := make([]byte, mldsa87.SignatureSize) // 4627 bytes
-		err = mldsa87.SignTo(privateKey, txBytes, nil, false, signature)
-		if err != nil {
-			return map[string]interface{}{
-				"error": "Failed to sign transaction",
-			}
-		}
-		signatureBase64 := base64.StdEncoding.EncodeToString(signature)
-
-		log.Printf("✅ Transaction signed successfully")
-		log.Printf("📋 Signature length: %d bytes", len(signature))
-		log.Printf("📋 Transaction hash: %s", txHash)
-
-		// Create the signed transaction in the format expected by the node
-		signedTx := map[string]interface{}{
-			"transaction": string(txBytes),
-			"signature":   signatureBase64,
-			"tx_hash":     txHash,
-			"signer_key":  currentWallet.PublicKey,
-			"algorithm":   "ML-DSA-87",
-			"header": map[string]interface{}{
-				"alg": "ML-DSA-87",
-				"typ": "JWT",
-			},
-		}
+	// Sign with ML-DSA-87 using a copy of the seed, which signWithSeed wipes
+	seed := append([]byte(nil), currentWallet.seed...)
+	signature, err := signWithSeed(seed, txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to sign transaction: %v", err)
+	}
+	signatureBase64 := base64.StdEncoding.EncodeToString(signature)
+
+	logInfof("✅ Transaction signed successfully")
+	logDebugf("📋 Signature length: %d bytes", len(signature))
+	logInfof("📋 Transaction hash: %s", txHash)
+
+	// Create the signed transaction in the format expected by the node
+	signedTx := map[string]interface{}{
+		"transaction": string(txBytes),
+		"signature":   signatureBase64,
+		"tx_hash":     txHash,
+		"signer_key":  currentWallet.PublicKey,
+		"algorithm":   "ML-DSA-87",
+		"header": map[string]interface{}{
+			"alg": "ML-DSA-87",
+			"typ": "JWT",
+		},
+	}
 
-		// Serialize complete signed transaction
-		signedTxBytes, err := json.Marshal(signedTx)
-		if err != nil {
-			return map[string]interface{}{
-				"error": "Failed to serialize signed transaction",
-			}
-		}
+	// Serialize complete signed transaction
+	signedTxBytes, err := json.Marshal(signedTx)
+	if err != nil {
+		return nil, errors.New("Failed to serialize signed transaction")
+	}
 
-		log.Printf("📦 Complete transaction size: %d bytes", len(signedTxBytes))
+	logDebugf("📦 Complete transaction size: %d bytes", len(signedTxBytes))
 
-		return map[string]interface{}{
-			"txid":               txHash,
-			"raw_tx":             hex.EncodeToString(txBytes),
-			"signature":          signatureBase64,
-			"signer_key":         currentWallet.PublicKey,
-			"algorithm":          "ML-DSA-87",
-			"signed_transaction": signedTx,
-		}
-	}))
+	return map[string]interface{}{
+		"txid":               txHash,
+		"raw_tx":             hex.EncodeToString(txBytes),
+		"signature":          signatureBase64,
+		"signer_key":         currentWallet.PublicKey,
+		"algorithm":          "ML-DSA-87",
+		"signed_transaction": signedTx,
+	}, nil
 }
 
 // Broadcast transaction to network
@@ -1001,12 +961,12 @@ func broadcastTransaction(this js.Value, args []js.Value) interface{} {
 	// Get the signed transaction data directly from signTransaction return (outside Promise wrapper)
 	signedTxData := args[0]
 
-	log.Printf("📡 Broadcasting transaction data type: %s", signedTxData.Type().String())
+	logDebugf("📡 Broadcasting transaction data type: %s", signedTxData.Type().String())
 
 	// Extract the signed_transaction field which contains the node-formatted data
 	signedTxObj := signedTxData.Get("signed_transaction")
 
-	log.Printf("📡 signed_transaction field type: %s, isNull: %v", signedTxObj.Type().String(), signedTxObj.IsNull())
+	logDebugf("📡 signed_transaction field type: %s, isNull: %v", signedTxObj.Type().String(), signedTxObj.IsNull())
 
 	return createResolvedPromise(nil).Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 
@@ -1041,8 +1001,8 @@ func broadcastTransaction(this js.Value, args []js.Value) interface{} {
 			}
 		}
 
-		log.Printf("📡 Broadcasting transaction to mempool...")
-		log.Printf("📦 Payload size: %d bytes", len(payload))
+		logInfof("📡 Broadcasting transaction to mempool...")
+		logDebugf("📦 Payload size: %d bytes", len(payload))
 
 		// Make HTTP request to broadcast transaction
 		endpoint := "/api/v1/mempool/transactions"
@@ -1053,8 +1013,8 @@ func broadcastTransaction(this js.Value, args []js.Value) interface{} {
 			statusCode := result.Get("status_code").Int()
 			body := result.Get("body").String()
 
-			log.Printf("📡 Broadcast response: HTTP %d", statusCode)
-			log.Printf("📄 Response body: %s", body)
+			logInfof("📡 Broadcast response: HTTP %d", statusCode)
+			logDebugf("📄 Response body: %s", body)
 
 			if statusCode == 200 || statusCode == 201 || statusCode == 202 {
 				var result map[string]interface{}
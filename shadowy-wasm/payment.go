@@ -0,0 +1,232 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall/js"
+	"time"
+)
+
+// paymentParams is a payment request as accepted by
+// shadowy_build_transaction and shadowy_sign_transaction
+type paymentParams struct {
+	Destination string
+	FromAddress string
+	Amount      uint64
+	Fee         uint64
+	Overrides   txOverrides
+}
+
+// parsePaymentParams reads either the inputs/outputs format or the legacy
+// destination/amount/fee format and validates both addresses
+func parsePaymentParams(txData js.Value) (paymentParams, error) {
+	var params paymentParams
+
+	// Check if this is the new transaction format with inputs/outputs
+	if !txData.Get("inputs").IsUndefined() {
+		logInfof("🔐 Received transaction with inputs/outputs format")
+
+		// Extract destination from first output
+		outputs := txData.Get("outputs")
+		if outputs.Length() > 0 {
+			firstOutput := outputs.Index(0)
+			params.Destination = firstOutput.Get("address").String()
+			params.Amount = uint64(firstOutput.Get("value").Float())
+		}
+
+		// Extract source from transaction or use current wallet
+		if currentWallet != nil {
+			params.FromAddress = currentWallet.Address
+		}
+
+		// Use default fee
+		params.Fee = 100000 // 0.001 SHADOW
+
+	} else {
+		// Legacy format
+		params.Destination = txData.Get("destination").String()
+		if !txData.Get("amount").IsUndefined() {
+			params.Amount = uint64(txData.Get("amount").Float())
+		}
+		if !txData.Get("fee").IsUndefined() {
+			params.Fee = uint64(txData.Get("fee").Float())
+		}
+		params.FromAddress = txData.Get("from_address").String()
+	}
+
+	// Validate addresses
+	if len(params.Destination) != 51 || !strings.HasPrefix(params.Destination, "S") {
+		return params, fmt.Errorf("Invalid destination address format: %s (expected 51 chars starting with S)", params.Destination)
+	}
+
+	if len(params.FromAddress) != 51 || !strings.HasPrefix(params.FromAddress, "S") {
+		return params, fmt.Errorf("Invalid from address format: %s (expected 51 chars starting with S)", params.FromAddress)
+	}
+
+	// Optional locktime and sequence numbers for timelocks and RBF
+	overrides, err := parseTxOverrides(txData)
+	if err != nil {
+		return params, err
+	}
+	params.Overrides = overrides
+
+	return params, nil
+}
+
+// utxosFromJS converts the array returned by shadowy_get_utxos
+func utxosFromJS(utxosValue js.Value) []UTXO {
+	var utxos []UTXO
+	if !js.Global().Get("Array").Call("isArray", utxosValue).Bool() {
+		return utxos
+	}
+	for i := 0; i < utxosValue.Length(); i++ {
+		utxoJS := utxosValue.Index(i)
+		utxo := UTXO{
+			TxID:          utxoJS.Get("txid").String(),
+			Vout:          uint32(utxoJS.Get("vout").Int()),
+			Value:         uint64(utxoJS.Get("value").Float()),
+			ScriptPubkey:  utxoJS.Get("script_pubkey").String(),
+			Address:       utxoJS.Get("address").String(),
+			Confirmations: utxoJS.Get("confirmations").Int(),
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos
+}
+
+// builtPayment is an unsigned payment ready for review or signing
+type builtPayment struct {
+	Tx            Transaction
+	Fee           uint64 // Fee actually paid, including absorbed dust
+	RequestedFee  uint64
+	TotalSelected uint64
+}
+
+// buildPaymentTransaction selects UTXOs and assembles the unsigned
+// transaction exactly as it will be signed
+func buildPaymentTransaction(params paymentParams, utxos []UTXO, timestamp time.Time) (*builtPayment, error) {
+	if len(utxos) == 0 {
+		return nil, fmt.Errorf("insufficient funds: no UTXOs for %s", params.FromAddress)
+	}
+
+	// Select UTXOs using greedy algorithm
+	totalNeeded := params.Amount + params.Fee
+	selectedUTXOs, totalSelected, err := selectUTXOs(utxos, totalNeeded)
+	if err != nil {
+		return nil, err
+	}
+
+	logDebugf("💰 Selected %d UTXOs totaling %d satoshis", len(selectedUTXOs), totalSelected)
+
+	// Payment plus change, with dust change paid as fee
+	outputs, paidFee := buildPaymentOutputs(params.Destination, params.FromAddress, params.Amount, params.Fee, totalSelected)
+
+	return &builtPayment{
+		Tx: Transaction{
+			Version:   1,
+			Inputs:    buildInputs(selectedUTXOs, params.Overrides),
+			Outputs:   outputs,
+			Locktime:  params.Overrides.Locktime,
+			Timestamp: timestamp.UTC().Format(time.RFC3339),
+		},
+		Fee:           paidFee,
+		RequestedFee:  params.Fee,
+		TotalSelected: totalSelected,
+	}, nil
+}
+
+// toMap describes the unsigned transaction for JavaScript. tx_hash is the
+// hash the signature will cover; unsigned_transaction can be passed back
+// to shadowy_sign_transaction to sign exactly this transaction.
+func (b *builtPayment) toMap() (map[string]interface{}, error) {
+	txBytes, err := json.Marshal(b.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to serialize transaction")
+	}
+	hash := sha256.Sum256(txBytes)
+
+	var txObject map[string]interface{}
+	if err := json.Unmarshal(txBytes, &txObject); err != nil {
+		return nil, fmt.Errorf("Failed to serialize transaction")
+	}
+
+	result := map[string]interface{}{
+		"transaction":          txObject,
+		"unsigned_transaction": string(txBytes),
+		"tx_hash":              hex.EncodeToString(hash[:]),
+		"fee":                  b.Fee,
+		"total_input":          b.TotalSelected,
+	}
+	if b.Fee > b.RequestedFee {
+		result["dust_absorbed"] = b.Fee - b.RequestedFee
+	}
+	return result, nil
+}
+
+// parsePrebuilt reads a shadowy_build_transaction result back into the
+// transaction it describes
+func parsePrebuilt(txData js.Value) (Transaction, uint64, error) {
+	var tx Transaction
+	if err := json.Unmarshal([]byte(txData.Get("unsigned_transaction").String()), &tx); err != nil {
+		return tx, 0, fmt.Errorf("Invalid unsigned_transaction: %v", err)
+	}
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return tx, 0, fmt.Errorf("Invalid unsigned_transaction: needs inputs and outputs")
+	}
+
+	var fee uint64
+	if value := txData.Get("fee"); value.Type() == js.TypeNumber {
+		fee = uint64(value.Float())
+	}
+	return tx, fee, nil
+}
+
+// buildTransaction selects UTXOs and returns the unsigned transaction
+// without signing it, so a UI can show exactly what will be signed
+func buildTransaction(this js.Value, args []js.Value) interface{} {
+	if currentWallet == nil {
+		return createResolvedPromise(map[string]interface{}{
+			"error": "No wallet loaded",
+		})
+	}
+
+	if len(args) < 1 {
+		return createResolvedPromise(map[string]interface{}{
+			"error": "Transaction data required",
+		})
+	}
+
+	params, err := parsePaymentParams(args[0])
+	if err != nil {
+		return createResolvedPromise(map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// A dry run shows what would really be signed, so it never falls back
+	// to mock UTXOs
+	return createResolvedPromise(fetchUTXOs(false)).Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		built, err := buildPaymentTransaction(params, utxosFromJS(args[0]), time.Now())
+		if err != nil {
+			return map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+
+		result, err := built.toMap()
+		if err != nil {
+			return map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+		return result
+	}), js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return args[0]
+	}))
+}
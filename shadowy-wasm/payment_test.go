@@ -0,0 +1,131 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"strings"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+func testPaymentUTXOs(address string) []UTXO {
+	return []UTXO{
+		{TxID: "small", Vout: 0, Value: 50000, Address: address},
+		{TxID: "large", Vout: 1, Value: 300000000, Address: address},
+	}
+}
+
+func TestBuiltTransactionIsWhatGetsSigned(t *testing.T) {
+	_, sender := signTestTransaction(t) // loads a wallet to sign with
+
+	params, err := parsePaymentParams(js.ValueOf(map[string]interface{}{
+		"destination":  testRecipient,
+		"from_address": sender,
+		"amount":       100000000,
+		"fee":          100000,
+		"locktime":     42,
+	}))
+	if err != nil {
+		t.Fatalf("parsePaymentParams failed: %v", err)
+	}
+
+	built, err := buildPaymentTransaction(params, testPaymentUTXOs(sender), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("buildPaymentTransaction failed: %v", err)
+	}
+	if len(built.Tx.Inputs) != 1 || built.Tx.Inputs[0].TxID != "large" {
+		t.Errorf("Expected the large UTXO selected, got %+v", built.Tx.Inputs)
+	}
+	if len(built.Tx.Outputs) != 2 || built.Tx.Outputs[1].Value != 300000000-100000000-100000 {
+		t.Errorf("Expected payment and change outputs, got %+v", built.Tx.Outputs)
+	}
+	if built.Tx.Locktime != 42 || built.Fee != 100000 {
+		t.Errorf("Unexpected locktime %d or fee %d", built.Tx.Locktime, built.Fee)
+	}
+
+	preview, err := built.toMap()
+	if err != nil {
+		t.Fatalf("toMap failed: %v", err)
+	}
+
+	// Hand the preview back as JavaScript would and sign it
+	tx, fee, err := parsePrebuilt(js.ValueOf(preview))
+	if err != nil {
+		t.Fatalf("parsePrebuilt failed: %v", err)
+	}
+	if fee != 100000 {
+		t.Errorf("Expected the built fee to carry over, got %d", fee)
+	}
+	signed, err := signAndPackage(tx)
+	if err != nil {
+		t.Fatalf("Signing failed: %v", err)
+	}
+
+	if signed["txid"] != preview["tx_hash"] {
+		t.Errorf("Signed hash %v differs from previewed hash %v", signed["txid"], preview["tx_hash"])
+	}
+	body := signed["signed_transaction"].(map[string]interface{})["transaction"]
+	if body != preview["unsigned_transaction"] {
+		t.Errorf("Signed body differs from the preview:\n%v\n%v", body, preview["unsigned_transaction"])
+	}
+
+	// Signing the built transaction directly gives the same hash
+	direct, err := signAndPackage(built.Tx)
+	if err != nil {
+		t.Fatalf("Signing failed: %v", err)
+	}
+	if direct["txid"] != preview["tx_hash"] {
+		t.Errorf("Direct signing hash %v differs from preview %v", direct["txid"], preview["tx_hash"])
+	}
+}
+
+func TestBuildReportsAbsorbedDust(t *testing.T) {
+	_, sender := signTestTransaction(t)
+	params := paymentParams{Destination: testRecipient, FromAddress: sender, Amount: 249900, Fee: 100000, Overrides: defaultTxOverrides()}
+
+	built, err := buildPaymentTransaction(params, []UTXO{{TxID: "only", Value: 350000}}, time.Now())
+	if err != nil {
+		t.Fatalf("buildPaymentTransaction failed: %v", err)
+	}
+	preview, err := built.toMap()
+	if err != nil {
+		t.Fatalf("toMap failed: %v", err)
+	}
+	if preview["fee"] != uint64(100100) || preview["dust_absorbed"] != uint64(100) {
+		t.Errorf("Expected 100 satoshis of dust in the fee, got fee %v dust %v", preview["fee"], preview["dust_absorbed"])
+	}
+}
+
+func TestBuildWithoutUTXOsFails(t *testing.T) {
+	params := paymentParams{Destination: testRecipient, FromAddress: "S-sender", Amount: 100000, Fee: 1000, Overrides: defaultTxOverrides()}
+
+	_, err := buildPaymentTransaction(params, nil, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "insufficient funds: no UTXOs") {
+		t.Errorf("Expected an insufficient funds error without UTXOs, got %v", err)
+	}
+}
+
+func TestParsePrebuiltRejectsEmptyTransaction(t *testing.T) {
+	if _, _, err := parsePrebuilt(js.ValueOf(map[string]interface{}{"unsigned_transaction": `{"version":1}`})); err == nil {
+		t.Error("Expected a transaction without inputs to be rejected")
+	}
+	if _, _, err := parsePrebuilt(js.ValueOf(map[string]interface{}{"unsigned_transaction": "not json"})); err == nil {
+		t.Error("Expected malformed JSON to be rejected")
+	}
+}
+
+func TestUTXOsFromJSIgnoresNonArrays(t *testing.T) {
+	utxos := utxosFromJS(js.ValueOf([]interface{}{
+		map[string]interface{}{"txid": "abc", "vout": 1, "value": 5000, "address": "S42", "confirmations": 3},
+	}))
+	if len(utxos) != 1 || utxos[0].TxID != "abc" || utxos[0].Value != 5000 {
+		t.Errorf("Expected one UTXO from the array, got %+v", utxos)
+	}
+	for _, value := range []interface{}{map[string]interface{}{"txid": "abc"}, nil, "abc"} {
+		if utxos := utxosFromJS(js.ValueOf(value)); len(utxos) != 0 {
+			t.Errorf("Expected no UTXOs from %v, got %+v", value, utxos)
+		}
+	}
+}
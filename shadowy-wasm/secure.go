@@ -0,0 +1,64 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+// zero overwrites b with zeros so key material does not linger in the
+// long-lived WASM heap after use
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
+
+// zeroPrivateKey wipes an expanded ML-DSA-87 private key in place
+func zeroPrivateKey(key *mldsa87.PrivateKey) {
+	if key == nil {
+		return
+	}
+	*key = mldsa87.PrivateKey{}
+	runtime.KeepAlive(key)
+}
+
+// signWithSeed derives the ML-DSA-87 key from seed and signs msg. Both the
+// seed and the derived key are wiped before returning, so callers must pass
+// a copy if they need to keep the seed.
+func signWithSeed(seed, msg []byte) ([]byte, error) {
+	defer zero(seed)
+
+	if len(seed) == 0 {
+		return nil, errors.New("wallet has no signing key")
+	}
+
+	_, privateKey, err := mldsa87.GenerateKey(bytes.NewReader(seed))
+	if err != nil {
+		return nil, err
+	}
+	defer zeroPrivateKey(privateKey)
+
+	signature := make([]byte, mldsa87.SignatureSize) // 4627 bytes
+	if err := mldsa87.SignTo(privateKey, msg, nil, false, signature); err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// setCurrentWallet makes wallet the active wallet, wiping the seed of the
+// wallet it replaces
+func setCurrentWallet(wallet *WalletV3) {
+	if currentWallet != nil && currentWallet != wallet {
+		zero(currentWallet.seed)
+		currentWallet.seed = nil
+	}
+	currentWallet = wallet
+}
@@ -0,0 +1,115 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func testSeed() []byte {
+	seed := make([]byte, 64)
+	for i := range seed {
+		seed[i] = byte(i + 1)
+	}
+	return seed
+}
+
+func TestZero(t *testing.T) {
+	b := []byte{1, 2, 3, 4}
+	zero(b)
+	if !isZero(b) {
+		t.Errorf("Expected zeroed buffer, got %v", b)
+	}
+
+	// Nil and empty slices must be safe
+	zero(nil)
+	zero([]byte{})
+}
+
+func TestSignWithSeedWipesSeed(t *testing.T) {
+	publicKey, _, err := mldsa87.GenerateKey(bytes.NewReader(testSeed()))
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+
+	seed := testSeed()
+	msg := []byte("shadowy test transaction")
+	signature, err := signWithSeed(seed, msg)
+	if err != nil {
+		t.Fatalf("Signing failed: %v", err)
+	}
+
+	if !isZero(seed) {
+		t.Error("Seed should be zeroed after signing")
+	}
+	if !mldsa87.Verify(publicKey, msg, nil, signature) {
+		t.Error("Signature should verify against the seed's public key")
+	}
+}
+
+func TestSignWithSeedWipesSeedOnError(t *testing.T) {
+	// Too short for key generation, so signing fails after reading it
+	seed := []byte{9, 9, 9}
+	if _, err := signWithSeed(seed, []byte("msg")); err == nil {
+		t.Fatal("Expected error for short seed")
+	}
+	if !isZero(seed) {
+		t.Error("Seed should be zeroed even when signing fails")
+	}
+}
+
+func TestZeroPrivateKey(t *testing.T) {
+	_, privateKey, err := mldsa87.GenerateKey(bytes.NewReader(testSeed()))
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+
+	zeroPrivateKey(privateKey)
+	if !reflect.DeepEqual(*privateKey, mldsa87.PrivateKey{}) {
+		t.Error("Private key should be zeroed")
+	}
+
+	zeroPrivateKey(nil)
+}
+
+func TestSetCurrentWalletWipesPreviousSeed(t *testing.T) {
+	defer setCurrentWallet(nil)
+
+	first := &WalletV3{Name: "first", seed: testSeed()}
+	firstSeed := first.seed
+	setCurrentWallet(first)
+
+	second := &WalletV3{Name: "second", seed: testSeed()}
+	setCurrentWallet(second)
+
+	if !isZero(firstSeed) {
+		t.Error("Replaced wallet's seed should be zeroed")
+	}
+	if first.seed != nil {
+		t.Error("Replaced wallet should drop its seed")
+	}
+	if isZero(second.seed) {
+		t.Error("Active wallet's seed should be left intact")
+	}
+
+	// Unloading wipes the active wallet too
+	secondSeed := second.seed
+	setCurrentWallet(nil)
+	if !isZero(secondSeed) || currentWallet != nil {
+		t.Error("Unloading should wipe the active wallet's seed")
+	}
+}
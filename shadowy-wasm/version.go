@@ -0,0 +1,133 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall/js"
+)
+
+// Node API major versions this client knows how to parse
+const (
+	MinSupportedAPIMajor = 0
+	MaxSupportedAPIMajor = 1
+)
+
+// APIVersionCheck is the outcome of comparing the node's reported version
+// against the range this client supports
+type APIVersionCheck struct {
+	Detected  string // Version string reported by the node
+	Major     int    // Parsed major version, -1 if unknown
+	Supported bool   // False only when the major version is known to be out of range
+	Status    string // "supported", "unsupported" or "unknown"
+	Warning   string
+}
+
+// nodeAPIVersion holds the result of the last negotiation with the node
+var nodeAPIVersion = APIVersionCheck{Major: -1, Supported: true, Status: "unknown"}
+
+// toMap converts the check into a value that can be handed to JavaScript
+func (c APIVersionCheck) toMap() map[string]interface{} {
+	result := map[string]interface{}{
+		"detected_version":    c.Detected,
+		"major":               c.Major,
+		"supported":           c.Supported,
+		"status":              c.Status,
+		"min_supported_major": MinSupportedAPIMajor,
+		"max_supported_major": MaxSupportedAPIMajor,
+		"client_version":      WasmVersion,
+	}
+	if c.Warning != "" {
+		result["warning"] = c.Warning
+	}
+	return result
+}
+
+// checkAPIVersion parses a node version response body. An explicit
+// api_version field wins over the software version.
+func checkAPIVersion(body []byte) APIVersionCheck {
+	var info struct {
+		APIVersion string `json:"api_version"`
+		Version    string `json:"version"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return unknownAPIVersion("", "could not parse node version response")
+	}
+
+	detected := info.APIVersion
+	if detected == "" {
+		detected = info.Version
+	}
+
+	major, ok := parseMajorVersion(detected)
+	if !ok {
+		return unknownAPIVersion(detected, fmt.Sprintf("could not determine node API version from %q", detected))
+	}
+
+	if major < MinSupportedAPIMajor || major > MaxSupportedAPIMajor {
+		return APIVersionCheck{
+			Detected:  detected,
+			Major:     major,
+			Supported: false,
+			Status:    "unsupported",
+			Warning: fmt.Sprintf("node API version %s is outside the supported range %d.x-%d.x; upgrade the Shadowy WASM client",
+				detected, MinSupportedAPIMajor, MaxSupportedAPIMajor),
+		}
+	}
+
+	return APIVersionCheck{Detected: detected, Major: major, Supported: true, Status: "supported"}
+}
+
+// unknownAPIVersion reports a version that could not be determined. It is
+// not treated as unsupported, since development nodes report "dev".
+func unknownAPIVersion(detected, warning string) APIVersionCheck {
+	return APIVersionCheck{Detected: detected, Major: -1, Supported: true, Status: "unknown", Warning: warning}
+}
+
+// parseMajorVersion extracts the major number from strings like "1.2.3" or "v2"
+func parseMajorVersion(version string) (int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, ".-+"); i >= 0 {
+		version = version[:i]
+	}
+	major, err := strconv.Atoi(version)
+	if err != nil || major < 0 {
+		return 0, false
+	}
+	return major, true
+}
+
+// negotiateAPIVersion asks the node for its version, stores the result and
+// returns a Promise resolving to the structured check
+func negotiateAPIVersion() js.Value {
+	return createResolvedPromise(makeHTTPRequest("GET", "/api/v1/version", "")).Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		response := args[0]
+
+		var check APIVersionCheck
+		if !response.Get("error").IsUndefined() {
+			check = unknownAPIVersion("", fmt.Sprintf("version request failed: %s", response.Get("error").String()))
+		} else if result := response.Get("result"); result.IsUndefined() || result.Get("status_code").Int() != 200 {
+			check = unknownAPIVersion("", "node did not report a version")
+		} else {
+			check = checkAPIVersion([]byte(result.Get("body").String()))
+		}
+
+		nodeAPIVersion = check
+		if check.Warning != "" {
+			logWarnf("⚠️ %s", check.Warning)
+		} else {
+			logInfof("✅ Node API version %s supported", check.Detected)
+		}
+
+		return check.toMap()
+	}))
+}
+
+// Get the node API version detected by the last createClient call
+func getAPIVersion(this js.Value, args []js.Value) interface{} {
+	return nodeAPIVersion.toMap()
+}
@@ -0,0 +1,50 @@
+//go:build wasm
+// +build wasm
+
+package main
+
+import "testing"
+
+func TestCheckAPIVersionSupported(t *testing.T) {
+	check := checkAPIVersion([]byte(`{"version": "1.2.3", "build_number": 42}`))
+	if !check.Supported || check.Status != "supported" {
+		t.Fatalf("Expected 1.2.3 to be supported, got %+v", check)
+	}
+	if check.Major != 1 || check.Detected != "1.2.3" {
+		t.Errorf("Unexpected parsed version: %+v", check)
+	}
+	if check.Warning != "" {
+		t.Errorf("Supported version should not warn, got %q", check.Warning)
+	}
+}
+
+func TestCheckAPIVersionUnsupported(t *testing.T) {
+	check := checkAPIVersion([]byte(`{"version": "v3.0.0"}`))
+	if check.Supported || check.Status != "unsupported" {
+		t.Fatalf("Expected v3.0.0 to be unsupported, got %+v", check)
+	}
+	if check.Major != 3 {
+		t.Errorf("Expected major 3, got %d", check.Major)
+	}
+	if check.Warning == "" {
+		t.Error("Unsupported version should carry an upgrade warning")
+	}
+
+	// An explicit api_version takes precedence over the software version
+	check = checkAPIVersion([]byte(`{"version": "1.0.0", "api_version": "2"}`))
+	if check.Supported || check.Major != 2 {
+		t.Errorf("Expected api_version 2 to be unsupported, got %+v", check)
+	}
+}
+
+func TestCheckAPIVersionUnknown(t *testing.T) {
+	for _, body := range []string{`{"version": "dev"}`, `{}`, `not json`} {
+		check := checkAPIVersion([]byte(body))
+		if check.Status != "unknown" || !check.Supported || check.Major != -1 {
+			t.Errorf("Expected unknown but usable version for %s, got %+v", body, check)
+		}
+		if check.Warning == "" {
+			t.Errorf("Unknown version should warn for %s", body)
+		}
+	}
+}